@@ -1,4 +1,6 @@
 // Package devbox creates and configures Devbox development environments.
+//
+// Package go.jetify.com/devbox/pkg/devbox has the full Go API of Devbox.
 package devbox
 
 import (
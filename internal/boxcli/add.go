@@ -4,7 +4,10 @@
 package boxcli
 
 import (
+	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
@@ -12,6 +15,7 @@
 	"go.jetify.com/devbox/internal/boxcli/usererr"
 	"go.jetify.com/devbox/internal/devbox"
 	"go.jetify.com/devbox/internal/devbox/devopt"
+	"go.jetify.com/devbox/internal/lock"
 	"go.jetify.com/devbox/internal/nix"
 )
 
@@ -20,22 +24,31 @@
 type addCmdFlags struct {
 	config           configFlags
 	allowInsecure    []string
+	insecureExpires  string
 	disablePlugin    bool
 	platforms        []string
 	excludePlatforms []string
 	patchGlibc       bool
 	patch            string
 	outputs          []string
+	override         []string
+	overlay          string
+	fromToolchain    bool
+	explain          bool
 }
 
 func addCmd() *cobra.Command {
 	flags := addCmdFlags{}
 
 	command := &cobra.Command{
-		Use:     "add <pkg>...",
-		Short:   "Add a new package to your devbox",
-		PreRunE: ensureNixInstalled,
+		Use:               "add <pkg>...",
+		Short:             "Add a new package to your devbox",
+		PreRunE:           ensureNixInstalled,
+		ValidArgsFunction: completePackages,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if flags.fromToolchain {
+				return addToolchainCmdFunc(cmd, args, flags)
+			}
 			if len(args) == 0 {
 				fmt.Fprintf(
 					cmd.ErrOrStderr(),
@@ -57,6 +70,9 @@ func addCmd() *cobra.Command {
 	command.Flags().StringSliceVar(
 		&flags.allowInsecure, "allow-insecure", []string{},
 		"allow adding packages marked as insecure.")
+	command.Flags().StringVar(
+		&flags.insecureExpires, "insecure-expires", "",
+		"date (YYYY-MM-DD) after which the --allow-insecure acceptance should be reviewed")
 	command.Flags().BoolVar(
 		&flags.disablePlugin, "disable-plugin", false,
 		"disable plugin (if any) for this package.")
@@ -75,9 +91,22 @@ func addCmd() *cobra.Command {
 	command.Flags().StringSliceVarP(
 		&flags.outputs, "outputs", "o", []string{},
 		"specify the outputs to select for the nix package")
+	command.Flags().StringArrayVar(
+		&flags.override, "override", []string{},
+		"override an argument of the nix package as name=value. Overridden packages are built from source.")
+	command.Flags().StringVar(
+		&flags.overlay, "overlay", "",
+		"path to a nix overlay file to apply to the package's nixpkgs")
+	command.Flags().BoolVar(
+		&flags.fromToolchain, "from-toolchain-file", false,
+		"add the toolchain from the project's toolchain file, such as rust-toolchain.toml")
+	command.Flags().BoolVar(
+		&flags.explain, "explain", false,
+		"print every org policy rule that the packages are checked against")
 
 	_ = command.Flags().MarkDeprecated("patch-glibc", `use --patch=always instead`)
 	command.MarkFlagsMutuallyExclusive("patch", "patch-glibc")
+	command.MarkFlagsRequiredTogether("insecure-expires", "allow-insecure")
 
 	return command
 }
@@ -91,18 +120,79 @@ func addCmdFunc(cmd *cobra.Command, args []string, flags addCmdFlags) error {
 	if err != nil {
 		return errors.WithStack(err)
 	}
+	opts, err := addOpts(flags)
+	if err != nil {
+		return err
+	}
+	return box.Add(cmd.Context(), args, opts)
+}
+
+// addToolchainCmdFunc adds a language toolchain from the project's toolchain
+// file. Only rust-toolchain.toml is supported.
+func addToolchainCmdFunc(cmd *cobra.Command, args []string, flags addCmdFlags) error {
+	if len(args) != 1 || args[0] != "rust" {
+		return usererr.New("--from-toolchain-file is only supported for rust. Run `devbox add rust --from-toolchain-file`.")
+	}
+	box, err := devbox.Open(&devopt.Opts{
+		Dir:         flags.config.path,
+		Environment: flags.config.environment,
+		Stderr:      cmd.ErrOrStderr(),
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	opts, err := addOpts(flags)
+	if err != nil {
+		return err
+	}
+	return box.AddRustToolchain(cmd.Context(), opts)
+}
+
+func addOpts(flags addCmdFlags) (devopt.AddOpts, error) {
+	override, err := parseOverrides(flags.override)
+	if err != nil {
+		return devopt.AddOpts{}, err
+	}
+	if flags.insecureExpires != "" {
+		if _, err := time.Parse(lock.InsecureDateFormat, flags.insecureExpires); err != nil {
+			return devopt.AddOpts{}, usererr.New("Invalid --insecure-expires date %q. Dates must be in the form YYYY-MM-DD.", flags.insecureExpires)
+		}
+	}
 
 	opts := devopt.AddOpts{
 		AllowInsecure:    flags.allowInsecure,
+		InsecureExpires:  flags.insecureExpires,
 		DisablePlugin:    flags.disablePlugin,
 		Platforms:        flags.platforms,
 		ExcludePlatforms: flags.excludePlatforms,
 		Patch:            flags.patch,
 		Outputs:          flags.outputs,
+		Override:         override,
+		Overlay:          flags.overlay,
+		Explain:          flags.explain,
 	}
 	if flags.patchGlibc {
 		// Backwards compatibility so --patch-glibc still works.
 		opts.Patch = "always"
 	}
-	return box.Add(cmd.Context(), args, opts)
+	return opts, nil
+}
+
+// parseOverrides parses name=value arguments into the arguments of a package's
+// override function. Values are parsed as JSON if possible so that, for
+// example, true is a bool, and are strings otherwise.
+func parseOverrides(args []string) (map[string]any, error) {
+	override := map[string]any{}
+	for _, arg := range args {
+		name, raw, ok := strings.Cut(arg, "=")
+		if !ok || name == "" {
+			return nil, usererr.New("Invalid override %q. Overrides must be in the form name=value.", arg)
+		}
+		var value any
+		if err := json.Unmarshal([]byte(raw), &value); err != nil {
+			value = raw
+		}
+		override[name] = value
+	}
+	return override, nil
 }
@@ -0,0 +1,111 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package boxcli
+
+import (
+	"path/filepath"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"go.jetify.com/devbox/internal/devbox"
+	"go.jetify.com/devbox/internal/devbox/devopt"
+	"go.jetify.com/devbox/internal/fileutil"
+	"go.jetify.com/devbox/internal/ux"
+)
+
+type bundleCmdFlags struct {
+	config configFlags
+	output string
+}
+
+func bundleCmd() *cobra.Command {
+	flags := bundleCmdFlags{}
+	command := &cobra.Command{
+		Use:   "bundle",
+		Short: "Export the project's environment to a single portable archive",
+		Long: heredoc.Doc(`
+			Export everything that the project's environment needs to a single
+			zstd-compressed tarball: the nix closure of its packages and shell
+			environment, the flake inputs that devbox evaluates, devbox.json,
+			devbox.lock and the cached shell environment.
+
+			Use "devbox unbundle" to import the archive on a machine of the same
+			system, such as an air-gapped machine without network access.
+		`),
+		Example: "  devbox bundle --output env.tar.zst",
+		Args:    cobra.NoArgs,
+		PreRunE: ensureNixInstalled,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			box, err := devbox.Open(&devopt.Opts{
+				Dir:         flags.config.path,
+				Environment: flags.config.environment,
+				Stderr:      cmd.ErrOrStderr(),
+			})
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			return box.Bundle(cmd.Context(), flags.output)
+		},
+	}
+
+	flags.config.register(command)
+	command.Flags().StringVarP(
+		&flags.output, "output", "o", "devbox-bundle.tar.zst", "path of the archive to write")
+	return command
+}
+
+type unbundleCmdFlags struct {
+	pathFlag
+	force bool
+}
+
+func unbundleCmd() *cobra.Command {
+	flags := unbundleCmdFlags{}
+	command := &cobra.Command{
+		Use:   "unbundle <archive>",
+		Short: "Import a project environment exported with devbox bundle",
+		Long: heredoc.Doc(`
+			Import an archive created by "devbox bundle" without using the
+			network. It copies the archive's store paths to the nix store, and
+			writes its devbox.json, devbox.lock and cached shell environment to
+			the project directory, keeping files that already exist unless
+			--force is set.
+
+			The archive's store paths aren't signed, so the current user must be
+			in trusted-users in nix.conf.
+		`),
+		Example: "  devbox unbundle env.tar.zst\n" +
+			"  devbox unbundle env.tar.zst --config ./my-project --force",
+		Args:    cobra.ExactArgs(1),
+		PreRunE: ensureNixInstalled,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return unbundleCmdFunc(cmd, args[0], flags)
+		},
+	}
+
+	flags.pathFlag.register(command)
+	command.Flags().BoolVarP(
+		&flags.force, "force", "f", false, "replace the project's devbox.json and devbox.lock if they exist")
+	return command
+}
+
+func unbundleCmdFunc(cmd *cobra.Command, archive string, flags unbundleCmdFlags) error {
+	projectDir := flags.path
+	if projectDir == "" {
+		projectDir = "."
+	} else if fileutil.IsFile(projectDir) {
+		projectDir = filepath.Dir(projectDir)
+	}
+
+	metadata, err := devbox.Unbundle(cmd.Context(), cmd.ErrOrStderr(), archive, projectDir, flags.force)
+	if err != nil {
+		return err
+	}
+	ux.Fsuccessf(cmd.ErrOrStderr(),
+		"Imported the bundle created by devbox %s on %s. Run \"devbox shell\" to use it.\n",
+		metadata.DevboxVersion, metadata.Created.Format("2006-01-02"))
+	return nil
+}
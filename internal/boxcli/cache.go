@@ -6,6 +6,7 @@
 import (
 	"encoding/json"
 	"fmt"
+	"os"
 	"os/user"
 	"slices"
 
@@ -17,6 +18,7 @@
 	"go.jetify.com/devbox/internal/devbox/devopt"
 	"go.jetify.com/devbox/internal/devbox/providers/identity"
 	"go.jetify.com/devbox/internal/devbox/providers/nixcache"
+	"go.jetify.com/devbox/internal/ux"
 	nixv1alpha1 "go.jetify.com/pkg/api/gen/priv/nix/v1alpha1"
 )
 
@@ -74,7 +76,10 @@ func cacheCmd() *cobra.Command {
 	cacheCommand.AddCommand(cacheConfigureCmd())
 	cacheCommand.AddCommand(cacheCredentialsCmd())
 	cacheCommand.AddCommand(cacheEnableCmd())
+	cacheCommand.AddCommand(cacheExportCmd())
+	cacheCommand.AddCommand(cacheImportCmd())
 	cacheCommand.AddCommand(cacheInfoCmd())
+	cacheCommand.AddCommand(cacheKeyCmd())
 
 	return cacheCommand
 }
@@ -219,3 +224,100 @@ func cacheInfoCmd() *cobra.Command {
 		},
 	}
 }
+
+func cacheKeyCmd() *cobra.Command {
+	flags := pathFlag{}
+	cmd := &cobra.Command{
+		Use:   "key",
+		Short: "Print a cache key for the project's packages",
+		Long: heredoc.Doc(`
+			Print a key that changes when devbox.lock changes and is different
+			on each system. Use it as the key of the directory given to
+			"devbox cache export" and "devbox cache import" in CI caches such
+			as actions/cache or GitLab's cache.
+		`),
+		Args: cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			box, err := devbox.Open(&devopt.Opts{
+				Dir:    flags.path,
+				Stderr: cmd.ErrOrStderr(),
+			})
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			key, err := box.CICacheKey()
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), key)
+			return nil
+		},
+	}
+	flags.register(cmd)
+	return cmd
+}
+
+func cacheExportCmd() *cobra.Command {
+	flags := pathFlag{}
+	cmd := &cobra.Command{
+		Use:   "export <dir>",
+		Short: "Export the project's store paths to a directory",
+		Long: heredoc.Doc(`
+			Install the project and copy the store paths it needs to a binary
+			cache in <dir>. Cache <dir> in CI using the key from
+			"devbox cache key", and restore it with "devbox cache import" to
+			avoid downloading or building the packages again.
+		`),
+		Example: "  devbox cache export .devbox-cache",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			box, err := devbox.Open(&devopt.Opts{
+				Dir:    flags.path,
+				Stderr: cmd.ErrOrStderr(),
+			})
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			if err := os.MkdirAll(args[0], 0o755); err != nil {
+				return errors.WithStack(err)
+			}
+			manifest, err := box.ExportCICache(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+			ux.Fsuccessf(cmd.ErrOrStderr(), "Exported the cache for key %s to %s\n", manifest.Key, args[0])
+			return nil
+		},
+	}
+	flags.register(cmd)
+	return cmd
+}
+
+func cacheImportCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "import <dir>",
+		Short: "Import store paths exported by devbox cache export",
+		Long: heredoc.Doc(`
+			Copy the store paths in a directory created by "devbox cache export"
+			to the nix store. If <dir> doesn't contain a cache, such as when the
+			CI cache missed, this does nothing.
+
+			Importing store paths requires the user to be in trusted-users in
+			nix.conf, because exported store paths aren't signed.
+		`),
+		Example: "  devbox cache import .devbox-cache",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			manifest, err := devbox.ImportCICache(cmd.Context(), cmd.ErrOrStderr(), args[0])
+			if err != nil {
+				return err
+			}
+			if manifest == nil {
+				ux.Finfof(cmd.ErrOrStderr(), "No cache to import in %s\n", args[0])
+				return nil
+			}
+			ux.Fsuccessf(cmd.ErrOrStderr(), "Imported the cache for key %s\n", manifest.Key)
+			return nil
+		},
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package boxcli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/spf13/cobra"
+
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+	"go.jetify.com/devbox/internal/devbox"
+	"go.jetify.com/devbox/internal/devbox/devopt"
+	"go.jetify.com/devbox/internal/envir"
+	"go.jetify.com/devbox/internal/nix"
+)
+
+type ciCmdFlags struct {
+	envFlag
+	config  configFlags
+	verbose bool
+}
+
+func ciCmd() *cobra.Command {
+	flags := ciCmdFlags{}
+	command := &cobra.Command{
+		Use:   "ci [-- <cmd> [<args>]]",
+		Short: "Check, fetch and set up the project in CI, and run a command in it",
+		Long: heredoc.Doc(`
+			Set up the project in a CI pipeline and run a command or script in
+			its environment. It's the same as running these commands, without
+			the boilerplate:
+
+			  devbox install --frozen-lockfile --locked
+			  devbox fetch
+			  devbox run -- <cmd>
+
+			It fails if devbox.json and devbox.lock disagree, and it never
+			changes either of them, so that CI doesn't silently resolve
+			packages again. It doesn't prompt, and it only prints nix's
+			warnings and errors unless --verbose is set.
+
+			On GitHub Actions, errors are also printed as workflow commands
+			so that they're annotated on the run. The exit code is the exit
+			code of the command.
+		`),
+		Example: "  devbox ci\n  devbox ci -- make test\n  devbox ci -- test",
+		PreRunE: ensureNixInstalled,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := runCICmd(cmd, args, flags)
+			if err != nil && os.Getenv("GITHUB_ACTIONS") == "true" {
+				fmt.Fprintln(cmd.OutOrStdout(), githubActionsError(ciErrorMessage(err, args)))
+			}
+			return err
+		},
+	}
+	flags.envFlag.register(command)
+	flags.config.register(command)
+	command.Flags().BoolVar(
+		&flags.verbose, "verbose", false, "print each build and download")
+	return command
+}
+
+func runCICmd(cmd *cobra.Command, args []string, flags ciCmdFlags) error {
+	// Anything that would change devbox.lock or devbox.json fails.
+	if err := os.Setenv(envir.DevboxLocked, "1"); err != nil {
+		return err
+	}
+	// CI runs the scripts of the project that it checked out, and there's
+	// nobody to ask whether to trust it.
+	if err := os.Setenv(envir.DevboxNoTrustPrompt, "1"); err != nil {
+		return err
+	}
+
+	env, err := flags.Env(flags.config.path)
+	if err != nil {
+		return err
+	}
+	box, err := devbox.Open(&devopt.Opts{
+		Dir:         flags.config.path,
+		Env:         env,
+		Environment: flags.config.environment,
+		Stderr:      cmd.ErrOrStderr(),
+	})
+	if err != nil {
+		return err
+	}
+	if err := box.CheckFrozenLockfile(); err != nil {
+		return err
+	}
+
+	ctx := cmd.Context()
+	if !flags.verbose {
+		ctx = nix.WithProgress(ctx, nix.ProgressQuiet, cmd.ErrOrStderr())
+	}
+	if err := box.Fetch(ctx, devopt.FetchOpts{}); err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return box.Install(ctx)
+	}
+	return box.RunScript(ctx, devopt.EnvOptions{}, args[0], args[1:])
+}
+
+// ciErrorMessage returns the message of an error of devbox ci for the CI
+// system.
+func ciErrorMessage(err error, args []string) string {
+	var exitErr *usererr.ExitError
+	if errors.As(err, &exitErr) && len(args) > 0 {
+		return fmt.Sprintf("%s exited with status %d", strings.Join(args, " "), exitErr.ExitCode())
+	}
+	if userErr, ok := usererr.Extract(err); ok {
+		return userErr.Error()
+	}
+	return err.Error()
+}
+
+// githubActionsError formats msg as a GitHub Actions error workflow command,
+// which annotates the workflow run with it.
+func githubActionsError(msg string) string {
+	msg = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(strings.TrimSpace(msg))
+	return "::error title=devbox ci::" + msg
+}
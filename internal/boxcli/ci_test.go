@@ -0,0 +1,31 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package boxcli
+
+import (
+	"errors"
+	"testing"
+
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+)
+
+func TestGithubActionsError(t *testing.T) {
+	got := githubActionsError("devbox.lock is out of date:\n  + go 1.22\n100% sure\n")
+	want := "::error title=devbox ci::devbox.lock is out of date:%0A  + go 1.22%0A100%25 sure"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestCIErrorMessage(t *testing.T) {
+	err := usererr.New("devbox.json and devbox.lock disagree")
+	if got, want := ciErrorMessage(err, nil), "devbox.json and devbox.lock disagree"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	err = errors.New("internal error")
+	if got, want := ciErrorMessage(err, []string{"make"}), "internal error"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
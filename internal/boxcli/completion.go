@@ -0,0 +1,108 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package boxcli
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"go.jetify.com/devbox/internal/devbox"
+	"go.jetify.com/devbox/internal/devbox/devopt"
+	"go.jetify.com/devbox/internal/searcher"
+)
+
+// completionSearchTimeout limits how long completing a package name waits
+// for the search service when the local index has no matches.
+const completionSearchTimeout = 2 * time.Second
+
+// minRemoteCompletionPrefix is the shortest prefix that completing a package
+// name sends to the search service. Shorter prefixes only use the local index.
+const minRemoteCompletionPrefix = 2
+
+// openForCompletion opens the project for a completion function. Completions
+// print to stdout, so it discards warnings instead of printing them.
+func openForCompletion(path string) (*devbox.Devbox, error) {
+	return devbox.Open(&devopt.Opts{
+		Dir:            path,
+		Stderr:         io.Discard,
+		IgnoreWarnings: true,
+	})
+}
+
+// completeScripts completes the first argument of devbox run with the names
+// of the scripts in devbox.json.
+func completeScripts(flags *configFlags) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			// Complete the arguments of the script or command as files.
+			return nil, cobra.ShellCompDirectiveDefault
+		}
+		box, err := openForCompletion(flags.path)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveDefault
+		}
+		return box.ListScripts(), cobra.ShellCompDirectiveDefault
+	}
+}
+
+// completeServices completes the names of the project's services that aren't
+// already arguments.
+func completeServices(flags *configFlags) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		box, err := openForCompletion(flags.path)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		svcs, err := box.Services()
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		names := []string{}
+		for name := range svcs {
+			if !slices.Contains(args, name) {
+				names = append(names, name)
+			}
+		}
+		slices.Sort(names)
+		return names, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completePackages completes package names for devbox add from the local
+// search index. When the index has no matches, it asks the search service and
+// adds the results to the index, so that later completions are local.
+func completePackages(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	index := searcher.LocalIndex()
+	entries, err := index.Complete(toComplete)
+	if err != nil {
+		slog.Debug("failed to read the local search index", "err", err)
+	}
+	if len(entries) == 0 && len(toComplete) >= minRemoteCompletionPrefix {
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		ctx, cancel := context.WithTimeout(ctx, completionSearchTimeout)
+		defer cancel()
+		// Search adds the results to the local index.
+		if _, err := searcher.Client().Search(ctx, toComplete); err != nil {
+			slog.Debug("failed to search for packages to complete", "err", err)
+		}
+		entries, _ = index.Complete(toComplete)
+	}
+
+	completions := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if slices.Contains(args, entry.Name) {
+			continue
+		}
+		completions = append(completions, cobra.CompletionWithDesc(entry.Name, entry.Summary))
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,84 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package boxcli
+
+import (
+	"fmt"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"go.jetify.com/devbox/internal/devbox"
+	"go.jetify.com/devbox/internal/devbox/devopt"
+	"go.jetify.com/devbox/internal/ux"
+)
+
+type configUpgradeFlags struct {
+	config configFlags
+	dryRun bool
+}
+
+func configCmd() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "config",
+		Short: "Manage the project's configuration files",
+	}
+	command.AddCommand(configUpgradeCmd())
+	return command
+}
+
+func configUpgradeCmd() *cobra.Command {
+	flags := configUpgradeFlags{}
+	command := &cobra.Command{
+		Use:   "upgrade",
+		Short: "Migrate deprecated fields in devbox.json, plugins and devbox.lock",
+		Long: heredoc.Doc(`
+			Rewrite deprecated fields in the project's devbox.json, the plugin.json
+			of local plugins it includes, and devbox.lock to the fields that
+			replace them. Comments and formatting in devbox.json and plugin.json
+			are preserved.
+
+			Use --dry-run to list the changes without making them.
+		`),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runConfigUpgradeCmd(cmd, flags)
+		},
+	}
+	flags.config.register(command)
+	command.Flags().BoolVar(
+		&flags.dryRun, "dry-run", false, "list the changes without making them")
+	return command
+}
+
+func runConfigUpgradeCmd(cmd *cobra.Command, flags configUpgradeFlags) error {
+	box, err := devbox.Open(&devopt.Opts{
+		Dir:            flags.config.path,
+		Environment:    flags.config.environment,
+		Stderr:         cmd.ErrOrStderr(),
+		IgnoreWarnings: true,
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	var migrations []devbox.ConfigMigration
+	if flags.dryRun {
+		migrations = box.PendingConfigMigrations()
+	} else if migrations, err = box.UpgradeConfig(); err != nil {
+		return err
+	}
+	if len(migrations) == 0 {
+		ux.Finfof(cmd.ErrOrStderr(), "The project doesn't use any deprecated fields.\n")
+		return nil
+	}
+	for _, m := range migrations {
+		fmt.Fprintf(cmd.OutOrStdout(), "%s: %s\n", m.Path, m.Description)
+	}
+	if !flags.dryRun {
+		ux.Fsuccessf(cmd.ErrOrStderr(), "Upgraded the project's configuration.\n")
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package boxcli
+
+import (
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"go.jetify.com/devbox/internal/devbox"
+	"go.jetify.com/devbox/internal/devbox/devopt"
+)
+
+type direnvSetupCmdFlags struct {
+	envFlag
+	config   configFlags
+	envrcDir string
+	force    bool
+}
+
+func direnvGroupCmd() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "direnv",
+		Short: "Manage the direnv integration of this devbox project",
+	}
+	command.AddCommand(direnvSetupCmd())
+	return command
+}
+
+func direnvSetupCmd() *cobra.Command {
+	flags := &direnvSetupCmdFlags{}
+	command := &cobra.Command{
+		Use:   "setup",
+		Short: "Add or update the devbox block of the project's .envrc",
+		Long: heredoc.Doc(`
+			Add a block to the project's .envrc that loads the devbox environment
+			with direnv, or update the block if an older version of devbox added
+			it. Running it again is safe: the rest of the .envrc is kept as is,
+			and the file isn't changed if the block is up to date.
+
+			When nix-direnv is loaded, the block caches the environment in
+			direnv's layout directory and only recomputes it when devbox.json or
+			devbox.lock change. Otherwise it runs devbox shellenv each time
+			direnv loads the environment.
+		`),
+		Example: "  devbox direnv setup\n" +
+			"  devbox direnv setup --env-file .env --envrc-dir ..",
+		Args:    cobra.NoArgs,
+		PreRunE: ensureNixInstalled,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			box, err := devbox.Open(&devopt.Opts{
+				Dir:         flags.config.path,
+				Environment: flags.config.environment,
+				Stderr:      cmd.ErrOrStderr(),
+			})
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			return box.SetupDirenv(cmd.Context(), devopt.EnvrcOpts{
+				EnvFlags:  devopt.EnvFlags(flags.envFlag),
+				Force:     flags.force,
+				EnvrcDir:  flags.envrcDir,
+				ConfigDir: flags.config.path,
+			})
+		},
+	}
+	flags.envFlag.register(command)
+	flags.config.register(command)
+	command.Flags().StringVar(
+		&flags.envrcDir, "envrc-dir", "",
+		"path to the directory of the .envrc, if it isn't the directory of devbox.json")
+	command.Flags().BoolVarP(
+		&flags.force, "force", "f", false,
+		"replace the devbox block even if a newer version of devbox wrote it")
+	return command
+}
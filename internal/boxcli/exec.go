@@ -0,0 +1,44 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package boxcli
+
+import (
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/spf13/cobra"
+
+	"go.jetify.com/devbox/internal/devbox"
+)
+
+type execCmdFlags struct {
+	pkgs []string
+}
+
+func execCmd() *cobra.Command {
+	flags := execCmdFlags{}
+	command := &cobra.Command{
+		Use:   "exec [--pkg <pkg>]... -- <cmd> [<args>]...",
+		Short: "Run a command from a package without adding it to a project",
+		Long: heredoc.Doc(`
+			Fetch one or more packages and run a command with their binaries in
+			the PATH. The packages are resolved to the latest nixpkgs and fetched
+			to the nix store, but they aren't added to devbox.json or devbox.lock.
+
+			If --pkg isn't given, the latest version of the package with the same
+			name as the command is used.
+		`),
+		Example: "  devbox exec --pkg jq@1.7 -- jq .\n" +
+			"  devbox exec -- cowsay hello\n" +
+			"  devbox exec --pkg nodejs@20 --pkg yarn -- yarn install",
+		Args:    cobra.MinimumNArgs(1),
+		PreRunE: ensureNixInstalled,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return devbox.ExecPackages(cmd.Context(), cmd.ErrOrStderr(), flags.pkgs, args)
+		},
+	}
+	command.Flags().StringArrayVar(
+		&flags.pkgs, "pkg", nil, "package to run the command with. Can be repeated")
+	// Stop parsing flags after the command so that its flags are passed to it.
+	command.Flags().SetInterspersed(false)
+	return command
+}
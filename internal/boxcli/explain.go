@@ -0,0 +1,118 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package boxcli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"go.jetify.com/devbox/internal/devbox"
+	"go.jetify.com/devbox/internal/devbox/devopt"
+)
+
+type explainDevEnvCmdFlags struct {
+	envFlag
+	config   configFlags
+	unsetEnv []string
+	pure     bool
+	json     bool
+}
+
+func explainCmd() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "explain",
+		Short: "Explain how devbox computes parts of your environment",
+	}
+	command.AddCommand(explainDevEnvCmd())
+	return command
+}
+
+func explainDevEnvCmd() *cobra.Command {
+	flags := explainDevEnvCmdFlags{}
+	command := &cobra.Command{
+		Use:   "dev-env [<var>]...",
+		Short: "Show how each environment variable got its value",
+		Long: heredoc.Doc(`
+			Show how each variable in the devbox environment got its value. For
+			every variable, it lists the steps that set or unset it, in the order
+			they ran: the current environment, nix print-dev-env, devbox itself,
+			the go, java and python toolchains, env_from, each plugin's env,
+			devbox.json's env, and the --env, --env-file and --unset-env flags.
+
+			The last step is the one that determined the value. With no
+			arguments, it explains every variable in the environment.
+		`),
+		Example: "  devbox explain dev-env PATH\n" +
+			"  devbox explain dev-env --env DEBUG=1 DEBUG\n" +
+			"  devbox explain dev-env --json",
+		PreRunE: ensureNixInstalled,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return explainDevEnvCmdFunc(cmd, args, flags)
+		},
+	}
+
+	flags.envFlag.register(command)
+	flags.config.register(command)
+	command.Flags().StringSliceVar(
+		&flags.unsetEnv, "unset-env", nil,
+		"environment variables to remove from the devbox environment, after --env is applied")
+	command.Flags().BoolVar(
+		&flags.pure, "pure", false, "explain the environment of a pure shell, which inherits almost no variables from the current environment")
+	command.Flags().BoolVar(&flags.json, "json", false, "print the explanations as JSON")
+	return command
+}
+
+func explainDevEnvCmdFunc(cmd *cobra.Command, args []string, flags explainDevEnvCmdFlags) error {
+	env, err := flags.Env(flags.config.path)
+	if err != nil {
+		return err
+	}
+	box, err := devbox.Open(&devopt.Opts{
+		Dir:         flags.config.path,
+		Env:         env,
+		UnsetEnv:    flags.unsetEnv,
+		Environment: flags.config.environment,
+		Stderr:      cmd.ErrOrStderr(),
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	explanations, err := box.ExplainEnv(cmd.Context(), devopt.EnvOptions{Pure: flags.pure}, args...)
+	if err != nil {
+		return err
+	}
+	if flags.json {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return errors.WithStack(enc.Encode(explanations))
+	}
+	printEnvExplanations(cmd.OutOrStdout(), explanations)
+	return nil
+}
+
+func printEnvExplanations(w io.Writer, explanations []devbox.EnvExplanation) {
+	for i, e := range explanations {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		if e.Set {
+			fmt.Fprintf(w, "%s=%s\n", e.Name, e.Value)
+		} else {
+			fmt.Fprintf(w, "%s is not set\n", e.Name)
+		}
+		for j, c := range e.Contributions {
+			if c.Unset {
+				fmt.Fprintf(w, "  %d. %s: unset\n", j+1, c.Source)
+			} else {
+				fmt.Fprintf(w, "  %d. %s: %s\n", j+1, c.Source, c.Value)
+			}
+		}
+	}
+}
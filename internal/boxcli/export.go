@@ -0,0 +1,91 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package boxcli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"go.jetify.com/devbox/internal/devbox"
+	"go.jetify.com/devbox/internal/devbox/devopt"
+)
+
+type exportCmdFlags struct {
+	envFlag
+	config configFlags
+	format string
+	output string
+	pure   bool
+}
+
+func exportCmd() *cobra.Command {
+	flags := exportCmdFlags{}
+	command := &cobra.Command{
+		Use:   "export",
+		Short: "Write the project's environment variables to a file for containers or services",
+		Long: heredoc.Doc(`
+			Write the Devbox environment's variables in a format that other
+			tools read, to run processes with the project's environment
+			outside of a devbox shell:
+
+			  dotenv       KEY=value lines, for docker run --env-file and
+			               kubectl create configmap --from-env-file
+			  docker-args  --env arguments for docker run
+			  systemd      a file for a systemd unit's EnvironmentFile
+
+			Init hooks don't run, so variables that they set aren't included.
+		`),
+		Example: "  devbox export --format dotenv --output .env\n" +
+			"  docker run --env-file .env my-image\n" +
+			"  kubectl create configmap my-env --from-env-file .env\n" +
+			"  eval \"docker run $(devbox export --format docker-args) my-image\"\n" +
+			"  devbox export --format systemd --output /etc/my-service.env",
+		Args:    cobra.ExactArgs(0),
+		PreRunE: ensureNixInstalled,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			env, err := flags.Env(flags.config.path)
+			if err != nil {
+				return err
+			}
+			box, err := devbox.Open(&devopt.Opts{
+				Dir:         flags.config.path,
+				Environment: flags.config.environment,
+				Stderr:      cmd.ErrOrStderr(),
+				Env:         env,
+			})
+			if err != nil {
+				return err
+			}
+			s, err := box.EnvFile(cmd.Context(), devopt.EnvFileOpts{
+				EnvOptions: devopt.EnvOptions{Pure: flags.pure},
+				Format:     devopt.EnvFileFormat(flags.format),
+			})
+			if err != nil {
+				return err
+			}
+			if flags.output == "" {
+				fmt.Fprintln(cmd.OutOrStdout(), s)
+				return nil
+			}
+			// The variables can include secrets from env_from, so only the
+			// user can read the file.
+			return errors.WithStack(os.WriteFile(flags.output, []byte(s+"\n"), 0o600))
+		},
+	}
+	command.Flags().StringVarP(
+		&flags.format, "format", "f", string(devopt.EnvFileFormatDotenv),
+		"format of the variables: dotenv, docker-args or systemd")
+	command.Flags().StringVarP(
+		&flags.output, "output", "o", "", "file to write the variables to, instead of stdout")
+	command.Flags().BoolVar(
+		&flags.pure, "pure", false,
+		"only include the project's variables, and not the ones inherited from the current environment")
+	flags.config.register(command)
+	flags.envFlag.register(command)
+	return command
+}
@@ -0,0 +1,64 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package boxcli
+
+import (
+	"fmt"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/spf13/cobra"
+
+	"go.jetify.com/devbox/internal/devbox"
+	"go.jetify.com/devbox/internal/devbox/devopt"
+)
+
+type fetchCmdFlags struct {
+	config       configFlags
+	allPlatforms bool
+}
+
+func fetchCmd() *cobra.Command {
+	flags := fetchCmdFlags{}
+	command := &cobra.Command{
+		Use:   "fetch",
+		Short: "Download the project's packages into the nix store",
+		Long: heredoc.Doc(`
+			Download the project's packages for the current platform into the
+			nix store, without computing the shell environment. Packages that
+			aren't in a binary cache are built.
+
+			Use it to prime CI caches, or in its own docker layer so that the
+			packages are only downloaded again when devbox.json or devbox.lock
+			change. devbox install, devbox shell and devbox run then only have
+			to set up the environment.
+
+			With --all-platforms, devbox.lock also records the store paths of
+			the packages on every platform, so that it doesn't have to be
+			updated on other platforms.
+		`),
+		Example: "  devbox fetch\n  devbox fetch --all-platforms",
+		Args:    cobra.ExactArgs(0),
+		PreRunE: ensureNixInstalled,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			box, err := devbox.Open(&devopt.Opts{
+				Dir:         flags.config.path,
+				Environment: flags.config.environment,
+				Stderr:      cmd.ErrOrStderr(),
+			})
+			if err != nil {
+				return err
+			}
+			if err := box.Fetch(cmd.Context(), devopt.FetchOpts{AllPlatforms: flags.allPlatforms}); err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.ErrOrStderr(), "Finished fetching packages.")
+			return nil
+		},
+	}
+	flags.config.register(command)
+	command.Flags().BoolVar(
+		&flags.allPlatforms, "all-platforms", false,
+		"record the packages' store paths for every platform in devbox.lock")
+	return command
+}
@@ -4,10 +4,13 @@
 package boxcli
 
 import (
+	"bytes"
 	"cmp"
 	"fmt"
+	"os"
 	"regexp"
 
+	"github.com/MakeNowJust/heredoc/v2"
 	"github.com/pkg/errors"
 	"github.com/samber/lo"
 	"github.com/spf13/cobra"
@@ -16,6 +19,8 @@
 	"go.jetify.com/devbox/internal/devbox"
 	"go.jetify.com/devbox/internal/devbox/devopt"
 	"go.jetify.com/devbox/internal/devbox/docgen"
+	"go.jetify.com/devbox/internal/fileutil"
+	"go.jetify.com/devbox/internal/ux"
 )
 
 type generateCmdFlags struct {
@@ -38,6 +43,13 @@ type GenerateReadmeCmdFlags struct {
 	template     string
 }
 
+type generateHomeManagerCmdFlags struct {
+	config configFlags
+	global bool
+	output string
+	force  bool
+}
+
 type GenerateAliasCmdFlags struct {
 	config   configFlags
 	prefix   string
@@ -59,6 +71,7 @@ func generateCmd() *cobra.Command {
 	command.AddCommand(dockerfileCmd())
 	command.AddCommand(debugCmd())
 	command.AddCommand(direnvCmd())
+	command.AddCommand(homeManagerCmd())
 	command.AddCommand(genReadmeCmd())
 	flags.config.register(command)
 
@@ -168,6 +181,38 @@ func direnvCmd() *cobra.Command {
 	return command
 }
 
+func homeManagerCmd() *cobra.Command {
+	flags := &generateHomeManagerCmdFlags{}
+	command := &cobra.Command{
+		Use:   "home-manager",
+		Short: "Generate a home-manager module with the packages and env of devbox.json",
+		Long: heredoc.Doc(`
+			Generate a home-manager module that installs the packages of
+			devbox.json, at the versions in devbox.lock, with home.packages,
+			and sets its env with home.sessionVariables. Use --global to
+			generate a module for the packages of devbox global instead.
+
+			The module evaluates the packages' flakes with builtins.getFlake,
+			so flakes must be enabled in the nix that evaluates your
+			home-manager configuration.
+		`),
+		Example: "  devbox generate home-manager --output ~/.config/home-manager/devbox.nix\n" +
+			"  devbox generate home-manager --global > devbox-global.nix",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGenerateHomeManagerCmd(cmd, flags)
+		},
+	}
+	flags.config.register(command)
+	command.Flags().BoolVar(
+		&flags.global, "global", false, "generate the module for the packages of devbox global")
+	command.Flags().StringVarP(
+		&flags.output, "output", "o", "", "path of the module to write, instead of stdout")
+	command.Flags().BoolVarP(
+		&flags.force, "force", "f", false, "force overwrite existing files")
+	return command
+}
+
 func genReadmeCmd() *cobra.Command {
 	flags := &GenerateReadmeCmdFlags{}
 
@@ -278,6 +323,42 @@ func runGenerateCmd(cmd *cobra.Command, flags *generateCmdFlags) error {
 	return nil
 }
 
+func runGenerateHomeManagerCmd(cmd *cobra.Command, flags *generateHomeManagerCmdFlags) error {
+	path := flags.config.path
+	if flags.global {
+		globalPath, err := ensureGlobalConfig()
+		if err != nil {
+			return err
+		}
+		path = globalPath
+	}
+	box, err := devbox.Open(&devopt.Opts{
+		Dir:         path,
+		Environment: flags.config.environment,
+		Stderr:      cmd.ErrOrStderr(),
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if flags.output == "" {
+		return box.GenerateHomeManager(cmd.Context(), cmd.OutOrStdout())
+	}
+	if !flags.force && fileutil.Exists(flags.output) {
+		return usererr.New(
+			"%s already exists. Remove it or use --force to overwrite it.", flags.output)
+	}
+	buf := &bytes.Buffer{}
+	if err := box.GenerateHomeManager(cmd.Context(), buf); err != nil {
+		return err
+	}
+	if err := os.WriteFile(flags.output, buf.Bytes(), 0o644); err != nil {
+		return errors.WithStack(err)
+	}
+	ux.Fsuccessf(cmd.ErrOrStderr(), "Wrote the home-manager module to %s\n", flags.output)
+	return nil
+}
+
 func runGenerateDirenvCmd(cmd *cobra.Command, flags *generateCmdFlags) error {
 	// --print-envrc is used within the .envrc file and therefore doesn't make sense to also
 	// use it with --envrc-dir, which specifies a directory where the .envrc file should be generated.
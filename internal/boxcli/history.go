@@ -0,0 +1,91 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package boxcli
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"go.jetify.com/devbox/internal/devbox"
+	"go.jetify.com/devbox/internal/devbox/devopt"
+)
+
+type historyCmdFlags struct {
+	config configFlags
+	json   bool
+}
+
+func historyCmd() *cobra.Command {
+	flags := historyCmdFlags{}
+	command := &cobra.Command{
+		Use:   "history",
+		Short: "List the recent activations of the project's environment",
+		Long: heredoc.Doc(`
+			List the last 100 times that devbox shell, devbox run or devbox
+			shellenv activated the project's environment, newest first, with
+			the hash of devbox.lock at the time and how long the environment
+			took to compute. devbox shellenv runs whenever direnv reloads
+			the environment, so it's recorded at most every 10 minutes while
+			devbox.lock doesn't change.
+
+			Activations where devbox.lock changed since the one before are
+			marked, which helps to tell whether the environment changed
+			around when a build broke.
+		`),
+		Example: "  devbox history\n  devbox history --json",
+		Args:    cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			box, err := devbox.Open(&devopt.Opts{
+				Dir:         flags.config.path,
+				Environment: flags.config.environment,
+				Stderr:      cmd.ErrOrStderr(),
+			})
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			activations, err := box.Activations()
+			if err != nil {
+				return err
+			}
+			if flags.json {
+				if activations == nil {
+					activations = []devbox.Activation{}
+				}
+				return printJSON(cmd.OutOrStdout(), activations)
+			}
+			if len(activations) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "The environment hasn't been activated yet.")
+				return nil
+			}
+			return printActivations(cmd.OutOrStdout(), activations)
+		},
+	}
+	flags.config.register(command)
+	command.Flags().BoolVar(&flags.json, "json", false, "list the activations in json format")
+	return command
+}
+
+// printActivations prints a table of the activations, which are newest first.
+func printActivations(w io.Writer, activations []devbox.Activation) error {
+	tw := tabwriter.NewWriter(w, 3, 2, 4, ' ', 0)
+	fmt.Fprintln(tw, "TIME\tCOMMAND\tDURATION\tLOCK")
+	for i, a := range activations {
+		lockHash := a.LockHash
+		if len(lockHash) > 12 {
+			lockHash = lockHash[:12]
+		}
+		if i+1 < len(activations) && a.LockHash != activations[i+1].LockHash {
+			lockHash += " (devbox.lock changed)"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n",
+			a.Time.Local().Format(time.DateTime), a.Command, a.Duration, lockHash)
+	}
+	return tw.Flush()
+}
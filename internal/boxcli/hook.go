@@ -0,0 +1,239 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package boxcli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+	"go.jetify.com/devbox/internal/devbox"
+	"go.jetify.com/devbox/internal/devbox/devopt"
+	"go.jetify.com/devbox/internal/envir"
+	"go.jetify.com/devbox/internal/shellrc"
+	"go.jetify.com/devbox/internal/trust"
+	"go.jetify.com/devbox/internal/ux"
+)
+
+// hookRegexp matches the line of a shell rcfile that loads the devbox shell
+// hook.
+var hookRegexp = regexp.MustCompile(`\bdevbox\s+hook\s+(bash|zsh|fish)\b`)
+
+// hookScripts are the shell hooks that devbox hook <shell> prints. They run
+// devbox hook env when the working directory changes, and evaluate its
+// output.
+var hookScripts = map[string]string{
+	"bash": heredoc.Doc(`
+		_devbox_hook() {
+		  local previous_exit_status=$?
+		  if [[ "${_DEVBOX_HOOK_PWD:-}" != "$PWD" ]]; then
+		    _DEVBOX_HOOK_PWD="$PWD"
+		    eval "$(devbox hook env --shell bash)"
+		  fi
+		  return $previous_exit_status
+		}
+		if [[ ";${PROMPT_COMMAND:-};" != *";_devbox_hook;"* ]]; then
+		  PROMPT_COMMAND="_devbox_hook${PROMPT_COMMAND:+;$PROMPT_COMMAND}"
+		fi
+	`),
+	"zsh": heredoc.Doc(`
+		_devbox_hook() {
+		  eval "$(devbox hook env --shell zsh)"
+		}
+		typeset -ag chpwd_functions
+		if (( ! ${chpwd_functions[(I)_devbox_hook]} )); then
+		  chpwd_functions=(_devbox_hook $chpwd_functions)
+		fi
+		_devbox_hook
+	`),
+	"fish": heredoc.Doc(`
+		function _devbox_hook --on-variable PWD --description 'Activate the devbox project in the current directory'
+		    devbox hook env --shell fish | source
+		end
+		_devbox_hook
+	`),
+}
+
+type hookCmdFlags struct {
+	config configFlags
+	shell  string
+}
+
+func hookCmd() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "hook",
+		Short: "Activate devbox projects when you cd into them",
+		Long: heredoc.Doc(`
+			The devbox shell hook activates the environment of a devbox project
+			when you cd into the project, and deactivates it when you leave,
+			without direnv. Like devbox shellenv, it sets the variables of the
+			project's environment, but it doesn't run the project's init hooks.
+
+			Run "devbox hook install" to load the hook in your shell's rcfile.
+			The first time you cd into a project, the hook asks whether to
+			activate it. Your answers are kept in your devbox config directory,
+			and "devbox hook allow" and "devbox hook deny" change them.
+		`),
+		Example: "  devbox hook install\n" +
+			"  devbox hook allow\n" +
+			"  eval \"$(devbox hook bash)\"",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		command.AddCommand(&cobra.Command{
+			Use:   shell,
+			Short: fmt.Sprintf("Print the devbox shell hook for %s", shell),
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				_, err := fmt.Fprint(cmd.OutOrStdout(), hookScripts[shell])
+				return errors.WithStack(err)
+			},
+		})
+	}
+	command.AddCommand(hookInstallCmd())
+	command.AddCommand(hookDecisionCmd(trust.Allow))
+	command.AddCommand(hookDecisionCmd(trust.Deny))
+	command.AddCommand(hookEnvCmd())
+	return command
+}
+
+func hookInstallCmd() *cobra.Command {
+	flags := hookCmdFlags{}
+	command := &cobra.Command{
+		Use:   "install",
+		Short: "Load the devbox shell hook in your shell's rcfile",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			w := cmd.ErrOrStderr()
+			shell := filepath.Base(flags.shell)
+			rcfile := shellrc.ForShell(shell)
+			if rcfile == "" {
+				return usererr.New("The devbox shell hook supports bash, zsh and fish, not %s. "+
+					"Use --shell to choose one of them.", shell)
+			}
+			if shellrc.Contains(rcfile, hookRegexp) {
+				fmt.Fprintf(w, "%s already loads the devbox shell hook.\n", rcfile)
+				return nil
+			}
+
+			hook := fmt.Sprintf(`eval "$(devbox hook %s)"`, shell)
+			if shell == "fish" {
+				hook = "devbox hook fish | source"
+			}
+			err := shellrc.Append(rcfile, "", "# Activate devbox projects when you cd into them.", hook)
+			if err != nil {
+				return err
+			}
+			ux.Fsuccessf(w, "Updated %s. Restart your shell to load the devbox shell hook.\n", rcfile)
+			return nil
+		},
+	}
+	command.Flags().StringVar(
+		&flags.shell, "shell", os.Getenv(envir.Shell), "shell to load the hook in: bash, zsh or fish")
+	return command
+}
+
+func hookDecisionCmd(decision trust.Decision) *cobra.Command {
+	flags := hookCmdFlags{}
+	short := "Activate the project when you cd into it"
+	if decision == trust.Deny {
+		short = "Don't activate the project when you cd into it, and don't ask again"
+	}
+	command := &cobra.Command{
+		Use:   string(decision),
+		Short: short,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			box, err := devbox.Open(&devopt.Opts{
+				Dir:         flags.config.path,
+				Environment: flags.config.environment,
+				Stderr:      cmd.ErrOrStderr(),
+			})
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			if err := trust.Set(box.ProjectDir(), decision); err != nil {
+				return err
+			}
+			if decision == trust.Allow {
+				ux.Fsuccessf(cmd.ErrOrStderr(), "The devbox shell hook activates %s when you cd into it.\n",
+					box.ProjectDir())
+			} else {
+				ux.Fsuccessf(cmd.ErrOrStderr(), "The devbox shell hook doesn't activate %s.\n", box.ProjectDir())
+			}
+			return nil
+		},
+	}
+	flags.config.register(command)
+	return command
+}
+
+func hookEnvCmd() *cobra.Command {
+	flags := hookCmdFlags{}
+	command := &cobra.Command{
+		Use:    "env",
+		Short:  "Print the script that the devbox shell hook evaluates in the current directory",
+		Hidden: true,
+		Args:   cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir, err := os.Getwd()
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			script, err := devbox.HookEnv(cmd.Context(), cmd.ErrOrStderr(), devopt.HookEnvOpts{
+				Dir:   dir,
+				Shell: flags.shell,
+				Ask:   askHookDecision,
+			})
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprint(cmd.OutOrStdout(), script)
+			return errors.WithStack(err)
+		},
+	}
+	command.Flags().StringVar(&flags.shell, "shell", "bash", "shell that evaluates the script: bash, zsh or fish")
+	return command
+}
+
+// askHookDecision asks the user whether the shell hook may activate the
+// project in projectDir. The shell reads the hook's output, so it asks on the
+// terminal. It returns "" if there's no terminal or the user skips the
+// question.
+func askHookDecision(projectDir string) (trust.Decision, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return "", nil
+	}
+	defer tty.Close()
+
+	const allow, deny, skip = "Yes, whenever I cd into it", "No, and don't ask again", "Not now"
+	answer := skip
+	prompt := &survey.Select{
+		Message: fmt.Sprintf("Activate the devbox environment of %s?", projectDir),
+		Options: []string{allow, deny, skip},
+		Default: allow,
+	}
+	if err := survey.AskOne(prompt, &answer, survey.WithStdio(tty, tty, tty)); err != nil {
+		return "", nil
+	}
+	switch answer {
+	case allow:
+		return trust.Allow, nil
+	case deny:
+		return trust.Deny, nil
+	}
+	return "", nil
+}
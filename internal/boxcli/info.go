@@ -5,24 +5,35 @@
 
 import (
 	"fmt"
+	"strings"
+	"text/tabwriter"
 
+	"github.com/MakeNowJust/heredoc/v2"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
 	"go.jetify.com/devbox/internal/devbox"
 	"go.jetify.com/devbox/internal/devbox/devopt"
+	"go.jetify.com/devbox/internal/nix"
 )
 
 type infoCmdFlags struct {
 	config   configFlags
 	markdown bool
+	json     bool
 }
 
 func infoCmd() *cobra.Command {
 	flags := infoCmdFlags{}
 	command := &cobra.Command{
-		Use:     "info <pkg>",
-		Short:   "Display package info",
+		Use:   "info <pkg>",
+		Short: "Display package info",
+		Long: heredoc.Doc(`
+			Display a package's description, homepage, license, the platforms
+			it's available on, its outputs and closure size, and the notes of
+			its plugin, if it has one. The package doesn't need to be installed.
+		`),
+		Example: "  devbox info go@1.22\n  devbox info --json terraform",
 		Args:    cobra.ExactArgs(1),
 		PreRunE: ensureNixInstalled,
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -32,6 +43,81 @@ func infoCmd() *cobra.Command {
 
 	flags.config.register(command)
 	command.Flags().BoolVar(&flags.markdown, "markdown", false, "output in markdown format")
+	command.Flags().BoolVar(&flags.json, "json", false, "output in json format")
+	command.MarkFlagsMutuallyExclusive("markdown", "json")
+	command.AddCommand(infoNixCmd())
+	return command
+}
+
+// nixBackendInfo is the nix backend as printed by devbox info nix --json.
+type nixBackendInfo struct {
+	Path          string          `json:"path"`
+	Flavor        nix.Flavor      `json:"flavor"`
+	FlavorVersion string          `json:"flavor_version,omitempty"`
+	Version       string          `json:"version"`
+	System        string          `json:"system"`
+	StoreDir      string          `json:"store_dir"`
+	Features      map[string]bool `json:"features"`
+}
+
+func infoNixCmd() *cobra.Command {
+	jsonOutput := false
+	command := &cobra.Command{
+		Use:   "nix",
+		Short: "Display which nix implementation devbox uses and its features",
+		Long: heredoc.Doc(`
+			Display the nix implementation that devbox runs, such as upstream
+			Nix, Lix or Determinate Nix, its version, and which of the nix
+			features that devbox depends on it supports. Devbox works around
+			the features that are missing.
+
+			To display the info of the nix package, run devbox info nix@latest.
+		`),
+		Args:    cobra.ExactArgs(0),
+		PreRunE: ensureNixInstalled,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			backend := nix.CurrentBackend()
+			info := backend.Info()
+			out := nixBackendInfo{
+				Path:          backend.Path(),
+				Flavor:        info.Flavor,
+				FlavorVersion: info.FlavorVersion,
+				Version:       info.Version,
+				System:        info.System,
+				StoreDir:      info.StoreDir,
+				Features:      map[string]bool{},
+			}
+			for _, feature := range nix.Features {
+				out.Features[string(feature)] = backend.Supports(feature)
+			}
+			if jsonOutput {
+				return printJSON(cmd.OutOrStdout(), out)
+			}
+
+			flavor := string(out.Flavor)
+			if out.FlavorVersion != "" {
+				flavor += " " + out.FlavorVersion
+			}
+			tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintf(tw, "Path:\t%s\n", out.Path)
+			fmt.Fprintf(tw, "Flavor:\t%s\n", flavor)
+			fmt.Fprintf(tw, "Version:\t%s\n", out.Version)
+			fmt.Fprintf(tw, "System:\t%s\n", out.System)
+			fmt.Fprintf(tw, "Store:\t%s\n", out.StoreDir)
+			supported, missing := []string{}, []string{}
+			for _, feature := range nix.Features {
+				if out.Features[string(feature)] {
+					supported = append(supported, string(feature))
+				} else {
+					missing = append(missing, string(feature))
+				}
+			}
+			fmt.Fprintf(tw, "Features:\t%s\n", strings.Join(supported, ", "))
+			fmt.Fprintf(tw, "Missing features:\t%s\n", strings.Join(missing, ", "))
+			return tw.Flush()
+		},
+	}
+	command.Flags().BoolVar(&jsonOutput, "json", false, "output in json format")
 	return command
 }
 
@@ -45,10 +131,55 @@ func infoCmdFunc(cmd *cobra.Command, pkg string, flags infoCmdFlags) error {
 		return errors.WithStack(err)
 	}
 
-	info, err := box.Info(cmd.Context(), pkg, flags.markdown)
+	info, err := box.PackageInfo(cmd.Context(), pkg)
 	if err != nil {
 		return errors.WithStack(err)
 	}
-	fmt.Fprint(cmd.OutOrStdout(), info)
+	if flags.json {
+		return printJSON(cmd.OutOrStdout(), info)
+	}
+
+	w := cmd.OutOrStdout()
+	heading := ""
+	if flags.markdown {
+		heading = "## "
+	}
+	fmt.Fprintf(w, "%s%s %s\n%s\n\n", heading, info.Name, info.Version, info.Description)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if info.Homepage != "" {
+		fmt.Fprintf(tw, "Homepage:\t%s\n", info.Homepage)
+	}
+	if len(info.Licenses) > 0 {
+		license := strings.Join(info.Licenses, ", ")
+		if info.Unfree {
+			license += " (unfree)"
+		}
+		fmt.Fprintf(tw, "License:\t%s\n", license)
+	}
+	fmt.Fprintf(tw, "Platforms:\t%s\n", strings.Join(info.Platforms, ", "))
+	if len(info.Outputs) > 0 {
+		outputs := []string{}
+		for _, o := range info.Outputs {
+			if o.Default {
+				outputs = append(outputs, o.Name+" (default)")
+			} else {
+				outputs = append(outputs, o.Name)
+			}
+		}
+		fmt.Fprintf(tw, "Outputs:\t%s\n", strings.Join(outputs, ", "))
+	}
+	if info.ClosureSize > 0 {
+		fmt.Fprintf(tw, "Closure size:\t%s\n", formatSize(info.ClosureSize))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	notes, err := box.PackageNotes(cmd.Context(), pkg, flags.markdown)
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(w, notes)
 	return nil
 }
@@ -45,6 +45,9 @@ func initCmd() *cobra.Command {
 			if flags.dryRun {
 				return nil
 			}
+			if err := trustNewProject(cmd.ErrOrStderr(), path); err != nil {
+				return err
+			}
 			fmt.Fprintf(cmd.OutOrStdout(), "Created devbox.json in %s\n", path)
 			fmt.Fprintln(cmd.OutOrStdout(), "Run `devbox add <package>` to add packages, or `devbox shell` to start a dev shell.")
 			return nil
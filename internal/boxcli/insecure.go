@@ -0,0 +1,119 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package boxcli
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"go.jetify.com/devbox/internal/devbox"
+	"go.jetify.com/devbox/internal/devbox/devopt"
+	"go.jetify.com/devbox/internal/ux"
+)
+
+type insecureListCmdFlags struct {
+	config configFlags
+	json   bool
+}
+
+func insecureCmd() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "insecure",
+		Short: "Review the insecure packages that the project allows",
+	}
+	command.AddCommand(insecureListCmd())
+	return command
+}
+
+func insecureListCmd() *cobra.Command {
+	flags := insecureListCmdFlags{}
+	command := &cobra.Command{
+		Use:     "ls",
+		Aliases: []string{"list"},
+		Short:   "List the allow_insecure exceptions of the project's packages",
+		Long: heredoc.Doc(`
+			List the allow_insecure exceptions of the project's packages, when
+			they were accepted and when they expire.
+
+			An exception is stale if it expired, if the package has advisories
+			that weren't known when it was accepted, or if devbox.lock has no
+			record of it. Re-accept an exception with:
+
+			  devbox add <pkg> --allow-insecure=<name> [--insecure-expires=YYYY-MM-DD]
+		`),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return insecureListCmdFunc(cmd, flags)
+		},
+	}
+	flags.config.register(command)
+	command.Flags().BoolVar(&flags.json, "json", false, "output in json format")
+	return command
+}
+
+func insecureListCmdFunc(cmd *cobra.Command, flags insecureListCmdFlags) error {
+	box, err := devbox.Open(&devopt.Opts{
+		Dir:            flags.config.path,
+		Environment:    flags.config.environment,
+		Stderr:         cmd.ErrOrStderr(),
+		IgnoreWarnings: true,
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	exceptions := box.InsecureExceptions(cmd.Context())
+	if flags.json {
+		return printJSON(cmd.OutOrStdout(), exceptions)
+	}
+	if len(exceptions) == 0 {
+		ux.Finfof(cmd.ErrOrStderr(), "The project doesn't allow any insecure packages.\n")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(cmd.OutOrStdout(), 3, 2, 4, ' ', 0)
+	fmt.Fprintln(tw, "PACKAGE\tALLOWS\tACCEPTED\tEXPIRES\tSTATUS")
+	for _, e := range exceptions {
+		accepted, expires := "-", "-"
+		if e.Acceptance != nil {
+			accepted = e.Acceptance.AcceptedAt
+			if e.Acceptance.Expires != "" {
+				expires = e.Acceptance.Expires
+			}
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", e.Package, e.Name, accepted, expires, insecureStatus(e))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	for _, e := range exceptions {
+		if e.Stale() {
+			ux.Fwarningf(
+				cmd.ErrOrStderr(),
+				"Some exceptions are stale. Review them and re-accept them with "+
+					"`devbox add <pkg> --allow-insecure=<name>`.\n",
+			)
+			break
+		}
+	}
+	return nil
+}
+
+func insecureStatus(e devbox.InsecureException) string {
+	switch {
+	case e.Acceptance == nil:
+		return "not recorded"
+	case e.Expired:
+		return "expired"
+	case len(e.NewAdvisories) > 0:
+		return fmt.Sprintf("%d new advisories", len(e.NewAdvisories))
+	default:
+		return "ok"
+	}
+}
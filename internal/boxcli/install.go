@@ -5,29 +5,45 @@
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
+	"go.jetify.com/devbox/internal/boxcli/usererr"
 	"go.jetify.com/devbox/internal/devbox"
 	"go.jetify.com/devbox/internal/devbox/devopt"
 	"go.jetify.com/devbox/internal/devpkg"
+	"go.jetify.com/devbox/internal/nix"
 	"go.jetify.com/devbox/internal/ux"
 )
 
 type installCmdFlags struct {
 	runCmdFlags
-	tidyLockfile bool
+	tidyLockfile   bool
+	frozenLockfile bool
+	check          bool
+	json           bool
 }
 
 func installCmd() *cobra.Command {
 	flags := installCmdFlags{}
 	command := &cobra.Command{
-		Use:     "install",
-		Short:   "Install all packages mentioned in devbox.json",
-		Args:    cobra.MaximumNArgs(0),
-		PreRunE: ensureNixInstalled,
+		Use:   "install",
+		Short: "Install all packages mentioned in devbox.json",
+		Args:  cobra.MaximumNArgs(0),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			// A check shouldn't install Nix, so it reports that Nix is
+			// missing instead.
+			if flags.check {
+				return nil
+			}
+			return ensureNixInstalled(cmd, args)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if flags.check {
+				return installCheckCmdFunc(cmd, flags)
+			}
 			return installCmdFunc(cmd, flags)
 		},
 	}
@@ -38,6 +54,21 @@ func installCmd() *cobra.Command {
 		"Fix missing store paths in the devbox.lock file.",
 		// Could potentially do more in the future.
 	)
+	command.Flags().BoolVar(
+		&flags.frozenLockfile, "frozen-lockfile", false,
+		"Fail if devbox.json and devbox.lock disagree instead of updating devbox.lock, such as in CI.",
+	)
+	command.Flags().BoolVar(
+		&flags.check, "check", false,
+		"Check that all packages are installed without changing anything. Exits with an error if they aren't.",
+	)
+	command.Flags().BoolVar(
+		&flags.json, "json", false,
+		"Print the progress of builds and downloads as JSON objects, one per line.",
+	)
+	command.MarkFlagsMutuallyExclusive("check", "tidy-lockfile")
+	command.MarkFlagsMutuallyExclusive("check", "json")
+	command.MarkFlagsMutuallyExclusive("frozen-lockfile", "tidy-lockfile")
 
 	return command
 }
@@ -56,6 +87,18 @@ func installCmdFunc(cmd *cobra.Command, flags installCmdFlags) error {
 	if flags.tidyLockfile {
 		ctx = ux.HideMessage(ctx, devpkg.MissingStorePathsWarning)
 	}
+	if flags.frozenLockfile {
+		if err := box.CheckFrozenLockfile(); err != nil {
+			return err
+		}
+	}
+	if flags.json {
+		ctx = nix.WithProgress(ctx, nix.ProgressJSON, cmd.OutOrStdout())
+	} else if quiet, _ := cmd.Flags().GetBool("quiet"); quiet {
+		// --quiet discards the command's output, but nix's errors are
+		// still worth printing.
+		ctx = nix.WithProgress(ctx, nix.ProgressQuiet, os.Stderr)
+	}
 	if err = box.Install(ctx); err != nil {
 		return errors.WithStack(err)
 	}
@@ -67,3 +110,31 @@ func installCmdFunc(cmd *cobra.Command, flags installCmdFlags) error {
 	fmt.Fprintln(cmd.ErrOrStderr(), "Finished installing packages.")
 	return nil
 }
+
+func installCheckCmdFunc(cmd *cobra.Command, flags installCmdFlags) error {
+	box, err := devbox.Open(&devopt.Opts{
+		Dir:         flags.config.path,
+		Environment: flags.config.environment,
+		Stderr:      cmd.ErrOrStderr(),
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if flags.frozenLockfile {
+		if err := box.CheckFrozenLockfile(); err != nil {
+			return err
+		}
+	}
+	problems, err := box.CheckInstall(cmd.Context())
+	if err != nil {
+		return err
+	}
+	if len(problems) > 0 {
+		for _, problem := range problems {
+			ux.Fwarningf(cmd.ErrOrStderr(), "%s\n", problem)
+		}
+		return usererr.New("The project isn't fully installed. Run `devbox install` to install it.")
+	}
+	fmt.Fprintln(cmd.ErrOrStderr(), "All packages are installed.")
+	return nil
+}
@@ -0,0 +1,126 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package boxcli
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/spf13/cobra"
+
+	"go.jetify.com/devbox/internal/devbox"
+	"go.jetify.com/devbox/internal/devbox/devopt"
+	"go.jetify.com/devbox/internal/ux"
+)
+
+type javaUseCmdFlags struct {
+	config configFlags
+	script string
+}
+
+func javaCmd() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "java",
+		Short: "Switch between the project's JDKs",
+		Long: heredoc.Doc(`
+			Switch between the JDKs of a project that has several of them
+			installed side by side, such as jdk17 and jdk21.
+
+			The JDK selected with devbox java use sets JAVA_HOME and comes
+			first in the PATH in devbox shell and devbox run. Scripts can
+			run with a different JDK, which helps projects that build
+			against several JDKs.
+		`),
+	}
+	command.AddCommand(javaListCmd())
+	command.AddCommand(javaUseCmd())
+	return command
+}
+
+func javaListCmd() *cobra.Command {
+	flags := configFlags{}
+	command := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List the project's installed JDKs",
+		Args:    cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			box, err := devbox.Open(&devopt.Opts{
+				Dir:         flags.path,
+				Environment: flags.environment,
+				Stderr:      cmd.ErrOrStderr(),
+			})
+			if err != nil {
+				return err
+			}
+			jdks, err := box.JDKs(cmd.Context())
+			if err != nil {
+				return err
+			}
+			if len(jdks) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "No JDKs are installed. Add one with `devbox add jdk`.")
+				return nil
+			}
+
+			def := box.Config().Root.JavaVersion("")
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			for _, jdk := range jdks {
+				marker := " "
+				if jdk.Version == def {
+					marker = "*"
+				}
+				fmt.Fprintf(w, "%s %s\t%s\t%s\n", marker, jdk.Version, jdk.Package, jdk.Home)
+			}
+			return w.Flush()
+		},
+	}
+	flags.register(command)
+	return command
+}
+
+func javaUseCmd() *cobra.Command {
+	flags := javaUseCmdFlags{}
+	command := &cobra.Command{
+		Use:   "use <version>",
+		Short: "Set the JDK that sets JAVA_HOME",
+		Long: heredoc.Doc(`
+			Set the JDK major version, such as 21, that sets JAVA_HOME in the
+			project's environment. The JDK must be installed. The choice is
+			saved as java.default in devbox.json.
+
+			Use --script to run a single script with a different JDK. It's
+			saved in java.scripts in devbox.json.
+		`),
+		Example: "  devbox java use 21\n  devbox java use 17 --script build-legacy",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			box, err := devbox.Open(&devopt.Opts{
+				Dir:         flags.config.path,
+				Environment: flags.config.environment,
+				Stderr:      cmd.ErrOrStderr(),
+			})
+			if err != nil {
+				return err
+			}
+			jdk, err := box.UseJDK(cmd.Context(), args[0], flags.script)
+			if err != nil {
+				return err
+			}
+			if flags.script != "" {
+				ux.Fsuccessf(cmd.ErrOrStderr(), "Script %s now runs with JDK %s (%s)\n", flags.script, jdk.Version, jdk.Package)
+				return nil
+			}
+			ux.Fsuccessf(cmd.ErrOrStderr(), "JAVA_HOME now uses JDK %s (%s)\n", jdk.Version, jdk.Package)
+			if box.IsEnvEnabled() {
+				ux.Finfof(cmd.ErrOrStderr(), "Run %s to update your current shell.\n", box.RefreshAliasOrCommand())
+			}
+			return nil
+		},
+	}
+	flags.config.register(command)
+	command.Flags().StringVar(
+		&flags.script, "script", "", "select the JDK for this script only")
+	return command
+}
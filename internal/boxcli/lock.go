@@ -0,0 +1,399 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package boxcli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+	"go.jetify.com/devbox/internal/devbox"
+	"go.jetify.com/devbox/internal/devbox/devopt"
+	"go.jetify.com/devbox/internal/lock"
+	"go.jetify.com/devbox/internal/ux"
+)
+
+func lockCmd() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "lock",
+		Short: "Compare, restore, check, tidy and export devbox.lock",
+		Long: heredoc.Doc(`
+			Devbox keeps the last 10 versions of devbox.lock in .devbox, so
+			that a bad devbox update or devbox add can be undone. Use devbox
+			lock diff to see how the project's packages changed.
+		`),
+	}
+	command.AddCommand(lockCheckCmd())
+	command.AddCommand(lockDiffCmd())
+	command.AddCommand(lockExportCmd())
+	command.AddCommand(lockHistoryCmd())
+	command.AddCommand(lockRollbackCmd())
+	command.AddCommand(lockTidyCmd())
+	return command
+}
+
+type lockCheckCmdFlags struct {
+	config  configFlags
+	systems []string
+	json    bool
+}
+
+func lockCheckCmd() *cobra.Command {
+	flags := lockCheckCmdFlags{}
+	command := &cobra.Command{
+		Use:   "check",
+		Short: "Check that the packages in devbox.lock are available on every system",
+		Long: heredoc.Doc(`
+			Check with the Devbox search index that every package in
+			devbox.lock, at its locked version, is built for each system,
+			so that a lockfile that works on one platform doesn't break on
+			another, such as an Intel Mac. Packages whose "platforms" or
+			"excluded_platforms" leave out a system aren't checked on it.
+
+			The systems default to the ones in devbox.json's "systems", or
+			to aarch64-darwin, aarch64-linux, x86_64-darwin and x86_64-linux.
+			It fails if a package isn't available on one of them, so it can
+			run in CI before a change to devbox.lock is merged.
+		`),
+		Example: "  devbox lock check\n" +
+			"  devbox lock check --systems aarch64-darwin,x86_64-linux",
+		Args: cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return lockCheckCmdFunc(cmd, flags)
+		},
+	}
+	flags.config.register(command)
+	command.Flags().StringSliceVar(
+		&flags.systems, "systems", nil,
+		"comma-separated systems to check, such as aarch64-darwin,x86_64-linux")
+	command.Flags().BoolVar(&flags.json, "json", false, "print the result as JSON")
+	return command
+}
+
+func lockCheckCmdFunc(cmd *cobra.Command, flags lockCheckCmdFlags) error {
+	box, err := devbox.Open(&devopt.Opts{
+		Dir:         flags.config.path,
+		Environment: flags.config.environment,
+		Stderr:      cmd.ErrOrStderr(),
+	})
+	if err != nil {
+		return err
+	}
+	check, err := box.CheckLockSystems(cmd.Context(), devopt.LockCheckOpts{Systems: flags.systems})
+	if err != nil {
+		return err
+	}
+
+	if flags.json {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(check); err != nil {
+			return errors.WithStack(err)
+		}
+	} else if len(check.Problems) == 0 {
+		ux.Fsuccessf(cmd.ErrOrStderr(), "All %d checked packages in devbox.lock are available on %s.\n",
+			len(check.Checked), strings.Join(check.Systems, ", "))
+	} else {
+		w := cmd.OutOrStdout()
+		fmt.Fprintf(w, "These packages aren't available on every system (%s):\n", strings.Join(check.Systems, ", "))
+		for _, problem := range check.Problems {
+			fmt.Fprintf(w, "  - %s %s\n", problem.Package, problem.Reason)
+		}
+	}
+	if len(check.Problems) > 0 {
+		return usererr.New("%d packages in devbox.lock aren't available on every system.", len(check.Problems))
+	}
+	return nil
+}
+
+type lockDiffCmdFlags struct {
+	config configFlags
+	since  string
+}
+
+func lockDiffCmd() *cobra.Command {
+	flags := lockDiffCmdFlags{}
+	command := &cobra.Command{
+		Use:   "diff [<rev>]",
+		Short: "Summarize how the project's packages changed",
+		Long: heredoc.Doc(`
+			List the packages that were added, removed, upgraded or
+			downgraded between an earlier devbox.lock and the current one.
+
+			The earlier devbox.lock is from a git revision, which defaults to
+			HEAD, or from a while ago with --since. --since uses the git
+			history if the project is in a git repository, and the previous
+			versions that Devbox keeps otherwise.
+		`),
+		Example: "  devbox lock diff\n  devbox lock diff HEAD~1\n  devbox lock diff v1.2.0\n" +
+			"  devbox lock diff --since 7d",
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts := devopt.LockDiffOpts{}
+			if len(args) == 1 {
+				opts.Rev = args[0]
+			}
+			if flags.since != "" {
+				if opts.Rev != "" {
+					return usererr.New("Pass either a git revision or --since, not both.")
+				}
+				since, err := parseSince(flags.since)
+				if err != nil {
+					return err
+				}
+				opts.Since = since
+			}
+
+			box, err := devbox.Open(&devopt.Opts{
+				Dir:         flags.config.path,
+				Environment: flags.config.environment,
+				Stderr:      cmd.ErrOrStderr(),
+			})
+			if err != nil {
+				return err
+			}
+			changes, err := box.DiffLockfile(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			if len(changes) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "No packages changed.")
+				return nil
+			}
+			printLockChanges(cmd.OutOrStdout(), changes)
+			return nil
+		},
+	}
+	flags.config.register(command)
+	command.Flags().StringVar(
+		&flags.since, "since", "", "compare with devbox.lock from this long ago, such as 7d, 2w or 12h")
+	return command
+}
+
+// lockExportFormats are the formats that devbox lock export supports.
+var lockExportFormats = []string{"renovate"}
+
+type lockExportCmdFlags struct {
+	config configFlags
+	format string
+}
+
+func lockExportCmd() *cobra.Command {
+	flags := lockExportCmdFlags{}
+	command := &cobra.Command{
+		Use:   "export",
+		Short: "Print the project's packages for dependency update bots",
+		Long: heredoc.Doc(`
+			Print the packages in devbox.json and the versions that they're
+			locked at, as JSON that dependency update bots can read.
+
+			The renovate format lists the packages in "deps", with the
+			fields of Renovate's package dependencies: depName,
+			currentValue, lockedVersion, and a datasource to look up new
+			versions with, or a skipReason for packages that Renovate
+			can't update. A Renovate JSONata custom manager can read it
+			with a matchString of "deps".
+		`),
+		Example: "  devbox lock export --format renovate > devbox.renovate.json",
+		Args:    cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !slices.Contains(lockExportFormats, flags.format) {
+				return usererr.New("Unsupported format %q. Use one of: %s.",
+					flags.format, strings.Join(lockExportFormats, ", "))
+			}
+			box, err := devbox.Open(&devopt.Opts{
+				Dir:         flags.config.path,
+				Environment: flags.config.environment,
+				Stderr:      cmd.ErrOrStderr(),
+			})
+			if err != nil {
+				return err
+			}
+			return printJSON(cmd.OutOrStdout(), box.RenovateManifest())
+		},
+	}
+	flags.config.register(command)
+	command.Flags().StringVar(
+		&flags.format, "format", "renovate", "the format to export, which is currently only renovate")
+	return command
+}
+
+// printLockChanges prints the changes grouped by kind.
+func printLockChanges(w io.Writer, changes []lock.Change) {
+	groups := []struct {
+		kind  lock.ChangeKind
+		title string
+		sign  string
+	}{
+		{lock.Added, "Added", "+"},
+		{lock.Removed, "Removed", "-"},
+		{lock.Upgraded, "Upgraded", "↑"},
+		{lock.Downgraded, "Downgraded", "↓"},
+		{lock.Changed, "Changed", "~"},
+	}
+	first := true
+	for _, group := range groups {
+		inGroup := lo.Filter(changes, func(c lock.Change, _ int) bool { return c.Kind == group.kind })
+		if len(inGroup) == 0 {
+			continue
+		}
+		if !first {
+			fmt.Fprintln(w)
+		}
+		first = false
+		fmt.Fprintf(w, "%s:\n", group.title)
+		for _, c := range inGroup {
+			switch {
+			case c.Kind == lock.Added:
+				fmt.Fprintf(w, "  %s %s %s\n", group.sign, c.Name, c.NewVersion)
+			case c.Kind == lock.Removed:
+				fmt.Fprintf(w, "  %s %s %s\n", group.sign, c.Name, c.OldVersion)
+			case c.OldVersion == c.NewVersion:
+				fmt.Fprintf(w, "  %s %s %s (new build)\n", group.sign, c.Name, c.NewVersion)
+			default:
+				fmt.Fprintf(w, "  %s %s %s -> %s\n", group.sign, c.Name, c.OldVersion, c.NewVersion)
+			}
+		}
+	}
+}
+
+// parseSince parses a duration such as 7d or 2w, as well as the units that
+// time.ParseDuration accepts.
+func parseSince(s string) (time.Duration, error) {
+	units := map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour}
+	for suffix, unit := range units {
+		if n, err := strconv.Atoi(strings.TrimSuffix(s, suffix)); err == nil && strings.HasSuffix(s, suffix) && n > 0 {
+			return time.Duration(n) * unit, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, usererr.New("Invalid duration %q. Use a duration such as 7d, 2w or 12h.", s)
+	}
+	return d, nil
+}
+
+func lockHistoryCmd() *cobra.Command {
+	flags := configFlags{}
+	command := &cobra.Command{
+		Use:   "history",
+		Short: "List the previous versions of devbox.lock",
+		Args:  cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			box, err := devbox.Open(&devopt.Opts{
+				Dir:         flags.path,
+				Environment: flags.environment,
+				Stderr:      cmd.ErrOrStderr(),
+			})
+			if err != nil {
+				return err
+			}
+			backups, err := box.LockfileHistory()
+			if err != nil {
+				return err
+			}
+			if len(backups) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "There are no previous versions of devbox.lock.")
+				return nil
+			}
+			for _, b := range backups {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s\t%s\n", b.Time.Local().Format(time.DateTime), b.Path)
+			}
+			return nil
+		},
+	}
+	flags.register(command)
+	return command
+}
+
+func lockRollbackCmd() *cobra.Command {
+	flags := configFlags{}
+	command := &cobra.Command{
+		Use:   "rollback",
+		Short: "Restore the previous version of devbox.lock",
+		Long: heredoc.Doc(`
+			Restore the previous version of devbox.lock and install the
+			packages that it resolves to. Rolling back again restores the
+			version before that one.
+		`),
+		Args:    cobra.ExactArgs(0),
+		PreRunE: ensureNixInstalled,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			box, err := devbox.Open(&devopt.Opts{
+				Dir:         flags.path,
+				Environment: flags.environment,
+				Stderr:      cmd.ErrOrStderr(),
+			})
+			if err != nil {
+				return err
+			}
+			restored, err := box.RollbackLockfile(cmd.Context())
+			if err != nil {
+				return err
+			}
+			ux.Fsuccessf(cmd.ErrOrStderr(), "Restored devbox.lock from %s\n",
+				restored.Time.Local().Format(time.DateTime))
+			return nil
+		},
+	}
+	flags.register(command)
+	return command
+}
+
+type lockTidyCmdFlags struct {
+	config  configFlags
+	systems bool
+}
+
+func lockTidyCmd() *cobra.Command {
+	flags := lockTidyCmdFlags{}
+	command := &cobra.Command{
+		Use:   "tidy",
+		Short: "Remove unused entries from devbox.lock",
+		Long: heredoc.Doc(`
+			Remove the packages that are no longer in devbox.json from
+			devbox.lock.
+
+			With --systems, also remove the store paths of the systems that
+			aren't listed in devbox.json's "systems", such as platforms that
+			the team no longer uses.
+		`),
+		Example: "  devbox lock tidy\n  devbox lock tidy --systems",
+		Args:    cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			box, err := devbox.Open(&devopt.Opts{
+				Dir:         flags.config.path,
+				Environment: flags.config.environment,
+				Stderr:      cmd.ErrOrStderr(),
+			})
+			if err != nil {
+				return err
+			}
+			removed, err := box.TidyLockfile(devopt.LockTidyOpts{Systems: flags.systems})
+			if err != nil {
+				return err
+			}
+			if flags.systems {
+				ux.Fsuccessf(cmd.ErrOrStderr(), "Removed %d unused system entries from devbox.lock.\n", removed)
+			} else {
+				ux.Fsuccessf(cmd.ErrOrStderr(), "Tidied devbox.lock.\n")
+			}
+			return nil
+		},
+	}
+	flags.config.register(command)
+	command.Flags().BoolVar(
+		&flags.systems, "systems", false, "remove the store paths of systems that aren't in devbox.json's systems")
+	return command
+}
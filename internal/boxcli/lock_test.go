@@ -0,0 +1,33 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package boxcli
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSince(t *testing.T) {
+	tests := map[string]time.Duration{
+		"7d":  7 * 24 * time.Hour,
+		"2w":  14 * 24 * time.Hour,
+		"12h": 12 * time.Hour,
+		"90m": 90 * time.Minute,
+	}
+	for s, want := range tests {
+		got, err := parseSince(s)
+		if err != nil {
+			t.Errorf("got error for %q: %v", s, err)
+		}
+		if got != want {
+			t.Errorf("got %v for %q, want %v", got, s, want)
+		}
+	}
+
+	for _, s := range []string{"", "d", "-1d", "0d", "seven days", "-5h"} {
+		if _, err := parseSince(s); err == nil {
+			t.Errorf("got no error for %q", s)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package boxcli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+	"go.jetify.com/devbox/internal/devbox"
+	"go.jetify.com/devbox/internal/devbox/devopt"
+)
+
+type logsBuildCmdFlags struct {
+	config configFlags
+	list   bool
+	json   bool
+}
+
+func logsCmd() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "logs",
+		Short: "View the logs that devbox keeps for the project",
+	}
+	command.AddCommand(logsBuildCmd())
+	return command
+}
+
+func logsBuildCmd() *cobra.Command {
+	flags := logsBuildCmdFlags{}
+	command := &cobra.Command{
+		Use:   "build [<pkg>]",
+		Short: "Print the last nix build log of a package",
+		Long: heredoc.Doc(`
+			Print the log of the last time nix built a package, including builds
+			that failed. Devbox keeps the last 5 logs of each derivation in
+			.devbox/logs/build.
+
+			The package is a package in devbox.json or the name of a derivation,
+			such as hello-2.12.1 for a dependency. Without a package, it prints
+			the most recent build log.
+		`),
+		Example: "  devbox logs build my-tool\n  devbox logs build --list",
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			box, err := devbox.Open(&devopt.Opts{
+				Dir:         flags.config.path,
+				Environment: flags.config.environment,
+				Stderr:      cmd.ErrOrStderr(),
+			})
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			pkg := ""
+			if len(args) > 0 {
+				pkg = args[0]
+			}
+			logs, err := box.BuildLogs(pkg)
+			if err != nil {
+				return err
+			}
+
+			if flags.json {
+				return printJSON(cmd.OutOrStdout(), logs)
+			}
+			if flags.list {
+				tw := tabwriter.NewWriter(cmd.OutOrStdout(), 3, 2, 4, ' ', 0)
+				fmt.Fprintln(tw, "NAME\tTIME\tSTATUS\tPATH")
+				for _, log := range logs {
+					status := "ok"
+					if log.Failed {
+						status = "failed"
+					}
+					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n",
+						log.Name, log.Time.Local().Format(time.DateTime), status, log.Path)
+				}
+				return tw.Flush()
+			}
+
+			if len(logs) == 0 {
+				if pkg == "" {
+					return usererr.New("No build logs found. Devbox logs the packages that nix builds from source.")
+				}
+				return usererr.New("No build logs found for %s. Devbox only logs the packages that nix builds from source.", pkg)
+			}
+			f, err := os.Open(logs[0].Path)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			defer f.Close()
+			_, err = io.Copy(cmd.OutOrStdout(), f)
+			return errors.WithStack(err)
+		},
+	}
+	flags.config.register(command)
+	command.Flags().BoolVar(&flags.list, "list", false, "list the build logs instead of printing the last one")
+	command.Flags().BoolVar(&flags.json, "json", false, "list the build logs in json format")
+	return command
+}
@@ -0,0 +1,156 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package boxcli
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+	"go.jetify.com/devbox/internal/devbox"
+	"go.jetify.com/devbox/internal/devbox/devopt"
+)
+
+type metricsCmdFlags struct {
+	config configFlags
+	since  string
+	format string
+}
+
+func metricsCmd() *cobra.Command {
+	flags := metricsCmdFlags{}
+	command := &cobra.Command{
+		Use:   "metrics",
+		Short: "Report on the health of the project's environment over time",
+		Long: heredoc.Doc(`
+			Report on the metrics that devbox records while DEVBOX_METRICS=1 is
+			set: how long shells and scripts take to start, how long
+			installs take, how often the installed environment is up to date
+			when it's activated, and how many installed packages come from a
+			binary cache rather than being built from source.
+
+			The metrics are recorded locally, for each user of the project.
+			--format prometheus prints them in the Prometheus text format, so
+			that a node_exporter textfile collector can export them.
+		`),
+		Example: "  devbox metrics\n" +
+			"  devbox metrics --since 7d --format json\n" +
+			"  devbox metrics --format prometheus > /var/lib/node_exporter/devbox.prom",
+		Args: cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			since := time.Time{}
+			if flags.since != "" {
+				d, err := parseSince(flags.since)
+				if err != nil {
+					return err
+				}
+				since = time.Now().Add(-d)
+			}
+			box, err := devbox.Open(&devopt.Opts{
+				Dir:         flags.config.path,
+				Environment: flags.config.environment,
+				Stderr:      cmd.ErrOrStderr(),
+			})
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			summary, err := box.Metrics(since)
+			if err != nil {
+				return err
+			}
+
+			switch flags.format {
+			case "table":
+				return printMetrics(cmd.OutOrStdout(), summary)
+			case "json":
+				return printJSON(cmd.OutOrStdout(), summary)
+			case "prometheus":
+				return printPrometheusMetrics(cmd.OutOrStdout(), summary)
+			}
+			return usererr.New("Invalid format %q. Use table, json or prometheus.", flags.format)
+		},
+	}
+	flags.config.register(command)
+	command.Flags().StringVar(
+		&flags.since, "since", "", "only report on the metrics from this long ago, such as 7d, 2w or 12h")
+	command.Flags().StringVar(
+		&flags.format, "format", "table", "output format, either table, json or prometheus")
+	return command
+}
+
+func printMetrics(w io.Writer, summary *devbox.MetricsSummary) error {
+	if summary.Samples == 0 {
+		fmt.Fprintln(w, "No metrics were recorded. Set DEVBOX_METRICS=1 to record them.")
+		return nil
+	}
+	fmt.Fprintf(w, "%d samples from %s to %s\n\n", summary.Samples,
+		summary.First.Local().Format(time.DateTime), summary.Last.Local().Format(time.DateTime))
+
+	tw := tabwriter.NewWriter(w, 3, 2, 4, ' ', 0)
+	if len(summary.Durations) > 0 {
+		fmt.Fprintln(tw, "METRIC\tSAMPLES\tMEAN\tP50\tP90\tMAX")
+		for _, d := range summary.Durations {
+			fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t%s\n", d.Name, d.Samples,
+				roundDuration(d.Mean), roundDuration(d.P50), roundDuration(d.P90), roundDuration(d.Max))
+		}
+	}
+	if len(summary.Caches) > 0 {
+		if len(summary.Durations) > 0 {
+			fmt.Fprintln(tw)
+		}
+		fmt.Fprintln(tw, "CACHE\tHITS\tMISSES\tHIT RATE")
+		for _, c := range summary.Caches {
+			fmt.Fprintf(tw, "%s\t%d\t%d\t%.1f%%\n", c.Name, c.Hits, c.Misses, c.HitRate*100)
+		}
+	}
+	return tw.Flush()
+}
+
+// printPrometheusMetrics prints summary in the Prometheus text exposition
+// format, with the durations as summaries in seconds and the caches as
+// counters.
+func printPrometheusMetrics(w io.Writer, summary *devbox.MetricsSummary) error {
+	project := "project=" + strconv.Quote(summary.Project)
+	help := map[string]string{
+		devbox.MetricShellStartup: "How long devbox took to compute the environment when activating it.",
+		devbox.MetricInstall:      "How long devbox took to install the project's packages.",
+	}
+	for _, d := range summary.Durations {
+		name := "devbox_" + d.Name + "_seconds"
+		fmt.Fprintf(w, "# HELP %s %s\n", name, help[d.Name])
+		fmt.Fprintf(w, "# TYPE %s summary\n", name)
+		fmt.Fprintf(w, "%s{%s,quantile=\"0.5\"} %g\n", name, project, d.P50.Seconds())
+		fmt.Fprintf(w, "%s{%s,quantile=\"0.9\"} %g\n", name, project, d.P90.Seconds())
+		fmt.Fprintf(w, "%s_sum{%s} %g\n", name, project, d.Sum.Seconds())
+		fmt.Fprintf(w, "%s_count{%s} %d\n", name, project, d.Samples)
+	}
+	if len(summary.Caches) > 0 {
+		fmt.Fprintln(w, "# HELP devbox_cache_hits_total Lookups that devbox found in a cache.")
+		fmt.Fprintln(w, "# TYPE devbox_cache_hits_total counter")
+		for _, c := range summary.Caches {
+			fmt.Fprintf(w, "devbox_cache_hits_total{%s,cache=%q} %d\n", project, c.Name, c.Hits)
+		}
+		fmt.Fprintln(w, "# HELP devbox_cache_misses_total Lookups that devbox didn't find in a cache.")
+		fmt.Fprintln(w, "# TYPE devbox_cache_misses_total counter")
+		for _, c := range summary.Caches {
+			fmt.Fprintf(w, "devbox_cache_misses_total{%s,cache=%q} %d\n", project, c.Name, c.Misses)
+		}
+	}
+	return nil
+}
+
+// roundDuration rounds d to a precision that's readable in a table.
+func roundDuration(d time.Duration) time.Duration {
+	if d >= time.Second {
+		return d.Round(10 * time.Millisecond)
+	}
+	return d.Round(time.Millisecond)
+}
@@ -49,6 +49,11 @@ func SyncLockfiles(pkgs []string) error {
 					lockFile.Packages[key].Source = latestPkg.Source
 					lockFile.Packages[key].Version = latestPkg.Version
 					lockFile.Packages[key].Systems = latestPkg.Systems
+					lockFile.Packages[key].Checksums = latestPkg.Checksums
+					// PatchesHash is intentionally omitted because
+					// patch files are local to each project.
+					// Toolchain and InsecureAcceptances are omitted
+					// for the same reason.
 					changed = true
 				}
 			}
@@ -0,0 +1,56 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package boxcli
+
+import (
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/spf13/cobra"
+
+	"go.jetify.com/devbox/internal/devbox"
+	"go.jetify.com/devbox/internal/devbox/devopt"
+)
+
+func nixCmd() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "nix",
+		Short: "Manage how devbox uses nix",
+	}
+	command.AddCommand(nixWarmCmd())
+	return command
+}
+
+func nixWarmCmd() *cobra.Command {
+	flags := configFlags{}
+	command := &cobra.Command{
+		Use:   "warm",
+		Short: "Evaluate the project's flake to populate nix's evaluation cache",
+		Long: heredoc.Doc(`
+			Install the project's packages if needed and evaluate the flake that
+			devbox generates for it, so that nix's evaluation cache and devbox's
+			cached environment are ready before the next devbox shell or
+			devbox run. This speeds up the first shell after a change to
+			devbox.json, which helps most with large package sets, for example
+			when run in CI or after git pull.
+
+			Set "nix": {"eval_cache": true} in devbox.json to make every nix
+			command that devbox runs use the evaluation cache, even if it's
+			disabled in nix.conf.
+		`),
+		Example: "  devbox nix warm\n  devbox nix warm --config ./services/api",
+		Args:    cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			box, err := devbox.Open(&devopt.Opts{
+				Dir:         flags.path,
+				Environment: flags.environment,
+				Stderr:      cmd.ErrOrStderr(),
+			})
+			if err != nil {
+				return err
+			}
+			return box.WarmEvalCache(cmd.Context())
+		},
+	}
+	flags.register(command)
+	return command
+}
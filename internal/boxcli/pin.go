@@ -0,0 +1,78 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package boxcli
+
+import (
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"go.jetify.com/devbox/internal/devbox"
+	"go.jetify.com/devbox/internal/devbox/devopt"
+)
+
+type pinCmdFlags struct {
+	config configFlags
+}
+
+func pinCmd() *cobra.Command {
+	flags := pinCmdFlags{}
+	command := &cobra.Command{
+		Use:   "pin <pkg>...",
+		Short: "Freeze packages at their locked versions",
+		Long: heredoc.Doc(`
+			Freeze packages at the versions in devbox.lock. The version of each
+			package in devbox.json is replaced by its exact locked version, such
+			as go@1.22 by go@1.22.3, and devbox update skips it until it's
+			unpinned with devbox unpin.
+		`),
+		Example: "  devbox pin go\n  devbox pin nodejs python",
+		Args:    cobra.MinimumNArgs(1),
+		PreRunE: ensureNixInstalled,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			box, err := openPinBox(cmd, flags)
+			if err != nil {
+				return err
+			}
+			return box.Pin(cmd.Context(), args...)
+		},
+	}
+	flags.config.register(command)
+	return command
+}
+
+func unpinCmd() *cobra.Command {
+	flags := pinCmdFlags{}
+	command := &cobra.Command{
+		Use:   "unpin <pkg>...",
+		Short: "Let devbox update update pinned packages again",
+		Long: heredoc.Doc(`
+			Restore the versions that packages had in devbox.json before they
+			were pinned with devbox pin, such as go@1.22, so that devbox update
+			updates them again. The packages stay at their locked versions until
+			they're updated.
+		`),
+		Example: "  devbox unpin go\n  devbox unpin go && devbox update go",
+		Args:    cobra.MinimumNArgs(1),
+		PreRunE: ensureNixInstalled,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			box, err := openPinBox(cmd, flags)
+			if err != nil {
+				return err
+			}
+			return box.Unpin(cmd.Context(), args...)
+		},
+	}
+	flags.config.register(command)
+	return command
+}
+
+func openPinBox(cmd *cobra.Command, flags pinCmdFlags) (*devbox.Devbox, error) {
+	box, err := devbox.Open(&devopt.Opts{
+		Dir:         flags.config.path,
+		Environment: flags.config.environment,
+		Stderr:      cmd.ErrOrStderr(),
+	})
+	return box, errors.WithStack(err)
+}
@@ -0,0 +1,415 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package boxcli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+	"go.jetify.com/devbox/internal/devbox"
+	"go.jetify.com/devbox/internal/devbox/devopt"
+	"go.jetify.com/devbox/internal/plugin"
+	"go.jetify.com/devbox/internal/ux"
+)
+
+type pluginCleanCmdFlags struct {
+	config configFlags
+	yes    bool
+}
+
+type pluginDevCmdFlags struct {
+	config configFlags
+	remove bool
+}
+
+type pluginListCmdFlags struct {
+	config configFlags
+	json   bool
+}
+
+func pluginCmd() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "plugin",
+		Short: "Develop and inspect devbox plugins",
+	}
+	command.AddCommand(pluginCleanCmd())
+	command.AddCommand(pluginDevCmd())
+	command.AddCommand(pluginInfoCmd())
+	command.AddCommand(pluginListCmd())
+	command.AddCommand(pluginNewCmd())
+	command.AddCommand(pluginRestoreCmd())
+	command.AddCommand(pluginValidateCmd())
+	return command
+}
+
+func pluginCleanCmd() *cobra.Command {
+	flags := pluginCleanCmdFlags{}
+	command := &cobra.Command{
+		Use:   "clean",
+		Short: "Delete files created by plugins that are no longer in the project",
+		Long: heredoc.Doc(`
+			Delete files that plugins created with create_files once the
+			plugin, or the package that triggered it, is removed from the
+			project.
+
+			Files the plugin created in .devbox are deleted automatically. This
+			command deletes the rest, such as config files in devbox.d. Files
+			that were edited since the plugin created them are marked as
+			modified. To undo the changes to the files of an active plugin
+			instead, use "devbox plugin restore".
+		`),
+		Args: cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			box, err := devbox.Open(&devopt.Opts{
+				Dir:    flags.config.path,
+				Stderr: cmd.ErrOrStderr(),
+			})
+			if err != nil {
+				return err
+			}
+			orphans, err := box.OrphanedPluginFiles()
+			if err != nil {
+				return err
+			}
+			if len(orphans) == 0 {
+				fmt.Fprintln(cmd.ErrOrStderr(), "No files to clean up")
+				return nil
+			}
+
+			fmt.Fprintln(cmd.ErrOrStderr(), "The following files were created by plugins that are no longer in your project:")
+			for _, f := range orphans {
+				rel, _ := filepath.Rel(box.ProjectDir(), f.Path)
+				fmt.Fprintf(cmd.ErrOrStderr(), "  %s (%s)", rel, f.Plugin)
+				if f.Modified {
+					fmt.Fprint(cmd.ErrOrStderr(), " [modified]")
+				}
+				fmt.Fprintln(cmd.ErrOrStderr())
+			}
+			if !flags.yes {
+				prompt := &survey.Confirm{Message: "Delete these files?"}
+				if err := survey.AskOne(prompt, &flags.yes); err != nil {
+					return errors.WithStack(err)
+				}
+				if !flags.yes {
+					return nil
+				}
+			}
+			if err := box.RemovePluginFiles(orphans); err != nil {
+				return err
+			}
+			ux.Fsuccessf(cmd.ErrOrStderr(), "Deleted %d file(s)\n", len(orphans))
+			return nil
+		},
+	}
+	flags.config.register(command)
+	command.Flags().BoolVarP(
+		&flags.yes, "yes", "y", false, "delete the files without asking for confirmation")
+	return command
+}
+
+func pluginDevCmd() *cobra.Command {
+	flags := pluginDevCmdFlags{}
+	command := &cobra.Command{
+		Use:   "dev <path>",
+		Short: "Use a local plugin in development mode",
+		Long: heredoc.Doc(`
+			Link a local plugin into the current project in development mode.
+
+			A plugin in development mode is included in the project without
+			being added to devbox.json. Devbox validates its plugin.json on
+			every command, never caches any of its content, and recreates the
+			files it creates whenever any file in the plugin changes.
+
+			Use --remove to take the plugin out of development mode.
+		`),
+		Example: "\n  devbox plugin dev ./my-plugin\n  devbox plugin dev --remove my-plugin",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if flags.remove {
+				if err := devbox.UnlinkDevPlugin(flags.config.path, args[0]); err != nil {
+					return err
+				}
+				ux.Fsuccessf(cmd.ErrOrStderr(), "Removed %s from development mode\n", args[0])
+				return nil
+			}
+			name, err := devbox.LinkDevPlugin(flags.config.path, args[0])
+			if err != nil {
+				return err
+			}
+			ux.Fsuccessf(
+				cmd.ErrOrStderr(),
+				"Plugin %q is in development mode. Changes are picked up by the next devbox command.\n",
+				name,
+			)
+			return nil
+		},
+	}
+	flags.config.register(command)
+	command.Flags().BoolVar(
+		&flags.remove, "remove", false, "take the plugin out of development mode")
+	return command
+}
+
+func pluginNewCmd() *cobra.Command {
+	var dir string
+	command := &cobra.Command{
+		Use:   "new <name>",
+		Short: "Create a new plugin",
+		Long: heredoc.Doc(`
+			Create a new plugin with a plugin.json, a README and an example
+			service. The plugin is created in a directory named after the
+			plugin unless --dir is set.
+		`),
+		Example: "\n  devbox plugin new my-plugin\n  devbox plugin new my-plugin --dir plugins/my-plugin",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if dir == "" {
+				dir = args[0]
+			}
+			if err := devbox.ScaffoldPlugin(dir, args[0]); err != nil {
+				return err
+			}
+			ux.Fsuccessf(cmd.ErrOrStderr(), "Created plugin %q in %s\n", args[0], dir)
+			ux.Finfof(
+				cmd.ErrOrStderr(),
+				"Run `devbox plugin dev %s` in a devbox project to try it out.\n",
+				dir,
+			)
+			return nil
+		},
+	}
+	command.Flags().StringVar(&dir, "dir", "", "directory to create the plugin in")
+	return command
+}
+
+func pluginRestoreCmd() *cobra.Command {
+	flags := pluginCleanCmdFlags{}
+	command := &cobra.Command{
+		Use:   "restore <name>",
+		Short: "Create the files of an active plugin again",
+		Long: heredoc.Doc(`
+			Create the files that an active plugin creates with create_files
+			again, such as its config files in devbox.d. Changes that were made
+			to the files since the plugin created them are overwritten, and
+			files that were deleted are created again.
+
+			Files of plugins that are no longer in the project can't be
+			restored. Use "devbox plugin clean" to delete them.
+		`),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			box, err := devbox.Open(&devopt.Opts{
+				Dir:    flags.config.path,
+				Stderr: cmd.ErrOrStderr(),
+			})
+			if err != nil {
+				return err
+			}
+			summary, err := box.Plugin(args[0])
+			if err != nil {
+				return err
+			}
+			if len(summary.CreateFiles) == 0 {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Plugin %s doesn't create any files\n", summary.Name)
+				return nil
+			}
+			if !flags.yes {
+				fmt.Fprintf(cmd.ErrOrStderr(), "The following files of plugin %s will be overwritten:\n", summary.Name)
+				for _, file := range summary.CreateFiles {
+					rel, _ := filepath.Rel(box.ProjectDir(), file)
+					fmt.Fprintf(cmd.ErrOrStderr(), "  %s\n", rel)
+				}
+				prompt := &survey.Confirm{Message: "Restore these files?"}
+				if err := survey.AskOne(prompt, &flags.yes); err != nil {
+					return errors.WithStack(err)
+				}
+				if !flags.yes {
+					return nil
+				}
+			}
+			restored, err := box.RestorePluginFiles(summary.Name)
+			if err != nil {
+				return err
+			}
+			ux.Fsuccessf(cmd.ErrOrStderr(), "Restored %d file(s)\n", len(restored))
+			return nil
+		},
+	}
+	flags.config.register(command)
+	command.Flags().BoolVarP(
+		&flags.yes, "yes", "y", false, "restore the files without asking for confirmation")
+	return command
+}
+
+func pluginValidateCmd() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "validate <path|ref>",
+		Short: "Check a plugin for mistakes",
+		Long: heredoc.Doc(`
+			Check a plugin for mistakes without installing it.
+
+			Validate checks the plugin.json syntax and schema, renders every
+			template, checks that the files the plugin creates exist, and
+			checks that the services it defines are well formed. It accepts a
+			local path or any ref that can be used in include.
+		`),
+		Example: "\n  devbox plugin validate ./my-plugin\n  devbox plugin validate github:org/repo?dir=my-plugin",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			problems, err := devbox.ValidatePlugin(args[0])
+			if err != nil {
+				return err
+			}
+			if len(problems) > 0 {
+				return usererr.New(
+					"found %d problem(s) in %s:\n\n%s",
+					len(problems), args[0], strings.Join(problems, "\n\n"),
+				)
+			}
+			ux.Fsuccessf(cmd.ErrOrStderr(), "%s is valid\n", args[0])
+			return nil
+		},
+	}
+	return command
+}
+
+func pluginListCmd() *cobra.Command {
+	flags := pluginListCmdFlags{}
+	command := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List the plugins active in the project",
+		Long: heredoc.Doc(`
+			List every plugin active in the project. This includes builtin
+			plugins triggered by packages, plugins listed in include, plugins
+			included by other plugins, and plugins in development mode.
+		`),
+		Args: cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			box, err := devbox.Open(&devopt.Opts{
+				Dir:    flags.config.path,
+				Stderr: cmd.ErrOrStderr(),
+			})
+			if err != nil {
+				return err
+			}
+			summaries, err := box.Plugins()
+			if err != nil {
+				return err
+			}
+			if flags.json {
+				return printJSON(cmd.OutOrStdout(), summaries)
+			}
+			if len(summaries) == 0 {
+				fmt.Fprintln(cmd.ErrOrStderr(), "No plugins are active in this project")
+				return nil
+			}
+			tw := tabwriter.NewWriter(cmd.OutOrStdout(), 3, 2, 4, ' ', 0)
+			fmt.Fprintln(tw, "NAME\tVERSION\tTYPE\tSOURCE\tREV")
+			for _, s := range summaries {
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
+					s.Name, s.Version, pluginType(s), s.Source, s.Rev)
+			}
+			return tw.Flush()
+		},
+	}
+	flags.config.register(command)
+	command.Flags().BoolVar(&flags.json, "json", false, "output in json format")
+	return command
+}
+
+func pluginInfoCmd() *cobra.Command {
+	flags := pluginListCmdFlags{}
+	command := &cobra.Command{
+		Use:   "info <name>",
+		Short: "Show what an active plugin adds to the environment",
+		Long: heredoc.Doc(`
+			Show where an active plugin comes from, and the environment
+			variables, files and services it adds to the project.
+		`),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			box, err := devbox.Open(&devopt.Opts{
+				Dir:    flags.config.path,
+				Stderr: cmd.ErrOrStderr(),
+			})
+			if err != nil {
+				return err
+			}
+			summary, err := box.Plugin(args[0])
+			if err != nil {
+				return err
+			}
+			if flags.json {
+				return printJSON(cmd.OutOrStdout(), summary)
+			}
+			printPluginSummary(cmd.OutOrStdout(), summary)
+			return nil
+		},
+	}
+	flags.config.register(command)
+	command.Flags().BoolVar(&flags.json, "json", false, "output in json format")
+	return command
+}
+
+func printPluginSummary(w io.Writer, s *plugin.Summary) {
+	fmt.Fprintf(w, "Name:    %s\n", s.Name)
+	if s.Version != "" {
+		fmt.Fprintf(w, "Version: %s\n", s.Version)
+	}
+	fmt.Fprintf(w, "Type:    %s\n", pluginType(s))
+	fmt.Fprintf(w, "Source:  %s\n", s.Source)
+	if s.Rev != "" {
+		fmt.Fprintf(w, "Rev:     %s\n", s.Rev)
+	}
+	if s.Description != "" {
+		fmt.Fprintf(w, "\n%s\n", s.Description)
+	}
+	if len(s.Env) > 0 {
+		fmt.Fprintln(w, "\nEnvironment variables:")
+		for _, name := range slices.Sorted(maps.Keys(s.Env)) {
+			fmt.Fprintf(w, "  %s=%s\n", name, s.Env[name])
+		}
+	}
+	if len(s.CreateFiles) > 0 {
+		fmt.Fprintln(w, "\nCreated files:")
+		for _, file := range s.CreateFiles {
+			fmt.Fprintf(w, "  %s\n", file)
+		}
+	}
+	if len(s.Services) > 0 {
+		fmt.Fprintln(w, "\nServices:")
+		for _, name := range s.Services {
+			fmt.Fprintf(w, "  %s\n", name)
+		}
+	}
+}
+
+func pluginType(s *plugin.Summary) string {
+	if s.Dev {
+		return s.Type + " (dev)"
+	}
+	return s.Type
+}
+
+func printJSON(w io.Writer, v any) error {
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	_, err = fmt.Fprintln(w, string(out))
+	return errors.WithStack(err)
+}
@@ -0,0 +1,126 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package boxcli
+
+import (
+	"encoding/json"
+	"fmt"
+	"text/template"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+	"go.jetify.com/devbox/internal/devbox"
+)
+
+const defaultPromptFormat = `{{ .Name }}{{ if .Dirty }}*{{ end }}` +
+	`{{ if and .Environment (ne .Environment "dev") }} ({{ .Environment }}){{ end }}`
+
+type promptCmdFlags struct {
+	pathFlag
+	format string
+	json   bool
+}
+
+func promptCmd() *cobra.Command {
+	flags := promptCmdFlags{}
+	command := &cobra.Command{
+		Use:   "prompt",
+		Short: "Print a short project status for shell prompts",
+		Long: heredoc.Doc(`
+			Print a short status of the current devbox project for use in a shell
+			prompt: the project's name, a "*" if devbox.json or devbox.lock changed
+			since the environment was last installed, and the environment of the
+			active devbox shell when it isn't dev.
+
+			Inside a devbox shell, it shows the shell's project. Otherwise, it
+			looks for a devbox.json in the current directory and its parents, and
+			prints nothing if there isn't one.
+
+			It reads cached state instead of computing the environment, so it's
+			fast enough to run on every prompt. Use "devbox prompt starship" or
+			"devbox prompt p10k" to print a snippet that adds it to your prompt.
+		`),
+		Example: "  devbox prompt\n" +
+			"  devbox prompt --format '{{ .Name }}{{ if .Active }} (active){{ end }}'\n" +
+			"  devbox prompt --json",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return promptCmdFunc(cmd, flags)
+		},
+	}
+
+	flags.pathFlag.register(command)
+	command.Flags().StringVar(&flags.format, "format", defaultPromptFormat,
+		"Go template for the status, with the fields .Name, .ProjectDir, .Active, .Dirty and .Environment")
+	command.Flags().BoolVar(&flags.json, "json", false, "print the status as JSON")
+	command.MarkFlagsMutuallyExclusive("format", "json")
+
+	command.AddCommand(promptSnippetCmd("starship", "Print a starship module that shows the devbox status",
+		starshipPromptSnippet))
+	command.AddCommand(promptSnippetCmd("p10k", "Print a powerlevel10k segment that shows the devbox status",
+		p10kPromptSnippet))
+	return command
+}
+
+func promptCmdFunc(cmd *cobra.Command, flags promptCmdFlags) error {
+	tmpl, err := template.New("prompt").Parse(flags.format)
+	if err != nil {
+		return usererr.WithUserMessage(err, "Invalid --format template.")
+	}
+
+	status, err := devbox.Prompt(flags.path)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if status == nil {
+		return nil
+	}
+
+	if flags.json {
+		b, err := json.Marshal(status)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		_, err = fmt.Fprintln(cmd.OutOrStdout(), string(b))
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(tmpl.Execute(cmd.OutOrStdout(), status))
+}
+
+func promptSnippetCmd(name, short, snippet string) *cobra.Command {
+	return &cobra.Command{
+		Use:   name,
+		Short: short,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, err := fmt.Fprint(cmd.OutOrStdout(), snippet)
+			return errors.WithStack(err)
+		},
+	}
+}
+
+var starshipPromptSnippet = heredoc.Doc(`
+	# Add this to ~/.config/starship.toml. The module is hidden when
+	# devbox prompt prints nothing, outside of devbox projects.
+	[custom.devbox]
+	command = "devbox prompt"
+	when = true
+	symbol = "📦 "
+	style = "bold 208"
+	format = "[$symbol($output )]($style)"
+`)
+
+var p10kPromptSnippet = heredoc.Doc(`
+	# Add this to ~/.p10k.zsh, and add devbox to
+	# POWERLEVEL9K_LEFT_PROMPT_ELEMENTS or POWERLEVEL9K_RIGHT_PROMPT_ELEMENTS.
+	function prompt_devbox() {
+	  local devbox_status
+	  devbox_status="$(devbox prompt 2>/dev/null)" || return
+	  [[ -n "$devbox_status" ]] || return
+	  p10k segment -f 208 -i '📦' -t "$devbox_status"
+	}
+`)
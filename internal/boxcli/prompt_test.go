@@ -0,0 +1,34 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package boxcli
+
+import (
+	"strings"
+	"testing"
+	"text/template"
+
+	"go.jetify.com/devbox/internal/devbox"
+)
+
+func TestDefaultPromptFormat(t *testing.T) {
+	tmpl := template.Must(template.New("prompt").Parse(defaultPromptFormat))
+	tests := []struct {
+		status devbox.PromptStatus
+		want   string
+	}{
+		{devbox.PromptStatus{Name: "api"}, "api"},
+		{devbox.PromptStatus{Name: "api", Dirty: true}, "api*"},
+		{devbox.PromptStatus{Name: "api", Active: true, Environment: "dev"}, "api"},
+		{devbox.PromptStatus{Name: "api", Active: true, Dirty: true, Environment: "prod"}, "api* (prod)"},
+	}
+	for _, test := range tests {
+		sb := &strings.Builder{}
+		if err := tmpl.Execute(sb, test.status); err != nil {
+			t.Fatal(err)
+		}
+		if got := sb.String(); got != test.want {
+			t.Errorf("prompt for %+v = %q, want %q", test.status, got, test.want)
+		}
+	}
+}
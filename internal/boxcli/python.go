@@ -0,0 +1,138 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package boxcli
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/spf13/cobra"
+
+	"go.jetify.com/devbox/internal/devbox"
+	"go.jetify.com/devbox/internal/devbox/devopt"
+	"go.jetify.com/devbox/internal/python"
+	"go.jetify.com/devbox/internal/ux"
+)
+
+type pythonVenvCmdFlags struct {
+	config   configFlags
+	recreate bool
+}
+
+func pythonCmd() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "python",
+		Short: "Manage the project's Python virtual environment",
+		Long: heredoc.Doc(`
+			Manage the Python virtual environment of a project with a Python
+			package.
+
+			Devbox creates the virtual environment in $VENV_DIR (.venv by
+			default) with the project's Python, and activates it in devbox
+			shell and devbox run. When the python package changes, Devbox
+			recreates the virtual environment so that it never uses a Python
+			that's no longer in the project.
+		`),
+	}
+	command.AddCommand(pythonInfoCmd())
+	command.AddCommand(pythonVenvCmd())
+	return command
+}
+
+func pythonInfoCmd() *cobra.Command {
+	flags := configFlags{}
+	command := &cobra.Command{
+		Use:   "info",
+		Short: "Show the state of the Python virtual environment",
+		Args:  cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			box, err := devbox.Open(&devopt.Opts{
+				Dir:         flags.path,
+				Environment: flags.environment,
+				Stderr:      cmd.ErrOrStderr(),
+			})
+			if err != nil {
+				return err
+			}
+			venv, err := box.PythonVenv()
+			if err != nil {
+				return err
+			}
+
+			interpreter, err := venv.Interpreter()
+			if err != nil {
+				interpreter = "not installed (run `devbox install`)"
+			}
+			status, err := venv.Status()
+			if err != nil {
+				return err
+			}
+			tool := python.DetectTool(box.ProjectDir())
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintf(w, "Python:\t%s\n", interpreter)
+			fmt.Fprintf(w, "Virtual environment:\t%s\n", venv.Dir)
+			fmt.Fprintf(w, "Status:\t%s\n", status)
+			fmt.Fprintf(w, "Dependencies:\t%s\n", tool)
+			return w.Flush()
+		},
+	}
+	flags.register(command)
+	return command
+}
+
+func pythonVenvCmd() *cobra.Command {
+	flags := pythonVenvCmdFlags{}
+	command := &cobra.Command{
+		Use:   "venv",
+		Short: "Create or update the Python virtual environment",
+		Long: heredoc.Doc(`
+			Install the project's packages and create the Python virtual
+			environment if it doesn't exist or uses a different Python.
+
+			Virtual environments that Devbox didn't create are left as they
+			are. Use --recreate to replace them, or to start over with an
+			empty virtual environment.
+		`),
+		Args: cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			box, err := devbox.Open(&devopt.Opts{
+				Dir:         flags.config.path,
+				Environment: flags.config.environment,
+				Stderr:      cmd.ErrOrStderr(),
+			})
+			if err != nil {
+				return err
+			}
+			venv, err := box.EnsurePythonVenv(cmd.Context(), flags.recreate)
+			if err != nil {
+				return err
+			}
+
+			status, err := venv.Status()
+			if err != nil {
+				return err
+			}
+			if status == python.StatusUnmanaged {
+				ux.Fwarningf(
+					cmd.ErrOrStderr(),
+					"The virtual environment in %s wasn't created by Devbox and might not use the project's Python. "+
+						"Run `devbox python venv --recreate` to replace it.\n",
+					venv.Dir,
+				)
+				return nil
+			}
+			ux.Fsuccessf(cmd.ErrOrStderr(), "Python virtual environment is ready in %s\n", venv.Dir)
+			if install := python.DetectTool(box.ProjectDir()).InstallCommand(box.ProjectDir()); install != "" {
+				ux.Finfof(cmd.ErrOrStderr(), "Run `%s` in devbox shell to install your project's dependencies.\n", install)
+			}
+			return nil
+		},
+	}
+	flags.config.register(command)
+	command.Flags().BoolVar(
+		&flags.recreate, "recreate", false, "replace the virtual environment even if it's up to date")
+	return command
+}
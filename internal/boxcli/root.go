@@ -8,6 +8,7 @@
 	"fmt"
 	"io"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -17,6 +18,10 @@
 	"go.jetify.com/devbox/internal/boxcli/midcobra"
 	"go.jetify.com/devbox/internal/cmdutil"
 	"go.jetify.com/devbox/internal/debug"
+	"go.jetify.com/devbox/internal/envir"
+	"go.jetify.com/devbox/internal/httpclient"
+	"go.jetify.com/devbox/internal/nix"
+	"go.jetify.com/devbox/internal/projectlock"
 	"go.jetify.com/devbox/internal/telemetry"
 	"go.jetify.com/devbox/internal/vercheck"
 )
@@ -58,29 +63,62 @@ func RootCmd() *cobra.Command {
 	if featureflag.Auth.Enabled() {
 		command.AddCommand(authCmd())
 	}
+	command.AddCommand(bundleCmd())
 	command.AddCommand(cacheCmd())
+	command.AddCommand(ciCmd())
+	command.AddCommand(configCmd())
 	command.AddCommand(createCmd())
+	command.AddCommand(direnvGroupCmd())
 	command.AddCommand(secretsCmd())
+	command.AddCommand(execCmd())
+	command.AddCommand(explainCmd())
+	command.AddCommand(exportCmd())
+	command.AddCommand(fetchCmd())
 	command.AddCommand(generateCmd())
 	command.AddCommand(globalCmd())
+	command.AddCommand(historyCmd())
+	command.AddCommand(hookCmd())
 	command.AddCommand(infoCmd())
 	command.AddCommand(initCmd())
+	command.AddCommand(insecureCmd())
 	command.AddCommand(installCmd())
 	command.AddCommand(integrateCmd())
+	command.AddCommand(javaCmd())
 	command.AddCommand(listCmd())
+	command.AddCommand(lockCmd())
 	command.AddCommand(logCmd())
+	command.AddCommand(logsCmd())
+	command.AddCommand(metricsCmd())
+	command.AddCommand(nixCmd())
 	command.AddCommand(patchCmd())
+	command.AddCommand(pinCmd())
+	command.AddCommand(pluginCmd())
+	command.AddCommand(promptCmd())
+	command.AddCommand(pythonCmd())
 	command.AddCommand(removeCmd())
 	command.AddCommand(runCmd(runFlagDefaults{}))
 	command.AddCommand(searchCmd())
+	command.AddCommand(serveCmd())
 	command.AddCommand(servicesCmd())
 	command.AddCommand(setupCmd())
 	command.AddCommand(shellCmd(shellFlagDefaults{}))
 	command.AddCommand(shellEnvCmd(shellenvFlagDefaults{
 		recomputeEnv: true,
 	}))
+	command.AddCommand(snapshotCmd())
+	command.AddCommand(stateCmd())
+	command.AddCommand(statusCmd())
+	command.AddCommand(testCmd())
+	command.AddCommand(trustCmd())
+	command.AddCommand(uiCmd())
+	command.AddCommand(unbundleCmd())
+	command.AddCommand(uninstallCmd())
+	command.AddCommand(unpinCmd())
+	command.AddCommand(untrustCmd())
 	command.AddCommand(updateCmd())
+	command.AddCommand(verifyCmd())
 	command.AddCommand(versionCmd())
+	command.AddCommand(vmCmd())
 	// Internal commands
 	command.AddCommand(genDocsCmd())
 
@@ -97,6 +135,83 @@ func RootCmd() *cobra.Command {
 
 	command.PersistentFlags().BoolVarP(
 		&flags.quiet, "quiet", "q", false, "suppresses logs")
+	command.PersistentFlags().Func(
+		"network-timeout",
+		"maximum time a network request may take, including retries (e.g. 2m). Defaults to no limit",
+		func(value string) error {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return err
+			}
+			httpclient.SetTimeout(d)
+			return nil
+		},
+	)
+	command.PersistentFlags().Func(
+		"lock-timeout",
+		"maximum time to wait for another devbox command to finish with the project (e.g. 30s). Defaults to no limit",
+		func(value string) error {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return err
+			}
+			projectlock.SetTimeout(d)
+			return nil
+		},
+	)
+	command.PersistentFlags().BoolFunc(
+		"locked",
+		"fail instead of changing devbox.lock or devbox.json, such as in CI. Same as setting "+envir.DevboxLocked+"=1",
+		func(value string) error {
+			locked, err := strconv.ParseBool(value)
+			if err != nil {
+				return err
+			}
+			if !locked {
+				return os.Unsetenv(envir.DevboxLocked)
+			}
+			return os.Setenv(envir.DevboxLocked, "1")
+		},
+	)
+	command.PersistentFlags().BoolFunc(
+		"no-trust-prompt",
+		"run the init hooks, scripts and services of projects that you didn't trust, such as in CI. "+
+			"Same as setting "+envir.DevboxNoTrustPrompt+"=1",
+		func(value string) error {
+			disabled, err := strconv.ParseBool(value)
+			if err != nil {
+				return err
+			}
+			if !disabled {
+				return os.Unsetenv(envir.DevboxNoTrustPrompt)
+			}
+			return os.Setenv(envir.DevboxNoTrustPrompt, "1")
+		},
+	)
+	command.PersistentFlags().Func(
+		"nix-timeout",
+		"maximum time each nix command may run before it's interrupted (e.g. 30m). Defaults to no limit",
+		func(value string) error {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return err
+			}
+			nix.SetTimeout(d)
+			return nil
+		},
+	)
+	command.PersistentFlags().Func(
+		"suggest",
+		"maximum number of similar package names to suggest when a package isn't found, or 0 to disable "+
+			"suggestions. Same as setting "+envir.DevboxSuggest,
+		func(value string) error {
+			limit, err := strconv.Atoi(value)
+			if err != nil || limit < 0 {
+				return fmt.Errorf("invalid number of suggestions %q", value)
+			}
+			return os.Setenv(envir.DevboxSuggest, value)
+		},
+	)
 	debugMiddleware.AttachToFlag(command.PersistentFlags(), "debug")
 	traceMiddleware.AttachToFlag(command.PersistentFlags(), "trace")
 
@@ -110,12 +225,18 @@ func Execute(ctx context.Context, args []string) int {
 	exe.AddMiddleware(traceMiddleware)
 	exe.AddMiddleware(midcobra.Telemetry())
 	exe.AddMiddleware(debugMiddleware)
-	return exe.Execute(ctx, wrapArgsForRun(rootCmd, args))
+	args = wrapArgsForRun(rootCmd, args)
+	// Project locks say which command holds them, without its arguments.
+	if subcmd, _, err := rootCmd.Find(args); err == nil {
+		projectlock.SetCommand(subcmd.CommandPath())
+	}
+	return exe.Execute(ctx, args)
 }
 
 func Main() {
 	timer := debug.Timer(strings.Join(os.Args, " "))
 	setSystemBinaryPaths()
+	httpclient.InstallDefault()
 	ctx := context.Background()
 
 	if len(os.Args) > 1 && os.Args[1] == "upload-telemetry" {
@@ -26,12 +26,21 @@
 
 type runCmdFlags struct {
 	envFlag
-	config       configFlags
-	omitNixEnv   bool
-	pure         bool
-	listScripts  bool
-	recomputeEnv bool
-	allProjects  bool
+	config        configFlags
+	omitNixEnv    bool
+	pure          bool
+	listScripts   bool
+	json          bool
+	recomputeEnv  bool
+	allProjects   bool
+	cacheDirs     bool
+	sandbox       bool
+	network       string
+	inContainer   bool
+	image         string
+	on            string
+	installDevbox bool
+	unsetEnv      []string
 }
 
 // runFlagDefaults are the flag default values that differ
@@ -46,13 +55,23 @@ func runCmd(defaults runFlagDefaults) *cobra.Command {
 		Use:   "run [<script> | <cmd>]",
 		Short: "Run a script or command in a shell with access to your packages",
 		Long: "Start a new shell and runs your script or command in it, exiting when done.\n\n" +
-			"The script must be defined in `devbox.json`, or else it will be interpreted as an " +
+			"The script must be defined in `devbox.json` or be an executable file in " +
+			"`devbox.d/scripts`, or else it will be interpreted as an " +
 			"arbitrary command. You can pass arguments to your script or command. Everything " +
-			"after `--` will be passed verbatim into your command (see examples).\n\n",
+			"after `--` will be passed verbatim into your command (see examples). Scripts get " +
+			"their arguments as positional parameters, \"$@\", and scripts with named \"params\" " +
+			"also get them as variables. Scripts run in sh, unless they set an \"interpreter\", " +
+			"such as python, or start with a shebang. Comments at the top of a script file can " +
+			"set its \"description\" and the \"deps\" that run before it.\n\n",
 		Example: "\nRun a command directly:\n\n  devbox add cowsay\n  devbox run cowsay hello\n  " +
 			"devbox run -- cowsay -d hello\n\nRun a script (defined as `\"moo\": \"cowsay moo\"`) " +
-			"in your devbox.json:\n\n  devbox run moo",
-		PreRunE: ensureNixInstalled,
+			"in your devbox.json:\n\n  devbox run moo\n\n" +
+			"Pass arguments to a script (defined as `\"test\": \"go test \\\"$@\\\" ./...\"`):\n\n" +
+			"  devbox run test -- -run TestFoo\n\n" +
+			"Override environment variables for one run:\n\n  devbox run --env DEBUG=1 --unset-env CI test\n\n" +
+			"List the scripts with their descriptions, leaving out \"hidden\" ones:\n\n  devbox run\n  devbox run --json",
+		PreRunE:           ensureNixInstalled,
+		ValidArgsFunction: completeScripts(&flags.config),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return runScriptCmd(cmd, args, flags)
 		},
@@ -60,10 +79,14 @@ func runCmd(defaults runFlagDefaults) *cobra.Command {
 
 	flags.envFlag.register(command)
 	flags.config.register(command)
+	command.Flags().StringSliceVar(
+		&flags.unsetEnv, "unset-env", nil,
+		"environment variables to remove from the devbox environment for this command, after --env is applied")
 	command.Flags().BoolVar(
 		&flags.pure, "pure", false, "if this flag is specified, devbox runs the script in an isolated environment inheriting almost no variables from the current environment. A few variables, in particular HOME, USER and DISPLAY, are retained.")
 	command.Flags().BoolVarP(
-		&flags.listScripts, "list", "l", false, "list all scripts defined in devbox.json")
+		&flags.listScripts, "list", "l", false, "list all scripts defined in devbox.json, devbox.d/scripts and plugins")
+	command.Flags().BoolVar(&flags.json, "json", false, "print the list of scripts as JSON")
 	command.Flags().BoolVar(
 		&flags.omitNixEnv, "omit-nix-env", defaults.omitNixEnv,
 		"shell environment will omit the env-vars from print-dev-env",
@@ -77,6 +100,39 @@ func runCmd(defaults runFlagDefaults) *cobra.Command {
 		"run command in all projects in the working directory, recursively. If command is not found in any project, it will be skipped.",
 	)
 
+	command.Flags().BoolVar(
+		&flags.sandbox, "sandbox", false,
+		"run the command in a sandbox that denies network access and only allows writes to the project directory. "+
+			"It uses bubblewrap on Linux and sandbox-exec on macOS.")
+	command.Flags().StringVar(
+		&flags.network, "network", "",
+		"network access for a sandboxed command: none or host. Setting it runs the command in a sandbox.")
+	command.Flags().BoolVar(
+		&flags.inContainer, "in-container", false,
+		"run the command in an ephemeral docker or podman container with the project directory and its "+
+			"nix packages. Linux only.")
+	command.Flags().StringVar(
+		&flags.image, "container-image", "",
+		"image for --in-container. Defaults to a small image with a shell.")
+	command.MarkFlagsMutuallyExclusive("in-container", "sandbox")
+	command.MarkFlagsMutuallyExclusive("in-container", "network")
+	command.Flags().StringVar(
+		&flags.on, "on", "",
+		"run the command on a remote machine, such as ssh://user@host, or on the project's VM with --on vm. The project is copied to the machine "+
+			"with rsync, skipping the files that git ignores, and the machine installs the project's packages.")
+	command.MarkFlagsMutuallyExclusive("on", "sandbox")
+	command.MarkFlagsMutuallyExclusive("on", "network")
+	command.MarkFlagsMutuallyExclusive("on", "in-container")
+	command.Flags().BoolVar(
+		&flags.installDevbox, "install-devbox", false,
+		"with --on, install Devbox on the remote machine without asking if it isn't installed. "+
+			"This runs the install script from https://get.jetify.com/devbox there.")
+	command.Flags().BoolVar(
+		&flags.cacheDirs, "cache-dirs", false,
+		"print the directories where the project's toolchains cache downloads and builds, one per line. "+
+			"CI systems can cache them between runs.",
+	)
+
 	command.ValidArgs = listScripts(command, flags)
 
 	return command
@@ -125,21 +181,102 @@ func listScripts(cmd *cobra.Command, flags runCmdFlags) []string {
 	return box.ListScripts()
 }
 
-func runScriptCmd(cmd *cobra.Command, args []string, flags runCmdFlags) error {
-	ctx := cmd.Context()
-	if len(args) == 0 || flags.listScripts {
-		scripts := listScripts(cmd, flags)
-		if len(scripts) == 0 {
-			fmt.Fprintln(cmd.OutOrStdout(), "no scripts defined in devbox.json")
-			return nil
+// printScriptCatalog prints the scripts that devbox run can run, grouped by
+// the config that defines them, with their parameters and descriptions.
+func printScriptCatalog(cmd *cobra.Command, flags runCmdFlags) error {
+	devboxOpts := &devopt.Opts{
+		Dir:            flags.config.path,
+		Environment:    flags.config.environment,
+		Stderr:         cmd.ErrOrStderr(),
+		IgnoreWarnings: true,
+	}
+	boxes := []*devbox.Devbox{}
+	if flags.allProjects {
+		var err error
+		if boxes, err = multi.Open(devboxOpts); err != nil {
+			return errors.WithStack(err)
+		}
+	} else {
+		box, err := devbox.Open(devboxOpts)
+		if err != nil {
+			return redact.Errorf("error reading devbox.json: %w", err)
 		}
-		fmt.Fprintln(cmd.OutOrStdout(), "Available scripts:")
-		for _, p := range scripts {
-			fmt.Fprintf(cmd.OutOrStdout(), "* %s\n", p)
+		boxes = append(boxes, box)
+	}
+
+	// A script that several projects define is listed once.
+	catalog := []devbox.ScriptInfo{}
+	seen := map[string]bool{}
+	for _, box := range boxes {
+		for _, script := range box.ScriptCatalog() {
+			if !seen[script.Name] {
+				seen[script.Name] = true
+				catalog = append(catalog, script)
+			}
 		}
+	}
+	if flags.json {
+		return printJSON(cmd.OutOrStdout(), catalog)
+	}
+	if len(catalog) == 0 {
+		fmt.Fprintln(cmd.OutOrStdout(), "no scripts defined in devbox.json, devbox.d/scripts or plugins")
 		return nil
 	}
 
+	// Align the columns across groups, so that the list reads as one table.
+	nameWidth, usageWidth := 0, 0
+	for _, script := range catalog {
+		nameWidth = max(nameWidth, len(script.Name))
+		usageWidth = max(usageWidth, len(script.Usage))
+	}
+	w := cmd.OutOrStdout()
+	source := ""
+	for _, script := range catalog {
+		if script.Source != source {
+			if source != "" {
+				fmt.Fprintln(w)
+			}
+			source = script.Source
+			fmt.Fprintf(w, "Scripts from %s:\n", source)
+		}
+		line := fmt.Sprintf("  %-*s", nameWidth, script.Name)
+		if usageWidth > 0 {
+			line += fmt.Sprintf("  %-*s", usageWidth, script.Usage)
+		}
+		line += "  " + script.Description
+		fmt.Fprintln(w, strings.TrimRight(line, " "))
+	}
+	return nil
+}
+
+func printCacheDirs(cmd *cobra.Command, flags runCmdFlags) error {
+	box, err := devbox.Open(&devopt.Opts{
+		Dir:         flags.config.path,
+		Environment: flags.config.environment,
+		Stderr:      cmd.ErrOrStderr(),
+	})
+	if err != nil {
+		return err
+	}
+	dirs, err := box.CacheDirs(cmd.Context())
+	if err != nil {
+		return err
+	}
+	for _, dir := range dirs {
+		fmt.Fprintln(cmd.OutOrStdout(), dir)
+	}
+	return nil
+}
+
+func runScriptCmd(cmd *cobra.Command, args []string, flags runCmdFlags) error {
+	ctx := cmd.Context()
+	if flags.cacheDirs {
+		return printCacheDirs(cmd, flags)
+	}
+	if len(args) == 0 || flags.listScripts {
+		return printScriptCatalog(cmd, flags)
+	}
+
 	path, script, scriptArgs, err := parseScriptArgs(args, flags)
 	if err != nil {
 		return redact.Errorf("error parsing script arguments: %w", err)
@@ -155,6 +292,7 @@ func runScriptCmd(cmd *cobra.Command, args []string, flags runCmdFlags) error {
 	devboxOpts := &devopt.Opts{
 		Dir:         path,
 		Env:         env,
+		UnsetEnv:    flags.unsetEnv,
 		Environment: flags.config.environment,
 		Stderr:      cmd.ErrOrStderr(),
 	}
@@ -188,6 +326,14 @@ func runScriptCmd(cmd *cobra.Command, args []string, flags runCmdFlags) error {
 		OmitNixEnv:    flags.omitNixEnv,
 		Pure:          flags.pure,
 		SkipRecompute: !flags.recomputeEnv,
+		Sandbox: devopt.Sandbox{
+			Enabled: flags.sandbox,
+			Network: flags.network,
+		},
+		Container: devopt.Container{
+			Enabled: flags.inContainer,
+			Image:   flags.image,
+		},
 	}
 
 	if flags.allProjects {
@@ -203,6 +349,15 @@ func runScriptCmd(cmd *cobra.Command, args []string, flags runCmdFlags) error {
 			script,
 			box.ProjectDir(),
 		)
+		if flags.on != "" {
+			if err := box.RunRemote(ctx, devopt.RemoteOpts{
+				Target:        flags.on,
+				InstallDevbox: flags.installDevbox,
+			}, envOpts, script, scriptArgs); err != nil {
+				return redact.Errorf("error running script %q on %s: %w", script, flags.on, err)
+			}
+			continue
+		}
 		if err := box.RunScript(ctx, envOpts, script, scriptArgs); err != nil {
 			return redact.Errorf("error running script %q in Devbox: %w", script, err)
 		}
@@ -40,7 +40,7 @@ func searchCmd() *cobra.Command {
 				return printSearchResults(
 					cmd.OutOrStdout(), query, results, flags.showAll)
 			}
-			packageVersion, err := searcher.Client().Resolve(name, version)
+			packageVersion, err := searcher.Client().Resolve(cmd.Context(), name, version)
 			if err != nil {
 				// This is not ideal. Search service should return valid response we
 				// can parse
@@ -0,0 +1,107 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package boxcli
+
+import (
+	"encoding/json"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"go.jetify.com/devbox/internal/devbox"
+	"go.jetify.com/devbox/internal/devbox/devopt"
+	"go.jetify.com/devbox/internal/serve"
+	"go.jetify.com/devbox/internal/ux"
+)
+
+type serveCmdFlags struct {
+	config configFlags
+	socket string
+}
+
+func serveCmd() *cobra.Command {
+	flags := serveCmdFlags{}
+	command := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve an API of the project on a local socket",
+		Long: heredoc.Doc(`
+			Serve an API of the project on a Unix socket, for integrations
+			such as web dashboards and IDE plugins that talk to one
+			long-lived process instead of running devbox commands. The API
+			lists, adds and updates packages, computes the environment,
+			runs scripts and starts, stops and restarts services.
+
+			When it's ready, it prints the socket and a token as JSON on
+			stdout. Requests must have the header
+			"Authorization: Bearer <token>", and only your user can connect
+			to the socket. It serves until it's interrupted.
+
+			  GET  /v1/packages            POST /v1/packages {"packages": [...]}
+			  POST /v1/update              GET  /v1/env
+			  POST /v1/run {"script": "test", "args": [...]}
+			  GET  /v1/services            POST /v1/services/{start,stop,restart}
+			  GET  /v1/events
+
+			GET /v1/events streams server-sent events when devbox.json,
+			devbox.lock or a local plugin of the project changes, so that
+			editors can reload the environment.
+		`),
+		Example: "  devbox serve\n" +
+			"  devbox serve --socket /tmp/devbox.sock",
+		Args:    cobra.NoArgs,
+		PreRunE: ensureNixInstalled,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return serveCmdFunc(cmd, flags)
+		},
+	}
+	flags.config.register(command)
+	command.Flags().StringVar(
+		&flags.socket, "socket", "", "path of the Unix socket. Defaults to .devbox/serve.sock in the project")
+	return command
+}
+
+func serveCmdFunc(cmd *cobra.Command, flags serveCmdFlags) error {
+	opts := devopt.Opts{
+		Dir:         flags.config.path,
+		Environment: flags.config.environment,
+		Stderr:      cmd.ErrOrStderr(),
+	}
+	box, err := devbox.Open(&opts)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	// Later requests open the project from its directory.
+	opts.Dir = box.ProjectDir()
+	socket := flags.socket
+	if socket == "" {
+		socket = filepath.Join(box.ProjectDir(), ".devbox", "serve.sock")
+	}
+	if socket, err = filepath.Abs(socket); err != nil {
+		return errors.WithStack(err)
+	}
+	token, err := serve.NewToken()
+	if err != nil {
+		return err
+	}
+
+	listener, err := serve.Listen(socket)
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ready := map[string]string{"socket": socket, "token": token}
+	if err := json.NewEncoder(cmd.OutOrStdout()).Encode(ready); err != nil {
+		return errors.WithStack(err)
+	}
+	ux.Finfof(cmd.ErrOrStderr(), "Serving the API of %s on %s\n", box.ProjectDir(), socket)
+	return serve.New(opts, token).Serve(ctx, listener)
+}
@@ -18,6 +18,8 @@ type servicesCmdFlags struct {
 
 type serviceUpFlags struct {
 	background          bool
+	detach              bool
+	supervise           bool
 	processComposeFile  string
 	processComposeFlags []string
 	pcport              int
@@ -37,6 +39,14 @@ func (flags *serviceUpFlags) register(cmd *cobra.Command) {
 	)
 	cmd.Flags().BoolVarP(
 		&flags.background, "background", "b", false, "run service in background")
+	cmd.Flags().BoolVarP(
+		&flags.detach, "detach", "d", false,
+		"run services in the background, detached from the terminal so they keep running "+
+			"after it closes, and restart services that crash. Implies --background and --supervise")
+	cmd.Flags().BoolVar(
+		&flags.supervise, "supervise", false,
+		"restart services that exit with an error, after a backoff, unless their process-compose "+
+			"file sets a restart policy")
 	cmd.Flags().StringArrayVar(
 		&flags.processComposeFlags, "pcflags", []string{}, "pass flags directly to process compose")
 	cmd.Flags().IntVarP(
@@ -133,6 +143,41 @@ func servicesCmd(persistentPreRunE ...cobraFunc) *cobra.Command {
 		},
 	}
 
+	enableBootCommand := &cobra.Command{
+		Use:   "enable-boot",
+		Short: "Start the project's services whenever you log in",
+		Long: "Install a systemd user unit on Linux, or a launchd agent on macOS, that " +
+			"starts the project's services now and whenever you log in. Services that " +
+			"crash are restarted, as with `devbox services up --supervise`.",
+		Args: cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return enableServicesBoot(cmd, flags)
+		},
+	}
+
+	disableBootCommand := &cobra.Command{
+		Use:   "disable-boot",
+		Short: "Stop starting the project's services when you log in",
+		Args:  cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return disableServicesBoot(cmd, flags)
+		},
+	}
+
+	watchCommand := &cobra.Command{
+		Use:    "watch",
+		Short:  "Send the project's notifications when a detached service crashes",
+		Hidden: true,
+		Args:   cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return watchServices(cmd, flags)
+		},
+	}
+
+	for _, command := range []*cobra.Command{startCommand, stopCommand, restartCommand, upCommand} {
+		command.ValidArgsFunction = completeServices(&flags.config)
+	}
+
 	flags.envFlag.register(servicesCommand)
 	flags.config.registerPersistent(servicesCommand)
 	servicesCommand.PersistentFlags().BoolVar(
@@ -145,12 +190,15 @@ func servicesCmd(persistentPreRunE ...cobraFunc) *cobra.Command {
 	serviceUpFlags.register(upCommand)
 	serviceStopFlags.register(stopCommand)
 	servicesCommand.AddCommand(attachCommand)
+	servicesCommand.AddCommand(disableBootCommand)
+	servicesCommand.AddCommand(enableBootCommand)
 	servicesCommand.AddCommand(lsCommand)
 	servicesCommand.AddCommand(upCommand)
 	servicesCommand.AddCommand(restartCommand)
 	servicesCommand.AddCommand(startCommand)
 	servicesCommand.AddCommand(stopCommand)
 	servicesCommand.AddCommand(pcportCommand)
+	servicesCommand.AddCommand(watchCommand)
 	return servicesCommand
 }
 
@@ -167,6 +215,32 @@ func attachServices(cmd *cobra.Command, flags servicesCmdFlags) error {
 	return box.AttachToProcessManager(cmd.Context())
 }
 
+func enableServicesBoot(cmd *cobra.Command, flags servicesCmdFlags) error {
+	box, err := devbox.Open(&devopt.Opts{
+		Dir:         flags.config.path,
+		Environment: flags.config.environment,
+		Stderr:      cmd.ErrOrStderr(),
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return box.EnableServicesBoot(cmd.Context())
+}
+
+func disableServicesBoot(cmd *cobra.Command, flags servicesCmdFlags) error {
+	box, err := devbox.Open(&devopt.Opts{
+		Dir:         flags.config.path,
+		Environment: flags.config.environment,
+		Stderr:      cmd.ErrOrStderr(),
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return box.DisableServicesBoot(cmd.Context())
+}
+
 func listServices(cmd *cobra.Command, flags servicesCmdFlags) error {
 	box, err := devbox.Open(&devopt.Opts{
 		Dir:         flags.config.path,
@@ -279,6 +353,8 @@ func startProcessManager(
 		args,
 		devopt.ProcessComposeOpts{
 			Background:         flags.background,
+			Detach:             flags.detach,
+			Supervise:          flags.supervise,
 			ExtraFlags:         flags.processComposeFlags,
 			ProcessComposePort: flags.pcport,
 		},
@@ -297,3 +373,16 @@ func showProcessComposePort(cmd *cobra.Command, flags servicesCmdFlags) error {
 
 	return box.ShowProcessComposePort(cmd.Context(), cmd.OutOrStdout())
 }
+
+func watchServices(cmd *cobra.Command, flags servicesCmdFlags) error {
+	box, err := devbox.Open(&devopt.Opts{
+		Dir:         flags.config.path,
+		Environment: flags.config.environment,
+		Stderr:      cmd.ErrOrStderr(),
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return box.WatchServices(cmd.Context())
+}
@@ -4,22 +4,60 @@
 package boxcli
 
 import (
+	"context"
+	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"regexp"
 
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/mattn/go-isatty"
+	"github.com/pkg/errors"
 	"github.com/samber/lo"
 	"github.com/spf13/cobra"
+
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+	"go.jetify.com/devbox/internal/devbox"
+	"go.jetify.com/devbox/internal/envir"
 	"go.jetify.com/devbox/internal/nix"
+	"go.jetify.com/devbox/internal/shellrc"
+	"go.jetify.com/devbox/internal/telemetry"
 	"go.jetify.com/devbox/internal/ux"
 )
 
 const nixDaemonFlag = "daemon"
 
+// globalHookRegexp matches the line of a shell rcfile that loads devbox global.
+var globalHookRegexp = regexp.MustCompile(`\bdevbox\s+global\s+shellenv\b`)
+
+type setupCmdFlags struct {
+	yes bool
+}
+
 func setupCmd() *cobra.Command {
+	flags := setupCmdFlags{}
 	setupCommand := &cobra.Command{
-		Use:    "setup",
-		Short:  "Setup devbox dependencies",
-		Hidden: true,
+		Use:   "setup",
+		Short: "Set up devbox on this machine",
+		Long: heredoc.Doc(`
+			Walk through setting up devbox on this machine: installing Nix,
+			loading devbox global in your shell, choosing whether to send
+			telemetry, creating a devbox global profile, and creating a
+			devbox.json in the current directory.
+
+			Steps that are already done are skipped, so it's safe to run
+			devbox setup again. With --yes, it takes the recommended answer
+			to each question and leaves the telemetry setting as it is.
+		`),
+		Args: cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSetupCmd(cmd, flags)
+		},
 	}
+	setupCommand.Flags().BoolVarP(
+		&flags.yes, "yes", "y", false, "take the recommended answer to each question without asking")
 
 	installNixCommand := &cobra.Command{
 		Use:   "nix",
@@ -51,6 +89,182 @@ func runInstallNixCmd(cmd *cobra.Command) error {
 	return new(nix.Installer).Run(cmd.Context())
 }
 
+// setupAsker asks a yes or no question of the user, or returns the
+// recommended answer with --yes.
+type setupAsker func(question string, recommended bool) (bool, error)
+
+func runSetupCmd(cmd *cobra.Command, flags setupCmdFlags) error {
+	w := cmd.ErrOrStderr()
+	if !flags.yes && (!isatty.IsTerminal(os.Stdin.Fd()) || !isatty.IsTerminal(os.Stdout.Fd())) {
+		return usererr.New(
+			"devbox setup asks questions, so it needs an interactive terminal. " +
+				"Run devbox setup --yes to take the recommended answers.")
+	}
+	ask := func(question string, recommended bool) (bool, error) {
+		if flags.yes {
+			return recommended, nil
+		}
+		answer := recommended
+		prompt := &survey.Confirm{Message: question, Default: recommended}
+		err := survey.AskOne(prompt, &answer)
+		return answer, errors.WithStack(err)
+	}
+
+	steps := []struct {
+		title string
+		run   func() error
+	}{
+		{"Nix", func() error { return setupNix(cmd.Context(), w, ask) }},
+		{"Shell", func() error { return setupShellHook(w, ask) }},
+		{"Telemetry", func() error { return setupTelemetry(w, ask, flags.yes) }},
+		{"Global profile", func() error { return setupGlobalProfile(w, ask) }},
+		{"Project", func() error { return setupProject(w, ask) }},
+	}
+	for i, step := range steps {
+		fmt.Fprintf(w, "\n[%d/%d] %s\n", i+1, len(steps), step.title)
+		if err := step.run(); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintln(w)
+	ux.Fsuccessf(w, "Devbox is set up. Run `devbox shell` in a project to start a dev shell.\n")
+	return nil
+}
+
+func setupNix(ctx context.Context, w io.Writer, ask setupAsker) error {
+	if nix.BinaryInstalled() {
+		if !nix.AtLeast(nix.MinVersion) {
+			return usererr.New(
+				"Devbox requires nix of version >= %s. Your version is %s. "+
+					"Upgrade nix and run devbox setup again.",
+				nix.MinVersion, nix.Version())
+		}
+		fmt.Fprintf(w, "Nix %s is installed.\n", nix.Version())
+		return nil
+	}
+	install, err := ask("Nix isn't installed. Install it now?", true)
+	if err != nil {
+		return err
+	}
+	if !install {
+		fmt.Fprintln(w, "Skipped. Devbox installs Nix the first time that a command needs it.")
+		return nil
+	}
+	return nix.Install(ctx, w)
+}
+
+func setupShellHook(w io.Writer, ask setupAsker) error {
+	shell := os.Getenv(envir.Shell)
+	rcfile := shellrc.ForShell(shell)
+	if rcfile == "" {
+		fmt.Fprintf(w, "Devbox can't load devbox global in %s automatically. "+
+			"See `devbox global --help` to load it in your shell.\n", filepath.Base(shell))
+		return nil
+	}
+	if shellrc.Contains(rcfile, globalHookRegexp) {
+		fmt.Fprintf(w, "%s already loads devbox global.\n", rcfile)
+		return nil
+	}
+
+	hook := `eval "$(devbox global shellenv)"`
+	if filepath.Base(shell) == "fish" {
+		hook = "devbox global shellenv | source"
+	}
+	add, err := ask(fmt.Sprintf("Add `%s` to %s, so that devbox global packages are in every shell?", hook, rcfile), true)
+	if err != nil {
+		return err
+	}
+	if !add {
+		fmt.Fprintln(w, "Skipped.")
+		return nil
+	}
+	if err := shellrc.Append(rcfile, "", "# Load the packages of devbox global.", hook); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Updated %s. Restart your shell to load devbox global.\n", rcfile)
+	return nil
+}
+
+func setupTelemetry(w io.Writer, ask setupAsker, yes bool) error {
+	if envir.DoNotTrack() {
+		fmt.Fprintln(w, "DO_NOT_TRACK is set, so devbox doesn't send telemetry.")
+		return nil
+	}
+	enabled, decided := telemetry.Consent()
+	if yes {
+		fmt.Fprintln(w, "Skipped, because only you can decide whether devbox sends telemetry.")
+		return nil
+	}
+	enabled, err := ask("Send anonymous usage data and crash reports to help improve devbox?", enabled || !decided)
+	if err != nil {
+		return err
+	}
+	if err := telemetry.SetConsent(enabled); err != nil {
+		return err
+	}
+	if enabled {
+		fmt.Fprintln(w, "Devbox sends anonymous usage data and crash reports. Thank you!")
+	} else {
+		fmt.Fprintln(w, "Devbox doesn't send telemetry.")
+	}
+	return nil
+}
+
+func setupGlobalProfile(w io.Writer, ask setupAsker) error {
+	path, err := devbox.GlobalDataPath()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(filepath.Join(path, "devbox.json")); err == nil {
+		fmt.Fprintf(w, "The devbox global profile is in %s.\n", path)
+		return nil
+	}
+	create, err := ask("Create a devbox global profile, for the packages that you want in every shell?", true)
+	if err != nil {
+		return err
+	}
+	if !create {
+		fmt.Fprintln(w, "Skipped.")
+		return nil
+	}
+	if _, err := ensureGlobalConfig(); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Created the devbox global profile in %s. Add packages to it with `devbox global add <package>`.\n", path)
+	return nil
+}
+
+func setupProject(w io.Writer, ask setupAsker) error {
+	dir, err := os.Getwd()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "devbox.json")); err == nil {
+		fmt.Fprintf(w, "%s is a devbox project.\n", dir)
+		return nil
+	}
+	// Only recommend a project in a repository, not in the home directory
+	// or wherever devbox setup happens to run.
+	_, err = os.Stat(filepath.Join(dir, ".git"))
+	isRepo := err == nil
+	create, err := ask(fmt.Sprintf("Create a devbox.json in %s?", dir), isRepo)
+	if err != nil {
+		return err
+	}
+	if !create {
+		fmt.Fprintln(w, "Skipped. Run `devbox init` in a project to create its devbox.json.")
+		return nil
+	}
+	if err := devbox.InitConfig(dir); err != nil {
+		return err
+	}
+	if err := trustNewProject(w, dir); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Created devbox.json in %s. Add packages to it with `devbox add <package>`.\n", dir)
+	return nil
+}
+
 // ensureNixInstalled verifies that nix is installed and that it is of a supported version
 func ensureNixInstalled(cmd *cobra.Command, _args []string) error {
 	return nix.EnsureNixInstalled(cmd.Context(), cmd.ErrOrStderr(), nixDaemonFlagVal(cmd))
@@ -4,7 +4,13 @@
 package boxcli
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
@@ -19,10 +25,16 @@
 type shellCmdFlags struct {
 	envFlag
 	config       configFlags
+	addProjects  []string
+	explain      bool
+	json         bool
+	layer        bool
 	omitNixEnv   bool
+	pkgs         []string
 	printEnv     bool
 	pure         bool
 	recomputeEnv bool
+	tmux         bool
 }
 
 // shellFlagDefaults are the flag default values that differ
@@ -38,9 +50,34 @@ func shellCmd(defaults shellFlagDefaults) *cobra.Command {
 		Short: "Start a new shell with access to your packages",
 		Long: "Start a new shell with access to your packages.\n\n" +
 			"If the --config flag is set, the shell will be started using the devbox.json found in the --config flag directory. " +
-			"If --config isn't set, then devbox recursively searches the current directory and its parents.",
-		Args:    cobra.NoArgs,
-		PreRunE: ensureNixInstalled,
+			"If --config isn't set, then devbox recursively searches the current directory and its parents.\n\n" +
+			"Starting a shell for another project from inside a devbox shell replaces the environment of the " +
+			"current project with the new one, unless --layer is set. Run `exit` to return to the previous shell.\n\n" +
+			"The --add-project flag and add_projects in devbox.json layer the environments of other projects, " +
+			"such as a repository of shared tools, under the project's environment. The project's packages and " +
+			"variables take precedence over those of added projects, and earlier added projects take precedence " +
+			"over later ones. Projects added with --add-project come before the ones in devbox.json.\n\n" +
+			"The --pkg flag starts an ad hoc shell with the given packages instead of a project's shell. " +
+			"The packages are resolved to the latest nixpkgs and fetched to the nix store, but they aren't " +
+			"added to any devbox.json or devbox.lock.\n\n" +
+			"The --tmux flag starts the shell in a tmux session for the project, with a window that follows " +
+			"the logs of each of the project's services, which are started in the background if they aren't " +
+			"running. Running `devbox shell --tmux` again attaches to the existing session.\n\n" +
+			"The --explain flag prints what starting the shell would do, without doing it: the packages it " +
+			"installs, the files that plugins create, the init hooks it runs, the variables and aliases it sets, " +
+			"and the services that the project defines. Use it to review a project before you trust it.",
+		Example: "  devbox shell\n" +
+			"  devbox shell --pkg python@3.12 --pkg curl\n" +
+			"  devbox shell --tmux\n" +
+			"  devbox shell --explain",
+		Args: cobra.NoArgs,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			// Explaining the shell doesn't run nix.
+			if flags.explain {
+				return nil
+			}
+			return ensureNixInstalled(cmd, args)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return runShellCmd(cmd, flags)
 		},
@@ -56,6 +93,29 @@ func shellCmd(defaults shellFlagDefaults) *cobra.Command {
 	)
 	_ = command.Flags().MarkHidden("omit-nix-env")
 	command.Flags().BoolVar(&flags.recomputeEnv, "recompute", true, "recompute environment if needed")
+	command.Flags().StringArrayVar(
+		&flags.addProjects, "add-project", nil,
+		"directory of another devbox project whose environment is layered under this one's. Can be repeated")
+	command.Flags().BoolVar(
+		&flags.layer, "layer", false,
+		"when started from another project's devbox shell, keep that project's environment under this one's")
+	command.Flags().StringArrayVar(
+		&flags.pkgs, "pkg", nil,
+		"package to start an ad hoc shell with, without a project. Can be repeated")
+	command.Flags().BoolVar(
+		&flags.tmux, "tmux", false,
+		"start the shell in a tmux session with a window for the logs of each service")
+	command.Flags().BoolVar(
+		&flags.explain, "explain", false,
+		"print the packages, plugin files, init hooks, variables and services of the shell without starting it")
+	command.Flags().BoolVar(&flags.json, "json", false, "with --explain, print the explanation as JSON")
+	command.MarkFlagsMutuallyExclusive("pkg", "add-project")
+	command.MarkFlagsMutuallyExclusive("pkg", "print-env")
+	command.MarkFlagsMutuallyExclusive("tmux", "pkg")
+	command.MarkFlagsMutuallyExclusive("tmux", "print-env")
+	command.MarkFlagsMutuallyExclusive("explain", "pkg")
+	command.MarkFlagsMutuallyExclusive("explain", "print-env")
+	command.MarkFlagsMutuallyExclusive("explain", "tmux")
 
 	flags.config.register(command)
 	flags.envFlag.register(command)
@@ -69,17 +129,36 @@ func runShellCmd(cmd *cobra.Command, flags shellCmdFlags) error {
 		return err
 	}
 
+	if len(flags.pkgs) > 0 {
+		return devbox.ShellPackages(ctx, cmd.ErrOrStderr(), flags.pkgs, env)
+	}
+
 	// Check the directory exists.
 	box, err := devbox.Open(&devopt.Opts{
 		Dir:         flags.config.path,
 		Env:         env,
 		Environment: flags.config.environment,
 		Stderr:      cmd.ErrOrStderr(),
+		AddProjects: flags.addProjects,
 	})
 	if err != nil {
 		return errors.WithStack(err)
 	}
 
+	if flags.explain {
+		preview, err := box.ExplainShell()
+		if err != nil {
+			return err
+		}
+		if flags.json {
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return errors.WithStack(enc.Encode(preview))
+		}
+		printShellPreview(cmd.OutOrStdout(), preview)
+		return nil
+	}
+
 	if flags.printEnv {
 		// false for includeHooks is because init hooks is not compatible with .envrc files generated
 		// by versions older than 0.4.6
@@ -92,10 +171,18 @@ func runShellCmd(cmd *cobra.Command, flags shellCmdFlags) error {
 		return nil // return here to prevent opening a devbox shell
 	}
 
-	if envir.IsDevboxShellEnabled() {
+	if envir.IsDevboxShellEnabled() && os.Getenv("DEVBOX_PROJECT_ROOT") == box.ProjectDir() {
 		return shellInceptionErrorMsg("devbox shell")
 	}
 
+	if flags.tmux {
+		shellCommand, err := tmuxShellCommand()
+		if err != nil {
+			return err
+		}
+		return box.ShellInTmux(ctx, shellCommand)
+	}
+
 	return box.Shell(ctx, devopt.EnvOptions{
 		Hooks: devopt.LifecycleHooks{
 			OnStaleState: func() {
@@ -109,13 +196,91 @@ func runShellCmd(cmd *cobra.Command, flags shellCmdFlags) error {
 				}
 			},
 		},
-		OmitNixEnv:    flags.omitNixEnv,
-		Pure:          flags.pure,
-		SkipRecompute: !flags.recomputeEnv,
+		OmitNixEnv:      flags.omitNixEnv,
+		Pure:            flags.pure,
+		ReplaceShellEnv: !flags.layer,
+		SkipRecompute:   !flags.recomputeEnv,
 	})
 }
 
 func shellInceptionErrorMsg(cmdPath string) error {
-	return usererr.New("You are already in an active %[1]s.\nRun `exit` before calling `%[1]s` again."+
-		" Shell inception is not supported.", cmdPath)
+	return usererr.New("You are already in an active %[1]s for this project.\n"+
+		"Run `exit` before calling `%[1]s` again, or start a shell for another project with --config.", cmdPath)
+}
+
+// tmuxShellCommand returns the command that starts the devbox shell in the
+// tmux session: the command line of this devbox shell, without --tmux.
+func tmuxShellCommand() ([]string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	command := []string{exe}
+	for _, arg := range os.Args[1:] {
+		if arg != "--tmux" && !strings.HasPrefix(arg, "--tmux=") {
+			command = append(command, arg)
+		}
+	}
+	return command, nil
+}
+
+// printShellPreview prints what starting a devbox shell would do, grouped by
+// kind and labeled with the config or plugin that each item comes from.
+func printShellPreview(w io.Writer, preview *devbox.ShellPreview) {
+	fmt.Fprintf(w, "Starting a devbox shell in %s would:\n", preview.ProjectDir)
+
+	if len(preview.Packages) > 0 {
+		fmt.Fprintln(w, "\nInstall these packages:")
+		for _, pkg := range preview.Packages {
+			fmt.Fprintf(w, "  %s\n", pkg)
+		}
+	}
+	if len(preview.CreateFiles) > 0 {
+		fmt.Fprintln(w, "\nCreate these files:")
+		for _, file := range preview.CreateFiles {
+			fmt.Fprintf(w, "  %s (%s)\n", file.Path, file.Source)
+		}
+	}
+	if len(preview.InitHooks) > 0 {
+		fmt.Fprintln(w, "\nRun these init hooks, in order:")
+		for _, hook := range preview.InitHooks {
+			label := hook.Source
+			if hook.Name != "" {
+				label += ", " + hook.Name
+			}
+			if hook.OnlyOnce {
+				label += ", once per version of devbox.lock"
+			}
+			fmt.Fprintf(w, "  %s:\n", label)
+			for _, line := range strings.Split(hook.Run, "\n") {
+				fmt.Fprintf(w, "    %s\n", line)
+			}
+		}
+	}
+	if len(preview.Env) > 0 || preview.EnvFrom != "" {
+		fmt.Fprintln(w, "\nSet these environment variables:")
+		if preview.EnvFrom != "" {
+			fmt.Fprintf(w, "  the variables in %s (env_from)\n", preview.EnvFrom)
+		}
+		for _, env := range preview.Env {
+			fmt.Fprintf(w, "  %s=%s (%s)\n", env.Name, env.Value, env.Source)
+		}
+	}
+	if len(preview.Aliases) > 0 {
+		fmt.Fprintln(w, "\nDefine these shell aliases:")
+		for _, name := range slices.Sorted(maps.Keys(preview.Aliases)) {
+			fmt.Fprintf(w, "  %s=%s\n", name, preview.Aliases[name])
+		}
+	}
+	if len(preview.Services) > 0 {
+		fmt.Fprintln(w, "\nDefine these services, which devbox services up and devbox shell --tmux start:")
+		for _, svc := range preview.Services {
+			fmt.Fprintf(w, "  %s (%s)\n", svc.Name, svc.Source)
+		}
+	}
+
+	if !preview.Trusted {
+		fmt.Fprintln(w, "\nYou haven't trusted this project with its current config. After you review it, "+
+			"run `devbox trust` to let devbox run its hooks.")
+	}
 }
@@ -25,6 +25,7 @@ type shellEnvCmdFlags struct {
 	recomputeEnv      bool
 	runInitHook       bool
 	format            string
+	github            bool
 }
 
 // shellenvFlagDefaults are the flag default values that differ
@@ -38,10 +39,19 @@ func shellEnvCmd(defaults shellenvFlagDefaults) *cobra.Command {
 	flags := shellEnvCmdFlags{}
 	command := &cobra.Command{
 		Use:     "shellenv",
+		Aliases: []string{"env"},
 		Short:   "Print shell commands that create a Devbox Environment in the shell",
+		Long: "Print shell commands that create a Devbox Environment in the shell.\n\n" +
+			"With --github, the environment is appended to the $GITHUB_ENV and $GITHUB_PATH files of " +
+			"a GitHub Actions step instead, so that the next steps of the job can use the project's " +
+			"packages without devbox run.",
+		Example: "  eval \"$(devbox shellenv)\"\n  devbox shellenv --github",
 		Args:    cobra.ExactArgs(0),
 		PreRunE: ensureNixInstalled,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if flags.github {
+				return shellEnvGitHubFunc(cmd, flags)
+			}
 			s, err := shellEnvFunc(cmd, flags)
 			if err != nil {
 				return err
@@ -87,12 +97,55 @@ func shellEnvCmd(defaults shellenvFlagDefaults) *cobra.Command {
 		"Output format for shell environment (nushell)",
 	)
 
+	command.Flags().BoolVar(
+		&flags.github, "github", false,
+		"append the environment to $GITHUB_ENV and $GITHUB_PATH for the next steps of a GitHub Actions job",
+	)
+	command.MarkFlagsMutuallyExclusive("github", "format")
+	command.MarkFlagsMutuallyExclusive("github", "init-hook")
+
 	flags.config.register(command)
 	flags.envFlag.register(command)
 
 	return command
 }
 
+func shellEnvGitHubFunc(cmd *cobra.Command, flags shellEnvCmdFlags) error {
+	env, err := flags.Env(flags.config.path)
+	if err != nil {
+		return err
+	}
+	ctx := cmd.Context()
+	box, err := devbox.Open(&devopt.Opts{
+		Dir:         flags.config.path,
+		Environment: flags.config.environment,
+		Stderr:      cmd.ErrOrStderr(),
+		Env:         env,
+	})
+	if err != nil {
+		return err
+	}
+	if flags.install {
+		if err := box.Install(ctx); err != nil {
+			return err
+		}
+	}
+	export, err := box.ExportToGitHub(ctx, devopt.EnvOptions{
+		OmitNixEnv:    flags.omitNixEnv,
+		Pure:          flags.pure,
+		SkipRecompute: !flags.recomputeEnv,
+	})
+	if err != nil {
+		return err
+	}
+	ux.Fsuccessf(
+		cmd.ErrOrStderr(),
+		"Exported %d variables and %d PATH entries for the next steps of the job.\n",
+		len(export.Vars), len(export.Paths),
+	)
+	return nil
+}
+
 func shellEnvFunc(
 	cmd *cobra.Command,
 	flags shellEnvCmdFlags,
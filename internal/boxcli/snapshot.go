@@ -0,0 +1,171 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package boxcli
+
+import (
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/spf13/cobra"
+
+	"go.jetify.com/devbox/internal/devbox"
+	"go.jetify.com/devbox/internal/devbox/devopt"
+	"go.jetify.com/devbox/internal/ux"
+)
+
+func snapshotCmd() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "snapshot",
+		Short: "Save and restore the state of the project's environment",
+		Long: heredoc.Doc(`
+			A snapshot saves the project's devbox.json, its devbox.lock,
+			which also pins its plugins, and the generation of its nix
+			profile. Restoring a snapshot rolls the nix profile back to that
+			generation, so going back to the packages from before an upgrade
+			doesn't build or download anything.
+
+			nix-collect-garbage -d deletes old profile generations. Restoring
+			a snapshot whose generation was deleted reinstalls its packages.
+		`),
+	}
+	command.AddCommand(snapshotCreateCmd())
+	command.AddCommand(snapshotListCmd())
+	command.AddCommand(snapshotRemoveCmd())
+	command.AddCommand(snapshotRestoreCmd())
+	return command
+}
+
+func snapshotCreateCmd() *cobra.Command {
+	flags := configFlags{}
+	command := &cobra.Command{
+		Use:     "create <name>",
+		Short:   "Save the state of the project's environment",
+		Example: "  devbox snapshot create pre-upgrade",
+		Args:    cobra.ExactArgs(1),
+		PreRunE: ensureNixInstalled,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			box, err := openSnapshotBox(cmd, flags)
+			if err != nil {
+				return err
+			}
+			snapshot, err := box.CreateSnapshot(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+			ux.Fsuccessf(cmd.ErrOrStderr(), "Created snapshot %s. Run `devbox snapshot restore %[1]s` to go back to it.\n",
+				snapshot.Name)
+			return nil
+		},
+	}
+	flags.register(command)
+	return command
+}
+
+type snapshotListCmdFlags struct {
+	config configFlags
+	json   bool
+}
+
+func snapshotListCmd() *cobra.Command {
+	flags := snapshotListCmdFlags{}
+	command := &cobra.Command{
+		Use:     "ls",
+		Aliases: []string{"list"},
+		Short:   "List the project's snapshots",
+		Args:    cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			box, err := openSnapshotBox(cmd, flags.config)
+			if err != nil {
+				return err
+			}
+			snapshots, err := box.Snapshots()
+			if err != nil {
+				return err
+			}
+			if flags.json {
+				if snapshots == nil {
+					snapshots = []devbox.Snapshot{}
+				}
+				return printJSON(cmd.OutOrStdout(), snapshots)
+			}
+			if len(snapshots) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "There are no snapshots. Create one with `devbox snapshot create <name>`.")
+				return nil
+			}
+			tw := tabwriter.NewWriter(cmd.OutOrStdout(), 3, 2, 4, ' ', 0)
+			fmt.Fprintln(tw, "NAME\tCREATED\tGENERATION")
+			for _, s := range snapshots {
+				fmt.Fprintf(tw, "%s\t%s\t%d\n", s.Name, s.Created.Local().Format(time.DateTime), s.ProfileGeneration)
+			}
+			return tw.Flush()
+		},
+	}
+	flags.config.register(command)
+	command.Flags().BoolVar(&flags.json, "json", false, "list the snapshots in json format")
+	return command
+}
+
+func snapshotRemoveCmd() *cobra.Command {
+	flags := configFlags{}
+	command := &cobra.Command{
+		Use:   "rm <name>",
+		Short: "Delete a snapshot",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			box, err := openSnapshotBox(cmd, flags)
+			if err != nil {
+				return err
+			}
+			if err := box.RemoveSnapshot(args[0]); err != nil {
+				return err
+			}
+			ux.Fsuccessf(cmd.ErrOrStderr(), "Removed snapshot %s.\n", args[0])
+			return nil
+		},
+	}
+	flags.register(command)
+	return command
+}
+
+func snapshotRestoreCmd() *cobra.Command {
+	flags := configFlags{}
+	command := &cobra.Command{
+		Use:   "restore <name>",
+		Short: "Go back to the state of the project's environment in a snapshot",
+		Long: heredoc.Doc(`
+			Restore devbox.json and devbox.lock from a snapshot and roll the
+			project's nix profile back to the snapshot's generation. Changes
+			to devbox.json and devbox.lock since the snapshot are
+			overwritten, so create another snapshot first to keep them.
+		`),
+		Example: "  devbox snapshot restore pre-upgrade",
+		Args:    cobra.ExactArgs(1),
+		PreRunE: ensureNixInstalled,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			box, err := openSnapshotBox(cmd, flags)
+			if err != nil {
+				return err
+			}
+			snapshot, err := box.RestoreSnapshot(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+			ux.Fsuccessf(cmd.ErrOrStderr(), "Restored snapshot %s from %s.\n",
+				snapshot.Name, snapshot.Created.Local().Format(time.DateTime))
+			return nil
+		},
+	}
+	flags.register(command)
+	return command
+}
+
+func openSnapshotBox(cmd *cobra.Command, flags configFlags) (*devbox.Devbox, error) {
+	return devbox.Open(&devopt.Opts{
+		Dir:         flags.path,
+		Environment: flags.environment,
+		Stderr:      cmd.ErrOrStderr(),
+	})
+}
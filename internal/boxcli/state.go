@@ -0,0 +1,216 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package boxcli
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"text/tabwriter"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"go.jetify.com/devbox/internal/devconfig"
+	"go.jetify.com/devbox/internal/state"
+	"go.jetify.com/devbox/internal/ux"
+)
+
+type stateListCmdFlags struct {
+	pathFlag
+	json bool
+}
+
+type stateCleanCmdFlags struct {
+	all bool
+	yes bool
+}
+
+func stateCmd() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "state",
+		Short: "List, clean and migrate the files that devbox keeps for you",
+		Long: heredoc.Doc(`
+			Devbox keeps caches, devbox global, the tools it installs for itself,
+			and its settings outside of your projects. These commands list where
+			those files are, delete them, and move the files of older devbox
+			versions to where the current version expects them.
+		`),
+	}
+	command.AddCommand(stateListCmd())
+	command.AddCommand(stateCleanCmd())
+	command.AddCommand(stateMigrateCmd())
+	return command
+}
+
+// stateLocation is a location with its size, as printed by devbox state ls.
+type stateLocation struct {
+	state.Location
+	Exists bool  `json:"exists"`
+	Bytes  int64 `json:"bytes"`
+}
+
+func stateListCmd() *cobra.Command {
+	flags := stateListCmdFlags{}
+	command := &cobra.Command{
+		Use:     "ls",
+		Aliases: []string{"list"},
+		Short:   "List the directories that devbox keeps state in, and their sizes",
+		Long: heredoc.Doc(`
+			List the directories that devbox keeps state in, their kind and the
+			disk space they use. When run in a project, it also lists the
+			project's .devbox directory.
+
+			The kind determines what devbox state clean deletes: caches are
+			recreated when they're needed, data such as devbox global packages
+			is lost when it's deleted, and config is never deleted.
+		`),
+		Args: cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			locations := state.UserLocations()
+			if cfg, err := devconfig.Find(flags.path); err == nil {
+				locations = append(locations, state.Location{
+					Name:        "project",
+					Kind:        state.KindCache,
+					Path:        filepath.Join(filepath.Dir(cfg.Root.AbsRootPath), ".devbox"),
+					Description: "the current project's packages and generated files",
+				})
+			}
+
+			listed := make([]stateLocation, len(locations))
+			for i, l := range locations {
+				listed[i] = stateLocation{Location: l, Exists: l.Exists(), Bytes: l.Size()}
+			}
+			if flags.json {
+				return printJSON(cmd.OutOrStdout(), listed)
+			}
+
+			tw := tabwriter.NewWriter(cmd.OutOrStdout(), 3, 2, 4, ' ', 0)
+			fmt.Fprintln(tw, "NAME\tKIND\tSIZE\tPATH")
+			for _, l := range listed {
+				size := formatSize(l.Bytes)
+				if !l.Exists {
+					size = "-"
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", l.Name, l.Kind, size, l.Path)
+			}
+			return tw.Flush()
+		},
+	}
+	flags.pathFlag.register(command)
+	command.Flags().BoolVar(&flags.json, "json", false, "output in json format")
+	return command
+}
+
+func stateCleanCmd() *cobra.Command {
+	flags := stateCleanCmdFlags{}
+	command := &cobra.Command{
+		Use:   "clean",
+		Short: "Delete devbox's caches",
+		Long: heredoc.Doc(`
+			Delete devbox's caches. Devbox downloads or recreates them when it
+			needs them again.
+
+			With --all, it also deletes devbox global, the tools that devbox
+			installs for itself, and setup and telemetry state. It never deletes
+			your settings or keys, and it doesn't touch the nix store or your
+			projects' .devbox directories.
+		`),
+		Example: "  devbox state clean\n  devbox state clean --all --yes",
+		Args:    cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			kinds := []state.Kind{state.KindCache}
+			if flags.all {
+				kinds = append(kinds, state.KindData, state.KindState)
+			}
+
+			locations := []state.Location{}
+			for _, l := range state.UserLocations() {
+				if slices.Contains(kinds, l.Kind) && l.Exists() {
+					locations = append(locations, l)
+				}
+			}
+			if len(locations) == 0 {
+				fmt.Fprintln(cmd.ErrOrStderr(), "Nothing to clean")
+				return nil
+			}
+
+			fmt.Fprintln(cmd.ErrOrStderr(), "The following will be deleted:")
+			for _, l := range locations {
+				fmt.Fprintf(cmd.ErrOrStderr(), "  %s (%s): %s\n", l.Name, formatSize(l.Size()), l.Path)
+			}
+			if !flags.yes {
+				prompt := &survey.Confirm{Message: "Delete these files?"}
+				if err := survey.AskOne(prompt, &flags.yes); err != nil {
+					return errors.WithStack(err)
+				}
+				if !flags.yes {
+					return nil
+				}
+			}
+
+			cleaned, err := state.Clean(state.UserLocations(), kinds...)
+			if err != nil {
+				return err
+			}
+			ux.Fsuccessf(cmd.ErrOrStderr(), "Deleted %d location(s)\n", len(cleaned))
+			return nil
+		},
+	}
+	command.Flags().BoolVar(
+		&flags.all, "all", false, "also delete devbox global, installed tools and state")
+	command.Flags().BoolVarP(
+		&flags.yes, "yes", "y", false, "delete the files without asking for confirmation")
+	return command
+}
+
+func stateMigrateCmd() *cobra.Command {
+	dryRun := false
+	command := &cobra.Command{
+		Use:   "migrate",
+		Short: "Move the files of older devbox versions to their current location",
+		Long: heredoc.Doc(`
+			Move the files that older versions of devbox kept in other
+			directories to where the current version expects them. Files that
+			already exist in the new location are kept.
+		`),
+		Args: cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			migrations := state.PendingMigrations()
+			if len(migrations) == 0 {
+				fmt.Fprintln(cmd.ErrOrStderr(), "Nothing to migrate")
+				return nil
+			}
+			for _, m := range migrations {
+				if dryRun {
+					ux.Finfof(cmd.ErrOrStderr(), "Would move %s from %s to %s\n", m.Description, m.From, m.To)
+					continue
+				}
+				if err := m.Apply(); err != nil {
+					return err
+				}
+				ux.Fsuccessf(cmd.ErrOrStderr(), "Moved %s from %s to %s\n", m.Description, m.From, m.To)
+			}
+			return nil
+		},
+	}
+	command.Flags().BoolVar(
+		&dryRun, "dry-run", false, "print the migrations without applying them")
+	return command
+}
+
+func formatSize(b int64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+	div, exp := int64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
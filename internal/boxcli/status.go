@@ -0,0 +1,102 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package boxcli
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"go.jetify.com/devbox/internal/devbox"
+	"go.jetify.com/devbox/internal/devbox/devopt"
+)
+
+type statusCmdFlags struct {
+	config configFlags
+	remote string
+	json   bool
+}
+
+func statusCmd() *cobra.Command {
+	flags := statusCmdFlags{}
+	command := &cobra.Command{
+		Use:   "status",
+		Short: "Show whether the environment matches devbox.json, devbox.lock and a branch",
+		Long: heredoc.Doc(`
+			Show whether the installed packages match devbox.json and
+			devbox.lock.
+
+			With --remote, also compare devbox.json and devbox.lock with the
+			ones committed at a git revision, such as origin/main, and report
+			the drift: the packages that were added or removed locally, and
+			the packages that resolve to a different version or build, such
+			as after a local devbox update. Run git fetch first to compare
+			with the latest commit of a remote branch.
+		`),
+		Example: "  devbox status\n  devbox status --remote origin/main",
+		Args:    cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			box, err := devbox.Open(&devopt.Opts{
+				Dir:         flags.config.path,
+				Environment: flags.config.environment,
+				Stderr:      cmd.ErrOrStderr(),
+			})
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			status, err := box.Status(cmd.Context(), devopt.StatusOpts{Remote: flags.remote})
+			if err != nil {
+				return err
+			}
+			if flags.json {
+				return printJSON(cmd.OutOrStdout(), status)
+			}
+			printStatus(cmd.OutOrStdout(), status)
+			return nil
+		},
+	}
+	flags.config.register(command)
+	command.Flags().StringVar(
+		&flags.remote, "remote", "", "git revision to compare devbox.json and devbox.lock with, such as origin/main")
+	command.Flags().BoolVar(&flags.json, "json", false, "print the status in json format")
+	return command
+}
+
+func printStatus(w io.Writer, status *devbox.Status) {
+	if status.UpToDate {
+		fmt.Fprintln(w, "The installed packages match devbox.json and devbox.lock.")
+	} else {
+		fmt.Fprintln(w, "The installed packages don't match devbox.json and devbox.lock. Run `devbox install` to update them.")
+	}
+	if status.Remote == "" {
+		return
+	}
+
+	if !status.HasDrift() {
+		fmt.Fprintf(w, "\nNo drift from %s.\n", status.Remote)
+		return
+	}
+	fmt.Fprintf(w, "\nDrift from %s:\n", status.Remote)
+	if len(status.AddedPackages) > 0 {
+		fmt.Fprintln(w, "\nAdded locally:")
+		for _, pkg := range status.AddedPackages {
+			fmt.Fprintf(w, "  + %s\n", pkg)
+		}
+	}
+	if len(status.RemovedPackages) > 0 {
+		fmt.Fprintln(w, "\nRemoved locally:")
+		for _, pkg := range status.RemovedPackages {
+			fmt.Fprintf(w, "  - %s\n", pkg)
+		}
+	}
+	if len(status.ResolutionChanges) > 0 {
+		// printLockChanges groups the changes under headings such as
+		// Upgraded and Changed.
+		fmt.Fprintln(w)
+		printLockChanges(w, status.ResolutionChanges)
+	}
+}
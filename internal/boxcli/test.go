@@ -0,0 +1,104 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package boxcli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+	"go.jetify.com/devbox/internal/devbox"
+	"go.jetify.com/devbox/internal/devbox/devopt"
+	"go.jetify.com/devbox/internal/ux"
+)
+
+type testCmdFlags struct {
+	config configFlags
+	json   bool
+}
+
+func testCmd() *cobra.Command {
+	flags := testCmdFlags{}
+	command := &cobra.Command{
+		Use:   "test [<name>]...",
+		Short: "Run the tests that devbox.json and its plugins declare",
+		Long: heredoc.Doc(`
+			Run the tests in the "tests" of devbox.json and of the plugins
+			that it includes, to check that the environment is set up the
+			way it should be, such as in the CI of a shared config or a
+			plugin. Each test runs a command in the project's environment,
+			after the init hooks:
+
+			  "tests": [{"name": "python", "run": "python --version", "expect": "3.12"}]
+
+			A test passes if its command exits with status 0 and, if it has
+			"expect", the command's output contains it. The environment is
+			computed for the tests instead of taken from the current shell.
+			It fails if a test fails.
+		`),
+		Example: "  devbox test\n  devbox test python --json",
+		PreRunE: ensureNixInstalled,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return testCmdFunc(cmd, args, flags)
+		},
+	}
+	flags.config.register(command)
+	command.Flags().BoolVar(&flags.json, "json", false, "print the results as JSON")
+	return command
+}
+
+func testCmdFunc(cmd *cobra.Command, names []string, flags testCmdFlags) error {
+	box, err := devbox.Open(&devopt.Opts{
+		Dir:         flags.config.path,
+		Environment: flags.config.environment,
+		Stderr:      cmd.ErrOrStderr(),
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	results, err := box.RunTests(cmd.Context(), devopt.TestOpts{Names: names})
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, result := range results {
+		if !result.Passed {
+			failed++
+		}
+	}
+	if flags.json {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(results); err != nil {
+			return errors.WithStack(err)
+		}
+	} else if len(results) == 0 {
+		ux.Finfof(cmd.ErrOrStderr(), "devbox.json and its plugins don't declare any tests.\n")
+	} else {
+		w := cmd.OutOrStdout()
+		for _, result := range results {
+			if result.Passed {
+				fmt.Fprintf(w, "PASS  %s (%s)\n", result.Name, result.Source)
+				continue
+			}
+			fmt.Fprintf(w, "FAIL  %s (%s): %s\n", result.Name, result.Source, result.Reason)
+			if output := strings.TrimSpace(result.Output); output != "" {
+				fmt.Fprintf(w, "      %s\n", strings.ReplaceAll(output, "\n", "\n      "))
+			}
+		}
+	}
+	if failed > 0 {
+		return usererr.New("%d of %d tests failed.", failed, len(results))
+	}
+	if !flags.json && len(results) > 0 {
+		ux.Fsuccessf(cmd.ErrOrStderr(), "All %d tests passed.\n", len(results))
+	}
+	return nil
+}
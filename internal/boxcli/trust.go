@@ -0,0 +1,98 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package boxcli
+
+import (
+	"io"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"go.jetify.com/devbox/internal/devbox"
+	"go.jetify.com/devbox/internal/devbox/devopt"
+	"go.jetify.com/devbox/internal/ux"
+)
+
+type trustCmdFlags struct {
+	config configFlags
+}
+
+func trustCmd() *cobra.Command {
+	flags := trustCmdFlags{}
+	command := &cobra.Command{
+		Use:   "trust",
+		Short: "Trust the project to run its init hooks, scripts and services",
+		Long: heredoc.Doc(`
+			Trust the project to run its init hooks, scripts and services.
+
+			Devbox doesn't run the code of a project, such as one that you just
+			cloned, until you trust it. It asks whether you trust the project
+			when there's a terminal to ask in, and fails otherwise. Review the
+			project's devbox.json, the plugins that it includes and its
+			process-compose file before you trust it.
+
+			Devbox asks again after any of them change, unless devbox changed
+			them itself, such as with devbox add. Projects that you create with
+			devbox init are trusted. Use --no-trust-prompt or set
+			DEVBOX_NO_TRUST_PROMPT=1 to run the code of projects without
+			trusting them, such as in CI.
+		`),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			box, err := openTrustedBox(cmd, flags)
+			if err != nil {
+				return err
+			}
+			if err := box.Trust(); err != nil {
+				return err
+			}
+			ux.Fsuccessf(cmd.ErrOrStderr(), "Trusted %s with its current devbox.json, plugins and process-compose file.\n",
+				box.ProjectDir())
+			return nil
+		},
+	}
+	flags.config.register(command)
+	return command
+}
+
+func untrustCmd() *cobra.Command {
+	flags := trustCmdFlags{}
+	command := &cobra.Command{
+		Use:   "untrust",
+		Short: "Stop trusting the project to run its init hooks, scripts and services",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			box, err := openTrustedBox(cmd, flags)
+			if err != nil {
+				return err
+			}
+			if err := box.Untrust(); err != nil {
+				return err
+			}
+			ux.Fsuccessf(cmd.ErrOrStderr(), "Devbox asks before it runs the code of %s.\n", box.ProjectDir())
+			return nil
+		},
+	}
+	flags.config.register(command)
+	return command
+}
+
+func openTrustedBox(cmd *cobra.Command, flags trustCmdFlags) (*devbox.Devbox, error) {
+	box, err := devbox.Open(&devopt.Opts{
+		Dir:         flags.config.path,
+		Environment: flags.config.environment,
+		Stderr:      cmd.ErrOrStderr(),
+	})
+	return box, errors.WithStack(err)
+}
+
+// trustNewProject trusts the project that the user just created in dir.
+func trustNewProject(w io.Writer, dir string) error {
+	box, err := devbox.Open(&devopt.Opts{Dir: dir, Stderr: w})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return box.Trust()
+}
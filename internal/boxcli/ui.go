@@ -0,0 +1,57 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package boxcli
+
+import (
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"go.jetify.com/devbox/internal/dashboard"
+	"go.jetify.com/devbox/internal/devbox"
+	"go.jetify.com/devbox/internal/devbox/devopt"
+)
+
+type uiCmdFlags struct {
+	config configFlags
+}
+
+func uiCmd() *cobra.Command {
+	flags := uiCmdFlags{}
+	command := &cobra.Command{
+		Use:   "ui",
+		Short: "Show an interactive dashboard for your devbox project",
+		Long: heredoc.Doc(`
+			Show an interactive dashboard for the current devbox project. It lists
+			the packages that have updates, the state of the project's services,
+			recent runs of the scripts in devbox.json, and the disk space used by
+			devbox's caches.
+
+			Use tab to switch between panels and the arrow keys to select an item.
+			Press u to update the selected package, U to update all packages, r to
+			restart the selected service, R to refresh, and q to quit.
+		`),
+		Args:    cobra.NoArgs,
+		PreRunE: ensureNixInstalled,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return uiCmdFunc(cmd, flags)
+		},
+	}
+
+	flags.config.register(command)
+	return command
+}
+
+func uiCmdFunc(cmd *cobra.Command, flags uiCmdFlags) error {
+	stderr := dashboard.NewStderr(cmd.ErrOrStderr())
+	box, err := devbox.Open(&devopt.Opts{
+		Dir:         flags.config.path,
+		Environment: flags.config.environment,
+		Stderr:      stderr,
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return dashboard.Run(cmd.Context(), box, stderr)
+}
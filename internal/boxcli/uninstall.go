@@ -0,0 +1,99 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package boxcli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"go.jetify.com/devbox/internal/envir"
+	"go.jetify.com/devbox/internal/state"
+	"go.jetify.com/devbox/internal/uninstall"
+	"go.jetify.com/devbox/internal/ux"
+)
+
+type uninstallCmdFlags struct {
+	nix bool
+	yes bool
+}
+
+func uninstallCmd() *cobra.Command {
+	flags := uninstallCmdFlags{}
+	command := &cobra.Command{
+		Use:   "uninstall",
+		Short: "Remove devbox's files, shell hooks and profiles from this machine",
+		Long: heredoc.Doc(`
+			Remove what devbox created outside of your projects: the lines that
+			load devbox global or devbox completions in your shell's rcfiles,
+			the nix garbage collector roots of devbox profiles, devbox global,
+			caches, installed tools and state.
+
+			With --nix, it also uninstalls nix and deletes the nix store, but
+			only if devbox installed nix.
+
+			It doesn't delete your projects, their .devbox directories or your
+			devbox settings, and it doesn't delete the devbox binary itself.
+		`),
+		Example: "  devbox uninstall\n  devbox uninstall --nix",
+		Args:    cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runUninstallCmd(cmd, &flags)
+		},
+	}
+	command.Flags().BoolVar(
+		&flags.nix, "nix", false, "also uninstall nix if devbox installed it")
+	command.Flags().BoolVarP(
+		&flags.yes, "yes", "y", false, "uninstall without asking for confirmation")
+	return command
+}
+
+func runUninstallCmd(cmd *cobra.Command, flags *uninstallCmdFlags) error {
+	steps, err := uninstall.Plan(uninstall.Opts{Nix: flags.nix})
+	if err != nil {
+		return err
+	}
+	if len(steps) == 0 {
+		fmt.Fprintln(cmd.ErrOrStderr(), "Nothing to uninstall")
+	} else {
+		fmt.Fprintln(cmd.ErrOrStderr(), "Devbox will:")
+		for _, step := range steps {
+			fmt.Fprintf(cmd.ErrOrStderr(), "  - %s\n", step.Description)
+			for _, path := range step.Paths {
+				fmt.Fprintf(cmd.ErrOrStderr(), "      %s\n", path)
+			}
+		}
+		if !flags.yes {
+			message := "Continue?"
+			if flags.nix {
+				message = "Continue? This deletes every package in the nix store."
+			}
+			prompt := &survey.Confirm{Message: message}
+			if err := survey.AskOne(prompt, &flags.yes); err != nil {
+				return errors.WithStack(err)
+			}
+			if !flags.yes {
+				return nil
+			}
+		}
+		for _, step := range steps {
+			if err := step.Run(cmd.Context()); err != nil {
+				return err
+			}
+		}
+		ux.Fsuccessf(cmd.ErrOrStderr(), "Removed devbox's files from this machine\n")
+	}
+
+	exe := os.Getenv(envir.LauncherPath)
+	if exe == "" {
+		exe, _ = os.Executable()
+	}
+	ux.Finfof(cmd.ErrOrStderr(),
+		"To finish uninstalling devbox, delete %s. Your settings are in %s.\n", exe, state.Config())
+	return nil
+}
@@ -18,6 +18,12 @@ type updateCmdFlags struct {
 	sync        bool
 	allProjects bool
 	noInstall   bool
+	allSystems  bool
+	changelog   bool
+	commit      bool
+	branch      string
+	pr          bool
+	explain     bool
 }
 
 func updateCmd() *cobra.Command {
@@ -29,7 +35,16 @@ func updateCmd() *cobra.Command {
 		Long: "Update one, many, or all packages in your devbox. " +
 			"If no packages are specified, all packages will be updated. " +
 			"Legacy non-versioned packages will be converted to @latest versioned " +
-			"packages resolved to their current version.",
+			"packages resolved to their current version. Packages with an \"update\" " +
+			"policy of patch or minor in devbox.json only get updates within their " +
+			"locked minor or major version, and ignored or pinned packages are " +
+			"skipped. Updated packages are checked against the org \"policy\" " +
+			"of devbox.json and its plugins, and --explain prints each rule. " +
+			"After updating, links to the changelogs of the packages whose " +
+			"versions changed are printed. " +
+			"With --commit, the changes to devbox.json and devbox.lock are " +
+			"committed, optionally on a new --branch that --pr opens a GitHub " +
+			"pull request for, which is useful for scheduled update jobs.",
 		PreRunE: func(cmd *cobra.Command, args []string) error {
 			if flags.noInstall {
 				return nil
@@ -61,6 +76,46 @@ func updateCmd() *cobra.Command {
 		false,
 		"update lockfile but don't install anything",
 	)
+	command.Flags().BoolVar(
+		&flags.allSystems,
+		"all-systems",
+		false,
+		"resolve the store paths of packages for all systems, not just this one, "+
+			"so that other platforms don't have to resolve them.",
+	)
+	command.Flags().BoolVar(
+		&flags.changelog,
+		"changelog",
+		false,
+		"print the release notes of the packages whose versions changed, "+
+			"not just links to their changelogs.",
+	)
+	command.Flags().BoolVar(
+		&flags.commit,
+		"commit",
+		false,
+		"commit the changes to devbox.json and devbox.lock with a message "+
+			"that lists the updated packages.",
+	)
+	command.Flags().StringVar(
+		&flags.branch,
+		"branch",
+		"",
+		"create this branch for the commit. Requires --commit.",
+	)
+	command.Flags().BoolVar(
+		&flags.pr,
+		"pr",
+		false,
+		"push the branch to origin and open a GitHub pull request for it, "+
+			"using the token in GITHUB_TOKEN. Requires --branch.",
+	)
+	command.Flags().BoolVar(
+		&flags.explain,
+		"explain",
+		false,
+		"print every org policy rule that the updated packages are checked against.",
+	)
 	return command
 }
 
@@ -69,8 +124,18 @@ func updateCmdFunc(cmd *cobra.Command, args []string, flags *updateCmdFlags) err
 		return usererr.New("cannot specify both a package and --sync")
 	}
 
+	if flags.branch != "" && !flags.commit {
+		return usererr.New("--branch requires --commit")
+	}
+	if flags.pr && flags.branch == "" {
+		return usererr.New("--pr requires --branch")
+	}
+	if flags.commit && (flags.allProjects || flags.sync) {
+		return usererr.New("--commit can't be used with --all-projects or --sync-lock")
+	}
+
 	if flags.allProjects {
-		return updateAllProjects(cmd, args)
+		return updateAllProjects(cmd, args, flags)
 	}
 
 	if flags.sync {
@@ -87,12 +152,18 @@ func updateCmdFunc(cmd *cobra.Command, args []string, flags *updateCmdFlags) err
 	}
 
 	return box.Update(cmd.Context(), devopt.UpdateOpts{
-		Pkgs:      args,
-		NoInstall: flags.noInstall,
+		Pkgs:        args,
+		NoInstall:   flags.noInstall,
+		AllSystems:  flags.allSystems,
+		Changelog:   flags.changelog,
+		Commit:      flags.commit,
+		Branch:      flags.branch,
+		PullRequest: flags.pr,
+		Explain:     flags.explain,
 	})
 }
 
-func updateAllProjects(cmd *cobra.Command, args []string) error {
+func updateAllProjects(cmd *cobra.Command, args []string, flags *updateCmdFlags) error {
 	boxes, err := multi.Open(&devopt.Opts{
 		Stderr: cmd.ErrOrStderr(),
 	})
@@ -103,6 +174,9 @@ func updateAllProjects(cmd *cobra.Command, args []string) error {
 		if err := box.Update(cmd.Context(), devopt.UpdateOpts{
 			Pkgs:                  args,
 			IgnoreMissingPackages: true,
+			AllSystems:            flags.allSystems,
+			Changelog:             flags.changelog,
+			Explain:               flags.explain,
 		}); err != nil {
 			return err
 		}
@@ -0,0 +1,194 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package boxcli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+	"go.jetify.com/devbox/internal/devbox"
+	"go.jetify.com/devbox/internal/devbox/devopt"
+	"go.jetify.com/devbox/internal/provenance"
+	"go.jetify.com/devbox/internal/state"
+	"go.jetify.com/devbox/internal/ux"
+)
+
+type verifyCmdFlags struct {
+	config     configFlags
+	provenance bool
+	output     string
+	check      string
+	key        string
+	publicKey  string
+	sigstore   bool
+	identity   string
+	issuer     string
+}
+
+func verifyCmd() *cobra.Command {
+	flags := verifyCmdFlags{}
+	command := &cobra.Command{
+		Use:   "verify",
+		Short: "Create or check a signed attestation of the project's environment",
+		Long: heredoc.Doc(`
+			With --provenance, install the project's packages and write a
+			signed attestation that binds devbox.json, devbox.lock, the
+			project's plugins and the nix store paths that they install. The
+			attestation is an in-toto statement in a DSSE envelope.
+
+			It's signed with a local key, which is created the first time in
+			Devbox's config directory, or with sigstore if --sigstore is set.
+			Share the key's .pub file with the people who check attestations.
+
+			With --check, compare the project's environment with an
+			attestation instead, and fail if the signature is invalid or the
+			environment is different.
+		`),
+		Example: "  devbox verify --provenance\n" +
+			"  devbox verify --provenance --check devbox.provenance.json --public-key team.pub\n" +
+			"  devbox verify --provenance --sigstore\n" +
+			"  devbox verify --provenance --check devbox.provenance.json --sigstore \\\n" +
+			"    --identity dev@example.com --issuer https://accounts.google.com",
+		Args: cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !flags.provenance {
+				return usererr.New("Nothing to verify. Pass --provenance to attest to the project's environment.")
+			}
+			box, err := devbox.Open(&devopt.Opts{
+				Dir:         flags.config.path,
+				Environment: flags.config.environment,
+				Stderr:      cmd.ErrOrStderr(),
+			})
+			if err != nil {
+				return err
+			}
+			if flags.check != "" {
+				return checkProvenance(cmd, box, &flags)
+			}
+			return writeProvenance(cmd, box, &flags)
+		},
+	}
+	flags.config.register(command)
+	command.Flags().BoolVar(
+		&flags.provenance, "provenance", false, "attest to the project's packages and the store paths they install")
+	command.Flags().StringVarP(
+		&flags.output, "output", "o", "", "file to write the attestation to (default devbox.provenance.json)")
+	command.Flags().StringVar(
+		&flags.check, "check", "", "check the project's environment against this attestation")
+	command.Flags().StringVar(
+		&flags.key, "key", "", "private key to sign with (default provenance.key in Devbox's config directory)")
+	command.Flags().StringVar(
+		&flags.publicKey, "public-key", "", "public key to check the signature with (default the .pub of --key)")
+	command.Flags().BoolVar(
+		&flags.sigstore, "sigstore", false, "sign or check with sigstore's keyless signing, which needs cosign")
+	command.Flags().StringVar(
+		&flags.identity, "identity", "", "identity, such as an email address, that signed with sigstore")
+	command.Flags().StringVar(
+		&flags.issuer, "issuer", "", "OIDC issuer that the sigstore signer logged in with")
+	command.MarkFlagsMutuallyExclusive("sigstore", "key")
+	command.MarkFlagsMutuallyExclusive("sigstore", "public-key")
+	command.MarkFlagsMutuallyExclusive("check", "output")
+	return command
+}
+
+func writeProvenance(cmd *cobra.Command, box *devbox.Devbox, flags *verifyCmdFlags) error {
+	statement, err := box.Provenance(cmd.Context())
+	if err != nil {
+		return err
+	}
+	envelope, err := provenance.NewEnvelope(statement)
+	if err != nil {
+		return err
+	}
+	if !flags.sigstore {
+		key, err := provenance.LoadOrCreateKey(provenanceKeyPath(flags))
+		if err != nil {
+			return err
+		}
+		if err := envelope.Sign(key); err != nil {
+			return err
+		}
+	}
+	data, err := json.MarshalIndent(envelope, "", "  ")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	output := flags.output
+	if output == "" {
+		output = filepath.Join(box.ProjectDir(), "devbox.provenance.json")
+	}
+	if err := os.WriteFile(output, append(data, '\n'), 0o644); err != nil {
+		return errors.WithStack(err)
+	}
+	if flags.sigstore {
+		if err := provenance.SignSigstore(cmd.Context(), cmd.ErrOrStderr(), output); err != nil {
+			return err
+		}
+	}
+	ux.Fsuccessf(cmd.ErrOrStderr(), "Wrote the attestation to %s.\n", output)
+	return nil
+}
+
+func checkProvenance(cmd *cobra.Command, box *devbox.Devbox, flags *verifyCmdFlags) error {
+	data, err := os.ReadFile(flags.check)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	envelope := &provenance.Envelope{}
+	if err := json.Unmarshal(data, envelope); err != nil {
+		return usererr.WithUserMessage(err, "%s isn't an attestation.", flags.check)
+	}
+
+	var want *provenance.Statement
+	if flags.sigstore {
+		err := provenance.VerifySigstore(cmd.Context(), cmd.ErrOrStderr(), flags.check, flags.identity, flags.issuer)
+		if err != nil {
+			return err
+		}
+		want, err = envelope.Statement()
+		if err != nil {
+			return err
+		}
+	} else {
+		publicKeyPath := flags.publicKey
+		if publicKeyPath == "" {
+			publicKeyPath = provenanceKeyPath(flags) + ".pub"
+		}
+		publicKey, err := provenance.LoadPublicKey(publicKeyPath)
+		if err != nil {
+			return err
+		}
+		want, err = envelope.Verify(publicKey)
+		if err != nil {
+			return err
+		}
+	}
+
+	got, err := box.Provenance(cmd.Context())
+	if err != nil {
+		return err
+	}
+	if diffs := provenance.Compare(want, got); len(diffs) > 0 {
+		for _, diff := range diffs {
+			ux.Fwarningf(cmd.ErrOrStderr(), "%s\n", diff)
+		}
+		return usererr.New("The project's environment doesn't match %s.", flags.check)
+	}
+	ux.Fsuccessf(cmd.ErrOrStderr(), "The project's environment matches %s.\n", flags.check)
+	return nil
+}
+
+func provenanceKeyPath(flags *verifyCmdFlags) string {
+	if flags.key != "" {
+		return flags.key
+	}
+	return state.Config("provenance.key")
+}
@@ -0,0 +1,174 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package boxcli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/spf13/cobra"
+
+	"go.jetify.com/devbox/internal/devbox"
+	"go.jetify.com/devbox/internal/devbox/devopt"
+	"go.jetify.com/devbox/internal/ux"
+	"go.jetify.com/devbox/internal/vm"
+)
+
+type vmUpCmdFlags struct {
+	config          configFlags
+	provider        string
+	region          string
+	size            string
+	sshFromAnywhere bool
+}
+
+func vmCmd() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "vm",
+		Short: "Run the project on a VM in your own cloud account",
+		Long: heredoc.Doc(`
+			Create a VM in your own AWS, Google Cloud or Fly.io account, with
+			Devbox and the project's packages installed on it. Devbox uses the
+			provider's CLI (aws, gcloud or fly), so it needs to be installed
+			and logged in.
+
+			If mutagen is installed, the project stays in sync with the VM.
+			Run scripts on the VM with devbox run --on vm <script>, and open a
+			shell on it with devbox vm ssh.
+		`),
+	}
+	command.AddCommand(vmUpCmd())
+	command.AddCommand(vmDownCmd())
+	command.AddCommand(vmSSHCmd())
+	command.AddCommand(vmStatusCmd())
+	return command
+}
+
+func vmUpCmd() *cobra.Command {
+	flags := vmUpCmdFlags{}
+	command := &cobra.Command{
+		Use:   "up",
+		Short: "Create a VM for the project",
+		Example: "  devbox vm up --provider aws --region us-west-2\n" +
+			"  devbox vm up --provider gcp --region us-central1-a --size e2-standard-4",
+		Args: cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			box, err := openVMDevbox(cmd, flags.config)
+			if err != nil {
+				return err
+			}
+			m, err := box.VMUp(cmd.Context(), devopt.VMUpOpts{
+				Provider:        flags.provider,
+				Region:          flags.region,
+				Size:            flags.size,
+				SSHFromAnywhere: flags.sshFromAnywhere,
+			})
+			if err != nil {
+				return err
+			}
+			ux.Fsuccessf(cmd.ErrOrStderr(), "VM %s is ready at %s\n", m.Name, m.Host)
+			fmt.Fprintln(cmd.ErrOrStderr(), "Run scripts on it with `devbox run --on vm <script>`, "+
+				"or open a shell with `devbox vm ssh`.")
+			return nil
+		},
+	}
+	flags.config.register(command)
+	command.Flags().StringVar(
+		&flags.provider, "provider", "",
+		"cloud provider to create the VM in: "+strings.Join(vm.Providers(), ", "))
+	command.Flags().StringVar(
+		&flags.region, "region", "", "region, or zone for gcp. Defaults to the provider CLI's default")
+	command.Flags().StringVar(
+		&flags.size, "size", "", "provider's machine type, such as t3.large, e2-standard-2 or shared-cpu-2x")
+	command.Flags().BoolVar(
+		&flags.sshFromAnywhere, "ssh-from-anywhere", false,
+		"for aws, allow SSH to the VM from any address instead of only from this machine's public IP")
+	_ = command.MarkFlagRequired("provider")
+	return command
+}
+
+func vmDownCmd() *cobra.Command {
+	flags := configFlags{}
+	command := &cobra.Command{
+		Use:   "down",
+		Short: "Delete the project's VM",
+		Args:  cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			box, err := openVMDevbox(cmd, flags)
+			if err != nil {
+				return err
+			}
+			if err := box.VMDown(cmd.Context()); err != nil {
+				return err
+			}
+			ux.Fsuccessf(cmd.ErrOrStderr(), "Deleted the project's VM\n")
+			return nil
+		},
+	}
+	flags.register(command)
+	return command
+}
+
+func vmSSHCmd() *cobra.Command {
+	flags := configFlags{}
+	command := &cobra.Command{
+		Use:   "ssh",
+		Short: "Open a devbox shell on the project's VM",
+		Args:  cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			box, err := openVMDevbox(cmd, flags)
+			if err != nil {
+				return err
+			}
+			return box.VMShell(cmd.Context())
+		},
+	}
+	flags.register(command)
+	return command
+}
+
+func vmStatusCmd() *cobra.Command {
+	flags := configFlags{}
+	command := &cobra.Command{
+		Use:   "status",
+		Short: "Show the project's VM",
+		Args:  cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			box, err := openVMDevbox(cmd, flags)
+			if err != nil {
+				return err
+			}
+			m, err := box.VM()
+			if err != nil {
+				return err
+			}
+			if m == nil {
+				fmt.Fprintln(cmd.OutOrStdout(), "This project doesn't have a VM. Create one with `devbox vm up`.")
+				return nil
+			}
+			sync := "off (install mutagen to turn it on)"
+			if m.SyncSession != "" {
+				sync = "mutagen session " + m.SyncSession
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Name:     %s\nProvider: %s\n", m.Name, m.Provider)
+			if m.Region != "" {
+				fmt.Fprintf(cmd.OutOrStdout(), "Region:   %s\n", m.Region)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "SSH:      %s\nSync:     %s\n",
+				strings.Join(m.Target().SSHCommand(), " "), sync)
+			return nil
+		},
+	}
+	flags.register(command)
+	return command
+}
+
+func openVMDevbox(cmd *cobra.Command, flags configFlags) (*devbox.Devbox, error) {
+	return devbox.Open(&devopt.Opts{
+		Dir:         flags.path,
+		Environment: flags.environment,
+		Stderr:      cmd.ErrOrStderr(),
+	})
+}
@@ -11,9 +11,15 @@
 	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"hash"
 	"io"
+	"io/fs"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
 
 	"go.jetify.com/devbox/internal/redact"
 )
@@ -49,6 +55,127 @@ func File(path string) (string, error) {
 	return hex.EncodeToString(h.Sum(nil)), nil
 }
 
+// skipDirs are directories that Dir doesn't hash because they hold VCS or tool
+// state rather than source files.
+var skipDirs = map[string]bool{
+	".devbox": true,
+	".direnv": true,
+	".git":    true,
+	".hg":     true,
+	".jj":     true,
+}
+
+// Dir returns a hex-encoded hash of the names, modes and contents of the files
+// in a directory tree. Symlinks are hashed by their target and aren't followed.
+// If the directory is in a git repository, only the files that git tracks or
+// would track are hashed, so ignored files such as build outputs don't change
+// the hash.
+func Dir(root string) (string, error) {
+	root, err := filepath.EvalSymlinks(root)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+
+	files, ok := gitFiles(root)
+	if !ok {
+		if files, err = walkFiles(root); err != nil {
+			return "", err
+		}
+	}
+	h := newHash()
+	for _, rel := range files {
+		if err := hashDirEntry(h, root, rel); err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// gitFiles returns the paths, relative to root, of the files in root that git
+// tracks, and of the untracked files that it doesn't ignore. It returns false
+// if root isn't in a git repository.
+func gitFiles(root string) ([]string, bool) {
+	cmd := exec.Command("git", "-C", root, "ls-files", "-z", "--cached", "--others", "--exclude-standard")
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, false
+	}
+	files := []string{}
+	for _, rel := range strings.Split(string(out), "\x00") {
+		if rel == "" || isInSkipDir(rel) {
+			continue
+		}
+		// Files that were deleted but not staged are still listed.
+		if _, err := os.Lstat(filepath.Join(root, rel)); err != nil {
+			continue
+		}
+		files = append(files, filepath.FromSlash(rel))
+	}
+	slices.Sort(files)
+	return slices.Compact(files), true
+}
+
+// walkFiles returns the paths, relative to root, of the files and directories
+// in root, except for skipDirs.
+func walkFiles(root string) ([]string, error) {
+	files := []string{}
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() && path != root && skipDirs[entry.Name()] {
+			return filepath.SkipDir
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, rel)
+		return nil
+	})
+	return files, err
+}
+
+func isInSkipDir(rel string) bool {
+	dirs := strings.Split(rel, "/")
+	return slices.ContainsFunc(dirs[:len(dirs)-1], func(dir string) bool { return skipDirs[dir] })
+}
+
+// hashDirEntry writes the name, type, executable bit and content of the file
+// rel in root to h.
+func hashDirEntry(h io.Writer, root, rel string) error {
+	path := filepath.Join(root, rel)
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+	// Only the executable bit matters to nix, so ignore the rest of the
+	// permissions.
+	fmt.Fprintf(h, "%s\x00%s\x00%t\x00", filepath.ToSlash(rel), info.Mode().Type(), info.Mode()&0o111 != 0)
+
+	switch {
+	case info.Mode()&fs.ModeSymlink != 0:
+		target, err := os.Readlink(path)
+		if err != nil {
+			return err
+		}
+		io.WriteString(h, target)
+	case info.Mode().IsRegular():
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if _, err := io.Copy(h, f); err != nil {
+			return err
+		}
+	}
+	h.Write([]byte{0})
+	return nil
+}
+
 // JSON marshals a to JSON and returns its hex-encoded hash.
 func JSON(a any) (string, error) {
 	b, err := json.Marshal(a)
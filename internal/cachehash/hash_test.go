@@ -3,6 +3,7 @@
 
 import (
 	"os"
+	"os/exec"
 	"path/filepath"
 	"testing"
 )
@@ -113,3 +114,109 @@ func TestJSONFileNotExist(t *testing.T) {
 		t.Errorf("got non-empty hash %q", hash)
 	}
 }
+
+func TestDir(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	hash := func() string {
+		h, err := Dir(dir)
+		if err != nil {
+			t.Fatalf("got Dir error: %v", err)
+		}
+		return h
+	}
+
+	write("flake.nix", "{ outputs = _: {}; }")
+	write("src/main.go", "package main")
+	initial := hash()
+	if initial == "" {
+		t.Fatal(`got Dir() == ""`)
+	}
+	if got := hash(); got != initial {
+		t.Errorf("got different hashes %q and %q for the same directory", initial, got)
+	}
+
+	write(".git/HEAD", "ref: refs/heads/main")
+	if got := hash(); got != initial {
+		t.Errorf("got different hash after changing .git, want the same")
+	}
+
+	write("src/main.go", "package main // changed")
+	changed := hash()
+	if changed == initial {
+		t.Errorf("got the same hash after changing a file, want different")
+	}
+
+	if err := os.Chmod(filepath.Join(dir, "src/main.go"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if got := hash(); got == changed {
+		t.Errorf("got the same hash after making a file executable, want different")
+	}
+
+	if err := os.Rename(filepath.Join(dir, "src"), filepath.Join(dir, "lib")); err != nil {
+		t.Fatal(err)
+	}
+	if got := hash(); got == changed {
+		t.Errorf("got the same hash after renaming a directory, want different")
+	}
+}
+
+func TestDirInGitRepo(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git isn't installed")
+	}
+	dir := t.TempDir()
+	write := func(name, content string) {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	hash := func() string {
+		h, err := Dir(dir)
+		if err != nil {
+			t.Fatalf("got Dir error: %v", err)
+		}
+		return h
+	}
+	if out, err := exec.Command("git", "init", "-q", dir).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v: %s", err, out)
+	}
+
+	write("flake.nix", "{ outputs = _: {}; }")
+	write(".gitignore", "result/\n")
+	initial := hash()
+
+	write("result/bin/hello", "built")
+	write(".devbox/gen/flake.nix", "generated")
+	if got := hash(); got != initial {
+		t.Errorf("got different hash after changing ignored files, want the same")
+	}
+
+	write("src/main.go", "package main")
+	if got := hash(); got == initial {
+		t.Errorf("got the same hash after adding an untracked file, want different")
+	}
+}
+
+func TestDirNotExist(t *testing.T) {
+	hash, err := Dir(t.TempDir() + "/notadir")
+	if err != nil {
+		t.Errorf("got error: %v", err)
+	}
+	if hash != "" {
+		t.Errorf("got non-empty hash %q", hash)
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+// Package container runs commands in ephemeral docker or podman containers
+// that have the project directory and its nix store paths mounted into them.
+package container
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
+
+	"github.com/mattn/go-isatty"
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+)
+
+// DefaultImage is the image that commands run in if no other image is given.
+// The project's packages come from the nix store, so it only has to provide a
+// shell and a few basic directories.
+const DefaultImage = "busybox:stable"
+
+// runtimes are the container runtimes that Devbox can use, in order of
+// preference.
+var runtimes = []string{"docker", "podman"}
+
+// storePathRegexp matches nix store paths, such as
+// /nix/store/ffkbz1w2n3dl0ik8mhjm8qi8di8dma5v-go-1.22.3.
+var storePathRegexp = regexp.MustCompile(`/nix/store/[0-9a-z]{32}-[^/:\s"'=;]+`)
+
+// Opts configures the container that a command runs in.
+type Opts struct {
+	// Image is the container image. It defaults to DefaultImage.
+	Image string
+
+	// ProjectDir is the project directory. It's mounted read-write at the
+	// same path, and is the working directory.
+	ProjectDir string
+
+	// StorePaths are the nix store paths to mount read-only.
+	StorePaths []string
+
+	// Env are the names of the environment variables to pass to the
+	// container. Their values come from the container runtime's
+	// environment.
+	Env []string
+
+	// CPUs and Memory limit the container's resources if they're set.
+	CPUs   float64
+	Memory string
+}
+
+// FindRuntime returns the path to docker or podman.
+func FindRuntime() (string, error) {
+	if runtime.GOOS != "linux" {
+		return "", usererr.New(
+			"Running in a container is only supported on Linux, where the container can run the project's packages.")
+	}
+	for _, name := range runtimes {
+		if path, err := exec.LookPath(name); err == nil {
+			return path, nil
+		}
+	}
+	return "", usererr.New("Running in a container needs docker or podman, but neither is installed.")
+}
+
+// Command returns the command line that runs args in a new container with the
+// given container runtime. The container is removed when the command exits.
+func Command(containerRuntime string, opts Opts, args ...string) []string {
+	image := opts.Image
+	if image == "" {
+		image = DefaultImage
+	}
+
+	cmd := []string{containerRuntime, "run", "--rm", "--interactive"}
+	if isatty.IsTerminal(os.Stdin.Fd()) && isatty.IsTerminal(os.Stdout.Fd()) {
+		cmd = append(cmd, "--tty")
+	}
+	cmd = append(cmd,
+		"--user", strconv.Itoa(os.Getuid())+":"+strconv.Itoa(os.Getgid()),
+		"--workdir", opts.ProjectDir,
+		"--volume", opts.ProjectDir+":"+opts.ProjectDir,
+		"--tmpfs", "/tmp",
+	)
+	for _, path := range opts.StorePaths {
+		cmd = append(cmd, "--volume", path+":"+path+":ro")
+	}
+	if opts.CPUs != 0 {
+		cmd = append(cmd, "--cpus", strconv.FormatFloat(opts.CPUs, 'f', -1, 64))
+	}
+	if opts.Memory != "" {
+		cmd = append(cmd, "--memory", opts.Memory)
+	}
+
+	// The host's home and temporary directories don't exist in the
+	// container.
+	for _, name := range opts.Env {
+		if name != "HOME" && name != "TMPDIR" {
+			cmd = append(cmd, "--env", name)
+		}
+	}
+	cmd = append(cmd, "--env", "HOME=/tmp", "--env", "TMPDIR=/tmp", image)
+
+	// The command's executable is resolved with the container's PATH,
+	// because the host's path might not exist in the container.
+	if len(args) > 0 {
+		args = append([]string{filepath.Base(args[0])}, args[1:]...)
+	}
+	return append(cmd, args...)
+}
+
+// StorePathsInEnv returns the nix store paths that the values of env refer to,
+// such as the directories in PATH.
+func StorePathsInEnv(env map[string]string) []string {
+	paths := []string{}
+	for _, value := range env {
+		paths = append(paths, storePathRegexp.FindAllString(value, -1)...)
+	}
+	slices.Sort(paths)
+	return slices.Compact(paths)
+}
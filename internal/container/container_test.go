@@ -0,0 +1,69 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package container
+
+import (
+	"os"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+func TestCommand(t *testing.T) {
+	opts := Opts{
+		ProjectDir: "/home/user/project",
+		StorePaths: []string{"/nix/store/ffkbz1w2n3dl0ik8mhjm8qi8di8dma5v-go-1.22.3"},
+		Env:        []string{"HOME", "PATH", "TMPDIR"},
+		CPUs:       1.5,
+		Memory:     "4G",
+	}
+	got := Command("/usr/bin/docker", opts, "/nix/store/abc-bash/bin/sh", "-c", "go test")
+	got = slices.DeleteFunc(got, func(arg string) bool { return arg == "--tty" })
+
+	want := []string{
+		"/usr/bin/docker", "run", "--rm", "--interactive",
+		"--user", strconv.Itoa(os.Getuid()) + ":" + strconv.Itoa(os.Getgid()),
+		"--workdir", "/home/user/project",
+		"--volume", "/home/user/project:/home/user/project",
+		"--tmpfs", "/tmp",
+		"--volume", "/nix/store/ffkbz1w2n3dl0ik8mhjm8qi8di8dma5v-go-1.22.3:/nix/store/ffkbz1w2n3dl0ik8mhjm8qi8di8dma5v-go-1.22.3:ro",
+		"--cpus", "1.5",
+		"--memory", "4G",
+		"--env", "PATH",
+		"--env", "HOME=/tmp",
+		"--env", "TMPDIR=/tmp",
+		DefaultImage,
+		"sh", "-c", "go test",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got command\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestCommandImage(t *testing.T) {
+	got := Command("podman", Opts{Image: "ubuntu:24.04", ProjectDir: "/project"}, "sh")
+	if len(got) < 2 || got[len(got)-2] != "ubuntu:24.04" || got[len(got)-1] != "sh" {
+		t.Errorf("got command %q, want it to run sh in ubuntu:24.04", got)
+	}
+	if slices.Contains(got, "--cpus") || slices.Contains(got, "--memory") {
+		t.Errorf("got command %q, want no resource limits", got)
+	}
+}
+
+func TestStorePathsInEnv(t *testing.T) {
+	env := map[string]string{
+		"PATH": "/nix/store/ffkbz1w2n3dl0ik8mhjm8qi8di8dma5v-go-1.22.3/bin:" +
+			"/nix/store/0c2ix4ad8ychgpqc1rkb2l2vn6kpqqw3-bash-5.2p26/bin:/usr/bin",
+		"GOROOT":  "/nix/store/ffkbz1w2n3dl0ik8mhjm8qi8di8dma5v-go-1.22.3/share/go",
+		"EDITOR":  "vim",
+		"INVALID": "/nix/store/tooshort-go",
+	}
+	want := []string{
+		"/nix/store/0c2ix4ad8ychgpqc1rkb2l2vn6kpqqw3-bash-5.2p26",
+		"/nix/store/ffkbz1w2n3dl0ik8mhjm8qi8di8dma5v-go-1.22.3",
+	}
+	if got := StorePathsInEnv(env); !slices.Equal(got, want) {
+		t.Errorf("got store paths %q, want %q", got, want)
+	}
+}
@@ -9,6 +9,8 @@
 	"path/filepath"
 
 	"github.com/pkg/errors"
+
+	"go.jetify.com/devbox/internal/fileutil"
 )
 
 // TODO: add support for .cue
@@ -77,7 +79,7 @@ func WriteFile(path string, value any) error {
 		return errors.WithStack(err)
 	}
 	data = append(data, '\n')
-	return errors.WithStack(os.WriteFile(path, data, 0o644))
+	return fileutil.WriteFileAtomic(path, data, 0o644)
 }
 
 func IsSupportedExtension(ext string) bool {
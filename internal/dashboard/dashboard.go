@@ -0,0 +1,263 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+// Package dashboard implements devbox ui, an interactive terminal dashboard
+// that shows the state of a project's packages, services, scripts and caches.
+package dashboard
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mattn/go-isatty"
+	"github.com/pkg/errors"
+
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+	"go.jetify.com/devbox/internal/devbox"
+	"go.jetify.com/devbox/internal/devbox/devopt"
+	"go.jetify.com/devbox/internal/ux"
+)
+
+// refreshInterval is how often the dashboard reloads the state of services.
+const refreshInterval = 5 * time.Second
+
+// Project is the part of a devbox project that the dashboard shows and acts
+// on. *devbox.Devbox implements it.
+type Project interface {
+	ProjectDir() string
+	Outdated(ctx context.Context) (map[string]devbox.UpdateVersion, error)
+	ServiceStates(ctx context.Context) ([]devbox.ServiceState, bool, error)
+	ScriptRuns() ([]devbox.ScriptRun, error)
+	CacheUsage() []devbox.CacheUsage
+	Update(ctx context.Context, opts devopt.UpdateOpts) error
+	RestartServices(ctx context.Context, runInCurrentShell bool, serviceNames ...string) error
+}
+
+// Stderr is the writer that a Project writes its messages to. It discards
+// them while the dashboard is on screen, and writes them to the underlying
+// writer while the dashboard runs an update or a restart.
+type Stderr struct {
+	mu      sync.Mutex
+	w       io.Writer
+	visible bool
+}
+
+// NewStderr returns a Stderr that writes to w. It writes everything until the
+// dashboard starts.
+func NewStderr(w io.Writer) *Stderr {
+	return &Stderr{w: w, visible: true}
+}
+
+func (s *Stderr) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.visible {
+		return len(p), nil
+	}
+	return s.w.Write(p)
+}
+
+func (s *Stderr) setVisible(visible bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.visible = visible
+}
+
+// outdatedMsg is the result of a check for package updates. gen is the
+// check's generation, so that the results of checks that a refresh replaced
+// are dropped.
+type outdatedMsg struct {
+	gen  int
+	pkgs map[string]devbox.UpdateVersion
+	err  error
+}
+
+type tickMsg struct{}
+
+// actionDoneMsg is sent when an update or a restart finishes.
+type actionDoneMsg struct {
+	success string
+	err     error
+}
+
+// program is the bubbletea model of the dashboard. It loads the state of the
+// project into model, and runs the commands that model returns for keys.
+type program struct {
+	ctx     context.Context
+	project Project
+	stderr  *Stderr
+	model   *model
+	width   int
+
+	// outdatedGen is the generation of the latest check for package updates.
+	// Checks read devbox.lock, so they're canceled and waited for before an
+	// action changes it.
+	outdatedGen    int
+	cancelOutdated context.CancelFunc
+	outdatedChecks sync.WaitGroup
+}
+
+// Run shows the dashboard for a project until the user quits or ctx is
+// canceled.
+func Run(ctx context.Context, p Project, stderr *Stderr) error {
+	if !isatty.IsTerminal(os.Stdin.Fd()) || !isatty.IsTerminal(os.Stdout.Fd()) {
+		return usererr.New("devbox ui needs an interactive terminal")
+	}
+
+	prog := &program{
+		ctx:     ctx,
+		project: p,
+		stderr:  stderr,
+		model:   newModel(p.ProjectDir()),
+	}
+	stderr.setVisible(false)
+	defer stderr.setVisible(true)
+	defer prog.stopOutdated()
+
+	_, err := tea.NewProgram(prog, tea.WithContext(ctx), tea.WithAltScreen()).Run()
+	if errors.Is(err, tea.ErrProgramKilled) && ctx.Err() != nil {
+		return nil
+	}
+	return errors.WithStack(err)
+}
+
+func (p *program) Init() tea.Cmd {
+	p.reload()
+	return tea.Batch(p.loadOutdated(), tick())
+}
+
+func (p *program) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		p.width = msg.Width
+	case tickMsg:
+		p.model.setServices(p.project.ServiceStates(p.ctx))
+		return p, tick()
+	case outdatedMsg:
+		if msg.gen == p.outdatedGen {
+			p.model.setOutdated(msg.pkgs, msg.err)
+		}
+	case actionDoneMsg:
+		p.model.message = msg.success
+		if msg.err != nil {
+			p.model.message = "Error: " + msg.err.Error()
+		}
+		p.reload()
+		return p, p.loadOutdated()
+	case tea.KeyMsg:
+		return p, p.handleKey(msg.String())
+	}
+	return p, nil
+}
+
+func (p *program) View() string {
+	return p.model.view(p.width)
+}
+
+func (p *program) handleKey(key string) tea.Cmd {
+	cmd := p.model.handleKey(key)
+	switch cmd.kind {
+	case quitCommand:
+		return tea.Quit
+	case refreshCommand:
+		p.model.message = ""
+		p.reload()
+		return p.loadOutdated()
+	case updateCommand:
+		return p.runAction(fmt.Sprintf("Updated %s.", cmd.target), func() error {
+			return p.project.Update(p.ctx, devopt.UpdateOpts{Pkgs: []string{cmd.target}})
+		})
+	case updateAllCommand:
+		return p.runAction("Updated all packages.", func() error {
+			return p.project.Update(p.ctx, devopt.UpdateOpts{})
+		})
+	case restartCommand:
+		return p.runAction(fmt.Sprintf("Restarted %s.", cmd.target), func() error {
+			return p.project.RestartServices(p.ctx, false, cmd.target)
+		})
+	}
+	return nil
+}
+
+// loadOutdated returns a command that checks for package updates in the
+// background, because it can take a while to search for the latest versions.
+// It cancels the previous check, whose result is then dropped.
+func (p *program) loadOutdated() tea.Cmd {
+	if p.cancelOutdated != nil {
+		p.cancelOutdated()
+	}
+	p.outdatedGen++
+	p.model.outdatedLoading = true
+
+	ctx, cancel := context.WithCancel(p.ctx)
+	p.cancelOutdated = cancel
+	p.outdatedChecks.Add(1)
+	gen := p.outdatedGen
+	return func() tea.Msg {
+		defer p.outdatedChecks.Done()
+		pkgs, err := p.project.Outdated(ctx)
+		return outdatedMsg{gen: gen, pkgs: pkgs, err: err}
+	}
+}
+
+// stopOutdated cancels the checks for package updates that are running and
+// waits for them to return.
+func (p *program) stopOutdated() {
+	if p.cancelOutdated != nil {
+		p.cancelOutdated()
+	}
+	p.outdatedChecks.Wait()
+}
+
+func (p *program) reload() {
+	p.model.setServices(p.project.ServiceStates(p.ctx))
+	p.model.setRuns(p.project.ScriptRuns())
+	p.model.setCaches(p.project.CacheUsage())
+}
+
+// runAction returns a command that leaves the dashboard to run action with
+// its output visible, waits for the user to press enter, and then shows the
+// dashboard again. The check for package updates is stopped first, because
+// it reads devbox.lock while an update writes it.
+func (p *program) runAction(success string, action func() error) tea.Cmd {
+	run := &actionCommand{
+		run: func(stdin io.Reader) error {
+			p.stopOutdated()
+			p.stderr.setVisible(true)
+			defer p.stderr.setVisible(false)
+
+			err := action()
+			if err != nil {
+				ux.Ferrorf(p.stderr, "%v\n", err)
+			}
+			fmt.Fprint(p.stderr, "\nPress enter to return to the dashboard.")
+			_, _ = bufio.NewReader(stdin).ReadString('\n')
+			return err
+		},
+	}
+	return tea.Exec(run, func(err error) tea.Msg {
+		return actionDoneMsg{success: success, err: err}
+	})
+}
+
+// actionCommand runs a function outside of the dashboard, with the terminal
+// released by bubbletea.
+type actionCommand struct {
+	run   func(stdin io.Reader) error
+	stdin io.Reader
+}
+
+func (c *actionCommand) Run() error           { return c.run(c.stdin) }
+func (c *actionCommand) SetStdin(r io.Reader) { c.stdin = r }
+func (c *actionCommand) SetStdout(io.Writer)  {}
+func (c *actionCommand) SetStderr(io.Writer)  {}
+
+func tick() tea.Cmd {
+	return tea.Tick(refreshInterval, func(time.Time) tea.Msg { return tickMsg{} })
+}
@@ -0,0 +1,290 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package dashboard
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	"go.jetify.com/devbox/internal/devbox"
+)
+
+// panel is a section of the dashboard that can have the focus.
+type panel int
+
+const (
+	packagesPanel panel = iota
+	servicesPanel
+	runsPanel
+	cachesPanel
+	numPanels
+)
+
+// commandKind is something that the dashboard does in response to a key.
+type commandKind int
+
+const (
+	noCommand commandKind = iota
+	quitCommand
+	refreshCommand
+	updateCommand
+	updateAllCommand
+	restartCommand
+)
+
+// command is a commandKind with the package or service it applies to.
+type command struct {
+	kind   commandKind
+	target string
+}
+
+type outdatedPackage struct {
+	name    string
+	current string
+	latest  string
+}
+
+// model is the state of the dashboard. It's updated by handleKey and by the
+// load functions, and rendered by view.
+type model struct {
+	projectDir string
+	focus      panel
+	cursor     [numPanels]int
+	now        func() time.Time
+
+	outdated        []outdatedPackage
+	outdatedLoading bool
+	outdatedErr     error
+
+	services        []devbox.ServiceState
+	servicesRunning bool
+	servicesErr     error
+
+	runs    []devbox.ScriptRun
+	runsErr error
+
+	caches []devbox.CacheUsage
+
+	// message is a one-line status, such as the result of the last action.
+	message string
+}
+
+func newModel(projectDir string) *model {
+	return &model{projectDir: projectDir, outdatedLoading: true, now: time.Now}
+}
+
+func (m *model) setOutdated(pkgs map[string]devbox.UpdateVersion, err error) {
+	m.outdatedLoading = false
+	m.outdatedErr = err
+	m.outdated = m.outdated[:0]
+	for name, version := range pkgs {
+		m.outdated = append(m.outdated, outdatedPackage{
+			name:    name,
+			current: version.Current,
+			latest:  version.Latest,
+		})
+	}
+	slices.SortFunc(m.outdated, func(a, b outdatedPackage) int { return strings.Compare(a.name, b.name) })
+	m.clampCursor(packagesPanel)
+}
+
+func (m *model) setServices(states []devbox.ServiceState, running bool, err error) {
+	m.services, m.servicesRunning, m.servicesErr = states, running, err
+	m.clampCursor(servicesPanel)
+}
+
+func (m *model) setRuns(runs []devbox.ScriptRun, err error) {
+	m.runs, m.runsErr = runs, err
+	m.clampCursor(runsPanel)
+}
+
+func (m *model) setCaches(caches []devbox.CacheUsage) {
+	m.caches = caches
+	m.clampCursor(cachesPanel)
+}
+
+func (m *model) rows(p panel) int {
+	switch p {
+	case packagesPanel:
+		return len(m.outdated)
+	case servicesPanel:
+		return len(m.services)
+	case runsPanel:
+		return len(m.runs)
+	case cachesPanel:
+		return len(m.caches)
+	default:
+		return 0
+	}
+}
+
+func (m *model) clampCursor(p panel) {
+	m.cursor[p] = max(0, min(m.cursor[p], m.rows(p)-1))
+}
+
+// handleKey updates the model for a key press and returns the command that
+// the key asks for, if any.
+func (m *model) handleKey(key string) command {
+	switch key {
+	case "q", "ctrl+c", "esc":
+		return command{kind: quitCommand}
+	case "tab", "right", "l":
+		m.focus = (m.focus + 1) % numPanels
+	case "shift+tab", "left", "h":
+		m.focus = (m.focus + numPanels - 1) % numPanels
+	case "down", "j":
+		m.cursor[m.focus]++
+		m.clampCursor(m.focus)
+	case "up", "k":
+		m.cursor[m.focus]--
+		m.clampCursor(m.focus)
+	case "R":
+		return command{kind: refreshCommand}
+	case "u":
+		if m.focus == packagesPanel && len(m.outdated) > 0 {
+			return command{kind: updateCommand, target: m.outdated[m.cursor[packagesPanel]].name}
+		}
+	case "U":
+		if len(m.outdated) > 0 {
+			return command{kind: updateAllCommand}
+		}
+	case "r":
+		if m.focus == servicesPanel && len(m.services) > 0 {
+			return command{kind: restartCommand, target: m.services[m.cursor[servicesPanel]].Name}
+		}
+	}
+	return command{}
+}
+
+// view renders the dashboard, cutting lines to width.
+func (m *model) view(width int) string {
+	lines := []string{
+		bold("devbox ui") + " - " + m.projectDir,
+		"",
+	}
+
+	title := "Packages with updates"
+	switch {
+	case m.outdatedLoading:
+		title += " (checking...)"
+	case m.outdatedErr == nil:
+		title += fmt.Sprintf(" (%d)", len(m.outdated))
+	}
+	rows := make([]string, len(m.outdated))
+	for i, pkg := range m.outdated {
+		rows[i] = fmt.Sprintf("%-30s %s -> %s", pkg.name, pkg.current, pkg.latest)
+	}
+	lines = append(lines, m.panelLines(packagesPanel, title, rows, m.outdatedErr,
+		lo.Ternary(m.outdatedLoading, "", "All packages are up to date."))...)
+
+	title = "Services"
+	if len(m.services) > 0 {
+		title += lo.Ternary(m.servicesRunning, " (process-compose is running)", " (process-compose isn't running)")
+	}
+	rows = make([]string, len(m.services))
+	for i, svc := range m.services {
+		status := svc.Status
+		if status == "" {
+			status = "stopped"
+		}
+		rows[i] = fmt.Sprintf("%-30s %-12s %-12s %d restarts", svc.Name, status, svc.Health, svc.Restarts)
+	}
+	lines = append(lines, m.panelLines(servicesPanel, title, rows, m.servicesErr, "No services.")...)
+
+	rows = make([]string, len(m.runs))
+	for i, run := range m.runs {
+		rows[i] = fmt.Sprintf("%-30s %-10s %-10s exit %d",
+			run.Name, formatAge(m.now().Sub(run.Started)), run.Duration.Round(100*time.Millisecond), run.ExitCode)
+	}
+	lines = append(lines, m.panelLines(runsPanel, "Recent script runs", rows, m.runsErr, "No script runs yet.")...)
+
+	rows = make([]string, len(m.caches))
+	for i, cache := range m.caches {
+		rows[i] = fmt.Sprintf("%-30s %10s  %s", cache.Name, formatBytes(cache.Bytes), cache.Path)
+	}
+	lines = append(lines, m.panelLines(cachesPanel, "Caches", rows, nil, "")...)
+
+	lines = append(lines,
+		"tab: switch panel  up/down: move  u: update  U: update all  r: restart service  R: refresh  q: quit")
+	if m.message != "" {
+		lines = append(lines, m.message)
+	}
+
+	for i, line := range lines {
+		lines[i] = truncate(line, width)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (m *model) panelLines(p panel, title string, rows []string, err error, empty string) []string {
+	focused := m.focus == p
+	lines := []string{lo.Ternary(focused, "> ", "  ") + lo.Ternary(focused, bold(title), title)}
+	switch {
+	case err != nil:
+		lines = append(lines, "    Error: "+err.Error())
+	case len(rows) == 0 && empty != "":
+		lines = append(lines, "    "+empty)
+	}
+	for i, row := range rows {
+		lines = append(lines, lo.Ternary(focused && i == m.cursor[p], "  * ", "    ")+row)
+	}
+	return append(lines, "")
+}
+
+func bold(s string) string {
+	return "\x1b[1m" + s + "\x1b[0m"
+}
+
+// truncate cuts s to width runes, not counting escape sequences.
+func truncate(s string, width int) string {
+	if width <= 0 {
+		return s
+	}
+	sb := strings.Builder{}
+	n := 0
+	inEscape := false
+	for _, r := range s {
+		switch {
+		case inEscape:
+			inEscape = r != 'm'
+		case r == '\x1b':
+			inEscape = true
+		case n >= width:
+			continue
+		default:
+			n++
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	}
+}
+
+func formatBytes(b int64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+	div, exp := int64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,164 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package dashboard
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"go.jetify.com/devbox/internal/devbox"
+	"go.jetify.com/devbox/internal/devbox/devopt"
+)
+
+func testModel() *model {
+	m := newModel("/project")
+	m.now = func() time.Time { return time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC) }
+	m.setOutdated(map[string]devbox.UpdateVersion{
+		"python": {Current: "3.11.0", Latest: "3.12.1"},
+		"go":     {Current: "1.21.0", Latest: "1.22.0"},
+	}, nil)
+	m.setServices([]devbox.ServiceState{
+		{Name: "postgres", Status: "Running", Health: "Ready"},
+		{Name: "redis", Status: "Running", Restarts: 2},
+	}, true, nil)
+	m.setRuns([]devbox.ScriptRun{{
+		Name:     "test",
+		Started:  m.now().Add(-5 * time.Minute),
+		Duration: 1500 * time.Millisecond,
+		ExitCode: 1,
+	}}, nil)
+	return m
+}
+
+func TestHandleKey(t *testing.T) {
+	m := testModel()
+
+	// Packages are sorted, and the cursor stops at the last one.
+	for _, key := range []string{"j", "down", "down"} {
+		m.handleKey(key)
+	}
+	if got, want := m.handleKey("u"), (command{kind: updateCommand, target: "python"}); got != want {
+		t.Errorf("handleKey(u) = %+v, want %+v", got, want)
+	}
+	if got := m.handleKey("r"); got.kind != noCommand {
+		t.Errorf("handleKey(r) in the packages panel = %+v, want no command", got)
+	}
+
+	m.handleKey("tab")
+	m.handleKey("down")
+	if got, want := m.handleKey("r"), (command{kind: restartCommand, target: "redis"}); got != want {
+		t.Errorf("handleKey(r) = %+v, want %+v", got, want)
+	}
+	if got := m.handleKey("u"); got.kind != noCommand {
+		t.Errorf("handleKey(u) in the services panel = %+v, want no command", got)
+	}
+	if got := m.handleKey("U"); got.kind != updateAllCommand {
+		t.Errorf("handleKey(U) = %+v, want update all", got)
+	}
+
+	m.handleKey("shift+tab")
+	m.handleKey("left")
+	if m.focus != cachesPanel {
+		t.Errorf("focus = %v after moving back twice, want %v", m.focus, cachesPanel)
+	}
+	for _, key := range []string{"q", "esc", "ctrl+c"} {
+		if got := m.handleKey(key); got.kind != quitCommand {
+			t.Errorf("handleKey(%s) = %+v, want quit", key, got)
+		}
+	}
+}
+
+func TestView(t *testing.T) {
+	m := testModel()
+	m.setCaches([]devbox.CacheUsage{{Name: "Devbox cache", Path: "/cache", Bytes: 3 << 20}})
+	got := m.view(0)
+	for _, want := range []string{
+		"Packages with updates (2)",
+		"  * go                             1.21.0 -> 1.22.0",
+		"postgres                       Running      Ready        0 restarts",
+		"test                           5m ago     1.5s       exit 1",
+		"Devbox cache                      3.0 MiB  /cache",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("view() is missing %q, got:\n%s", want, got)
+		}
+	}
+
+	m.setOutdated(nil, errors.New("search is down"))
+	m.setServices(nil, false, nil)
+	got = m.view(0)
+	for _, want := range []string{"Error: search is down", "No services."} {
+		if !strings.Contains(got, want) {
+			t.Errorf("view() is missing %q, got:\n%s", want, got)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	if got, want := truncate(bold("hello")+" world", 7), bold("hello")+" w"; got != want {
+		t.Errorf("truncate() = %q, want %q", got, want)
+	}
+	if got, want := truncate("héllo", 2), "hé"; got != want {
+		t.Errorf("truncate() = %q, want %q", got, want)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := map[int64]string{
+		512:                "512 B",
+		2048:               "2.0 KiB",
+		5 << 30:            "5.0 GiB",
+		1536 * 1024 * 1024: "1.5 GiB",
+	}
+	for in, want := range tests {
+		if got := formatBytes(in); got != want {
+			t.Errorf("formatBytes(%d) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// fakeProject is a Project whose update check blocks until it's canceled.
+type fakeProject struct{}
+
+func (*fakeProject) ProjectDir() string { return "/project" }
+
+func (*fakeProject) Outdated(ctx context.Context) (map[string]devbox.UpdateVersion, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (*fakeProject) ServiceStates(context.Context) ([]devbox.ServiceState, bool, error) {
+	return nil, false, nil
+}
+func (*fakeProject) ScriptRuns() ([]devbox.ScriptRun, error)                { return nil, nil }
+func (*fakeProject) CacheUsage() []devbox.CacheUsage                        { return nil }
+func (*fakeProject) Update(context.Context, devopt.UpdateOpts) error        { return nil }
+func (*fakeProject) RestartServices(context.Context, bool, ...string) error { return nil }
+
+func TestLoadOutdatedDropsReplacedChecks(t *testing.T) {
+	p := &program{ctx: context.Background(), project: &fakeProject{}, model: newModel("/project")}
+
+	first := p.loadOutdated()
+	firstMsg := make(chan tea.Msg, 1)
+	go func() { firstMsg <- first() }()
+
+	// A refresh cancels the first check, and its result is dropped.
+	second := p.loadOutdated()
+	p.Update(<-firstMsg)
+	if !p.model.outdatedLoading {
+		t.Error("the result of a replaced check was shown")
+	}
+
+	secondMsg := make(chan tea.Msg, 1)
+	go func() { secondMsg <- second() }()
+	p.stopOutdated()
+	p.Update(<-secondMsg)
+	if p.model.outdatedLoading {
+		t.Error("the result of the latest check wasn't shown")
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"go.jetify.com/devbox/internal/cachehash"
+	"go.jetify.com/devbox/internal/fileutil"
+	"go.jetify.com/devbox/internal/nix"
+	"go.jetify.com/devbox/internal/ux"
+)
+
+// maxActivations is how many environment activations devbox remembers per
+// project.
+const maxActivations = 100
+
+// shellenvRecordInterval is how often devbox shellenv activations are
+// recorded while devbox.lock doesn't change.
+const shellenvRecordInterval = 10 * time.Minute
+
+// Activation records an activation of the project's environment by devbox
+// shell, devbox run or devbox shellenv.
+type Activation struct {
+	Time    time.Time `json:"time"`
+	Command string    `json:"command"`
+	// LockHash is the hash of devbox.lock when the environment was
+	// activated, so that a change of hash shows when the environment
+	// changed.
+	LockHash string `json:"lock_hash"`
+	// Duration is how long it took to compute the environment.
+	Duration time.Duration `json:"duration"`
+}
+
+func activationsPath(projectDir string) string {
+	return filepath.Join(nix.ProjectUserDir(projectDir), "activations.json")
+}
+
+// Activations returns the project's most recent environment activations,
+// newest first.
+func (d *Devbox) Activations() ([]Activation, error) {
+	data, err := os.ReadFile(activationsPath(d.projectDir))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	activations := []Activation{}
+	if err := json.Unmarshal(data, &activations); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return activations, nil
+}
+
+// recordActivation remembers an activation of the environment by command,
+// which started computing the environment at started. Failing to record an
+// activation doesn't fail the command.
+func (d *Devbox) recordActivation(command string, started time.Time) {
+	lockHash, err := cachehash.JSONFile(filepath.Join(d.projectDir, "devbox.lock"))
+	if err != nil {
+		slog.Debug("failed to hash devbox.lock for the activation history", "err", err)
+	}
+	activation := Activation{
+		Time:     started,
+		Command:  command,
+		LockHash: lockHash,
+		Duration: time.Since(started).Round(time.Millisecond),
+	}
+	if err := d.saveActivation(activation); err != nil {
+		slog.Debug("failed to record environment activation", "command", command, "err", err)
+	}
+	d.recordMetric(MetricSample{
+		Time:     started,
+		Name:     MetricShellStartup,
+		Command:  command,
+		Duration: activation.Duration,
+	})
+}
+
+// saveActivation adds an activation to the history file. It holds a lock on
+// the history while it reads and rewrites it, so that activations from
+// concurrent commands aren't lost.
+func (d *Devbox) saveActivation(activation Activation) error {
+	if err := nix.EnsureProjectUserDir(d.projectDir); err != nil {
+		return err
+	}
+	path := activationsPath(d.projectDir)
+	unlock, err := lockActivations(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	activations, err := d.Activations()
+	if err != nil {
+		// The file is written atomically, so this only happens if
+		// something else changed it. Keep it for inspection rather than
+		// losing the history without a word.
+		ux.Fwarningf(d.stderr, "The environment activation history is unreadable, so devbox moved it to %s.corrupt and started a new one: %v\n", path, err)
+		if err := os.Rename(path, path+".corrupt"); err != nil {
+			return errors.WithStack(err)
+		}
+		activations = nil
+	}
+	activations, changed := addActivation(activations, activation)
+	if !changed {
+		return nil
+	}
+	data, err := json.Marshal(activations)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return fileutil.WriteFileAtomic(path, data, 0o644)
+}
+
+// addActivation returns activations with activation added first, keeping the
+// newest maxActivations. devbox shellenv runs every time direnv reloads the
+// environment, so it isn't recorded again while devbox.lock doesn't change,
+// until shellenvRecordInterval has passed. It returns false if activation
+// wasn't added.
+func addActivation(activations []Activation, activation Activation) ([]Activation, bool) {
+	if activation.Command == "shellenv" {
+		for _, a := range activations {
+			if a.Command != "shellenv" {
+				continue
+			}
+			if a.LockHash == activation.LockHash && activation.Time.Sub(a.Time) < shellenvRecordInterval {
+				return activations, false
+			}
+			break
+		}
+	}
+	activations = append([]Activation{activation}, activations...)
+	if len(activations) > maxActivations {
+		activations = activations[:maxActivations]
+	}
+	return activations, true
+}
+
+// lockActivations takes an exclusive lock on the activation history in path.
+// The lock is on a separate file, because the history is replaced when it's
+// written. Call the returned function to release the lock.
+func lockActivations(path string) (func(), error) {
+	file, err := os.OpenFile(path+".lock", os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX); err != nil {
+		file.Close()
+		return nil, errors.WithStack(err)
+	}
+	return func() { file.Close() }, nil
+}
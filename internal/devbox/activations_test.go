@@ -0,0 +1,102 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAddActivation(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	activations := []Activation{}
+	for i := range maxActivations + 5 {
+		var added bool
+		activations, added = addActivation(activations, Activation{
+			Time:    start.Add(time.Duration(i) * time.Minute),
+			Command: fmt.Sprintf("run test%d", i),
+		})
+		if !added {
+			t.Fatalf("activation %d wasn't added", i)
+		}
+	}
+
+	// The history keeps the newest activations, newest first.
+	if len(activations) != maxActivations {
+		t.Fatalf("got %d activations, want %d", len(activations), maxActivations)
+	}
+	if got, want := activations[0].Command, fmt.Sprintf("run test%d", maxActivations+4); got != want {
+		t.Errorf("got newest activation %q, want %q", got, want)
+	}
+	if got, want := activations[maxActivations-1].Command, "run test5"; got != want {
+		t.Errorf("got oldest activation %q, want %q", got, want)
+	}
+	for i := 1; i < len(activations); i++ {
+		if activations[i].Time.After(activations[i-1].Time) {
+			t.Fatalf("activation %d is newer than activation %d", i, i-1)
+		}
+	}
+}
+
+func TestAddActivationShellenv(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	shellenv := func(after time.Duration, lockHash string) Activation {
+		return Activation{Time: start.Add(after), Command: "shellenv", LockHash: lockHash}
+	}
+	activations, _ := addActivation(nil, shellenv(0, "a"))
+	activations, _ = addActivation(activations, Activation{Time: start.Add(time.Minute), Command: "shell", LockHash: "a"})
+
+	tests := []struct {
+		name       string
+		activation Activation
+		wantAdded  bool
+	}{
+		{"direnv reload", shellenv(2*time.Minute, "a"), false},
+		{"devbox.lock changed", shellenv(3*time.Minute, "b"), true},
+		{"interval passed", shellenv(shellenvRecordInterval+4*time.Minute, "b"), true},
+	}
+	for _, tt := range tests {
+		var added bool
+		activations, added = addActivation(activations, tt.activation)
+		if added != tt.wantAdded {
+			t.Errorf("%s: got added %v, want %v", tt.name, added, tt.wantAdded)
+		}
+	}
+	if len(activations) != 4 {
+		t.Errorf("got %d activations, want 4", len(activations))
+	}
+}
+
+func TestSaveActivationConcurrent(t *testing.T) {
+	d := &Devbox{projectDir: t.TempDir(), stderr: io.Discard}
+	start := time.Now()
+
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := d.saveActivation(Activation{Time: start.Add(time.Duration(i)), Command: fmt.Sprintf("run test%d", i)})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	activations, err := d.Activations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(activations) != 20 {
+		t.Errorf("got %d activations, want 20", len(activations))
+	}
+	if _, err := os.Stat(activationsPath(d.projectDir) + ".corrupt"); err == nil {
+		t.Error("the history was corrupted")
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+	"go.jetify.com/devbox/internal/devbox/devopt"
+)
+
+// layerAddedProjects computes the environments of the added projects on top
+// of env, so that the project's environment can be computed on top of theirs.
+//
+// The precedence of the layers, from highest to lowest, is:
+//
+//  1. The project itself.
+//  2. The projects added with --add-project, in the order of the flags.
+//  3. The projects in add_projects of devbox.json, in their order.
+//  4. The current environment.
+//
+// The packages of a layer come before those of lower layers in PATH, and its
+// variables override theirs. The added projects of an added project aren't
+// layered.
+func (d *Devbox) layerAddedProjects(
+	ctx context.Context,
+	env map[string]string,
+	envOpts devopt.EnvOptions,
+	sources *envSources,
+) (map[string]string, error) {
+	seen := map[string]bool{d.projectDir: true}
+	dirs := slices.DeleteFunc(slices.Clone(d.addedProjects), func(dir string) bool {
+		if seen[dir] {
+			return true
+		}
+		seen[dir] = true
+		return false
+	})
+
+	// Start with the lowest layer.
+	for _, dir := range slices.Backward(dirs) {
+		project, err := Open(&devopt.Opts{
+			Dir:         dir,
+			Environment: d.environment,
+			Stderr:      d.stderr,
+		})
+		if err != nil {
+			return nil, usererr.WithUserMessage(err, "Unable to open the added project %s.", dir)
+		}
+		project.addedProjects = nil
+
+		if !envOpts.SkipRecompute {
+			if err := project.ensureStateIsUpToDate(ctx, ensure); err != nil {
+				return nil, err
+			}
+		}
+		before := env
+		env, err = project.traceEnvFrom(ctx, maps.Clone(env), true /*usePrintDevEnvCache*/, envOpts, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		// Let the devbox.json variables of higher layers override the ones
+		// of this project.
+		for key := range env {
+			if _, ok := before[key]; !ok && strings.HasPrefix(key, devboxSetPrefix) {
+				delete(env, key)
+			}
+		}
+		sources.record("added project "+dir, env)
+	}
+	return env, nil
+}
@@ -0,0 +1,232 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+	"go.jetify.com/devbox/internal/cachehash"
+	"go.jetify.com/devbox/internal/envir"
+	"go.jetify.com/devbox/internal/httpclient"
+	"go.jetify.com/devbox/internal/lock"
+	"go.jetify.com/devbox/internal/ux"
+)
+
+// auditWebhookTimeout is how long devbox waits for an audit webhook to
+// respond.
+const auditWebhookTimeout = 10 * time.Second
+
+// AuditRecord is a record in the audit log of a command that changed, or
+// tried to change, the project's environment.
+type AuditRecord struct {
+	Time    time.Time `json:"time"`
+	User    string    `json:"user"`
+	Host    string    `json:"host"`
+	Project string    `json:"project"`
+	// Command is add, rm, update or install, and Args are the packages
+	// that it was run with.
+	Command string   `json:"command"`
+	Args    []string `json:"args,omitempty"`
+	// LockHashBefore and LockHashAfter are the hashes of devbox.lock
+	// before and after the command. They're empty if there wasn't one.
+	LockHashBefore string `json:"lock_hash_before"`
+	LockHashAfter  string `json:"lock_hash_after"`
+	// Changes are the packages whose locked versions changed.
+	Changes []lock.Change `json:"changes"`
+	// Error is why the command failed, if it did.
+	Error string `json:"error,omitempty"`
+}
+
+// trackCommand starts tracking a command that changes the project's
+// environment, so that it's recorded in the audit log and sent to the
+// notifications, if the project or DEVBOX_AUDIT_WEBHOOK configures any. Call
+// the returned function with the command's error once it's done. Commands that
+// a tracked command runs, such as the devbox add that devbox update runs for
+// legacy packages, aren't tracked separately. Failing to record or notify a
+// command doesn't fail it.
+func (d *Devbox) trackCommand(ctx context.Context, command string, args []string) func(error) {
+	logPath, webhooks := d.auditSinks()
+	targets := d.notifyTargets()
+	if d.trackingCommand || (logPath == "" && len(webhooks) == 0 && len(targets) == 0) {
+		return func(error) {}
+	}
+	d.trackingCommand = true
+
+	record := AuditRecord{
+		Time:    time.Now().UTC(),
+		User:    auditUser(),
+		Project: d.projectDir,
+		Command: command,
+		Args:    args,
+	}
+	record.Host, _ = os.Hostname()
+	before, beforeHash := d.readLockfileForAudit()
+	return func(err error) {
+		d.trackingCommand = false
+		after, afterHash := d.readLockfileForAudit()
+		record.LockHashBefore = beforeHash
+		record.LockHashAfter = afterHash
+		record.Changes = lock.Diff(before, after)
+		if err != nil {
+			record.Error = err.Error()
+		}
+
+		if logPath != "" {
+			if err := appendAuditRecord(logPath, record); err != nil {
+				ux.Fwarningf(d.stderr, "Failed to write the audit log %s: %v\n", logPath, err)
+			}
+		}
+		// Post the record even if the command was interrupted.
+		postCtx := context.WithoutCancel(ctx)
+		for _, webhook := range webhooks {
+			if err := postAuditRecord(postCtx, webhook, record); err != nil {
+				ux.Fwarningf(d.stderr, "Failed to send the audit record to %s: %v\n", webhook, err)
+			}
+		}
+		d.notifyCommand(postCtx, targets, record, err)
+	}
+}
+
+// auditSinks returns the path of the project's audit log and the webhooks
+// that audit records are posted to. The log must be in the project directory,
+// and devbox only posts to the webhook in devbox.json if the user trusts the
+// project, so that a cloned project can't write to files or send records
+// elsewhere.
+func (d *Devbox) auditSinks() (logPath string, webhooks []string) {
+	if audit := d.cfg.Root.Audit; audit != nil {
+		if audit.Log != "" {
+			var err error
+			logPath, err = auditLogPath(d.projectDir, audit.Log)
+			if err != nil {
+				ux.Fwarningf(d.stderr, "Skipping the audit log in devbox.json: %v\n", err)
+			}
+		}
+		if audit.Webhook != "" {
+			if err := d.ensureTrusted("send audit records to the webhook in devbox.json"); err != nil {
+				ux.Fwarningf(d.stderr, "Skipping the audit webhook in devbox.json: %v\n", err)
+			} else {
+				webhooks = append(webhooks, audit.Webhook)
+			}
+		}
+	}
+	if webhook := os.Getenv(envir.DevboxAuditWebhook); webhook != "" {
+		webhooks = append(webhooks, webhook)
+	}
+	return logPath, webhooks
+}
+
+// auditLogPath returns the absolute path of the audit log that devbox.json
+// configures as log. It returns an error if the log isn't in projectDir,
+// including through a symlink.
+func auditLogPath(projectDir, log string) (string, error) {
+	path := log
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(projectDir, path)
+	}
+	path = filepath.Clean(path)
+	if !isInDir(projectDir, path) || !isInDir(evalSymlinks(projectDir), evalSymlinks(path)) {
+		return "", usererr.New("The audit log %s isn't in the project directory %s.", log, projectDir)
+	}
+	return path, nil
+}
+
+// isInDir reports whether the clean path is in dir.
+func isInDir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// evalSymlinks resolves the symlinks in path, or in its directory if path
+// doesn't exist yet.
+func evalSymlinks(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	if dir, err := filepath.EvalSymlinks(filepath.Dir(path)); err == nil {
+		return filepath.Join(dir, filepath.Base(path))
+	}
+	return path
+}
+
+// readLockfileForAudit reads devbox.lock from disk and returns it with its
+// hash. It returns nil if the project doesn't have a readable one.
+func (d *Devbox) readLockfileForAudit() (*lock.File, string) {
+	path := filepath.Join(d.projectDir, "devbox.lock")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, ""
+	}
+	hash, _ := cachehash.JSONFile(path)
+	f, err := lock.ParseBytes(data)
+	if err != nil {
+		return nil, hash
+	}
+	return f, hash
+}
+
+func auditUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv(envir.User)
+}
+
+// appendAuditRecord appends record to the audit log in path as a line of
+// JSON. The log is only appended to, and it's locked while the record is
+// written so that records from concurrent commands don't interleave.
+func appendAuditRecord(path string, record AuditRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return errors.WithStack(err)
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer file.Close()
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX); err != nil {
+		return errors.WithStack(err)
+	}
+	_, err = file.Write(append(data, '\n'))
+	return errors.WithStack(err)
+}
+
+// postAuditRecord posts record as JSON to webhook.
+func postAuditRecord(ctx context.Context, webhook string, record AuditRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, auditWebhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook, bytes.NewReader(data))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := httpclient.Client().Do(req)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return errors.Errorf("POST %s: %s", webhook, res.Status)
+	}
+	return nil
+}
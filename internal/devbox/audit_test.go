@@ -0,0 +1,113 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestAppendAuditRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit", "devbox-audit.jsonl")
+
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			record := AuditRecord{Command: "add", Args: []string{fmt.Sprintf("pkg%d@latest", i)}}
+			if err := appendAuditRecord(path, record); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	lines := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		record := AuditRecord{}
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("line %d isn't a record: %v", lines+1, err)
+		}
+		if record.Command != "add" || len(record.Args) != 1 {
+			t.Errorf("got record %+v on line %d", record, lines+1)
+		}
+		lines++
+	}
+	if lines != 20 {
+		t.Errorf("got %d records, want 20", lines)
+	}
+}
+
+func TestPostAuditRecord(t *testing.T) {
+	var got AuditRecord
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	want := AuditRecord{Command: "update", LockHashBefore: "abc", LockHashAfter: "def"}
+	if err := postAuditRecord(context.Background(), server.URL, want); err != nil {
+		t.Fatal(err)
+	}
+	if got.Command != want.Command || got.LockHashBefore != want.LockHashBefore || got.LockHashAfter != want.LockHashAfter {
+		t.Errorf("got record %+v, want %+v", got, want)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer failing.Close()
+	if err := postAuditRecord(context.Background(), failing.URL, want); err == nil {
+		t.Error("got no error for a webhook that responded 403 Forbidden")
+	}
+}
+
+func TestAuditLogPath(t *testing.T) {
+	projectDir := t.TempDir()
+	outside := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(projectDir, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		log     string
+		want    string
+		wantErr bool
+	}{
+		{log: "audit.log", want: filepath.Join(projectDir, "audit.log")},
+		{log: ".devbox/audit/log.jsonl", want: filepath.Join(projectDir, ".devbox/audit/log.jsonl")},
+		{log: filepath.Join(projectDir, "audit.log"), want: filepath.Join(projectDir, "audit.log")},
+		{log: "../audit.log", wantErr: true},
+		{log: filepath.Join(outside, "audit.log"), wantErr: true},
+		{log: "link/audit.log", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.log, func(t *testing.T) {
+			got, err := auditLogPath(projectDir, test.log)
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("got auditLogPath() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if got != test.want {
+				t.Errorf("got auditLogPath() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/samber/lo"
+
+	"go.jetify.com/devbox/internal/devpkg"
+	"go.jetify.com/devbox/internal/nix"
+)
+
+func (d *Devbox) buildLogsDir() string {
+	return filepath.Join(d.projectDir, ".devbox", "logs", "build")
+}
+
+// BuildLogs returns the logs of the project's nix builds, newest first. If
+// pkg isn't empty, it only returns the logs of that package, which is either
+// a package in devbox.json or the name of a derivation such as hello-2.12.1.
+func (d *Devbox) BuildLogs(pkg string) ([]nix.BuildLog, error) {
+	logs, err := nix.BuildLogs(d.buildLogsDir())
+	if err != nil || pkg == "" {
+		return logs, err
+	}
+
+	names := []string{pkg}
+	prefix := pkg
+	if p, err := d.findPackageByName(pkg); err == nil {
+		names, prefix = buildLogNames(p)
+	}
+	return lo.Filter(logs, func(log nix.BuildLog, _ int) bool {
+		for _, name := range names {
+			// Outputs other than out have a suffix, such as
+			// python3-3.12.1-dev.
+			if log.Name == name || strings.HasPrefix(name, log.Name+"-") {
+				return true
+			}
+		}
+		return prefix != "" && strings.HasPrefix(log.Name, prefix+"-")
+	}), nil
+}
+
+// buildLogNames returns the derivation names that a package's builds are
+// logged as. Derivation names don't always start with the package's name,
+// such as python3-3.12.1 for python@3.12, so they're taken from the store
+// paths in devbox.lock, with the package's name as a fallback prefix.
+func buildLogNames(p *devpkg.Package) (names []string, prefix string) {
+	paths, _ := p.GetResolvedStorePaths()
+	for _, path := range paths {
+		names = append(names, nix.StorePathName(path))
+	}
+	prefix = p.CanonicalName()
+	if prefix == "" {
+		// Flakes, such as github:org/repo#my-tool.
+		_, attr, _ := strings.Cut(p.Raw, "#")
+		prefix = attr[strings.LastIndex(attr, ".")+1:]
+	}
+	return names, prefix
+}
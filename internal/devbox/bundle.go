@@ -0,0 +1,296 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mholt/archives"
+	"github.com/pkg/errors"
+
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+	"go.jetify.com/devbox/internal/build"
+	"go.jetify.com/devbox/internal/devbox/devopt"
+	"go.jetify.com/devbox/internal/devconfig/configfile"
+	"go.jetify.com/devbox/internal/fileutil"
+	"go.jetify.com/devbox/internal/nix"
+	"go.jetify.com/devbox/internal/ux"
+)
+
+const (
+	// bundleFormatVersion is incremented when the layout of a bundle
+	// changes in a way that older versions of devbox can't read.
+	bundleFormatVersion = 1
+
+	bundleMetadataName = "devbox-bundle.json"
+	bundleStoreDir     = "store"
+	bundleProjectDir   = "project"
+)
+
+// BundleMetadata describes the contents of a bundle created by Bundle.
+type BundleMetadata struct {
+	Version       int       `json:"version"`
+	Name          string    `json:"name,omitempty"`
+	System        string    `json:"system"`
+	DevboxVersion string    `json:"devbox_version"`
+	Created       time.Time `json:"created"`
+
+	// StorePaths are the store paths whose closures are in the bundle's
+	// binary cache: the project's profile, the paths of its shell
+	// environment, and its flake inputs.
+	StorePaths []string `json:"store_paths"`
+}
+
+var storePathRegexp = regexp.MustCompile(`/nix/store/[0-9a-z]{32}-[^/:"'\s]+`)
+
+// bundleArchive is the format of bundles: a zstd-compressed tarball.
+var bundleArchive = archives.CompressedArchive{
+	Archival:    archives.Tar{},
+	Extraction:  archives.Tar{},
+	Compression: archives.Zstd{},
+}
+
+// Bundle writes a single-file archive to output with everything that's needed
+// to use the project's environment on a machine without network access: the
+// nix closure of the project's packages and shell environment, the flake
+// inputs that devbox evaluates, devbox.json, devbox.lock and the cached
+// shell environment.
+func (d *Devbox) Bundle(ctx context.Context, output string) error {
+	if err := d.ensureStateIsUpToDate(ctx, ensure); err != nil {
+		return err
+	}
+	if _, err := d.computeEnv(ctx, true /*usePrintDevEnvCache*/, devopt.EnvOptions{}); err != nil {
+		return err
+	}
+
+	roots, err := d.bundleRoots()
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.MkdirTemp("", "devbox-bundle-")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	ux.Finfof(d.stderr, "Copying the closure of %d store paths\n", len(roots))
+	storeURL := nix.LocalBinaryCache(filepath.Join(tmp, bundleStoreDir))
+	if err := nix.CopyClosures(ctx, d.stderr, "", storeURL, false, roots); err != nil {
+		return err
+	}
+	flakePaths, err := nix.ArchiveFlake(ctx, d.flakeDir(), storeURL)
+	if err != nil {
+		return err
+	}
+
+	projectFiles := map[string]string{
+		d.cfg.Root.AbsRootPath:                     configfile.DefaultName,
+		filepath.Join(d.projectDir, "devbox.lock"): "devbox.lock",
+		d.nixPrintDevEnvCachePath():                ".devbox/.nix-print-dev-env-cache",
+	}
+	for src, name := range projectFiles {
+		if err := copyBundleFile(src, filepath.Join(tmp, bundleProjectDir, name)); err != nil {
+			return err
+		}
+	}
+
+	metadata := BundleMetadata{
+		Version:       bundleFormatVersion,
+		Name:          d.cfg.Root.Name,
+		System:        nix.System(),
+		DevboxVersion: build.Version,
+		Created:       time.Now().UTC(),
+		StorePaths:    append(roots, flakePaths...),
+	}
+	data, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmp, bundleMetadataName), data, 0o644); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := writeBundleArchive(ctx, tmp, output); err != nil {
+		return err
+	}
+	ux.Fsuccessf(d.stderr, "Wrote the bundle to %s\n", output)
+	return nil
+}
+
+// bundleRoots returns the store paths of the project's profile and of its
+// shell environment.
+func (d *Devbox) bundleRoots() ([]string, error) {
+	profile, err := filepath.EvalSymlinks(nix.ProjectProfilePath(d.projectDir))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	roots := []string{profile}
+
+	// The cached output of nix print-dev-env refers to the stdenv and
+	// the other inputs of the shell by their store paths.
+	data, err := os.ReadFile(d.nixPrintDevEnvCachePath())
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	for _, path := range storePathRegexp.FindAllString(string(data), -1) {
+		if !slices.Contains(roots, path) {
+			if _, err := os.Stat(path); err == nil {
+				roots = append(roots, path)
+			}
+		}
+	}
+	return roots, nil
+}
+
+func writeBundleArchive(ctx context.Context, dir, output string) error {
+	files, err := archives.FilesFromDisk(ctx, nil, map[string]string{
+		filepath.Join(dir, bundleMetadataName): bundleMetadataName,
+		filepath.Join(dir, bundleStoreDir):     bundleStoreDir,
+		filepath.Join(dir, bundleProjectDir):   bundleProjectDir,
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	out, err := os.Create(output)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err := bundleArchive.Archive(ctx, out, files); err != nil {
+		out.Close()
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(out.Close())
+}
+
+// Unbundle imports a bundle created by Bundle: it copies the bundle's store
+// paths to the local nix store without using the network, and writes the
+// bundle's devbox.json, devbox.lock and cached shell environment to
+// projectDir. Existing project files are kept unless force is true.
+func Unbundle(
+	ctx context.Context,
+	stderr io.Writer,
+	archive, projectDir string,
+	force bool,
+) (*BundleMetadata, error) {
+	tmp, err := os.MkdirTemp("", "devbox-unbundle-")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	if err := extractBundleArchive(ctx, archive, tmp); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(tmp, bundleMetadataName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, usererr.New("%s isn't a devbox bundle", archive)
+	} else if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	metadata := &BundleMetadata{}
+	if err := json.Unmarshal(data, metadata); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if metadata.Version > bundleFormatVersion {
+		return nil, usererr.New(
+			"%s was created by a newer version of devbox (%s), please upgrade devbox to unbundle it",
+			archive, metadata.DevboxVersion)
+	}
+	if metadata.System != nix.System() {
+		return nil, usererr.New(
+			"%s was created for %s, but this machine is %s", archive, metadata.System, nix.System())
+	}
+
+	ux.Finfof(stderr, "Copying %d store paths to the nix store\n", len(metadata.StorePaths))
+	storeURL := nix.LocalBinaryCache(filepath.Join(tmp, bundleStoreDir))
+	if err := nix.CopyClosures(ctx, stderr, storeURL, "", true, metadata.StorePaths); err != nil {
+		return nil, usererr.WithUserMessage(err,
+			"Failed to copy the bundle's store paths. Copying unsigned store paths "+
+				"requires a user that's in trusted-users in nix.conf.")
+	}
+
+	if err := restoreBundleProject(stderr, filepath.Join(tmp, bundleProjectDir), projectDir, force); err != nil {
+		return nil, err
+	}
+	return metadata, nil
+}
+
+func restoreBundleProject(stderr io.Writer, src, projectDir string, force bool) error {
+	return filepath.WalkDir(src, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		dst := filepath.Join(projectDir, rel)
+		if !force && fileutil.Exists(dst) {
+			ux.Fwarningf(stderr, "Keeping the existing %s, use --force to replace it\n", rel)
+			return nil
+		}
+		return copyBundleFile(path, dst)
+	})
+}
+
+func copyBundleFile(src, dst string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(os.WriteFile(dst, data, 0o644))
+}
+
+func extractBundleArchive(ctx context.Context, archive, dir string) error {
+	in, err := os.Open(archive)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer in.Close()
+
+	return errors.WithStack(bundleArchive.Extract(ctx, in, func(ctx context.Context, f archives.FileInfo) error {
+		name := filepath.Clean(filepath.FromSlash(f.NameInArchive))
+		if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("bundle contains an invalid path: %s", f.NameInArchive)
+		}
+		dst := filepath.Join(dir, name)
+		if f.IsDir() {
+			return os.MkdirAll(dst, 0o755)
+		}
+		if !f.Mode().IsRegular() {
+			return fmt.Errorf("bundle contains %s, which isn't a regular file", f.NameInArchive)
+		}
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return err
+		}
+		r, err := f.Open()
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		w, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, f.Mode().Perm())
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(w, r); err != nil {
+			w.Close()
+			return err
+		}
+		return w.Close()
+	}))
+}
@@ -0,0 +1,173 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"go.jetify.com/devbox/internal/devpkg"
+	"go.jetify.com/devbox/internal/devpkg/pkgtype"
+	"go.jetify.com/devbox/internal/httpclient"
+	"go.jetify.com/devbox/internal/nix"
+	"go.jetify.com/devbox/internal/ux"
+)
+
+// githubAPIURL is the GitHub API that release notes are fetched from and that
+// devbox update --pr opens pull requests with.
+var githubAPIURL = "https://api.github.com/"
+
+// releaseNotesMaxLines is how many lines of a package's release notes devbox
+// update --changelog prints.
+const releaseNotesMaxLines = 15
+
+// lockedVersions returns the version of each package in devbox.lock, so that
+// printChangelogs can tell which versions an update changed.
+func (d *Devbox) lockedVersions(pkgs []*devpkg.Package) map[string]string {
+	versions := map[string]string{}
+	for _, pkg := range pkgs {
+		if locked := d.lockfile.Get(pkg.Raw); locked != nil {
+			versions[pkg.Raw] = locked.Version
+		}
+	}
+	return versions
+}
+
+// printChangelogs prints a link to the changelog of each nixpkgs package whose
+// version changed since before, according to the package's meta.changelog.
+// With releaseNotes, it also prints the start of the release notes of the new
+// version if the changelog is a GitHub release. It returns the changelogs by
+// package name.
+func (d *Devbox) printChangelogs(ctx context.Context, pkgs []*devpkg.Package, before map[string]string, releaseNotes bool) map[string]string {
+	changelogs := map[string]string{}
+	printedHeader := false
+	for _, pkg := range pkgs {
+		locked := d.lockfile.Get(pkg.Raw)
+		if locked == nil || locked.Version == "" || before[pkg.Raw] == "" || locked.Version == before[pkg.Raw] {
+			continue
+		}
+		installable, ok := nixpkgsEvalInstallable(locked.Resolved, nix.System())
+		if !ok {
+			continue
+		}
+		meta, err := nix.EvalPackageMeta(ctx, installable)
+		if err != nil {
+			slog.Debug("failed to evaluate the changelog of an updated package", "pkg", pkg.Raw, "err", err)
+			continue
+		}
+		if meta.Changelog == "" {
+			continue
+		}
+
+		if !printedHeader {
+			ux.Finfof(d.stderr, "Changelogs of the updated packages:\n")
+			printedHeader = true
+		}
+		name, _, _ := strings.Cut(pkg.Raw, "@")
+		changelogs[name] = meta.Changelog
+		fmt.Fprintf(d.stderr, "  %s %s -> %s: %s\n", name, before[pkg.Raw], locked.Version, meta.Changelog)
+		if !releaseNotes {
+			continue
+		}
+		notes, err := fetchReleaseNotes(ctx, meta.Changelog, locked.Version)
+		if err != nil {
+			slog.Debug("failed to fetch the release notes of an updated package", "pkg", pkg.Raw, "err", err)
+			continue
+		}
+		for _, line := range summarizeReleaseNotes(notes, releaseNotesMaxLines) {
+			fmt.Fprintf(d.stderr, "      %s\n", line)
+		}
+	}
+	return changelogs
+}
+
+// githubRelease parses a changelog URL that points to the releases of a GitHub
+// repository, such as https://github.com/cli/cli/releases/tag/v2.40.0. The tag
+// is empty if the URL points to all of the releases.
+func githubRelease(changelog string) (owner, repo, tag string, ok bool) {
+	u, err := url.Parse(changelog)
+	if err != nil || u.Host != "github.com" {
+		return "", "", "", false
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 3 || parts[2] != "releases" {
+		return "", "", "", false
+	}
+	if len(parts) == 5 && parts[3] == "tag" {
+		tag = parts[4]
+	}
+	return parts[0], parts[1], tag, true
+}
+
+// fetchReleaseNotes fetches the notes of the release of version from the
+// GitHub releases that changelog points to. It returns no notes if changelog
+// isn't a GitHub release or the release doesn't exist.
+func fetchReleaseNotes(ctx context.Context, changelog, version string) (string, error) {
+	owner, repo, tag, ok := githubRelease(changelog)
+	if !ok {
+		return "", nil
+	}
+	tags := []string{tag}
+	if tag == "" {
+		tags = []string{"v" + version, version}
+	}
+	for _, tag := range tags {
+		notes, found, err := fetchGithubRelease(ctx, owner, repo, tag)
+		if err != nil || found {
+			return notes, err
+		}
+	}
+	return "", nil
+}
+
+func fetchGithubRelease(ctx context.Context, owner, repo, tag string) (notes string, found bool, err error) {
+	u := fmt.Sprintf("%srepos/%s/%s/releases/tags/%s", githubAPIURL, owner, repo, url.PathEscape(tag))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return "", false, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if token := pkgtype.GithubToken(); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	res, err := httpclient.Client().Do(req)
+	if err != nil {
+		return "", false, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		return "", false, nil
+	}
+	if res.StatusCode != http.StatusOK {
+		return "", false, fmt.Errorf("GET %s: %s", u, res.Status)
+	}
+	release := struct {
+		Body string `json:"body"`
+	}{}
+	if err := json.NewDecoder(res.Body).Decode(&release); err != nil {
+		return "", false, err
+	}
+	return release.Body, true, nil
+}
+
+// summarizeReleaseNotes returns the first maxLines non-empty lines of notes,
+// and a line saying how many were left out.
+func summarizeReleaseNotes(notes string, maxLines int) []string {
+	lines := []string{}
+	for _, line := range strings.Split(strings.ReplaceAll(notes, "\r\n", "\n"), "\n") {
+		if line = strings.TrimRight(line, " \t"); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) <= maxLines {
+		return lines
+	}
+	omitted := len(lines) - maxLines
+	return append(lines[:maxLines], fmt.Sprintf("... (%d more lines)", omitted))
+}
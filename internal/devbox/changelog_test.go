@@ -0,0 +1,75 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestGithubRelease(t *testing.T) {
+	tests := []struct {
+		changelog string
+		owner     string
+		repo      string
+		tag       string
+		ok        bool
+	}{
+		{"https://github.com/cli/cli/releases/tag/v2.40.0", "cli", "cli", "v2.40.0", true},
+		{"https://github.com/cli/cli/releases", "cli", "cli", "", true},
+		{"https://github.com/cli/cli/blob/trunk/CHANGELOG.md", "", "", "", false},
+		{"https://go.dev/doc/devel/release#go1.22", "", "", "", false},
+		{"not a url\x7f", "", "", "", false},
+	}
+	for _, tt := range tests {
+		owner, repo, tag, ok := githubRelease(tt.changelog)
+		if owner != tt.owner || repo != tt.repo || tag != tt.tag || ok != tt.ok {
+			t.Errorf("githubRelease(%q) = %q, %q, %q, %v, want %q, %q, %q, %v",
+				tt.changelog, owner, repo, tag, ok, tt.owner, tt.repo, tt.tag, tt.ok)
+		}
+	}
+}
+
+func TestFetchReleaseNotes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/cli/cli/releases/tags/v2.40.0" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"body": "## What's new\r\n\r\n* Faster"}`)
+	}))
+	defer server.Close()
+	githubAPIURL = server.URL + "/"
+	t.Cleanup(func() { githubAPIURL = "https://api.github.com/" })
+
+	notes, err := fetchReleaseNotes(context.Background(), "https://github.com/cli/cli/releases", "2.40.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "## What's new\r\n\r\n* Faster"; notes != want {
+		t.Errorf("fetchReleaseNotes() = %q, want %q", notes, want)
+	}
+
+	notes, err = fetchReleaseNotes(context.Background(), "https://github.com/cli/cli/releases/tag/v1.0.0", "1.0.0")
+	if err != nil || notes != "" {
+		t.Errorf("fetchReleaseNotes() of a missing release = %q, %v, want no notes", notes, err)
+	}
+}
+
+func TestSummarizeReleaseNotes(t *testing.T) {
+	got := summarizeReleaseNotes("## What's new\r\n\r\n* Faster\n* Smaller  \n* Safer\n", 2)
+	want := []string{"## What's new", "* Faster", "... (2 more lines)"}
+	if !slices.Equal(got, want) {
+		t.Errorf("summarizeReleaseNotes() = %q, want %q", got, want)
+	}
+
+	got = summarizeReleaseNotes("* Faster\n", 2)
+	if want := []string{"* Faster"}; !slices.Equal(got, want) {
+		t.Errorf("summarizeReleaseNotes() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+	"go.jetify.com/devbox/internal/cachehash"
+	"go.jetify.com/devbox/internal/devbox/devopt"
+	"go.jetify.com/devbox/internal/nix"
+	"go.jetify.com/devbox/internal/ux"
+)
+
+const (
+	// ciCacheFormatVersion is part of the cache key, so that changing the
+	// layout of exported caches invalidates the old ones.
+	ciCacheFormatVersion = 1
+
+	ciCacheManifestName = "devbox-cache.json"
+	ciCacheStoreDir     = "store"
+)
+
+// CICacheManifest describes a directory exported by ExportCICache.
+type CICacheManifest struct {
+	Version int    `json:"version"`
+	Key     string `json:"key"`
+	System  string `json:"system"`
+
+	// StorePaths are the store paths whose closures are in the directory's
+	// binary cache.
+	StorePaths []string `json:"store_paths"`
+}
+
+// CICacheKey returns a key for caching the project's store paths in CI. It
+// changes when devbox.lock changes, and it's different on each system.
+func (d *Devbox) CICacheKey() (string, error) {
+	lockfile, err := os.ReadFile(filepath.Join(d.projectDir, "devbox.lock"))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return "", errors.WithStack(err)
+	}
+	return ciCacheKey(nix.System(), lockfile), nil
+}
+
+func ciCacheKey(system string, lockfile []byte) string {
+	version := []byte{byte(ciCacheFormatVersion)}
+	return "devbox-" + system + "-" + cachehash.Bytes(append(version, lockfile...))
+}
+
+// ExportCICache installs the project and copies the closure of its packages,
+// shell environment and flake inputs to a binary cache in dir, so that a CI
+// system can cache dir and ImportCICache can restore it without a binary
+// cache server.
+func (d *Devbox) ExportCICache(ctx context.Context, dir string) (*CICacheManifest, error) {
+	if err := d.ensureStateIsUpToDate(ctx, ensure); err != nil {
+		return nil, err
+	}
+	if _, err := d.computeEnv(ctx, true /*usePrintDevEnvCache*/, devopt.EnvOptions{}); err != nil {
+		return nil, err
+	}
+	roots, err := d.bundleRoots()
+	if err != nil {
+		return nil, err
+	}
+	key, err := d.CICacheKey()
+	if err != nil {
+		return nil, err
+	}
+
+	ux.Finfof(d.stderr, "Copying the closure of %d store paths to %s\n", len(roots), dir)
+	storeURL := nix.LocalBinaryCache(filepath.Join(dir, ciCacheStoreDir))
+	if err := nix.CopyClosures(ctx, d.stderr, "", storeURL, false, roots); err != nil {
+		return nil, err
+	}
+	flakePaths, err := nix.ArchiveFlake(ctx, d.flakeDir(), storeURL)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &CICacheManifest{
+		Version:    ciCacheFormatVersion,
+		Key:        key,
+		System:     nix.System(),
+		StorePaths: append(roots, flakePaths...),
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ciCacheManifestName), data, 0o644); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return manifest, nil
+}
+
+// ImportCICache copies the store paths that ExportCICache exported to dir to
+// the local nix store. It returns nil if dir doesn't have a cache, such as
+// when the CI system didn't restore one, so that the packages are downloaded
+// or built as usual.
+func ImportCICache(ctx context.Context, stderr io.Writer, dir string) (*CICacheManifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ciCacheManifestName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	manifest := &CICacheManifest{}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if manifest.Version != ciCacheFormatVersion {
+		ux.Fwarningf(stderr, "Skipping the cache in %s because it was exported by another version of devbox\n", dir)
+		return nil, nil
+	}
+	if manifest.System != nix.System() {
+		return nil, usererr.New(
+			"The cache in %s is for %s, but this machine is %s.", dir, manifest.System, nix.System())
+	}
+
+	ux.Finfof(stderr, "Copying %d store paths to the nix store\n", len(manifest.StorePaths))
+	storeURL := nix.LocalBinaryCache(filepath.Join(dir, ciCacheStoreDir))
+	if err := nix.CopyClosures(ctx, stderr, storeURL, "", true, manifest.StorePaths); err != nil {
+		return nil, usererr.WithUserMessage(err,
+			"Failed to copy the cached store paths. Copying unsigned store paths "+
+				"requires a user that's in trusted-users in nix.conf.")
+	}
+	return manifest, nil
+}
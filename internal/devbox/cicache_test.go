@@ -0,0 +1,27 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCICacheKey(t *testing.T) {
+	lockfile := []byte(`{"lockfile_version": "1", "packages": {}}`)
+	key := ciCacheKey("x86_64-linux", lockfile)
+	if !strings.HasPrefix(key, "devbox-x86_64-linux-") {
+		t.Errorf("got key %q, want prefix %q", key, "devbox-x86_64-linux-")
+	}
+	if got := ciCacheKey("x86_64-linux", lockfile); got != key {
+		t.Errorf("got different keys for the same lockfile: %q and %q", key, got)
+	}
+	if got := ciCacheKey("aarch64-darwin", lockfile); got == key {
+		t.Errorf("got the same key %q for different systems", key)
+	}
+	changed := []byte(`{"lockfile_version": "1", "packages": {"go@latest": {}}}`)
+	if got := ciCacheKey("x86_64-linux", changed); got == key {
+		t.Errorf("got the same key %q for different lockfiles", key)
+	}
+}
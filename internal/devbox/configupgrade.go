@@ -0,0 +1,125 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	"go.jetify.com/devbox/internal/devconfig/configfile"
+	"go.jetify.com/devbox/internal/plugin"
+)
+
+// ConfigMigration is a change that UpgradeConfig makes to a file of the
+// project to replace deprecated fields.
+type ConfigMigration struct {
+	// Path is the path of the devbox.json, plugin.json or devbox.lock
+	// that the migration changes.
+	Path        string
+	Description string
+}
+
+// PendingConfigMigrations returns the changes that UpgradeConfig would make.
+func (d *Devbox) PendingConfigMigrations() []ConfigMigration {
+	pending := []ConfigMigration{}
+	for _, m := range d.cfg.Root.PendingMigrations() {
+		pending = append(pending, ConfigMigration{
+			Path:        d.cfg.Root.AbsRootPath,
+			Description: m.Description,
+		})
+	}
+	for _, p := range d.localPluginConfigs() {
+		for _, m := range p.cfg.PendingMigrations() {
+			pending = append(pending, ConfigMigration{Path: p.path, Description: m.Description})
+		}
+	}
+	if n := d.lockfile.LegacyStorePathCount(); n > 0 {
+		pending = append(pending, ConfigMigration{
+			Path:        filepath.Join(d.projectDir, "devbox.lock"),
+			Description: legacyStorePathsDescription(n),
+		})
+	}
+	return pending
+}
+
+// UpgradeConfig rewrites the deprecated fields of the project's devbox.json,
+// local plugins and devbox.lock, and returns the changes it made. Edits to
+// devbox.json and plugin.json preserve comments and formatting.
+func (d *Devbox) UpgradeConfig() ([]ConfigMigration, error) {
+	applied := []ConfigMigration{}
+	if migrations := d.cfg.Root.Migrate(); len(migrations) > 0 {
+		if err := d.cfg.Root.Save(); err != nil {
+			return nil, err
+		}
+		for _, m := range migrations {
+			applied = append(applied, ConfigMigration{
+				Path:        d.cfg.Root.AbsRootPath,
+				Description: m.Description,
+			})
+		}
+	}
+
+	for _, p := range d.localPluginConfigs() {
+		migrations := p.cfg.Migrate()
+		if len(migrations) == 0 {
+			continue
+		}
+		if err := os.WriteFile(p.path, p.cfg.Bytes(), 0o644); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		for _, m := range migrations {
+			applied = append(applied, ConfigMigration{Path: p.path, Description: m.Description})
+		}
+	}
+
+	if n := d.lockfile.MigrateLegacyStorePaths(); n > 0 {
+		if err := d.lockfile.Save(); err != nil {
+			return nil, err
+		}
+		applied = append(applied, ConfigMigration{
+			Path:        filepath.Join(d.projectDir, "devbox.lock"),
+			Description: legacyStorePathsDescription(n),
+		})
+	}
+	return applied, nil
+}
+
+type localPluginConfig struct {
+	path string
+	cfg  *configfile.ConfigFile
+}
+
+// localPluginConfigs reads the plugin.json of each local plugin that the
+// project includes. Plugins are read again from disk because the loaded
+// plugin configs don't keep comments.
+func (d *Devbox) localPluginConfigs() []localPluginConfig {
+	configs := []localPluginConfig{}
+	for _, included := range d.cfg.IncludedPluginConfigs() {
+		local, ok := included.Source.(*plugin.LocalPlugin)
+		if !ok {
+			continue
+		}
+		path := local.Path()
+		data, err := os.ReadFile(path)
+		if err != nil {
+			slog.Debug("skipping migrations of local plugin", "path", path, "err", err)
+			continue
+		}
+		cfg, err := configfile.LoadBytes(data)
+		if err != nil {
+			slog.Debug("skipping migrations of local plugin", "path", path, "err", err)
+			continue
+		}
+		configs = append(configs, localPluginConfig{path: path, cfg: cfg})
+	}
+	return configs
+}
+
+func legacyStorePathsDescription(n int) string {
+	return fmt.Sprintf(`replace "store_path" with "outputs" in %d package systems`, n)
+}
@@ -0,0 +1,77 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"context"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+	"go.jetify.com/devbox/internal/container"
+	"go.jetify.com/devbox/internal/devbox/devopt"
+	"go.jetify.com/devbox/internal/fileutil"
+	"go.jetify.com/devbox/internal/nix"
+	"go.jetify.com/devbox/internal/ux"
+)
+
+// containerWrapper returns the wrapper that runs cmdName in an ephemeral
+// container, or nil if opts doesn't ask for one. The container has the
+// project directory and the closure of the project's nix store paths, so it
+// doesn't need an image with the project's packages. env is the environment
+// that the command runs with.
+func (d *Devbox) containerWrapper(
+	ctx context.Context,
+	cmdName string,
+	env map[string]string,
+	opts devopt.Container,
+) (nix.CommandWrapper, error) {
+	if !opts.Enabled {
+		return nil, nil
+	}
+	containerRuntime, err := container.FindRuntime()
+	if err != nil {
+		return nil, err
+	}
+
+	roots := container.StorePathsInEnv(env)
+	if profile, err := filepath.EvalSymlinks(nix.ProjectProfilePath(d.projectDir)); err == nil {
+		roots = append(roots, profile)
+	}
+	roots = slices.DeleteFunc(roots, func(path string) bool { return !fileutil.Exists(path) })
+	storePaths, err := nix.StorePathClosure(ctx, roots)
+	if err != nil {
+		return nil, err
+	}
+
+	containerOpts := container.Opts{
+		Image:      opts.Image,
+		ProjectDir: d.projectDir,
+		StorePaths: storePaths,
+		Env: slices.DeleteFunc(slices.Sorted(maps.Keys(env)), func(name string) bool {
+			return !isValidEnvName(name)
+		}),
+	}
+	if _, ok := d.cfg.Scripts()[cmdName]; ok {
+		l := toLimits(d.cfg.Root.ScriptLimits(cmdName))
+		if err := l.Validate(); err != nil {
+			return nil, err
+		}
+		if strings.HasSuffix(l.Memory, "%") {
+			return nil, usererr.New(
+				"Script %s has a memory limit of %s, but containers need an absolute limit such as 4G.",
+				cmdName, l.Memory)
+		}
+		containerOpts.CPUs = l.CPUs
+		containerOpts.Memory = l.Memory
+	}
+
+	ux.Finfof(d.stderr, "Running in a %s container with %d store paths\n",
+		filepath.Base(containerRuntime), len(storePaths))
+	return func(args []string) ([]string, error) {
+		return container.Command(containerRuntime, containerOpts, args...), nil
+	}, nil
+}
@@ -0,0 +1,81 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"go.jetify.com/devbox/internal/services"
+	"go.jetify.com/devbox/internal/state"
+)
+
+// ServiceState is the state of one of the project's services.
+type ServiceState struct {
+	Name string
+
+	// Status is the process-compose status of the service, such as
+	// "Running" or "Completed". It's empty when process-compose isn't
+	// running or isn't managing the service.
+	Status   string
+	Health   string
+	Restarts int
+}
+
+// ServiceStates returns the state of every service in the project, sorted by
+// name. It reports whether process-compose is running for the project.
+func (d *Devbox) ServiceStates(ctx context.Context) ([]ServiceState, bool, error) {
+	svcSet, err := d.Services()
+	if err != nil {
+		return nil, false, err
+	}
+	states := make([]ServiceState, 0, len(svcSet))
+	for name := range svcSet {
+		states = append(states, ServiceState{Name: name})
+	}
+	slices.SortFunc(states, func(a, b ServiceState) int { return strings.Compare(a.Name, b.Name) })
+
+	if !services.ProcessManagerIsRunning(d.projectDir) {
+		return states, false, nil
+	}
+	processes, err := services.ListServices(ctx, d.projectDir, io.Discard)
+	if err != nil {
+		return states, true, err
+	}
+	for _, p := range processes {
+		i := slices.IndexFunc(states, func(s ServiceState) bool { return s.Name == p.Name })
+		if i == -1 {
+			continue
+		}
+		states[i].Status = p.Status
+		states[i].Health = p.Health
+		states[i].Restarts = p.Restarts
+	}
+	return states, true, nil
+}
+
+// CacheUsage is the disk space that a cache or state directory uses.
+type CacheUsage struct {
+	Name  string
+	Path  string
+	Bytes int64
+}
+
+// CacheUsage returns the disk space used by the project's .devbox directory
+// and by devbox's cache directory. It doesn't count the nix store, which
+// would take too long to measure.
+func (d *Devbox) CacheUsage() []CacheUsage {
+	locations := []state.Location{
+		{Name: "Project state", Path: filepath.Join(d.projectDir, ".devbox")},
+		{Name: "Devbox cache", Path: state.Cache()},
+	}
+	usage := make([]CacheUsage, len(locations))
+	for i, l := range locations {
+		usage[i] = CacheUsage{Name: l.Name, Path: l.Path, Bytes: l.Size()}
+	}
+	return usage
+}
@@ -34,7 +34,6 @@
 	"go.jetify.com/devbox/internal/devconfig"
 	"go.jetify.com/devbox/internal/devconfig/configfile"
 	"go.jetify.com/devbox/internal/devpkg"
-	"go.jetify.com/devbox/internal/devpkg/pkgtype"
 	"go.jetify.com/devbox/internal/envir"
 	"go.jetify.com/devbox/internal/fileutil"
 	"go.jetify.com/devbox/internal/lock"
@@ -59,6 +58,7 @@
 type Devbox struct {
 	cfg                      *devconfig.Config
 	env                      map[string]string
+	unsetEnv                 []string
 	environment              string
 	lockfile                 *lock.File
 	nix                      nix.Nixer
@@ -66,15 +66,28 @@ type Devbox struct {
 	pluginManager            *plugin.Manager
 	customProcessComposeFile string
 
+	// addedProjects are the directories of the projects whose environments
+	// are layered under this project's environment.
+	addedProjects []string
+
 	// This is needed because of the --quiet flag.
 	stderr io.Writer
 
 	// packagesBeingUpdated tracks which packages are being updated so that
 	// installNixPackagesToStore only refreshes those, not all packages.
 	packagesBeingUpdated []*devpkg.Package
+
+	// trackingCommand is true while a command that's recorded in the audit
+	// log and notified runs, so that the commands it calls aren't tracked
+	// separately.
+	trackingCommand bool
 }
 
-var legacyPackagesWarningHasBeenShown = false
+var (
+	legacyPackagesWarningHasBeenShown  = false
+	configMigrationWarningHasBeenShown = false
+	insecureExpiryWarningHasBeenShown  = false
+)
 
 func InitConfig(dir string) error {
 	_, err := devconfig.Init(dir)
@@ -110,20 +123,35 @@ func Open(opts *devopt.Opts) (*Devbox, error) {
 		return nil, usererr.WithUserMessage(err, "Error loading devbox.json.")
 	}
 
+	if cfg.Root.NixEvalCache() {
+		nix.SetEvalCache(true)
+	}
+
 	environment, err := validateEnvironment(opts.Environment)
 	if err != nil {
 		return nil, err
 	}
 
+	addedProjects := make([]string, 0, len(opts.AddProjects))
+	for _, dir := range opts.AddProjects {
+		dir, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		addedProjects = append(addedProjects, dir)
+	}
+
 	box := &Devbox{
 		cfg:                      cfg,
 		env:                      opts.Env,
+		unsetEnv:                 opts.UnsetEnv,
 		environment:              environment,
 		nix:                      &nix.NixInstance{},
 		projectDir:               filepath.Dir(cfg.Root.AbsRootPath),
 		pluginManager:            plugin.NewManager(),
 		stderr:                   opts.Stderr,
 		customProcessComposeFile: opts.CustomProcessComposeFile,
+		addedProjects:            append(addedProjects, cfg.Root.AddedProjects()...),
 	}
 
 	lock, err := lock.GetFile(box)
@@ -136,8 +164,9 @@ func Open(opts *devopt.Opts) (*Devbox, error) {
 	}
 
 	// if lockfile has any allow insecure, we need to set the env var to ensure
-	// all nix commands work.
-	if err := box.moveAllowInsecureFromLockfile(box.stderr, lock, cfg); err != nil {
+	// all nix commands work. Open doesn't take a context, so this migration
+	// can't be canceled.
+	if err := box.moveAllowInsecureFromLockfile(context.Background(), box.stderr, lock, cfg); err != nil {
 		ux.Fwarningf(
 			box.stderr,
 			"Failed to move allow_insecure from devbox.lock to devbox.json. An insecure package may "+
@@ -175,6 +204,32 @@ func Open(opts *devopt.Opts) (*Devbox, error) {
 		)
 	}
 
+	if !opts.IgnoreWarnings && !configMigrationWarningHasBeenShown {
+		if pending := box.PendingConfigMigrations(); len(pending) > 0 {
+			configMigrationWarningHasBeenShown = true
+			ux.Fwarningf(
+				os.Stderr,
+				"Your project at %s uses deprecated fields in %d places. "+
+					"Run `devbox config upgrade --dry-run` to see them and "+
+					"`devbox config upgrade` to migrate them.\n",
+				box.projectDir,
+				len(pending),
+			)
+		}
+	}
+
+	if !opts.IgnoreWarnings && !insecureExpiryWarningHasBeenShown {
+		if expired := box.expiredInsecureAcceptances(); len(expired) > 0 {
+			insecureExpiryWarningHasBeenShown = true
+			ux.Fwarningf(
+				os.Stderr,
+				"The allow_insecure acceptance of %s expired. "+
+					"Run `devbox insecure ls` to review it.\n",
+				strings.Join(slices.Sorted(maps.Keys(expired)), ", "),
+			)
+		}
+	}
+
 	return box, nil
 }
 
@@ -204,6 +259,11 @@ func (d *Devbox) ConfigHash() (string, error) {
 		}
 		buf.WriteString(h)
 	}
+	devPluginsHash, err := plugin.DevPluginsHash(d.projectDir)
+	if err != nil {
+		return "", err
+	}
+	buf.WriteString(devPluginsHash)
 	return cachehash.Bytes(buf.Bytes()), nil
 }
 
@@ -217,6 +277,10 @@ func (d *Devbox) Stdenv() flake.Ref {
 	}
 }
 
+func (d *Devbox) PackageSources() map[string]string {
+	return d.cfg.PackageSources()
+}
+
 func (d *Devbox) Generate(ctx context.Context) error {
 	ctx, task := trace.NewTask(ctx, "devboxGenerate")
 	defer task.End()
@@ -228,18 +292,36 @@ func (d *Devbox) Shell(ctx context.Context, envOpts devopt.EnvOptions) error {
 	ctx, task := trace.NewTask(ctx, "devboxShell")
 	defer task.End()
 
+	if err := d.ensureTrusted("run the init hooks"); err != nil {
+		return err
+	}
+	d.syncPythonVenv(ctx)
+
+	started := time.Now()
 	envs, err := d.ensureStateIsUpToDateAndComputeEnv(ctx, envOpts)
 	if err != nil {
 		return err
 	}
+	d.recordActivation("shell", started)
 
-	fmt.Fprintln(d.stderr, "Starting a devbox shell...")
+	depth := envir.ShellDepth()
+	if depth == 0 {
+		fmt.Fprintln(d.stderr, "Starting a devbox shell...")
+	} else {
+		parent := os.Getenv("DEVBOX_PROJECT_ROOT")
+		mode := "replacing"
+		if !envOpts.ReplaceShellEnv {
+			mode = "layered on"
+		}
+		fmt.Fprintf(d.stderr, "Starting a devbox shell for %s, %s the environment of %s...\n",
+			d.projectDir, mode, parent)
+		ux.Finfof(d.stderr, "Run `exit` to return to the devbox shell of %s.\n", parent)
+	}
 
-	// Used to determine whether we're inside a shell (e.g. to prevent shell inception)
-	// TODO: This is likely obsolete but we need to decide what happens when
-	// the user does shell-ception. One option is to leave the current shell and
-	// join a new one (that way they are not in nested shells.)
+	// Used to determine whether we're inside a shell (e.g. to prevent shell
+	// inception) and how deeply shells are nested.
 	envs[envir.DevboxShellEnabled] = "1"
+	envs[envir.DevboxShellDepth] = strconv.Itoa(depth + 1)
 
 	if err = createDevboxSymlink(d); err != nil {
 		return err
@@ -264,6 +346,11 @@ func (d *Devbox) RunScript(ctx context.Context, envOpts devopt.EnvOptions, cmdNa
 	ctx, task := trace.NewTask(ctx, "devboxRun")
 	defer task.End()
 
+	if err := d.ensureTrusted("run the init hooks and scripts"); err != nil {
+		return err
+	}
+	d.syncPythonVenv(ctx)
+
 	if err := shellgen.WriteScriptsToFiles(d); err != nil {
 		return err
 	}
@@ -278,12 +365,35 @@ func (d *Devbox) RunScript(ctx context.Context, envOpts devopt.EnvOptions, cmdNa
 		// We set this to ensure that init-hooks do NOT re-run. They would have
 		// run when initializing the Devbox Environment in the current shell.
 		env[d.SkipInitHookEnvName()] = "true"
+
+		// The current shell's environment was computed without this
+		// command's overrides.
+		d.applyEnvOverrides(env)
 	} else {
 		var err error
+		started := time.Now()
 		env, err = d.ensureStateIsUpToDateAndComputeEnv(ctx, envOpts)
 		if err != nil {
 			return err
 		}
+		// Like script runs, arbitrary commands aren't recorded by name
+		// because their arguments can have secrets.
+		command := "run"
+		if _, ok := d.cfg.Scripts()[cmdName]; ok {
+			command = "run " + cmdName
+		}
+		d.recordActivation(command, started)
+	}
+
+	// Scripts can run with a different JDK than the rest of the environment.
+	if _, ok := d.cfg.Scripts()[cmdName]; ok {
+		if version := d.cfg.Root.JavaVersion(cmdName); version != d.cfg.Root.JavaVersion("") {
+			jdkBin, err := d.activateJDK(env, version)
+			if err != nil {
+				return err
+			}
+			env["PATH"] = envpath.JoinPathLists(jdkBin, env["PATH"])
+		}
 	}
 
 	// Used to determine whether we're inside a shell (e.g. to prevent shell inception)
@@ -291,26 +401,37 @@ func (d *Devbox) RunScript(ctx context.Context, envOpts devopt.EnvOptions, cmdNa
 	// better alternative since devbox run and devbox shell are not the same.
 	env["DEVBOX_SHELL_ENABLED"] = "1"
 
-	// wrap the arg in double-quotes, and escape any double-quotes inside it.
-	//
-	// TODO(gcurtis): this breaks quote-removal in parameter expansion,
-	// command substitution, and arithmetic expansion:
-	//
-	//	$ unset x
-	//	$ echo ${x:-"my file"}
-	//	my file
-	//	$ devbox run -- echo '${x:-"my file"}'
-	//	"my file"
-	for idx, arg := range cmdArgs {
-		cmdArgs[idx] = strconv.Quote(arg)
-	}
-
 	var cmdWithArgs []string
-	if _, ok := d.cfg.Scripts()[cmdName]; ok {
-		// it's a script, so replace the command with the script file's path.
-		script := shellgen.ScriptPath(d.ProjectDir(), cmdName)
-		cmdWithArgs = append([]string{strconv.Quote(script)}, cmdArgs...)
+	if script, ok := d.cfg.Scripts()[cmdName]; ok {
+		params, err := script.ParamValues(cmdName, cmdArgs)
+		if err != nil {
+			return err
+		}
+		maps.Copy(env, params)
+
+		// It's a script, so replace the command with the script file's
+		// path. The arguments are quoted for sh, which runs the script,
+		// so that the script gets them verbatim as "$@" whatever the
+		// user's shell is.
+		cmdWithArgs = []string{shellgen.QuotePOSIX(shellgen.ScriptPath(d.ProjectDir(), cmdName))}
+		for _, arg := range cmdArgs {
+			cmdWithArgs = append(cmdWithArgs, shellgen.QuotePOSIX(arg))
+		}
 	} else {
+		// wrap the arg in double-quotes, and escape any double-quotes inside it.
+		//
+		// TODO(gcurtis): this breaks quote-removal in parameter expansion,
+		// command substitution, and arithmetic expansion:
+		//
+		//	$ unset x
+		//	$ echo ${x:-"my file"}
+		//	my file
+		//	$ devbox run -- echo '${x:-"my file"}'
+		//	"my file"
+		for idx, arg := range cmdArgs {
+			cmdArgs[idx] = strconv.Quote(arg)
+		}
+
 		// Arbitrary commands should also run the hooks, so we write them to a file as well. However, if the
 		// command args include env variable evaluations, then they'll be evaluated _before_ the hooks run,
 		// which we don't want. So, one solution is to write the entire command and its arguments into the
@@ -338,15 +459,41 @@ func (d *Devbox) RunScript(ctx context.Context, envOpts devopt.EnvOptions, cmdNa
 		env["DEVBOX_RUN_CMD"] = strings.Join(append([]string{runCmd}, cmdArgs...), " ")
 	}
 
-	return nix.RunScript(d.projectDir, strings.Join(cmdWithArgs, " "), env)
+	// Containers are isolated and enforce the script's limits themselves.
+	containerWrapper, err := d.containerWrapper(ctx, cmdName, env, envOpts.Container)
+	if err != nil {
+		return err
+	}
+	if containerWrapper != nil {
+		started := time.Now()
+		err := nix.RunScript(d.projectDir, strings.Join(cmdWithArgs, " "), env, containerWrapper)
+		d.recordScriptRun(cmdName, started, err)
+		return err
+	}
+
+	sandboxWrapper, err := d.sandboxWrapper(cmdName, env, envOpts.Sandbox)
+	if err != nil {
+		return err
+	}
+	// The limits wrap the sandbox so that they apply to it as well.
+	started := time.Now()
+	err = nix.RunScript(
+		d.projectDir, strings.Join(cmdWithArgs, " "), env, sandboxWrapper, d.scriptLimitsWrapper(cmdName))
+	d.recordScriptRun(cmdName, started, err)
+	return err
 }
 
 // Install ensures that all the packages in the config are installed
 // but does not run init hooks. It is used to power devbox install cli command.
-func (d *Devbox) Install(ctx context.Context) error {
+func (d *Devbox) Install(ctx context.Context) (err error) {
 	ctx, task := trace.NewTask(ctx, "devboxInstall")
 	defer task.End()
+	done := d.trackCommand(ctx, "install", nil)
+	defer func() { done(err) }()
 
+	if err := d.runLifecycleHook(ctx, configfile.PreInstallHook, false, nil); err != nil {
+		return err
+	}
 	return d.ensureStateIsUpToDate(ctx, ensure)
 }
 
@@ -371,13 +518,21 @@ func (d *Devbox) EnvExports(ctx context.Context, opts devopt.EnvExportsOpts) (st
 	ctx, task := trace.NewTask(ctx, "devboxEnvExports")
 	defer task.End()
 
+	if opts.RunHooks {
+		if err := d.ensureTrusted("run the init hooks"); err != nil {
+			return "", err
+		}
+	}
+
 	var envs map[string]string
 	var err error
 
+	started := time.Now()
 	envs, err = d.ensureStateIsUpToDateAndComputeEnv(ctx, opts.EnvOptions)
 	if err != nil {
 		return "", err
 	}
+	d.recordActivation("shellenv", started)
 
 	// Use the appropriate export format based on shell type
 	var envStr string
@@ -415,50 +570,6 @@ func (d *Devbox) shellEnvHashKey() string {
 	return "__DEVBOX_SHELLENV_HASH_" + d.ProjectDirHash()
 }
 
-func (d *Devbox) Info(ctx context.Context, pkg string, markdown bool) (string, error) {
-	ctx, task := trace.NewTask(ctx, "devboxInfo")
-	defer task.End()
-
-	name, version, isVersioned := searcher.ParseVersionedPackage(pkg)
-	if !isVersioned {
-		name = pkg
-		version = "latest"
-	}
-
-	packageVersion, err := searcher.Client().Resolve(name, version)
-	if err != nil {
-		if !errors.Is(err, searcher.ErrNotFound) {
-			return "", usererr.WithUserMessage(err, "Package %q not found\n", pkg)
-		}
-
-		packageVersion = nil
-		// fallthrough to below
-	}
-
-	if packageVersion == nil {
-		return "", usererr.WithUserMessage(err, "Package %q not found\n", pkg)
-	}
-
-	// we should only have one result
-	info := fmt.Sprintf(
-		"%s%s %s\n%s\n",
-		lo.Ternary(markdown, "## ", ""),
-		packageVersion.Name,
-		packageVersion.Version,
-		packageVersion.Summary,
-	)
-	readme, err := plugin.Readme(
-		ctx,
-		devpkg.PackageFromStringWithDefaults(pkg, d.lockfile),
-		d.projectDir,
-		markdown,
-	)
-	if err != nil {
-		return "", err
-	}
-	return info + readme, nil
-}
-
 // GenerateDevcontainer generates devcontainer.json and Dockerfile for vscode run-in-container
 // and GitHub Codespaces
 func (d *Devbox) GenerateDevcontainer(ctx context.Context, generateOpts devopt.GenerateOpts) error {
@@ -592,9 +703,75 @@ func (d *Devbox) GenerateEnvrcFile(ctx context.Context, opts devopt.EnvrcOpts) e
 	return nil
 }
 
-// saveCfg writes the config file to the devbox directory.
+// SetupDirenv adds a versioned devbox block to the .envrc in opts.EnvrcDir,
+// or updates the block if an older version of devbox wrote it. The rest of
+// the .envrc is kept as is.
+func (d *Devbox) SetupDirenv(ctx context.Context, opts devopt.EnvrcOpts) error {
+	ctx, task := trace.NewTask(ctx, "devboxSetupDirenv")
+	defer task.End()
+
+	if opts.EnvrcDir == "" {
+		opts.EnvrcDir = opts.ConfigDir
+	}
+	envrcFilePath := filepath.Join(opts.EnvrcDir, ".envrc")
+	block, err := generate.EnvrcBlock(opts)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	existing, err := os.ReadFile(envrcFilePath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return errors.WithStack(err)
+	}
+	if version := generate.EnvrcBlockVersionOf(string(existing)); version > generate.EnvrcBlockVersion && !opts.Force {
+		return usererr.New(
+			"The .envrc in %q was set up by a newer version of devbox. "+
+				"Upgrade devbox, or use --force to replace its devbox block.",
+			opts.EnvrcDir,
+		)
+	}
+	envrc, changed := generate.UpdateEnvrc(string(existing), block)
+	if !changed {
+		ux.Finfof(d.stderr, "The .envrc in %q is up to date.\n", opts.EnvrcDir)
+		return nil
+	}
+
+	if err := d.ensureStateIsUpToDate(ctx, ensure); err != nil {
+		return err
+	}
+	if err := os.WriteFile(envrcFilePath, []byte(envrc), 0o644); err != nil {
+		return errors.WithStack(err)
+	}
+	if version := generate.EnvrcBlockVersionOf(string(existing)); version == 0 {
+		ux.Fsuccessf(d.stderr, "Added the devbox block to the .envrc in %q.\n", opts.EnvrcDir)
+	} else if version < generate.EnvrcBlockVersion {
+		ux.Fsuccessf(d.stderr, "Updated the devbox block in the .envrc in %q from v%d to v%d.\n",
+			opts.EnvrcDir, version, generate.EnvrcBlockVersion)
+	} else {
+		ux.Fsuccessf(d.stderr, "Updated the devbox block in the .envrc in %q.\n", opts.EnvrcDir)
+	}
+	if cmdutil.Exists("direnv") {
+		cmd := exec.Command("direnv", "allow", opts.EnvrcDir)
+		if err := cmd.Run(); err != nil {
+			return errors.WithStack(err)
+		}
+		ux.Fsuccessf(d.stderr, "ran `direnv allow %s`\n", opts.EnvrcDir)
+	}
+	return nil
+}
+
+// saveCfg writes the config file to the devbox directory. If the user trusted
+// the project, they keep trusting it with the config that devbox changed.
 func (d *Devbox) saveCfg() error {
-	return d.cfg.Root.SaveTo(d.ProjectDir())
+	trusted := d.isSavedConfigTrusted()
+	if err := d.cfg.Root.SaveTo(d.ProjectDir()); err != nil {
+		return err
+	}
+	if trusted {
+		if err := d.Trust(); err != nil {
+			slog.Debug("failed to trust the project's changed config", "err", err)
+		}
+	}
+	return nil
 }
 
 func (d *Devbox) Services() (services.Services, error) {
@@ -705,6 +882,17 @@ func (d *Devbox) computeEnv(
 	ctx context.Context,
 	usePrintDevEnvCache bool,
 	envOpts devopt.EnvOptions,
+) (map[string]string, error) {
+	return d.traceEnv(ctx, usePrintDevEnvCache, envOpts, nil)
+}
+
+// traceEnv computes the environment like computeEnv, and records which step
+// set each variable in sources if it isn't nil.
+func (d *Devbox) traceEnv(
+	ctx context.Context,
+	usePrintDevEnvCache bool,
+	envOpts devopt.EnvOptions,
+	sources *envSources,
 ) (map[string]string, error) {
 	defer debug.FunctionTimer().End()
 	defer trace.StartRegion(ctx, "devboxComputeEnv").End()
@@ -715,13 +903,34 @@ func (d *Devbox) computeEnv(
 	if err != nil {
 		return nil, err
 	}
+	if envOpts.ReplaceShellEnv && !envOpts.Pure {
+		removeShellEnv(env)
+	}
+	sources.record("current environment", env)
 
+	env, err = d.layerAddedProjects(ctx, env, envOpts, sources)
+	if err != nil {
+		return nil, err
+	}
+	return d.traceEnvFrom(ctx, env, usePrintDevEnvCache, envOpts, sources)
+}
+
+// traceEnvFrom computes the environment like traceEnv, starting from env
+// instead of the current environment.
+func (d *Devbox) traceEnvFrom(
+	ctx context.Context,
+	env map[string]string,
+	usePrintDevEnvCache bool,
+	envOpts devopt.EnvOptions,
+	sources *envSources,
+) (map[string]string, error) {
 	// check if contents of .envrc is old and print warning
 	if !usePrintDevEnvCache {
 		err := d.checkOldEnvrc()
 		if err != nil {
 			return nil, err
 		}
+		d.warnIfRustToolchainChanged()
 	}
 
 	slog.Debug("current environment PATH", "path", env["PATH"])
@@ -738,6 +947,7 @@ func (d *Devbox) computeEnv(
 		for k, v := range nixEnv {
 			env[k] = v
 		}
+		sources.record("nix print-dev-env", env)
 	}
 	slog.Debug("nix environment PATH", "path", env["PATH"])
 
@@ -755,14 +965,25 @@ func (d *Devbox) computeEnv(
 	env["DEVBOX_PROJECT_ROOT"] = d.projectDir
 	env["DEVBOX_WD"] = wd
 	env["DEVBOX_CONFIG_DIR"] = d.projectDir + "/devbox.d"
-	env["DEVBOX_PACKAGES_DIR"] = d.projectDir + "/" + nix.ProfilePath
+	env["DEVBOX_PACKAGES_DIR"] = nix.ProjectProfilePath(d.projectDir)
+	env["DEVBOX_ENVIRONMENT"] = d.environment
+	sources.record("devbox", env)
+
+	// Configure the go toolchain before devbox.json so that its env
+	// variables can override these.
+	goEnv, goBin := d.goEnv()
+	maps.Copy(env, goEnv)
+	sources.record("go toolchain", env)
 
 	// Include env variables in devbox.json
-	configEnv, err := d.configEnvs(ctx, env)
+	configEnv, configLayers, err := d.configEnvs(ctx, env)
 	if err != nil {
 		return nil, err
 	}
 	addEnvIfNotPreviouslySetByDevbox(env, configEnv)
+	sources.recordLayers(configLayers, env, func(layer, before map[string]string) map[string]string {
+		return conf.OSExpandEnvMap(layer, before, d.ProjectDir())
+	})
 
 	markEnvsAsSetByDevbox(configEnv)
 
@@ -813,9 +1034,42 @@ func (d *Devbox) computeEnv(
 	}
 	devboxEnvPath = envpath.JoinPathLists(devboxEnvPath, runXPaths)
 
+	urlPaths, err := d.URLPaths(ctx)
+	if err != nil {
+		return nil, err
+	}
+	devboxEnvPath = envpath.JoinPathLists(devboxEnvPath, urlPaths)
+
+	if goBin != "" {
+		devboxEnvPath = envpath.JoinPathLists(goBin, devboxEnvPath)
+	}
+
+	// The selected JDK's executables take precedence over those of the
+	// project's other JDKs.
+	jdkBin, err := d.activateJDK(env, d.cfg.Root.JavaVersion(""))
+	if err != nil {
+		ux.Fwarningf(d.stderr, "Unable to set JAVA_HOME: %v\n", err)
+	} else if jdkBin != "" {
+		devboxEnvPath = envpath.JoinPathLists(jdkBin, devboxEnvPath)
+	}
+	sources.record("java toolchain", env)
+
+	// The virtual environment's python and scripts take precedence over the
+	// ones from the python package.
+	if venvBin := d.activatePythonVenv(env); venvBin != "" {
+		devboxEnvPath = envpath.JoinPathLists(venvBin, devboxEnvPath)
+	}
+	sources.record("python virtual environment", env)
+
 	pathStack := envpath.Stack(env, originalEnv)
 	pathStack.Push(env, d.ProjectDirHash(), devboxEnvPath, envOpts.PreservePathStack)
-	env["PATH"] = pathStack.Path(env)
+	hostPath := envpath.StripPathList(env[envpath.InitPathEnv], d.cfg.Root.PathStrip())
+	if shimsDir, err := d.writeHostShims(devboxEnvPath, hostPath); err != nil {
+		ux.Fwarningf(d.stderr, "Unable to write the shims for path.strict: %v\n", err)
+	} else if shimsDir != "" {
+		hostPath = envpath.JoinPathLists(shimsDir, hostPath)
+	}
+	env["PATH"] = pathStack.PathWithHost(env, hostPath, d.cfg.Root.PathAppended())
 	slog.Debug("new path stack is", "path_stack", pathStack)
 
 	slog.Debug("computed environment PATH", "path", env["PATH"])
@@ -824,12 +1078,24 @@ func (d *Devbox) computeEnv(
 		// preserve the original XDG_DATA_DIRS by prepending to it
 		env["XDG_DATA_DIRS"] = envpath.JoinPathLists(env["XDG_DATA_DIRS"], os.Getenv("XDG_DATA_DIRS"))
 	}
+	sources.record("devbox PATH", env)
 
-	for k, v := range d.env {
-		env[k] = v
-	}
+	d.applyEnvOverrides(env)
+	sources.record("--env, --env-file or --unset-env flag", env)
+
+	err = d.addHashToEnv(env)
+	sources.record("devbox", env)
+	return env, err
+}
 
-	return env, d.addHashToEnv(env)
+// applyEnvOverrides sets the variables that were passed with --env and then
+// removes the ones that were passed with --unset-env, so that they take
+// precedence over everything else in the environment.
+func (d *Devbox) applyEnvOverrides(env map[string]string) {
+	maps.Copy(env, d.env)
+	for _, name := range d.unsetEnv {
+		delete(env, name)
+	}
 }
 
 // ensureStateIsUpToDateAndComputeEnv will return a map of the env-vars for the Devbox Environment
@@ -837,6 +1103,16 @@ func (d *Devbox) computeEnv(
 func (d *Devbox) ensureStateIsUpToDateAndComputeEnv(
 	ctx context.Context,
 	envOpts devopt.EnvOptions,
+) (map[string]string, error) {
+	return d.ensureStateIsUpToDateAndTraceEnv(ctx, envOpts, nil)
+}
+
+// ensureStateIsUpToDateAndTraceEnv is ensureStateIsUpToDateAndComputeEnv,
+// recording which step set each variable in sources if it isn't nil.
+func (d *Devbox) ensureStateIsUpToDateAndTraceEnv(
+	ctx context.Context,
+	envOpts devopt.EnvOptions,
+	sources *envSources,
 ) (map[string]string, error) {
 	defer debug.FunctionTimer().End()
 
@@ -875,7 +1151,7 @@ func (d *Devbox) ensureStateIsUpToDateAndComputeEnv(
 	// it's ok to use usePrintDevEnvCache=true here always. This does end up
 	// doing some non-nix work twice if lockfile is not up to date.
 	// TODO: Improve this to avoid extra work.
-	return d.computeEnv(ctx, true /*usePrintDevEnvCache*/, envOpts)
+	return d.traceEnv(ctx, true /*usePrintDevEnvCache*/, envOpts, sources)
 }
 
 func (d *Devbox) nixPrintDevEnvCachePath() string {
@@ -998,14 +1274,14 @@ func (d *Devbox) checkOldEnvrc() error {
 func (d *Devbox) configEnvs(
 	ctx context.Context,
 	existingEnv map[string]string,
-) (map[string]string, error) {
+) (map[string]string, []devconfig.EnvSource, error) {
 	defer debug.FunctionTimer().End()
-	env := map[string]string{}
+	layers := []devconfig.EnvSource{}
 	if d.cfg.IsEnvsecEnabled() {
 		secrets, err := d.Secrets(ctx)
 		// TODO: replace this with error.Is check once envsec exports it.
 		if err != nil && !strings.Contains(err.Error(), "project not initialized") {
-			return nil, err
+			return nil, nil, err
 		} else if err != nil {
 			ux.Fwarningf(
 				d.stderr,
@@ -1021,9 +1297,11 @@ func (d *Devbox) configEnvs(
 					err,
 				)
 			} else {
+				secretEnv := map[string]string{}
 				for _, secret := range cloudSecrets {
-					env[secret.Name] = secret.Value
+					secretEnv[secret.Name] = secret.Value
 				}
+				layers = append(layers, devconfig.EnvSource{Name: "jetify cloud secrets", Env: secretEnv})
 			}
 		}
 	} else if d.cfg.Root.IsdotEnvEnabled() {
@@ -1032,26 +1310,27 @@ func (d *Devbox) configEnvs(
 		if err != nil {
 			// it's fine to include the error ParseEnvsFromDotEnv here because
 			// the error message is relevant to the user
-			return nil, usererr.New(
+			return nil, nil, usererr.New(
 				"failed parsing %s file. Error: %v",
 				d.cfg.Root.EnvFrom,
 				err,
 			)
 		}
-		for k, v := range parsedEnvs {
-			env[k] = v
-		}
+		layers = append(layers, devconfig.EnvSource{Name: "env_from " + d.cfg.Root.EnvFrom, Env: parsedEnvs})
 	} else if d.cfg.Root.EnvFrom != "" {
-		return nil, usererr.New(
+		return nil, nil, usererr.New(
 			"unknown env_from value: %s. Supported values are: \"%q\" or a path to a file ending in \".env\"",
 			d.cfg.Root.EnvFrom,
 			configfile.JetifyCloudEnvFromValue,
 		)
 	}
-	for k, v := range d.cfg.Env() {
-		env[k] = v
+	layers = append(layers, d.cfg.EnvSources()...)
+
+	env := map[string]string{}
+	for _, layer := range layers {
+		maps.Copy(env, layer.Env)
 	}
-	return conf.OSExpandEnvMap(env, existingEnv, d.ProjectDir()), nil
+	return conf.OSExpandEnvMap(env, existingEnv, d.ProjectDir()), layers, nil
 }
 
 // ignoreCurrentEnvVar contains environment variables that Devbox should remove
@@ -1160,41 +1439,61 @@ func (d *Devbox) Lockfile() *lock.File {
 
 func (d *Devbox) RunXPaths(ctx context.Context) (string, error) {
 	runxBinPath := filepath.Join(d.projectDir, ".devbox", "virtenv", "runx", "bin")
-	if err := os.RemoveAll(runxBinPath); err != nil {
-		return "", err
-	}
-	if err := os.MkdirAll(runxBinPath, 0o755); err != nil {
-		return "", err
-	}
-
+	paths := []string{}
 	for _, pkg := range d.InstallablePackages() {
 		if !pkg.IsRunX() {
 			continue
 		}
-		lockedPkg, err := d.lockfile.Resolve(pkg.Raw)
+		pkgPaths, err := d.lockfile.InstallRunXPackage(ctx, pkg.Raw)
 		if err != nil {
 			return "", err
 		}
-		paths, err := pkgtype.RunXClient().Install(ctx, lockedPkg.Resolved)
+		paths = append(paths, pkgPaths...)
+	}
+	return runxBinPath, linkBinPath(runxBinPath, paths)
+}
+
+// URLPaths installs url packages and returns a directory with links to their
+// executables.
+func (d *Devbox) URLPaths(ctx context.Context) (string, error) {
+	urlBinPath := filepath.Join(d.projectDir, ".devbox", "virtenv", "url", "bin")
+	paths := []string{}
+	for _, pkg := range d.InstallablePackages() {
+		if !pkg.IsURL() {
+			continue
+		}
+		path, err := d.lockfile.InstallURLPackage(ctx, pkg.Raw, pkg.URLChecksum())
 		if err != nil {
 			return "", err
 		}
-		for _, path := range paths {
-			// create symlink to all files in p
-			files, err := os.ReadDir(path)
-			if err != nil {
-				return "", err
-			}
-			for _, file := range files {
-				src := filepath.Join(path, file.Name())
-				dst := filepath.Join(runxBinPath, file.Name())
-				if err := os.Symlink(src, dst); err != nil && !errors.Is(err, os.ErrExist) {
-					return "", err
-				}
+		paths = append(paths, path)
+	}
+	return urlBinPath, linkBinPath(urlBinPath, paths)
+}
+
+// linkBinPath recreates binPath with symlinks to all files in paths.
+func linkBinPath(binPath string, paths []string) error {
+	if err := os.RemoveAll(binPath); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(binPath, 0o755); err != nil {
+		return err
+	}
+	for _, path := range paths {
+		// create symlink to all files in p
+		files, err := os.ReadDir(path)
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			src := filepath.Join(path, file.Name())
+			dst := filepath.Join(binPath, file.Name())
+			if err := os.Symlink(src, dst); err != nil && !errors.Is(err, os.ErrExist) {
+				return err
 			}
 		}
 	}
-	return runxBinPath, nil
+	return nil
 }
 
 func validateEnvironment(environment string) (string, error) {
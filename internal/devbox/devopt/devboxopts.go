@@ -2,6 +2,9 @@
 
 import (
 	"io"
+	"time"
+
+	"go.jetify.com/devbox/internal/trust"
 )
 
 // Naming Convention:
@@ -11,16 +14,30 @@
 type Opts struct {
 	Dir                      string
 	Env                      map[string]string
+	UnsetEnv                 []string
 	Environment              string
 	IgnoreWarnings           bool
 	CustomProcessComposeFile string
 	Stderr                   io.Writer
+	// AddProjects are the directories of projects whose environments are
+	// layered under this project's environment, before the ones in
+	// devbox.json.
+	AddProjects []string
 }
 
 type ProcessComposeOpts struct {
 	ExtraFlags         []string
 	Background         bool
 	ProcessComposePort int
+
+	// Detach runs process-compose in the background in its own session, so
+	// that it keeps running after the terminal closes. It implies
+	// Background and Supervise.
+	Detach bool
+
+	// Supervise restarts services that exit with an error, after a
+	// backoff.
+	Supervise bool
 }
 
 type GenerateOpts struct {
@@ -56,17 +73,85 @@ type Credentials struct {
 
 type AddOpts struct {
 	AllowInsecure    []string
+	InsecureExpires  string // YYYY-MM-DD
 	Platforms        []string
 	ExcludePlatforms []string
 	DisablePlugin    bool
 	Patch            string
 	Outputs          []string
+	Override         map[string]any
+	Overlay          string
+	// Explain prints every org policy rule that the packages are checked
+	// against.
+	Explain bool
+}
+
+// RemoteOpts configures running a command on a remote machine.
+type RemoteOpts struct {
+	// Target is an ssh:// URL, or "vm" for the project's VM.
+	Target string
+
+	// InstallDevbox installs Devbox on the machine without asking if it
+	// isn't installed.
+	InstallDevbox bool
+}
+
+type VMUpOpts struct {
+	Provider        string
+	Region          string
+	Size            string
+	SSHFromAnywhere bool
+}
+
+type FetchOpts struct {
+	AllPlatforms bool
+}
+
+type LockDiffOpts struct {
+	Rev   string
+	Since time.Duration
+}
+
+type LockTidyOpts struct {
+	Systems bool
+}
+
+type LockCheckOpts struct {
+	// Systems are the systems to check. If it's empty, the systems in
+	// devbox.json or the default ones are checked.
+	Systems []string
+}
+
+type TestOpts struct {
+	// Names are the tests to run. If it's empty, every test runs.
+	Names []string
+}
+
+type StatusOpts struct {
+	// Remote is a git revision, such as origin/main, to compare the
+	// project's devbox.json and devbox.lock with.
+	Remote string
 }
 
 type UpdateOpts struct {
 	Pkgs                  []string
 	NoInstall             bool
 	IgnoreMissingPackages bool
+	// AllSystems records the packages' store paths for every system in
+	// devbox.json's systems, instead of only the current one.
+	AllSystems bool
+	// Changelog prints the release notes of the packages whose versions
+	// changed, not just links to their changelogs.
+	Changelog bool
+	// Commit commits the changes to devbox.json and devbox.lock, on a new
+	// branch if Branch is set. PullRequest pushes the branch and opens a
+	// GitHub pull request for it.
+	Commit      bool
+	Branch      string
+	PullRequest bool
+	// Explain prints every org policy rule that the updated packages are
+	// checked against.
+	Explain bool
 }
 
 type ShellFormat string
@@ -76,6 +161,27 @@ type UpdateOpts struct {
 	ShellFormatNushell ShellFormat = "nushell"
 )
 
+// EnvFileFormat is a format that devbox export writes the environment in.
+type EnvFileFormat string
+
+const (
+	// EnvFileFormatDotenv is KEY=value lines, for docker run --env-file
+	// and kubectl create configmap --from-env-file.
+	EnvFileFormatDotenv EnvFileFormat = "dotenv"
+	// EnvFileFormatDockerArgs is --env arguments for docker run.
+	EnvFileFormatDockerArgs EnvFileFormat = "docker-args"
+	// EnvFileFormatSystemd is a systemd EnvironmentFile.
+	EnvFileFormatSystemd EnvFileFormat = "systemd"
+)
+
+// EnvFileFormats are the formats that devbox export supports.
+var EnvFileFormats = []EnvFileFormat{EnvFileFormatDotenv, EnvFileFormatDockerArgs, EnvFileFormatSystemd}
+
+type EnvFileOpts struct {
+	EnvOptions EnvOptions
+	Format     EnvFileFormat
+}
+
 type EnvExportsOpts struct {
 	EnvOptions     EnvOptions
 	NoRefreshAlias bool
@@ -92,10 +198,48 @@ type EnvOptions struct {
 	OmitNixEnv        bool
 	PreservePathStack bool
 	Pure              bool
-	SkipRecompute     bool
+	// ReplaceShellEnv removes what devbox added to the environment of the
+	// devbox shells that this environment is computed in, so that they
+	// don't layer under it.
+	ReplaceShellEnv bool
+	SkipRecompute   bool
+	// Sandbox and Container are only used by RunScript.
+	Sandbox   Sandbox
+	Container Container
+}
+
+// Sandbox selects whether devbox run runs a command in a sandbox, in addition
+// to the scripts that devbox.json sandboxes.
+type Sandbox struct {
+	Enabled bool
+	// Network is "none" or "host". If it's empty, devbox.json decides.
+	Network string
+}
+
+// Container selects whether devbox run runs a command in an ephemeral docker
+// or podman container.
+type Container struct {
+	Enabled bool
+	// Image is the container image. If it's empty, Devbox uses a small
+	// default image.
+	Image string
 }
 
 type LifecycleHooks struct {
 	// OnStaleState is called when the Devbox state is out of date
 	OnStaleState func()
 }
+
+// HookEnvOpts configure the script that the devbox shell hook evaluates when
+// the shell's working directory changes.
+type HookEnvOpts struct {
+	// Dir is the shell's working directory.
+	Dir string
+	// Shell is the shell that evaluates the script: bash, zsh or fish.
+	Shell string
+	// Ask asks the user whether the project in projectDir may be activated
+	// automatically, the first time they cd into it. It returns "" if they
+	// didn't decide. If it's nil, the user is told how to allow the
+	// project instead.
+	Ask func(projectDir string) (trust.Decision, error)
+}
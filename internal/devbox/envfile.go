@@ -0,0 +1,125 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"context"
+	"io"
+	"runtime/trace"
+	"slices"
+	"strings"
+
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+	"go.jetify.com/devbox/internal/devbox/devopt"
+	"go.jetify.com/devbox/internal/ux"
+)
+
+// EnvFile returns the project's environment in a format that other tools read
+// environment variables from, such as docker run --env-file or a systemd
+// EnvironmentFile. Unlike EnvExports, it doesn't include shell functions or
+// the refresh alias, because it isn't read by a shell.
+func (d *Devbox) EnvFile(ctx context.Context, opts devopt.EnvFileOpts) (string, error) {
+	ctx, task := trace.NewTask(ctx, "devboxEnvFile")
+	defer task.End()
+
+	if !slices.Contains(devopt.EnvFileFormats, opts.Format) {
+		return "", usererr.New("Unknown format %q. Use one of: %s.", opts.Format, envFileFormatList())
+	}
+	envs, err := d.ensureStateIsUpToDateAndComputeEnv(ctx, opts.EnvOptions)
+	if err != nil {
+		return "", err
+	}
+
+	switch opts.Format {
+	case devopt.EnvFileFormatDockerArgs:
+		return dockerArgsify(d.stderr, envs), nil
+	case devopt.EnvFileFormatSystemd:
+		return systemdify(d.stderr, envs), nil
+	default:
+		return dotenvify(d.stderr, envs), nil
+	}
+}
+
+func envFileFormatList() string {
+	formats := make([]string, len(devopt.EnvFileFormats))
+	for i, f := range devopt.EnvFileFormats {
+		formats[i] = string(f)
+	}
+	return strings.Join(formats, ", ")
+}
+
+// envFileNames returns the sorted names of the variables in vars that can be
+// written to an environment file. It skips bash functions, and warns about
+// names that aren't valid.
+func envFileNames(w io.Writer, vars map[string]string) []string {
+	names := []string{}
+	var invalidNames []string
+	for name := range vars {
+		if strings.HasPrefix(name, "BASH_FUNC_") && strings.HasSuffix(name, "%%") {
+			continue
+		}
+		if !isValidEnvName(name) {
+			invalidNames = append(invalidNames, name)
+			continue
+		}
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	slices.Sort(invalidNames)
+	warnInvalidEnvNames(w, invalidNames)
+	return names
+}
+
+// dotenvify formats vars as KEY=value lines. docker run --env-file and kubectl
+// create configmap --from-env-file read values literally, without quotes or
+// escapes, so values that span several lines can't be written and are skipped.
+func dotenvify(w io.Writer, vars map[string]string) string {
+	var multiline []string
+	strb := strings.Builder{}
+	for _, name := range envFileNames(w, vars) {
+		if strings.ContainsAny(vars[name], "\r\n") {
+			multiline = append(multiline, name)
+			continue
+		}
+		strb.WriteString(name)
+		strb.WriteString("=")
+		strb.WriteString(vars[name])
+		strb.WriteString("\n")
+	}
+	if len(multiline) > 0 {
+		ux.Fwarningf(w, "Skipping variables with values that span several lines, which env files "+
+			"can't hold: %s. Use --format docker-args or systemd for them.\n", strings.Join(multiline, ", "))
+	}
+	return strings.TrimSpace(strb.String())
+}
+
+// dockerArgsify formats vars as docker run --env arguments, quoted for the
+// shell, such as `--env 'GOPATH=/home/user/go'`.
+func dockerArgsify(w io.Writer, vars map[string]string) string {
+	args := []string{}
+	for _, name := range envFileNames(w, vars) {
+		args = append(args, "--env", "'"+strings.ReplaceAll(name+"="+vars[name], "'", `'\''`)+"'")
+	}
+	return strings.Join(args, " ")
+}
+
+// systemdify formats vars as a systemd EnvironmentFile. Values are double
+// quoted with the characters that systemd treats specially escaped, which
+// lets them span several lines.
+func systemdify(w io.Writer, vars map[string]string) string {
+	strb := strings.Builder{}
+	for _, name := range envFileNames(w, vars) {
+		strb.WriteString(name)
+		strb.WriteString(`="`)
+		for _, r := range vars[name] {
+			switch r {
+			case '"', '\\', '$', '`':
+				strb.WriteRune('\\')
+			}
+			strb.WriteRune(r)
+		}
+		strb.WriteString("\"\n")
+	}
+	return strings.TrimSpace(strb.String())
+}
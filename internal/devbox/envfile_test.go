@@ -0,0 +1,43 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"io"
+	"testing"
+)
+
+var envFileVars = map[string]string{
+	"GOPATH":             "/home/user/go",
+	"GREETING":           `it's "$HOME"`,
+	"MULTILINE":          "a\nb",
+	"BASH_FUNC_hello%%":  "() { echo hello; }",
+	"//":                 "comment",
+	"__DEVBOX_SET_GOBIN": "1",
+}
+
+func TestDotenvify(t *testing.T) {
+	got := dotenvify(io.Discard, envFileVars)
+	want := "GOPATH=/home/user/go\nGREETING=it's \"$HOME\"\n__DEVBOX_SET_GOBIN=1"
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestDockerArgsify(t *testing.T) {
+	got := dockerArgsify(io.Discard, envFileVars)
+	want := `--env 'GOPATH=/home/user/go' --env 'GREETING=it'\''s "$HOME"' ` +
+		"--env 'MULTILINE=a\nb' --env '__DEVBOX_SET_GOBIN=1'"
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestSystemdify(t *testing.T) {
+	got := systemdify(io.Discard, envFileVars)
+	want := "GOPATH=\"/home/user/go\"\nGREETING=\"it's \\\"\\$HOME\\\"\"\nMULTILINE=\"a\nb\"\n__DEVBOX_SET_GOBIN=\"1\""
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
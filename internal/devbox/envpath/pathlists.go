@@ -55,3 +55,36 @@ func RemoveFromPath(path, pathToRemove string) string {
 
 	return newPath
 }
+
+// StripPathList removes the paths that match any of the glob patterns from a
+// PATH-style string. A leading "~/" in a pattern is the home directory.
+func StripPathList(path string, patterns []string) string {
+	if len(patterns) == 0 {
+		return path
+	}
+	home, _ := os.UserHomeDir()
+	expanded := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		if rest, ok := strings.CutPrefix(pattern, "~/"); ok && home != "" {
+			pattern = filepath.Join(home, rest)
+		}
+		expanded = append(expanded, filepath.Clean(pattern))
+	}
+
+	var kept []string
+	for _, p := range filepath.SplitList(path) {
+		if !matchesAny(filepath.Clean(p), expanded) {
+			kept = append(kept, p)
+		}
+	}
+	return strings.Join(kept, string(filepath.ListSeparator))
+}
+
+func matchesAny(path string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, path); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -30,3 +30,30 @@ func TestCleanEnvPath(t *testing.T) {
 		})
 	}
 }
+
+func TestStripPathList(t *testing.T) {
+	t.Setenv("HOME", "/home/user")
+	path := "/opt/homebrew/bin:/usr/bin:/opt/homebrew/sbin:/home/user/.cargo/bin:/bin"
+	tests := []struct {
+		patterns []string
+		want     string
+	}{
+		{
+			patterns: nil,
+			want:     path,
+		},
+		{
+			patterns: []string{"/opt/homebrew/*"},
+			want:     "/usr/bin:/home/user/.cargo/bin:/bin",
+		},
+		{
+			patterns: []string{"/opt/homebrew/bin", "~/.cargo/bin"},
+			want:     "/usr/bin:/opt/homebrew/sbin:/bin",
+		},
+	}
+	for _, test := range tests {
+		if got := StripPathList(path, test.patterns); got != test.want {
+			t.Errorf("StripPathList(%v) = %s, want %s", test.patterns, got, test.want)
+		}
+	}
+}
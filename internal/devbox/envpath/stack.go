@@ -55,6 +55,23 @@ func (s *stack) Path(env map[string]string) string {
 	return JoinPathLists(pathLists...)
 }
 
+// PathWithHost returns the PATH like Path, except that hostPath replaces the
+// PATH from before any devbox-shellenv modified the environment. If hostFirst
+// is true, hostPath comes before the paths of the devbox-projects, so that the
+// host's tools take precedence.
+func (s *stack) PathWithHost(env map[string]string, hostPath string, hostFirst bool) string {
+	pathLists := []string{}
+	for _, key := range s.keys {
+		if key != InitPathEnv {
+			pathLists = append(pathLists, env[key])
+		}
+	}
+	if hostFirst {
+		return JoinPathLists(append([]string{hostPath}, pathLists...)...)
+	}
+	return JoinPathLists(append(pathLists, hostPath)...)
+}
+
 // Key is the element stored in the stack for a devbox-project. It represents
 // a pointer to the devboxEnvPath value stored in its own env-var, also using this same Key.
 func Key(projectHash string) string {
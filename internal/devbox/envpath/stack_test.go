@@ -101,3 +101,16 @@ func TestNewStack(t *testing.T) {
 			})
 	}
 }
+
+func TestPathWithHost(t *testing.T) {
+	env := map[string]string{}
+	stack := Stack(env, map[string]string{"PATH": "/init-path"})
+	stack.Push(env, "fooProjectHash", "/foo1:/foo2", false)
+
+	if got, want := stack.PathWithHost(env, env[InitPathEnv], false), stack.Path(env); got != want {
+		t.Errorf("PathWithHost() = %s, want the same as Path(): %s", got, want)
+	}
+	if got, want := stack.PathWithHost(env, "/host1:/host2", true), "/host1:/host2:/foo1:/foo2"; got != want {
+		t.Errorf("PathWithHost(hostFirst) = %s, want %s", got, want)
+	}
+}
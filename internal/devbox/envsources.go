@@ -0,0 +1,131 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+
+	"go.jetify.com/devbox/internal/devbox/devopt"
+	"go.jetify.com/devbox/internal/devconfig"
+)
+
+// EnvContribution is a step of the environment computation that set or unset
+// a variable.
+type EnvContribution struct {
+	// Source names the step, such as "nix print-dev-env", "plugin nodejs"
+	// or "devbox.json".
+	Source string `json:"source"`
+	Value  string `json:"value,omitempty"`
+	Unset  bool   `json:"unset,omitempty"`
+}
+
+// EnvExplanation is the value of a variable in the devbox environment and
+// the steps that contributed to it, in the order they ran. The last
+// contribution is the one that determined the value.
+type EnvExplanation struct {
+	Name          string            `json:"name"`
+	Value         string            `json:"value"`
+	Set           bool              `json:"set"`
+	Contributions []EnvContribution `json:"contributions"`
+}
+
+// ExplainEnv computes the devbox environment and explains how each variable
+// got its value. If names is empty, it explains every variable in the
+// environment.
+func (d *Devbox) ExplainEnv(
+	ctx context.Context,
+	envOpts devopt.EnvOptions,
+	names ...string,
+) ([]EnvExplanation, error) {
+	sources := newEnvSources()
+	env, err := d.ensureStateIsUpToDateAndTraceEnv(ctx, envOpts, sources)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(names) == 0 {
+		names = slices.Sorted(maps.Keys(env))
+	}
+	explanations := make([]EnvExplanation, 0, len(names))
+	for _, name := range names {
+		value, set := env[name]
+		explanations = append(explanations, EnvExplanation{
+			Name:          name,
+			Value:         value,
+			Set:           set,
+			Contributions: sources.contributions[name],
+		})
+	}
+	return explanations, nil
+}
+
+// envSources records which step of the environment computation set each
+// variable. Its methods do nothing on a nil *envSources, so that computing
+// the environment without recording sources doesn't cost anything.
+type envSources struct {
+	// last is the environment as of the last recorded step.
+	last          map[string]string
+	contributions map[string][]EnvContribution
+}
+
+func newEnvSources() *envSources {
+	return &envSources{
+		last:          map[string]string{},
+		contributions: map[string][]EnvContribution{},
+	}
+}
+
+// record attributes every variable that changed since the last step to
+// source.
+func (s *envSources) record(source string, env map[string]string) {
+	if s == nil {
+		return
+	}
+	for name, value := range env {
+		if last, ok := s.last[name]; ok && last == value {
+			continue
+		}
+		s.add(name, EnvContribution{Source: source, Value: value})
+	}
+	for name := range s.last {
+		if _, ok := env[name]; !ok {
+			s.add(name, EnvContribution{Source: source, Unset: true})
+		}
+	}
+	s.last = maps.Clone(env)
+}
+
+// recordLayers attributes the variables of each layer of config env to the
+// layer, even when a later layer overrides them. Variables that devbox set in
+// an enclosing devbox shell are skipped, because the config doesn't change
+// them. expand expands a layer against the environment before the config env.
+func (s *envSources) recordLayers(
+	layers []devconfig.EnvSource,
+	env map[string]string,
+	expand func(layer, before map[string]string) map[string]string,
+) {
+	if s == nil {
+		return
+	}
+	for _, layer := range layers {
+		expanded := expand(layer.Env, s.last)
+		for _, name := range slices.Sorted(maps.Keys(expanded)) {
+			if _, setByDevbox := env[devboxSetPrefix+name]; setByDevbox {
+				continue
+			}
+			s.add(name, EnvContribution{Source: layer.Name, Value: expanded[name]})
+		}
+	}
+	s.last = maps.Clone(env)
+}
+
+func (s *envSources) add(name string, c EnvContribution) {
+	if strings.HasPrefix(name, devboxSetPrefix) {
+		return
+	}
+	s.contributions[name] = append(s.contributions[name], c)
+}
@@ -0,0 +1,71 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	"go.jetify.com/devbox/internal/devconfig"
+)
+
+func TestEnvSources(t *testing.T) {
+	sources := newEnvSources()
+	env := map[string]string{
+		"HOME": "/home/user",
+		"PATH": "/usr/bin",
+		"CI":   "1",
+		// Set by the devbox.json of an enclosing devbox shell.
+		"NESTED":                   "outer",
+		devboxSetPrefix + "NESTED": "1",
+	}
+	sources.record("current environment", env)
+
+	env["PATH"] = "/nix/bin:/usr/bin"
+	env["HOME"] = "/home/user"
+	sources.record("nix print-dev-env", env)
+
+	env["GREETING"] = "hi from devbox.json"
+	sources.recordLayers([]devconfig.EnvSource{
+		{Name: "plugin greeter", Env: map[string]string{"GREETING": "hi from plugin"}},
+		{Name: "devbox.json", Env: map[string]string{"GREETING": "hi from devbox.json", "NESTED": "inner"}},
+	}, env, func(layer, before map[string]string) map[string]string {
+		return maps.Clone(layer)
+	})
+
+	delete(env, "CI")
+	sources.record("--env, --env-file or --unset-env flag", env)
+
+	tests := map[string][]EnvContribution{
+		"HOME": {{Source: "current environment", Value: "/home/user"}},
+		"PATH": {
+			{Source: "current environment", Value: "/usr/bin"},
+			{Source: "nix print-dev-env", Value: "/nix/bin:/usr/bin"},
+		},
+		"GREETING": {
+			{Source: "plugin greeter", Value: "hi from plugin"},
+			{Source: "devbox.json", Value: "hi from devbox.json"},
+		},
+		"NESTED": {{Source: "current environment", Value: "outer"}},
+		"CI": {
+			{Source: "current environment", Value: "1"},
+			{Source: "--env, --env-file or --unset-env flag", Unset: true},
+		},
+	}
+	for name, want := range tests {
+		if got := sources.contributions[name]; !slices.Equal(got, want) {
+			t.Errorf("contributions[%s] = %+v, want %+v", name, got, want)
+		}
+	}
+	if got, ok := sources.contributions[devboxSetPrefix+"NESTED"]; ok {
+		t.Errorf("got contributions for %sNESTED: %+v, want none", devboxSetPrefix, got)
+	}
+}
+
+func TestEnvSourcesNil(t *testing.T) {
+	var sources *envSources
+	sources.record("current environment", map[string]string{"A": "1"})
+	sources.recordLayers(nil, nil, nil)
+}
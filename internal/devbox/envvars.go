@@ -186,6 +186,28 @@ func markEnvsAsSetByDevbox(envs ...map[string]string) {
 	}
 }
 
+// removeShellEnv removes what devbox added to env for the devbox shells that
+// it was taken from: their entries in the PATH stack, the variables from
+// their devbox.json, and the variables that skip their init hooks. The PATH
+// is reset to the one from before the outermost shell started.
+func removeShellEnv(env map[string]string) {
+	if initPath, ok := env[envpath.InitPathEnv]; ok {
+		env["PATH"] = initPath
+	}
+	delete(env, envpath.InitPathEnv)
+	delete(env, envpath.PathStackEnv)
+	for key := range env {
+		switch {
+		case strings.HasPrefix(key, envpath.Key("")),
+			strings.HasPrefix(key, "__DEVBOX_SKIP_INIT_HOOK_"):
+			delete(env, key)
+		case strings.HasPrefix(key, devboxSetPrefix):
+			delete(env, strings.TrimPrefix(key, devboxSetPrefix))
+			delete(env, key)
+		}
+	}
+}
+
 // IsEnvEnabled checks if the devbox environment is enabled.
 // This allows us to differentiate between global and
 // individual project shells.
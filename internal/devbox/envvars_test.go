@@ -5,6 +5,7 @@
 
 import (
 	"io"
+	"maps"
 	"strings"
 	"testing"
 )
@@ -60,3 +61,39 @@ func TestExportifyNushellSkipsInvalidNames(t *testing.T) {
 		t.Errorf("expected invalid name to be skipped, got:\n%s", got)
 	}
 }
+
+func TestApplyEnvOverrides(t *testing.T) {
+	d := &Devbox{
+		env:      map[string]string{"DEBUG": "1", "CI": "true"},
+		unsetEnv: []string{"CI", "GOFLAGS"},
+	}
+	env := map[string]string{"PATH": "/usr/bin", "DEBUG": "0", "GOFLAGS": "-mod=mod"}
+	d.applyEnvOverrides(env)
+
+	want := map[string]string{"PATH": "/usr/bin", "DEBUG": "1"}
+	if !maps.Equal(env, want) {
+		t.Errorf("got env %v, want %v", env, want)
+	}
+}
+
+func TestRemoveShellEnv(t *testing.T) {
+	env := map[string]string{
+		"PATH":                      "/a/bin:/usr/bin",
+		"DEVBOX_INIT_PATH":          "/usr/bin",
+		"DEVBOX_PATH_STACK":         "DEVBOX_NIX_ENV_PATH_a:DEVBOX_INIT_PATH",
+		"DEVBOX_NIX_ENV_PATH_a":     "/a/bin",
+		"__DEVBOX_SKIP_INIT_HOOK_a": "true",
+		"FROM_CONFIG":               "a",
+		"__DEVBOX_SET_FROM_CONFIG":  "1",
+		"HOME":                      "/home/user",
+	}
+	removeShellEnv(env)
+
+	want := map[string]string{
+		"PATH": "/usr/bin",
+		"HOME": "/home/user",
+	}
+	if !maps.Equal(env, want) {
+		t.Errorf("got env %v, want %v", env, want)
+	}
+}
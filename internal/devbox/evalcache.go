@@ -0,0 +1,33 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"context"
+	"time"
+
+	"go.jetify.com/devbox/internal/nix"
+	"go.jetify.com/devbox/internal/ux"
+)
+
+// WarmEvalCache brings the project up to date and evaluates its flake so that
+// nix's evaluation cache and devbox's cached environment are populated
+// before the next shell starts.
+func (d *Devbox) WarmEvalCache(ctx context.Context) error {
+	start := time.Now()
+	if err := d.ensureStateIsUpToDate(ctx, ensure); err != nil {
+		return err
+	}
+	drvPath, err := nix.WarmEvalCache(ctx, d.flakeDir())
+	if err != nil {
+		return err
+	}
+	// Recompute the environment instead of reading it from devbox's cache,
+	// which also evaluates the flake with the now warm eval cache.
+	if _, err := d.execPrintDevEnv(ctx, false /*usePrintDevEnvCache*/); err != nil {
+		return err
+	}
+	ux.Fsuccessf(d.stderr, "Evaluated %s in %s\n", drvPath, time.Since(start).Round(time.Millisecond))
+	return nil
+}
@@ -0,0 +1,179 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"cmp"
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+	"go.jetify.com/devbox/internal/devpkg"
+	"go.jetify.com/devbox/internal/devpkg/pkgtype"
+	"go.jetify.com/devbox/internal/envir"
+	"go.jetify.com/devbox/internal/lock"
+	"go.jetify.com/devbox/internal/nix"
+	"go.jetify.com/devbox/internal/ux"
+	"go.jetify.com/devbox/nix/flake"
+)
+
+// ExecPackages resolves and fetches pkgs and runs cmdArgs with the packages'
+// bin directories at the front of PATH. The packages are resolved to the
+// latest nixpkgs without reading or writing a project's devbox.json or
+// devbox.lock, so ExecPackages works the same inside and outside of projects.
+// If pkgs is empty, the latest version of the package named after the command
+// is used.
+func ExecPackages(ctx context.Context, stderr io.Writer, pkgs, cmdArgs []string) error {
+	if len(cmdArgs) == 0 {
+		return usererr.New("No command to run.")
+	}
+	if len(pkgs) == 0 {
+		pkgs = []string{filepath.Base(cmdArgs[0]) + "@latest"}
+	}
+	path, err := packagesPath(ctx, stderr, pkgs)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, cmdArgs[0], cmdArgs[1:]...)
+	cmd.Env = append(os.Environ(), "PATH="+path)
+	// Look up the command in the packages first, since exec.Command
+	// searches the PATH of this process.
+	if lp, err := lookPath(cmdArgs[0], path); err == nil {
+		cmd.Path = lp
+		cmd.Err = nil
+	}
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	slog.Debug("executing package command", "cmd", cmd.Args, "path", path)
+	return usererr.NewExecError(cmd.Run())
+}
+
+// ShellPackages resolves and fetches pkgs like ExecPackages and starts an
+// interactive shell with the packages' bin directories at the front of PATH
+// and env set. It's an ad hoc shell that isn't tied to a project.
+func ShellPackages(ctx context.Context, stderr io.Writer, pkgs []string, env map[string]string) error {
+	if len(pkgs) == 0 {
+		return usererr.New("No packages to start a shell with.")
+	}
+	path, err := packagesPath(ctx, stderr, pkgs)
+	if err != nil {
+		return err
+	}
+
+	shell := cmp.Or(os.Getenv(envir.Shell), "/bin/sh")
+	cmd := exec.CommandContext(ctx, shell)
+	cmd.Env = os.Environ()
+	for k, v := range env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	cmd.Env = append(cmd.Env,
+		"PATH="+path,
+		envir.DevboxShellDepth+"="+strconv.Itoa(envir.ShellDepth()+1),
+	)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	ux.Finfof(stderr, "Starting a shell with %s. Run `exit` to leave it.\n", strings.Join(pkgs, ", "))
+	slog.Debug("starting package shell", "shell", shell, "path", path)
+	return usererr.NewExecError(cmd.Run())
+}
+
+// packagesPath resolves pkgs with a lockfile that's only kept in memory,
+// fetches them to the nix store and returns the PATH with their bin
+// directories in front of it.
+func packagesPath(ctx context.Context, stderr io.Writer, pkgs []string) (string, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	lockfile := lock.NewFile(&execProject{dir: wd, pkgs: pkgs})
+
+	binDirs := []string{}
+	for _, raw := range pkgs {
+		if pkgtype.IsRunX(raw) || pkgtype.IsURL(raw) {
+			return "", usererr.New("Only nix packages can be used without a project, but %q isn't one.", raw)
+		}
+		pkg := devpkg.PackageFromStringWithDefaults(raw, lockfile)
+		installables, err := pkg.Installables()
+		if err != nil {
+			return "", err
+		}
+		err = nix.Build(ctx, &nix.BuildArgs{
+			AllowInsecure: pkg.HasAllowInsecure(),
+			Flags:         []string{"--no-link"},
+			Writer:        stderr,
+		}, installables...)
+		if err != nil {
+			return "", err
+		}
+		storePaths, err := pkg.GetStorePaths(ctx, stderr)
+		if err != nil {
+			return "", err
+		}
+		for _, path := range storePaths {
+			if _, err := os.Stat(filepath.Join(path, "bin")); err == nil {
+				binDirs = append(binDirs, filepath.Join(path, "bin"))
+			}
+		}
+	}
+
+	path := strings.Join(binDirs, string(filepath.ListSeparator))
+	if envPath := os.Getenv("PATH"); envPath != "" {
+		path += string(filepath.ListSeparator) + envPath
+	}
+	return path, nil
+}
+
+// lookPath is like exec.LookPath, but searches path instead of the PATH
+// environment variable.
+func lookPath(file, path string) (string, error) {
+	if strings.Contains(file, string(filepath.Separator)) {
+		return exec.LookPath(file)
+	}
+	for _, dir := range filepath.SplitList(path) {
+		candidate := filepath.Join(dir, file)
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() && info.Mode()&0o111 != 0 {
+			return candidate, nil
+		}
+	}
+	return "", errors.WithStack(exec.ErrNotFound)
+}
+
+// execProject is the project of the lockfile that packagesPath resolves
+// packages with. It isn't backed by a devbox.json.
+type execProject struct {
+	dir  string
+	pkgs []string
+}
+
+func (*execProject) ConfigHash() (string, error) { return "", nil }
+
+func (*execProject) Stdenv() flake.Ref {
+	return flake.Ref{
+		Type:  flake.TypeGitHub,
+		Owner: "NixOS",
+		Repo:  "nixpkgs",
+		Ref:   "nixpkgs-unstable",
+	}
+}
+
+func (*execProject) PackageSources() map[string]string { return nil }
+
+func (p *execProject) AllPackageNamesIncludingRemovedTriggerPackages() []string {
+	return p.pkgs
+}
+
+func (p *execProject) ProjectDir() string { return p.dir }
@@ -0,0 +1,37 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLookPath(t *testing.T) {
+	first, second := t.TempDir(), t.TempDir()
+	for _, dir := range []string{first, second} {
+		if err := os.WriteFile(filepath.Join(dir, "tool"), []byte("#!/bin/sh\n"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(first, "data"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	path := first + string(filepath.ListSeparator) + second
+
+	got, err := lookPath("tool", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(first, "tool"); got != want {
+		t.Errorf("got lookPath(%q) = %q, want %q", "tool", got, want)
+	}
+	if got, err := lookPath("data", path); err == nil {
+		t.Errorf("got lookPath(%q) = %q, want an error for a file that isn't executable", "data", got)
+	}
+	if got, err := lookPath("missing", path); err == nil {
+		t.Errorf("got lookPath(%q) = %q, want an error", "missing", got)
+	}
+}
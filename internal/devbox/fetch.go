@@ -0,0 +1,47 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"context"
+
+	"go.jetify.com/devbox/internal/devbox/devopt"
+	"go.jetify.com/devbox/internal/ux"
+)
+
+// Fetch downloads the project's packages for the current system into the nix
+// store, or builds them if they aren't in a binary cache. Unlike Install, it
+// doesn't compute the shell environment or set up the project's nix profile,
+// so it's for priming CI caches and docker layers.
+func (d *Devbox) Fetch(ctx context.Context, opts devopt.FetchOpts) error {
+	unlock, err := d.lockProject()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := d.fetchPackages(ctx, install); err != nil {
+		return err
+	}
+
+	if opts.AllPlatforms {
+		filled := 0
+		for _, pkg := range d.InstallablePackages() {
+			if !pkg.IsDevboxPackage {
+				continue
+			}
+			changed, err := d.lockfile.FillSystems(ctx, pkg.LockfileKey(), nil /*systems*/)
+			if err != nil {
+				return err
+			}
+			if changed {
+				filled++
+			}
+		}
+		if filled > 0 {
+			ux.Finfof(d.stderr, "Recorded the store paths of %d packages for all platforms in devbox.lock.\n", filled)
+		}
+	}
+	return d.updateLockfile(false /*recomputeState*/)
+}
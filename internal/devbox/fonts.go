@@ -0,0 +1,213 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"go.jetify.com/devbox/internal/cmdutil"
+	"go.jetify.com/devbox/internal/xdg"
+)
+
+// fontExtensions are the file extensions of the fonts that devbox installs.
+var fontExtensions = []string{
+	".ttf", ".otf", ".ttc", ".otc", ".dfont", ".woff", ".woff2",
+	".pcf", ".pcf.gz", ".bdf", ".pfa", ".pfb",
+}
+
+// userFontsDir returns the directory in the user's font directory that devbox
+// installs the fonts of devbox global packages into. Devbox owns the whole
+// directory.
+func userFontsDir() (string, error) {
+	if runtime.GOOS == "darwin" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+		return filepath.Join(home, "Library", "Fonts", "Devbox Fonts"), nil
+	}
+	return xdg.DataSubpath(filepath.Join("fonts", "devbox")), nil
+}
+
+// syncFonts installs the fonts of the global profile into the user's font
+// directory when fonts.link is set in the global devbox.json, and removes
+// the fonts of packages that are no longer in the profile. It does nothing
+// for projects.
+func (d *Devbox) syncFonts() error {
+	if !d.isGlobal() {
+		return nil
+	}
+	dst, err := userFontsDir()
+	if err != nil {
+		return err
+	}
+	src := ""
+	if d.cfg.Root.FontsLinked() {
+		profile, err := d.profilePath()
+		if err != nil {
+			return err
+		}
+		src = filepath.Join(profile, "share", "fonts")
+	}
+
+	// macOS doesn't load fonts through symlinks, so they're copied there.
+	changed, err := syncFontDir(src, dst, runtime.GOOS == "darwin")
+	if err != nil {
+		return err
+	}
+	if changed && cmdutil.Exists("fc-cache") {
+		// Refresh fontconfig's cache so that applications see the
+		// changes without a restart. It's only an optimization.
+		_ = exec.Command("fc-cache", "--force", filepath.Dir(dst)).Run()
+	}
+	return nil
+}
+
+// syncFontDir makes dst contain the font files in src, keeping their paths
+// relative to src. It symlinks the files, or copies them if copyFiles is
+// true, and removes everything else in dst. If src is "", it removes dst. It
+// returns true if it changed dst.
+func syncFontDir(src, dst string, copyFiles bool) (bool, error) {
+	want := map[string]string{}
+	if src != "" {
+		if err := findFonts(src, "", want); err != nil {
+			return false, err
+		}
+	}
+	if len(want) == 0 {
+		if _, err := os.Lstat(dst); errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return true, errors.WithStack(os.RemoveAll(dst))
+	}
+
+	changed := false
+	err := filepath.WalkDir(dst, func(path string, entry fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dst, path)
+		if err != nil {
+			return err
+		}
+		if target, ok := want[rel]; ok && fontInstalled(path, target, copyFiles) {
+			delete(want, rel)
+			return nil
+		}
+		changed = true
+		return os.Remove(path)
+	})
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+
+	for rel, target := range want {
+		changed = true
+		path := filepath.Join(dst, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return false, errors.WithStack(err)
+		}
+		if !copyFiles {
+			if err := os.Symlink(target, path); err != nil {
+				return false, errors.WithStack(err)
+			}
+			continue
+		}
+		data, err := os.ReadFile(target)
+		if err != nil {
+			return false, errors.WithStack(err)
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			return false, errors.WithStack(err)
+		}
+	}
+	return changed, removeEmptyDirs(dst)
+}
+
+// fontInstalled returns true if path is a symlink to target, or a copy of it
+// if copied is true. Copies are compared by size because store paths don't
+// change.
+func fontInstalled(path, target string, copied bool) bool {
+	if !copied {
+		link, err := os.Readlink(path)
+		return err == nil && link == target
+	}
+	got, err := os.Lstat(path)
+	if err != nil || !got.Mode().IsRegular() {
+		return false
+	}
+	want, err := os.Stat(target)
+	return err == nil && got.Size() == want.Size()
+}
+
+// findFonts adds the font files in dir/rel to fonts, keyed by their path
+// relative to dir. Unlike filepath.WalkDir, it follows symlinks, which nix
+// profiles consist of.
+func findFonts(dir, rel string, fonts map[string]string) error {
+	entries, err := os.ReadDir(filepath.Join(dir, rel))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return errors.WithStack(err)
+	}
+	for _, entry := range entries {
+		entryRel := filepath.Join(rel, entry.Name())
+		path := filepath.Join(dir, entryRel)
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if info.IsDir() {
+			if err := findFonts(dir, entryRel, fonts); err != nil {
+				return err
+			}
+		} else if isFontFile(path) {
+			fonts[entryRel] = path
+		}
+	}
+	return nil
+}
+
+func isFontFile(path string) bool {
+	name := strings.ToLower(filepath.Base(path))
+	for _, ext := range fontExtensions {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// removeEmptyDirs removes the empty directories under dir, leaving dir.
+func removeEmptyDirs(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		if err := removeEmptyDirs(path); err != nil {
+			return err
+		}
+		if children, err := os.ReadDir(path); err == nil && len(children) == 0 {
+			if err := os.Remove(path); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSyncFontDir(t *testing.T) {
+	for _, copyFiles := range []bool{false, true} {
+		dir := t.TempDir()
+		store := filepath.Join(dir, "store")
+		writeFile(t, filepath.Join(store, "truetype", "JetBrainsMono.ttf"), "jetbrains")
+		writeFile(t, filepath.Join(store, "opentype", "Fira.otf"), "fira")
+		writeFile(t, filepath.Join(store, "README.md"), "not a font")
+
+		// Nix profiles link to the directories of their packages.
+		src := filepath.Join(dir, "profile", "share", "fonts")
+		if err := os.MkdirAll(src, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range []string{"truetype", "opentype"} {
+			if err := os.Symlink(filepath.Join(store, name), filepath.Join(src, name)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		dst := filepath.Join(dir, "fonts", "devbox")
+
+		changed, err := syncFontDir(src, dst, copyFiles)
+		if err != nil || !changed {
+			t.Fatalf("syncFontDir() = %v, %v, want true, nil", changed, err)
+		}
+		assertFont(t, filepath.Join(dst, "truetype", "JetBrainsMono.ttf"), "jetbrains", copyFiles)
+		assertFont(t, filepath.Join(dst, "opentype", "Fira.otf"), "fira", copyFiles)
+		if _, err := os.Lstat(filepath.Join(dst, "README.md")); !os.IsNotExist(err) {
+			t.Errorf("got README.md in %s, want only fonts", dst)
+		}
+
+		changed, err = syncFontDir(src, dst, copyFiles)
+		if err != nil || changed {
+			t.Errorf("second syncFontDir() = %v, %v, want false, nil", changed, err)
+		}
+
+		// Removing a package removes its fonts and their directory.
+		if err := os.Remove(filepath.Join(src, "opentype")); err != nil {
+			t.Fatal(err)
+		}
+		if changed, err := syncFontDir(src, dst, copyFiles); err != nil || !changed {
+			t.Fatalf("syncFontDir() = %v, %v, want true, nil", changed, err)
+		}
+		if _, err := os.Lstat(filepath.Join(dst, "opentype")); !os.IsNotExist(err) {
+			t.Errorf("got %s after removing its package", filepath.Join(dst, "opentype"))
+		}
+		assertFont(t, filepath.Join(dst, "truetype", "JetBrainsMono.ttf"), "jetbrains", copyFiles)
+
+		if changed, err := syncFontDir("", dst, copyFiles); err != nil || !changed {
+			t.Fatalf("syncFontDir() = %v, %v, want true, nil", changed, err)
+		}
+		if _, err := os.Lstat(dst); !os.IsNotExist(err) {
+			t.Errorf("got %s after disabling fonts.link, want it removed", dst)
+		}
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func assertFont(t *testing.T, path, want string, copied bool) {
+	t.Helper()
+	info, err := os.Lstat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if isLink := info.Mode()&os.ModeSymlink != 0; isLink == copied {
+		t.Errorf("%s is a symlink: %v, want %v", path, isLink, !copied)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("%s contains %q, want %q", path, got, want)
+	}
+}
@@ -0,0 +1,36 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"slices"
+	"strings"
+
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+)
+
+// CheckFrozenLockfile returns an error if devbox.json and devbox.lock
+// disagree, because a package in devbox.json isn't in devbox.lock or
+// devbox.lock has a package that devbox.json doesn't. The error lists the
+// differences. It doesn't check if the packages are installed.
+func (d *Devbox) CheckFrozenLockfile() error {
+	diff := []string{}
+	for _, pkg := range d.InstallablePackages() {
+		if d.lockfile.Get(pkg.LockfileKey()) == nil {
+			diff = append(diff, "  + "+pkg.LockfileKey()+" (in devbox.json, missing from devbox.lock)")
+		}
+	}
+	slices.Sort(diff)
+	for _, key := range d.lockfile.ExtraPackages() {
+		diff = append(diff, "  - "+key+" (in devbox.lock, not in devbox.json)")
+	}
+	if len(diff) == 0 {
+		return nil
+	}
+	return usererr.New(
+		"devbox.json and devbox.lock disagree, and --frozen-lockfile is set:\n%s\n"+
+			"Run `devbox install` without --frozen-lockfile to update devbox.lock and commit it.",
+		strings.Join(diff, "\n"),
+	)
+}
@@ -0,0 +1,114 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package generate
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"go.jetify.com/devbox/internal/devbox/devopt"
+)
+
+// EnvrcBlockVersion is the version of the block that EnvrcBlock generates.
+// Increment it whenever the content of the block changes, so that
+// "devbox direnv setup" can tell which version a .envrc has.
+const EnvrcBlockVersion = 1
+
+// envrcBlockRegexp matches a block generated by EnvrcBlock, of any version.
+var envrcBlockRegexp = regexp.MustCompile(
+	`(?ms)^# >>> devbox direnv v(\d+) >>>$.*?^# <<< devbox direnv <<<$\n?`)
+
+// EnvrcBlock returns the block of a .envrc in opts.EnvrcDir that loads the
+// devbox environment of opts.ConfigDir. The block caches the environment
+// when nix-direnv is loaded, and runs devbox shellenv every time otherwise.
+func EnvrcBlock(opts devopt.EnvrcOpts) (string, error) {
+	flags := []string{}
+	for _, k := range slices.Sorted(maps.Keys(opts.EnvMap)) {
+		flags = append(flags, "--env "+quoteShell(k+"="+opts.EnvMap[k]))
+	}
+	if opts.EnvFile != "" {
+		flags = append(flags, "--env-file "+quoteShell(opts.EnvFile))
+	}
+
+	configDir, err := getRelativePathToConfig(opts.EnvrcDir, opts.ConfigDir)
+	if err != nil {
+		return "", err
+	}
+	// Without --config, devbox finds devbox.json in the .envrc directory or
+	// one of its parents.
+	configPath := `"$(dirname "$(find_up devbox.json)")"`
+	configFlag := ""
+	if configDir != "" {
+		configPath = quoteShell(configDir)
+		configFlag = "--config " + configPath
+	}
+
+	envFile := ""
+	if opts.EnvFile != "" {
+		envFile = quoteShell(opts.EnvFile)
+	}
+
+	t := template.Must(template.ParseFS(tmplFS, "tmpl/envrcBlock.tmpl"))
+	sb := &strings.Builder{}
+	err = t.Execute(sb, map[string]string{
+		"Version":    fmt.Sprintf("v%d", EnvrcBlockVersion),
+		"EnvFlag":    strings.Join(flags, " "),
+		"EnvFile":    envFile,
+		"ConfigDir":  configFlag,
+		"ConfigPath": configPath,
+	})
+	return sb.String(), err
+}
+
+// UpdateEnvrc returns envrc with its devbox block replaced by block, and
+// whether that changed anything. If envrc doesn't have a block yet, it
+// appends block to envrc. A .envrc that was written by "devbox generate
+// direnv" is replaced entirely, because it loads the environment the same way
+// the block does.
+func UpdateEnvrc(envrc, block string) (string, bool) {
+	var updated string
+	switch {
+	case envrcBlockRegexp.MatchString(envrc):
+		replaced := false
+		updated = envrcBlockRegexp.ReplaceAllStringFunc(envrc, func(string) string {
+			if replaced {
+				return ""
+			}
+			replaced = true
+			return block
+		})
+	case strings.Contains(envrc, "devbox generate direnv --print-envrc"):
+		updated = "#!/usr/bin/env bash\n\n" + block
+	case envrc == "":
+		updated = "#!/usr/bin/env bash\n\n" + block
+	default:
+		updated = strings.TrimRight(envrc, "\n") + "\n\n" + block
+	}
+	return updated, updated != envrc
+}
+
+// EnvrcBlockVersionOf returns the version of the devbox block in envrc, or 0
+// if it doesn't have one.
+func EnvrcBlockVersionOf(envrc string) int {
+	match := envrcBlockRegexp.FindStringSubmatch(envrc)
+	if match == nil {
+		return 0
+	}
+	version, _ := strconv.Atoi(match[1])
+	return version
+}
+
+var shellSafeRegexp = regexp.MustCompile(`^[\w@%+=:,./-]+$`)
+
+func quoteShell(s string) string {
+	if shellSafeRegexp.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
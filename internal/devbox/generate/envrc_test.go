@@ -0,0 +1,102 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package generate
+
+import (
+	"strings"
+	"testing"
+
+	"go.jetify.com/devbox/internal/devbox/devopt"
+)
+
+func TestEnvrcBlock(t *testing.T) {
+	dir := t.TempDir()
+	block, err := EnvrcBlock(devopt.EnvrcOpts{
+		EnvFlags: devopt.EnvFlags{
+			EnvMap:  map[string]string{"B": "two words", "A": "1"},
+			EnvFile: ".env",
+		},
+		EnvrcDir:  dir,
+		ConfigDir: dir,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"# >>> devbox direnv v1 >>>\n",
+		"--env A=1 --env 'B=two words' --env-file .env)\n",
+		`local config_dir="$(dirname "$(find_up devbox.json)")" layout cache`,
+		"dotenv_if_exists .env\n",
+	}
+	for _, w := range want {
+		if !strings.Contains(block, w) {
+			t.Errorf("block doesn't contain %q, got:\n%s", w, block)
+		}
+	}
+	if !strings.HasSuffix(block, "# <<< devbox direnv <<<\n") {
+		t.Errorf("block doesn't end with the end marker, got:\n%s", block)
+	}
+	if got := EnvrcBlockVersionOf(block); got != EnvrcBlockVersion {
+		t.Errorf("EnvrcBlockVersionOf(block) = %d, want %d", got, EnvrcBlockVersion)
+	}
+}
+
+func TestUpdateEnvrc(t *testing.T) {
+	block := "# >>> devbox direnv v2 >>>\nuse devbox\n# <<< devbox direnv <<<\n"
+	oldBlock := "# >>> devbox direnv v1 >>>\nuse_devbox() { :; }\nuse devbox\n# <<< devbox direnv <<<\n"
+
+	tests := []struct {
+		name        string
+		envrc       string
+		want        string
+		wantChanged bool
+	}{
+		{
+			name:        "new",
+			envrc:       "",
+			want:        "#!/usr/bin/env bash\n\n" + block,
+			wantChanged: true,
+		},
+		{
+			name:        "append",
+			envrc:       "export FOO=1\n\n",
+			want:        "export FOO=1\n\n" + block,
+			wantChanged: true,
+		},
+		{
+			name:        "upgrade",
+			envrc:       "export FOO=1\n" + oldBlock + "export BAR=1\n",
+			want:        "export FOO=1\n" + block + "export BAR=1\n",
+			wantChanged: true,
+		},
+		{
+			name:        "up to date",
+			envrc:       "export FOO=1\n" + block,
+			want:        "export FOO=1\n" + block,
+			wantChanged: false,
+		},
+		{
+			name:        "duplicate blocks",
+			envrc:       oldBlock + "export FOO=1\n" + oldBlock,
+			want:        block + "export FOO=1\n",
+			wantChanged: true,
+		},
+		{
+			name: "generated by devbox generate direnv",
+			envrc: "#!/usr/bin/env bash\n\n" +
+				"eval \"$(devbox generate direnv --print-envrc)\"\n",
+			want:        "#!/usr/bin/env bash\n\n" + block,
+			wantChanged: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, changed := UpdateEnvrc(test.envrc, block)
+			if got != test.want || changed != test.wantChanged {
+				t.Errorf("UpdateEnvrc() = %q, %v, want %q, %v", got, changed, test.want, test.wantChanged)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+	"go.jetify.com/devbox/internal/devbox/devopt"
+	"go.jetify.com/devbox/internal/envir"
+)
+
+// GitHubExport is what ExportToGitHub exported.
+type GitHubExport struct {
+	// Vars are the names of the variables written to $GITHUB_ENV.
+	Vars []string
+
+	// Paths are the directories written to $GITHUB_PATH, in the order that
+	// they come in PATH.
+	Paths []string
+}
+
+// ExportToGitHub appends the project's environment to the $GITHUB_ENV and
+// $GITHUB_PATH files of a GitHub Actions step, so that the next steps of the
+// job run in it. Only the variables that differ from the current environment
+// are exported, and PATH is exported as the directories that it adds.
+func (d *Devbox) ExportToGitHub(ctx context.Context, envOpts devopt.EnvOptions) (*GitHubExport, error) {
+	envFile, pathFile := os.Getenv("GITHUB_ENV"), os.Getenv("GITHUB_PATH")
+	if envFile == "" || pathFile == "" {
+		return nil, usererr.New(
+			"GITHUB_ENV and GITHUB_PATH aren't set. --github only works in a step of a GitHub Actions workflow.")
+	}
+
+	env, err := d.ensureStateIsUpToDateAndComputeEnv(ctx, envOpts)
+	if err != nil {
+		return nil, err
+	}
+	current := envir.PairsToMap(os.Environ())
+	export := &GitHubExport{Paths: newPathEntries(env["PATH"], current["PATH"])}
+
+	vars := map[string]string{}
+	for name, value := range env {
+		if name == "PATH" || !isValidEnvName(name) {
+			continue
+		}
+		if currentValue, ok := current[name]; ok && currentValue == value {
+			continue
+		}
+		vars[name] = value
+	}
+	export.Vars = slices.Sorted(maps.Keys(vars))
+
+	delimiter := make([]byte, 16)
+	if _, err := rand.Read(delimiter); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	envData := &strings.Builder{}
+	writeGitHubEnv(envData, vars, "DEVBOX_EOF_"+hex.EncodeToString(delimiter))
+	if err := appendToFile(envFile, envData.String()); err != nil {
+		return nil, err
+	}
+	pathData := &strings.Builder{}
+	writeGitHubPath(pathData, export.Paths)
+	if err := appendToFile(pathFile, pathData.String()); err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+// writeGitHubEnv writes vars in the format of $GITHUB_ENV. Every value uses
+// the multiline syntax with delimiter, so that values with newlines and
+// equal signs don't need escaping.
+func writeGitHubEnv(w io.Writer, vars map[string]string, delimiter string) {
+	for _, name := range slices.Sorted(maps.Keys(vars)) {
+		fmt.Fprintf(w, "%s<<%s\n%s\n%s\n", name, delimiter, vars[name], delimiter)
+	}
+}
+
+// writeGitHubPath writes paths in the format of $GITHUB_PATH. The runner
+// prepends each line to PATH, so the paths are written in reverse to keep
+// their order.
+func writeGitHubPath(w io.Writer, paths []string) {
+	for _, path := range slices.Backward(paths) {
+		fmt.Fprintln(w, path)
+	}
+}
+
+// newPathEntries returns the entries of path that aren't in currentPath, in
+// order.
+func newPathEntries(path, currentPath string) []string {
+	current := filepath.SplitList(currentPath)
+	entries := []string{}
+	for _, entry := range filepath.SplitList(path) {
+		if entry != "" && !slices.Contains(current, entry) && !slices.Contains(entries, entry) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func appendToFile(path, data string) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if _, err := f.WriteString(data); err != nil {
+		f.Close()
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(f.Close())
+}
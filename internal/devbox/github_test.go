@@ -0,0 +1,39 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestWriteGitHubEnv(t *testing.T) {
+	sb := &strings.Builder{}
+	writeGitHubEnv(sb, map[string]string{
+		"GOPATH":  "/home/runner/go",
+		"MESSAGE": "a=b\nc",
+	}, "EOF")
+	want := "GOPATH<<EOF\n/home/runner/go\nEOF\nMESSAGE<<EOF\na=b\nc\nEOF\n"
+	if got := sb.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestWriteGitHubPath(t *testing.T) {
+	sb := &strings.Builder{}
+	writeGitHubPath(sb, []string{"/project/.devbox/nix/profile/default/bin", "/nix/store/abc-go/bin"})
+	want := "/nix/store/abc-go/bin\n/project/.devbox/nix/profile/default/bin\n"
+	if got := sb.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestNewPathEntries(t *testing.T) {
+	got := newPathEntries("/a/bin:/usr/bin:/b/bin:/a/bin:/bin", "/usr/bin:/bin")
+	want := []string{"/a/bin", "/b/bin"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -10,20 +10,20 @@
 
 	"github.com/pkg/errors"
 
-	"go.jetify.com/devbox/internal/xdg"
+	"go.jetify.com/devbox/internal/state"
 )
 
 // In the future we will support multiple global profiles
 const currentGlobalProfile = "default"
 
 func GlobalDataPath() (string, error) {
-	path := xdg.DataSubpath(filepath.Join("devbox/global", currentGlobalProfile))
+	path := state.Data("global", currentGlobalProfile)
 	if err := os.MkdirAll(path, 0o755); err != nil {
 		return "", errors.WithStack(err)
 	}
 
 	nixProfilePath := filepath.Join(path)
-	currentPath := xdg.DataSubpath("devbox/global/current")
+	currentPath := state.Data("global", "current")
 
 	// For now default is always current. In the future we will support multiple
 	// and allow user to switch. Remove any existing symlink and create a new one
@@ -0,0 +1,118 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/mod/semver"
+
+	"go.jetify.com/devbox/internal/devbox/devopt"
+	"go.jetify.com/devbox/internal/devpkg"
+	"go.jetify.com/devbox/internal/envir"
+	"go.jetify.com/devbox/internal/nix"
+)
+
+// goIsolateEnvVar is the devbox.json env variable that gives the project its
+// own GOPATH, GOMODCACHE and GOCACHE under .devbox when it's set to true.
+const goIsolateEnvVar = "DEVBOX_GO_ISOLATE"
+
+var goPackageRegexp = regexp.MustCompile(`^go(_[0-9]+(_[0-9]+)?|_latest)?$`)
+
+// goPackage returns the project's go package, or nil if it doesn't have one.
+// Setting disable_plugin on the package disables the go integration.
+func (d *Devbox) goPackage() *devpkg.Package {
+	for _, pkg := range d.InstallablePackages() {
+		if !pkg.DisablePlugin && goPackageRegexp.MatchString(pkg.CanonicalName()) {
+			return pkg
+		}
+	}
+	return nil
+}
+
+// goEnv returns the environment variables that configure the project's go
+// toolchain and a directory to add to the PATH, if any. Variables that are set
+// in devbox.json take precedence over these.
+//
+// GOTOOLCHAIN pins the go command to the locked go version so that it
+// doesn't switch to a different toolchain because of a go.mod toolchain
+// line. If DEVBOX_GO_ISOLATE is true, the project also gets its own GOPATH and
+// caches, which keeps its modules separate from other projects and makes them
+// easy to cache in CI.
+func (d *Devbox) goEnv() (env map[string]string, binDir string) {
+	pkg := d.goPackage()
+	if pkg == nil {
+		return nil, ""
+	}
+
+	env = map[string]string{}
+	version, err := pkg.ResolvedVersion()
+	if err == nil {
+		if toolchain := goToolchain(version); toolchain != "" {
+			env["GOTOOLCHAIN"] = toolchain
+		}
+	}
+
+	if isolate, _ := strconv.ParseBool(d.cfg.Env()[goIsolateEnvVar]); isolate {
+		gopath := filepath.Join(d.projectDir, ".devbox", "go")
+		env["GOPATH"] = gopath
+		env["GOMODCACHE"] = filepath.Join(gopath, "pkg", "mod")
+		env["GOCACHE"] = filepath.Join(gopath, "cache")
+		env["GOBIN"] = filepath.Join(gopath, "bin")
+		binDir = env["GOBIN"]
+	}
+	return env, binDir
+}
+
+// goToolchain returns the GOTOOLCHAIN name of a go package version, such as
+// go1.22.3 for 1.22.3. It returns an empty string for versions that predate
+// toolchain switching in Go 1.21.
+func goToolchain(version string) string {
+	v := "v" + strings.TrimPrefix(version, "go")
+	if !semver.IsValid(v) || semver.Prerelease(v) != "" || semver.Compare(v, "v1.21.0") < 0 {
+		return ""
+	}
+	// Go 1.21 and later name their first release x.y.0.
+	if strings.Count(v, ".") == 1 {
+		v += ".0"
+	}
+	return "go" + strings.TrimPrefix(v, "v")
+}
+
+// CacheDirs returns the directories that the project's toolchains cache
+// downloads and build results in. CI systems can save and restore them between
+// runs to speed up builds. It only detects go's caches for now.
+func (d *Devbox) CacheDirs(ctx context.Context) ([]string, error) {
+	if d.goPackage() == nil {
+		return nil, nil
+	}
+	env, err := d.ensureStateIsUpToDateAndComputeEnv(ctx, devopt.EnvOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	goBin := filepath.Join(nix.ProfileBinPath(d.projectDir), "go")
+	cmd := exec.CommandContext(ctx, goBin, "env", "GOMODCACHE", "GOCACHE")
+	cmd.Env = envir.MapToPairs(env)
+	cmd.Dir = d.projectDir
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Wrap(err, "go env")
+	}
+
+	dirs := []string{}
+	for _, line := range bytes.Split(out, []byte("\n")) {
+		if dir := strings.TrimSpace(string(line)); dir != "" && dir != "off" {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs, nil
+}
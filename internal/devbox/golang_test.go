@@ -0,0 +1,38 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import "testing"
+
+func TestGoToolchain(t *testing.T) {
+	tests := map[string]string{
+		"1.22.3":   "go1.22.3",
+		"1.21.0":   "go1.21.0",
+		"1.23":     "go1.23.0",
+		"go1.24.1": "go1.24.1",
+		"1.20.14":  "",
+		"1.19":     "",
+		"1.23rc1":  "",
+		"latest":   "",
+		"":         "",
+	}
+	for version, want := range tests {
+		if got := goToolchain(version); got != want {
+			t.Errorf("goToolchain(%q) = %q, want %q", version, got, want)
+		}
+	}
+}
+
+func TestGoPackageRegexp(t *testing.T) {
+	for _, name := range []string{"go", "go_1_22", "go_1", "go_latest"} {
+		if !goPackageRegexp.MatchString(name) {
+			t.Errorf("goPackageRegexp doesn't match %q", name)
+		}
+	}
+	for _, name := range []string{"gopls", "go-task", "golangci-lint", "mongo"} {
+		if goPackageRegexp.MatchString(name) {
+			t.Errorf("goPackageRegexp matches %q", name)
+		}
+	}
+}
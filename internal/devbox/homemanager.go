@@ -0,0 +1,156 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"go.jetify.com/devbox/internal/ux"
+	"go.jetify.com/devbox/nix/flake"
+)
+
+// homeManagerPackage is a package of a generated home-manager module.
+type homeManagerPackage struct {
+	name          string
+	ref           flake.Ref
+	attrPath      string
+	allowInsecure []string
+}
+
+// GenerateHomeManager writes a home-manager module to w that installs the
+// project's packages with home.packages and sets the env of devbox.json and
+// its plugins with home.sessionVariables. Packages that aren't nix packages,
+// such as runx packages, can't be installed by home-manager and are skipped.
+func (d *Devbox) GenerateHomeManager(ctx context.Context, w io.Writer) error {
+	pkgs := []homeManagerPackage{}
+	for _, pkg := range d.InstallablePackages() {
+		if !pkg.IsNix() {
+			ux.Fwarningf(d.stderr, "Skipping %s, which isn't a nix package\n", pkg.Raw)
+			continue
+		}
+		installable, err := pkg.FlakeInstallable()
+		if err != nil {
+			return err
+		}
+		pkgs = append(pkgs, homeManagerPackage{
+			name:          pkg.Raw,
+			ref:           installable.Ref,
+			attrPath:      installable.AttrPath,
+			allowInsecure: pkg.AllowInsecure,
+		})
+	}
+	return writeHomeManagerModule(w, d.cfg.Root.AbsRootPath, d.projectDir, pkgs, d.cfg.Env())
+}
+
+// writeHomeManagerModule writes the home-manager module for pkgs and env.
+// References to $PWD in env are replaced with projectDir, the same as in
+// devbox shell; other variable references are left for the login shell to
+// expand.
+func writeHomeManagerModule(
+	w io.Writer,
+	configPath, projectDir string,
+	pkgs []homeManagerPackage,
+	env map[string]string,
+) error {
+	// Each nixpkgs revision is imported once, so that unfree and insecure
+	// packages can be allowed the same way devbox allows them.
+	nixpkgsNames := map[string]string{}
+	nixpkgsRefs := []flake.Ref{}
+	insecure := map[string][]string{}
+	for _, pkg := range pkgs {
+		if !pkg.ref.IsNixpkgs() {
+			continue
+		}
+		ref := pkg.ref.String()
+		if _, ok := nixpkgsNames[ref]; !ok {
+			suffix := pkg.ref.Rev[:min(7, len(pkg.ref.Rev))]
+			nixpkgsNames[ref] = "nixpkgs-" + cmp.Or(suffix, strconv.Itoa(len(nixpkgsRefs)))
+			nixpkgsRefs = append(nixpkgsRefs, pkg.ref)
+		}
+		for _, name := range pkg.allowInsecure {
+			if !slices.Contains(insecure[ref], name) {
+				insecure[ref] = append(insecure[ref], name)
+			}
+		}
+	}
+
+	sb := &strings.Builder{}
+	fmt.Fprintf(sb, "# Generated by devbox from %s.\n", configPath)
+	sb.WriteString("# Import this module in your home-manager configuration, for example\n")
+	sb.WriteString("# with imports = [ ./devbox.nix ];\n")
+	sb.WriteString("{ pkgs, lib, ... }:\n\n")
+	sb.WriteString("let\n")
+	sb.WriteString("  system = pkgs.stdenv.hostPlatform.system;\n")
+	for _, ref := range nixpkgsRefs {
+		fmt.Fprintf(sb, "  %s = import (builtins.getFlake %s) {\n", nixpkgsNames[ref.String()], nixString(ref.String()))
+		sb.WriteString("    inherit system;\n")
+		sb.WriteString("    config.allowUnfree = true;\n")
+		sb.WriteString("    config.permittedInsecurePackages = [")
+		for _, name := range insecure[ref.String()] {
+			fmt.Fprintf(sb, " %s", nixString(name))
+		}
+		sb.WriteString(" ];\n")
+		sb.WriteString("  };\n")
+	}
+	sb.WriteString("  flakePackage = ref: attrPath:\n")
+	sb.WriteString("    let\n")
+	sb.WriteString("      flake = builtins.getFlake ref;\n")
+	sb.WriteString("      outputs = (flake.legacyPackages.${system} or { }) // (flake.packages.${system} or { });\n")
+	sb.WriteString("    in\n")
+	sb.WriteString("    lib.getAttrFromPath (lib.splitString \".\" attrPath) outputs;\n")
+	sb.WriteString("in\n")
+	sb.WriteString("{\n")
+
+	sb.WriteString("  home.packages = [\n")
+	for _, pkg := range pkgs {
+		attrPath := cmp.Or(pkg.attrPath, "default")
+		fmt.Fprintf(sb, "    # %s\n", pkg.name)
+		if name, ok := nixpkgsNames[pkg.ref.String()]; ok {
+			fmt.Fprintf(sb, "    (lib.getAttrFromPath (lib.splitString \".\" %s) %s)\n", nixString(attrPath), name)
+		} else {
+			fmt.Fprintf(sb, "    (flakePackage %s %s)\n", nixString(pkg.ref.String()), nixString(attrPath))
+		}
+	}
+	sb.WriteString("  ];\n")
+
+	if len(env) > 0 {
+		sb.WriteString("\n  home.sessionVariables = {\n")
+		for _, name := range slices.Sorted(maps.Keys(env)) {
+			value := os.Expand(env[name], func(v string) string {
+				if v == "PWD" {
+					return projectDir
+				}
+				return "${" + v + "}"
+			})
+			fmt.Fprintf(sb, "    %s = %s;\n", nixString(name), nixString(value))
+		}
+		sb.WriteString("  };\n")
+	}
+	sb.WriteString("}\n")
+
+	_, err := io.WriteString(w, sb.String())
+	return errors.WithStack(err)
+}
+
+// nixString quotes s as a nix string literal.
+func nixString(s string) string {
+	r := strings.NewReplacer(
+		`\`, `\\`,
+		`"`, `\"`,
+		"${", `\${`,
+		"\n", `\n`,
+		"\t", `\t`,
+	)
+	return `"` + r.Replace(s) + `"`
+}
@@ -0,0 +1,62 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"strings"
+	"testing"
+
+	"go.jetify.com/devbox/nix/flake"
+)
+
+func TestWriteHomeManagerModule(t *testing.T) {
+	nixpkgs := flake.Ref{Type: flake.TypeGitHub, Owner: "NixOS", Repo: "nixpkgs", Rev: "0123456789abcdef0123456789abcdef01234567"}
+	pkgs := []homeManagerPackage{
+		{name: "go@1.22", ref: nixpkgs, attrPath: "go_1_22"},
+		{name: "python312Packages.pip@latest", ref: nixpkgs, attrPath: "python312Packages.pip", allowInsecure: []string{"openssl-1.1.1w"}},
+		{name: "github:numtide/treefmt", ref: flake.Ref{Type: flake.TypeGitHub, Owner: "numtide", Repo: "treefmt"}},
+	}
+	env := map[string]string{
+		"GOPATH":   "$PWD/.go",
+		"GREETING": `say "hi"`,
+		"PATH":     "$PATH:${HOME}/bin",
+	}
+
+	sb := &strings.Builder{}
+	if err := writeHomeManagerModule(sb, "/project/devbox.json", "/project", pkgs, env); err != nil {
+		t.Fatal(err)
+	}
+	got := sb.String()
+
+	want := []string{
+		"# Generated by devbox from /project/devbox.json.\n",
+		"{ pkgs, lib, ... }:\n",
+		`  nixpkgs-0123456 = import (builtins.getFlake "github:NixOS/nixpkgs/0123456789abcdef0123456789abcdef01234567") {` + "\n",
+		`    config.permittedInsecurePackages = [ "openssl-1.1.1w" ];` + "\n",
+		`    (lib.getAttrFromPath (lib.splitString "." "go_1_22") nixpkgs-0123456)` + "\n",
+		`    (lib.getAttrFromPath (lib.splitString "." "python312Packages.pip") nixpkgs-0123456)` + "\n",
+		`    (flakePackage "github:numtide/treefmt" "default")` + "\n",
+		`    "GOPATH" = "/project/.go";` + "\n",
+		`    "GREETING" = "say \"hi\"";` + "\n",
+		`    "PATH" = "\${PATH}:\${HOME}/bin";` + "\n",
+	}
+	for _, w := range want {
+		if !strings.Contains(got, w) {
+			t.Errorf("module doesn't contain %q, got:\n%s", w, got)
+		}
+	}
+	if n := strings.Count(got, "builtins.getFlake \"github:NixOS/nixpkgs"); n != 1 {
+		t.Errorf("module imports nixpkgs %d times, want 1", n)
+	}
+}
+
+func TestWriteHomeManagerModuleNoEnv(t *testing.T) {
+	sb := &strings.Builder{}
+	if err := writeHomeManagerModule(sb, "/project/devbox.json", "/project", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(sb.String(), "home.sessionVariables") {
+		t.Errorf("module sets home.sessionVariables without env:\n%s", sb.String())
+	}
+}
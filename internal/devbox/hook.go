@@ -0,0 +1,203 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"go.jetify.com/devbox/internal/devbox/devopt"
+	"go.jetify.com/devbox/internal/devconfig"
+	"go.jetify.com/devbox/internal/envir"
+	"go.jetify.com/devbox/internal/shellgen"
+	"go.jetify.com/devbox/internal/trust"
+	"go.jetify.com/devbox/internal/ux"
+)
+
+const (
+	// hookProjectEnv is the directory of the project that the shell hook
+	// activated.
+	hookProjectEnv = "DEVBOX_HOOK_PROJECT"
+	// hookRestoreEnv holds the values that the variables of the shell had
+	// before the shell hook activated a project, encoded by
+	// encodeHookRestore.
+	hookRestoreEnv = "DEVBOX_HOOK_RESTORE"
+)
+
+// HookEnv returns the script that the devbox shell hook evaluates after the
+// shell's working directory changes to opts.Dir. It deactivates the project
+// that the hook activated before, if the shell left it, and activates the
+// project in opts.Dir if the user allowed it. The script is empty if the
+// shell stays in the same project.
+//
+// Like devbox shellenv, the script sets the variables of the project's
+// environment, but it doesn't run the project's init hooks.
+func HookEnv(ctx context.Context, w io.Writer, opts devopt.HookEnvOpts) (string, error) {
+	projectDir := ""
+	cfg, err := devconfig.Find(opts.Dir)
+	if err == nil {
+		projectDir = filepath.Dir(cfg.Root.AbsRootPath)
+	} else if !errors.Is(err, devconfig.ErrNotFound) && !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+
+	active := os.Getenv(hookProjectEnv)
+	if projectDir == active {
+		return "", nil
+	}
+	// A devbox shell that the user started already has an environment.
+	if active == "" && envir.IsDevboxShellEnabled() {
+		return "", nil
+	}
+
+	script := &hookScript{shell: opts.Shell}
+	if active != "" {
+		restore, err := decodeHookRestore(os.Getenv(hookRestoreEnv))
+		if err != nil {
+			return "", err
+		}
+		// Restore the variables in this process too, so that the
+		// environment of the next project is computed without the
+		// previous one.
+		for _, name := range slices.Sorted(maps.Keys(restore)) {
+			if value := restore[name]; value == nil {
+				script.unset(name)
+				os.Unsetenv(name)
+			} else {
+				script.set(name, *value)
+				os.Setenv(name, *value)
+			}
+		}
+		for _, name := range []string{hookProjectEnv, hookRestoreEnv} {
+			script.unset(name)
+			os.Unsetenv(name)
+		}
+		ux.Finfof(w, "Deactivated the devbox environment of %s\n", active)
+	}
+	if projectDir == "" {
+		return script.String(), nil
+	}
+
+	decision, err := trust.Lookup(projectDir)
+	if err != nil {
+		return "", err
+	}
+	if decision == "" && opts.Ask != nil {
+		if decision, err = opts.Ask(projectDir); err != nil {
+			return "", err
+		}
+		if decision != "" {
+			if err := trust.Set(projectDir, decision); err != nil {
+				return "", err
+			}
+		}
+	}
+	switch decision {
+	case trust.Deny:
+		return script.String(), nil
+	case "":
+		ux.Finfof(w, "%s is a devbox project. Run `devbox hook allow` to activate "+
+			"its environment when you cd into it, or `devbox hook deny` to stop asking.\n", projectDir)
+		return script.String(), nil
+	}
+
+	box, err := Open(&devopt.Opts{Dir: projectDir, Stderr: w})
+	if err != nil {
+		return "", err
+	}
+	envs, err := box.ensureStateIsUpToDateAndComputeEnv(ctx, devopt.EnvOptions{})
+	if err != nil {
+		return "", err
+	}
+
+	restore := map[string]*string{}
+	for _, name := range slices.Sorted(maps.Keys(envs)) {
+		old, ok := os.LookupEnv(name)
+		if (ok && old == envs[name]) || !isValidEnvName(name) {
+			continue
+		}
+		if ok {
+			restore[name] = &old
+		} else {
+			restore[name] = nil
+		}
+		script.set(name, envs[name])
+	}
+	encoded, err := encodeHookRestore(restore)
+	if err != nil {
+		return "", err
+	}
+	script.set(hookProjectEnv, projectDir)
+	script.set(hookRestoreEnv, encoded)
+	ux.Finfof(w, "Activated the devbox environment of %s\n", projectDir)
+	return script.String(), nil
+}
+
+// hookScript builds the script of the shell hook for bash, zsh or fish.
+type hookScript struct {
+	shell string
+	lines []string
+}
+
+func (s *hookScript) set(name, value string) {
+	if s.shell == "fish" {
+		s.lines = append(s.lines, "set -gx "+name+" "+shellgen.QuoteFish(value)+";")
+	} else {
+		s.lines = append(s.lines, "export "+name+"="+shellgen.QuotePOSIX(value)+";")
+	}
+}
+
+func (s *hookScript) unset(name string) {
+	if s.shell == "fish" {
+		s.lines = append(s.lines, "set -e "+name+";")
+	} else {
+		s.lines = append(s.lines, "unset "+name+";")
+	}
+}
+
+func (s *hookScript) String() string {
+	if len(s.lines) == 0 {
+		return ""
+	}
+	lines := s.lines
+	if s.shell != "fish" {
+		// Forget the locations of commands that PATH changed.
+		lines = append(lines, "hash -r;")
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// encodeHookRestore encodes the values that variables had before the shell
+// hook activated a project. A nil value means that the variable wasn't set.
+func encodeHookRestore(restore map[string]*string) (string, error) {
+	data, err := json.Marshal(restore)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+func decodeHookRestore(encoded string) (map[string]*string, error) {
+	restore := map[string]*string{}
+	if encoded == "" {
+		return restore, nil
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errors.Wrapf(err, "decoding %s", hookRestoreEnv)
+	}
+	if err := json.Unmarshal(data, &restore); err != nil {
+		return nil, errors.Wrapf(err, "decoding %s", hookRestoreEnv)
+	}
+	return restore, nil
+}
@@ -0,0 +1,41 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import "testing"
+
+func TestHookScript(t *testing.T) {
+	for shell, want := range map[string]string{
+		"bash": "export GREETING='it'\\''s \\ \"$HOME\"';\nunset OLD;\nhash -r;\n",
+		"fish": "set -gx GREETING 'it\\'s \\\\ \"$HOME\"';\nset -e OLD;\n",
+	} {
+		script := &hookScript{shell: shell}
+		script.set("GREETING", `it's \ "$HOME"`)
+		script.unset("OLD")
+		if got := script.String(); got != want {
+			t.Errorf("got %s script %q, want %q", shell, got, want)
+		}
+	}
+	if got := (&hookScript{shell: "zsh"}).String(); got != "" {
+		t.Errorf("got script %q without changes, want an empty one", got)
+	}
+}
+
+func TestHookRestore(t *testing.T) {
+	old := "/usr/bin:/bin"
+	encoded, err := encodeHookRestore(map[string]*string{"PATH": &old, "GOPATH": nil})
+	if err != nil {
+		t.Fatal(err)
+	}
+	restore, err := decodeHookRestore(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(restore) != 2 || restore["PATH"] == nil || *restore["PATH"] != old || restore["GOPATH"] != nil {
+		t.Errorf("got restore %v, want PATH restored to %q and GOPATH unset", restore, old)
+	}
+	if _, err := decodeHookRestore("not base64!"); err == nil {
+		t.Error("got no error decoding an invalid value")
+	}
+}
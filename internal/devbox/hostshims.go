@@ -0,0 +1,142 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+	"go.jetify.com/devbox/internal/devconfig/configfile"
+	"go.jetify.com/devbox/internal/shellgen"
+)
+
+// systemPathDirs are host directories whose commands strict mode allows,
+// because they come with the operating system or with nix.
+var systemPathDirs = []string{
+	"/bin",
+	"/sbin",
+	"/usr/bin",
+	"/usr/sbin",
+	"/usr/libexec",
+	"/System/Cryptexes/App/usr/bin",
+	"/nix/var/nix/profiles/default/bin",
+	"/run/current-system/sw/bin",
+	"/run/wrappers/bin",
+}
+
+// alwaysAllowedCommands are host commands that strict mode never shims.
+var alwaysAllowedCommands = []string{"devbox"}
+
+func (d *Devbox) hostShimsDir() string {
+	return filepath.Join(d.projectDir, ".devbox", "gen", "host-shims")
+}
+
+// writeHostShims writes a shim for each command that would resolve to a host
+// binary that devbox doesn't provide, when path.strict is set in devbox.json.
+// Each shim warns and then runs the host binary, or refuses to run it,
+// depending on the strict mode. It returns the directory of the shims, which
+// must come before hostPath in PATH, or "" if strict mode is off.
+func (d *Devbox) writeHostShims(devboxPath, hostPath string) (string, error) {
+	dir := d.hostShimsDir()
+	mode := d.cfg.Root.PathStrict()
+	if mode == "" {
+		return "", errors.WithStack(os.RemoveAll(dir))
+	}
+
+	shims := hostShims(devboxPath, hostPath, mode, d.cfg.Root.PathStrictAllow())
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", errors.WithStack(err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	for _, entry := range entries {
+		if _, ok := shims[entry.Name()]; !ok {
+			if err := os.Remove(filepath.Join(dir, entry.Name())); err != nil {
+				return "", errors.WithStack(err)
+			}
+		}
+	}
+	for name, content := range shims {
+		path := filepath.Join(dir, name)
+		if existing, err := os.ReadFile(path); err == nil && bytes.Equal(existing, content) {
+			continue
+		}
+		if err := os.WriteFile(path, content, 0o755); err != nil {
+			return "", errors.WithStack(err)
+		}
+	}
+	return dir, nil
+}
+
+// hostShims returns the contents of the shims, keyed by command name, for the
+// commands that resolve to a host binary outside of the system directories
+// and allow list. Commands that devboxPath provides are never shimmed.
+func hostShims(devboxPath, hostPath, mode string, allow []string) map[string][]byte {
+	seen := map[string]bool{}
+	for _, dir := range filepath.SplitList(devboxPath) {
+		for _, name := range executables(dir) {
+			seen[name] = true
+		}
+	}
+
+	shims := map[string][]byte{}
+	for _, dir := range filepath.SplitList(hostPath) {
+		dir = filepath.Clean(dir)
+		allowedDir := slices.Contains(systemPathDirs, dir) || slices.Contains(allow, dir)
+		for _, name := range executables(dir) {
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			if allowedDir || slices.Contains(allow, name) || slices.Contains(alwaysAllowedCommands, name) {
+				continue
+			}
+			shims[name] = hostShim(name, filepath.Join(dir, name), mode)
+		}
+	}
+	return shims
+}
+
+func hostShim(name, target, mode string) []byte {
+	sb := &strings.Builder{}
+	sb.WriteString("#!/bin/sh\n")
+	if mode == configfile.StrictBlock {
+		fmt.Fprintf(sb, "echo %s >&2\n", shellgen.QuotePOSIX(fmt.Sprintf(
+			"devbox: %s resolves to %s on the host, which isn't provided by devbox. "+
+				"Add a package that provides it, or add it to path.strict_allow in devbox.json.",
+			name, target)))
+		sb.WriteString("exit 127\n")
+		return []byte(sb.String())
+	}
+	fmt.Fprintf(sb, "echo %s >&2\n", shellgen.QuotePOSIX(fmt.Sprintf(
+		"devbox: warning: %s resolves to %s on the host, which isn't provided by devbox.",
+		name, target)))
+	fmt.Fprintf(sb, "exec %s \"$@\"\n", shellgen.QuotePOSIX(target))
+	return []byte(sb.String())
+}
+
+// executables returns the names of the executable files in dir, following
+// symlinks.
+func executables(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	names := []string{}
+	for _, entry := range entries {
+		info, err := os.Stat(filepath.Join(dir, entry.Name()))
+		if err != nil || !info.Mode().IsRegular() || info.Mode()&0o111 == 0 {
+			continue
+		}
+		names = append(names, entry.Name())
+	}
+	return names
+}
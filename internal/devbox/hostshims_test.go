@@ -0,0 +1,92 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"go.jetify.com/devbox/internal/devconfig/configfile"
+)
+
+func writeExecutables(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\necho "+name+" \"$@\"\n"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestHostShims(t *testing.T) {
+	tmp := t.TempDir()
+	devboxBin := filepath.Join(tmp, "profile", "bin")
+	brewBin := filepath.Join(tmp, "homebrew", "bin")
+	toolsBin := filepath.Join(tmp, "tools", "bin")
+	writeExecutables(t, devboxBin, "go", "node")
+	writeExecutables(t, brewBin, "go", "python3", "docker", "devbox", "jq")
+	writeExecutables(t, toolsBin, "jq", "terraform")
+	if err := os.WriteFile(filepath.Join(brewBin, "README"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	hostPath := strings.Join([]string{brewBin, toolsBin}, string(filepath.ListSeparator))
+	shims := hostShims(devboxBin, hostPath, configfile.StrictWarn, []string{"docker", toolsBin})
+
+	got := []string{}
+	for name := range shims {
+		got = append(got, name)
+	}
+	slices.Sort(got)
+	if want := []string{"jq", "python3"}; !slices.Equal(got, want) {
+		t.Errorf("hostShims() shims %v, want %v", got, want)
+	}
+	if want := filepath.Join(brewBin, "jq"); !strings.Contains(string(shims["jq"]), want) {
+		t.Errorf("shim for jq doesn't run %s:\n%s", want, shims["jq"])
+	}
+}
+
+func TestHostShim(t *testing.T) {
+	tmp := t.TempDir()
+	writeExecutables(t, tmp, "hello")
+	target := filepath.Join(tmp, "hello")
+
+	shim := filepath.Join(tmp, "warn-shim")
+	if err := os.WriteFile(shim, hostShim("hello", target, configfile.StrictWarn), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(shim, "world")
+	stderr := &strings.Builder{}
+	cmd.Stderr = stderr
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(out), "hello world\n"; got != want {
+		t.Errorf("warn shim printed %q, want %q", got, want)
+	}
+	if !strings.Contains(stderr.String(), "warning: hello resolves to "+target) {
+		t.Errorf("warn shim didn't print a warning, got stderr %q", stderr)
+	}
+
+	shim = filepath.Join(tmp, "block-shim")
+	if err := os.WriteFile(shim, hostShim("hello", target, configfile.StrictBlock), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	out, err = exec.Command(shim, "world").Output()
+	if exitErr := (*exec.ExitError)(nil); !errors.As(err, &exitErr) || exitErr.ExitCode() != 127 {
+		t.Errorf("block shim returned error %v, want exit status 127", err)
+	}
+	if len(out) != 0 {
+		t.Errorf("block shim ran the host binary, got output %q", out)
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"go.jetify.com/devbox/internal/devbox/devopt"
+	"go.jetify.com/devbox/internal/devpkg"
+	"go.jetify.com/devbox/internal/lock"
+	"go.jetify.com/devbox/internal/nix"
+)
+
+// InsecureException is an allow_insecure entry of a package in devbox.json and
+// the acceptance that devbox.lock recorded for it.
+type InsecureException struct {
+	Package string `json:"package"`
+	Name    string `json:"name"`
+
+	// Acceptance is nil if the entry was added before acceptances were
+	// recorded, or by editing devbox.json.
+	Acceptance *lock.InsecureAcceptance `json:"acceptance"`
+
+	// Advisories are the package's known vulnerabilities now.
+	Advisories []string `json:"advisories"`
+
+	// NewAdvisories are the advisories that weren't accepted.
+	NewAdvisories []string `json:"new_advisories"`
+
+	Expired bool `json:"expired"`
+}
+
+// Stale reports whether the exception should be reviewed.
+func (e InsecureException) Stale() bool {
+	return e.Acceptance == nil || e.Expired || len(e.NewAdvisories) > 0
+}
+
+// InsecureExceptions returns the allow_insecure entries of the project's
+// packages. It evaluates the current advisories of each package, which can
+// take a while the first time.
+func (d *Devbox) InsecureExceptions(ctx context.Context) []InsecureException {
+	exceptions := []InsecureException{}
+	now := time.Now()
+	for _, cfgPkg := range d.cfg.Root.TopLevelPackages() {
+		if len(cfgPkg.AllowInsecure) == 0 {
+			continue
+		}
+		name := cfgPkg.VersionedName()
+		advisories := d.packageAdvisories(ctx, devpkg.PackageFromStringWithDefaults(name, d.lockfile))
+		for _, entry := range cfgPkg.AllowInsecure {
+			exception := InsecureException{Package: name, Name: entry, Advisories: advisories}
+			if acceptance, ok := d.lockfile.InsecureAcceptance(name, entry); ok {
+				exception.Acceptance = &acceptance
+				exception.NewAdvisories = acceptance.NewAdvisories(advisories)
+				exception.Expired = acceptance.Expired(now)
+			}
+			exceptions = append(exceptions, exception)
+		}
+	}
+	return exceptions
+}
+
+// recordInsecureAcceptances records in devbox.lock that the allow_insecure
+// entries in opts were accepted for pkgs, along with the packages' current
+// advisories.
+func (d *Devbox) recordInsecureAcceptances(ctx context.Context, pkgs []string, opts devopt.AddOpts) error {
+	if len(opts.AllowInsecure) == 0 {
+		return nil
+	}
+	acceptedAt := time.Now().Format(lock.InsecureDateFormat)
+	for _, name := range pkgs {
+		advisories := d.packageAdvisories(ctx, devpkg.PackageFromStringWithDefaults(name, d.lockfile))
+		for _, entry := range opts.AllowInsecure {
+			d.lockfile.AcceptInsecure(name, lock.InsecureAcceptance{
+				Name:       entry,
+				Advisories: advisories,
+				AcceptedAt: acceptedAt,
+				Expires:    opts.InsecureExpires,
+			})
+		}
+	}
+	return d.lockfile.Save()
+}
+
+// expiredInsecureAcceptances returns the allow_insecure entries whose
+// acceptance expired, keyed by package. It doesn't evaluate anything, so it's
+// cheap enough to check on every command.
+func (d *Devbox) expiredInsecureAcceptances() map[string][]string {
+	expired := map[string][]string{}
+	now := time.Now()
+	for _, cfgPkg := range d.cfg.Root.TopLevelPackages() {
+		name := cfgPkg.VersionedName()
+		for _, entry := range cfgPkg.AllowInsecure {
+			if acceptance, ok := d.lockfile.InsecureAcceptance(name, entry); ok && acceptance.Expired(now) {
+				expired[name] = append(expired[name], entry)
+			}
+		}
+	}
+	return expired
+}
+
+func (d *Devbox) packageAdvisories(ctx context.Context, pkg *devpkg.Package) []string {
+	ref, err := evalInstallable(pkg)
+	if err != nil {
+		slog.Debug("failed to get the installable of an insecure package", "pkg", pkg.Raw, "err", err)
+		return nil
+	}
+	return nix.PackageKnownVulnerabilities(ctx, ref)
+}
+
+// evalInstallable returns the installable to evaluate the meta attributes of
+// pkg with.
+func evalInstallable(pkg *devpkg.Package) (string, error) {
+	ref, err := pkg.NormalizedDevboxPackageReference()
+	if err == nil && ref != "" {
+		return ref, nil
+	}
+	installable, err := pkg.FlakeInstallable()
+	if err != nil {
+		return "", err
+	}
+	return installable.String(), nil
+}
@@ -0,0 +1,99 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+
+	"go.jetify.com/devbox/internal/devpkg"
+	"go.jetify.com/devbox/internal/nix"
+	"go.jetify.com/devbox/internal/nix/nixprofile"
+)
+
+// CheckInstall returns the reasons that `devbox install` would have to change
+// something, or nothing if the project is fully installed. Unlike Install, it
+// doesn't install packages or write any files, so it's a fast check for CI.
+func (d *Devbox) CheckInstall(ctx context.Context) ([]string, error) {
+	if !nix.BinaryInstalled() {
+		return []string{"Nix isn't installed"}, nil
+	}
+
+	problems := []string{}
+	packages := lo.Filter(d.InstallablePackages(), devpkg.IsNix)
+	storePathsForPackage := map[*devpkg.Package][]string{}
+	for _, pkg := range packages {
+		if d.lockfile.Get(pkg.LockfileKey()) == nil {
+			problems = append(problems, pkg.Raw+" isn't in devbox.lock")
+			continue
+		}
+		// Custom builds are built with the environment's flake, so their
+		// store paths aren't known until they're built.
+		if pkg.IsCustomBuild() {
+			continue
+		}
+		storePaths, err := pkg.GetStorePaths(ctx, io.Discard)
+		if err != nil {
+			return nil, err
+		}
+		storePathsForPackage[pkg] = storePaths
+	}
+
+	inStore, err := nix.StorePathsAreInStore(ctx, lo.Flatten(lo.Values(storePathsForPackage)))
+	if err != nil {
+		return nil, err
+	}
+	inProfile, err := d.profileStorePaths(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if inProfile == nil {
+		problems = append(problems, "the project's nix profile doesn't exist")
+	}
+	for _, pkg := range packages {
+		for _, storePath := range storePathsForPackage[pkg] {
+			if !inStore[storePath] {
+				problems = append(problems, pkg.Raw+" output "+storePath+" isn't in the nix store")
+			} else if inProfile != nil && !inProfile[storePath] {
+				problems = append(problems, pkg.Raw+" output "+storePath+" isn't linked into the project's nix profile")
+			}
+		}
+	}
+
+	upToDate, err := d.lockfile.IsUpToDateAndInstalled(isFishShell())
+	if err != nil {
+		return nil, err
+	}
+	if !upToDate {
+		problems = append(problems, "devbox.json, devbox.lock or the nix profile changed since the last install")
+	}
+	return problems, nil
+}
+
+// profileStorePaths returns the store paths in the project's nix profile, or
+// nil if the profile doesn't exist. Unlike profilePath, it doesn't create the
+// profile's directory.
+func (d *Devbox) profileStorePaths(ctx context.Context) (map[string]bool, error) {
+	profilePath := nix.ProjectProfilePath(d.projectDir)
+	if _, err := os.Stat(profilePath); errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	items, err := nixprofile.ProfileListItems(ctx, io.Discard, profilePath)
+	if err != nil {
+		return nil, err
+	}
+	storePaths := map[string]bool{}
+	for _, item := range items {
+		for _, storePath := range item.StorePaths() {
+			storePaths[storePath] = true
+		}
+	}
+	return storePaths, nil
+}
@@ -0,0 +1,161 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"context"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+	"go.jetify.com/devbox/internal/devpkg"
+	"go.jetify.com/devbox/internal/fileutil"
+)
+
+// jdkRegexp matches the names of the nixpkgs packages that provide a JDK or
+// JRE, such as jdk21, openjdk17_headless, zulu or temurin-bin-17.
+var jdkRegexp = regexp.MustCompile(
+	`^(jdk|openjdk|jre|zulu|corretto|graalvm-ce|temurin-bin|temurin-jre-bin|semeru-bin)(-?[0-9]+)?(_headless)?$`)
+
+// JDK is a JDK package that's installed in the project.
+type JDK struct {
+	// Package is the package's name in devbox.json.
+	Package string
+
+	// Version is the JDK's major version, such as 21.
+	Version string
+
+	// Home is the JDK's home directory, which JAVA_HOME is set to.
+	Home string
+}
+
+// JDKs returns the JDK packages of the project that are installed.
+func (d *Devbox) JDKs(ctx context.Context) ([]JDK, error) {
+	return d.jdks(func(pkg *devpkg.Package) ([]string, error) {
+		return pkg.GetStorePaths(ctx, d.stderr)
+	})
+}
+
+// jdks returns the JDK packages of the project, finding their homes in the
+// store paths that storePaths returns.
+func (d *Devbox) jdks(storePaths func(*devpkg.Package) ([]string, error)) ([]JDK, error) {
+	jdks := []JDK{}
+	for _, pkg := range d.InstallablePackages() {
+		if !jdkRegexp.MatchString(pkg.CanonicalName()) {
+			continue
+		}
+		version, err := pkg.ResolvedVersion()
+		if err != nil {
+			return nil, err
+		}
+		paths, err := storePaths(pkg)
+		if err != nil {
+			return nil, err
+		}
+		for _, storePath := range paths {
+			if home := javaHome(storePath); home != "" {
+				jdks = append(jdks, JDK{
+					Package: pkg.Versioned(),
+					Version: javaMajorVersion(version),
+					Home:    home,
+				})
+				break
+			}
+		}
+	}
+	return jdks, nil
+}
+
+// UseJDK makes the installed JDK with the given major version the one that
+// sets JAVA_HOME, or the one that the script runs with if script isn't empty.
+func (d *Devbox) UseJDK(ctx context.Context, version, script string) (*JDK, error) {
+	if script != "" {
+		if _, ok := d.cfg.Scripts()[script]; !ok {
+			return nil, usererr.New("Script %q isn't defined in devbox.json.", script)
+		}
+	}
+	jdks, err := d.JDKs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	jdk, err := findJDK(jdks, version)
+	if err != nil {
+		return nil, err
+	}
+	if script == "" {
+		d.cfg.Root.SetJavaDefault(jdk.Version)
+	} else {
+		d.cfg.Root.SetJavaScript(script, jdk.Version)
+	}
+	return jdk, d.saveCfg()
+}
+
+// findJDK returns the JDK in jdks with the given major version.
+func findJDK(jdks []JDK, version string) (*JDK, error) {
+	if len(jdks) == 0 {
+		return nil, usererr.New(
+			"This project doesn't have a JDK. Add one with `devbox add jdk%s`, then run `devbox install`.", version)
+	}
+	major := javaMajorVersion(version)
+	for _, jdk := range jdks {
+		if jdk.Version == major {
+			return &jdk, nil
+		}
+	}
+	return nil, usererr.New(
+		"This project doesn't have JDK %s. Add it with `devbox add jdk%s`, or run `devbox java list` "+
+			"to see the installed JDKs.", version, major)
+}
+
+// activateJDK sets JAVA_HOME in env to the JDK with the given major version
+// and returns its bin directory to add to the PATH. It returns an empty string
+// if version is empty. It runs every time the environment is computed, so it
+// finds the JDKs in the store paths that the lockfile records instead of
+// evaluating the packages with nix.
+func (d *Devbox) activateJDK(env map[string]string, version string) (string, error) {
+	if version == "" {
+		return "", nil
+	}
+	jdks, err := d.jdks((*devpkg.Package).GetResolvedStorePaths)
+	if err != nil {
+		return "", err
+	}
+	jdk, err := findJDK(jdks, version)
+	if err != nil {
+		return "", err
+	}
+	env["JAVA_HOME"] = jdk.Home
+	return filepath.Join(jdk.Home, "bin"), nil
+}
+
+// javaHome returns the JDK home directory in a JDK package's store path, or an
+// empty string if it doesn't have one. Most Linux JDKs keep it in lib/openjdk,
+// and macOS JDKs in a .jdk bundle.
+func javaHome(storePath string) string {
+	candidates := []string{filepath.Join(storePath, "lib", "openjdk"), storePath}
+	bundles, _ := filepath.Glob(filepath.Join(storePath, "*", "Contents", "Home"))
+	candidates = append(candidates, bundles...)
+	bundles, _ = filepath.Glob(
+		filepath.Join(storePath, "Library", "Java", "JavaVirtualMachines", "*", "Contents", "Home"))
+	candidates = append(candidates, bundles...)
+
+	for _, dir := range candidates {
+		if fileutil.IsFile(filepath.Join(dir, "bin", "java")) {
+			return dir
+		}
+	}
+	return ""
+}
+
+// javaMajorVersion returns the major version of a Java version, such as 21
+// for 21.0.2+13, or 8 for both 8u402 and 1.8.0_402.
+func javaMajorVersion(version string) string {
+	version = strings.TrimPrefix(version, "1.")
+	end := strings.IndexFunc(version, func(r rune) bool { return r < '0' || r > '9' })
+	if end == -1 {
+		return version
+	}
+	return version[:end]
+}
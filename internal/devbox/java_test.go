@@ -0,0 +1,67 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestJavaMajorVersion(t *testing.T) {
+	tests := map[string]string{
+		"21.0.2+13": "21",
+		"17.0.10":   "17",
+		"11":        "11",
+		"8u402-ga":  "8",
+		"1.8.0_402": "8",
+		"":          "",
+	}
+	for version, want := range tests {
+		if got := javaMajorVersion(version); got != want {
+			t.Errorf("javaMajorVersion(%q) = %q, want %q", version, got, want)
+		}
+	}
+}
+
+func TestJDKRegexp(t *testing.T) {
+	for _, name := range []string{"jdk", "jdk21", "openjdk17_headless", "zulu", "temurin-bin-17", "corretto21"} {
+		if !jdkRegexp.MatchString(name) {
+			t.Errorf("jdkRegexp doesn't match %q", name)
+		}
+	}
+	for _, name := range []string{"maven", "gradle", "jdtls", "jdk-ls"} {
+		if jdkRegexp.MatchString(name) {
+			t.Errorf("jdkRegexp matches %q", name)
+		}
+	}
+}
+
+func TestJavaHome(t *testing.T) {
+	tests := map[string]string{
+		"linux":  "lib/openjdk",
+		"plain":  "",
+		"darwin": "zulu-21.jdk/Contents/Home",
+	}
+	for name, home := range tests {
+		t.Run(name, func(t *testing.T) {
+			storePath := t.TempDir()
+			bin := filepath.Join(storePath, home, "bin")
+			if err := os.MkdirAll(bin, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(bin, "java"), nil, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			want := filepath.Join(storePath, home)
+			if got := javaHome(storePath); got != want {
+				t.Errorf("javaHome() = %q, want %q", got, want)
+			}
+		})
+	}
+
+	if got := javaHome(t.TempDir()); got != "" {
+		t.Errorf("javaHome() of a package without java = %q, want empty", got)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"context"
+	"maps"
+	"os"
+	"strings"
+
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+	"go.jetify.com/devbox/internal/devbox/devopt"
+	"go.jetify.com/devbox/internal/envir"
+	"go.jetify.com/devbox/internal/nix"
+	"go.jetify.com/devbox/internal/ux"
+)
+
+// runLifecycleHook runs the named lifecycle hook from the "hooks" section of
+// devbox.json, if it has one. When devboxEnv is true, the hook runs in the
+// devbox environment (without the init hooks), so it can use the project's
+// packages. Otherwise, it runs in the environment devbox was started with.
+//
+// Like init hooks, lifecycle hooks only run in projects that the user trusts.
+//
+// The hook can read the hook's name from DEVBOX_HOOK, and the packages the
+// command changed from DEVBOX_HOOK_PACKAGES.
+func (d *Devbox) runLifecycleHook(
+	ctx context.Context,
+	name string,
+	devboxEnv bool,
+	pkgs []string,
+) error {
+	commands := d.cfg.Root.LifecycleHook(name)
+	if commands == nil {
+		return nil
+	}
+	if err := d.ensureTrusted("run the " + name + " hook"); err != nil {
+		return err
+	}
+
+	env := envir.PairsToMap(os.Environ())
+	if devboxEnv {
+		var err error
+		env, err = d.ensureStateIsUpToDateAndComputeEnv(ctx, devopt.EnvOptions{})
+		if err != nil {
+			return err
+		}
+		env = maps.Clone(env)
+	}
+	env["DEVBOX_HOOK"] = name
+	env["DEVBOX_HOOK_PACKAGES"] = strings.Join(pkgs, " ")
+
+	ux.Finfof(d.stderr, "Running %s hook\n", name)
+	if err := nix.RunScript(d.projectDir, commands.String(), env); err != nil {
+		return usererr.WithUserMessage(err, "The %s hook in devbox.json failed.", name)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.jetify.com/devbox/internal/devbox/devopt"
+	"go.jetify.com/devbox/internal/devconfig/configfile"
+	"go.jetify.com/devbox/internal/envir"
+)
+
+func TestLifecycleHookNeedsTrust(t *testing.T) {
+	t.Setenv(envir.XDGConfigHome, t.TempDir())
+	t.Setenv(envir.DevboxNoTrustPrompt, "")
+
+	dir := t.TempDir()
+	config := `{"packages": [], "hooks": {"pre_install": "touch ran"}}`
+	if err := os.WriteFile(filepath.Join(dir, "devbox.json"), []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	box, err := Open(&devopt.Opts{Dir: dir, Stderr: os.Stderr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	marker := filepath.Join(dir, "ran")
+
+	err = box.runLifecycleHook(context.Background(), configfile.PreInstallHook, false, nil)
+	if err == nil {
+		t.Error("got runLifecycleHook() = nil in an untrusted project, want error")
+	}
+	if _, err := os.Stat(marker); !os.IsNotExist(err) {
+		t.Fatalf("the pre_install hook ran in an untrusted project")
+	}
+
+	if err := box.Trust(); err != nil {
+		t.Fatal(err)
+	}
+	err = box.runLifecycleHook(context.Background(), configfile.PreInstallHook, false, nil)
+	if err != nil {
+		t.Fatalf("got runLifecycleHook() = %v in a trusted project, want nil", err)
+	}
+	if _, err := os.Stat(marker); err != nil {
+		t.Errorf("the pre_install hook didn't run in a trusted project: %v", err)
+	}
+}
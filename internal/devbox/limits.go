@@ -0,0 +1,53 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"github.com/pkg/errors"
+
+	"go.jetify.com/devbox/internal/devconfig/configfile"
+	"go.jetify.com/devbox/internal/limits"
+	"go.jetify.com/devbox/internal/nix"
+	"go.jetify.com/devbox/internal/ux"
+)
+
+// scriptLimitsWrapper returns the wrapper that runs a script with its limits
+// from devbox.json, or nil if it doesn't have any.
+func (d *Devbox) scriptLimitsWrapper(cmdName string) nix.CommandWrapper {
+	if _, ok := d.cfg.Scripts()[cmdName]; !ok {
+		return nil
+	}
+	l := toLimits(d.cfg.Root.ScriptLimits(cmdName))
+	if l.IsZero() {
+		return nil
+	}
+	return func(args []string) ([]string, error) {
+		wrapped, err := limits.Command(l, args...)
+		if errors.Is(err, limits.ErrUnsupported) {
+			ux.Fwarningf(d.stderr, "Script %s runs without its resource limits because %v.\n", cmdName, err)
+			return args, nil
+		}
+		return wrapped, err
+	}
+}
+
+// serviceLimits returns the limits of the services that have them in
+// devbox.json.
+func (d *Devbox) serviceLimits() map[string]limits.Limits {
+	svcLimits := map[string]limits.Limits{}
+	if d.cfg.Root.Limits == nil {
+		return svcLimits
+	}
+	for name, l := range d.cfg.Root.Limits.Services {
+		svcLimits[name] = toLimits(l)
+	}
+	return svcLimits
+}
+
+func toLimits(l *configfile.ResourceLimits) limits.Limits {
+	if l == nil {
+		return limits.Limits{}
+	}
+	return limits.Limits{CPUs: l.CPUs, Memory: l.Memory}
+}
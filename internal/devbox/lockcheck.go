@@ -0,0 +1,108 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+
+	"go.jetify.com/devbox/internal/devbox/devopt"
+	"go.jetify.com/devbox/internal/nix"
+	"go.jetify.com/devbox/internal/searcher"
+)
+
+// LockSystemProblem is a package in devbox.lock that can't be installed on a
+// system.
+type LockSystemProblem struct {
+	Package string `json:"package"`
+	System  string `json:"system,omitempty"`
+	Reason  string `json:"reason"`
+}
+
+// LockCheck is the result of checking devbox.lock against a set of systems.
+type LockCheck struct {
+	Systems []string `json:"systems"`
+
+	// Checked are the packages that were checked. Flakes, runx packages
+	// and other packages that the search index doesn't know about are
+	// skipped.
+	Checked  []string            `json:"checked"`
+	Problems []LockSystemProblem `json:"problems"`
+}
+
+// CheckLockSystems checks with the search index that every nixpkgs package in
+// devbox.lock, at its locked version, is built for each of the systems in
+// opts that the package is enabled on. A package that nixpkgs doesn't build
+// for a system is broken or unsupported there, so a lockfile that passes
+// locally can still fail on a teammate's machine.
+func (d *Devbox) CheckLockSystems(ctx context.Context, opts devopt.LockCheckOpts) (*LockCheck, error) {
+	systems := opts.Systems
+	if len(systems) == 0 {
+		var err error
+		if systems, err = d.lockSystems(); err != nil {
+			return nil, err
+		}
+	} else if err := nix.EnsureValidPlatform(systems...); err != nil {
+		return nil, err
+	}
+
+	check := &LockCheck{Systems: systems, Checked: []string{}, Problems: []LockSystemProblem{}}
+	for _, pkg := range d.AllPackages() {
+		if !pkg.IsDevboxPackage || pkg.IsRunX() {
+			continue
+		}
+		key := pkg.LockfileKey()
+		enabled := systems
+		if cfgPackage, _ := d.cfg.Root.GetPackage(pkg.Raw); cfgPackage != nil {
+			enabled = lo.Filter(systems, func(system string, _ int) bool {
+				return cfgPackage.IsEnabledOnSystem(system)
+			})
+		}
+		if len(enabled) == 0 {
+			continue
+		}
+		check.Checked = append(check.Checked, key)
+
+		locked := d.lockfile.Get(key)
+		if locked == nil || locked.Version == "" {
+			check.Problems = append(check.Problems, LockSystemProblem{
+				Package: key,
+				Reason:  "isn't locked to a version. Run `devbox install` to lock it",
+			})
+			continue
+		}
+		name, _, _ := searcher.ParseVersionedPackage(key)
+		resolved, err := searcher.Client().Resolve(ctx, name, locked.Version)
+		if errors.Is(err, searcher.ErrNotFound) {
+			check.Problems = append(check.Problems, LockSystemProblem{
+				Package: key,
+				Reason:  fmt.Sprintf("version %s isn't in the search index", locked.Version),
+			})
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		for _, system := range missingSystems(resolved, enabled) {
+			check.Problems = append(check.Problems, LockSystemProblem{
+				Package: key,
+				System:  system,
+				Reason: fmt.Sprintf("version %s isn't built for %s, so it's broken or unsupported there",
+					locked.Version, system),
+			})
+		}
+	}
+	return check, nil
+}
+
+// missingSystems returns the systems that nixpkgs doesn't build the resolved
+// package for.
+func missingSystems(resolved *searcher.PackageVersion, systems []string) []string {
+	return lo.Filter(systems, func(system string, _ int) bool {
+		_, ok := resolved.Systems[system]
+		return !ok
+	})
+}
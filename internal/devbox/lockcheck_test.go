@@ -0,0 +1,25 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"slices"
+	"testing"
+
+	"go.jetify.com/devbox/internal/searcher"
+)
+
+func TestMissingSystems(t *testing.T) {
+	resolved := &searcher.PackageVersion{
+		Systems: map[string]searcher.PackageInfo{
+			"x86_64-linux":   {},
+			"aarch64-darwin": {},
+		},
+	}
+	got := missingSystems(resolved, []string{"aarch64-darwin", "x86_64-darwin", "x86_64-linux", "aarch64-linux"})
+	want := []string{"x86_64-darwin", "aarch64-linux"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got missingSystems() = %v, want %v", got, want)
+	}
+}
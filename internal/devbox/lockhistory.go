@@ -0,0 +1,144 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+	"go.jetify.com/devbox/internal/devbox/devopt"
+	"go.jetify.com/devbox/internal/lock"
+)
+
+// LockfileHistory returns the previous versions of the project's devbox.lock,
+// newest first.
+func (d *Devbox) LockfileHistory() ([]lock.Backup, error) {
+	return lock.History(d.projectDir)
+}
+
+// RollbackLockfile restores the previous version of devbox.lock, such as after
+// an update that broke something, and installs the packages that it resolves
+// to.
+func (d *Devbox) RollbackLockfile(ctx context.Context) (*lock.Backup, error) {
+	unlock, err := d.lockProject()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	restored, err := lock.Rollback(d.projectDir)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.lockfile.Reload(); err != nil {
+		return nil, err
+	}
+	return restored, d.ensureStateIsUpToDate(ctx, ensure)
+}
+
+// DiffLockfile returns the packages that changed between an earlier devbox.lock
+// and the current one. The earlier one is from a git revision, or from a
+// duration ago. Durations use git's history if the project is in a git
+// repository, and the previous versions that Devbox keeps otherwise.
+func (d *Devbox) DiffLockfile(ctx context.Context, opts devopt.LockDiffOpts) ([]lock.Change, error) {
+	var old *lock.File
+	var err error
+	switch {
+	case opts.Since > 0 && d.isGitRepo(ctx):
+		old, err = d.gitLockfileBefore(ctx, time.Now().Add(-opts.Since))
+	case opts.Since > 0:
+		old, err = d.historyLockfileAt(time.Now().Add(-opts.Since))
+	default:
+		rev := opts.Rev
+		if rev == "" {
+			rev = "HEAD"
+		}
+		old, err = d.gitLockfile(ctx, rev)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return lock.Diff(old, d.lockfile), nil
+}
+
+func (d *Devbox) isGitRepo(ctx context.Context) bool {
+	return d.git(ctx, "rev-parse", "--is-inside-work-tree") == nil
+}
+
+// gitLockfile returns devbox.lock at a git revision, or nil if the project
+// didn't have one then.
+func (d *Devbox) gitLockfile(ctx context.Context, rev string) (*lock.File, error) {
+	if err := d.git(ctx, "rev-parse", "--verify", "--quiet", rev+"^{commit}"); err != nil {
+		return nil, usererr.New("%q isn't a git revision of this project.", rev)
+	}
+	var data bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", "-C", d.projectDir, "show", rev+":./devbox.lock")
+	cmd.Stdout = &data
+	if err := cmd.Run(); err != nil {
+		return nil, nil
+	}
+	return lock.ParseBytes(data.Bytes())
+}
+
+// gitLockfileBefore returns devbox.lock at the last commit before t that
+// changed it, or nil if no commit before t had one.
+func (d *Devbox) gitLockfileBefore(ctx context.Context, t time.Time) (*lock.File, error) {
+	out, err := exec.CommandContext(ctx, "git", "-C", d.projectDir, "rev-list", "-1",
+		"--before="+strconv.FormatInt(t.Unix(), 10), "HEAD", "--", "devbox.lock").Output()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the git history of devbox.lock")
+	}
+	rev := strings.TrimSpace(string(out))
+	if rev == "" {
+		return nil, nil
+	}
+	return d.gitLockfile(ctx, rev)
+}
+
+// historyLockfileAt returns the devbox.lock that the project had at t, from
+// the previous versions that Devbox keeps.
+func (d *Devbox) historyLockfileAt(t time.Time) (*lock.File, error) {
+	backups, err := lock.History(d.projectDir)
+	if err != nil {
+		return nil, err
+	}
+	// Backups are named after when they were replaced, so the one that the
+	// project had at t is the oldest one replaced after t.
+	var atTime *lock.Backup
+	for i := range backups {
+		if backups[i].Time.After(t) {
+			atTime = &backups[i]
+		}
+	}
+	if atTime == nil {
+		return d.lockfile, nil
+	}
+	data, err := os.ReadFile(atTime.Path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return lock.ParseBytes(data)
+}
+
+func (d *Devbox) git(ctx context.Context, args ...string) error {
+	return exec.CommandContext(ctx, "git", append([]string{"-C", d.projectDir}, args...)...).Run()
+}
+
+// RenovateManifest returns the packages in devbox.json with the fields of
+// Renovate's package dependencies, for devbox lock export.
+func (d *Devbox) RenovateManifest() *lock.RenovateManifest {
+	pkgs := []string{}
+	for _, pkg := range d.cfg.Root.TopLevelPackages() {
+		pkgs = append(pkgs, pkg.VersionedName())
+	}
+	return d.lockfile.RenovateManifest(pkgs)
+}
@@ -0,0 +1,52 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+	"go.jetify.com/devbox/internal/devbox/devopt"
+	"go.jetify.com/devbox/internal/nix"
+)
+
+// defaultLockSystems are the systems that `devbox update --all-systems`
+// resolves if devbox.json doesn't list the project's systems.
+var defaultLockSystems = []string{"aarch64-darwin", "aarch64-linux", "x86_64-darwin", "x86_64-linux"}
+
+// lockSystems returns the systems that devbox.lock has store paths for: the
+// systems in devbox.json, or defaultLockSystems.
+func (d *Devbox) lockSystems() ([]string, error) {
+	systems := d.cfg.Root.Systems
+	if len(systems) == 0 {
+		return defaultLockSystems, nil
+	}
+	return systems, nix.EnsureValidPlatform(systems...)
+}
+
+// TidyLockfile removes the packages that are no longer in devbox.json from
+// devbox.lock. With opts.Systems, it also removes the store paths of systems
+// that aren't in devbox.json's systems, and returns how many it removed.
+func (d *Devbox) TidyLockfile(opts devopt.LockTidyOpts) (int, error) {
+	if opts.Systems && len(d.cfg.Root.Systems) == 0 {
+		return 0, usererr.New(
+			"devbox.json doesn't list the project's systems. Add the systems to keep, such as " +
+				`"systems": ["x86_64-linux", "aarch64-darwin"], and run it again.`)
+	}
+	systems, err := d.lockSystems()
+	if err != nil {
+		return 0, err
+	}
+
+	unlock, err := d.lockProject()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	d.lockfile.Tidy()
+	removed := 0
+	if opts.Systems {
+		removed = d.lockfile.PruneSystems(systems)
+	}
+	return removed, d.lockfile.Save()
+}
@@ -0,0 +1,102 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// macAppsDirName is the directory in ~/Applications that devbox links the
+// .app bundles of devbox global packages into. Devbox only ever changes the
+// links in it that point into the global profile.
+const macAppsDirName = "Devbox Apps"
+
+// syncMacApps links the .app bundles of the global profile into
+// ~/Applications/Devbox Apps when macos.link_apps is set in the global
+// devbox.json, and removes the links of apps that are no longer in the
+// profile. It does nothing for projects or on other systems.
+func (d *Devbox) syncMacApps() error {
+	if runtime.GOOS != "darwin" || !d.isGlobal() {
+		return nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	profile, err := d.profilePath()
+	if err != nil {
+		return err
+	}
+	return linkMacApps(
+		filepath.Join(profile, "Applications"),
+		filepath.Join(home, "Applications", macAppsDirName),
+		d.cfg.Root.MacOSLinkApps(),
+	)
+}
+
+// linkMacApps makes linkDir contain a symlink to each .app bundle in appsDir
+// if enabled is true, and removes the symlinks into appsDir that are
+// dangling, or all of them if enabled is false. Other files in linkDir are
+// left alone.
+func linkMacApps(appsDir, linkDir string, enabled bool) error {
+	want := map[string]string{}
+	if enabled {
+		entries, err := os.ReadDir(appsDir)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return errors.WithStack(err)
+		}
+		for _, entry := range entries {
+			if strings.HasSuffix(entry.Name(), ".app") {
+				want[entry.Name()] = filepath.Join(appsDir, entry.Name())
+			}
+		}
+	}
+
+	entries, err := os.ReadDir(linkDir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return errors.WithStack(err)
+	}
+	for _, entry := range entries {
+		link := filepath.Join(linkDir, entry.Name())
+		target, err := os.Readlink(link)
+		if err != nil || filepath.Dir(target) != appsDir {
+			continue
+		}
+		if want[entry.Name()] == target {
+			delete(want, entry.Name())
+			continue
+		}
+		if err := os.Remove(link); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	if len(want) > 0 {
+		if err := os.MkdirAll(linkDir, 0o755); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	for name, target := range want {
+		link := filepath.Join(linkDir, name)
+		if _, err := os.Lstat(link); err == nil {
+			// Don't replace an app that devbox didn't link.
+			continue
+		}
+		if err := os.Symlink(target, link); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	// Remove the directory once it's empty, so that turning off link_apps
+	// leaves nothing behind.
+	if entries, err := os.ReadDir(linkDir); err == nil && len(entries) == 0 {
+		return errors.WithStack(os.Remove(linkDir))
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestLinkMacApps(t *testing.T) {
+	dir := t.TempDir()
+	appsDir := filepath.Join(dir, "profile", "Applications")
+	linkDir := filepath.Join(dir, "Applications", macAppsDirName)
+	for _, name := range []string{"kitty.app", "WezTerm.app", "README"} {
+		if err := os.MkdirAll(filepath.Join(appsDir, name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := linkMacApps(appsDir, linkDir, true); err != nil {
+		t.Fatal(err)
+	}
+	assertLinks(t, linkDir, "WezTerm.app", "kitty.app")
+	if target, _ := os.Readlink(filepath.Join(linkDir, "kitty.app")); target != filepath.Join(appsDir, "kitty.app") {
+		t.Errorf("kitty.app links to %s, want %s", target, filepath.Join(appsDir, "kitty.app"))
+	}
+
+	// An app that the user put there themselves is kept.
+	if err := os.Mkdir(filepath.Join(linkDir, "Mine.app"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// Removing a package removes the link to its app.
+	if err := os.RemoveAll(filepath.Join(appsDir, "kitty.app")); err != nil {
+		t.Fatal(err)
+	}
+	if err := linkMacApps(appsDir, linkDir, true); err != nil {
+		t.Fatal(err)
+	}
+	assertLinks(t, linkDir, "Mine.app", "WezTerm.app")
+
+	if err := os.Remove(filepath.Join(linkDir, "Mine.app")); err != nil {
+		t.Fatal(err)
+	}
+	if err := linkMacApps(appsDir, linkDir, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(linkDir); !os.IsNotExist(err) {
+		t.Errorf("got %s after disabling link_apps, want it removed", linkDir)
+	}
+}
+
+func assertLinks(t *testing.T, dir string, want ...string) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []string{}
+	for _, entry := range entries {
+		got = append(got, entry.Name())
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v in %s, want %v", got, dir, want)
+	}
+}
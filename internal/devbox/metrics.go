@@ -0,0 +1,274 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"bufio"
+	"encoding/json"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"go.jetify.com/devbox/internal/devpkg"
+	"go.jetify.com/devbox/internal/envir"
+	"go.jetify.com/devbox/internal/nix"
+)
+
+// Metrics that devbox records when DEVBOX_METRICS is set.
+const (
+	// MetricShellStartup is how long devbox shell, devbox run or devbox
+	// shellenv took to compute the environment.
+	MetricShellStartup = "shell_startup"
+	// MetricInstall is how long it took to install the project's packages,
+	// either for a command such as devbox add, or to activate an
+	// environment that wasn't up to date.
+	MetricInstall = "install"
+	// MetricStateCache is whether activating the environment could reuse
+	// the installed state, or had to install packages first.
+	MetricStateCache = "state_cache"
+	// MetricBinaryCache is how many of the packages that devbox installed
+	// were in a binary cache, rather than built from source.
+	MetricBinaryCache = "binary_cache"
+)
+
+// maxMetricsFileSize is how large the metrics file grows before it's moved to
+// metrics.jsonl.old and a new one is started, so that devbox keeps one to two
+// files' worth of samples.
+const maxMetricsFileSize = 4 << 20
+
+// MetricSample is a measurement of one of the metrics.
+type MetricSample struct {
+	Time time.Time `json:"time"`
+	Name string    `json:"name"`
+	// Command is the command that the sample was measured in, such as
+	// shell or shellenv.
+	Command string `json:"command,omitempty"`
+	// Duration is set for metrics of latency, such as shell_startup.
+	Duration time.Duration `json:"duration,omitempty"`
+	// Hits and Misses are set for the metrics of caches.
+	Hits   int `json:"hits,omitempty"`
+	Misses int `json:"misses,omitempty"`
+}
+
+// DurationSummary summarizes the samples of a latency metric.
+type DurationSummary struct {
+	Name    string        `json:"name"`
+	Samples int           `json:"samples"`
+	Sum     time.Duration `json:"sum"`
+	Mean    time.Duration `json:"mean"`
+	P50     time.Duration `json:"p50"`
+	P90     time.Duration `json:"p90"`
+	Max     time.Duration `json:"max"`
+}
+
+// CacheSummary summarizes the samples of a cache metric.
+type CacheSummary struct {
+	Name    string  `json:"name"`
+	Hits    int     `json:"hits"`
+	Misses  int     `json:"misses"`
+	HitRate float64 `json:"hit_rate"`
+}
+
+// MetricsSummary summarizes the metrics that were recorded for a project.
+type MetricsSummary struct {
+	Project   string            `json:"project"`
+	Samples   int               `json:"samples"`
+	First     time.Time         `json:"first,omitzero"`
+	Last      time.Time         `json:"last,omitzero"`
+	Durations []DurationSummary `json:"durations"`
+	Caches    []CacheSummary    `json:"caches"`
+}
+
+func metricsPath(projectDir string) string {
+	return filepath.Join(nix.ProjectUserDir(projectDir), "metrics.jsonl")
+}
+
+// Metrics summarizes the metrics that were recorded since since, or all of
+// them if since is zero.
+func (d *Devbox) Metrics(since time.Time) (*MetricsSummary, error) {
+	samples, err := readMetricSamples(metricsPath(d.projectDir))
+	if err != nil {
+		return nil, err
+	}
+	samples = slices.DeleteFunc(samples, func(s MetricSample) bool {
+		return s.Time.Before(since)
+	})
+	summary := summarizeMetrics(samples)
+	summary.Project = d.projectDir
+	return summary, nil
+}
+
+// recordMetric records sample if DEVBOX_METRICS is set. Failing to record a
+// sample doesn't fail the command.
+func (d *Devbox) recordMetric(sample MetricSample) {
+	if !envir.IsMetricsEnabled() {
+		return
+	}
+	if sample.Time.IsZero() {
+		sample.Time = time.Now()
+	}
+	err := nix.EnsureProjectUserDir(d.projectDir)
+	if err == nil {
+		err = appendMetricSample(metricsPath(d.projectDir), sample)
+	}
+	if err != nil {
+		slog.Debug("failed to record metric", "metric", sample.Name, "err", err)
+	}
+}
+
+// recordStateCacheMetric records whether the installed state of the project
+// was up to date when its environment was activated.
+func (d *Devbox) recordStateCacheMetric(upToDate bool) {
+	sample := MetricSample{Name: MetricStateCache, Misses: 1}
+	if upToDate {
+		sample = MetricSample{Name: MetricStateCache, Hits: 1}
+	}
+	d.recordMetric(sample)
+}
+
+// recordBinaryCacheMetric records how many of packages, which devbox is about
+// to install, are in a binary cache.
+func (d *Devbox) recordBinaryCacheMetric(packages []*devpkg.Package) {
+	if !envir.IsMetricsEnabled() || len(packages) == 0 {
+		return
+	}
+	sample := MetricSample{Name: MetricBinaryCache}
+	for _, pkg := range packages {
+		if inCache, err := pkg.IsInBinaryCache(); err == nil && inCache {
+			sample.Hits++
+		} else {
+			sample.Misses++
+		}
+	}
+	d.recordMetric(sample)
+}
+
+// appendMetricSample appends sample to the metrics file in path as a line of
+// JSON, holding a lock on the file so that samples from concurrent commands
+// don't interleave.
+func appendMetricSample(path string, sample MetricSample) error {
+	data, err := json.Marshal(sample)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer file.Close()
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX); err != nil {
+		return errors.WithStack(err)
+	}
+	if info, err := file.Stat(); err == nil && info.Size() >= maxMetricsFileSize {
+		// Commands that are waiting for the lock append to the old file,
+		// which is still read.
+		if err := os.Rename(path, path+".old"); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	_, err = file.Write(append(data, '\n'))
+	return errors.WithStack(err)
+}
+
+// readMetricSamples reads the samples in the metrics file in path and the one
+// it replaced, oldest first. It skips lines that aren't samples, such as a
+// line that a crash cut short.
+func readMetricSamples(path string) ([]MetricSample, error) {
+	samples := []MetricSample{}
+	for _, p := range []string{path + ".old", path} {
+		file, err := os.Open(p)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			sample := MetricSample{}
+			if err := json.Unmarshal(scanner.Bytes(), &sample); err == nil {
+				samples = append(samples, sample)
+			}
+		}
+		err = scanner.Err()
+		file.Close()
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	slices.SortStableFunc(samples, func(a, b MetricSample) int {
+		return a.Time.Compare(b.Time)
+	})
+	return samples, nil
+}
+
+// summarizeMetrics summarizes samples, which are oldest first. The metrics are
+// sorted by name.
+func summarizeMetrics(samples []MetricSample) *MetricsSummary {
+	summary := &MetricsSummary{
+		Samples:   len(samples),
+		Durations: []DurationSummary{},
+		Caches:    []CacheSummary{},
+	}
+	if len(samples) > 0 {
+		summary.First = samples[0].Time
+		summary.Last = samples[len(samples)-1].Time
+	}
+
+	durations := map[string][]time.Duration{}
+	caches := map[string]*CacheSummary{}
+	for _, s := range samples {
+		switch s.Name {
+		case MetricShellStartup, MetricInstall:
+			durations[s.Name] = append(durations[s.Name], s.Duration)
+		case MetricStateCache, MetricBinaryCache:
+			if caches[s.Name] == nil {
+				caches[s.Name] = &CacheSummary{Name: s.Name}
+			}
+			caches[s.Name].Hits += s.Hits
+			caches[s.Name].Misses += s.Misses
+		}
+	}
+
+	for _, name := range []string{MetricInstall, MetricShellStartup} {
+		if d := durations[name]; len(d) > 0 {
+			summary.Durations = append(summary.Durations, summarizeDurations(name, d))
+		}
+	}
+	for _, name := range []string{MetricBinaryCache, MetricStateCache} {
+		if c := caches[name]; c != nil {
+			if total := c.Hits + c.Misses; total > 0 {
+				c.HitRate = float64(c.Hits) / float64(total)
+			}
+			summary.Caches = append(summary.Caches, *c)
+		}
+	}
+	return summary
+}
+
+func summarizeDurations(name string, durations []time.Duration) DurationSummary {
+	slices.Sort(durations)
+	summary := DurationSummary{Name: name, Samples: len(durations)}
+	for _, d := range durations {
+		summary.Sum += d
+	}
+	summary.Mean = summary.Sum / time.Duration(len(durations))
+	summary.P50 = percentile(durations, 0.5)
+	summary.P90 = percentile(durations, 0.9)
+	summary.Max = durations[len(durations)-1]
+	return summary
+}
+
+// percentile returns the p-th percentile of sorted with the nearest-rank
+// method.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	return sorted[max(0, min(rank, len(sorted)-1))]
+}
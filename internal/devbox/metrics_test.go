@@ -0,0 +1,95 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSummarizeMetrics(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	samples := []MetricSample{}
+	for i := range 10 {
+		samples = append(samples, MetricSample{
+			Time:     start.Add(time.Duration(i) * time.Minute),
+			Name:     MetricShellStartup,
+			Command:  "shell",
+			Duration: time.Duration(i+1) * 100 * time.Millisecond,
+		})
+	}
+	samples = append(samples,
+		MetricSample{Time: start.Add(time.Hour), Name: MetricStateCache, Hits: 1},
+		MetricSample{Time: start.Add(time.Hour), Name: MetricStateCache, Hits: 1},
+		MetricSample{Time: start.Add(time.Hour), Name: MetricStateCache, Hits: 1},
+		MetricSample{Time: start.Add(time.Hour), Name: MetricStateCache, Misses: 1},
+		MetricSample{Time: start.Add(2 * time.Hour), Name: MetricBinaryCache, Hits: 3, Misses: 1},
+	)
+
+	summary := summarizeMetrics(samples)
+	if summary.Samples != 15 || !summary.First.Equal(start) || !summary.Last.Equal(start.Add(2*time.Hour)) {
+		t.Errorf("got %d samples from %s to %s", summary.Samples, summary.First, summary.Last)
+	}
+
+	if len(summary.Durations) != 1 {
+		t.Fatalf("got duration summaries %+v, want one for %s", summary.Durations, MetricShellStartup)
+	}
+	want := DurationSummary{
+		Name:    MetricShellStartup,
+		Samples: 10,
+		Sum:     5500 * time.Millisecond,
+		Mean:    550 * time.Millisecond,
+		P50:     500 * time.Millisecond,
+		P90:     900 * time.Millisecond,
+		Max:     time.Second,
+	}
+	if got := summary.Durations[0]; got != want {
+		t.Errorf("got duration summary %+v, want %+v", got, want)
+	}
+
+	wantCaches := []CacheSummary{
+		{Name: MetricBinaryCache, Hits: 3, Misses: 1, HitRate: 0.75},
+		{Name: MetricStateCache, Hits: 3, Misses: 1, HitRate: 0.75},
+	}
+	if len(summary.Caches) != len(wantCaches) {
+		t.Fatalf("got cache summaries %+v, want %+v", summary.Caches, wantCaches)
+	}
+	for i, got := range summary.Caches {
+		if got != wantCaches[i] {
+			t.Errorf("got cache summary %+v, want %+v", got, wantCaches[i])
+		}
+	}
+}
+
+func TestReadMetricSamples(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.jsonl")
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	if err := appendMetricSample(path, MetricSample{Time: start, Name: MetricInstall}); err != nil {
+		t.Fatal(err)
+	}
+	// The sample is in the old file once the file is moved.
+	if err := os.Rename(path, path+".old"); err != nil {
+		t.Fatal(err)
+	}
+	if err := appendMetricSample(path, MetricSample{Time: start.Add(time.Minute), Name: MetricShellStartup}); err != nil {
+		t.Fatal(err)
+	}
+	// A line that a crash cut short is skipped.
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString(`{"time":"2024-01-01T12:02:00Z","na`)
+	file.Close()
+
+	samples, err := readMetricSamples(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != 2 || samples[0].Name != MetricInstall || samples[1].Name != MetricShellStartup {
+		t.Errorf("got samples %+v, want the install and the shell_startup samples", samples)
+	}
+}
@@ -42,7 +42,7 @@ func (d *Devbox) syncNixProfileFromFlake(ctx context.Context) error {
 	}
 
 	// Get the store-paths of the packages currently installed in the nix profile
-	items, err := nixprofile.ProfileListItems(d.stderr, profilePath)
+	items, err := nixprofile.ProfileListItems(ctx, d.stderr, profilePath)
 	if err != nil {
 		return fmt.Errorf("nix profile list: %v", err)
 	}
@@ -61,7 +61,7 @@ func (d *Devbox) syncNixProfileFromFlake(ctx context.Context) error {
 		}
 		slog.Debug("removing packages from nix profile", "pkgs", strings.Join(packagesToRemove, ", "))
 
-		if err := nix.ProfileRemove(profilePath, remove...); err != nil {
+		if err := nix.ProfileRemove(ctx, profilePath, remove...); err != nil {
 			return err
 		}
 	}
@@ -0,0 +1,198 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"go.jetify.com/devbox/internal/lock"
+	"go.jetify.com/devbox/internal/notify"
+	"go.jetify.com/devbox/internal/services"
+	"go.jetify.com/devbox/internal/ux"
+)
+
+// servicesWatchInterval is how often devbox services watch checks whether a
+// service crashed.
+const servicesWatchInterval = 5 * time.Second
+
+// notifyTargets returns the notifications that devbox.json configures. It
+// warns about the ones that are invalid and skips them. It skips all of them if
+// the user doesn't trust the project, so that a cloned project can't send
+// messages about the user's commands elsewhere.
+func (d *Devbox) notifyTargets() []*notify.Target {
+	targets := []*notify.Target{}
+	if len(d.cfg.Root.Notifications) == 0 {
+		return targets
+	}
+	if err := d.ensureTrusted("send notifications to the targets in devbox.json"); err != nil {
+		ux.Fwarningf(d.stderr, "Skipping the notifications in devbox.json: %v\n", err)
+		return targets
+	}
+	for _, n := range d.cfg.Root.Notifications {
+		target := &notify.Target{Type: n.Type, URL: n.URL, Template: n.Template}
+		for _, event := range n.Events {
+			target.Events = append(target.Events, notify.Event(event))
+		}
+		if err := target.Validate(); err != nil {
+			ux.Fwarningf(d.stderr, "Skipping an invalid notification in devbox.json: %v\n", err)
+			continue
+		}
+		targets = append(targets, target)
+	}
+	return targets
+}
+
+// notifyCommand notifies targets that a tracked command failed to install
+// packages or changed devbox.lock. Interrupted commands aren't notified.
+func (d *Devbox) notifyCommand(ctx context.Context, targets []*notify.Target, record AuditRecord, err error) {
+	msg := notify.Message{Command: "devbox " + record.Command}
+	switch {
+	case errors.Is(err, context.Canceled):
+		return
+	case err != nil && record.Command != "rm":
+		msg.Event = notify.InstallFailed
+		msg.Summary = fmt.Sprintf("devbox %s failed to install the packages of %s", record.Command, d.projectDir)
+		msg.Error = record.Error
+	case record.LockHashBefore != record.LockHashAfter:
+		msg.Event = notify.LockfileChanged
+		msg.Summary = fmt.Sprintf("devbox %s changed devbox.lock in %s", record.Command, d.projectDir)
+		msg.Changes = describeChanges(record.Changes)
+	default:
+		return
+	}
+	d.notify(ctx, targets, msg)
+}
+
+// notify fills in who and where msg is from, and sends it to targets.
+func (d *Devbox) notify(ctx context.Context, targets []*notify.Target, msg notify.Message) {
+	msg.Time = time.Now().UTC()
+	msg.Project = d.projectDir
+	msg.User = auditUser()
+	msg.Host, _ = os.Hostname()
+	if err := notify.Send(ctx, targets, msg); err != nil {
+		ux.Fwarningf(d.stderr, "Failed to send a notification: %v\n", err)
+	}
+}
+
+// describeChanges describes each change to the locked packages on a line, such
+// as "go: 1.22.1 -> 1.22.3".
+func describeChanges(changes []lock.Change) []string {
+	lines := make([]string, 0, len(changes))
+	for _, change := range changes {
+		switch {
+		case change.Kind == lock.Added:
+			lines = append(lines, fmt.Sprintf("%s: added %s", change.Name, change.NewVersion))
+		case change.Kind == lock.Removed:
+			lines = append(lines, fmt.Sprintf("%s: removed %s", change.Name, change.OldVersion))
+		case change.OldVersion == change.NewVersion:
+			lines = append(lines, fmt.Sprintf("%s: rebuilt %s", change.Name, change.NewVersion))
+		default:
+			lines = append(lines, fmt.Sprintf("%s: %s -> %s", change.Name, change.OldVersion, change.NewVersion))
+		}
+	}
+	return lines
+}
+
+// wantsServiceCrashes reports whether a notification in devbox.json is
+// notified when a service crashes.
+func (d *Devbox) wantsServiceCrashes() bool {
+	return slices.ContainsFunc(d.notifyTargets(), func(t *notify.Target) bool {
+		return t.Wants(notify.ServiceCrashed)
+	})
+}
+
+// startServicesWatch starts devbox services watch in the background, in its
+// own session so that it outlives the terminal like the detached process
+// manager does. Its output goes to .devbox/services-watch.log.
+func (d *Devbox) startServicesWatch() error {
+	exe, err := os.Executable()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	logPath := filepath.Join(d.projectDir, ".devbox", "services-watch.log")
+	logFile, err := os.OpenFile(logPath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer logFile.Close()
+
+	cmd := exec.Command(exe, "services", "watch", "--config", d.projectDir)
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := cmd.Start(); err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(cmd.Process.Release())
+}
+
+// WatchServices notifies the project's notifications when one of its services
+// crashes, until the process manager stops or ctx is canceled. devbox services
+// up --detach runs it in the background.
+func (d *Devbox) WatchServices(ctx context.Context) error {
+	targets := d.notifyTargets()
+	prev := map[string]services.Process{}
+	for services.ProcessManagerIsRunning(d.projectDir) {
+		processes, err := services.ListServices(ctx, d.projectDir, io.Discard)
+		if err != nil {
+			slog.Debug("failed to list services to watch them", "err", err)
+		} else {
+			current := map[string]services.Process{}
+			for _, p := range processes {
+				current[p.Name] = p
+			}
+			for _, p := range crashedServices(prev, current) {
+				d.notify(ctx, targets, notify.Message{
+					Event:   notify.ServiceCrashed,
+					Summary: fmt.Sprintf("Service %s crashed in %s", p.Name, d.projectDir),
+					Service: p.Name,
+					Error:   fmt.Sprintf("%s with exit code %d after %d restarts", p.Status, p.ExitCode, p.Restarts),
+				})
+			}
+			prev = current
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(servicesWatchInterval):
+		}
+	}
+	return nil
+}
+
+// crashedServices returns the services in current that crashed since prev:
+// the ones that process-compose restarted, and the ones that exited with an
+// error, unless they had already exited in prev.
+func crashedServices(prev, current map[string]services.Process) []services.Process {
+	crashed := []services.Process{}
+	for _, name := range slices.Sorted(maps.Keys(current)) {
+		p := current[name]
+		old, seen := prev[name]
+		if p.Restarts > old.Restarts {
+			crashed = append(crashed, p)
+			continue
+		}
+		if exitedWithError(p) && !(seen && exitedWithError(old)) {
+			crashed = append(crashed, p)
+		}
+	}
+	return crashed
+}
+
+func exitedWithError(p services.Process) bool {
+	return p.ExitCode != 0 && p.Status != "Running"
+}
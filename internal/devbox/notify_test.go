@@ -0,0 +1,51 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"slices"
+	"testing"
+
+	"go.jetify.com/devbox/internal/services"
+)
+
+func TestCrashedServices(t *testing.T) {
+	running := services.Process{Name: "web", Status: "Running"}
+	restarted := services.Process{Name: "web", Status: "Running", Restarts: 1}
+	failed := services.Process{Name: "worker", Status: "Completed", ExitCode: 1}
+	completed := services.Process{Name: "migrate", Status: "Completed"}
+
+	tests := []struct {
+		name    string
+		prev    []services.Process
+		current []services.Process
+		want    []string
+	}{
+		{"running", []services.Process{running}, []services.Process{running}, []string{}},
+		{"restarted", []services.Process{running}, []services.Process{restarted}, []string{"web"}},
+		{"restarted before the first check", nil, []services.Process{restarted}, []string{"web"}},
+		{"exited with an error", []services.Process{running}, []services.Process{failed}, []string{"worker"}},
+		{"already exited with an error", []services.Process{failed}, []services.Process{failed}, []string{}},
+		{"exited without an error", nil, []services.Process{completed}, []string{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := []string{}
+			for _, p := range crashedServices(byName(test.prev), byName(test.current)) {
+				got = append(got, p.Name)
+			}
+			if !slices.Equal(got, test.want) {
+				t.Errorf("got crashed services %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func byName(processes []services.Process) map[string]services.Process {
+	m := map[string]services.Process{}
+	for _, p := range processes {
+		m[p.Name] = p
+	}
+	return m
+}
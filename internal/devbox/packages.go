@@ -23,7 +23,6 @@
 	"go.jetify.com/devbox/internal/devconfig"
 	"go.jetify.com/devbox/internal/devconfig/configfile"
 	"go.jetify.com/devbox/internal/devpkg"
-	"go.jetify.com/devbox/internal/devpkg/pkgtype"
 	"go.jetify.com/devbox/internal/lock"
 	"go.jetify.com/devbox/internal/setup"
 	"go.jetify.com/devbox/internal/shellgen"
@@ -55,12 +54,15 @@ func (d *Devbox) Outdated(ctx context.Context) (map[string]UpdateVersion, error)
 	var warnings []string
 
 	for _, pkg := range d.AllPackages() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		// For non-devbox packages, like flakes, we can skip for now
 		if !pkg.IsDevboxPackage {
 			continue
 		}
 
-		lockPackage, err := lockfile.FetchResolvedPackage(pkg.Versioned(), false)
+		lockPackage, err := lockfile.FetchResolvedPackage(ctx, pkg.Versioned(), false)
 		if err != nil {
 			warnings = append(warnings, fmt.Sprintf("Note: unable to check updates for %s", pkg.CanonicalName()))
 			continue
@@ -82,10 +84,18 @@ func (d *Devbox) Outdated(ctx context.Context) (map[string]UpdateVersion, error)
 
 // Add adds the `pkgs` to the config (i.e. devbox.json) and nix profile for this
 // devbox project
-func (d *Devbox) Add(ctx context.Context, pkgsNames []string, opts devopt.AddOpts) error {
+func (d *Devbox) Add(ctx context.Context, pkgsNames []string, opts devopt.AddOpts) (err error) {
 	ctx, task := trace.NewTask(ctx, "devboxAdd")
 	defer task.End()
 
+	unlock, err := d.lockProject()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	done := d.trackCommand(ctx, "add", pkgsNames)
+	defer func() { done(err) }()
+
 	// Track which packages had no changes so we can report that to the user.
 	unchangedPackageNames := []string{}
 
@@ -93,6 +103,15 @@ func (d *Devbox) Add(ctx context.Context, pkgsNames []string, opts devopt.AddOpt
 	// replace it.
 	pkgs := devpkg.PackagesFromStringsWithOptions(lo.Uniq(pkgsNames), d.lockfile, opts)
 
+	// Check the org policy before replacing or adding anything, so that a
+	// violation leaves devbox.json as it was.
+	versioned := lo.Map(pkgs, func(pkg *devpkg.Package, _ int) *devpkg.Package {
+		return devpkg.PackageFromStringWithOptions(pkg.Versioned(), d.lockfile, opts)
+	})
+	if err := d.checkPolicy(ctx, versioned, opts.Explain); err != nil {
+		return err
+	}
+
 	// addedPackageNames keeps track of the possibly transformed (versioned)
 	// names of added packages (even if they are already in config). We use this
 	// to know the exact name to mark as allowed insecure later on.
@@ -144,7 +163,7 @@ func (d *Devbox) Add(ctx context.Context, pkgsNames []string, opts devopt.AddOpt
 				Ref:      d.lockfile.Stdenv(),
 				AttrPath: pkg.Raw,
 			}
-			_, err := nix.Search(installable.String())
+			_, err := nix.Search(ctx, installable.String())
 			if err != nil {
 				// This means it looked like a devbox package or attribute path, but we
 				// could not find it in search or in the legacy nixpkgs path.
@@ -170,7 +189,14 @@ func (d *Devbox) Add(ctx context.Context, pkgsNames []string, opts devopt.AddOpt
 		return err
 	}
 
-	return d.printPostAddMessage(ctx, pkgs, unchangedPackageNames, opts)
+	if err := d.recordInsecureAcceptances(ctx, addedPackageNames, opts); err != nil {
+		return err
+	}
+
+	if err := d.printPostAddMessage(ctx, pkgs, unchangedPackageNames, opts); err != nil {
+		return err
+	}
+	return d.runLifecycleHook(ctx, configfile.PostAddHook, true, addedPackageNames)
 }
 
 func (d *Devbox) setPackageOptions(pkgs []string, opts devopt.AddOpts) error {
@@ -201,6 +227,14 @@ func (d *Devbox) setPackageOptions(pkgs []string, opts devopt.AddOpts) error {
 			d.stderr, pkg, opts.AllowInsecure); err != nil {
 			return err
 		}
+		if err := d.cfg.PackageMutator().SetOverride(
+			d.stderr, pkg, opts.Override); err != nil {
+			return err
+		}
+		if err := d.cfg.PackageMutator().SetOverlay(
+			pkg, opts.Overlay); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -238,10 +272,18 @@ func (d *Devbox) printPostAddMessage(
 
 // Remove removes the `pkgs` from the config (i.e. devbox.json) and nix profile
 // for this devbox project
-func (d *Devbox) Remove(ctx context.Context, pkgs ...string) error {
+func (d *Devbox) Remove(ctx context.Context, pkgs ...string) (err error) {
 	ctx, task := trace.NewTask(ctx, "devboxRemove")
 	defer task.End()
 
+	unlock, err := d.lockProject()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	done := d.trackCommand(ctx, "rm", pkgs)
+	defer func() { done(err) }()
+
 	packagesToUninstall := []string{}
 	missingPkgs := []string{}
 	for _, pkg := range lo.Uniq(pkgs) {
@@ -271,7 +313,10 @@ func (d *Devbox) Remove(ctx context.Context, pkgs ...string) error {
 		return err
 	}
 
-	return d.saveCfg()
+	if err := d.saveCfg(); err != nil {
+		return err
+	}
+	return d.cleanupOrphanedPluginFiles(true /*warn*/)
 }
 
 // installMode is an enum for helping with ensureStateIsUpToDate implementation
@@ -301,6 +346,13 @@ func (d *Devbox) Remove(ctx context.Context, pkgs ...string) error {
 func (d *Devbox) ensureStateIsUpToDate(ctx context.Context, mode installMode) error {
 	defer trace.StartRegion(ctx, "devboxEnsureStateIsUpToDate").End()
 	defer debug.FunctionTimer().End()
+	started := time.Now()
+
+	unlock, err := d.lockProject()
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
 	upToDate, err := d.lockfile.IsUpToDateAndInstalled(isFishShell())
 	if err != nil {
@@ -311,6 +363,7 @@ func (d *Devbox) ensureStateIsUpToDate(ctx context.Context, mode installMode) er
 	// like updating the flake or installing packages locally, so must continue
 	// below
 	if mode == ensure {
+		d.recordStateCacheMetric(upToDate)
 		// if mode is ensure and we are up to date, then we can skip the rest
 		if upToDate {
 			return nil
@@ -340,6 +393,15 @@ func (d *Devbox) ensureStateIsUpToDate(ctx context.Context, mode installMode) er
 			return err
 		}
 	}
+	if err := d.syncMacApps(); err != nil {
+		return err
+	}
+	if err := d.syncFonts(); err != nil {
+		return err
+	}
+	if mode == install || mode == update || mode == ensure {
+		d.syncPythonVenv(ctx)
+	}
 
 	// If we're in a devbox shell (global or project), then the environment might
 	// be out of date after the user installs something. If have direnv active
@@ -353,7 +415,18 @@ func (d *Devbox) ensureStateIsUpToDate(ctx context.Context, mode installMode) er
 		)
 	}
 
-	return d.updateLockfile(recomputeState)
+	if err := d.updateLockfile(recomputeState); err != nil {
+		return err
+	}
+	if mode == install || mode == update || mode == ensure {
+		d.recordMetric(MetricSample{
+			Time:     started,
+			Name:     MetricInstall,
+			Command:  string(mode),
+			Duration: time.Since(started).Round(time.Millisecond),
+		})
+	}
+	return nil
 }
 
 // updateLockfile will ensure devbox.lock is up to date with the current state of the project.update
@@ -369,6 +442,19 @@ func (d *Devbox) updateLockfile(recomputeState bool) error {
 		}
 	}
 
+	// Record the content hashes of local flakes and patch files so that
+	// changes to them are visible in the lockfile.
+	for _, pkg := range d.AllPackages() {
+		hash, err := pkg.LocalFlakeHash()
+		if err != nil {
+			return err
+		}
+		if hash != "" {
+			d.lockfile.SetLocalFlakeHash(pkg.LockfileKey(), hash)
+		}
+		d.lockfile.SetPatchesHash(pkg.LockfileKey(), pkg.PatchesHash())
+	}
+
 	// Update plugin versions in lockfile.
 	for _, pluginConfig := range d.Config().IncludedPluginConfigs() {
 		if err := d.PluginManager().UpdateLockfileVersion(pluginConfig); err != nil {
@@ -414,16 +500,22 @@ func (d *Devbox) recomputeState(ctx context.Context) error {
 	if err := plugin.RemoveInvalidSymlinks(d.projectDir); err != nil {
 		return err
 	}
+	if err := d.cleanupOrphanedPluginFiles(false /*warn*/); err != nil {
+		return err
+	}
 
 	return d.syncNixProfileFromFlake(ctx)
 }
 
 func (d *Devbox) profilePath() (string, error) {
-	absPath := filepath.Join(d.projectDir, nix.ProfilePath)
+	absPath := nix.ProjectProfilePath(d.projectDir)
 
 	if err := resetProfileDirForFlakes(absPath); err != nil {
 		slog.Error("resetProfileDirForFlakes error", "err", err)
 	}
+	if err := nix.EnsureProjectUserDir(d.projectDir); err != nil {
+		return "", err
+	}
 
 	return absPath, errors.WithStack(os.MkdirAll(filepath.Dir(absPath), 0o755))
 }
@@ -470,7 +562,15 @@ func (d *Devbox) installPackages(ctx context.Context, mode installMode) error {
 			return err
 		}
 	}
+	if err := d.checkUnfreePackages(ctx, lo.Filter(d.InstallablePackages(), devpkg.IsNix)); err != nil {
+		return err
+	}
+	return d.fetchPackages(ctx, mode)
+}
 
+// fetchPackages installs the project's nix packages in the nix store, and
+// downloads its runx and URL packages.
+func (d *Devbox) fetchPackages(ctx context.Context, mode installMode) error {
 	if err := d.installNixPackagesToStore(ctx, mode); err != nil {
 		if caches, _ := nixcache.CachedReadCaches(ctx); len(caches) > 0 {
 			err = d.handleInstallFailure(ctx, mode)
@@ -478,7 +578,10 @@ func (d *Devbox) installPackages(ctx context.Context, mode installMode) error {
 		return err
 	}
 
-	return d.InstallRunXPackages(ctx)
+	if err := d.InstallRunXPackages(ctx); err != nil {
+		return err
+	}
+	return d.InstallURLPackages(ctx)
 }
 
 func (d *Devbox) handleInstallFailure(ctx context.Context, mode installMode) error {
@@ -494,20 +597,22 @@ func (d *Devbox) handleInstallFailure(ctx context.Context, mode installMode) err
 
 func (d *Devbox) InstallRunXPackages(ctx context.Context) error {
 	for _, pkg := range lo.Filter(d.InstallablePackages(), devpkg.IsRunX) {
-		lockedPkg, err := d.lockfile.Resolve(pkg.Raw)
-		if err != nil {
-			return err
-		}
-		if _, err := pkgtype.RunXClient().Install(
-			ctx,
-			lockedPkg.Resolved,
-		); err != nil {
+		if _, err := d.lockfile.InstallRunXPackage(ctx, pkg.Raw); err != nil {
 			return fmt.Errorf("error installing runx package %s: %w", pkg, err)
 		}
 	}
 	return nil
 }
 
+func (d *Devbox) InstallURLPackages(ctx context.Context) error {
+	for _, pkg := range lo.Filter(d.InstallablePackages(), devpkg.IsURL) {
+		if _, err := d.lockfile.InstallURLPackage(ctx, pkg.Raw, pkg.URLChecksum()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // installNixPackagesToStore will install all the packages in the nix store, if
 // mode is install or update, and we're not in a devbox environment.
 // This is done by running `nix build` on the flake. We do this so that the
@@ -529,12 +634,15 @@ func (d *Devbox) installNixPackagesToStore(ctx context.Context, mode installMode
 	args := &nix.BuildArgs{
 		Flags:  flags,
 		Writer: d.stderr,
+		LogDir: d.buildLogsDir(),
 	}
 	err = d.appendExtraSubstituters(ctx, args)
 	if err != nil {
 		return err
 	}
 
+	d.recordBinaryCacheMetric(packages)
+
 	packageNames := lo.Map(
 		packages,
 		func(p *devpkg.Package, _ int) string { return p.Raw },
@@ -629,6 +737,11 @@ func (d *Devbox) packagesToInstallInStore(ctx context.Context, mode installMode)
 	// First, get and prepare all the packages that must be installed in this project
 	// and remove non-nix packages from the list
 	packages := lo.Filter(d.InstallablePackages(), devpkg.IsNix)
+	// Custom builds are built with the rest of the environment's flake, so
+	// don't build the unmodified package here.
+	packages = lo.Reject(packages, func(pkg *devpkg.Package, _ int) bool {
+		return pkg.IsCustomBuild()
+	})
 	if err := devpkg.FillNarInfoCache(ctx, packages...); err != nil {
 		return nil, err
 	}
@@ -680,7 +793,7 @@ func (d *Devbox) isBeingUpdated(pkg *devpkg.Package) bool {
 //
 // NOTE: ideally, this function would be in devconfig, but it leads to an import cycle with devpkg, so
 // leaving in this "top-level" devbox package where we can import devconfig, devpkg and lock.
-func (d *Devbox) moveAllowInsecureFromLockfile(writer io.Writer, lockfile *lock.File, cfg *devconfig.Config) error {
+func (d *Devbox) moveAllowInsecureFromLockfile(ctx context.Context, writer io.Writer, lockfile *lock.File, cfg *devconfig.Config) error {
 	if !lockfile.HasAllowInsecurePackages() {
 		return nil
 	}
@@ -696,7 +809,7 @@ func (d *Devbox) moveAllowInsecureFromLockfile(writer io.Writer, lockfile *lock.
 	// Set the devbox.json packages to allow_insecure
 	for _, versionedName := range insecurePackages {
 		pkg := devpkg.PackageFromStringWithDefaults(versionedName, lockfile)
-		storeName, err := pkg.StoreName()
+		storeName, err := pkg.StoreName(ctx)
 		if err != nil {
 			return fmt.Errorf("failed to get package's store name for package %q with error %w", versionedName, err)
 		}
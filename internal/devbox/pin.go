@@ -0,0 +1,106 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"context"
+	"runtime/trace"
+
+	"github.com/samber/lo"
+
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+	"go.jetify.com/devbox/internal/ux"
+)
+
+// Pin freezes packages at the version that devbox.lock resolved them to. The
+// package's version in devbox.json is replaced by the exact version, and the
+// version it had before is kept so that Unpin can restore it. Pinned packages
+// aren't updated by devbox update.
+func (d *Devbox) Pin(ctx context.Context, pkgs ...string) error {
+	ctx, task := trace.NewTask(ctx, "devboxPin")
+	defer task.End()
+
+	unlock, err := d.lockProject()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for _, name := range lo.Uniq(pkgs) {
+		pkg, err := d.findPackageByName(name)
+		if err != nil {
+			return err
+		}
+		if cfgPkg, _ := d.cfg.Root.GetPackage(pkg.Raw); cfgPkg != nil && cfgPkg.IsPinned() {
+			ux.Finfof(d.stderr, "%s is already pinned\n", pkg.Raw)
+			continue
+		}
+		if pkg.IsLegacy() || !pkg.IsDevboxPackage {
+			return usererr.New(
+				"Can't pin %s. Only versioned packages, such as go@1.22, can be pinned.", pkg.Raw)
+		}
+
+		locked, err := d.lockfile.Resolve(pkg.Raw)
+		if err != nil {
+			return err
+		}
+		if locked.Version == "" {
+			return usererr.New("Can't pin %s because devbox.lock doesn't have its version.", pkg.Raw)
+		}
+		pinned, err := d.cfg.PackageMutator().Pin(pkg.Raw, locked.Version)
+		if err != nil {
+			return err
+		}
+		// The pinned package resolves to the same version, so reuse the
+		// resolution instead of resolving it again.
+		d.lockfile.Packages[pinned] = locked
+		ux.Finfof(d.stderr, "Pinned %s to %s\n", pkg.Raw, pinned)
+	}
+
+	if err := d.ensureStateIsUpToDate(ctx, install); err != nil {
+		return err
+	}
+	return d.saveCfg()
+}
+
+// Unpin restores the version that packages had before they were pinned, so
+// that devbox update updates them again. It doesn't update them.
+func (d *Devbox) Unpin(ctx context.Context, pkgs ...string) error {
+	ctx, task := trace.NewTask(ctx, "devboxUnpin")
+	defer task.End()
+
+	unlock, err := d.lockProject()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for _, name := range lo.Uniq(pkgs) {
+		pkg, err := d.findPackageByName(name)
+		if err != nil {
+			return err
+		}
+		if cfgPkg, _ := d.cfg.Root.GetPackage(pkg.Raw); cfgPkg == nil || !cfgPkg.IsPinned() {
+			ux.Finfof(d.stderr, "%s isn't pinned\n", pkg.Raw)
+			continue
+		}
+
+		unpinned, err := d.cfg.PackageMutator().Unpin(pkg.Raw)
+		if err != nil {
+			return err
+		}
+		// The pinned version still satisfies the restored version, so keep
+		// using it until the package is updated.
+		if locked := d.lockfile.Get(pkg.Raw); locked != nil && d.lockfile.Get(unpinned) == nil {
+			d.lockfile.Packages[unpinned] = locked
+		}
+		ux.Finfof(d.stderr, "Unpinned %s to %s. Run `devbox update %s` to update it.\n",
+			pkg.Raw, unpinned, pkg.CanonicalName())
+	}
+
+	if err := d.ensureStateIsUpToDate(ctx, install); err != nil {
+		return err
+	}
+	return d.saveCfg()
+}
@@ -0,0 +1,120 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"runtime/trace"
+	"slices"
+
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+	"go.jetify.com/devbox/internal/devpkg"
+	"go.jetify.com/devbox/internal/nix"
+	"go.jetify.com/devbox/internal/plugin"
+	"go.jetify.com/devbox/internal/searcher"
+)
+
+// PackageInfo is the metadata of a package, as displayed by devbox info.
+// Fields that couldn't be determined are empty.
+type PackageInfo struct {
+	Name        string   `json:"name"`
+	Version     string   `json:"version"`
+	Description string   `json:"description"`
+	Homepage    string   `json:"homepage,omitempty"`
+	Licenses    []string `json:"licenses,omitempty"`
+	Unfree      bool     `json:"unfree"`
+
+	// Platforms are the systems that the package is available on.
+	Platforms []string `json:"platforms"`
+
+	// Outputs are the package's outputs on the current system.
+	Outputs []nix.PackageOutput `json:"outputs,omitempty"`
+
+	// ClosureSize is the size in bytes of the package's default outputs
+	// and everything they depend on, on the current system.
+	ClosureSize int64 `json:"closure_size,omitempty"`
+}
+
+// PackageInfo looks up pkg, such as go@1.22, in the search index and
+// evaluates the rest of its metadata with nix. It doesn't install the package.
+func (d *Devbox) PackageInfo(ctx context.Context, pkg string) (*PackageInfo, error) {
+	ctx, task := trace.NewTask(ctx, "devboxPackageInfo")
+	defer task.End()
+
+	name, version, isVersioned := searcher.ParseVersionedPackage(pkg)
+	if !isVersioned {
+		name = pkg
+		version = "latest"
+	}
+
+	packageVersion, err := searcher.Client().Resolve(ctx, name, version)
+	if err != nil {
+		return nil, usererr.WithUserMessage(err, "Package %q not found\n", pkg)
+	}
+	if packageVersion == nil {
+		return nil, usererr.New("Package %q not found", pkg)
+	}
+
+	info := &PackageInfo{
+		Name:        packageVersion.Name,
+		Version:     packageVersion.Version,
+		Description: packageVersion.Summary,
+		Platforms:   slices.Sorted(maps.Keys(packageVersion.Systems)),
+	}
+
+	sysInfo, ok := packageVersion.Systems[nix.System()]
+	if !ok || len(sysInfo.AttrPaths) == 0 {
+		// The package isn't available on this system, so there's
+		// nothing to evaluate.
+		return info, nil
+	}
+	installable, ok := nixpkgsEvalInstallable(
+		fmt.Sprintf("github:NixOS/nixpkgs/%s#%s", sysInfo.CommitHash, sysInfo.AttrPaths[0]),
+		nix.System(),
+	)
+	if !ok {
+		return info, nil
+	}
+
+	if meta, err := nix.EvalPackageMeta(ctx, installable); err != nil {
+		slog.Debug("failed to evaluate the meta attributes of a package", "pkg", pkg, "err", err)
+	} else {
+		info.Description = lo.Ternary(meta.Description != "", meta.Description, info.Description)
+		info.Homepage = meta.Homepage
+		info.Licenses = meta.Licenses
+		info.Unfree = meta.Unfree
+	}
+
+	outputs, err := nix.EvalPackageOutputs(ctx, installable, true)
+	if err != nil {
+		slog.Debug("failed to evaluate the outputs of a package", "pkg", pkg, "err", err)
+		return info, nil
+	}
+	info.Outputs = outputs
+
+	defaults := lo.FilterMap(outputs, func(o nix.PackageOutput, _ int) (string, bool) {
+		return o.Path, o.Default
+	})
+	if info.ClosureSize, err = nix.ClosureSize(ctx, defaults); err != nil {
+		slog.Debug("failed to get the closure size of a package", "pkg", pkg, "err", err)
+	}
+	return info, nil
+}
+
+// PackageNotes returns the notes of the plugin of pkg, if it has one.
+func (d *Devbox) PackageNotes(ctx context.Context, pkg string, markdown bool) (string, error) {
+	notes, err := plugin.Readme(
+		ctx,
+		devpkg.PackageFromStringWithDefaults(pkg, d.lockfile),
+		d.projectDir,
+		markdown,
+	)
+	return notes, errors.WithStack(err)
+}
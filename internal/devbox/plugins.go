@@ -0,0 +1,162 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"cmp"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+	"go.jetify.com/devbox/internal/devconfig"
+	"go.jetify.com/devbox/internal/plugin"
+	"go.jetify.com/devbox/internal/ux"
+)
+
+// LinkDevPlugin puts the local plugin at pluginPath into development mode for
+// the project whose config is in dir. It returns the plugin's name.
+//
+// Unlike the other plugin functions, this doesn't require opening the project,
+// because a broken plugin under development would prevent that.
+func LinkDevPlugin(dir, pluginPath string) (string, error) {
+	projectDir, err := findProjectDir(dir)
+	if err != nil {
+		return "", err
+	}
+	return plugin.LinkDevPlugin(projectDir, pluginPath)
+}
+
+// UnlinkDevPlugin takes a plugin out of development mode.
+func UnlinkDevPlugin(dir, nameOrPath string) error {
+	projectDir, err := findProjectDir(dir)
+	if err != nil {
+		return err
+	}
+	return plugin.UnlinkDevPlugin(projectDir, nameOrPath)
+}
+
+// ScaffoldPlugin creates a new plugin called name in dir.
+func ScaffoldPlugin(dir, name string) error {
+	return plugin.Scaffold(dir, name)
+}
+
+// ValidatePlugin checks the plugin referenced by includableRef and returns a
+// list of problems with it. Relative paths are resolved against the current
+// directory.
+func ValidatePlugin(includableRef string) ([]string, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return plugin.Validate(includableRef, wd)
+}
+
+// findProjectDir locates the project directory the same way Open does, but
+// without loading the project's plugins.
+func findProjectDir(dir string) (string, error) {
+	var cfg *devconfig.Config
+	var err error
+	if dir == "" {
+		cfg, err = devconfig.Find(".")
+	} else {
+		cfg, err = devconfig.Open(dir)
+	}
+	if errors.Is(err, devconfig.ErrNotFound) {
+		return "", usererr.New("no devbox.json found. Did you run `devbox init` yet?")
+	}
+	if err != nil {
+		return "", err
+	}
+	return filepath.Dir(cfg.Root.AbsRootPath), nil
+}
+
+// Plugins returns a summary of every plugin active in the project, including
+// builtin plugins triggered by packages and plugins included by other plugins.
+func (d *Devbox) Plugins() ([]*plugin.Summary, error) {
+	summaries := []*plugin.Summary{}
+	for _, cfg := range d.cfg.IncludedPluginConfigs() {
+		summary, err := cfg.Summarize()
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, summary)
+	}
+	slices.SortStableFunc(summaries, func(a, b *plugin.Summary) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return summaries, nil
+}
+
+// Plugin returns a summary of the active plugin with the given name.
+func (d *Devbox) Plugin(name string) (*plugin.Summary, error) {
+	summaries, err := d.Plugins()
+	if err != nil {
+		return nil, err
+	}
+	for _, summary := range summaries {
+		if summary.Name == name {
+			return summary, nil
+		}
+	}
+	return nil, usererr.New(
+		"plugin %q is not active in this project. Run `devbox plugin list` to see active plugins", name)
+}
+
+// OrphanedPluginFiles returns the files created by plugins that are no longer
+// part of the project.
+func (d *Devbox) OrphanedPluginFiles() ([]plugin.CreatedFile, error) {
+	return d.pluginManager.OrphanedFiles(d.cfg.IncludedPluginConfigs())
+}
+
+// RemovePluginFiles deletes files that were created by plugins.
+func (d *Devbox) RemovePluginFiles(files []plugin.CreatedFile) error {
+	return d.pluginManager.RemoveCreatedFiles(files)
+}
+
+// RestorePluginFiles creates the files of the active plugin with the given
+// name again, overwriting the changes that were made to them. It returns the
+// paths of the files.
+func (d *Devbox) RestorePluginFiles(name string) ([]string, error) {
+	for _, cfg := range d.cfg.IncludedPluginConfigs() {
+		if cmp.Or(cfg.Name, cfg.Source.CanonicalName()) == name {
+			return d.pluginManager.RestoreCreatedFiles(cfg)
+		}
+	}
+	return nil, usererr.New(
+		"plugin %q is not active in this project. Run `devbox plugin list` to see active plugins", name)
+}
+
+// cleanupOrphanedPluginFiles deletes files that removed plugins created in
+// .devbox, since those are always safe to recreate. Orphaned files in the rest
+// of the project may have been edited by the user, so they are only deleted
+// with `devbox plugin clean`. If warn is true, the user is told about them.
+func (d *Devbox) cleanupOrphanedPluginFiles(warn bool) error {
+	orphans, err := d.OrphanedPluginFiles()
+	if err != nil {
+		return err
+	}
+	hidden, visible := lo.FilterReject(orphans, func(f plugin.CreatedFile, _ int) bool {
+		return f.IsHidden(d.projectDir)
+	})
+	if err := d.RemovePluginFiles(hidden); err != nil {
+		return err
+	}
+	if warn && len(visible) > 0 {
+		paths := lo.Map(visible, func(f plugin.CreatedFile, _ int) string {
+			rel, _ := filepath.Rel(d.projectDir, f.Path)
+			return "  " + rel
+		})
+		ux.Finfof(
+			d.stderr,
+			"The following files were created by plugins that are no longer in "+
+				"your project:\n%s\nRun `devbox plugin clean` to delete them.\n",
+			strings.Join(paths, "\n"),
+		)
+	}
+	return nil
+}
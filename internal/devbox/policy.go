@@ -0,0 +1,334 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+	"go.jetify.com/devbox/internal/cuecfg"
+	"go.jetify.com/devbox/internal/devconfig"
+	"go.jetify.com/devbox/internal/devconfig/configfile"
+	"go.jetify.com/devbox/internal/devpkg"
+	"go.jetify.com/devbox/internal/devpkg/pkgtype"
+	"go.jetify.com/devbox/internal/httpclient"
+	"go.jetify.com/devbox/internal/lock"
+)
+
+// policyCheck is the result of checking a package against one rule of an org
+// policy.
+type policyCheck struct {
+	policy string
+	pkg    string
+	rule   string
+	ok     bool
+	detail string
+}
+
+func (c policyCheck) String() string {
+	return fmt.Sprintf("  - %s violates the %s rule of the policy in %s: %s", c.pkg, c.rule, c.policy, c.detail)
+}
+
+// checkPolicy checks pkgs against the org policies of the project and the
+// plugins it includes, and returns an error listing the violations. With
+// explain, it prints every rule that each package was checked against.
+func (d *Devbox) checkPolicy(ctx context.Context, pkgs []*devpkg.Package, explain bool) error {
+	sources := d.cfg.Policies()
+	if len(sources) == 0 {
+		if explain {
+			fmt.Fprintln(d.stderr, "No org policy applies to this project.")
+		}
+		return nil
+	}
+
+	checks := []policyCheck{}
+	for _, source := range sources {
+		rules, err := loadPolicyRules(ctx, source)
+		if err != nil {
+			return err
+		}
+		name := policyName(source)
+		for _, pkg := range pkgs {
+			for _, check := range d.evalPolicy(rules, pkg) {
+				check.policy = name
+				checks = append(checks, check)
+			}
+		}
+	}
+	if explain {
+		printPolicyChecks(d.stderr, checks)
+	}
+
+	violations := []string{}
+	for _, check := range checks {
+		if !check.ok {
+			violations = append(violations, check.String())
+		}
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+	msg := "Some packages violate the org policy:\n%s"
+	if !explain {
+		msg += "\n\nRun the command again with --explain to see every rule that the packages were checked against."
+	}
+	return usererr.New(msg, strings.Join(violations, "\n"))
+}
+
+// evalPolicy checks pkg against each rule that rules set. It only resolves
+// pkg if a rule needs its locked version or revision.
+func (d *Devbox) evalPolicy(rules *configfile.PolicyRules, pkg *devpkg.Package) []policyCheck {
+	var locked *lock.Package
+	resolved := false
+	resolve := func() *lock.Package {
+		if !resolved {
+			resolved = true
+			var err error
+			if locked, err = d.lockfile.Resolve(pkg.Raw); err != nil {
+				// Installing will report the error if it's real.
+				slog.Debug("failed to resolve a package to check it against the policy", "pkg", pkg.Raw, "err", err)
+			}
+		}
+		return locked
+	}
+
+	checks := []policyCheck{}
+	if len(rules.Banned) > 0 {
+		check := policyCheck{pkg: pkg.Raw, rule: "banned", ok: true, detail: "not banned"}
+		name, version := policyPackageName(pkg), requestedVersion(pkg)
+		if needsLockedVersion(rules.Banned, name) {
+			if locked := resolve(); locked != nil && locked.Version != "" {
+				version = locked.Version
+			}
+		}
+		if key, reason, banned := bannedBy(rules.Banned, name, version); banned {
+			check.ok = false
+			check.detail = fmt.Sprintf("%s is banned", key)
+			if reason != "" {
+				check.detail += " (" + reason + ")"
+			}
+		}
+		checks = append(checks, check)
+	}
+
+	if maxAge, _ := rules.MaxAge(); maxAge > 0 {
+		check := policyCheck{pkg: pkg.Raw, rule: "max_package_age", ok: true}
+		modified := time.Time{}
+		if locked := resolve(); locked != nil {
+			modified, _ = time.Parse(time.RFC3339, locked.LastModified)
+		}
+		if modified.IsZero() {
+			check.detail = "skipped, the package doesn't resolve to a dated revision"
+		} else {
+			days := int(time.Since(modified).Hours() / 24)
+			check.detail = fmt.Sprintf("resolves to a revision from %s, %d days ago", modified.Format(time.DateOnly), days)
+			if time.Since(modified) > maxAge {
+				check.ok = false
+				check.detail += ", which is older than " + rules.MaxPackageAge
+			}
+		}
+		checks = append(checks, check)
+	}
+
+	if len(rules.Registries) > 0 {
+		registry := d.packageRegistry(pkg)
+		check := policyCheck{pkg: pkg.Raw, rule: "registries", ok: true}
+		if registryAllowed(rules.Registries, registry, pkg.Raw) {
+			check.detail = fmt.Sprintf("comes from %s, which is allowed", registry)
+		} else {
+			check.ok = false
+			check.detail = fmt.Sprintf("comes from %s, which isn't one of %s", registry, strings.Join(rules.Registries, ", "))
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// packageRegistry returns where pkg comes from: nixpkgs, runx, the name of a
+// package source, or "flake" for other flake references and URLs.
+func (d *Devbox) packageRegistry(pkg *devpkg.Package) string {
+	if pkg.IsRunX() {
+		return "runx"
+	}
+	if source, ok := pkgtype.ParseSourcePackage(pkg.Raw, d.PackageSources()); ok {
+		return source.Source
+	}
+	if pkg.IsDevboxPackage {
+		return "nixpkgs"
+	}
+	return "flake"
+}
+
+// registryAllowed reports whether a package from registry is allowed by
+// registries. Flake references are allowed by a registry that starts them,
+// such as github:my-org/.
+func registryAllowed(registries []string, registry, raw string) bool {
+	if slices.Contains(registries, registry) {
+		return true
+	}
+	if registry != "flake" {
+		return false
+	}
+	return slices.ContainsFunc(registries, func(prefix string) bool {
+		return strings.HasPrefix(raw, prefix)
+	})
+}
+
+// bannedBy returns the entry of banned that bans the package name at version.
+// An entry without a version bans every version, and an entry such as
+// python@2 bans 2 and 2.x but not 20.
+func bannedBy(banned map[string]string, name, version string) (key, reason string, ok bool) {
+	keys := make([]string, 0, len(banned))
+	for k := range banned {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		bannedName, bannedVersion, _ := strings.Cut(key, "@")
+		if bannedName != name {
+			continue
+		}
+		if bannedVersion == "" || version == bannedVersion || strings.HasPrefix(version, bannedVersion+".") {
+			return key, banned[key], true
+		}
+	}
+	return "", "", false
+}
+
+// needsLockedVersion reports whether banned has an entry for a specific
+// version of name, so that the version a package resolves to matters.
+func needsLockedVersion(banned map[string]string, name string) bool {
+	for key := range banned {
+		if bannedName, bannedVersion, _ := strings.Cut(key, "@"); bannedName == name && bannedVersion != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// policyPackageName is the name that a policy bans pkg by, such as "python"
+// for python@3.12.
+func policyPackageName(pkg *devpkg.Package) string {
+	if name := pkg.CanonicalName(); name != "" {
+		return name
+	}
+	return pkg.Raw
+}
+
+// requestedVersion is the version in devbox.json, such as 3.12 for
+// python@3.12. It's empty if the package doesn't have one.
+func requestedVersion(pkg *devpkg.Package) string {
+	if !pkg.IsDevboxPackage {
+		return ""
+	}
+	_, version, _ := strings.Cut(pkg.Raw, "@")
+	return version
+}
+
+func policyName(source devconfig.PolicySource) string {
+	if source.Policy.Source == "" {
+		return source.Name
+	}
+	return fmt.Sprintf("%s (%s)", source.Name, source.Policy.Source)
+}
+
+// loadPolicyRules returns the rules of an org policy, reading them from its
+// policy file or URL if the config doesn't set them inline. Policy files are
+// data in the same formats as other config files. They can't contain CUE
+// constraints, because devbox evaluates the rules itself.
+func loadPolicyRules(ctx context.Context, source devconfig.PolicySource) (*configfile.PolicyRules, error) {
+	if source.Policy.Rules != nil {
+		return source.Policy.Rules, nil
+	}
+
+	var data []byte
+	var err error
+	ext := ""
+	if u, parseErr := url.Parse(source.Policy.Source); parseErr == nil && u.Scheme == "https" {
+		ext = path.Ext(u.Path)
+		data, err = fetchPolicy(ctx, source.Policy.Source)
+	} else {
+		file := source.Policy.Source
+		if !filepath.IsAbs(file) {
+			if source.Dir == "" {
+				return nil, usererr.New(
+					"The policy of %s is a relative path, which only works in local files. Use an https URL instead.",
+					source.Name)
+			}
+			file = filepath.Join(source.Dir, file)
+		}
+		ext = filepath.Ext(file)
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return nil, usererr.WithUserMessage(err, "Unable to load the policy of %s.", policyName(source))
+	}
+
+	if ext == "" {
+		ext = ".json"
+	}
+	if ext == ".cue" {
+		return nil, usererr.New(
+			"The policy of %s is a CUE file, which isn't supported. Write the policy rules as JSON, YAML or TOML.",
+			policyName(source))
+	}
+	rules := &configfile.PolicyRules{}
+	if err := cuecfg.Unmarshal(data, ext, rules); err != nil {
+		return nil, usererr.WithUserMessage(err, "The policy of %s is invalid.", policyName(source))
+	}
+	if _, err := rules.MaxAge(); err != nil {
+		return nil, usererr.WithUserMessage(err, "The policy of %s is invalid.", policyName(source))
+	}
+	return rules, nil
+}
+
+func fetchPolicy(ctx context.Context, policyURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, policyURL, nil)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	res, err := httpclient.Client().Do(req)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("GET %s: %s", policyURL, res.Status)
+	}
+	data, err := io.ReadAll(res.Body)
+	return data, errors.WithStack(err)
+}
+
+func printPolicyChecks(w io.Writer, checks []policyCheck) {
+	policy := ""
+	tw := tabwriter.NewWriter(w, 3, 2, 2, ' ', 0)
+	for _, check := range checks {
+		if check.policy != policy {
+			if policy != "" {
+				fmt.Fprintln(tw)
+			}
+			policy = check.policy
+			fmt.Fprintf(tw, "Policy in %s:\n", policy)
+		}
+		result := "ok"
+		if !check.ok {
+			result = "VIOLATION"
+		}
+		fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\n", check.pkg, check.rule, result, check.detail)
+	}
+	tw.Flush()
+}
@@ -0,0 +1,52 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import "testing"
+
+func TestBannedBy(t *testing.T) {
+	banned := map[string]string{
+		"python@2": "Python 2 is end of life",
+		"left-pad": "",
+	}
+	cases := []struct {
+		name, version string
+		wantKey       string
+	}{
+		{"python", "2", "python@2"},
+		{"python", "2.7.18", "python@2"},
+		{"python", "20.1", ""},
+		{"python", "3.12", ""},
+		{"python", "", ""},
+		{"left-pad", "1.3.0", "left-pad"},
+		{"left-pad", "", "left-pad"},
+		{"nodejs", "20", ""},
+	}
+	for _, tc := range cases {
+		key, _, ok := bannedBy(banned, tc.name, tc.version)
+		if ok != (tc.wantKey != "") || key != tc.wantKey {
+			t.Errorf("bannedBy(%q, %q) = %q, %v, want %q", tc.name, tc.version, key, ok, tc.wantKey)
+		}
+	}
+}
+
+func TestRegistryAllowed(t *testing.T) {
+	registries := []string{"nixpkgs", "mycorp", "github:my-org/"}
+	cases := []struct {
+		registry, raw string
+		want          bool
+	}{
+		{"nixpkgs", "python@3.12", true},
+		{"runx", "runx:golangci/golangci-lint@latest", false},
+		{"mycorp", "mycorp#hello", true},
+		{"flake", "github:my-org/tools#lint", true},
+		{"flake", "github:other-org/tools#lint", false},
+		{"flake", "path:./flake#hello", false},
+	}
+	for _, tc := range cases {
+		if got := registryAllowed(registries, tc.registry, tc.raw); got != tc.want {
+			t.Errorf("registryAllowed(%q, %q) = %v, want %v", tc.registry, tc.raw, got, tc.want)
+		}
+	}
+}
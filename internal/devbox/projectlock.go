@@ -0,0 +1,65 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"path/filepath"
+	"sync"
+
+	"go.jetify.com/devbox/internal/projectlock"
+	"go.jetify.com/devbox/internal/ux"
+)
+
+// projectLocks are the project locks that this process holds, by project
+// directory. flock locks belong to open files, so taking the lock again
+// through another file in the same process would wait forever.
+var projectLocks = struct {
+	sync.Mutex
+	held  map[string]int
+	locks map[string]*projectlock.Lock
+}{held: map[string]int{}, locks: map[string]*projectlock.Lock{}}
+
+// lockProject takes the project's lock, which is held while devbox.lock or the
+// project's profile change, so that concurrent devbox commands, including
+// ones from other users of a shared project, take turns. It waits if another
+// process holds the lock. The lockfile is read again when the lock is taken,
+// because the process that held it might have changed it. Call the returned
+// function to release the lock.
+func (d *Devbox) lockProject() (func(), error) {
+	projectLocks.Lock()
+	defer projectLocks.Unlock()
+
+	if projectLocks.held[d.projectDir] > 0 {
+		projectLocks.held[d.projectDir]++
+		return d.unlockProject, nil
+	}
+
+	path := filepath.Join(d.projectDir, ".devbox", "project.lock")
+	lock, err := projectlock.Acquire(path, func(holder projectlock.Holder) {
+		ux.Finfof(d.stderr, "Waiting for %s to finish with this project\n", holder)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := d.lockfile.Reload(); err != nil {
+		_ = lock.Release()
+		return nil, err
+	}
+	projectLocks.held[d.projectDir] = 1
+	projectLocks.locks[d.projectDir] = lock
+	return d.unlockProject, nil
+}
+
+func (d *Devbox) unlockProject() {
+	projectLocks.Lock()
+	defer projectLocks.Unlock()
+
+	projectLocks.held[d.projectDir]--
+	if projectLocks.held[d.projectDir] > 0 {
+		return
+	}
+	_ = projectLocks.locks[d.projectDir].Release()
+	delete(projectLocks.held, d.projectDir)
+	delete(projectLocks.locks, d.projectDir)
+}
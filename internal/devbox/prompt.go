@@ -0,0 +1,77 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	"go.jetify.com/devbox/internal/devconfig"
+	"go.jetify.com/devbox/internal/lock"
+)
+
+// PromptStatus is the state of a project that `devbox prompt` shows in a
+// shell prompt.
+type PromptStatus struct {
+	// Name is the project's name in devbox.json, or the name of its
+	// directory.
+	Name       string `json:"name"`
+	ProjectDir string `json:"project_dir"`
+
+	// Active is true in a devbox shell of the project.
+	Active bool `json:"active"`
+
+	// Dirty is true when devbox.json or devbox.lock changed since the
+	// environment was last brought up to date.
+	Dirty bool `json:"dirty"`
+
+	// Environment is the environment (dev, prod or preview) that the active
+	// shell was started with. It's empty outside a devbox shell.
+	Environment string `json:"environment,omitempty"`
+}
+
+// Prompt returns the status of the project in dir, or of the project of the
+// active devbox shell if dir is empty, falling back to searching the current
+// directory and its parents. Unlike Open, it doesn't load plugins or compute
+// the environment, so it's fast enough to run on every shell prompt. It
+// returns nil if there's no project.
+func Prompt(dir string) (*PromptStatus, error) {
+	if dir == "" {
+		dir = os.Getenv("DEVBOX_PROJECT_ROOT")
+	}
+
+	var cfg *devconfig.Config
+	var err error
+	if dir == "" {
+		cfg, err = devconfig.Find(".")
+	} else {
+		cfg, err = devconfig.Open(dir)
+	}
+	if errors.Is(err, devconfig.ErrNotFound) || errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	projectDir := filepath.Dir(cfg.Root.AbsRootPath)
+	status := &PromptStatus{
+		Name:       cfg.Root.Name,
+		ProjectDir: projectDir,
+		Active:     os.Getenv("DEVBOX_PROJECT_ROOT") == projectDir,
+	}
+	if status.Name == "" {
+		status.Name = filepath.Base(projectDir)
+	}
+	if status.Active {
+		status.Environment = os.Getenv("DEVBOX_ENVIRONMENT")
+	}
+	status.Dirty, err = lock.IsStale(projectDir, cfg.Root.AbsRootPath)
+	if err != nil {
+		return nil, err
+	}
+	return status, nil
+}
@@ -0,0 +1,62 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+
+	"go.jetify.com/devbox/internal/build"
+	"go.jetify.com/devbox/internal/nix"
+	"go.jetify.com/devbox/internal/provenance"
+)
+
+// Provenance installs the project's packages and returns a statement that
+// binds devbox.json, devbox.lock and the project's plugins to the nix store
+// paths that they install.
+func (d *Devbox) Provenance(ctx context.Context) (*provenance.Statement, error) {
+	if err := d.ensureStateIsUpToDate(ctx, ensure); err != nil {
+		return nil, err
+	}
+
+	subjects := []provenance.Subject{}
+	for _, path := range []string{d.cfg.Root.AbsRootPath, filepath.Join(d.projectDir, "devbox.lock")} {
+		subject, err := provenance.FileSubject(filepath.Base(path), path)
+		if err != nil {
+			return nil, err
+		}
+		subjects = append(subjects, subject)
+	}
+
+	plugins := []provenance.Plugin{}
+	for _, cfg := range d.cfg.IncludedPluginConfigs() {
+		hash, err := cfg.Hash()
+		if err != nil {
+			return nil, err
+		}
+		ref := cfg.Name
+		if cfg.Source != nil {
+			ref = cfg.Source.LockfileKey()
+		}
+		plugins = append(plugins, provenance.Plugin{Ref: ref, Version: cfg.Version, Hash: hash})
+	}
+
+	storePaths := []string{}
+	for _, pkg := range d.InstallablePackages() {
+		paths, err := pkg.GetStorePaths(ctx, d.stderr)
+		if err != nil {
+			return nil, err
+		}
+		storePaths = append(storePaths, paths...)
+	}
+
+	return provenance.NewStatement(subjects, provenance.Predicate{
+		DevboxVersion: build.Version,
+		System:        nix.System(),
+		Plugins:       plugins,
+		StorePaths:    storePaths,
+		CreatedAt:     time.Now().UTC(),
+	}), nil
+}
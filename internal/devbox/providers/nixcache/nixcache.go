@@ -16,6 +16,7 @@
 	"go.jetify.com/devbox/internal/devbox/providers/identity"
 	"go.jetify.com/devbox/internal/goutil"
 	"go.jetify.com/devbox/internal/redact"
+	"go.jetify.com/devbox/internal/state"
 	"go.jetify.com/pkg/api"
 	nixv1alpha1 "go.jetify.com/pkg/api/gen/priv/nix/v1alpha1"
 	"go.jetify.com/pkg/auth"
@@ -27,10 +28,10 @@
 	func(ctx context.Context) (AWSCredentials, error) {
 		// Adding version to caches to avoid conflicts if we want to update the schema
 		// or while working on dev.
-		cache := filecache.New[AWSCredentials](fmt.Sprintf(
-			"devbox/%s/providers/nixcache",
-			build.Version,
-		))
+		cache := filecache.New(
+			fmt.Sprintf("devbox/%s/providers/nixcache", build.Version),
+			filecache.WithCacheDir[AWSCredentials](state.FileCacheDir()),
+		)
 		token, err := identity.GenSession(ctx)
 		if err != nil {
 			return AWSCredentials{}, err
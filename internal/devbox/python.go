@@ -0,0 +1,118 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"cmp"
+	"context"
+	"path/filepath"
+	"slices"
+
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+	"go.jetify.com/devbox/internal/devpkg"
+	"go.jetify.com/devbox/internal/envir"
+	"go.jetify.com/devbox/internal/fileutil"
+	"go.jetify.com/devbox/internal/nix"
+	"go.jetify.com/devbox/internal/python"
+	"go.jetify.com/devbox/internal/ux"
+)
+
+// PythonVenv returns the project's Python virtual environment. It returns an
+// error if the project doesn't have a Python interpreter package, or the
+// package's plugin is disabled.
+func (d *Devbox) PythonVenv() (*python.Venv, error) {
+	venv := d.pythonVenv(d.cfg.Env())
+	if venv == nil {
+		return nil, usererr.New(
+			"This project doesn't have a Python package. Add one with `devbox add python`.")
+	}
+	return venv, nil
+}
+
+// EnsurePythonVenv installs the project's packages and then creates or
+// updates its Python virtual environment. If recreate is true, an existing
+// virtual environment is replaced even if it's up to date.
+func (d *Devbox) EnsurePythonVenv(ctx context.Context, recreate bool) (*python.Venv, error) {
+	venv, err := d.PythonVenv()
+	if err != nil {
+		return nil, err
+	}
+	if err := d.Install(ctx); err != nil {
+		return nil, err
+	}
+	if recreate {
+		ux.Finfof(d.stderr, "Recreating the Python virtual environment in %s\n", venv.Dir)
+		return venv, venv.Create(ctx, d.stderr)
+	}
+	_, err = venv.Ensure(ctx, d.stderr)
+	return venv, err
+}
+
+// pythonVenv returns the virtual environment for the project's Python
+// interpreter, or nil if it doesn't have one. Devbox manages the virtual
+// environment in place of the python plugin, so disabling the plugin also
+// disables the virtual environment. The directory is $VENV_DIR in env.
+func (d *Devbox) pythonVenv(env map[string]string) *python.Venv {
+	hasPython := slices.ContainsFunc(d.InstallablePackages(), func(pkg *devpkg.Package) bool {
+		return !pkg.DisablePlugin && python.IsInterpreter(pkg.CanonicalName())
+	})
+	if !hasPython {
+		return nil
+	}
+	return &python.Venv{
+		Dir:    cmp.Or(env["VENV_DIR"], filepath.Join(d.projectDir, ".venv")),
+		Python: filepath.Join(nix.ProfileBinPath(d.projectDir), "python3"),
+	}
+}
+
+// syncPythonVenv creates the project's Python virtual environment if it's
+// missing, or recreates it if the project's Python changed. Creating it runs
+// the project's Python interpreter, so it's only done if the user trusts the
+// project. Failures are warnings, because the environment works without it.
+func (d *Devbox) syncPythonVenv(ctx context.Context) {
+	venv := d.pythonVenv(d.cfg.Env())
+	// Python 2 interpreters don't have python3 or venv.
+	if venv == nil || !fileutil.Exists(venv.Python) {
+		return
+	}
+	if status, err := venv.Status(); err == nil && status != python.StatusMissing && status != python.StatusStale {
+		return
+	}
+	if !envir.IsTrustPromptDisabled() {
+		if trusted, err := d.IsTrusted(); err != nil || !trusted {
+			ux.Finfof(d.stderr, "Run `devbox trust` to let devbox create the Python virtual environment of %s.\n",
+				d.projectDir)
+			return
+		}
+	}
+
+	status, err := venv.Ensure(ctx, d.stderr)
+	if err != nil {
+		ux.Fwarningf(d.stderr, "Unable to set up the Python virtual environment: %v\n", err)
+		return
+	}
+	if status == python.StatusMissing {
+		tool := python.DetectTool(d.projectDir)
+		if install := tool.InstallCommand(d.projectDir); install != "" {
+			ux.Finfof(d.stderr, "Run `%s` to install your project's dependencies.\n", install)
+		}
+	}
+}
+
+// activatePythonVenv activates the project's Python virtual environment in
+// env, if it exists. It returns the virtual environment's bin directory to add
+// to the PATH, or an empty string if there isn't one. It doesn't create the
+// virtual environment, which syncPythonVenv does when the packages are
+// installed and shells start.
+func (d *Devbox) activatePythonVenv(env map[string]string) string {
+	venv := d.pythonVenv(env)
+	if venv == nil {
+		return ""
+	}
+	if status, err := venv.Status(); err != nil || status == python.StatusMissing {
+		return ""
+	}
+	env["VIRTUAL_ENV"] = venv.Dir
+	return venv.BinDir()
+}
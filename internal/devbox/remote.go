@@ -0,0 +1,64 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"context"
+	"maps"
+	"os"
+	"slices"
+
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+	"go.jetify.com/devbox/internal/devbox/devopt"
+	"go.jetify.com/devbox/internal/remote"
+	"go.jetify.com/devbox/internal/ux"
+)
+
+// RunRemote runs a script or command on a remote machine, such as
+// ssh://user@host or the project's VM, instead of locally. The project is copied to the machine,
+// which installs the packages in the lockfile before it runs the command.
+func (d *Devbox) RunRemote(
+	ctx context.Context,
+	opts devopt.RemoteOpts,
+	envOpts devopt.EnvOptions,
+	cmdName string,
+	cmdArgs []string,
+) error {
+	t, err := d.remoteTarget(opts.Target)
+	if err != nil {
+		return err
+	}
+	t.InstallDevbox = t.InstallDevbox || opts.InstallDevbox
+
+	args := []string{"run", "--environment", d.environment}
+	if envOpts.Pure {
+		args = append(args, "--pure")
+	}
+	for _, name := range slices.Sorted(maps.Keys(d.env)) {
+		args = append(args, "--env", name+"="+d.env[name])
+	}
+	for _, name := range d.unsetEnv {
+		args = append(args, "--unset-env", name)
+	}
+	args = append(args, "--", cmdName)
+	args = append(args, cmdArgs...)
+
+	ux.Finfof(d.stderr, "Running %s on %s\n", cmdName, t.Host)
+	return remote.Run(ctx, os.Stdout, d.stderr, t, d.projectDir, args...)
+}
+
+// remoteTarget parses a remote target. The target "vm" is the project's VM.
+func (d *Devbox) remoteTarget(target string) (*remote.Target, error) {
+	if target != "vm" {
+		return remote.ParseTarget(target)
+	}
+	m, err := d.VM()
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return nil, usererr.New("This project doesn't have a VM. Create one with `devbox vm up`.")
+	}
+	return m.Target(), nil
+}
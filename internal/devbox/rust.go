@@ -0,0 +1,96 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"context"
+	"maps"
+	"path/filepath"
+
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+	"go.jetify.com/devbox/internal/cachehash"
+	"go.jetify.com/devbox/internal/devbox/devopt"
+	"go.jetify.com/devbox/internal/lock"
+	"go.jetify.com/devbox/internal/rust"
+	"go.jetify.com/devbox/internal/ux"
+)
+
+// AddRustToolchain adds the Rust toolchain from the project's
+// rust-toolchain.toml file to devbox.json, replacing any toolchain that was
+// added before. The toolchain comes from the rust-overlay flake, with the
+// file's components and targets as overrides, and the lockfile records the
+// toolchain file so that Devbox can tell when it changes.
+func (d *Devbox) AddRustToolchain(ctx context.Context, opts devopt.AddOpts) error {
+	path := rust.FindToolchainFile(d.projectDir)
+	if path == "" {
+		return usererr.New(
+			"This project doesn't have a rust-toolchain.toml file. Add one, or add rust with `devbox add rustc cargo`.")
+	}
+	toolchain, err := rust.ParseToolchainFile(path)
+	if err != nil {
+		return err
+	}
+	installable, err := toolchain.Installable()
+	if err != nil {
+		return err
+	}
+	hash, err := cachehash.File(path)
+	if err != nil {
+		return err
+	}
+
+	// Remove the previous toolchain without installing, so that Add starts
+	// from a clean package entry and the file's overrides replace the old
+	// ones instead of merging with them.
+	for _, pkg := range d.cfg.Root.TopLevelPackages() {
+		if rust.IsToolchainPackage(pkg.VersionedName()) {
+			d.cfg.PackageMutator().Remove(pkg.VersionedName())
+		}
+	}
+
+	override := toolchain.Override()
+	if override != nil {
+		maps.Copy(override, opts.Override)
+		opts.Override = override
+	}
+	ux.Finfof(d.stderr, "Using Rust toolchain %q from %s\n", toolchain.Channel, filepath.Base(path))
+	if err := d.Add(ctx, []string{installable}, opts); err != nil {
+		return err
+	}
+
+	relPath, err := filepath.Rel(d.projectDir, path)
+	if err != nil {
+		return err
+	}
+	d.lockfile.SetToolchain(installable, &lock.Toolchain{
+		File:    filepath.ToSlash(relPath),
+		Channel: toolchain.Channel,
+		Hash:    hash,
+	})
+	return d.lockfile.Save()
+}
+
+// warnIfRustToolchainChanged warns if a Rust toolchain file changed since its
+// toolchain was added to devbox.json.
+func (d *Devbox) warnIfRustToolchainChanged() {
+	for _, pkg := range d.TopLevelPackages() {
+		if !rust.IsToolchainPackage(pkg.Raw) {
+			continue
+		}
+		entry := d.lockfile.Get(pkg.LockfileKey())
+		if entry == nil || entry.Toolchain == nil {
+			continue
+		}
+		hash, err := cachehash.File(filepath.Join(d.projectDir, entry.Toolchain.File))
+		if err == nil && hash == entry.Toolchain.Hash {
+			continue
+		}
+		ux.Fwarningf(
+			d.stderr,
+			"%s changed since the Rust toolchain was added to devbox.json. "+
+				"Run `devbox add rust --from-toolchain-file` to update it.\n",
+			entry.Toolchain.File,
+		)
+	}
+}
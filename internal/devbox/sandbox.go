@@ -0,0 +1,62 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"os"
+	"path/filepath"
+
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+	"go.jetify.com/devbox/internal/devbox/devopt"
+	"go.jetify.com/devbox/internal/nix"
+	"go.jetify.com/devbox/internal/sandbox"
+)
+
+// sandboxWrapper returns the wrapper that runs cmdName in a sandbox, or nil
+// if it doesn't run in one. env is the environment that it runs with.
+func (d *Devbox) sandboxWrapper(
+	cmdName string,
+	env map[string]string,
+	opts devopt.Sandbox,
+) (nix.CommandWrapper, error) {
+	policy, err := d.sandboxPolicy(cmdName, opts)
+	if err != nil || policy == nil {
+		return nil, err
+	}
+	return func(args []string) ([]string, error) {
+		return sandbox.Command(*policy, env["PATH"], env["TMPDIR"], args...)
+	}, nil
+}
+
+// sandboxPolicy returns the sandbox policy that devbox run runs cmdName
+// with, or nil if it doesn't run in a sandbox. Commands run in a sandbox if
+// opts asks for one or if cmdName is a script that devbox.json sandboxes.
+func (d *Devbox) sandboxPolicy(cmdName string, opts devopt.Sandbox) (*sandbox.Policy, error) {
+	_, isScript := d.cfg.Scripts()[cmdName]
+	if !opts.Enabled && opts.Network == "" && !(isScript && d.cfg.Root.IsSandboxed(cmdName)) {
+		return nil, nil
+	}
+
+	policy := &sandbox.Policy{Writable: []string{d.projectDir}}
+	if cfg := d.cfg.Root.Sandbox; cfg != nil {
+		policy.AllowNetwork = cfg.AllowNetwork
+		for _, path := range cfg.Writable {
+			path = os.ExpandEnv(path)
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(d.projectDir, path)
+			}
+			policy.Writable = append(policy.Writable, path)
+		}
+	}
+	switch opts.Network {
+	case "":
+	case "none":
+		policy.AllowNetwork = false
+	case "host":
+		policy.AllowNetwork = true
+	default:
+		return nil, usererr.New("Invalid network %q. It must be none or host.", opts.Network)
+	}
+	return policy, nil
+}
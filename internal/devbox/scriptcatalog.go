@@ -0,0 +1,49 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"maps"
+	"slices"
+)
+
+// ScriptInfo is a script that devbox run lists.
+type ScriptInfo struct {
+	Name string `json:"name"`
+
+	// Source is "devbox.json", "devbox.d/scripts" or "plugin <name>".
+	Source      string `json:"source"`
+	Description string `json:"description,omitempty"`
+
+	// Usage is the script's named parameters, such as "<stage> [<region>]".
+	Usage string `json:"usage,omitempty"`
+}
+
+// ScriptCatalog returns the scripts that devbox run lists, grouped by source:
+// devbox.json first, then devbox.d/scripts, then plugins. It leaves out hidden
+// scripts and the scripts that a script of the same name overrides.
+func (d *Devbox) ScriptCatalog() []ScriptInfo {
+	catalog := []ScriptInfo{}
+	seen := map[string]bool{}
+	sources := d.cfg.ScriptSources()
+	for _, source := range slices.Backward(sources) {
+		for _, name := range slices.Sorted(maps.Keys(source.Scripts)) {
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			script := source.Scripts[name]
+			if script.Hidden {
+				continue
+			}
+			catalog = append(catalog, ScriptInfo{
+				Name:        name,
+				Source:      source.Name,
+				Description: script.Description,
+				Usage:       script.Usage(),
+			})
+		}
+	}
+	return catalog
+}
@@ -0,0 +1,54 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"go.jetify.com/devbox/internal/devbox/devopt"
+)
+
+func TestScriptCatalog(t *testing.T) {
+	dir := t.TempDir()
+	config := `{
+  "packages": [],
+  "shell": {"scripts": {
+    "test": "go test ./...",
+    "deploy": {"run": "./deploy.sh", "description": "Deploy the site", "params": [{"name": "stage"}]},
+    "setup": {"run": "./setup.sh", "hidden": true}
+  }}
+}`
+	if err := os.WriteFile(filepath.Join(dir, "devbox.json"), []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	scriptsDir := filepath.Join(dir, "devbox.d", "scripts")
+	if err := os.MkdirAll(scriptsDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"lint": "#!/bin/sh\n# description: Lint the code\ngolangci-lint run\n",
+		"test": "#!/bin/sh\ngo test -race ./...\n",
+	}
+	for name, body := range files {
+		if err := os.WriteFile(filepath.Join(scriptsDir, name), []byte(body), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	box, err := Open(&devopt.Opts{Dir: dir, Stderr: os.Stderr})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []ScriptInfo{
+		{Name: "deploy", Source: "devbox.json", Description: "Deploy the site", Usage: "<stage>"},
+		{Name: "test", Source: "devbox.json"},
+		{Name: "lint", Source: "devbox.d/scripts", Description: "Lint the code"},
+	}
+	if got := box.ScriptCatalog(); !slices.Equal(got, want) {
+		t.Errorf("got ScriptCatalog() = %v, want %v", got, want)
+	}
+}
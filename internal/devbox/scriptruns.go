@@ -0,0 +1,88 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+	"go.jetify.com/devbox/internal/nix"
+)
+
+// maxScriptRuns is how many script runs devbox remembers per project.
+const maxScriptRuns = 20
+
+// ScriptRun records a run of a script in devbox.json.
+type ScriptRun struct {
+	Name     string        `json:"name"`
+	Started  time.Time     `json:"started"`
+	Duration time.Duration `json:"duration"`
+	ExitCode int           `json:"exit_code"`
+}
+
+func scriptRunsPath(projectDir string) string {
+	return filepath.Join(nix.ProjectUserDir(projectDir), "script-runs.json")
+}
+
+// ScriptRuns returns the project's most recent script runs, newest first.
+func (d *Devbox) ScriptRuns() ([]ScriptRun, error) {
+	data, err := os.ReadFile(scriptRunsPath(d.projectDir))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	runs := []ScriptRun{}
+	if err := json.Unmarshal(data, &runs); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return runs, nil
+}
+
+// recordScriptRun remembers a run of cmdName if it's a script in devbox.json.
+// Arbitrary commands aren't recorded because their arguments can have
+// secrets. Failing to record a run doesn't fail the run.
+func (d *Devbox) recordScriptRun(cmdName string, started time.Time, runErr error) {
+	if _, ok := d.cfg.Scripts()[cmdName]; !ok {
+		return
+	}
+	run := ScriptRun{
+		Name:     cmdName,
+		Started:  started,
+		Duration: time.Since(started).Round(time.Millisecond),
+	}
+	var exitErr *usererr.ExitError
+	if errors.As(runErr, &exitErr) {
+		run.ExitCode = exitErr.ExitCode()
+	} else if runErr != nil {
+		run.ExitCode = -1
+	}
+
+	runs, err := d.ScriptRuns()
+	if err != nil {
+		// Start over rather than failing on a corrupt file.
+		runs = nil
+	}
+	runs = append([]ScriptRun{run}, runs...)
+	if len(runs) > maxScriptRuns {
+		runs = runs[:maxScriptRuns]
+	}
+	data, err := json.Marshal(runs)
+	if err == nil {
+		err = nix.EnsureProjectUserDir(d.projectDir)
+	}
+	if err == nil {
+		err = os.WriteFile(scriptRunsPath(d.projectDir), data, 0o644)
+	}
+	if err != nil {
+		slog.Debug("failed to record script run", "script", cmdName, "err", err)
+	}
+}
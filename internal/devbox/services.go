@@ -4,17 +4,25 @@
 	"context"
 	"fmt"
 	"io"
+	"os"
+	"path/filepath"
 	"strconv"
 	"text/tabwriter"
 
+	"github.com/pkg/errors"
+
 	"go.jetify.com/devbox/internal/boxcli/usererr"
 	"go.jetify.com/devbox/internal/devbox/devopt"
 	"go.jetify.com/devbox/internal/services"
+	"go.jetify.com/devbox/internal/ux"
 )
 
 func (d *Devbox) StartServices(
 	ctx context.Context, runInCurrentShell bool, serviceNames ...string,
 ) error {
+	if err := d.ensureTrusted("start the services"); err != nil {
+		return err
+	}
 	if !runInCurrentShell {
 		return d.runDevboxServicesScript(ctx,
 			append(
@@ -136,6 +144,9 @@ func (d *Devbox) ListServices(ctx context.Context, runInCurrentShell bool) error
 func (d *Devbox) RestartServices(
 	ctx context.Context, runInCurrentShell bool, serviceNames ...string,
 ) error {
+	if err := d.ensureTrusted("start the services"); err != nil {
+		return err
+	}
 	if !runInCurrentShell {
 		return d.runDevboxServicesScript(ctx,
 			append(
@@ -203,6 +214,9 @@ func (d *Devbox) StartProcessManager(
 	requestedServices []string,
 	processComposeOpts devopt.ProcessComposeOpts,
 ) error {
+	if err := d.ensureTrusted("start the services"); err != nil {
+		return err
+	}
 	if !runInCurrentShell {
 		args := []string{"up", "--run-in-current-shell"}
 		args = append(args, requestedServices...)
@@ -216,6 +230,12 @@ func (d *Devbox) StartProcessManager(
 		if processComposeOpts.Background {
 			args = append(args, "--background")
 		}
+		if processComposeOpts.Detach {
+			args = append(args, "--detach")
+		}
+		if processComposeOpts.Supervise {
+			args = append(args, "--supervise")
+		}
 		for _, flag := range processComposeOpts.ExtraFlags {
 			args = append(args, "--pcflags", flag)
 		}
@@ -253,18 +273,51 @@ func (d *Devbox) StartProcessManager(
 
 	// Start the process manager
 
-	return services.StartProcessManager(
+	// Services with limits in devbox.json run with them. The limits file
+	// must come after the services' files so that it overrides them.
+	extraFlags := processComposeOpts.ExtraFlags
+	limitsFile := filepath.Join(d.projectDir, ".devbox", "gen", "process-compose-limits.yaml")
+	hasLimits, err := services.WriteLimitsFile(d.stderr, limitsFile, svcs, d.serviceLimits())
+	if err != nil {
+		return err
+	}
+	if hasLimits {
+		extraFlags = append([]string{"-f", limitsFile}, extraFlags...)
+	}
+	supervisorFile := filepath.Join(d.projectDir, ".devbox", "gen", "process-compose-supervisor.yaml")
+	if processComposeOpts.Supervise || processComposeOpts.Detach {
+		supervised, err := services.WriteSupervisorFile(supervisorFile, svcs)
+		if err != nil {
+			return err
+		}
+		if supervised {
+			extraFlags = append([]string{"-f", supervisorFile}, extraFlags...)
+		}
+	}
+
+	err = services.StartProcessManager(
 		d.stderr,
 		requestedServices,
 		svcs,
 		d.projectDir,
 		services.ProcessComposeOpts{
 			BinPath:            processComposeBinPath,
-			Background:         processComposeOpts.Background,
-			ExtraFlags:         processComposeOpts.ExtraFlags,
+			Background:         processComposeOpts.Background || processComposeOpts.Detach,
+			Detach:             processComposeOpts.Detach,
+			ExtraFlags:         extraFlags,
 			ProcessComposePort: processComposeOpts.ProcessComposePort,
 		},
 	)
+	if err != nil {
+		return err
+	}
+	// Nobody watches detached services, so notify when they crash.
+	if processComposeOpts.Detach && d.wantsServiceCrashes() {
+		if err := d.startServicesWatch(); err != nil {
+			ux.Fwarningf(d.stderr, "Failed to start watching the services for crashes: %v\n", err)
+		}
+	}
+	return nil
 }
 
 // runDevboxServicesScript invokes RunScript with the envOptions set to the appropriate
@@ -283,3 +336,73 @@ func (d *Devbox) ShowProcessComposePort(ctx context.Context, writer io.Writer) e
 	fmt.Fprintf(writer, "%d\n", port)
 	return nil
 }
+
+// bootUnit returns the unit that runs the project's services, supervised,
+// when the user logs in.
+func (d *Devbox) bootUnit() (*services.BootUnit, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &services.BootUnit{
+		Name:        "devbox-services-" + d.ProjectDirHash(),
+		Description: "devbox services of " + d.projectDir,
+		Args: []string{
+			exe, "services", "up", "--config", d.projectDir, "--supervise", "--pcflags", "-t=false",
+		},
+		Dir:     d.projectDir,
+		Path:    os.Getenv("PATH"),
+		LogFile: filepath.Join(d.projectDir, ".devbox", "services-boot.log"),
+	}, nil
+}
+
+// EnableServicesBoot installs a systemd user unit on Linux, or a launchd
+// agent on macOS, that starts the project's services now and whenever the
+// user logs in, and restarts them if they crash.
+func (d *Devbox) EnableServicesBoot(ctx context.Context) error {
+	svcs, err := d.Services()
+	if err != nil {
+		return err
+	}
+	if len(svcs) == 0 {
+		return usererr.New("No services found in your project")
+	}
+	if services.ProcessManagerIsRunning(d.projectDir) {
+		return usererr.New(
+			"Services are already running. Stop them with `devbox services stop` " +
+				"so that they can start at login instead.")
+	}
+
+	unit, err := d.bootUnit()
+	if err != nil {
+		return err
+	}
+	if err := unit.Enable(); err != nil {
+		return err
+	}
+	file, err := unit.File()
+	if err != nil {
+		return err
+	}
+	ux.Fsuccessf(d.stderr, "Installed %s. Your services start now and whenever you log in.\n", file)
+	return nil
+}
+
+// DisableServicesBoot stops and removes the unit that EnableServicesBoot
+// installed.
+func (d *Devbox) DisableServicesBoot(ctx context.Context) error {
+	unit, err := d.bootUnit()
+	if err != nil {
+		return err
+	}
+	disabled, err := unit.Disable()
+	if err != nil {
+		return err
+	}
+	if !disabled {
+		ux.Finfof(d.stderr, "Services of this project don't start at login.\n")
+		return nil
+	}
+	ux.Fsuccessf(d.stderr, "Services of this project no longer start at login.\n")
+	return nil
+}
@@ -0,0 +1,180 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package shellcmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+	"go.jetify.com/devbox/internal/cuecfg"
+)
+
+// Hook is a single init hook. Hooks written as plain strings in a devbox
+// config run as-is, exactly like the init_hook lists of earlier versions.
+// Hooks written as objects are named and run with a failure policy.
+type Hook struct {
+	// Name identifies the hook in error and timing messages.
+	Name string `json:"name,omitempty"`
+
+	// Run contains the hook's shell commands.
+	Run Commands `json:"run"`
+
+	// ContinueOnError runs the remaining hooks when this one fails. By
+	// default, a failing hook stops the hooks that come after it.
+	ContinueOnError bool `json:"continue_on_error,omitempty"`
+
+	// OnlyOnce runs the hook once per version of devbox.lock instead of
+	// every time a shell starts.
+	OnlyOnce bool `json:"only_once,omitempty"`
+
+	// Source is the name of the plugin that contributed the hook. It's
+	// empty for hooks in the project's devbox.json.
+	Source string `json:"-"`
+
+	// plain is true when the hook was written as a bare string.
+	plain bool
+}
+
+// Plain reports whether the hook was written as a bare string, in which case
+// it has no name or failure policy and runs as-is.
+func (h *Hook) Plain() bool {
+	return h.plain
+}
+
+// Hooks marshals and unmarshals the init hooks in a devbox config. It accepts
+// a single string, or an array that mixes strings and hook objects:
+//
+//	"init_hook": [
+//	  "echo hello",
+//	  {"name": "migrate", "run": "./migrate.sh", "only_once": true}
+//	]
+//
+// Consecutive strings in an array are the lines of a single plain hook, so
+// configs without hook objects behave the same as before.
+type Hooks struct {
+	// MarshalAs determines whether MarshalJSON encodes a single plain hook
+	// as a string or as an array. UnmarshalJSON sets it automatically so
+	// that hooks marshal back to their original format.
+	MarshalAs CmdFormat
+	Hooks     []Hook
+}
+
+// MarshalJSON marshals the hooks back into their config representation.
+func (h Hooks) MarshalJSON() ([]byte, error) {
+	if h.MarshalAs == CmdString && len(h.Hooks) == 1 && h.Hooks[0].plain {
+		return cuecfg.MarshalJSON(h.Hooks[0].Run.String())
+	}
+	items := []any{}
+	for _, hook := range h.Hooks {
+		if !hook.plain {
+			items = append(items, hook)
+			continue
+		}
+		for _, cmd := range hook.Run.Cmds {
+			items = append(items, cmd)
+		}
+	}
+	return cuecfg.MarshalJSON(items)
+}
+
+// UnmarshalJSON unmarshals hooks from a string, an array of strings and hook
+// objects, or null.
+func (h *Hooks) UnmarshalJSON(data []byte) error {
+	h.MarshalAs = CmdArray
+	h.Hooks = nil
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || string(data) == "null" {
+		return nil
+	}
+
+	switch data[0] {
+	case '"':
+		var cmd string
+		if err := json.Unmarshal(data, &cmd); err != nil {
+			return err
+		}
+		h.MarshalAs = CmdString
+		h.Hooks = []Hook{{
+			Run:   Commands{MarshalAs: CmdString, Cmds: []string{cmd}},
+			plain: true,
+		}}
+		return nil
+	case '[':
+		var items []json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return err
+		}
+		for _, item := range items {
+			if err := h.appendItem(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return errors.Errorf("init_hook must be a string or an array, got: %s", data)
+	}
+}
+
+func (h *Hooks) appendItem(item json.RawMessage) error {
+	item = bytes.TrimSpace(item)
+	if len(item) == 0 {
+		return nil
+	}
+	switch item[0] {
+	case '"':
+		var cmd string
+		if err := json.Unmarshal(item, &cmd); err != nil {
+			return err
+		}
+		if n := len(h.Hooks); n > 0 && h.Hooks[n-1].plain {
+			h.Hooks[n-1].Run.Cmds = append(h.Hooks[n-1].Run.Cmds, cmd)
+			return nil
+		}
+		h.Hooks = append(h.Hooks, Hook{Run: Commands{Cmds: []string{cmd}}, plain: true})
+		return nil
+	case '{':
+		hook := Hook{}
+		if err := json.Unmarshal(item, &hook); err != nil {
+			return err
+		}
+		if strings.TrimSpace(hook.Run.String()) == "" {
+			if hook.Name != "" {
+				return errors.Errorf("init_hook %q has no run commands", hook.Name)
+			}
+			return errors.New("init_hook object has no run commands")
+		}
+		h.Hooks = append(h.Hooks, hook)
+		return nil
+	default:
+		return errors.Errorf("init_hook items must be strings or objects, got: %s", item)
+	}
+}
+
+// String returns the commands of every hook joined with newlines.
+func (h *Hooks) String() string {
+	if h == nil {
+		return ""
+	}
+	cmds := make([]string, 0, len(h.Hooks))
+	for _, hook := range h.Hooks {
+		cmds = append(cmds, hook.Run.String())
+	}
+	return strings.Join(cmds, "\n")
+}
+
+// Structured reports whether any hook is a hook object rather than a plain
+// string.
+func (h *Hooks) Structured() bool {
+	if h == nil {
+		return false
+	}
+	for _, hook := range h.Hooks {
+		if !hook.plain {
+			return true
+		}
+	}
+	return false
+}
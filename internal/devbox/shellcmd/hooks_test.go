@@ -0,0 +1,110 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package shellcmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"go.jetify.com/devbox/internal/cuecfg"
+)
+
+func TestHooksUnmarshal(t *testing.T) {
+	tests := []struct {
+		jsonIn     string
+		wantPlain  []bool
+		wantString string
+	}{
+		{
+			jsonIn:     `null`,
+			wantPlain:  []bool{},
+			wantString: "",
+		},
+		{
+			jsonIn:     `"echo hi"`,
+			wantPlain:  []bool{true},
+			wantString: "echo hi",
+		},
+		{
+			jsonIn:     `["echo 'line1'","echo 'line2'"]`,
+			wantPlain:  []bool{true},
+			wantString: "echo 'line1'\necho 'line2'",
+		},
+		{
+			jsonIn:     `["echo a",{"name":"setup","run":"./setup.sh","only_once":true},"echo b","echo c"]`,
+			wantPlain:  []bool{true, false, true},
+			wantString: "echo a\n./setup.sh\necho b\necho c",
+		},
+		{
+			jsonIn:     `[{"name":"multi","run":["echo 1","echo 2"],"continue_on_error":true}]`,
+			wantPlain:  []bool{false},
+			wantString: "echo 1\necho 2",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.jsonIn, func(t *testing.T) {
+			got := Hooks{}
+			if err := json.Unmarshal([]byte(test.jsonIn), &got); err != nil {
+				t.Fatal("Got error unmarshalling test input:", err)
+			}
+			gotPlain := []bool{}
+			for _, hook := range got.Hooks {
+				gotPlain = append(gotPlain, hook.Plain())
+			}
+			if diff := cmp.Diff(test.wantPlain, gotPlain); diff != "" {
+				t.Errorf("Got different plain hooks (-want +got):\n%s", diff)
+			}
+			if got := got.String(); got != test.wantString {
+				t.Errorf("got.String() = %q, want %q", got, test.wantString)
+			}
+			if test.jsonIn == "null" {
+				return
+			}
+			b, err := cuecfg.MarshalJSON(got)
+			if err != nil {
+				t.Fatal("Got error marshalling back to JSON:", err)
+			}
+			compact := &bytes.Buffer{}
+			if err := json.Compact(compact, b); err != nil {
+				t.Fatal("Got invalid JSON after marshalling:", err)
+			}
+			if diff := cmp.Diff(test.jsonIn, compact.String()); diff != "" {
+				t.Errorf("Got different JSON after unmarshalling and re-marshalling (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestHooksUnmarshalFields(t *testing.T) {
+	got := Hooks{}
+	in := `[{"name":"migrate","run":"./migrate.sh","continue_on_error":true,"only_once":true}]`
+	if err := json.Unmarshal([]byte(in), &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Hooks) != 1 {
+		t.Fatalf("got %d hooks, want 1", len(got.Hooks))
+	}
+	hook := got.Hooks[0]
+	if hook.Name != "migrate" || !hook.ContinueOnError || !hook.OnlyOnce {
+		t.Errorf("got hook %+v, want name, continue_on_error and only_once set", hook)
+	}
+	if !got.Structured() {
+		t.Error("got.Structured() = false, want true")
+	}
+}
+
+func TestHooksUnmarshalError(t *testing.T) {
+	for _, in := range []string{
+		`[{"name":"empty"}]`,
+		`[{"run":"  "}]`,
+		`[1]`,
+		`{"run":"echo hi"}`,
+	} {
+		if err := json.Unmarshal([]byte(in), &Hooks{}); err == nil {
+			t.Errorf("json.Unmarshal(%s) got nil error, want error", in)
+		}
+	}
+}
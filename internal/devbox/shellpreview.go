@@ -0,0 +1,143 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"maps"
+	"path/filepath"
+	"slices"
+
+	"go.jetify.com/devbox/internal/plugin"
+	"go.jetify.com/devbox/internal/services"
+)
+
+// ShellPreview lists what starting a devbox shell of the project runs and
+// changes: the packages it installs, the files that plugins create, the init
+// hooks it runs, the variables and aliases it sets and the services that the
+// project defines.
+type ShellPreview struct {
+	ProjectDir string `json:"project_dir"`
+
+	// Trusted is true if the user trusts the project with its current
+	// config. devbox shell asks before it runs the hooks of a project that
+	// isn't trusted.
+	Trusted bool `json:"trusted"`
+
+	Packages    []string          `json:"packages"`
+	CreateFiles []PreviewFile     `json:"create_files"`
+	InitHooks   []PreviewHook     `json:"init_hooks"`
+	Env         []PreviewEnv      `json:"env"`
+	EnvFrom     string            `json:"env_from,omitempty"`
+	Aliases     map[string]string `json:"aliases,omitempty"`
+	Services    []PreviewService  `json:"services"`
+	Plugins     []*plugin.Summary `json:"plugins"`
+}
+
+// PreviewFile is a file that a plugin creates in the project.
+type PreviewFile struct {
+	Path   string `json:"path"`
+	Source string `json:"source"`
+}
+
+// PreviewHook is an init hook. Source is "devbox.json" or "plugin <name>".
+type PreviewHook struct {
+	Source   string `json:"source"`
+	Name     string `json:"name,omitempty"`
+	Run      string `json:"run"`
+	OnlyOnce bool   `json:"only_once,omitempty"`
+}
+
+// PreviewEnv is a variable that devbox.json or a plugin sets. When several
+// sources set the same variable, the last one wins.
+type PreviewEnv struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+// PreviewService is a service that devbox services up, or devbox shell
+// --tmux, starts. Source is the process-compose file or plugin that defines it.
+type PreviewService struct {
+	Name   string `json:"name"`
+	Source string `json:"source"`
+}
+
+// ExplainShell returns what starting a devbox shell of the project would run
+// and change, without running or installing anything, so that users can
+// review a project before they trust it.
+func (d *Devbox) ExplainShell() (*ShellPreview, error) {
+	trusted, err := d.IsTrusted()
+	if err != nil {
+		return nil, err
+	}
+	preview := &ShellPreview{
+		ProjectDir:  d.projectDir,
+		Trusted:     trusted,
+		Packages:    []string{},
+		CreateFiles: []PreviewFile{},
+		InitHooks:   []PreviewHook{},
+		Env:         []PreviewEnv{},
+		EnvFrom:     d.cfg.Root.EnvFrom,
+		Aliases:     d.cfg.Aliases(),
+		Services:    []PreviewService{},
+		Plugins:     []*plugin.Summary{},
+	}
+
+	for _, pkg := range d.AllPackages() {
+		preview.Packages = append(preview.Packages, pkg.Versioned())
+	}
+
+	for _, hook := range d.cfg.InitHook().Hooks {
+		source := "devbox.json"
+		if hook.Source != "" {
+			source = "plugin " + hook.Source
+		}
+		preview.InitHooks = append(preview.InitHooks, PreviewHook{
+			Source:   source,
+			Name:     hook.Name,
+			Run:      hook.Run.String(),
+			OnlyOnce: hook.OnlyOnce,
+		})
+	}
+
+	for _, layer := range d.cfg.EnvSources() {
+		for _, name := range slices.Sorted(maps.Keys(layer.Env)) {
+			preview.Env = append(preview.Env, PreviewEnv{
+				Name:   name,
+				Value:  layer.Env[name],
+				Source: layer.Name,
+			})
+		}
+	}
+
+	for _, cfg := range d.cfg.IncludedPluginConfigs() {
+		summary, err := cfg.Summarize()
+		if err != nil {
+			return nil, err
+		}
+		preview.Plugins = append(preview.Plugins, summary)
+		for _, file := range summary.CreateFiles {
+			preview.CreateFiles = append(preview.CreateFiles, PreviewFile{
+				Path:   file,
+				Source: "plugin " + summary.Name,
+			})
+		}
+		for _, name := range summary.Services {
+			preview.Services = append(preview.Services, PreviewService{
+				Name:   name,
+				Source: "plugin " + summary.Name,
+			})
+		}
+	}
+
+	userServices := services.FromUserProcessCompose(d.projectDir, d.customProcessComposeFile)
+	for _, name := range slices.Sorted(maps.Keys(userServices)) {
+		source := userServices[name].ProcessComposePath
+		if rel, err := filepath.Rel(d.projectDir, source); err == nil {
+			source = rel
+		}
+		preview.Services = append(preview.Services, PreviewService{Name: name, Source: source})
+	}
+	return preview, nil
+}
@@ -0,0 +1,67 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"go.jetify.com/devbox/internal/devbox/devopt"
+	"go.jetify.com/devbox/internal/envir"
+)
+
+func TestExplainShell(t *testing.T) {
+	t.Setenv(envir.XDGConfigHome, t.TempDir())
+
+	dir := t.TempDir()
+	config := `{
+  "packages": [],
+  "env": {"GREETING": "hello"},
+  "aliases": {"ll": "ls -l"},
+  "shell": {"init_hook": ["touch ran", {"name": "migrate", "run": "./migrate.sh", "only_once": true}]}
+}`
+	if err := os.WriteFile(filepath.Join(dir, "devbox.json"), []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	processCompose := "processes:\n  web:\n    command: ./serve.sh\n"
+	if err := os.WriteFile(filepath.Join(dir, "process-compose.yaml"), []byte(processCompose), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	box, err := Open(&devopt.Opts{Dir: dir, Stderr: os.Stderr})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	preview, err := box.ExplainShell()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "ran")); !os.IsNotExist(err) {
+		t.Error("ExplainShell ran an init hook")
+	}
+	if preview.Trusted {
+		t.Error("got Trusted = true for a project that the user didn't trust")
+	}
+
+	wantHooks := []PreviewHook{
+		{Source: "devbox.json", Run: "touch ran"},
+		{Source: "devbox.json", Name: "migrate", Run: "./migrate.sh", OnlyOnce: true},
+	}
+	if !slices.Equal(preview.InitHooks, wantHooks) {
+		t.Errorf("got InitHooks = %v, want %v", preview.InitHooks, wantHooks)
+	}
+	wantEnv := PreviewEnv{Name: "GREETING", Value: "hello", Source: "devbox.json"}
+	if !slices.Contains(preview.Env, wantEnv) {
+		t.Errorf("got Env = %v, want it to contain %v", preview.Env, wantEnv)
+	}
+	if got := preview.Aliases["ll"]; got != "ls -l" {
+		t.Errorf(`got Aliases["ll"] = %q, want "ls -l"`, got)
+	}
+	wantService := PreviewService{Name: "web", Source: "process-compose.yaml"}
+	if !slices.Contains(preview.Services, wantService) {
+		t.Errorf("got Services = %v, want it to contain %v", preview.Services, wantService)
+	}
+}
@@ -0,0 +1,233 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+	"go.jetify.com/devbox/internal/devconfig"
+	"go.jetify.com/devbox/internal/envir"
+	"go.jetify.com/devbox/internal/fileutil"
+	"go.jetify.com/devbox/internal/nix"
+	"go.jetify.com/devbox/internal/ux"
+)
+
+var snapshotNameRegexp = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// snapshotInfoFile is the file in a snapshot's directory that describes it.
+const snapshotInfoFile = "snapshot.json"
+
+// Snapshot is a saved state of the project's environment: its devbox.json,
+// its devbox.lock, which also pins its plugins, and the generation of its nix
+// profile.
+type Snapshot struct {
+	Name    string    `json:"name"`
+	Created time.Time `json:"created"`
+	// ProfileGeneration is the generation of the project's nix profile when
+	// the snapshot was created, or 0 if the project had no profile.
+	ProfileGeneration int `json:"profile_generation"`
+}
+
+func snapshotsDir(projectDir string) string {
+	return filepath.Join(nix.ProjectUserDir(projectDir), "snapshots")
+}
+
+func (d *Devbox) snapshotDir(name string) (string, error) {
+	if !snapshotNameRegexp.MatchString(name) {
+		return "", usererr.New(
+			"Invalid snapshot name %q. Use letters, digits, dots, dashes and underscores.", name)
+	}
+	return filepath.Join(snapshotsDir(d.projectDir), name), nil
+}
+
+// CreateSnapshot installs the project's packages and saves the state of its
+// environment as a snapshot that RestoreSnapshot can go back to.
+func (d *Devbox) CreateSnapshot(ctx context.Context, name string) (*Snapshot, error) {
+	dir, err := d.snapshotDir(name)
+	if err != nil {
+		return nil, err
+	}
+	if fileutil.Exists(dir) {
+		return nil, usererr.New(
+			"Snapshot %q already exists. Remove it with `devbox snapshot rm %[1]s` first.", name)
+	}
+
+	unlock, err := d.lockProject()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	// The snapshot's profile generation has to match its devbox.json and
+	// devbox.lock.
+	if err := d.ensureStateIsUpToDate(ctx, ensure); err != nil {
+		return nil, err
+	}
+	generation, err := nix.ProfileGeneration(nix.ProjectProfilePath(d.projectDir))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := nix.EnsureProjectUserDir(d.projectDir); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	for src, dst := range d.snapshotFiles(dir) {
+		if err := copySnapshotFile(src, dst); err != nil {
+			return nil, err
+		}
+	}
+	snapshot := &Snapshot{Name: name, Created: time.Now(), ProfileGeneration: generation}
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err := fileutil.WriteFileAtomic(filepath.Join(dir, snapshotInfoFile), data, 0o644); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// Snapshots returns the project's snapshots, newest first.
+func (d *Devbox) Snapshots() ([]Snapshot, error) {
+	entries, err := os.ReadDir(snapshotsDir(d.projectDir))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	snapshots := []Snapshot{}
+	for _, entry := range entries {
+		snapshot, err := readSnapshot(filepath.Join(snapshotsDir(d.projectDir), entry.Name()))
+		if err != nil {
+			// Skip what isn't a snapshot rather than hiding the others.
+			continue
+		}
+		snapshots = append(snapshots, *snapshot)
+	}
+	slices.SortFunc(snapshots, func(a, b Snapshot) int {
+		return cmp.Or(b.Created.Compare(a.Created), cmp.Compare(a.Name, b.Name))
+	})
+	return snapshots, nil
+}
+
+// RestoreSnapshot restores the project's devbox.json and devbox.lock from a
+// snapshot and rolls its nix profile back to the snapshot's generation, so
+// that nothing has to be built or downloaded. If the generation was garbage
+// collected, the packages are installed from the restored devbox.lock instead.
+func (d *Devbox) RestoreSnapshot(ctx context.Context, name string) (*Snapshot, error) {
+	dir, err := d.snapshotDir(name)
+	if err != nil {
+		return nil, err
+	}
+	snapshot, err := readSnapshot(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, usererr.New("Snapshot %q doesn't exist. Run `devbox snapshot ls` to list the snapshots.", name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if envir.IsLocked() {
+		return nil, usererr.New(
+			"Can't restore a snapshot because --locked or %s is set.", envir.DevboxLocked)
+	}
+
+	unlock, err := d.lockProject()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	for dst, src := range d.snapshotFiles(dir) {
+		if err := copySnapshotFile(src, dst); err != nil {
+			return nil, err
+		}
+	}
+	cfg, err := devconfig.Open(d.projectDir)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.lockfile.Reload(); err != nil {
+		return nil, err
+	}
+	if err := cfg.LoadRecursive(d.lockfile); err != nil {
+		return nil, err
+	}
+	d.cfg = cfg
+
+	profile := nix.ProjectProfilePath(d.projectDir)
+	if snapshot.ProfileGeneration > 0 && nix.ProfileGenerationExists(profile, snapshot.ProfileGeneration) {
+		if err := nix.ProfileRollback(ctx, profile, snapshot.ProfileGeneration); err != nil {
+			return nil, err
+		}
+	} else if snapshot.ProfileGeneration > 0 {
+		ux.Fwarningf(d.stderr,
+			"Generation %d of the nix profile no longer exists, so the packages will be reinstalled.\n",
+			snapshot.ProfileGeneration)
+	}
+	return snapshot, d.ensureStateIsUpToDate(ctx, ensure)
+}
+
+// RemoveSnapshot deletes a snapshot. It doesn't delete the generation of the
+// nix profile that the snapshot refers to.
+func (d *Devbox) RemoveSnapshot(name string) error {
+	dir, err := d.snapshotDir(name)
+	if err != nil {
+		return err
+	}
+	if !fileutil.Exists(dir) {
+		return usererr.New("Snapshot %q doesn't exist.", name)
+	}
+	return errors.WithStack(os.RemoveAll(dir))
+}
+
+// snapshotFiles maps each of the project's files that a snapshot saves to its
+// copy in the snapshot directory.
+func (d *Devbox) snapshotFiles(dir string) map[string]string {
+	return map[string]string{
+		d.cfg.Root.AbsRootPath:                     filepath.Join(dir, "devbox.json"),
+		filepath.Join(d.projectDir, "devbox.lock"): filepath.Join(dir, "devbox.lock"),
+	}
+}
+
+func readSnapshot(dir string) (*Snapshot, error) {
+	data, err := os.ReadFile(filepath.Join(dir, snapshotInfoFile))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	snapshot := &Snapshot{}
+	if err := json.Unmarshal(data, snapshot); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return snapshot, nil
+}
+
+// copySnapshotFile copies src to dst, or removes dst if src doesn't exist,
+// such as a project without a devbox.lock.
+func copySnapshotFile(src, dst string) error {
+	data, err := os.ReadFile(src)
+	if errors.Is(err, os.ErrNotExist) {
+		if err := os.Remove(dst); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return errors.WithStack(err)
+		}
+		return nil
+	}
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return fileutil.WriteFileAtomic(dst, data, 0o644)
+}
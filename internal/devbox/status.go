@@ -0,0 +1,115 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"path/filepath"
+	"slices"
+
+	"github.com/pkg/errors"
+
+	"go.jetify.com/devbox/internal/devbox/devopt"
+	"go.jetify.com/devbox/internal/devconfig/configfile"
+	"go.jetify.com/devbox/internal/lock"
+)
+
+// Status is how the project's environment compares with its devbox.json and
+// devbox.lock, and with the ones at a git revision if StatusOpts.Remote is
+// set.
+type Status struct {
+	// UpToDate is false if the installed packages don't match devbox.json
+	// and devbox.lock, such as after a git pull.
+	UpToDate bool `json:"up_to_date"`
+
+	Remote string `json:"remote,omitempty"`
+	// AddedPackages and RemovedPackages are the packages in the local
+	// devbox.json that aren't in the remote one, and the other way around.
+	AddedPackages   []string `json:"added_packages,omitempty"`
+	RemovedPackages []string `json:"removed_packages,omitempty"`
+	// ResolutionChanges are the packages that resolve to a different
+	// version or build in the local devbox.lock than in the remote one.
+	ResolutionChanges []lock.Change `json:"resolution_changes,omitempty"`
+}
+
+// HasDrift reports whether the project differs from the remote.
+func (s *Status) HasDrift() bool {
+	return len(s.AddedPackages) > 0 || len(s.RemovedPackages) > 0 || len(s.ResolutionChanges) > 0
+}
+
+// Status returns how the project's environment compares with its devbox.json
+// and devbox.lock, and with the ones committed at opts.Remote.
+func (d *Devbox) Status(ctx context.Context, opts devopt.StatusOpts) (*Status, error) {
+	upToDate, err := d.lockfile.IsUpToDateAndInstalled(isFishShell())
+	if err != nil {
+		return nil, err
+	}
+	status := &Status{UpToDate: upToDate, Remote: opts.Remote}
+	if opts.Remote == "" {
+		return status, nil
+	}
+
+	remoteLock, err := d.gitLockfile(ctx, opts.Remote)
+	if err != nil {
+		return nil, err
+	}
+	remotePkgs, err := d.gitConfigPackages(ctx, opts.Remote)
+	if err != nil {
+		return nil, err
+	}
+	localPkgs := []string{}
+	for _, pkg := range d.cfg.Root.TopLevelPackages() {
+		localPkgs = append(localPkgs, pkg.VersionedName())
+	}
+	status.AddedPackages, status.RemovedPackages = diffPackageLists(remotePkgs, localPkgs)
+
+	for _, change := range lock.Diff(remoteLock, d.lockfile) {
+		// Added and removed packages are already reported from devbox.json.
+		if change.Kind != lock.Added && change.Kind != lock.Removed {
+			status.ResolutionChanges = append(status.ResolutionChanges, change)
+		}
+	}
+	return status, nil
+}
+
+// gitConfigPackages returns the versioned names of the packages in the
+// project's devbox.json at a git revision, or none if it didn't have one.
+func (d *Devbox) gitConfigPackages(ctx context.Context, rev string) ([]string, error) {
+	name := filepath.Base(d.cfg.Root.AbsRootPath)
+	var data bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", "-C", d.projectDir, "show", rev+":./"+name)
+	cmd.Stdout = &data
+	if err := cmd.Run(); err != nil {
+		return nil, nil
+	}
+	cfg, err := configfile.LoadBytes(data.Bytes())
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse %s at %s", name, rev)
+	}
+	pkgs := []string{}
+	for _, pkg := range cfg.TopLevelPackages() {
+		pkgs = append(pkgs, pkg.VersionedName())
+	}
+	return pkgs, nil
+}
+
+// diffPackageLists returns the packages in local that aren't in remote, and
+// the ones in remote that aren't in local, sorted.
+func diffPackageLists(remote, local []string) (added, removed []string) {
+	for _, pkg := range local {
+		if !slices.Contains(remote, pkg) {
+			added = append(added, pkg)
+		}
+	}
+	for _, pkg := range remote {
+		if !slices.Contains(local, pkg) {
+			removed = append(removed, pkg)
+		}
+	}
+	slices.Sort(added)
+	slices.Sort(removed)
+	return added, removed
+}
@@ -0,0 +1,26 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDiffPackageLists(t *testing.T) {
+	remote := []string{"go@1.22", "nodejs@20", "jq@latest"}
+	local := []string{"nodejs@20", "ripgrep@latest", "go@1.23", "jq@latest"}
+	added, removed := diffPackageLists(remote, local)
+	if want := []string{"go@1.23", "ripgrep@latest"}; !slices.Equal(added, want) {
+		t.Errorf("got added %v, want %v", added, want)
+	}
+	if want := []string{"go@1.22"}; !slices.Equal(removed, want) {
+		t.Errorf("got removed %v, want %v", removed, want)
+	}
+
+	added, removed = diffPackageLists(local, local)
+	if len(added) > 0 || len(removed) > 0 {
+		t.Errorf("got added %v and removed %v for the same lists, want none", added, removed)
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+	"go.jetify.com/devbox/internal/cmdutil"
+	"go.jetify.com/devbox/internal/devbox/devopt"
+	"go.jetify.com/devbox/internal/devconfig"
+	"go.jetify.com/devbox/internal/devconfig/configfile"
+	"go.jetify.com/devbox/internal/shellgen"
+)
+
+// TestResult is the outcome of a test that devbox.json or a plugin declares.
+type TestResult struct {
+	Name string `json:"name"`
+
+	// Source is "devbox.json" or "plugin <name>".
+	Source string `json:"source"`
+	Passed bool   `json:"passed"`
+
+	// Reason explains why the test failed.
+	Reason string `json:"reason,omitempty"`
+
+	// Output is the combined stdout and stderr of the test's command.
+	Output string `json:"output"`
+}
+
+// RunTests runs the tests that devbox.json and the plugins it includes
+// declare. Each test runs in the project's environment, with its init hooks,
+// which is computed for the tests instead of taken from the current shell.
+// It returns an error if a test couldn't run, and not if it failed.
+func (d *Devbox) RunTests(ctx context.Context, opts devopt.TestOpts) ([]TestResult, error) {
+	tests := d.cfg.Tests()
+	for _, name := range opts.Names {
+		if !slices.ContainsFunc(tests, func(t devconfig.TestSource) bool { return testName(t.Test) == name }) {
+			return nil, usererr.New("devbox.json and its plugins don't declare a test named %q", name)
+		}
+	}
+	if len(tests) == 0 {
+		return []TestResult{}, nil
+	}
+
+	if err := d.ensureTrusted("run the init hooks and tests"); err != nil {
+		return nil, err
+	}
+	env, err := d.ensureStateIsUpToDateAndComputeEnv(ctx, devopt.EnvOptions{})
+	if err != nil {
+		return nil, err
+	}
+	env["DEVBOX_SHELL_ENABLED"] = "1"
+
+	// Like devbox run, run the commands through a script that runs the init
+	// hooks first.
+	if err := shellgen.WriteScriptsToFiles(d); err != nil {
+		return nil, err
+	}
+	scriptBody, err := shellgen.ScriptBody(d, "eval $DEVBOX_RUN_CMD\n")
+	if err != nil {
+		return nil, err
+	}
+	if err := shellgen.WriteScriptFile(d, arbitraryCmdFilename, scriptBody); err != nil {
+		return nil, err
+	}
+	script := strconv.Quote(shellgen.ScriptPath(d.ProjectDir(), arbitraryCmdFilename))
+
+	results := []TestResult{}
+	for _, source := range tests {
+		name := testName(source.Test)
+		if len(opts.Names) > 0 && !slices.Contains(opts.Names, name) {
+			continue
+		}
+		env["DEVBOX_RUN_CMD"] = source.Test.Run
+		output, err := d.runTestCommand(ctx, script, env)
+		if err != nil && !errors.As(err, new(*exec.ExitError)) {
+			return nil, err
+		}
+		results = append(results, checkTest(source, output, err))
+	}
+	return results, nil
+}
+
+func (d *Devbox) runTestCommand(ctx context.Context, script string, env map[string]string) (string, error) {
+	envPairs := []string{}
+	for k, v := range env {
+		envPairs = append(envPairs, fmt.Sprintf("%s=%s", k, v))
+	}
+	shPath := cmdutil.GetPathOrDefault("sh", "/bin/sh")
+	cmd := exec.CommandContext(ctx, shPath, "-c", script)
+	cmd.Env = envPairs
+	cmd.Dir = d.projectDir
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	err := cmd.Run()
+	return output.String(), errors.WithStack(err)
+}
+
+// checkTest returns the result of a test whose command printed output and
+// exited with runErr.
+func checkTest(source devconfig.TestSource, output string, runErr error) TestResult {
+	result := TestResult{
+		Name:   testName(source.Test),
+		Source: source.Name,
+		Passed: true,
+		Output: output,
+	}
+	if exitErr := (*exec.ExitError)(nil); errors.As(runErr, &exitErr) {
+		result.Passed = false
+		result.Reason = fmt.Sprintf("exited with status %d", exitErr.ExitCode())
+	} else if runErr != nil {
+		result.Passed = false
+		result.Reason = runErr.Error()
+	} else if !strings.Contains(output, source.Test.Expect) {
+		result.Passed = false
+		result.Reason = fmt.Sprintf("output doesn't contain %q", source.Test.Expect)
+	}
+	return result
+}
+
+func testName(test *configfile.Test) string {
+	return cmp.Or(test.Name, test.Run)
+}
@@ -0,0 +1,60 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"os/exec"
+	"testing"
+
+	"go.jetify.com/devbox/internal/devconfig"
+	"go.jetify.com/devbox/internal/devconfig/configfile"
+)
+
+func TestCheckTest(t *testing.T) {
+	exitErr := exec.Command("sh", "-c", "exit 3").Run()
+	cases := []struct {
+		name       string
+		test       configfile.Test
+		output     string
+		runErr     error
+		wantPassed bool
+		wantReason string
+	}{
+		{
+			name:       "expected output",
+			test:       configfile.Test{Run: "python --version", Expect: "3.12"},
+			output:     "Python 3.12.4\n",
+			wantPassed: true,
+		},
+		{
+			name:       "unexpected output",
+			test:       configfile.Test{Run: "python --version", Expect: "3.12"},
+			output:     "Python 3.11.9\n",
+			wantReason: `output doesn't contain "3.12"`,
+		},
+		{
+			name:       "no expect",
+			test:       configfile.Test{Run: "true"},
+			wantPassed: true,
+		},
+		{
+			name:       "non-zero exit",
+			test:       configfile.Test{Run: "exit 3"},
+			runErr:     exitErr,
+			wantReason: "exited with status 3",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := checkTest(devconfig.TestSource{Name: "devbox.json", Test: &tc.test}, tc.output, tc.runErr)
+			if got.Passed != tc.wantPassed || got.Reason != tc.wantReason {
+				t.Errorf("got Passed = %v, Reason = %q, want %v, %q",
+					got.Passed, got.Reason, tc.wantPassed, tc.wantReason)
+			}
+			if got.Name != tc.test.Run {
+				t.Errorf("got Name = %q, want %q", got.Name, tc.test.Run)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"context"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+
+	"github.com/pkg/errors"
+
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+	"go.jetify.com/devbox/internal/devbox/devopt"
+	"go.jetify.com/devbox/internal/services"
+	"go.jetify.com/devbox/internal/ux"
+)
+
+// tmuxShellWindow is the name of the tmux window of the devbox shell.
+const tmuxShellWindow = "shell"
+
+// tmuxNameRegexp matches the characters that tmux doesn't allow in the names
+// of sessions and windows.
+var tmuxNameRegexp = regexp.MustCompile(`[.:\s]+`)
+
+// ShellInTmux attaches to a tmux session for the project, with a window that
+// runs shellCommand, which starts a devbox shell, and a window that follows
+// the logs of each of the project's services. It creates the session, and
+// starts the services in the background, if they aren't running yet.
+func (d *Devbox) ShellInTmux(ctx context.Context, shellCommand []string) error {
+	tmux, err := exec.LookPath("tmux")
+	if err != nil {
+		return usererr.New("devbox shell --tmux needs tmux. Install it, for example with `devbox global add tmux`.")
+	}
+	session := d.tmuxSession()
+
+	if exec.Command(tmux, "has-session", "-t", "="+session).Run() != nil {
+		logCommands, err := d.serviceLogCommands(ctx)
+		if err != nil {
+			return err
+		}
+		for _, args := range tmuxSessionCommands(session, d.projectDir, shellCommand, logCommands) {
+			cmd := exec.CommandContext(ctx, tmux, args...)
+			cmd.Stderr = d.stderr
+			if err := cmd.Run(); err != nil {
+				return errors.Wrapf(err, "tmux %s", args[0])
+			}
+		}
+	}
+
+	// Inside of tmux, switch to the session instead of nesting it.
+	args := []string{"attach-session", "-t", "=" + session}
+	if os.Getenv("TMUX") != "" {
+		args = []string{"switch-client", "-t", "=" + session}
+	}
+	cmd := exec.CommandContext(ctx, tmux, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return errors.WithStack(cmd.Run())
+}
+
+// tmuxSession is the name of the project's tmux session, such as
+// devbox-myapp-1a2b3c.
+func (d *Devbox) tmuxSession() string {
+	name := tmuxNameRegexp.ReplaceAllString(filepath.Base(d.projectDir), "-")
+	return "devbox-" + name + "-" + d.ProjectDirHash()[:6]
+}
+
+// serviceLogCommands returns the command that follows the logs of each of the
+// project's services. It starts the services in the background first, if the
+// project has any and they aren't running.
+func (d *Devbox) serviceLogCommands(ctx context.Context) (map[string][]string, error) {
+	svcs, err := d.Services()
+	if err != nil || len(svcs) == 0 {
+		return nil, err
+	}
+	if !services.ProcessManagerIsRunning(d.projectDir) {
+		ux.Finfof(d.stderr, "Starting the project's services in the background.\n")
+		err := d.StartProcessManager(ctx, false, nil, devopt.ProcessComposeOpts{Background: true})
+		if err != nil {
+			return nil, err
+		}
+	}
+	processComposeBinPath, err := utilityLookPath("process-compose")
+	if err != nil {
+		return nil, err
+	}
+	port, err := services.GetProcessManagerPort(d.projectDir)
+	if err != nil {
+		return nil, err
+	}
+
+	commands := map[string][]string{}
+	for name := range svcs {
+		commands[name] = []string{
+			processComposeBinPath, "process", "logs", name, "--follow", "--port", strconv.Itoa(port),
+		}
+	}
+	return commands, nil
+}
+
+// tmuxSessionCommands returns the tmux commands that create a detached session
+// with a window that runs shellCommand in projectDir, and a window for each
+// service that runs its log command. The shell's window is the one that's
+// selected when the session is attached. Log windows stay open when their
+// command exits, so that its last output can be read.
+func tmuxSessionCommands(session, projectDir string, shellCommand []string, logCommands map[string][]string) [][]string {
+	commands := [][]string{
+		append([]string{"new-session", "-d", "-s", session, "-n", tmuxShellWindow, "-c", projectDir, "--"}, shellCommand...),
+	}
+	for _, name := range slices.Sorted(maps.Keys(logCommands)) {
+		window := tmuxNameRegexp.ReplaceAllString(name, "-")
+		commands = append(commands,
+			append([]string{"new-window", "-d", "-t", session + ":", "-n", window, "-c", projectDir, "--"}, logCommands[name]...),
+			[]string{"set-option", "-w", "-t", session + ":" + window, "remain-on-exit", "on"},
+		)
+	}
+	return commands
+}
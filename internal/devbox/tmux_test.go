@@ -0,0 +1,31 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestTmuxSessionCommands(t *testing.T) {
+	commands := tmuxSessionCommands(
+		"devbox-app-abc123",
+		"/src/app",
+		[]string{"/bin/devbox", "shell", "--pure"},
+		map[string][]string{
+			"web":        {"process-compose", "process", "logs", "web", "--follow", "--port", "8260"},
+			"postgresql": {"process-compose", "process", "logs", "postgresql", "--follow", "--port", "8260"},
+		},
+	)
+	want := [][]string{
+		{"new-session", "-d", "-s", "devbox-app-abc123", "-n", "shell", "-c", "/src/app", "--", "/bin/devbox", "shell", "--pure"},
+		{"new-window", "-d", "-t", "devbox-app-abc123:", "-n", "postgresql", "-c", "/src/app", "--", "process-compose", "process", "logs", "postgresql", "--follow", "--port", "8260"},
+		{"set-option", "-w", "-t", "devbox-app-abc123:postgresql", "remain-on-exit", "on"},
+		{"new-window", "-d", "-t", "devbox-app-abc123:", "-n", "web", "-c", "/src/app", "--", "process-compose", "process", "logs", "web", "--follow", "--port", "8260"},
+		{"set-option", "-w", "-t", "devbox-app-abc123:web", "remain-on-exit", "on"},
+	}
+	if !slices.EqualFunc(commands, want, slices.Equal) {
+		t.Errorf("got tmux commands\n%q\nwant\n%q", commands, want)
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/mattn/go-isatty"
+	"github.com/pkg/errors"
+
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+	"go.jetify.com/devbox/internal/cachehash"
+	"go.jetify.com/devbox/internal/devconfig"
+	"go.jetify.com/devbox/internal/devpkg"
+	"go.jetify.com/devbox/internal/envir"
+	"go.jetify.com/devbox/internal/plugin"
+	"go.jetify.com/devbox/internal/services"
+	"go.jetify.com/devbox/internal/trust"
+)
+
+// TrustHash returns the hash of the files that decide which code the project
+// runs: devbox.json, the plugins that it includes, the project's local
+// plugins, its devbox.d/scripts files and its process-compose file. Unlike
+// ConfigHash, it doesn't change when packages are resolved to other versions,
+// and it leaves out the built-in plugins, which are part of devbox.
+func (d *Devbox) TrustHash() (string, error) {
+	return trustHash(d.cfg, d.projectDir, d.customProcessComposeFile)
+}
+
+func trustHash(cfg *devconfig.Config, projectDir, processComposeFile string) (string, error) {
+	buf := bytes.Buffer{}
+	h, err := cfg.Root.Hash()
+	if err != nil {
+		return "", err
+	}
+	buf.WriteString(h)
+	for _, pluginConfig := range cfg.IncludedPluginConfigs() {
+		if _, builtIn := pluginConfig.Source.(*devpkg.Package); builtIn {
+			continue
+		}
+		h, err := pluginConfig.Hash()
+		if err != nil {
+			return "", err
+		}
+		buf.WriteString(h)
+	}
+	devPluginsHash, err := plugin.DevPluginsHash(projectDir)
+	if err != nil {
+		return "", err
+	}
+	buf.WriteString(devPluginsHash)
+	scriptFiles := cfg.Root.ScriptFiles()
+	for _, name := range slices.Sorted(maps.Keys(scriptFiles)) {
+		h, err := cachehash.File(scriptFiles[name].File)
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+		buf.WriteString(name + h)
+	}
+	if path := services.LookupProcessCompose(projectDir, processComposeFile); path != "" {
+		h, err := cachehash.File(path)
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+		buf.WriteString(h)
+	}
+	return cachehash.Bytes(buf.Bytes()), nil
+}
+
+// IsTrusted reports whether the user trusts the project with its current
+// config to run its init hooks, scripts and services.
+func (d *Devbox) IsTrusted() (bool, error) {
+	if d.isGlobal() {
+		return true, nil
+	}
+	hash, err := d.TrustHash()
+	if err != nil {
+		return false, err
+	}
+	return trust.IsTrusted(d.projectDir, hash)
+}
+
+// Trust records that the user trusts the project with its current config.
+func (d *Devbox) Trust() error {
+	hash, err := d.TrustHash()
+	if err != nil {
+		return err
+	}
+	return trust.SetTrusted(d.projectDir, hash)
+}
+
+// Untrust removes the user's trust of the project.
+func (d *Devbox) Untrust() error {
+	return trust.Untrust(d.projectDir)
+}
+
+// ensureTrusted returns an error if the user doesn't trust the project to run
+// its code, such as its init hooks, after asking them if there's a terminal to
+// ask in. action describes what the project is about to run.
+func (d *Devbox) ensureTrusted(action string) error {
+	if envir.IsTrustPromptDisabled() {
+		return nil
+	}
+	trusted, err := d.IsTrusted()
+	if err != nil || trusted {
+		return err
+	}
+
+	if isatty.IsTerminal(os.Stdin.Fd()) && isatty.IsTerminal(os.Stderr.Fd()) {
+		fmt.Fprintf(d.stderr, "Devbox is about to %s of %s, which you haven't trusted yet, or "+
+			"whose devbox.json, plugins or process-compose file changed since you did.\n", action, d.projectDir)
+		answer := false
+		// Ask on stderr, because devbox shellenv's output is evaluated.
+		err := survey.AskOne(&survey.Confirm{Message: "Do you trust this project?"}, &answer,
+			survey.WithStdio(os.Stdin, os.Stderr, os.Stderr))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if answer {
+			return d.Trust()
+		}
+	}
+	return usererr.New(
+		"Devbox didn't %s of %s, because you don't trust the project. Review its devbox.json, "+
+			"plugins and process-compose file, then run `devbox trust` to trust it, or use "+
+			"--no-trust-prompt to run it anyway, such as in CI.", action, d.projectDir)
+}
+
+// isSavedConfigTrusted reports whether the user trusts the project with the
+// config that's saved in devbox.json, which can differ from the one that
+// devbox is about to save.
+func (d *Devbox) isSavedConfigTrusted() bool {
+	if !trust.HasTrusted(d.projectDir) {
+		return false
+	}
+	saved, err := devconfig.Open(d.projectDir)
+	if err != nil || saved.LoadRecursive(d.lockfile) != nil {
+		return false
+	}
+	hash, err := trustHash(saved, d.projectDir, d.customProcessComposeFile)
+	if err != nil {
+		return false
+	}
+	trusted, err := trust.IsTrusted(d.projectDir, hash)
+	return err == nil && trusted
+}
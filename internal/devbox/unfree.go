@@ -0,0 +1,94 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+
+	"github.com/samber/lo"
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+	"go.jetify.com/devbox/internal/devconfig"
+	"go.jetify.com/devbox/internal/devconfig/configfile"
+	"go.jetify.com/devbox/internal/devpkg"
+	"go.jetify.com/devbox/internal/nix"
+	"go.jetify.com/devbox/internal/state"
+)
+
+// unfreePolicy returns the project's allow_unfree setting, or devbox global's
+// if the project doesn't set it. It returns the path of the devbox.json the
+// policy came from, and a nil policy if neither sets it.
+func (d *Devbox) unfreePolicy() (*configfile.UnfreePolicy, string) {
+	if policy := d.cfg.Root.AllowUnfree; policy != nil {
+		return policy, d.cfg.Root.AbsRootPath
+	}
+
+	globalPath := state.Data("global", currentGlobalProfile)
+	if filepath.Clean(d.projectDir) == filepath.Clean(globalPath) {
+		return nil, ""
+	}
+	global, err := devconfig.Open(globalPath)
+	if err != nil {
+		return nil, ""
+	}
+	return global.Root.AllowUnfree, global.Root.AbsRootPath
+}
+
+// checkUnfreePackages returns an error listing the packages with unfree
+// licenses that the unfree policy doesn't allow. It only evaluates the
+// packages' licenses if the policy restricts them.
+func (d *Devbox) checkUnfreePackages(ctx context.Context, pkgs []*devpkg.Package) error {
+	policy, path := d.unfreePolicy()
+	if policy == nil || policy.All {
+		return nil
+	}
+
+	forbidden := []string{}
+	names := lo.Map(policy.Packages, func(name string, _ int) string { return fmt.Sprintf("%q", name) })
+	for _, pkg := range pkgs {
+		name := unfreePolicyName(pkg)
+		if policy.Allows(name) {
+			continue
+		}
+		installable, err := evalInstallable(pkg)
+		if err != nil {
+			slog.Debug("failed to get the installable of a package to check its license", "pkg", pkg.Raw, "err", err)
+			continue
+		}
+		license, err := nix.EvalPackageLicense(ctx, installable)
+		if err != nil {
+			// Installing will report the error if it's real.
+			slog.Debug("failed to evaluate the license of a package", "pkg", pkg.Raw, "err", err)
+			continue
+		}
+		if !license.Unfree {
+			continue
+		}
+		licenses := strings.Join(license.Licenses, ", ")
+		if licenses == "" {
+			licenses = "unfree"
+		}
+		forbidden = append(forbidden, fmt.Sprintf("  - %s (%s)", pkg.Raw, licenses))
+		names = append(names, fmt.Sprintf("%q", name))
+	}
+	if len(forbidden) == 0 {
+		return nil
+	}
+	return usererr.New(
+		"The unfree package policy in %s doesn't allow these packages:\n%s\n\n"+
+			"To allow them, add them to allow_unfree, such as \"allow_unfree\": [%s].",
+		path, strings.Join(forbidden, "\n"), strings.Join(names, ", "))
+}
+
+// unfreePolicyName is the name that allow_unfree allows pkg by, such as
+// "terraform" for terraform@1.5.
+func unfreePolicyName(pkg *devpkg.Package) string {
+	if name := pkg.CanonicalName(); name != "" {
+		return name
+	}
+	return pkg.Raw
+}
@@ -11,7 +11,9 @@
 	"time"
 
 	"github.com/pkg/errors"
+	"github.com/samber/lo"
 	"go.jetify.com/devbox/internal/devbox/devopt"
+	"go.jetify.com/devbox/internal/devconfig/configfile"
 	"go.jetify.com/devbox/internal/devpkg"
 	"go.jetify.com/devbox/internal/devpkg/pkgtype"
 	"go.jetify.com/devbox/internal/lock"
@@ -23,14 +25,40 @@
 	"go.jetify.com/devbox/nix/flake"
 )
 
-func (d *Devbox) Update(ctx context.Context, opts devopt.UpdateOpts) error {
+func (d *Devbox) Update(ctx context.Context, opts devopt.UpdateOpts) (err error) {
+	unlock, err := d.lockProject()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	done := d.trackCommand(ctx, "update", opts.Pkgs)
+	defer func() { done(err) }()
+
+	if opts.Commit {
+		if err := d.checkCanCommitUpdate(ctx, opts); err != nil {
+			return err
+		}
+	}
+	changelogs, err := d.update(ctx, opts)
+	if err != nil {
+		return err
+	}
+	if opts.Commit {
+		return d.commitUpdate(ctx, opts, changelogs)
+	}
+	return nil
+}
+
+// update updates the packages and returns the changelogs of the ones whose
+// versions changed, by package name.
+func (d *Devbox) update(ctx context.Context, opts devopt.UpdateOpts) (map[string]string, error) {
 	if len(opts.Pkgs) == 0 || slices.Contains(opts.Pkgs, "nixpkgs") {
 		if err := d.lockfile.UpdateStdenv(); err != nil {
-			return err
+			return nil, err
 		}
 		// if nixpkgs is the only package to update, just return here.
 		if len(opts.Pkgs) == 1 {
-			return nil
+			return nil, nil
 		}
 		// Otherwise, remove nixpkgs and continue
 		opts.Pkgs = slices.DeleteFunc(opts.Pkgs, func(pkg string) bool {
@@ -40,7 +68,7 @@ func (d *Devbox) Update(ctx context.Context, opts devopt.UpdateOpts) error {
 
 	inputs, err := d.inputsToUpdate(opts)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	pendingPackagesToUpdate := []*devpkg.Package{}
@@ -51,11 +79,11 @@ func (d *Devbox) Update(ctx context.Context, opts devopt.UpdateOpts) error {
 			// Get the package from the config to get the Platforms and ExcludedPlatforms later
 			cfgPackage, ok := d.cfg.Root.GetPackage(pkg.Raw)
 			if !ok {
-				return fmt.Errorf("package %s not found in config", pkg.Raw)
+				return nil, fmt.Errorf("package %s not found in config", pkg.Raw)
 			}
 
 			if err := d.Remove(ctx, pkg.Raw); err != nil {
-				return err
+				return nil, err
 			}
 			// Calling Add function with the original package names, since
 			// Add will automatically append @latest if search is able to handle that.
@@ -63,16 +91,25 @@ func (d *Devbox) Update(ctx context.Context, opts devopt.UpdateOpts) error {
 			if err := d.Add(ctx, []string{pkg.Raw}, devopt.AddOpts{
 				Platforms:        cfgPackage.Platforms,
 				ExcludePlatforms: cfgPackage.ExcludedPlatforms,
+				Explain:          opts.Explain,
 			}); err != nil {
-				return err
+				return nil, err
 			}
 		} else {
 			pendingPackagesToUpdate = append(pendingPackagesToUpdate, pkg)
 		}
 	}
 
-	if err := d.updatePendingPackages(pendingPackagesToUpdate); err != nil {
-		return err
+	before := d.resolvedPackages(pendingPackagesToUpdate)
+	versionsBefore := d.lockedVersions(pendingPackagesToUpdate)
+	if err := d.updatePendingPackages(ctx, pendingPackagesToUpdate); err != nil {
+		return nil, err
+	}
+	if err := d.checkUpdatedPackages(ctx, pendingPackagesToUpdate, before); err != nil {
+		return nil, err
+	}
+	if err := d.checkPolicy(ctx, pendingPackagesToUpdate, opts.Explain); err != nil {
+		return nil, err
 	}
 
 	d.packagesBeingUpdated = inputs
@@ -82,28 +119,82 @@ func (d *Devbox) Update(ctx context.Context, opts devopt.UpdateOpts) error {
 		mode = noInstall
 	}
 	if err := d.ensureStateIsUpToDate(ctx, mode); err != nil {
-		return err
+		return nil, err
 	}
 
 	// I'm not entirely sure this is even needed, so ignoring the error.
 	// It's definitely not needed for non-flakes. (which is 99.9% of packages)
 	// It will return an error if .devbox/gen/flake is missing
 	// TODO: Remove this if it's not needed.
-	_ = nix.FlakeUpdate(shellgen.FlakePath(d))
+	_ = nix.FlakeUpdate(ctx, shellgen.FlakePath(d))
 
 	// fix any missing store paths.
 	if err = d.FixMissingStorePaths(ctx); err != nil {
-		return errors.WithStack(err)
+		return nil, errors.WithStack(err)
+	}
+
+	if opts.AllSystems {
+		if err := d.resolveAllSystems(ctx, inputs); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := plugin.Update(); err != nil {
+		return nil, err
 	}
 
-	return plugin.Update()
+	changelogs := d.printChangelogs(ctx, pendingPackagesToUpdate, versionsBefore, opts.Changelog)
+
+	// The packages aren't installed with --no-install, so the hook can't
+	// run in the devbox environment.
+	updated := lo.Map(inputs, func(pkg *devpkg.Package, _ int) string { return pkg.Raw })
+	return changelogs, d.runLifecycleHook(ctx, configfile.PostUpdateHook, !opts.NoInstall, updated)
+}
+
+// resolveAllSystems records the store paths of the packages in devbox.lock for
+// every system in lockSystems that they're enabled on, so that the first
+// checkout on another platform doesn't have to resolve them.
+func (d *Devbox) resolveAllSystems(ctx context.Context, pkgs []*devpkg.Package) error {
+	systems, err := d.lockSystems()
+	if err != nil {
+		return err
+	}
+	filled := 0
+	for _, pkg := range pkgs {
+		if !pkg.IsDevboxPackage || pkg.IsRunX() {
+			continue
+		}
+		enabled := systems
+		if cfgPackage, _ := d.cfg.Root.GetPackage(pkg.Raw); cfgPackage != nil {
+			enabled = lo.Filter(systems, func(system string, _ int) bool {
+				return cfgPackage.IsEnabledOnSystem(system)
+			})
+		}
+		if len(enabled) == 0 {
+			continue
+		}
+		changed, err := d.lockfile.FillSystems(ctx, pkg.LockfileKey(), enabled)
+		if err != nil {
+			return err
+		}
+		if changed {
+			filled++
+		}
+	}
+	if filled > 0 {
+		ux.Finfof(d.stderr, "Recorded the store paths of %d packages for %s in devbox.lock.\n",
+			filled, strings.Join(systems, ", "))
+	}
+	return d.lockfile.Save()
 }
 
 func (d *Devbox) inputsToUpdate(
 	opts devopt.UpdateOpts,
 ) ([]*devpkg.Package, error) {
 	if len(opts.Pkgs) == 0 {
-		return d.AllPackages(), nil
+		return lo.Reject(d.AllPackages(), func(pkg *devpkg.Package, _ int) bool {
+			return d.skipUpdate(pkg)
+		}), nil
 	}
 
 	var pkgsToUpdate []*devpkg.Package
@@ -114,6 +205,9 @@ func (d *Devbox) inputsToUpdate(
 		} else if err != nil {
 			return nil, err
 		}
+		if d.skipUpdate(found) {
+			continue
+		}
 		pkgsToUpdate = append(pkgsToUpdate, found)
 	}
 	return pkgsToUpdate, nil
@@ -123,16 +217,20 @@ func (d *Devbox) inputsToUpdate(
 // the right strategy per package kind. Flake refs warn-and-continue on
 // failure (see #1180 / #1840); versioned nixpkgs packages abort the update on
 // failure. Unversioned non-flake entries are left alone.
-func (d *Devbox) updatePendingPackages(pkgs []*devpkg.Package) error {
+func (d *Devbox) updatePendingPackages(ctx context.Context, pkgs []*devpkg.Package) error {
 	for _, pkg := range pkgs {
 		if pkgtype.IsFlake(pkg.Raw) {
-			if err := d.updateDevboxPackage(pkg); err != nil {
+			if err := d.updateDevboxPackage(ctx, pkg); err != nil {
 				ux.Fwarningf(d.stderr, "Failed to update %s: %s\n", pkg.Raw, err)
 			}
 			continue
 		}
 		if _, _, isVersioned := searcher.ParseVersionedPackage(pkg.Raw); isVersioned {
-			if err := d.updateDevboxPackage(pkg); err != nil {
+			target, ok := d.updateTarget(pkg)
+			if !ok {
+				continue
+			}
+			if err := d.updateDevboxPackageTo(ctx, pkg, target); err != nil {
 				return err
 			}
 		}
@@ -140,11 +238,17 @@ func (d *Devbox) updatePendingPackages(pkgs []*devpkg.Package) error {
 	return nil
 }
 
-func (d *Devbox) updateDevboxPackage(pkg *devpkg.Package) error {
+func (d *Devbox) updateDevboxPackage(ctx context.Context, pkg *devpkg.Package) error {
+	return d.updateDevboxPackageTo(ctx, pkg, pkg.Raw)
+}
+
+// updateDevboxPackageTo updates the lockfile entry of pkg to the resolution
+// of target, which is pkg.Raw unless an update policy limits the version.
+func (d *Devbox) updateDevboxPackageTo(ctx context.Context, pkg *devpkg.Package, target string) error {
 	// refresh=true so flake refs bypass nix's own metadata cache and re-query
 	// upstream. Without this, `devbox update` on a github: ref can return a
 	// stale commit that nix had cached from an earlier call.
-	resolved, err := d.lockfile.FetchResolvedPackage(pkg.Raw, true)
+	resolved, err := d.lockfile.FetchResolvedPackage(ctx, target, true)
 	if err != nil {
 		return err
 	}
@@ -189,6 +293,26 @@ func (d *Devbox) mergeResolvedPackageToLockfile(
 		return nil
 	}
 
+	// Record the runx checksums that devbox.lock doesn't have yet. The ones
+	// it has aren't replaced, because a different checksum for the same
+	// release means that the release was modified after it was locked.
+	if len(resolved.Checksums) > 0 {
+		added := false
+		for system, checksum := range resolved.Checksums {
+			if existing.Checksums[system] == "" {
+				if existing.Checksums == nil {
+					existing.Checksums = map[string]string{}
+				}
+				existing.Checksums[system] = checksum
+				added = true
+			}
+		}
+		if added {
+			ux.Finfof(d.stderr, "Recorded the checksums of %s\n", pkg)
+			return nil
+		}
+	}
+
 	// Add any missing system infos for packages whose versions did not change.
 	if lockfile.Packages[pkg.Raw].Systems == nil {
 		lockfile.Packages[pkg.Raw].Systems = map[string]*lock.SystemInfo{}
@@ -304,4 +428,5 @@ func useResolvedPackageInLockfile(
 ) {
 	lockfile.Packages[pkg.Raw] = resolved
 	lockfile.Packages[pkg.Raw].AllowInsecure = existing.AllowInsecure
+	lockfile.Packages[pkg.Raw].InsecureAcceptances = existing.InsecureAcceptances
 }
@@ -0,0 +1,244 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+	"go.jetify.com/devbox/internal/devbox/devopt"
+	"go.jetify.com/devbox/internal/devpkg/pkgtype"
+	"go.jetify.com/devbox/internal/httpclient"
+	"go.jetify.com/devbox/internal/lock"
+	"go.jetify.com/devbox/internal/ux"
+)
+
+// checkCanCommitUpdate checks, before anything is updated, that devbox update
+// --commit can commit the update the way opts asks it to.
+func (d *Devbox) checkCanCommitUpdate(ctx context.Context, opts devopt.UpdateOpts) error {
+	if !d.isGitRepo(ctx) {
+		return usererr.New("Can't commit the update because %s isn't in a git repository.", d.projectDir)
+	}
+	status, err := d.gitOutput(ctx, append([]string{"status", "--porcelain", "--"}, d.updateFiles()...)...)
+	if err != nil {
+		return err
+	}
+	if status != "" {
+		return usererr.New(
+			"Can't commit the update because devbox.json or devbox.lock has uncommitted changes. " +
+				"Commit or stash them first.",
+		)
+	}
+	if opts.Branch != "" && d.git(ctx, "rev-parse", "--verify", "--quiet", "refs/heads/"+opts.Branch) == nil {
+		return usererr.New("Branch %q already exists.", opts.Branch)
+	}
+	if !opts.PullRequest {
+		return nil
+	}
+	if _, _, err := d.githubRemote(ctx); err != nil {
+		return err
+	}
+	if pkgtype.GithubToken() == "" {
+		return usererr.New("Opening a pull request needs a GitHub token in the GITHUB_TOKEN environment variable.")
+	}
+	return nil
+}
+
+// commitUpdate commits the changes that devbox update made to devbox.json
+// and devbox.lock, with a message that lists the updated packages and their
+// changelogs. It doesn't commit anything if nothing changed.
+func (d *Devbox) commitUpdate(ctx context.Context, opts devopt.UpdateOpts, changelogs map[string]string) error {
+	files := d.updateFiles()
+	status, err := d.gitOutput(ctx, append([]string{"status", "--porcelain", "--"}, files...)...)
+	if err != nil {
+		return err
+	}
+	if status == "" {
+		ux.Finfof(d.stderr, "Nothing was updated, so there's nothing to commit.\n")
+		return nil
+	}
+
+	old, err := d.gitLockfile(ctx, "HEAD")
+	if err != nil {
+		return err
+	}
+	title, body := updateCommitMessage(lock.Diff(old, d.lockfile), changelogs)
+
+	base, err := d.gitOutput(ctx, "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return err
+	}
+	if opts.Branch != "" {
+		if _, err := d.gitOutput(ctx, "checkout", "-b", opts.Branch); err != nil {
+			return err
+		}
+	}
+	if _, err := d.gitOutput(ctx, append([]string{"add", "--"}, files...)...); err != nil {
+		return err
+	}
+	commit := append([]string{"commit", "-m", title, "-m", body, "--"}, files...)
+	if _, err := d.gitOutput(ctx, commit...); err != nil {
+		return err
+	}
+	ux.Fsuccessf(d.stderr, "Committed the update: %s\n", title)
+
+	if !opts.PullRequest {
+		return nil
+	}
+	if _, err := d.gitOutput(ctx, "push", "--set-upstream", "origin", opts.Branch); err != nil {
+		return err
+	}
+	owner, repo, err := d.githubRemote(ctx)
+	if err != nil {
+		return err
+	}
+	prURL, err := openPullRequest(ctx, owner, repo, pullRequest{
+		Title: title,
+		Body:  body,
+		Head:  opts.Branch,
+		Base:  base,
+	})
+	if err != nil {
+		return err
+	}
+	ux.Fsuccessf(d.stderr, "Opened a pull request: %s\n", prURL)
+	return nil
+}
+
+// updateFiles returns the files that devbox update changes.
+func (d *Devbox) updateFiles() []string {
+	return []string{d.cfg.Root.AbsRootPath, filepath.Join(d.projectDir, "devbox.lock")}
+}
+
+// updateCommitMessage returns the title and body of the commit of an update
+// that made changes, with a link to the changelog of each package that has one.
+func updateCommitMessage(changes []lock.Change, changelogs map[string]string) (title, body string) {
+	title = "Update devbox packages"
+	if len(changes) == 1 && changes[0].NewVersion != "" {
+		title = fmt.Sprintf("Update %s to %s", changes[0].Name, changes[0].NewVersion)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Updated with `devbox update`.\n")
+	if len(changes) > 0 {
+		sb.WriteString("\n")
+	}
+	for _, change := range changes {
+		switch change.Kind {
+		case lock.Added:
+			fmt.Fprintf(&sb, "- %s: added %s\n", change.Name, change.NewVersion)
+		case lock.Removed:
+			fmt.Fprintf(&sb, "- %s: removed %s\n", change.Name, change.OldVersion)
+		case lock.Changed:
+			if change.OldVersion == change.NewVersion {
+				fmt.Fprintf(&sb, "- %s: rebuilt %s\n", change.Name, change.NewVersion)
+				continue
+			}
+			fallthrough
+		default:
+			fmt.Fprintf(&sb, "- %s: %s -> %s\n", change.Name, change.OldVersion, change.NewVersion)
+		}
+		if changelog := changelogs[change.Name]; changelog != "" {
+			fmt.Fprintf(&sb, "  Changelog: %s\n", changelog)
+		}
+	}
+	return title, sb.String()
+}
+
+// githubRemote returns the GitHub repository of the project's origin remote.
+func (d *Devbox) githubRemote(ctx context.Context) (owner, repo string, err error) {
+	remote, err := d.gitOutput(ctx, "remote", "get-url", "origin")
+	if err != nil {
+		return "", "", usererr.New("Opening a pull request needs an origin remote.")
+	}
+	owner, repo, ok := parseGithubRemote(remote)
+	if !ok {
+		return "", "", usererr.New("Can't open a pull request because origin (%s) isn't a GitHub repository.", remote)
+	}
+	return owner, repo, nil
+}
+
+// parseGithubRemote parses the owner and name of a GitHub repository from a git
+// remote URL, such as git@github.com:jetify-com/devbox.git or
+// https://github.com/jetify-com/devbox.
+func parseGithubRemote(remote string) (owner, repo string, ok bool) {
+	var path string
+	if rest, found := strings.CutPrefix(remote, "git@github.com:"); found {
+		path = rest
+	} else {
+		u, err := url.Parse(remote)
+		if err != nil || u.Host != "github.com" {
+			return "", "", false
+		}
+		path = u.Path
+	}
+	owner, repo, found := strings.Cut(strings.Trim(strings.TrimSuffix(path, ".git"), "/"), "/")
+	if !found || owner == "" || repo == "" || strings.Contains(repo, "/") {
+		return "", "", false
+	}
+	return owner, repo, true
+}
+
+type pullRequest struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	Head  string `json:"head"`
+	Base  string `json:"base"`
+}
+
+// openPullRequest opens a pull request with the GitHub API and returns its URL.
+func openPullRequest(ctx context.Context, owner, repo string, pr pullRequest) (string, error) {
+	data, err := json.Marshal(pr)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	u := fmt.Sprintf("%srepos/%s/%s/pulls", githubAPIURL, owner, repo)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(data))
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Content-Type", "application/json")
+	if token := pkgtype.GithubToken(); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	res, err := httpclient.Client().Do(req)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusCreated {
+		return "", usererr.New("Failed to open a pull request: POST %s: %s", u, res.Status)
+	}
+	created := struct {
+		HTMLURL string `json:"html_url"`
+	}{}
+	if err := json.NewDecoder(res.Body).Decode(&created); err != nil {
+		return "", errors.WithStack(err)
+	}
+	return created.HTMLURL, nil
+}
+
+// gitOutput runs git in the project directory and returns its trimmed output.
+// The error includes what git printed to stderr.
+func (d *Devbox) gitOutput(ctx context.Context, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", d.projectDir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", errors.Errorf("git %s: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
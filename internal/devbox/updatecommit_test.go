@@ -0,0 +1,96 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.jetify.com/devbox/internal/lock"
+)
+
+func TestParseGithubRemote(t *testing.T) {
+	tests := []struct {
+		remote string
+		owner  string
+		repo   string
+		ok     bool
+	}{
+		{"git@github.com:jetify-com/devbox.git", "jetify-com", "devbox", true},
+		{"https://github.com/jetify-com/devbox.git", "jetify-com", "devbox", true},
+		{"https://github.com/jetify-com/devbox", "jetify-com", "devbox", true},
+		{"ssh://git@github.com/jetify-com/devbox.git", "jetify-com", "devbox", true},
+		{"https://gitlab.com/jetify-com/devbox.git", "", "", false},
+		{"https://github.com/jetify-com", "", "", false},
+		{"/srv/git/devbox.git", "", "", false},
+	}
+	for _, tt := range tests {
+		owner, repo, ok := parseGithubRemote(tt.remote)
+		if owner != tt.owner || repo != tt.repo || ok != tt.ok {
+			t.Errorf("parseGithubRemote(%q) = %q, %q, %v, want %q, %q, %v",
+				tt.remote, owner, repo, ok, tt.owner, tt.repo, tt.ok)
+		}
+	}
+}
+
+func TestUpdateCommitMessage(t *testing.T) {
+	changes := []lock.Change{
+		{Name: "go", Kind: lock.Upgraded, OldVersion: "1.22.1", NewVersion: "1.22.2"},
+		{Name: "hello", Kind: lock.Changed, OldVersion: "2.12.1", NewVersion: "2.12.1"},
+	}
+	changelogs := map[string]string{"go": "https://go.dev/doc/devel/release#go1.22.2"}
+	title, body := updateCommitMessage(changes, changelogs)
+	if want := "Update devbox packages"; title != want {
+		t.Errorf("got title %q, want %q", title, want)
+	}
+	wantBody := "Updated with `devbox update`.\n\n" +
+		"- go: 1.22.1 -> 1.22.2\n" +
+		"  Changelog: https://go.dev/doc/devel/release#go1.22.2\n" +
+		"- hello: rebuilt 2.12.1\n"
+	if body != wantBody {
+		t.Errorf("got body %q, want %q", body, wantBody)
+	}
+
+	title, _ = updateCommitMessage(changes[:1], changelogs)
+	if want := "Update go to 1.22.2"; title != want {
+		t.Errorf("got title %q, want %q", title, want)
+	}
+}
+
+func TestOpenPullRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/repos/jetify-com/devbox/pulls" {
+			http.NotFound(w, r)
+			return
+		}
+		pr := pullRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&pr); err != nil || pr.Head != "devbox-update" || pr.Base != "main" {
+			http.Error(w, "bad request", http.StatusUnprocessableEntity)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"html_url": "https://github.com/jetify-com/devbox/pull/1"}`)
+	}))
+	defer server.Close()
+	githubAPIURL = server.URL + "/"
+	t.Cleanup(func() { githubAPIURL = "https://api.github.com/" })
+
+	pr := pullRequest{Title: "Update devbox packages", Head: "devbox-update", Base: "main"}
+	got, err := openPullRequest(context.Background(), "jetify-com", "devbox", pr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "https://github.com/jetify-com/devbox/pull/1"; got != want {
+		t.Errorf("openPullRequest() = %q, want %q", got, want)
+	}
+
+	pr.Base = "develop"
+	if _, err := openPullRequest(context.Background(), "jetify-com", "devbox", pr); err == nil {
+		t.Error("openPullRequest() with a rejected request returned no error")
+	}
+}
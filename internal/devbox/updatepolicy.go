@@ -0,0 +1,86 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.jetify.com/devbox/internal/devconfig/configfile"
+	"go.jetify.com/devbox/internal/devpkg"
+	"go.jetify.com/devbox/internal/ux"
+)
+
+// skipUpdate reports whether pkg was pinned with devbox pin or has the ignore
+// update policy, and tells the user that it isn't updated.
+func (d *Devbox) skipUpdate(pkg *devpkg.Package) bool {
+	cfgPkg, ok := d.cfg.Root.GetPackage(pkg.Raw)
+	switch {
+	case !ok:
+		return false
+	case cfgPkg.IsPinned():
+		ux.Finfof(d.stderr, "Skipping pinned package %s. Run `devbox unpin %s` to update it.\n",
+			pkg.Raw, pkg.CanonicalName())
+		return true
+	case cfgPkg.Update == configfile.UpdateIgnore:
+		ux.Finfof(d.stderr, "Skipping %s because its update policy is %s.\n", pkg.Raw, cfgPkg.Update)
+		return true
+	}
+	return false
+}
+
+// updateTarget returns the versioned name to resolve pkg at when it's updated,
+// according to its update policy. It returns pkg.Raw if the package doesn't
+// have a policy, and false if it can't be updated under its policy.
+func (d *Devbox) updateTarget(pkg *devpkg.Package) (string, bool) {
+	cfgPkg, ok := d.cfg.Root.GetPackage(pkg.Raw)
+	locked := d.lockfile.Get(pkg.Raw)
+	if !ok || locked == nil || locked.Version == "" {
+		return pkg.Raw, true
+	}
+	version, err := updatePolicyVersion(cfgPkg.Update, cfgPkg.Version, locked.Version)
+	if err != nil {
+		ux.Fwarningf(d.stderr, "Not updating %s: %v\n", pkg.Raw, err)
+		return "", false
+	}
+	return cfgPkg.Name + "@" + version, true
+}
+
+// updatePolicyVersion returns the version to resolve a package at when it's
+// updated, given its update policy, its version in devbox.json and its locked
+// version. For example, the patch policy resolves nodejs@20 that's locked at
+// 20.11.0 at 20.11, which the search index resolves to the newest 20.11.x.
+func updatePolicyVersion(policy configfile.UpdatePolicy, configVersion, lockedVersion string) (string, error) {
+	var keep int
+	switch policy {
+	case configfile.UpdateMinor:
+		keep = 1
+	case configfile.UpdatePatch:
+		keep = 2
+	default:
+		return configVersion, nil
+	}
+
+	parts := strings.Split(lockedVersion, ".")
+	if len(parts) < keep {
+		return "", fmt.Errorf("update policy %s needs a version with at least %d parts, but it's locked at %s",
+			policy, keep, lockedVersion)
+	}
+	for _, part := range parts[:keep] {
+		if _, err := strconv.Atoi(part); err != nil {
+			return "", fmt.Errorf("update policy %s needs a numeric version, but it's locked at %s",
+				policy, lockedVersion)
+		}
+	}
+	version := strings.Join(parts[:keep], ".")
+
+	// The version in devbox.json is more specific than the policy, so it
+	// already limits the update further.
+	if configVersion != "" && configVersion != "latest" &&
+		version != configVersion && !strings.HasPrefix(version, configVersion+".") {
+		return configVersion, nil
+	}
+	return version, nil
+}
@@ -0,0 +1,43 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"testing"
+
+	"go.jetify.com/devbox/internal/devconfig/configfile"
+)
+
+func TestUpdatePolicyVersion(t *testing.T) {
+	tests := []struct {
+		policy        configfile.UpdatePolicy
+		configVersion string
+		lockedVersion string
+		want          string
+		wantErr       bool
+	}{
+		{"", "20", "20.11.0", "20", false},
+		{configfile.UpdateMajor, "latest", "20.11.0", "latest", false},
+		{configfile.UpdatePatch, "20", "20.11.0", "20.11", false},
+		{configfile.UpdatePatch, "latest", "20.11.0", "20.11", false},
+		{configfile.UpdateMinor, "20", "20.11.0", "20", false},
+		{configfile.UpdateMinor, "latest", "20.11.0", "20", false},
+		{configfile.UpdateMinor, "20.11", "20.11.0", "20.11", false},
+		{configfile.UpdatePatch, "20.11.0", "20.11.0", "20.11.0", false},
+		{configfile.UpdatePatch, "latest", "20", "", true},
+		{configfile.UpdateMinor, "latest", "unstable-2024-01-01", "", true},
+	}
+	for _, tt := range tests {
+		got, err := updatePolicyVersion(tt.policy, tt.configVersion, tt.lockedVersion)
+		if gotErr := err != nil; gotErr != tt.wantErr {
+			t.Errorf("updatePolicyVersion(%q, %q, %q) error = %v, wantErr %v",
+				tt.policy, tt.configVersion, tt.lockedVersion, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("updatePolicyVersion(%q, %q, %q) = %q, want %q",
+				tt.policy, tt.configVersion, tt.lockedVersion, got, tt.want)
+		}
+	}
+}
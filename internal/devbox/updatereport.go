@@ -0,0 +1,142 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+	"go.jetify.com/devbox/internal/devpkg"
+	"go.jetify.com/devbox/internal/nix"
+	"go.jetify.com/devbox/internal/ux"
+	"go.jetify.com/devbox/nix/flake"
+)
+
+// updateProblem is a package that an update resolved to a nixpkgs revision
+// where it was removed, renamed, or marked broken or insecure.
+type updateProblem struct {
+	pkg           string
+	status        nix.PackageStatus
+	allowInsecure bool
+}
+
+// blocking reports whether the package can't be installed from the new
+// revision, as opposed to a rename that still works through an alias.
+func (p updateProblem) blocking() bool {
+	return p.status.Removed || p.status.Broken || (p.status.Insecure && !p.allowInsecure)
+}
+
+func (p updateProblem) String() string {
+	name, _, _ := strings.Cut(p.pkg, "@")
+	s := p.status
+	var msg string
+	switch {
+	case s.Removed && s.RenamedTo != "":
+		msg = fmt.Sprintf("was renamed to %[1]s. Replace it with `devbox rm %[2]s && devbox add %[1]s@latest`",
+			s.RenamedTo, name)
+	case s.Removed:
+		msg = fmt.Sprintf("was removed from nixpkgs. Remove it with `devbox rm %s` or pin an older version", name)
+	case s.Broken:
+		msg = "is marked broken in the new nixpkgs. Pin an older version until it's fixed"
+	case s.Insecure && !p.allowInsecure:
+		msg = "is marked insecure in the new nixpkgs"
+		if len(s.KnownVulnerabilities) > 0 {
+			msg += " (" + strings.Join(s.KnownVulnerabilities, "; ") + ")"
+		}
+		msg += fmt.Sprintf(". Allow it with `devbox add %s --allow-insecure=<name>` or pin an older version", p.pkg)
+	case s.RenamedTo != "":
+		msg = fmt.Sprintf("was renamed to %s and only works through an alias, which may be removed later", s.RenamedTo)
+	default:
+		return ""
+	}
+	if s.Message != "" {
+		msg += "\n    nixpkgs: " + s.Message
+	}
+	return fmt.Sprintf("  - %s %s", p.pkg, msg)
+}
+
+// resolvedPackages returns the resolved installable of each package in
+// devbox.lock, so that checkUpdatedPackages can tell which ones an update
+// changed.
+func (d *Devbox) resolvedPackages(pkgs []*devpkg.Package) map[string]string {
+	resolved := map[string]string{}
+	for _, pkg := range pkgs {
+		if locked := d.lockfile.Get(pkg.Raw); locked != nil {
+			resolved[pkg.Raw] = locked.Resolved
+		}
+	}
+	return resolved
+}
+
+// nixpkgsEvalInstallable returns the installable to evaluate a package that
+// resolved to a nixpkgs installable with for system. It returns false if
+// resolved isn't a nixpkgs installable.
+func nixpkgsEvalInstallable(resolved, system string) (string, bool) {
+	installable, err := flake.ParseInstallable(resolved)
+	if err != nil || !installable.Ref.IsNixpkgs() || installable.AttrPath == "" {
+		return "", false
+	}
+	if !strings.HasPrefix(installable.AttrPath, "legacyPackages.") &&
+		!strings.HasPrefix(installable.AttrPath, "packages.") {
+		installable.AttrPath = "legacyPackages." + system + "." + installable.AttrPath
+	}
+	installable.Outputs = ""
+	return installable.String(), true
+}
+
+// checkUpdatedPackages evaluates the packages whose nixpkgs revision changed
+// since before, and returns an error with a migration report if any of them
+// can't be installed from the new revision. It runs before anything is
+// installed or written to devbox.lock, so that the update doesn't fail halfway.
+// Renamed packages that still work through an alias are only a warning.
+func (d *Devbox) checkUpdatedPackages(ctx context.Context, pkgs []*devpkg.Package, before map[string]string) error {
+	problems := []updateProblem{}
+	for _, pkg := range pkgs {
+		locked := d.lockfile.Get(pkg.Raw)
+		if locked == nil || locked.Resolved == before[pkg.Raw] {
+			continue
+		}
+		installable, ok := nixpkgsEvalInstallable(locked.Resolved, nix.System())
+		if !ok {
+			continue
+		}
+
+		status, err := nix.EvalPackageStatus(ctx, installable)
+		if err != nil {
+			// Installing will report the error if it's real.
+			slog.Debug("failed to evaluate the status of an updated package", "pkg", pkg.Raw, "err", err)
+			continue
+		}
+		if status.OK() {
+			continue
+		}
+		problems = append(problems, updateProblem{
+			pkg:           pkg.Raw,
+			status:        status,
+			allowInsecure: pkg.HasAllowInsecure() || nix.IsInsecureAllowed(),
+		})
+	}
+
+	blocking, warnings := []string{}, []string{}
+	for _, p := range problems {
+		if p.blocking() {
+			blocking = append(blocking, p.String())
+		} else if s := p.String(); s != "" {
+			warnings = append(warnings, s)
+		}
+	}
+	if len(warnings) > 0 {
+		ux.Fwarningf(d.stderr, "Some updated packages were renamed in nixpkgs:\n%s\n", strings.Join(warnings, "\n"))
+	}
+	if len(blocking) > 0 {
+		return usererr.New(
+			"Some packages can't be installed from the updated nixpkgs, so they weren't updated:\n%s\n\n"+
+				"Change them in devbox.json and run `devbox update` again.",
+			strings.Join(blocking, "\n"))
+	}
+	return nil
+}
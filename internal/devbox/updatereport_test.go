@@ -0,0 +1,72 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"strings"
+	"testing"
+
+	"go.jetify.com/devbox/internal/nix"
+)
+
+func TestUpdateProblem(t *testing.T) {
+	cases := []struct {
+		name         string
+		problem      updateProblem
+		wantBlocking bool
+		wantContains string
+	}{
+		{
+			name:         "renamed and removed",
+			problem:      updateProblem{pkg: "nodejs-16_x@latest", status: nix.PackageStatus{Removed: true, RenamedTo: "nodejs_16"}},
+			wantBlocking: true,
+			wantContains: "devbox rm nodejs-16_x && devbox add nodejs_16@latest",
+		},
+		{
+			name:         "alias",
+			problem:      updateProblem{pkg: "exa@latest", status: nix.PackageStatus{RenamedTo: "eza"}},
+			wantBlocking: false,
+			wantContains: "was renamed to eza",
+		},
+		{
+			name:         "broken",
+			problem:      updateProblem{pkg: "foo@1.0", status: nix.PackageStatus{Broken: true}},
+			wantBlocking: true,
+			wantContains: "is marked broken",
+		},
+		{
+			name: "insecure",
+			problem: updateProblem{pkg: "openssl@1.1", status: nix.PackageStatus{
+				Insecure:             true,
+				KnownVulnerabilities: []string{"OpenSSL 1.1 is end of life"},
+			}},
+			wantBlocking: true,
+			wantContains: "(OpenSSL 1.1 is end of life)",
+		},
+		{
+			name: "allowed insecure",
+			problem: updateProblem{
+				pkg:           "openssl@1.1",
+				status:        nix.PackageStatus{Insecure: true},
+				allowInsecure: true,
+			},
+			wantBlocking: false,
+			wantContains: "",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.problem.blocking(); got != tc.wantBlocking {
+				t.Errorf("got blocking() = %v, want %v", got, tc.wantBlocking)
+			}
+			got := tc.problem.String()
+			if tc.wantContains == "" && got != "" {
+				t.Errorf("got String() = %q, want empty", got)
+			}
+			if !strings.Contains(got, tc.wantContains) {
+				t.Errorf("got String() = %q, want it to contain %q", got, tc.wantContains)
+			}
+		})
+	}
+}
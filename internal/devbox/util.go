@@ -14,7 +14,7 @@
 	"github.com/pkg/errors"
 
 	"go.jetify.com/devbox/internal/devbox/devopt"
-	"go.jetify.com/devbox/internal/xdg"
+	"go.jetify.com/devbox/internal/state"
 )
 
 const processComposeVersion = "1.110.0"
@@ -96,7 +96,7 @@ func utilityLookPath(binName string) (string, error) {
 }
 
 func utilityDataPath() (string, error) {
-	path := xdg.DataSubpath("devbox/util")
+	path := state.Data("util")
 	return path, errors.WithStack(os.MkdirAll(path, 0o755))
 }
 
@@ -0,0 +1,132 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+	"go.jetify.com/devbox/internal/cachehash"
+	"go.jetify.com/devbox/internal/devbox/devopt"
+	"go.jetify.com/devbox/internal/remote"
+	"go.jetify.com/devbox/internal/ux"
+	"go.jetify.com/devbox/internal/vm"
+)
+
+// vmBootTimeout is how long to wait for a new VM to accept SSH connections.
+const vmBootTimeout = 10 * time.Minute
+
+// VM returns the project's VM, or nil if it doesn't have one.
+func (d *Devbox) VM() (*vm.Machine, error) {
+	return vm.Load(d.vmStatePath())
+}
+
+// VMUp creates a VM in the user's cloud account, installs Devbox and the
+// project's packages on it, and syncs the project to it with mutagen if
+// mutagen is installed.
+func (d *Devbox) VMUp(ctx context.Context, opts devopt.VMUpOpts) (*vm.Machine, error) {
+	existing, err := d.VM()
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, usererr.New(
+			"This project already has the %s VM %s. Delete it with `devbox vm down` first.",
+			existing.Provider, existing.Name)
+	}
+	provider, err := vm.NewProvider(opts.Provider)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := vm.PublicKey()
+	if err != nil {
+		return nil, err
+	}
+
+	name := vm.MachineName(d.projectDir, cachehash.Bytes6([]byte(d.projectDir+time.Now().String())))
+	ux.Finfof(d.stderr, "Creating the %s VM %s\n", opts.Provider, name)
+	m, err := provider.Create(ctx, vm.CreateOpts{
+		Name:            name,
+		Region:          opts.Region,
+		Size:            opts.Size,
+		PublicKey:       publicKey,
+		SSHFromAnywhere: opts.SSHFromAnywhere,
+		Stderr:          d.stderr,
+	})
+	// Save the VM even if it isn't ready, so that devbox vm down can
+	// delete it.
+	if m != nil {
+		if err := m.Save(d.vmStatePath()); err != nil {
+			return nil, err
+		}
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create the VM. Run `devbox vm down` to delete what was created")
+	}
+
+	ux.Finfof(d.stderr, "Waiting for %s to boot\n", m.Host)
+	if err := m.Target().WaitForSSH(ctx, vmBootTimeout); err != nil {
+		return nil, err
+	}
+	ux.Finfof(d.stderr, "Installing Devbox and the project's packages on %s\n", m.Host)
+	if m.Dir, err = remote.Setup(ctx, os.Stdout, d.stderr, m.Target(), d.projectDir); err != nil {
+		return nil, err
+	}
+	if err := m.Save(d.vmStatePath()); err != nil {
+		return nil, err
+	}
+
+	if m.SyncSession, err = vm.StartSync(ctx, d.stderr, m, d.projectDir); err != nil {
+		ux.Fwarningf(d.stderr, "Failed to start syncing the project to the VM: %v\n", err)
+	} else if m.SyncSession == "" {
+		ux.Finfof(d.stderr, "Install mutagen to keep the project in sync with the VM. "+
+			"Until then, devbox run --on copies it before each run.\n")
+	}
+	return m, m.Save(d.vmStatePath())
+}
+
+// VMDown deletes the project's VM.
+func (d *Devbox) VMDown(ctx context.Context) error {
+	m, err := d.VM()
+	if err != nil {
+		return err
+	}
+	if m == nil {
+		return usererr.New("This project doesn't have a VM.")
+	}
+	provider, err := vm.NewProvider(m.Provider)
+	if err != nil {
+		return err
+	}
+	if err := vm.StopSync(ctx, d.stderr, m); err != nil {
+		ux.Fwarningf(d.stderr, "Failed to stop syncing the project to the VM: %v\n", err)
+	}
+	ux.Finfof(d.stderr, "Deleting the %s VM %s\n", m.Provider, m.Name)
+	if err := provider.Destroy(ctx, m, d.stderr); err != nil {
+		return err
+	}
+	return errors.WithStack(os.Remove(d.vmStatePath()))
+}
+
+// VMShell opens devbox shell on the project's VM.
+func (d *Devbox) VMShell(ctx context.Context) error {
+	m, err := d.VM()
+	if err != nil {
+		return err
+	}
+	if m == nil {
+		return usererr.New("This project doesn't have a VM. Create one with `devbox vm up`.")
+	}
+	t := m.Target()
+	return remote.Exec(ctx, os.Stdout, d.stderr, t, t.ProjectDir(d.projectDir), "shell")
+}
+
+func (d *Devbox) vmStatePath() string {
+	return filepath.Join(d.projectDir, ".devbox", "vm.json")
+}
@@ -1,6 +1,7 @@
 package devconfig
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"io"
@@ -9,6 +10,7 @@
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
 	"syscall"
 	"time"
 
@@ -19,6 +21,7 @@
 	"go.jetify.com/devbox/internal/cachehash"
 	"go.jetify.com/devbox/internal/devbox/shellcmd"
 	"go.jetify.com/devbox/internal/devconfig/configfile"
+	"go.jetify.com/devbox/internal/httpclient"
 	"go.jetify.com/devbox/internal/lock"
 	"go.jetify.com/devbox/internal/plugin"
 )
@@ -200,7 +203,7 @@ func LoadConfigFromURL(ctx context.Context, url string) (*Config, error) {
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
-	res, err := http.DefaultClient.Do(req)
+	res, err := httpclient.Client().Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -236,9 +239,18 @@ func (c *Config) loadRecursive(
 	seen map[string]bool,
 	cyclePath string,
 ) error {
-	included := make([]*Config, 0, len(c.Root.Include))
-
-	for _, includeRef := range c.Root.Include {
+	includes := c.Root.Include
+	if cyclePath == "" && c.Root.AbsRootPath != "" {
+		// Plugins linked with `devbox plugin dev` are only included by the
+		// project's root config.
+		includes = append(
+			slices.Clone(includes),
+			plugin.DevIncludes(filepath.Dir(c.Root.AbsRootPath))...,
+		)
+	}
+	included := make([]*Config, 0, len(includes))
+
+	for _, includeRef := range includes {
 		pluginConfig, err := plugin.LoadConfigFromInclude(
 			includeRef, lockfile, filepath.Dir(c.Root.AbsRootPath))
 		if err != nil {
@@ -349,24 +361,61 @@ func (c *Config) NixPkgsCommitHash() string {
 	return c.Root.NixPkgsCommitHash()
 }
 
+// PackageSources returns the package sources of the project's devbox.json.
+// Included configs can't declare sources.
+func (c *Config) PackageSources() map[string]string {
+	return c.Root.PackageSources
+}
+
 func (c *Config) Env() map[string]string {
 	env := map[string]string{}
+	for _, src := range c.EnvSources() {
+		maps.Copy(env, src.Env)
+	}
+	return env
+}
+
+// EnvSource is the env of a single config.
+type EnvSource struct {
+	// Name is "plugin <name>" for included configs and "devbox.json" for
+	// the root config.
+	Name string
+	Env  map[string]string
+}
+
+// EnvSources returns the env of each included config (plugin) followed by the
+// env of this config, in the order that Env merges them. Values are expanded
+// the same way as in Env.
+func (c *Config) EnvSources() []EnvSource {
+	env := map[string]string{}
+	sources := make([]EnvSource, 0, len(c.included)+1)
 	for _, i := range c.included {
 		expandedEnvFromPlugin := OSExpandIfPossible(i.Env(), env)
 		maps.Copy(env, expandedEnvFromPlugin)
+		sources = append(sources, EnvSource{
+			Name: "plugin " + cmp.Or(i.Root.Name, "(unnamed)"),
+			Env:  expandedEnvFromPlugin,
+		})
 	}
 	rootConfigEnv := OSExpandIfPossible(c.Root.Env, env)
-	maps.Copy(env, rootConfigEnv)
-	return env
+	return append(sources, EnvSource{Name: "devbox.json", Env: rootConfigEnv})
 }
 
-func (c *Config) InitHook() *shellcmd.Commands {
-	commands := shellcmd.Commands{}
+// InitHook returns the init hooks of the included configs (plugins) followed
+// by the hooks in this config. Hooks from plugins have their Source set to the
+// plugin's name.
+func (c *Config) InitHook() *shellcmd.Hooks {
+	hooks := shellcmd.Hooks{}
 	for _, i := range c.included {
-		commands.Cmds = append(commands.Cmds, i.InitHook().Cmds...)
+		for _, hook := range i.InitHook().Hooks {
+			if hook.Source == "" {
+				hook.Source = cmp.Or(i.Root.Name, "plugin")
+			}
+			hooks.Hooks = append(hooks.Hooks, hook)
+		}
 	}
-	commands.Cmds = append(commands.Cmds, c.Root.InitHook().Cmds...)
-	return &commands
+	hooks.Hooks = append(hooks.Hooks, c.Root.InitHook().Hooks...)
+	return &hooks
 }
 
 // Aliases returns the merged shell aliases from this config and any included
@@ -381,15 +430,104 @@ func (c *Config) Aliases() map[string]string {
 	return aliases
 }
 
+// Scripts returns the scripts of the included configs (plugins), the project's
+// devbox.d/scripts directory and this config, in order of precedence.
 func (c *Config) Scripts() configfile.Scripts {
 	scripts := configfile.Scripts{}
 	for _, i := range c.included {
 		maps.Copy(scripts, i.Scripts())
 	}
+	maps.Copy(scripts, c.Root.ScriptFiles())
 	maps.Copy(scripts, c.Root.Scripts())
 	return scripts
 }
 
+// ScriptSource is the scripts of a config, or of the project's
+// devbox.d/scripts directory.
+type ScriptSource struct {
+	// Name is "plugin <name>" for included configs, "devbox.d/scripts" for
+	// script files and "devbox.json" for the root config.
+	Name    string
+	Scripts configfile.Scripts
+}
+
+// ScriptSources returns the scripts of each source in the same order of
+// precedence as Scripts. A script overrides the scripts of the same name in
+// the sources before it.
+func (c *Config) ScriptSources() []ScriptSource {
+	return c.scriptSources("devbox.json")
+}
+
+func (c *Config) scriptSources(name string) []ScriptSource {
+	sources := []ScriptSource{}
+	for _, i := range c.included {
+		sources = append(sources, i.scriptSources("plugin "+cmp.Or(i.Root.Name, "(unnamed)"))...)
+	}
+	if files := c.Root.ScriptFiles(); len(files) > 0 {
+		sources = append(sources, ScriptSource{Name: configfile.ScriptFilesDir, Scripts: files})
+	}
+	return append(sources, ScriptSource{Name: name, Scripts: c.Root.Scripts()})
+}
+
+// PolicySource is the org policy of a single config.
+type PolicySource struct {
+	// Name is "plugin <name>" for included configs and "devbox.json" for
+	// the root config.
+	Name   string
+	Policy *configfile.Policy
+
+	// Dir is the directory that a relative policy file path is relative
+	// to. It's empty for plugins that aren't local files.
+	Dir string
+}
+
+// Policies returns the org policies of the included configs (plugins)
+// followed by the policy of this config. Every policy applies, so a project
+// can't loosen the policy of a plugin it includes.
+func (c *Config) Policies() []PolicySource {
+	return c.policies("devbox.json")
+}
+
+func (c *Config) policies(name string) []PolicySource {
+	policies := []PolicySource{}
+	for _, i := range c.included {
+		policies = append(policies, i.policies("plugin "+cmp.Or(i.Root.Name, "(unnamed)"))...)
+	}
+	if c.Root.Policy == nil {
+		return policies
+	}
+	source := PolicySource{Name: name, Policy: c.Root.Policy}
+	if c.Root.AbsRootPath != "" {
+		source.Dir = filepath.Dir(c.Root.AbsRootPath)
+	}
+	return append(policies, source)
+}
+
+// TestSource is a test and the config that declares it.
+type TestSource struct {
+	// Name is "plugin <name>" for included configs and "devbox.json" for
+	// the root config.
+	Name string
+	Test *configfile.Test
+}
+
+// Tests returns the tests of the included configs (plugins) followed by the
+// tests of this config.
+func (c *Config) Tests() []TestSource {
+	return c.tests("devbox.json")
+}
+
+func (c *Config) tests(name string) []TestSource {
+	tests := []TestSource{}
+	for _, i := range c.included {
+		tests = append(tests, i.tests("plugin "+cmp.Or(i.Root.Name, "(unnamed)"))...)
+	}
+	for _, test := range c.Root.Tests {
+		tests = append(tests, TestSource{Name: name, Test: test})
+	}
+	return tests
+}
+
 func (c *Config) Hash() (string, error) {
 	data := []byte{}
 	for _, i := range c.included {
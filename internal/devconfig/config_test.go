@@ -567,5 +567,6 @@ type testLockProject struct {
 
 func (p *testLockProject) ConfigHash() (string, error)                              { return "", nil }
 func (p *testLockProject) Stdenv() flake.Ref                                        { return flake.Ref{} }
+func (p *testLockProject) PackageSources() map[string]string                        { return nil }
 func (p *testLockProject) AllPackageNamesIncludingRemovedTriggerPackages() []string { return nil }
 func (p *testLockProject) ProjectDir() string                                       { return p.dir }
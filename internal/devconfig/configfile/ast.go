@@ -3,9 +3,11 @@
 import (
 	"bytes"
 	"cmp"
+	"encoding/json"
 	"regexp"
 	"slices"
 
+	"github.com/pkg/errors"
 	"github.com/tailscale/hujson"
 )
 
@@ -193,6 +195,39 @@ func (c *configAST) setPackageBool(name, fieldName string, val bool) {
 	c.root.Format()
 }
 
+// setPackageJSON sets a field on a package to the JSON encoding of val,
+// replacing any existing value.
+func (c *configAST) setPackageJSON(name, fieldName string, val any) error {
+	pkgObject := c.findPkgObject(name)
+	if pkgObject == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(val)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	value, err := hujson.Parse(data)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if i := c.memberIndex(pkgObject, fieldName); i == -1 {
+		pkgObject.Members = append(pkgObject.Members, hujson.ObjectMember{
+			Name: hujson.Value{
+				Value:       hujson.String(fieldName),
+				BeforeExtra: []byte{'\n'},
+			},
+			Value: value,
+		})
+	} else {
+		pkgObject.Members[i].Value.Value = value.Value
+	}
+
+	c.root.Format()
+	return nil
+}
+
 func (c *configAST) appendPlatforms(name, fieldName string, platforms []string) {
 	if len(platforms) == 0 {
 		return
@@ -217,6 +252,58 @@ func (c *configAST) appendAllowInsecure(name, fieldName string, whitelist []stri
 	c.appendStringSliceField(name, fieldName, whitelist)
 }
 
+// setPackageVersion sets the version of the named package, whether the
+// package is a version string or an object.
+func (c *configAST) setPackageVersion(name, version string) {
+	pkgs := c.packagesField(true).Value.Value.(*hujson.Object)
+	i := c.memberIndex(pkgs, name)
+	if i == -1 {
+		return
+	}
+
+	pkg := &pkgs.Members[i].Value
+	obj, ok := pkg.Value.(*hujson.Object)
+	if !ok {
+		pkg.Value = hujson.String(version)
+		c.root.Format()
+		return
+	}
+	if j := c.memberIndex(obj, "version"); j != -1 {
+		obj.Members[j].Value.Value = hujson.String(version)
+	} else {
+		obj.Members = slices.Insert(obj.Members, 0, hujson.ObjectMember{
+			Name: hujson.Value{
+				Value:       hujson.String("version"),
+				BeforeExtra: []byte{'\n'},
+			},
+			Value: hujson.Value{Value: hujson.String(version)},
+		})
+	}
+	c.root.Format()
+}
+
+// removePackageField removes a field from the named package, if the package
+// is an object that has it.
+func (c *configAST) removePackageField(name, fieldName string) {
+	obj, ok := c.packagesField(false).Value.Value.(*hujson.Object)
+	if !ok {
+		return
+	}
+	i := c.memberIndex(obj, name)
+	if i == -1 {
+		return
+	}
+	obj, ok = obj.Members[i].Value.Value.(*hujson.Object)
+	if !ok {
+		return
+	}
+	if i = c.memberIndex(obj, fieldName); i == -1 {
+		return
+	}
+	obj.Members = slices.Delete(obj.Members, i, i+1)
+	c.root.Format()
+}
+
 // removePatch removes the patch field from the named package.
 func (c *configAST) removePatch(name string) {
 	pkgs := c.packagesField(false)
@@ -441,6 +528,36 @@ func (c *configAST) createMemberIfMissing(key string) *hujson.ObjectMember {
 	return &c.root.Value.(*hujson.Object).Members[i]
 }
 
+// setNestedString sets the string member at path, such as "java", "default",
+// creating the objects along the path if they don't exist.
+func (c *configAST) setNestedString(val string, path ...string) {
+	obj := c.root.Value.(*hujson.Object)
+	for i, key := range path {
+		idx := c.memberIndex(obj, key)
+		if idx == -1 {
+			obj.Members = append(obj.Members, hujson.ObjectMember{
+				Name: hujson.Value{
+					Value:       hujson.String(key),
+					BeforeExtra: []byte{'\n'},
+				},
+			})
+			idx = len(obj.Members) - 1
+		}
+		member := &obj.Members[idx]
+		if i == len(path)-1 {
+			member.Value.Value = hujson.String(val)
+			break
+		}
+		next, ok := member.Value.Value.(*hujson.Object)
+		if !ok {
+			next = &hujson.Object{}
+			member.Value.Value = next
+		}
+		obj = next
+	}
+	c.root.Format()
+}
+
 func mapToObjectMembers(env map[string]string) []hujson.ObjectMember {
 	members := make([]hujson.ObjectMember, 0, len(env))
 	for k, v := range env {
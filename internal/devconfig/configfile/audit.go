@@ -0,0 +1,18 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package configfile
+
+// AuditConfig configures the audit log of the commands that change the
+// project's environment: devbox add, rm, update and install.
+type AuditConfig struct {
+	// Log is a file that a record of each command is appended to, as a
+	// line of JSON. Relative paths are relative to the directory of
+	// devbox.json, and the file must be in that directory.
+	Log string `json:"log,omitempty"`
+
+	// Webhook is a URL that each record is posted to as JSON, such as the
+	// endpoint of a central audit service. Devbox only posts to it if the
+	// user trusts the project.
+	Webhook string `json:"webhook,omitempty"`
+}
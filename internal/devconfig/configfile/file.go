@@ -36,6 +36,11 @@ type ConfigFile struct {
 	// its environment. Deliberately do not omitempty.
 	PackagesMutator PackagesMutator `json:"packages"`
 
+	// PackageSources maps names to flake references of nix package sets,
+	// such as a private fork of nixpkgs. Packages use a source with the
+	// syntax <name>#<attr-path>[@<version>].
+	PackageSources map[string]string `json:"package_sources,omitempty"`
+
 	// Env allows specifying env variables
 	Env map[string]string `json:"env,omitempty"`
 
@@ -51,6 +56,72 @@ type ConfigFile struct {
 	// value is the command it expands to. Aliases use the current shell's
 	// builtin `alias` command and work in bash, zsh, and fish.
 	Aliases map[string]string `json:"aliases,omitempty"`
+
+	// Hooks contains shell commands that run before or after devbox commands,
+	// keyed by lifecycle hook name such as "post_update".
+	Hooks map[string]*shellcmd.Commands `json:"hooks,omitempty"`
+
+	// Java selects the JDK that sets JAVA_HOME when the project has more
+	// than one.
+	Java *JavaConfig `json:"java,omitempty"`
+
+	// Sandbox configures the sandbox that devbox run runs scripts in.
+	Sandbox *SandboxConfig `json:"sandbox,omitempty"`
+
+	// Limits declares CPU and memory limits for scripts and services.
+	Limits *LimitsConfig `json:"limits,omitempty"`
+
+	// Path controls the order of devbox's entries in PATH and strips
+	// entries from the host PATH.
+	Path *PathConfig `json:"path,omitempty"`
+
+	// Fonts configures how devbox installs the fonts of packages.
+	Fonts *FontsConfig `json:"fonts,omitempty"`
+
+	// MacOS configures how devbox integrates packages with macOS.
+	MacOS *MacOSConfig `json:"macos,omitempty"`
+
+	// Nix configures how devbox invokes nix.
+	Nix *NixConfig `json:"nix,omitempty"`
+
+	// Systems are the systems that the project is used on, such as
+	// x86_64-linux and aarch64-darwin. `devbox lock tidy --systems` removes
+	// the store paths of other systems from devbox.lock, and `devbox update
+	// --all-systems` only resolves these systems.
+	Systems []string `json:"systems,omitempty"`
+
+	// AllowUnfree restricts the packages with unfree licenses that the
+	// project can install. If it's unset, devbox global's setting applies,
+	// and if neither is set all unfree packages are allowed.
+	AllowUnfree *UnfreePolicy `json:"allow_unfree,omitempty"`
+
+	// Policy is an org policy, such as banned packages, that devbox add and
+	// devbox update check packages against. Plugins can set it too, so that
+	// including a plugin distributes the policy.
+	Policy *Policy `json:"policy,omitempty"`
+
+	// Audit configures an audit log of the commands that change the
+	// environment, for teams that need to track changes to their
+	// toolchains.
+	Audit *AuditConfig `json:"audit,omitempty"`
+
+	// Notifications are webhooks, such as a Slack channel, that are
+	// notified when devbox.lock changes, an install fails, or a service
+	// that runs detached crashes. Devbox only notifies them if the user
+	// trusts the project.
+	Notifications []*Notification `json:"notifications,omitempty"`
+
+	// AddProjects are the directories of other devbox projects, such as a
+	// repository of shared tools, whose environments are layered under this
+	// project's environment. Relative paths are relative to the directory
+	// of devbox.json.
+	AddProjects []string `json:"add_projects,omitempty"`
+
+	// Tests are checks that `devbox test` runs in the project's
+	// environment, such as that a tool has the expected version. Plugins
+	// can declare tests too, to validate the environments they set up.
+	Tests []*Test `json:"tests,omitempty"`
+
 	// Nixpkgs specifies the repository to pull packages from
 	// Deprecated: Versioned packages don't need this
 	Nixpkgs *NixpkgsConfig `json:"nixpkgs,omitempty"`
@@ -66,9 +137,9 @@ type ConfigFile struct {
 }
 
 type shellConfig struct {
-	// InitHook contains commands that will run at shell startup.
-	InitHook *shellcmd.Commands            `json:"init_hook,omitempty"`
-	Scripts  map[string]*shellcmd.Commands `json:"scripts,omitempty"`
+	// InitHook contains the hooks that will run at shell startup.
+	InitHook *shellcmd.Hooks          `json:"init_hook,omitempty"`
+	Scripts  map[string]*scriptConfig `json:"scripts,omitempty"`
 }
 
 type NixpkgsConfig struct {
@@ -107,16 +178,21 @@ func (c *ConfigFile) NixPkgsCommitHash() string {
 	return c.Nixpkgs.Commit
 }
 
-func (c *ConfigFile) InitHook() *shellcmd.Commands {
+func (c *ConfigFile) InitHook() *shellcmd.Hooks {
 	if c == nil || c.Shell == nil || c.Shell.InitHook == nil {
-		return &shellcmd.Commands{}
+		return &shellcmd.Hooks{}
 	}
 	return c.Shell.InitHook
 }
 
 // SaveTo writes the config to a file.
 func (c *ConfigFile) SaveTo(path string) error {
-	return os.WriteFile(filepath.Join(path, DefaultName), c.Bytes(), 0o644)
+	file := filepath.Join(path, DefaultName)
+	data := c.Bytes()
+	if err := checkLocked(file, data); err != nil {
+		return err
+	}
+	return os.WriteFile(file, data, 0o644)
 }
 
 // TODO: Can we remove SaveTo and just use Save()?
@@ -165,6 +241,10 @@ func validateConfig(cfg *ConfigFile) error {
 		ValidateNixpkg,
 		validateScripts,
 		validateAliases,
+		validateLifecycleHooks,
+		validatePath,
+		validatePackageSources,
+		validateTests,
 	}
 
 	for _, fn := range fns {
@@ -191,6 +271,13 @@ func validateScripts(cfg *ConfigFile) error {
 			return errors.Errorf(
 				"cannot have an empty script body in devbox.json: %s", k)
 		}
+		if err := validateScriptParams(k, scripts[k].Params); err != nil {
+			return err
+		}
+		if interpreter := scripts[k].Interpreter; interpreter != "" && strings.TrimSpace(interpreter) == "" {
+			return errors.Errorf(
+				"cannot have an empty script interpreter in devbox.json: %s", k)
+		}
 	}
 	return nil
 }
@@ -684,6 +684,44 @@ func TestSetAllowInsecure(t *testing.T) {
 	}
 }
 
+func TestSetOverride(t *testing.T) {
+	in, want := parseConfigTxtarTest(t, `
+-- in --
+{
+  "packages": {
+    "ffmpeg": {
+      "version":  "latest",
+      "override": {"withWebp": false}
+    }
+  }
+}
+-- want --
+{
+  "packages": {
+    "ffmpeg": {
+      "version":  "latest",
+      "override": {"withSvg": true, "withWebp": true},
+      "overlay":  "./overlay.nix"
+    }
+  }
+}`)
+
+	err := in.PackagesMutator.SetOverride(io.Discard, "ffmpeg@latest", map[string]any{"withWebp": true, "withSvg": true})
+	if err != nil {
+		t.Error(err)
+	}
+	err = in.PackagesMutator.SetOverlay("ffmpeg@latest", "./overlay.nix")
+	if err != nil {
+		t.Error(err)
+	}
+	if diff := cmp.Diff(want, in.Bytes(), optParseHujson()); diff != "" {
+		t.Errorf("wrong parsed config json (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(want, in.Bytes()); diff != "" {
+		t.Errorf("wrong raw config hujson (-want +got):\n%s", diff)
+	}
+}
+
 func TestSetEnv(t *testing.T) {
 	in, want := parseConfigTxtarTest(t, `
 -- in --
@@ -756,6 +794,38 @@ func TestSetEnvClear(t *testing.T) {
 	}
 }
 
+func TestSetJava(t *testing.T) {
+	in, want := parseConfigTxtarTest(t, `
+-- in --
+{
+  "java": {"default": "17"}
+}
+-- want --
+{
+  "java": {
+    "default": "21",
+    "scripts": {
+      "build-legacy": "11"
+    }
+  }
+}`)
+
+	in.SetJavaDefault("21")
+	in.SetJavaScript("build-legacy", "11")
+	if diff := cmp.Diff(want, in.Bytes(), optParseHujson()); diff != "" {
+		t.Errorf("wrong parsed config json (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(want, in.Bytes()); diff != "" {
+		t.Errorf("wrong raw config hujson (-want +got):\n%s", diff)
+	}
+	if got := in.JavaVersion("build-legacy"); got != "11" {
+		t.Errorf("got JavaVersion(build-legacy) = %q, want 11", got)
+	}
+	if got := in.JavaVersion("test"); got != "21" {
+		t.Errorf("got JavaVersion(test) = %q, want 21", got)
+	}
+}
+
 func TestNixpkgsValidation(t *testing.T) {
 	testCases := map[string]struct {
 		commit   string
@@ -782,3 +852,52 @@ func TestNixpkgsValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestPinUnpin(t *testing.T) {
+	in, want := parseConfigTxtarTest(t, `
+-- in --
+{
+  "packages": {
+    "go": "1.22"
+  }
+}
+-- want --
+{
+  "packages": {
+    "go": {
+      "version":     "1.22.3",
+      "pinned_from": "1.22"
+    }
+  }
+}`)
+
+	pinned, err := in.PackagesMutator.Pin("go@1.22", "1.22.3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pinned != "go@1.22.3" {
+		t.Errorf("got pinned versioned name %q, want go@1.22.3", pinned)
+	}
+	if diff := cmp.Diff(want, in.Bytes()); diff != "" {
+		t.Errorf("wrong raw config hujson after pin (-want +got):\n%s", diff)
+	}
+
+	unpinned, err := in.PackagesMutator.Unpin("go@1.22.3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unpinned != "go@1.22" {
+		t.Errorf("got unpinned versioned name %q, want go@1.22", unpinned)
+	}
+	want = []byte(`{
+  "packages": {
+    "go": {
+      "version": "1.22"
+    }
+  }
+}
+`)
+	if diff := cmp.Diff(want, in.Bytes()); diff != "" {
+		t.Errorf("wrong raw config hujson after unpin (-want +got):\n%s", diff)
+	}
+}
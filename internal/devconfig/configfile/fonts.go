@@ -0,0 +1,18 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package configfile
+
+// FontsConfig configures how devbox installs the fonts of packages.
+type FontsConfig struct {
+	// Link installs the fonts of devbox global packages, such as
+	// jetbrains-mono or nerd-fonts, into the user's font directory so that
+	// applications can use them. It has no effect in projects.
+	Link bool `json:"link,omitempty"`
+}
+
+// FontsLinked returns true if the fonts of devbox global packages should be
+// installed into the user's font directory.
+func (c *ConfigFile) FontsLinked() bool {
+	return c != nil && c.Fonts != nil && c.Fonts.Link
+}
@@ -0,0 +1,50 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package configfile
+
+// JavaConfig selects between the JDKs of a project that has several of them
+// installed side by side. Versions are JDK major versions, such as "21".
+type JavaConfig struct {
+	// Default is the version of the JDK that sets JAVA_HOME in the
+	// environment.
+	Default string `json:"default,omitempty"`
+
+	// Scripts maps script names to the version of the JDK that the script
+	// runs with, overriding Default.
+	Scripts map[string]string `json:"scripts,omitempty"`
+}
+
+// JavaVersion returns the JDK version that the named script runs with, or the
+// default version if script is empty or doesn't have one. It returns an empty
+// string if no version is set.
+func (c *ConfigFile) JavaVersion(script string) string {
+	if c == nil || c.Java == nil {
+		return ""
+	}
+	if version := c.Java.Scripts[script]; script != "" && version != "" {
+		return version
+	}
+	return c.Java.Default
+}
+
+// SetJavaDefault sets the version of the JDK that sets JAVA_HOME.
+func (c *ConfigFile) SetJavaDefault(version string) {
+	if c.Java == nil {
+		c.Java = &JavaConfig{}
+	}
+	c.Java.Default = version
+	c.ast.setNestedString(version, "java", "default")
+}
+
+// SetJavaScript sets the version of the JDK that a script runs with.
+func (c *ConfigFile) SetJavaScript(script, version string) {
+	if c.Java == nil {
+		c.Java = &JavaConfig{}
+	}
+	if c.Java.Scripts == nil {
+		c.Java.Scripts = map[string]string{}
+	}
+	c.Java.Scripts[script] = version
+	c.ast.setNestedString(version, "java", "scripts", script)
+}
@@ -0,0 +1,50 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package configfile
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+	"go.jetify.com/devbox/internal/devbox/shellcmd"
+)
+
+// Names of the lifecycle hooks that run around devbox commands.
+const (
+	// PreInstallHook runs before `devbox install` installs the packages.
+	PreInstallHook = "pre_install"
+
+	// PostAddHook runs after `devbox add` adds and installs packages.
+	PostAddHook = "post_add"
+
+	// PostUpdateHook runs after `devbox update` updates packages.
+	PostUpdateHook = "post_update"
+)
+
+// LifecycleHooks lists the lifecycle hooks that devbox.json can declare.
+var LifecycleHooks = []string{PreInstallHook, PostAddHook, PostUpdateHook}
+
+// LifecycleHook returns the commands of the named lifecycle hook, or nil if
+// the config doesn't declare it.
+func (c *ConfigFile) LifecycleHook(name string) *shellcmd.Commands {
+	if c == nil {
+		return nil
+	}
+	return c.Hooks[name]
+}
+
+func validateLifecycleHooks(cfg *ConfigFile) error {
+	for name, commands := range cfg.Hooks {
+		if !slices.Contains(LifecycleHooks, name) {
+			return errors.Errorf(
+				"unknown hook %q in devbox.json, valid hooks are: %s",
+				name, strings.Join(LifecycleHooks, ", "))
+		}
+		if strings.TrimSpace(commands.String()) == "" {
+			return errors.Errorf("cannot have an empty hook in devbox.json: %s", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package configfile
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestValidateLifecycleHooks(t *testing.T) {
+	tests := []struct {
+		hooks   string
+		wantErr bool
+	}{
+		{hooks: `{}`},
+		{hooks: `{"post_update": "npm install"}`},
+		{hooks: `{"pre_install": ["echo a", "echo b"], "post_add": "echo $DEVBOX_HOOK_PACKAGES"}`},
+		{hooks: `{"post_remove": "echo hi"}`, wantErr: true},
+		{hooks: `{"post_add": "  "}`, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.hooks, func(t *testing.T) {
+			cfg := &ConfigFile{}
+			if err := json.Unmarshal([]byte(`{"hooks": `+test.hooks+`}`), cfg); err != nil {
+				t.Fatal(err)
+			}
+			err := validateLifecycleHooks(cfg)
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Errorf("validateLifecycleHooks() error = %v, wantErr %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestLifecycleHook(t *testing.T) {
+	cfg := &ConfigFile{}
+	if err := json.Unmarshal([]byte(`{"hooks": {"post_update": "npm install"}}`), cfg); err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.LifecycleHook(PostUpdateHook).String(); got != "npm install" {
+		t.Errorf("LifecycleHook(%q) = %q, want %q", PostUpdateHook, got, "npm install")
+	}
+	if got := cfg.LifecycleHook(PostAddHook); got != nil {
+		t.Errorf("LifecycleHook(%q) = %v, want nil", PostAddHook, got)
+	}
+}
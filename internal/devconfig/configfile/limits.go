@@ -0,0 +1,39 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package configfile
+
+// LimitsConfig declares the CPU and memory limits of scripts and services,
+// keyed by their names.
+type LimitsConfig struct {
+	Scripts  map[string]*ResourceLimits `json:"scripts,omitempty"`
+	Services map[string]*ResourceLimits `json:"services,omitempty"`
+}
+
+// ResourceLimits are the resources that a script or service and its child
+// processes can use.
+type ResourceLimits struct {
+	// CPUs is the number of CPUs, such as 1.5.
+	CPUs float64 `json:"cpus,omitempty"`
+
+	// Memory is the maximum memory, such as 512M or 4G.
+	Memory string `json:"memory,omitempty"`
+}
+
+// ScriptLimits returns the limits of the named script, or nil if it doesn't
+// have any.
+func (c *ConfigFile) ScriptLimits(script string) *ResourceLimits {
+	if c == nil || c.Limits == nil {
+		return nil
+	}
+	return c.Limits.Scripts[script]
+}
+
+// ServiceLimits returns the limits of the named service, or nil if it doesn't
+// have any.
+func (c *ConfigFile) ServiceLimits(service string) *ResourceLimits {
+	if c == nil || c.Limits == nil {
+		return nil
+	}
+	return c.Limits.Services[service]
+}
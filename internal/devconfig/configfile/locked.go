@@ -0,0 +1,68 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package configfile
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+	"go.jetify.com/devbox/internal/envir"
+)
+
+// checkLocked returns an error if writing data to the config file at path
+// would change it while devbox.json must not change.
+func checkLocked(path string, data []byte) error {
+	if !envir.IsLocked() {
+		return nil
+	}
+	old, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return errors.WithStack(err)
+	}
+	if bytes.Equal(old, data) {
+		return nil
+	}
+	return usererr.New(
+		"%s can't be changed because --locked or %s is set. The change would be:\n%s\n"+
+			"Run the command without --locked to update it and commit it.",
+		path,
+		envir.DevboxLocked,
+		lineDiff(string(old), string(data)),
+	)
+}
+
+// lineDiff returns the lines that differ between old and new, prefixed with
+// "-" for removed lines and "+" for added ones. Only the part between the
+// lines that both start and end with is compared.
+func lineDiff(old, new string) string {
+	oldLines := strings.Split(strings.TrimSuffix(old, "\n"), "\n")
+	newLines := strings.Split(strings.TrimSuffix(new, "\n"), "\n")
+	if old == "" {
+		oldLines = nil
+	}
+
+	prefix := 0
+	for prefix < len(oldLines) && prefix < len(newLines) && oldLines[prefix] == newLines[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(oldLines)-prefix && suffix < len(newLines)-prefix &&
+		oldLines[len(oldLines)-1-suffix] == newLines[len(newLines)-1-suffix] {
+		suffix++
+	}
+
+	diff := strings.Builder{}
+	for _, line := range oldLines[prefix : len(oldLines)-suffix] {
+		diff.WriteString("- " + line + "\n")
+	}
+	for _, line := range newLines[prefix : len(newLines)-suffix] {
+		diff.WriteString("+ " + line + "\n")
+	}
+	return strings.TrimSuffix(diff.String(), "\n")
+}
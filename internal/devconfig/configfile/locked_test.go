@@ -0,0 +1,46 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package configfile
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.jetify.com/devbox/internal/envir"
+)
+
+func TestLineDiff(t *testing.T) {
+	old := "{\n  \"packages\": [\n    \"go@1.21\"\n  ]\n}\n"
+	new := "{\n  \"packages\": [\n    \"go@1.22\",\n    \"jq\"\n  ]\n}\n"
+	got := lineDiff(old, new)
+	want := "-     \"go@1.21\"\n+     \"go@1.22\",\n+     \"jq\""
+	if got != want {
+		t.Errorf("got diff:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestCheckLocked(t *testing.T) {
+	path := filepath.Join(t.TempDir(), DefaultName)
+	if err := os.WriteFile(path, []byte("{}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := checkLocked(path, []byte("{\"packages\": []}\n")); err != nil {
+		t.Errorf("got error %v when devbox.json isn't locked", err)
+	}
+
+	t.Setenv(envir.DevboxLocked, "1")
+	if err := checkLocked(path, []byte("{}\n")); err != nil {
+		t.Errorf("got error %v for writing devbox.json without changes", err)
+	}
+	err := checkLocked(path, []byte("{\"packages\": []}\n"))
+	if err == nil {
+		t.Fatal("got nil error for changing a locked devbox.json")
+	}
+	if !strings.Contains(err.Error(), "+ {\"packages\": []}") {
+		t.Errorf("got error %q, want it to show the change", err)
+	}
+}
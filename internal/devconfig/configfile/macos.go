@@ -0,0 +1,18 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package configfile
+
+// MacOSConfig configures how devbox integrates packages with macOS.
+type MacOSConfig struct {
+	// LinkApps links the .app bundles of devbox global packages, such as
+	// kitty or wezterm, into ~/Applications so that Finder and the Dock can
+	// launch them. It has no effect in projects or on other systems.
+	LinkApps bool `json:"link_apps,omitempty"`
+}
+
+// MacOSLinkApps returns true if the .app bundles of devbox global packages
+// should be linked into ~/Applications.
+func (c *ConfigFile) MacOSLinkApps() bool {
+	return c != nil && c.MacOS != nil && c.MacOS.LinkApps
+}
@@ -0,0 +1,138 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package configfile
+
+import (
+	"slices"
+
+	"github.com/tailscale/hujson"
+)
+
+// Migration rewrites deprecated fields of a devbox.json or plugin.json to the
+// fields that replace them. Migrations edit the syntax tree, so comments and
+// formatting are preserved.
+type Migration struct {
+	// Version orders migrations. Migrations are applied in order of
+	// version, so a migration can assume that earlier ones were applied.
+	Version int
+
+	// Description says what the migration changes.
+	Description string
+
+	needed func(*configAST) bool
+	apply  func(*configAST)
+}
+
+// migrations are the migrations that devbox config upgrade applies. New
+// migrations must be appended with the next version.
+var migrations = []Migration{
+	{
+		Version:     1,
+		Description: `replace "patch_glibc" with "patch"`,
+		needed:      (*configAST).hasPatchGlibc,
+		apply:       (*configAST).migratePatchGlibc,
+	},
+	{
+		Version:     2,
+		Description: `rename the "readme" field of plugins to "description"`,
+		needed:      (*configAST).hasReadme,
+		apply:       (*configAST).migrateReadme,
+	},
+}
+
+// PendingMigrations returns the migrations that Migrate would apply to the
+// config, without changing it.
+func (c *ConfigFile) PendingMigrations() []Migration {
+	if c.ast == nil {
+		return nil
+	}
+	return runMigrations(&configAST{root: c.ast.root.Clone()})
+}
+
+// Migrate applies the pending migrations to the config and returns them. The
+// changes aren't written until the config is saved.
+func (c *ConfigFile) Migrate() []Migration {
+	if c.ast == nil {
+		return nil
+	}
+	return runMigrations(c.ast)
+}
+
+func runMigrations(ast *configAST) []Migration {
+	if _, ok := ast.root.Value.(*hujson.Object); !ok {
+		return nil
+	}
+	applied := []Migration{}
+	for _, m := range migrations {
+		if m.needed(ast) {
+			m.apply(ast)
+			applied = append(applied, m)
+		}
+	}
+	return applied
+}
+
+// packageObjects returns the packages that are objects, keyed by name.
+func (c *configAST) packageObjects() map[string]*hujson.Object {
+	rootObject := c.root.Value.(*hujson.Object)
+	i := c.memberIndex(rootObject, "packages")
+	if i == -1 {
+		return nil
+	}
+	pkgs, ok := rootObject.Members[i].Value.Value.(*hujson.Object)
+	if !ok {
+		return nil
+	}
+	objects := map[string]*hujson.Object{}
+	for _, member := range pkgs.Members {
+		if obj, ok := member.Value.Value.(*hujson.Object); ok {
+			objects[member.Name.Value.(hujson.Literal).String()] = obj
+		}
+	}
+	return objects
+}
+
+func (c *configAST) hasPatchGlibc() bool {
+	for _, obj := range c.packageObjects() {
+		if c.memberIndex(obj, "patch_glibc") != -1 {
+			return true
+		}
+	}
+	return false
+}
+
+// migratePatchGlibc replaces patch_glibc with the equivalent patch mode. A
+// patch_glibc of false is removed because it's the same as the default.
+func (c *configAST) migratePatchGlibc() {
+	for name, obj := range c.packageObjects() {
+		i := c.memberIndex(obj, "patch_glibc")
+		if i == -1 {
+			continue
+		}
+		if lit, ok := obj.Members[i].Value.Value.(hujson.Literal); ok && lit.Bool() &&
+			c.memberIndex(obj, "patch") == -1 {
+			c.setPatch(name, PatchAlways)
+			continue
+		}
+		obj.Members = slices.Delete(obj.Members, i, i+1)
+		c.root.Format()
+	}
+}
+
+func (c *configAST) hasReadme() bool {
+	return c.memberIndex(c.root.Value.(*hujson.Object), "readme") != -1
+}
+
+// migrateReadme renames a plugin's readme field to description, unless it
+// already has a description.
+func (c *configAST) migrateReadme() {
+	rootObject := c.root.Value.(*hujson.Object)
+	i := c.memberIndex(rootObject, "readme")
+	if c.memberIndex(rootObject, "description") == -1 {
+		rootObject.Members[i].Name.Value = hujson.String("description")
+		return
+	}
+	rootObject.Members = slices.Delete(rootObject.Members, i, i+1)
+	c.root.Format()
+}
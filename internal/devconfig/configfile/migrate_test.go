@@ -0,0 +1,96 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package configfile
+
+import (
+	"testing"
+)
+
+func TestMigrate(t *testing.T) {
+	cfg := mustLoad(t, `{
+  "packages": {
+    // Needs the latest glibc.
+    "python": {
+      "version": "3.12",
+      "patch_glibc": true
+    },
+    "go": {
+      "version": "latest",
+      "patch_glibc": false
+    }
+  },
+  "readme": "A plugin."
+}
+`)
+
+	before := string(cfg.Bytes())
+	pending := cfg.PendingMigrations()
+	if len(pending) != 2 {
+		t.Fatalf("got %d pending migrations, want 2", len(pending))
+	}
+	if got := string(cfg.Bytes()); got != before {
+		t.Fatalf("PendingMigrations changed the config:\n%s", got)
+	}
+
+	applied := cfg.Migrate()
+	if len(applied) != 2 || applied[0].Version != 1 || applied[1].Version != 2 {
+		t.Errorf("got applied migrations %v, want versions 1 and 2 in order", applied)
+	}
+	want := `{
+  "packages": {
+    // Needs the latest glibc.
+    "python": {
+      "version": "3.12",
+      "patch":   "always",
+    },
+    "go": {
+      "version": "latest",
+    },
+  },
+  "description": "A plugin.",
+}
+`
+	if got := string(cfg.Bytes()); got != want {
+		t.Errorf("got migrated config:\n%s\nwant:\n%s", got, want)
+	}
+	if pending := cfg.PendingMigrations(); len(pending) != 0 {
+		t.Errorf("got %d pending migrations after Migrate, want 0", len(pending))
+	}
+}
+
+func TestMigrateKeepsExistingFields(t *testing.T) {
+	cfg := mustLoad(t, `{
+  "packages": {
+    "python": {
+      "patch": "never",
+      "patch_glibc": true
+    }
+  },
+  "description": "New.",
+  "readme": "Old."
+}
+`)
+	cfg.Migrate()
+	want := `{
+  "packages": {
+    "python": {
+      "patch": "never"
+    }
+  },
+  "description": "New."
+}
+`
+	if got := string(cfg.Bytes()); got != want {
+		t.Errorf("got migrated config:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func mustLoad(t *testing.T, s string) *ConfigFile {
+	t.Helper()
+	cfg, err := LoadBytes([]byte(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
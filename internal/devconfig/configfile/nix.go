@@ -0,0 +1,19 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package configfile
+
+// NixConfig configures how devbox invokes nix.
+type NixConfig struct {
+	// EvalCache makes every nix command that devbox runs use nix's
+	// evaluation cache, even if it's disabled in nix.conf. Evaluations of
+	// the project's flake are then reused across shells until devbox.json
+	// or devbox.lock change.
+	EvalCache bool `json:"eval_cache,omitempty"`
+}
+
+// NixEvalCache returns true if devbox should enable nix's evaluation cache
+// for the nix commands it runs.
+func (c *ConfigFile) NixEvalCache() bool {
+	return c != nil && c.Nix != nil && c.Nix.EvalCache
+}
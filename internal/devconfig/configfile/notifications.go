@@ -0,0 +1,28 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package configfile
+
+// Notification is a destination that devbox notifies when the project's
+// environment changes or breaks: when devbox.lock changes, when an install
+// fails, or when a service that runs detached crashes.
+type Notification struct {
+	// Type is slack for a Slack incoming webhook, or http to post to any
+	// other URL.
+	Type string `json:"type"`
+
+	// URL is the webhook that notifications are posted to.
+	URL string `json:"url"`
+
+	// Events are the events to notify about: lockfile_changed,
+	// install_failed and service_crashed. If it's empty, every event is
+	// notified.
+	Events []string `json:"events,omitempty"`
+
+	// Template is a Go text/template that renders the payload from the
+	// notification's fields, such as {{.Summary}}. Slack notifications
+	// post it as the message text, and http notifications post it as the
+	// body. Without a template, Slack gets the summary and http gets the
+	// notification as JSON.
+	Template string `json:"template,omitempty"`
+}
@@ -5,6 +5,7 @@
 	"encoding/json"
 	"fmt"
 	"io"
+	"maps"
 	"slices"
 	"strings"
 
@@ -14,6 +15,7 @@
 	"go.jetify.com/devbox/internal/nix"
 	"go.jetify.com/devbox/internal/searcher"
 	"go.jetify.com/devbox/internal/ux"
+	"go.jetify.com/devbox/nix/flake"
 )
 
 type PackagesMutator struct {
@@ -235,6 +237,93 @@ func (pkgs *PackagesMutator) SetAllowInsecure(writer io.Writer, versionedName st
 	return nil
 }
 
+// SetOverride merges args into the arguments that the package's override
+// function is called with.
+func (pkgs *PackagesMutator) SetOverride(writer io.Writer, versionedName string, args map[string]any) error {
+	if len(args) == 0 {
+		return nil
+	}
+
+	name, version := parseVersionedName(versionedName)
+	i := pkgs.index(name, version)
+	if i == -1 {
+		return errors.Errorf("package %s not found", versionedName)
+	}
+
+	pkg := &pkgs.collection[i]
+	if pkg.Override == nil {
+		pkg.Override = map[string]any{}
+	}
+	maps.Copy(pkg.Override, args)
+	if err := pkgs.ast.setPackageJSON(pkg.Name, "override", pkg.Override); err != nil {
+		return err
+	}
+	ux.Finfof(writer, "Overrode %s for package %s\n",
+		strings.Join(slices.Sorted(maps.Keys(args)), ", "), versionedName)
+	return nil
+}
+
+// SetOverlay sets the path to the overlay file that's applied to the package's
+// nixpkgs.
+func (pkgs *PackagesMutator) SetOverlay(versionedName, path string) error {
+	if path == "" {
+		return nil
+	}
+
+	name, version := parseVersionedName(versionedName)
+	i := pkgs.index(name, version)
+	if i == -1 {
+		return errors.Errorf("package %s not found", versionedName)
+	}
+
+	pkgs.collection[i].Overlay = path
+	return pkgs.ast.setPackageJSON(name, "overlay", path)
+}
+
+// Pin changes the version of a package to version, and records the version it
+// had before so that Unpin can restore it. It returns the new versioned name.
+func (pkgs *PackagesMutator) Pin(versionedName, version string) (string, error) {
+	name, oldVersion := parseVersionedName(versionedName)
+	i := pkgs.index(name, oldVersion)
+	if i == -1 {
+		return "", errors.Errorf("package %s not found", versionedName)
+	}
+
+	pkg := &pkgs.collection[i]
+	if oldVersion == "" {
+		return "", errors.Errorf("package %s doesn't have a version to pin", versionedName)
+	}
+	if !pkg.IsPinned() {
+		pkg.PinnedFrom = oldVersion
+	}
+	pkg.Version = version
+	pkgs.ast.setPackageVersion(name, version)
+	if err := pkgs.ast.setPackageJSON(name, "pinned_from", pkg.PinnedFrom); err != nil {
+		return "", err
+	}
+	return pkg.VersionedName(), nil
+}
+
+// Unpin restores the version that a package had before it was pinned. It
+// returns the new versioned name.
+func (pkgs *PackagesMutator) Unpin(versionedName string) (string, error) {
+	name, version := parseVersionedName(versionedName)
+	i := pkgs.index(name, version)
+	if i == -1 {
+		return "", errors.Errorf("package %s not found", versionedName)
+	}
+
+	pkg := &pkgs.collection[i]
+	if !pkg.IsPinned() {
+		return versionedName, nil
+	}
+	pkg.Version = pkg.PinnedFrom
+	pkg.PinnedFrom = ""
+	pkgs.ast.setPackageVersion(name, pkg.Version)
+	pkgs.ast.removePackageField(name, "pinned_from")
+	return pkg.VersionedName(), nil
+}
+
 func (pkgs *PackagesMutator) index(name, version string) int {
 	return slices.IndexFunc(pkgs.collection, func(p Package) bool {
 		return p.Name == name && p.Version == version
@@ -269,6 +358,37 @@ func (p PatchMode) validate() error {
 	}
 }
 
+// UpdatePolicy limits the versions that `devbox update` updates a package to,
+// relative to its locked version.
+type UpdatePolicy string
+
+const (
+	// UpdateMajor updates a package to any version that its version in
+	// devbox.json allows. It's the default.
+	UpdateMajor UpdatePolicy = "major"
+
+	// UpdateMinor only updates a package to versions with the same major
+	// version as its locked version.
+	UpdateMinor UpdatePolicy = "minor"
+
+	// UpdatePatch only updates a package to versions with the same major and
+	// minor versions as its locked version.
+	UpdatePatch UpdatePolicy = "patch"
+
+	// UpdateIgnore never updates a package.
+	UpdateIgnore UpdatePolicy = "ignore"
+)
+
+func (u UpdatePolicy) validate() error {
+	switch u {
+	case "", UpdateMajor, UpdateMinor, UpdatePatch, UpdateIgnore:
+		return nil
+	default:
+		return fmt.Errorf("invalid update policy %q (must be %s, %s, %s or %s)",
+			u, UpdateMajor, UpdateMinor, UpdatePatch, UpdateIgnore)
+	}
+}
+
 type Package struct {
 	Name    string
 	Version string `json:"version,omitempty"`
@@ -294,6 +414,78 @@ type Package struct {
 	// AllowInsecure is a whitelist of packages that may be marked insecure
 	// in nixpkgs, but are allowed by the user to be installed.
 	AllowInsecure []string `json:"allow_insecure,omitempty"`
+
+	// PluginOverrides overrides values set by the builtin plugin that the
+	// package triggers, if any.
+	PluginOverrides *PluginOverrides `json:"plugin_overrides,omitempty"`
+
+	// SHA256 is the hex-encoded SHA-256 checksum of the file downloaded by a
+	// url: package. It's an alternative to putting the checksum in the
+	// package's #sha256= URL fragment.
+	SHA256 string `json:"sha256,omitempty"`
+
+	// Override is passed to the package's override function to change the
+	// arguments it's built with. For example, {"withWebp": true} builds
+	// ffmpeg with pkgs.ffmpeg.override { withWebp = true; }. Overridden
+	// packages aren't in the binary cache, so they're built from source.
+	Override map[string]any `json:"override,omitempty"`
+
+	// Overlay is the path to a Nix file, relative to devbox.json, that
+	// contains an overlay to apply to the nixpkgs that the package comes
+	// from. The file must not import other files relative to itself.
+	Overlay string `json:"overlay,omitempty"`
+
+	// Patches are paths to patch files, relative to devbox.json, that are
+	// applied to the package's source with overrideAttrs.
+	Patches []string `json:"patches,omitempty"`
+
+	// Inputs overrides the inputs of a flake package. The key is the name
+	// of the input and the value is a flake reference, or
+	// [InputFollowsStdenv] to use the project's nixpkgs. Sharing nixpkgs
+	// avoids evaluating and downloading another copy of it.
+	Inputs map[string]string `json:"inputs,omitempty"`
+
+	// PinnedFrom is the version that the package had before `devbox pin`
+	// froze it at its locked version. Pinned packages aren't updated by
+	// `devbox update`, and `devbox unpin` restores this version.
+	PinnedFrom string `json:"pinned_from,omitempty"`
+
+	// Update limits the versions that `devbox update` updates the package
+	// to. If empty, it defaults to [UpdateMajor].
+	Update UpdatePolicy `json:"update,omitempty"`
+}
+
+// IsPinned reports whether the package was pinned with `devbox pin`.
+func (p *Package) IsPinned() bool {
+	return p.PinnedFrom != ""
+}
+
+// InputFollowsStdenv makes a flake package's input follow the nixpkgs that
+// the project uses for its standard environment.
+const InputFollowsStdenv = "follows-stdenv"
+
+func validateInputs(inputs map[string]string) error {
+	for name, ref := range inputs {
+		if name == "" || strings.ContainsAny(name, `."/ `) {
+			return fmt.Errorf("invalid flake input name %q", name)
+		}
+		if ref == InputFollowsStdenv {
+			continue
+		}
+		if _, err := flake.ParseRef(ref); err != nil {
+			return fmt.Errorf("invalid flake reference %q for input %q (must be a flake reference or %q): %v",
+				ref, name, InputFollowsStdenv, err)
+		}
+	}
+	return nil
+}
+
+// PluginOverrides are values that replace the ones set by a builtin plugin.
+type PluginOverrides struct {
+	// Env sets or replaces environment variables set by the plugin. Values
+	// may use the same template variables as the plugin, such as
+	// {{ .DevboxProjectDir }} or {{ .Virtenv }}.
+	Env map[string]string `json:"env,omitempty"`
 }
 
 func NewVersionOnlyPackage(name, version string) Package {
@@ -308,7 +500,12 @@ func NewVersionOnlyPackage(name, version string) Package {
 // If the package has a list of excluded platforms, it is enabled on all platforms
 // except those.
 func (p *Package) IsEnabledOnPlatform() bool {
-	platform := nix.System()
+	return p.IsEnabledOnSystem(nix.System())
+}
+
+// IsEnabledOnSystem is like IsEnabledOnPlatform, but for the given system
+// instead of the current one.
+func (p *Package) IsEnabledOnSystem(platform string) bool {
 	if len(p.Platforms) > 0 {
 		for _, plt := range p.Platforms {
 			if plt == platform {
@@ -356,7 +553,13 @@ func (p *Package) UnmarshalJSON(data []byte) error {
 			p.Patch = PatchAuto
 		}
 	}
-	return p.Patch.validate()
+	if err := p.Patch.validate(); err != nil {
+		return err
+	}
+	if err := p.Update.validate(); err != nil {
+		return err
+	}
+	return validateInputs(p.Inputs)
 }
 
 // parseVersionedName parses the name and version from package@version representation
@@ -204,6 +204,23 @@ func TestJsonifyConfigPackages(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "map-with-plugin-overrides",
+			jsonConfig: `{"packages":{"python":{"version":"3.12",` +
+				`"plugin_overrides":{"env":{"VENV_DIR":"{{ .DevboxProjectDir }}/venv"}}` +
+				`}}}`,
+			expected: PackagesMutator{
+				collection: []Package{
+					{
+						Name:    "python",
+						Version: "3.12",
+						PluginOverrides: &PluginOverrides{
+							Env: map[string]string{"VENV_DIR": "{{ .DevboxProjectDir }}/venv"},
+						},
+					},
+				},
+			},
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -302,3 +319,46 @@ func TestParseVersionedName(t *testing.T) {
 		})
 	}
 }
+
+func TestIsEnabledOnSystem(t *testing.T) {
+	testCases := []struct {
+		name   string
+		pkg    Package
+		system string
+		want   bool
+	}{
+		{"no platforms", Package{}, "aarch64-darwin", true},
+		{"in platforms", Package{Platforms: []string{"x86_64-linux"}}, "x86_64-linux", true},
+		{"not in platforms", Package{Platforms: []string{"x86_64-linux"}}, "aarch64-darwin", false},
+		{"excluded", Package{ExcludedPlatforms: []string{"aarch64-darwin"}}, "aarch64-darwin", false},
+		{"not excluded", Package{ExcludedPlatforms: []string{"aarch64-darwin"}}, "x86_64-linux", true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.pkg.IsEnabledOnSystem(tc.system); got != tc.want {
+				t.Errorf("IsEnabledOnSystem(%q) = %v, want %v", tc.system, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPackageInputs(t *testing.T) {
+	tests := []struct {
+		json    string
+		wantErr bool
+	}{
+		{json: `{"inputs": {"nixpkgs": "follows-stdenv"}}`},
+		{json: `{"inputs": {"flake-utils": "github:numtide/flake-utils"}}`},
+		{json: `{"inputs": {"nixpkgs": "github:NixOS"}}`, wantErr: true},
+		{json: `{"inputs": {"nix.pkgs": "follows-stdenv"}}`, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.json, func(t *testing.T) {
+			pkg := &Package{}
+			err := pkg.UnmarshalJSON([]byte(test.json))
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Errorf("UnmarshalJSON() error = %v, wantErr %v", err, test.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package configfile
+
+import (
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// Positions of the devbox entries in PATH relative to the host PATH.
+const (
+	// PathPrepend puts devbox's entries before the host PATH, so that
+	// devbox's tools take precedence. It's the default.
+	PathPrepend = "prepend"
+
+	// PathAppend puts devbox's entries after the host PATH, so that the
+	// host's tools take precedence.
+	PathAppend = "append"
+)
+
+// Strict modes for commands that resolve to a host binary that devbox doesn't
+// provide.
+const (
+	// StrictWarn prints a warning and runs the host binary.
+	StrictWarn = "warn"
+
+	// StrictBlock prints an error and doesn't run the host binary.
+	StrictBlock = "block"
+)
+
+// PathConfig controls where devbox puts its entries in PATH and which entries
+// of the host PATH it keeps.
+type PathConfig struct {
+	// Position is PathPrepend or PathAppend.
+	Position string `json:"position,omitempty"`
+
+	// Strip are entries to remove from the host PATH, such as
+	// "/opt/homebrew/bin". They can be glob patterns, and a leading "~/" is
+	// the home directory.
+	Strip []string `json:"strip,omitempty"`
+
+	// Strict is StrictWarn or StrictBlock to catch commands that resolve to
+	// a host binary outside of the system directories, such as a homebrew
+	// tool, instead of a package in the project.
+	Strict string `json:"strict,omitempty"`
+
+	// StrictAllow are host commands that strict mode allows, such as
+	// "docker". Entries that are absolute paths allow every command in a
+	// directory.
+	StrictAllow []string `json:"strict_allow,omitempty"`
+}
+
+// PathAppended returns true if devbox's PATH entries go after the host PATH.
+func (c *ConfigFile) PathAppended() bool {
+	return c != nil && c.Path != nil && c.Path.Position == PathAppend
+}
+
+// PathStrip returns the patterns of the host PATH entries to remove.
+func (c *ConfigFile) PathStrip() []string {
+	if c == nil || c.Path == nil {
+		return nil
+	}
+	return c.Path.Strip
+}
+
+// PathStrict returns the strict mode for host binaries, or "" if it's off.
+func (c *ConfigFile) PathStrict() string {
+	if c == nil || c.Path == nil {
+		return ""
+	}
+	return c.Path.Strict
+}
+
+// PathStrictAllow returns the host commands and directories that strict mode
+// allows.
+func (c *ConfigFile) PathStrictAllow() []string {
+	if c == nil || c.Path == nil {
+		return nil
+	}
+	return c.Path.StrictAllow
+}
+
+func validatePath(cfg *ConfigFile) error {
+	if cfg.Path == nil {
+		return nil
+	}
+	switch cfg.Path.Position {
+	case "", PathPrepend, PathAppend:
+	default:
+		return errors.Errorf(
+			"invalid path.position %q in devbox.json, must be %q or %q",
+			cfg.Path.Position, PathPrepend, PathAppend)
+	}
+	switch cfg.Path.Strict {
+	case "", StrictWarn, StrictBlock:
+	default:
+		return errors.Errorf(
+			"invalid path.strict %q in devbox.json, must be %q or %q",
+			cfg.Path.Strict, StrictWarn, StrictBlock)
+	}
+	for _, pattern := range cfg.Path.Strip {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return errors.Errorf("invalid pattern %q in path.strip in devbox.json", pattern)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package configfile
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestValidatePath(t *testing.T) {
+	tests := []struct {
+		path    string
+		wantErr bool
+	}{
+		{path: `{}`},
+		{path: `{"position": "append"}`},
+		{path: `{"position": "prepend", "strip": ["/opt/homebrew/*", "~/.cargo/bin"]}`},
+		{path: `{"position": "first"}`, wantErr: true},
+		{path: `{"strip": ["/opt/[homebrew"]}`, wantErr: true},
+		{path: `{"strict": "block", "strict_allow": ["docker", "/opt/tools/bin"]}`},
+		{path: `{"strict": "error"}`, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			cfg := &ConfigFile{}
+			if err := json.Unmarshal([]byte(`{"path": `+test.path+`}`), cfg); err != nil {
+				t.Fatal(err)
+			}
+			err := validatePath(cfg)
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Errorf("validatePath() error = %v, wantErr %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestPathAppended(t *testing.T) {
+	var cfg *ConfigFile
+	if cfg.PathAppended() {
+		t.Error("PathAppended() = true for a nil config, want false")
+	}
+	cfg = &ConfigFile{Path: &PathConfig{Position: PathAppend}}
+	if !cfg.PathAppended() {
+		t.Error("PathAppended() = false, want true")
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package configfile
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Policy is an org policy that devbox add and devbox update check the
+// project's packages against. In devbox.json or a plugin it's either the
+// path or https URL of a policy file, or the rules themselves:
+//
+//	"policy": "https://example.com/devbox-policy.json"
+//	"policy": {"banned": {"python@2": "Python 2 is end of life"}}
+type Policy struct {
+	// Source is the path or URL of a JSON, YAML or TOML policy file.
+	// Relative paths are relative to the devbox.json or plugin that sets
+	// the policy.
+	Source string
+	Rules  *PolicyRules
+}
+
+// PolicyRules are the rules of an org policy.
+type PolicyRules struct {
+	// Banned maps the packages that can't be used to the reason, such as
+	// "python@2": "Python 2 is end of life". A package without a version
+	// bans all of its versions.
+	Banned map[string]string `json:"banned,omitempty"        yaml:"banned,omitempty"        toml:"banned,omitempty"`
+
+	// MaxPackageAge is how long ago the nixpkgs commit or flake that a
+	// package resolves to can be from, such as 180d or 26w.
+	MaxPackageAge string `json:"max_package_age,omitempty" yaml:"max_package_age,omitempty" toml:"max_package_age,omitempty"`
+
+	// Registries are where packages can come from: nixpkgs for packages
+	// from the Devbox search index, runx, the name of a package source, or
+	// the start of a flake reference, such as github:my-org/. If it's
+	// empty, packages can come from anywhere.
+	Registries []string `json:"registries,omitempty"      yaml:"registries,omitempty"      toml:"registries,omitempty"`
+}
+
+// MaxAge parses MaxPackageAge, which is a number of days (d), weeks (w) or
+// hours (h). It returns 0 if MaxPackageAge is unset.
+func (r *PolicyRules) MaxAge() (time.Duration, error) {
+	if r.MaxPackageAge == "" {
+		return 0, nil
+	}
+	units := map[string]time.Duration{"h": time.Hour, "d": 24 * time.Hour, "w": 7 * 24 * time.Hour}
+	for suffix, unit := range units {
+		n, found := strings.CutSuffix(r.MaxPackageAge, suffix)
+		if !found {
+			continue
+		}
+		if count, err := strconv.Atoi(n); err == nil && count > 0 {
+			return time.Duration(count) * unit, nil
+		}
+	}
+	return 0, errors.Errorf("invalid max_package_age %q, use a duration such as 180d, 26w or 72h", r.MaxPackageAge)
+}
+
+func (p *Policy) UnmarshalJSON(data []byte) error {
+	*p = Policy{}
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		p.Rules = &PolicyRules{}
+		if err := json.Unmarshal(data, p.Rules); err != nil {
+			return errors.Wrap(err, "invalid policy rules")
+		}
+		_, err := p.Rules.MaxAge()
+		return err
+	}
+	if err := json.Unmarshal(data, &p.Source); err != nil || p.Source == "" {
+		return errors.New("policy must be the path or URL of a policy file, or an object of policy rules")
+	}
+	return nil
+}
+
+func (p Policy) MarshalJSON() ([]byte, error) {
+	if p.Rules != nil {
+		return json.Marshal(p.Rules)
+	}
+	return json.Marshal(p.Source)
+}
@@ -0,0 +1,72 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package configfile
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestPolicyJSON(t *testing.T) {
+	var policy Policy
+	if err := json.Unmarshal([]byte(`"https://example.com/policy.yaml"`), &policy); err != nil {
+		t.Fatal(err)
+	}
+	if policy.Source != "https://example.com/policy.yaml" || policy.Rules != nil {
+		t.Errorf("got policy %+v, want source https://example.com/policy.yaml", policy)
+	}
+
+	data := `{"banned":{"python@2":"end of life"},"max_package_age":"180d","registries":["nixpkgs"]}`
+	if err := json.Unmarshal([]byte(data), &policy); err != nil {
+		t.Fatal(err)
+	}
+	if policy.Source != "" || policy.Rules == nil {
+		t.Fatalf("got policy %+v, want rules", policy)
+	}
+	if got := policy.Rules.Banned["python@2"]; got != "end of life" {
+		t.Errorf("got banned reason %q, want %q", got, "end of life")
+	}
+	b, err := json.Marshal(policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != data {
+		t.Errorf("got marshaled policy %s, want %s", b, data)
+	}
+
+	for _, invalid := range []string{`""`, `true`, `{"max_package_age":"6 months"}`} {
+		if err := json.Unmarshal([]byte(invalid), &policy); err == nil {
+			t.Errorf("got nil error for invalid policy %s", invalid)
+		}
+	}
+}
+
+func TestPolicyRulesMaxAge(t *testing.T) {
+	tests := []struct {
+		maxAge  string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"72h", 72 * time.Hour, false},
+		{"180d", 180 * 24 * time.Hour, false},
+		{"26w", 26 * 7 * 24 * time.Hour, false},
+		{"0d", 0, true},
+		{"d", 0, true},
+		{"6mo", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.maxAge, func(t *testing.T) {
+			rules := &PolicyRules{MaxPackageAge: tt.maxAge}
+			got, err := rules.MaxAge()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got max age %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package configfile
+
+import "path/filepath"
+
+// AddedProjects returns the absolute directories of the projects in
+// add_projects.
+func (c *ConfigFile) AddedProjects() []string {
+	if c == nil {
+		return nil
+	}
+	dirs := make([]string, 0, len(c.AddProjects))
+	for _, dir := range c.AddProjects {
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(filepath.Dir(c.AbsRootPath), dir)
+		}
+		dirs = append(dirs, filepath.Clean(dir))
+	}
+	return dirs
+}
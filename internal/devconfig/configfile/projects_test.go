@@ -0,0 +1,25 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package configfile
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestAddedProjects(t *testing.T) {
+	cfg := &ConfigFile{
+		AbsRootPath: "/src/service/devbox.json",
+		AddProjects: []string{"../tools", "/opt/toolbox/"},
+	}
+	got := cfg.AddedProjects()
+	want := []string{"/src/tools", "/opt/toolbox"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got AddedProjects() = %v, want %v", got, want)
+	}
+
+	if got := (*ConfigFile)(nil).AddedProjects(); len(got) != 0 {
+		t.Errorf("got AddedProjects() = %v for nil config, want none", got)
+	}
+}
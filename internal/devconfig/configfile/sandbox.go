@@ -0,0 +1,27 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package configfile
+
+import "slices"
+
+// SandboxConfig configures the sandbox that devbox run runs scripts in. The
+// sandbox denies network access and only allows writes to the project
+// directory and temporary directories.
+type SandboxConfig struct {
+	// Scripts are the names of the scripts that always run in the sandbox.
+	Scripts []string `json:"scripts,omitempty"`
+
+	// AllowNetwork lets sandboxed commands use the network.
+	AllowNetwork bool `json:"allow_network,omitempty"`
+
+	// Writable are more paths that sandboxed commands can write to, such as
+	// a build cache. Relative paths are relative to the project directory,
+	// and environment variables are expanded.
+	Writable []string `json:"writable,omitempty"`
+}
+
+// IsSandboxed returns true if the named script always runs in the sandbox.
+func (c *ConfigFile) IsSandboxed(script string) bool {
+	return c != nil && c.Sandbox != nil && slices.Contains(c.Sandbox.Scripts, script)
+}
@@ -0,0 +1,107 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package configfile
+
+import (
+	"bufio"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.jetify.com/devbox/internal/devbox/shellcmd"
+)
+
+// ScriptFilesDir is the directory of a project, relative to devbox.json, whose
+// executable files are scripts of the project.
+const ScriptFilesDir = "devbox.d/scripts"
+
+// ScriptFiles returns the scripts in the project's devbox.d/scripts
+// directory. Each executable file is a script named after the file, which
+// runs the file. The comments at the top of the file can have front matter:
+//
+//	#!/usr/bin/env bash
+//	# description: Build the site
+//	# deps: lint, test
+//	# hidden: true
+//
+// Plugins don't have script files, so it returns nil for plugin.json.
+func (c *ConfigFile) ScriptFiles() Scripts {
+	if c == nil || filepath.Base(c.AbsRootPath) != DefaultName {
+		return nil
+	}
+	dir := filepath.Join(filepath.Dir(c.AbsRootPath), ScriptFilesDir)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			slog.Debug("failed to read the script files", "dir", dir, "err", err)
+		}
+		return nil
+	}
+
+	scripts := Scripts{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") || whitespace.MatchString(name) {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		// Stat follows symlinks to scripts elsewhere.
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() || info.Mode().Perm()&0o111 == 0 {
+			continue
+		}
+		script, err := readScriptFile(path)
+		if err != nil {
+			slog.Debug("failed to read a script file", "path", path, "err", err)
+			continue
+		}
+		scripts[name] = script
+	}
+	return scripts
+}
+
+// readScriptFile returns the script that runs the file at path, with the
+// description, deps and hidden setting in the file's front matter.
+func readScriptFile(path string) (*script, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	quoted := "'" + strings.ReplaceAll(path, "'", `'\''`) + "'"
+	s := &script{
+		Commands: shellcmd.Commands{Cmds: []string{"exec " + quoted + ` "$@"`}},
+		File:     path,
+	}
+	scanner := bufio.NewScanner(f)
+	for i := 0; scanner.Scan(); i++ {
+		line := strings.TrimSpace(scanner.Text())
+		if i == 0 && strings.HasPrefix(line, "#!") {
+			continue
+		}
+		comment, ok := strings.CutPrefix(line, "#")
+		if !ok {
+			comment, ok = strings.CutPrefix(line, "//")
+		}
+		if !ok {
+			break
+		}
+		key, value, ok := strings.Cut(comment, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(key) {
+		case "description":
+			s.Description = value
+		case "deps":
+			s.Deps = strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' })
+		case "hidden":
+			s.Hidden = value == "true"
+		}
+	}
+	return s, scanner.Err()
+}
@@ -0,0 +1,85 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package configfile
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestScriptFiles(t *testing.T) {
+	dir := t.TempDir()
+	scriptsDir := filepath.Join(dir, ScriptFilesDir)
+	if err := os.MkdirAll(scriptsDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]struct {
+		body string
+		mode os.FileMode
+	}{
+		"build":    {"#!/usr/bin/env bash\n# description: Build the site\n# deps: lint, test\n\nmake\n", 0o755},
+		"lint":     {"#!/bin/sh\ngolangci-lint run\n", 0o755},
+		"notes.md": {"# description: Not a script\n", 0o644},
+		".hidden":  {"#!/bin/sh\n", 0o755},
+	}
+	for name, file := range files {
+		if err := os.WriteFile(filepath.Join(scriptsDir, name), []byte(file.body), file.mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := &ConfigFile{AbsRootPath: filepath.Join(dir, DefaultName)}
+	scripts := cfg.ScriptFiles()
+	if len(scripts) != 2 || scripts["build"] == nil || scripts["lint"] == nil {
+		t.Fatalf("got scripts %v, want build and lint", scripts)
+	}
+	build := scripts["build"]
+	if build.Description != "Build the site" {
+		t.Errorf("got Description = %q, want %q", build.Description, "Build the site")
+	}
+	if want := []string{"lint", "test"}; !slices.Equal(build.Deps, want) {
+		t.Errorf("got Deps = %v, want %v", build.Deps, want)
+	}
+	if want := filepath.Join(scriptsDir, "build"); build.File != want {
+		t.Errorf("got File = %q, want %q", build.File, want)
+	}
+
+	plugin := &ConfigFile{AbsRootPath: filepath.Join(dir, "plugin.json")}
+	if scripts := plugin.ScriptFiles(); scripts != nil {
+		t.Errorf("got plugin script files %v, want nil", scripts)
+	}
+}
+
+func TestCheckDeps(t *testing.T) {
+	tests := []struct {
+		name    string
+		scripts Scripts
+		wantErr bool
+	}{
+		{
+			name:    "ok",
+			scripts: Scripts{"build": {Deps: []string{"lint"}}, "lint": {}},
+		},
+		{
+			name:    "missing",
+			scripts: Scripts{"build": {Deps: []string{"lint"}}},
+			wantErr: true,
+		},
+		{
+			name:    "cycle",
+			scripts: Scripts{"a": {Deps: []string{"b"}}, "b": {Deps: []string{"a"}}},
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.scripts.CheckDeps()
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Errorf("CheckDeps() error = %v, wantErr %v", err, test.wantErr)
+			}
+		})
+	}
+}
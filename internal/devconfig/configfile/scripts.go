@@ -1,14 +1,101 @@
 package configfile
 
 import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
 	"strings"
 
+	"github.com/pkg/errors"
+
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+	"go.jetify.com/devbox/internal/cuecfg"
 	"go.jetify.com/devbox/internal/devbox/shellcmd"
 )
 
+// scriptConfig is a script in devbox.json. It's either the script's shell
+// commands, as a string or an array of strings, or an object that has the
+// commands in "run" and the script's settings:
+//
+//	"deploy": {
+//	  "run": "./deploy.sh --stage \"$stage\" --region \"$region\"",
+//	  "params": [{"name": "stage"}, {"name": "region", "default": "us-east-1"}]
+//	},
+//	"report": {
+//	  "interpreter": "python",
+//	  "run": ["import sys", "print(sys.argv[1:])"],
+//	  "description": "Print a report"
+//	}
+type scriptConfig struct {
+	Run    shellcmd.Commands `json:"run"`
+	Params []ScriptParam     `json:"params,omitempty"`
+
+	// Interpreter runs the script's commands instead of sh, such as
+	// "python", "deno" or "bash -euo pipefail".
+	Interpreter string `json:"interpreter,omitempty"`
+
+	// Description is shown next to the script when devbox run lists the
+	// scripts. Hidden scripts aren't listed, but they still run.
+	Description string `json:"description,omitempty"`
+	Hidden      bool   `json:"hidden,omitempty"`
+
+	// object is true when the script was written as an object, so that it
+	// marshals back to the same form.
+	object bool
+}
+
+// ScriptParam is a named parameter of a script. devbox run sets the variable
+// Name to the argument at the parameter's position, or to Default if there's
+// no argument at that position. The arguments are also the script's
+// positional parameters, "$@".
+type ScriptParam struct {
+	Name string `json:"name"`
+
+	// Default is the value of an optional parameter. Parameters without a
+	// default are required.
+	Default *string `json:"default,omitempty"`
+}
+
+func (s scriptConfig) MarshalJSON() ([]byte, error) {
+	if !s.object {
+		return s.Run.MarshalJSON()
+	}
+	type object scriptConfig
+	return cuecfg.MarshalJSON(object(s))
+}
+
+func (s *scriptConfig) UnmarshalJSON(data []byte) error {
+	if len(data) == 0 || data[0] != '{' {
+		*s = scriptConfig{}
+		return s.Run.UnmarshalJSON(data)
+	}
+	type object scriptConfig
+	obj := object{}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	*s = scriptConfig(obj)
+	s.object = true
+	return nil
+}
+
 type script struct {
 	shellcmd.Commands
-	Comments string
+	Comments    string
+	Params      []ScriptParam
+	Interpreter string
+
+	// File is the path of the script's file in devbox.d/scripts. It's
+	// empty for scripts in devbox.json.
+	File        string
+	Description string
+
+	// Deps are the scripts that run before this one.
+	Deps []string
+
+	Hidden bool
 }
 
 type Scripts map[string]*script
@@ -18,14 +105,18 @@ func (c *ConfigFile) Scripts() Scripts {
 		return nil
 	}
 	result := make(Scripts)
-	for name, commands := range c.Shell.Scripts {
+	for name, cfg := range c.Shell.Scripts {
 		comments := ""
 		if c.ast != nil {
 			comments = string(c.ast.beforeComment("shell", "scripts", name))
 		}
 		result[name] = &script{
-			Commands: *commands,
-			Comments: comments,
+			Commands:    cfg.Run,
+			Comments:    comments,
+			Params:      cfg.Params,
+			Interpreter: cfg.Interpreter,
+			Description: cfg.Description,
+			Hidden:      cfg.Hidden,
 		}
 	}
 
@@ -43,9 +134,112 @@ func (s Scripts) WithRelativePaths(projectDir string) Scripts {
 			)
 		}
 		result[name] = &script{
-			Commands: commandsWithRelativePaths,
-			Comments: s.Comments,
+			Commands:    commandsWithRelativePaths,
+			Comments:    s.Comments,
+			Params:      s.Params,
+			Interpreter: s.Interpreter,
+			File:        s.File,
+			Description: s.Description,
+			Deps:        s.Deps,
+			Hidden:      s.Hidden,
 		}
 	}
 	return result
 }
+
+// ParamValues returns the values of the script's named parameters when the
+// script named name runs with args. It returns an error if an argument of a
+// required parameter is missing.
+func (s *script) ParamValues(name string, args []string) (map[string]string, error) {
+	values := map[string]string{}
+	for i, param := range s.Params {
+		switch {
+		case i < len(args):
+			values[param.Name] = args[i]
+		case param.Default != nil:
+			values[param.Name] = *param.Default
+		default:
+			return nil, usererr.New("Script %s requires the %s parameter. Usage: devbox run %s %s",
+				name, param.Name, name, s.Usage())
+		}
+	}
+	return values, nil
+}
+
+// Usage returns the parameters of the script as they're passed to devbox run,
+// such as "<stage> [<region>]".
+func (s *script) Usage() string {
+	params := make([]string, len(s.Params))
+	for i, param := range s.Params {
+		params[i] = fmt.Sprintf("<%s>", param.Name)
+		if param.Default != nil {
+			params[i] = fmt.Sprintf("[%s]", params[i])
+		}
+	}
+	return strings.Join(params, " ")
+}
+
+// paramName matches the names of variables that POSIX shells can set.
+var paramName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func validateScriptParams(name string, params []ScriptParam) error {
+	seen := map[string]bool{}
+	optional := false
+	for _, param := range params {
+		if !paramName.MatchString(param.Name) {
+			return errors.Errorf(
+				"script %s in devbox.json has a parameter with an invalid name %q: "+
+					"names must be letters, digits and underscores, and can't start with a digit",
+				name, param.Name)
+		}
+		if seen[param.Name] {
+			return errors.Errorf("script %s in devbox.json has more than one %s parameter", name, param.Name)
+		}
+		seen[param.Name] = true
+		if param.Default != nil {
+			optional = true
+		} else if optional {
+			return errors.Errorf(
+				"script %s in devbox.json has the required parameter %s after an optional one",
+				name, param.Name)
+		}
+	}
+	return nil
+}
+
+// CheckDeps returns an error if a script depends on a script that doesn't
+// exist, or if scripts depend on each other in a cycle.
+func (s Scripts) CheckDeps() error {
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := map[string]int{}
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case visiting:
+			return usererr.New("Scripts depend on each other in a cycle: %s",
+				strings.Join(append(path, name), " -> "))
+		case done:
+			return nil
+		}
+		state[name] = visiting
+		for _, dep := range s[name].Deps {
+			if _, ok := s[dep]; !ok {
+				return usererr.New("Script %s depends on %s, which isn't a script of the project.", name, dep)
+			}
+			if err := visit(dep, append(path, name)); err != nil {
+				return err
+			}
+		}
+		state[name] = done
+		return nil
+	}
+	for _, name := range slices.Sorted(maps.Keys(s)) {
+		if err := visit(name, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
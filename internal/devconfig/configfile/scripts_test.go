@@ -0,0 +1,88 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package configfile
+
+import (
+	"encoding/json"
+	"maps"
+	"testing"
+)
+
+func TestValidateScriptParams(t *testing.T) {
+	tests := []struct {
+		scripts string
+		wantErr bool
+	}{
+		{scripts: `{"test": "go test ./..."}`},
+		{scripts: `{"test": {"run": "go test \"$@\""}}`},
+		{scripts: `{"deploy": {"run": "./deploy.sh", "params": [{"name": "stage"}, {"name": "region", "default": "us-east-1"}]}}`},
+		{scripts: `{"deploy": {"run": "./deploy.sh", "params": [{"name": "1stage"}]}}`, wantErr: true},
+		{scripts: `{"deploy": {"run": "./deploy.sh", "params": [{"name": "stage"}, {"name": "stage"}]}}`, wantErr: true},
+		{scripts: `{"deploy": {"run": "./deploy.sh", "params": [{"name": "region", "default": ""}, {"name": "stage"}]}}`, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.scripts, func(t *testing.T) {
+			cfg := &ConfigFile{}
+			if err := json.Unmarshal([]byte(`{"shell": {"scripts": `+test.scripts+`}}`), cfg); err != nil {
+				t.Fatal(err)
+			}
+			err := validateScripts(cfg)
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Errorf("validateScripts() error = %v, wantErr %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestScriptParamValues(t *testing.T) {
+	cfg := &ConfigFile{}
+	config := `{"shell": {"scripts": {"deploy": {
+		"run": "./deploy.sh",
+		"params": [{"name": "stage"}, {"name": "region", "default": "us-east-1"}]
+	}}}}`
+	if err := json.Unmarshal([]byte(config), cfg); err != nil {
+		t.Fatal(err)
+	}
+	script := cfg.Scripts()["deploy"]
+
+	got, err := script.ParamValues("deploy", []string{"prod", "eu-west-1", "--dry-run"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"stage": "prod", "region": "eu-west-1"}; !maps.Equal(got, want) {
+		t.Errorf("got ParamValues() = %v, want %v", got, want)
+	}
+
+	got, err = script.ParamValues("deploy", []string{"prod"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"stage": "prod", "region": "us-east-1"}; !maps.Equal(got, want) {
+		t.Errorf("got ParamValues() = %v, want %v", got, want)
+	}
+
+	if _, err := script.ParamValues("deploy", nil); err == nil {
+		t.Error("got ParamValues() error = nil for a missing required parameter")
+	}
+}
+
+func TestScriptMarshalJSON(t *testing.T) {
+	for _, script := range []string{
+		`"go test ./..."`,
+		`["go vet ./...","go test ./..."]`,
+		`{"run":"./deploy.sh","params":[{"name":"stage"}]}`,
+	} {
+		s := scriptConfig{}
+		if err := json.Unmarshal([]byte(script), &s); err != nil {
+			t.Fatal(err)
+		}
+		got, err := json.Marshal(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != script {
+			t.Errorf("got %s, want %s", got, script)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package configfile
+
+import (
+	"regexp"
+
+	"github.com/pkg/errors"
+
+	"go.jetify.com/devbox/nix/flake"
+)
+
+// packageSourceNameRegexp matches the names of package sources. Names can't
+// contain ':', '/' or '#' so that they can't be confused with flake
+// references.
+var packageSourceNameRegexp = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*$`)
+
+func validatePackageSources(cfg *ConfigFile) error {
+	for name, ref := range cfg.PackageSources {
+		if !packageSourceNameRegexp.MatchString(name) {
+			return errors.Errorf(
+				"invalid package source name %q in devbox.json, must start with a letter "+
+					"and contain only letters, digits, '-' and '_'", name)
+		}
+		if _, err := flake.ParseRef(ref); err != nil {
+			return errors.Errorf(
+				"invalid flake reference %q for package source %q in devbox.json: %v",
+				ref, name, err)
+		}
+	}
+	return nil
+}
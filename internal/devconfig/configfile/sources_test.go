@@ -0,0 +1,35 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package configfile
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestValidatePackageSources(t *testing.T) {
+	tests := []struct {
+		sources string
+		wantErr bool
+	}{
+		{sources: `{}`},
+		{sources: `{"mycorp": "github:mycorp/nixpkgs-fork"}`},
+		{sources: `{"my_corp-2": "git+ssh://git@example.com/nix/pkgs?ref=main"}`},
+		{sources: `{"my/corp": "github:mycorp/nixpkgs-fork"}`, wantErr: true},
+		{sources: `{"2corp": "github:mycorp/nixpkgs-fork"}`, wantErr: true},
+		{sources: `{"mycorp": "github:mycorp"}`, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.sources, func(t *testing.T) {
+			cfg := &ConfigFile{}
+			if err := json.Unmarshal([]byte(`{"package_sources": `+test.sources+`}`), cfg); err != nil {
+				t.Fatal(err)
+			}
+			err := validatePackageSources(cfg)
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Errorf("validatePackageSources() error = %v, wantErr %v", err, test.wantErr)
+			}
+		})
+	}
+}
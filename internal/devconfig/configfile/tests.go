@@ -0,0 +1,35 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package configfile
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Test is a check that `devbox test` runs in the project's environment, such
+// as that the environment has the expected version of a tool. It passes if
+// Run exits with status 0 and its output contains Expect.
+type Test struct {
+	// Name identifies the test in the output of devbox test. It defaults
+	// to Run.
+	Name string `json:"name,omitempty"`
+
+	// Run is the shell command that the test runs.
+	Run string `json:"run"`
+
+	// Expect is a string that the command's combined stdout and stderr
+	// must contain. If it's empty, only the exit status is checked.
+	Expect string `json:"expect,omitempty"`
+}
+
+func validateTests(cfg *ConfigFile) error {
+	for i, test := range cfg.Tests {
+		if test == nil || strings.TrimSpace(test.Run) == "" {
+			return errors.Errorf("test %d in devbox.json has an empty run command", i+1)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package configfile
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+
+	"github.com/pkg/errors"
+)
+
+// UnfreePolicy is which packages with unfree licenses a project can install.
+// In devbox.json it's either a bool that allows or forbids all of them, or an
+// allowlist of package names:
+//
+//	"allow_unfree": ["terraform", "vscode"]
+type UnfreePolicy struct {
+	All      bool
+	Packages []string
+}
+
+// Allows reports whether the policy allows the unfree package name, such as
+// "terraform". A nil policy allows every package.
+func (p *UnfreePolicy) Allows(name string) bool {
+	return p == nil || p.All || slices.Contains(p.Packages, name)
+}
+
+func (p *UnfreePolicy) UnmarshalJSON(data []byte) error {
+	*p = UnfreePolicy{}
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		if err := json.Unmarshal(data, &p.Packages); err != nil {
+			return errors.New("allow_unfree must be true, false or a list of package names")
+		}
+		return nil
+	}
+	if err := json.Unmarshal(data, &p.All); err != nil {
+		return errors.New("allow_unfree must be true, false or a list of package names")
+	}
+	return nil
+}
+
+func (p UnfreePolicy) MarshalJSON() ([]byte, error) {
+	if p.Packages != nil {
+		return json.Marshal(p.Packages)
+	}
+	return json.Marshal(p.All)
+}
@@ -0,0 +1,63 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package configfile
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestUnfreePolicyJSON(t *testing.T) {
+	tests := []struct {
+		json    string
+		allowed []string
+		denied  []string
+	}{
+		{`true`, []string{"terraform", "vscode"}, nil},
+		{`false`, nil, []string{"terraform"}},
+		{`[]`, nil, []string{"terraform"}},
+		{`["terraform"]`, []string{"terraform"}, []string{"vscode"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.json, func(t *testing.T) {
+			var policy UnfreePolicy
+			if err := json.Unmarshal([]byte(tt.json), &policy); err != nil {
+				t.Fatal(err)
+			}
+			for _, name := range tt.allowed {
+				if !policy.Allows(name) {
+					t.Errorf("Allows(%q) = false, want true", name)
+				}
+			}
+			for _, name := range tt.denied {
+				if policy.Allows(name) {
+					t.Errorf("Allows(%q) = true, want false", name)
+				}
+			}
+			b, err := json.Marshal(policy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != tt.json {
+				t.Errorf("Marshal() = %s, want %s", b, tt.json)
+			}
+		})
+	}
+}
+
+func TestUnfreePolicyInvalid(t *testing.T) {
+	for _, data := range []string{`"terraform"`, `[1]`, `{}`} {
+		var policy UnfreePolicy
+		if err := json.Unmarshal([]byte(data), &policy); err == nil {
+			t.Errorf("Unmarshal(%s) succeeded, want an error", data)
+		}
+	}
+}
+
+func TestUnfreePolicyNilAllowsAll(t *testing.T) {
+	var policy *UnfreePolicy
+	if !policy.Allows("terraform") {
+		t.Error("a nil policy doesn't allow unfree packages")
+	}
+}
@@ -7,10 +7,18 @@
 	"os"
 	"path/filepath"
 
+	"go.jetify.com/devbox/internal/boxcli/usererr"
 	"go.jetify.com/devbox/internal/devconfig/configfile"
+	"go.jetify.com/devbox/internal/envir"
 )
 
 func Init(dir string) (*Config, error) {
+	if envir.IsLocked() {
+		return nil, usererr.New(
+			"Can't create %s because --locked or %s is set.",
+			configfile.DefaultName, envir.DevboxLocked,
+		)
+	}
 	file, err := os.OpenFile(
 		filepath.Join(dir, configfile.DefaultName),
 		os.O_RDWR|os.O_CREATE|os.O_EXCL,
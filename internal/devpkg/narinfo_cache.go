@@ -16,6 +16,7 @@
 	"go.jetify.com/devbox/internal/debug"
 	"go.jetify.com/devbox/internal/devbox/providers/nixcache"
 	"go.jetify.com/devbox/internal/goutil"
+	"go.jetify.com/devbox/internal/httpclient"
 	"go.jetify.com/devbox/internal/lock"
 	"go.jetify.com/devbox/internal/nix"
 	"golang.org/x/sync/errgroup"
@@ -211,8 +212,8 @@ func (p *Package) outputsForOutputName(output string) ([]lock.Output, error) {
 // the package to query it from the binary cache.
 func (p *Package) isEligibleForBinaryCache() (bool, error) {
 	defer debug.FunctionTimer().End()
-	// Patched packages are not in the binary cache.
-	if p.Patch {
+	// Patched and custom-built packages are not in the binary cache.
+	if p.Patch || p.IsCustomBuild() {
 		return false, nil
 	}
 	sysInfo, err := p.sysInfoIfExists()
@@ -232,8 +233,7 @@ func (p *Package) sysInfoIfExists() (*lock.SystemInfo, error) {
 		return nil, nil
 	}
 
-	// disable for nix < 2.17
-	if !nix.AtLeast(nix.Version2_17) {
+	if !nix.Supports(nix.FeatureLockedSystems) {
 		return nil, nil
 	}
 
@@ -271,7 +271,7 @@ func() (bool, error) {
 			if err != nil {
 				return false, err
 			}
-			res, err := http.DefaultClient.Do(req)
+			res, err := httpclient.Client().Do(req)
 			if err != nil {
 				return false, err
 			}
@@ -4,13 +4,16 @@
 package devpkg
 
 import (
+	"bytes"
 	"cmp"
 	"context"
 	"fmt"
 	"io"
 	"log/slog"
+	"maps"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 	"sync"
 
@@ -42,6 +45,14 @@ type Package struct {
 	// If package does not trigger plugin, this will have no effect.
 	DisablePlugin bool
 
+	// PluginOverrides replaces values set by the built-in plugin the package
+	// triggers. If package does not trigger plugin, this will have no effect.
+	PluginOverrides *configfile.PluginOverrides
+
+	// SHA256 is the checksum set in the config of a url package. Use
+	// URLChecksum to also get checksums set in the package's URL.
+	SHA256 string
+
 	// installable is the flake attribute that the package resolves to.
 	// When it gets set depends on the original package string:
 	//
@@ -78,6 +89,9 @@ type Package struct {
 	// 3. Github
 	//    remote flakes with raw name starting with `Github:`
 	//    example: github:nixos/nixpkgs/5233fd2ba76a3accb5aaa999c00509a11fd0793c#hello
+	// 4. URL
+	//    files downloaded from a URL, with an optional checksum
+	//    example: url:https://example.com/tool.tar.gz#sha256=<hex>
 	Raw string
 
 	// Outputs is a list of outputs to build from the package's derivation.
@@ -91,6 +105,22 @@ type Package struct {
 	// installed even if they are marked as insecure.
 	AllowInsecure []string
 
+	// Override is passed to the package's override function to change the
+	// arguments it's built with.
+	Override map[string]any
+
+	// Overlay is the absolute path to a Nix file with an overlay to apply to
+	// the nixpkgs that the package comes from.
+	Overlay string
+
+	// Patches are the absolute paths to patch files to apply to the
+	// package's source.
+	Patches []string
+
+	// Inputs overrides the inputs of a flake package. See
+	// [configfile.Package.Inputs].
+	Inputs map[string]string
+
 	// isInstallable is true if the package may be enabled on the current platform.
 	// It's a function to allow deferring nix System call until it's needed.
 	isInstallable func() bool
@@ -111,9 +141,24 @@ func PackagesFromConfig(packages []configfile.Package, l lock.Locker) []*Package
 	for _, cfgPkg := range packages {
 		pkg := newPackage(cfgPkg.VersionedName(), cfgPkg.IsEnabledOnPlatform, l)
 		pkg.DisablePlugin = cfgPkg.DisablePlugin
+		pkg.PluginOverrides = cfgPkg.PluginOverrides
+		pkg.SHA256 = cfgPkg.SHA256
 		pkg.Patch = pkgNeedsPatch(pkg.CanonicalName(), cfgPkg.Patch)
 		pkg.outputs.selectedNames = lo.Uniq(append(pkg.outputs.selectedNames, cfgPkg.Outputs...))
 		pkg.AllowInsecure = cfgPkg.AllowInsecure
+		pkg.Override = cfgPkg.Override
+		if cfgPkg.Overlay != "" {
+			pkg.Overlay = absPath(l.ProjectDir(), cfgPkg.Overlay)
+		}
+		for _, patch := range cfgPkg.Patches {
+			pkg.Patches = append(pkg.Patches, absPath(l.ProjectDir(), patch))
+		}
+		pkg.Inputs = cfgPkg.Inputs
+		if pkg.IsCustomBuild() && cfgPkg.Patch == configfile.PatchAuto {
+			// Automatic patches are for the package as it's built by
+			// nixpkgs and might not apply to a custom build.
+			pkg.Patch = false
+		}
 		result = append(result, pkg)
 	}
 	return result
@@ -139,6 +184,24 @@ func newPackage(raw string, isInstallable func() bool, locker lock.Locker) *Pack
 		isInstallable: sync.OnceValue(isInstallable),
 	}
 
+	if _, ok := pkgtype.ParseSourcePackage(raw, locker.PackageSources()); ok {
+		// Packages from package_sources are flakes, but only the lockfile
+		// knows which flake the source's name expands to.
+		pkg.resolve = sync.OnceValue(func() error { return resolve(pkg) })
+		pkg.Patch = pkgNeedsPatch(pkg.CanonicalName(), configfile.PatchAuto)
+		return pkg
+	}
+
+	if pkgtype.IsURL(raw) {
+		// URL packages aren't nix packages, so there's no installable and
+		// resolving only locks them.
+		pkg.resolve = sync.OnceValue(func() error {
+			_, err := locker.Resolve(raw)
+			return err
+		})
+		return pkg
+	}
+
 	// The raw string is either a Devbox package ("name" or "name@version")
 	// or it's a flake installable. In some cases they're ambiguous
 	// ("nixpkgs" is a devbox package and a flake). When that happens, we
@@ -236,6 +299,9 @@ func (p *Package) FlakeInputName() string {
 	default:
 		result = p.installable.Ref.String() + "-" + p.Hash()
 	}
+	if inputsHash := p.InputsHash(); inputsHash != "" {
+		result += "-" + inputsHash
+	}
 
 	// replace all non-alphanumeric with dashes
 	return inputNameRegex.ReplaceAllString(result, "-")
@@ -352,7 +418,7 @@ func (p *Package) PackageAttributePath() (string, error) {
 // always normalized which means it should not be used to compare packages.
 // During happy paths (devbox packages and nix flakes that contains a fragment)
 // it is much faster than NormalizedPackageAttributePath
-func (p *Package) FullPackageAttributePath() (string, error) {
+func (p *Package) FullPackageAttributePath(ctx context.Context) (string, error) {
 	if p.IsDevboxPackage {
 		reference, err := p.NormalizedDevboxPackageReference()
 		if err != nil {
@@ -361,17 +427,17 @@ func (p *Package) FullPackageAttributePath() (string, error) {
 		_, fragment, _ := strings.Cut(reference, "#")
 		return fragment, nil
 	}
-	return p.NormalizedPackageAttributePath()
+	return p.NormalizedPackageAttributePath(ctx)
 }
 
 // NormalizedPackageAttributePath returns an attribute path normalized by nix
 // search. This is useful for comparing different attribute paths that may
 // point to the same package. Note, it may be an expensive call.
-func (p *Package) NormalizedPackageAttributePath() (string, error) {
+func (p *Package) NormalizedPackageAttributePath(ctx context.Context) (string, error) {
 	if p.normalizedPackageAttributePathCache != "" {
 		return p.normalizedPackageAttributePathCache, nil
 	}
-	path, err := p.normalizePackageAttributePath()
+	path, err := p.normalizePackageAttributePath(ctx)
 	if err != nil {
 		return path, err
 	}
@@ -381,7 +447,7 @@ func (p *Package) NormalizedPackageAttributePath() (string, error) {
 
 // normalizePackageAttributePath calls nix search to find the normalized attribute
 // path. It may be an expensive call (~100ms).
-func (p *Package) normalizePackageAttributePath() (string, error) {
+func (p *Package) normalizePackageAttributePath(ctx context.Context) (string, error) {
 	installable, err := p.FlakeInstallable()
 	if err != nil {
 		return "", err
@@ -401,12 +467,12 @@ func (p *Package) normalizePackageAttributePath() (string, error) {
 		//
 		// This will be slow if its the first time on the user's machine that this
 		// query is running. Otherwise, it will be cached and fast.
-		if infos, err = nix.SearchNixpkgsAttribute(query); err != nil {
+		if infos, err = nix.SearchNixpkgsAttribute(ctx, query); err != nil {
 			return "", err
 		}
 	} else {
 		// fallback to the slow but generalized nix.Search
-		if infos, err = nix.Search(query); err != nil {
+		if infos, err = nix.Search(ctx, query); err != nil {
 			return "", err
 		}
 	}
@@ -443,7 +509,7 @@ func (p *Package) normalizePackageAttributePath() (string, error) {
 		)
 	}
 
-	if nix.PkgExistsForAnySystem(query) {
+	if nix.PkgExistsForAnySystem(ctx, query) {
 		return "", usererr.WithUserMessage(
 			ErrCannotBuildPackageOnSystem,
 			"Package \"%s\" was found, but we're unable to build it for your system."+
@@ -458,24 +524,95 @@ func (p *Package) normalizePackageAttributePath() (string, error) {
 var ErrCannotBuildPackageOnSystem = errors.New("unable to build for system")
 
 func (p *Package) Hash() string {
-	sum := ""
-	if p.installable.Ref.Type == flake.TypePath {
-		// For local flakes, use content hash of the flake.nix file to ensure
-		// user always gets newest flake.
-		sum, _ = cachehash.File(filepath.Join(p.installable.Ref.Path, "flake.nix"))
-	}
+	// For local flakes, use the content hash of the flake's directory to
+	// ensure the user always gets the newest flake.
+	sum, _ := p.LocalFlakeHash()
 
 	if sum == "" {
 		sum = cachehash.Bytes([]byte(cmp.Or(p.installable.String(), p.Raw)))
 	}
+	if p.Overlay != "" {
+		// Rebuild the package when its overlay changes.
+		overlaySum, _ := cachehash.File(p.Overlay)
+		sum = cachehash.Bytes([]byte(sum + overlaySum))
+	}
+	if patchesSum := p.PatchesHash(); patchesSum != "" {
+		sum = cachehash.Bytes([]byte(sum + patchesSum))
+	}
 	return sum[:min(len(sum), 6)]
 }
 
+// IsCustomBuild returns true if the package has overrides, an overlay,
+// patches or flake input overrides that change how nix builds it.
+func (p *Package) IsCustomBuild() bool {
+	return len(p.Override) > 0 || p.Overlay != "" || len(p.Patches) > 0 || len(p.Inputs) > 0
+}
+
+// InputsHash returns a short hash of the package's flake input overrides, or
+// an empty string if it doesn't have any. Packages from the same flake with
+// different overrides need different inputs in the generated flake.
+func (p *Package) InputsHash() string {
+	if len(p.Inputs) == 0 {
+		return ""
+	}
+	buf := bytes.Buffer{}
+	for _, name := range slices.Sorted(maps.Keys(p.Inputs)) {
+		buf.WriteString(name + "=" + p.Inputs[name] + "\n")
+	}
+	return cachehash.Bytes6(buf.Bytes())
+}
+
+// PatchesHash returns the content hash of the package's patch files, or an
+// empty string if it doesn't have any. Missing files don't change the hash.
+func (p *Package) PatchesHash() string {
+	if len(p.Patches) == 0 {
+		return ""
+	}
+	buf := bytes.Buffer{}
+	for _, patch := range p.Patches {
+		sum, _ := cachehash.File(patch)
+		buf.WriteString(sum)
+	}
+	return cachehash.Bytes(buf.Bytes())
+}
+
+func absPath(projectDir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(projectDir, path)
+}
+
+// localFlakeHashes caches the content hashes of local flakes for the rest of
+// the command, because hashing a flake reads every file in it.
+var localFlakeHashes = sync.Map{}
+
+// LocalFlakeHash returns the content hash of the directory of a local path:
+// flake, which are the files that git tracks if the flake is in a git
+// repository. It returns an empty string for other packages, or if the
+// directory doesn't exist.
+func (p *Package) LocalFlakeHash() (string, error) {
+	if p.installable.Ref.Type != flake.TypePath {
+		return "", nil
+	}
+	path := p.installable.Ref.Path
+	hash, _ := localFlakeHashes.LoadOrStore(path, sync.OnceValues(func() (string, error) {
+		return cachehash.Dir(path)
+	}))
+	return hash.(func() (string, error))()
+}
+
 // Equals compares two Packages. This may be an expensive operation since it
 // may have to normalize a Package's attribute path, which may require a network
 // call.
-func (p *Package) Equals(other *Package) bool {
-	if p.Raw == other.Raw || p.installable == other.installable {
+func (p *Package) Equals(ctx context.Context, other *Package) bool {
+	if p.Raw == other.Raw {
+		return true
+	}
+	if p.IsURL() || other.IsURL() {
+		return false
+	}
+	if p.installable == other.installable {
 		return true
 	}
 
@@ -484,11 +621,11 @@ func (p *Package) Equals(other *Package) bool {
 		return false
 	}
 
-	name, err := p.NormalizedPackageAttributePath()
+	name, err := p.NormalizedPackageAttributePath(ctx)
 	if err != nil {
 		return false
 	}
-	otherName, err := other.NormalizedPackageAttributePath()
+	otherName, err := other.NormalizedPackageAttributePath(ctx)
 	if err != nil {
 		return false
 	}
@@ -527,7 +664,7 @@ func (p *Package) LegacyToVersioned() string {
 // This is an internal method, and should not be called directly.
 func EnsureNixpkgsPrefetched(ctx context.Context, w io.Writer, pkgs []*Package) error {
 	for _, input := range pkgs {
-		if err := input.ensureNixpkgsPrefetched(w); err != nil {
+		if err := input.ensureNixpkgsPrefetched(ctx, w); err != nil {
 			return err
 		}
 	}
@@ -536,7 +673,7 @@ func EnsureNixpkgsPrefetched(ctx context.Context, w io.Writer, pkgs []*Package)
 
 // ensureNixpkgsPrefetched should be called via the public EnsureNixpkgsPrefetched.
 // See function comment there.
-func (p *Package) ensureNixpkgsPrefetched(w io.Writer) error {
+func (p *Package) ensureNixpkgsPrefetched(ctx context.Context, w io.Writer) error {
 	inCache, err := p.IsInBinaryCache()
 	if err != nil {
 		return err
@@ -551,7 +688,7 @@ func (p *Package) ensureNixpkgsPrefetched(w io.Writer) error {
 	if hash == "" {
 		return nil
 	}
-	return nix.EnsureNixpkgsPrefetched(w, hash)
+	return nix.EnsureNixpkgsPrefetched(ctx, w, hash)
 }
 
 // version returns the version of the package
@@ -631,12 +768,12 @@ func (p *Package) HasAllowInsecure() bool {
 // /nix/store/abc123-foo-1.0.0 -> foo-1.0.0
 // Warning, this is probably slowish. If you need to call this multiple times,
 // consider caching the result.
-func (p *Package) StoreName() (string, error) {
+func (p *Package) StoreName(ctx context.Context) (string, error) {
 	u, err := p.urlForInstall()
 	if err != nil {
 		return "", err
 	}
-	name, err := nix.EvalPackageName(u)
+	name, err := nix.EvalPackageName(ctx, u)
 	if err != nil {
 		return "", err
 	}
@@ -657,6 +794,21 @@ func (p *Package) IsRunX() bool {
 	return pkgtype.IsRunX(p.Raw)
 }
 
+func (p *Package) IsURL() bool {
+	return pkgtype.IsURL(p.Raw)
+}
+
+// URLChecksum returns the expected SHA-256 of the file downloaded by a url
+// package, from either the package's sha256 field or its URL.
+func (p *Package) URLChecksum() string {
+	_, checksum := pkgtype.SplitURLChecksum(p.Raw)
+	return cmp.Or(p.SHA256, checksum)
+}
+
+func IsURL(p *Package, _ int) bool {
+	return p.IsURL()
+}
+
 func (p *Package) IsNix() bool {
 	return IsNix(p, 0)
 }
@@ -680,7 +832,7 @@ func (p *Package) LockfileKey() string {
 }
 
 func IsNix(p *Package, _ int) bool {
-	return !p.IsRunX()
+	return !p.IsRunX() && !p.IsURL()
 }
 
 func IsRunX(p *Package, _ int) bool {
@@ -701,7 +853,7 @@ func (p *Package) DocsURL() string {
 // GetOutputNames returns the names of the nix package outputs. Outputs can be
 // specified in devbox.json package fields or as part of the flake reference.
 func (p *Package) GetOutputNames() ([]string, error) {
-	if p.IsRunX() {
+	if !p.IsNix() {
 		return []string{}, nil
 	}
 
@@ -780,7 +932,7 @@ func (p *Package) GetStorePaths(ctx context.Context, w io.Writer) ([]string, err
 		storePathsForInstallable, err := nix.StorePathsFromInstallable(
 			ctx, installable, p.HasAllowInsecure())
 		if err != nil {
-			return nil, packageInstallErrorHandler(err, p, installable)
+			return nil, packageInstallErrorHandler(ctx, err, p, installable)
 		}
 		storePathsForPackage = append(storePathsForPackage, storePathsForInstallable...)
 	}
@@ -791,7 +943,7 @@ func (p *Package) GetStorePaths(ctx context.Context, w io.Writer) ([]string, err
 // can work around them:
 // 1. Packages that cannot be installed on the current system, but may be installable on other systems.packageInstallErrorHandler
 // 2. Packages marked insecure by nix
-func packageInstallErrorHandler(err error, pkg *Package, installableOrEmpty string) error {
+func packageInstallErrorHandler(ctx context.Context, err error, pkg *Package, installableOrEmpty string) error {
 	if err == nil {
 		return nil
 	}
@@ -832,7 +984,7 @@ func packageInstallErrorHandler(err error, pkg *Package, installableOrEmpty stri
 		)
 	}
 
-	if isInsecureErr, userErr := nix.IsExitErrorInsecurePackage(err, pkg.Versioned(), installableOrEmpty); isInsecureErr {
+	if isInsecureErr, userErr := nix.IsExitErrorInsecurePackage(ctx, err, pkg.Versioned(), installableOrEmpty); isInsecureErr {
 		return userErr
 	}
 
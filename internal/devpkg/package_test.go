@@ -10,6 +10,7 @@
 	"testing"
 
 	"github.com/samber/lo"
+	"go.jetify.com/devbox/internal/devpkg/pkgtype"
 	"go.jetify.com/devbox/internal/lock"
 	"go.jetify.com/devbox/internal/nix"
 	"go.jetify.com/devbox/nix/flake"
@@ -103,6 +104,7 @@ type testInput struct {
 
 type lockfile struct {
 	projectDir string
+	sources    map[string]string
 }
 
 func (l *lockfile) ProjectDir() string {
@@ -118,11 +120,18 @@ func (l *lockfile) Stdenv() flake.Ref {
 	}
 }
 
+func (l *lockfile) PackageSources() map[string]string {
+	return l.sources
+}
+
 func (l *lockfile) Get(pkg string) *lock.Package {
 	return nil
 }
 
 func (l *lockfile) Resolve(pkg string) (*lock.Package, error) {
+	if source, ok := pkgtype.ParseSourcePackage(pkg, l.sources); ok {
+		return &lock.Package{Resolved: source.Installable(), Version: source.Version}, nil
+	}
 	switch {
 	case strings.Contains(pkg, "path:"):
 		return &lock.Package{Resolved: pkg}, nil
@@ -139,7 +148,25 @@ func (l *lockfile) Resolve(pkg string) (*lock.Package, error) {
 }
 
 func testInputFromString(s, projectDir string) *testInput {
-	return lo.ToPtr(testInput{Package: PackageFromStringWithDefaults(s, &lockfile{projectDir})})
+	return lo.ToPtr(testInput{Package: PackageFromStringWithDefaults(s, &lockfile{projectDir: projectDir})})
+}
+
+func TestSourcePackage(t *testing.T) {
+	locker := &lockfile{sources: map[string]string{"mycorp": "github:mycorp/nixpkgs-fork"}}
+	pkg := PackageFromStringWithDefaults("mycorp#internal-tool@1.2", locker)
+	if pkg.IsDevboxPackage {
+		t.Error("got IsDevboxPackage = true for a package from a package source")
+	}
+	if got, want := pkg.URLForFlakeInput(), "github:mycorp/nixpkgs-fork"; got != want {
+		t.Errorf("URLForFlakeInput() = %q, want %q", got, want)
+	}
+	attrPath, err := pkg.PackageAttributePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attrPath != "internal-tool" {
+		t.Errorf("PackageAttributePath() = %q, want %q", attrPath, "internal-tool")
+	}
 }
 
 func TestHashFromNixPkgsURL(t *testing.T) {
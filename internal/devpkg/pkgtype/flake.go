@@ -7,7 +7,7 @@
 )
 
 func IsFlake(s string) bool {
-	if IsRunX(s) {
+	if IsRunX(s) || IsURL(s) {
 		return false
 	}
 	parsed, err := flake.ParseInstallable(s)
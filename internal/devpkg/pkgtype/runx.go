@@ -5,8 +5,9 @@
 	"os"
 	"strings"
 
+	"github.com/cavaliergopher/grab/v3"
+	"go.jetify.com/devbox/internal/httpclient"
 	"go.jetify.com/pkg/runx/impl/registry"
-	"go.jetify.com/pkg/runx/impl/runx"
 )
 
 const (
@@ -23,16 +24,16 @@ func IsRunX(s string) bool {
 	return strings.HasPrefix(s, RunXPrefix)
 }
 
-func RunXClient() *runx.RunX {
-	return &runx.RunX{
-		GithubAPIToken: getGithubToken(),
-	}
-}
-
 func RunXRegistry(ctx context.Context) (*registry.Registry, error) {
 	if cachedRegistry == nil {
+		// runx downloads release artifacts with grab's default client, which
+		// has its own transport. Use Devbox's so that downloads honor
+		// DEVBOX_CA_BUNDLE and are retried like other requests. runx's GitHub
+		// API requests use http.DefaultTransport, which httpclient.InstallDefault
+		// already replaces.
+		grab.DefaultClient.HTTPClient = httpclient.Client()
 		var err error
-		cachedRegistry, err = registry.NewLocalRegistry(ctx, getGithubToken())
+		cachedRegistry, err = registry.NewLocalRegistry(ctx, GithubToken())
 		if err != nil {
 			return nil, err
 		}
@@ -40,7 +41,9 @@ func RunXRegistry(ctx context.Context) (*registry.Registry, error) {
 	return cachedRegistry, nil
 }
 
-func getGithubToken() string {
+// GithubToken returns the token runx uses to access GitHub, which is required
+// for packages released from private repositories.
+func GithubToken() string {
 	token := os.Getenv(githubAPITokenVarName)
 	if token == "" {
 		token = os.Getenv(oldGithubAPITokenVarName)
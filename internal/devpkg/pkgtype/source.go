@@ -0,0 +1,55 @@
+package pkgtype
+
+import "strings"
+
+// SourcePackage is a package from a source in devbox.json's package_sources,
+// as in mycorp#internal-tool@1.2.
+type SourcePackage struct {
+	// Source is the name of the source, such as mycorp.
+	Source string
+
+	// Ref is the flake reference that the source maps to, such as
+	// github:mycorp/nixpkgs-fork.
+	Ref string
+
+	// AttrPath is the package's attribute path in the source.
+	AttrPath string
+
+	// Version is the version the package must have, or "" or "latest" for
+	// any version.
+	Version string
+}
+
+// ParseSourcePackage parses a package that starts with the name of a source
+// in sources followed by a '#'. It returns false for other packages.
+func ParseSourcePackage(s string, sources map[string]string) (SourcePackage, bool) {
+	source, rest, found := strings.Cut(s, "#")
+	if !found || rest == "" {
+		return SourcePackage{}, false
+	}
+	ref, ok := sources[source]
+	if !ok {
+		return SourcePackage{}, false
+	}
+
+	pkg := SourcePackage{Source: source, Ref: ref, AttrPath: rest}
+	if i := strings.LastIndex(rest, "@"); i > 0 && i < len(rest)-1 {
+		pkg.AttrPath, pkg.Version = rest[:i], rest[i+1:]
+	}
+	return pkg, true
+}
+
+// Installable returns the flake installable of the package, without its
+// version.
+func (p SourcePackage) Installable() string {
+	return p.Ref + "#" + p.AttrPath
+}
+
+// MatchesVersion returns true if version satisfies the package's version. A
+// version such as 1.2 matches 1.2 and 1.2.x, but not 1.20.
+func (p SourcePackage) MatchesVersion(version string) bool {
+	if p.Version == "" || p.Version == "latest" {
+		return true
+	}
+	return version == p.Version || strings.HasPrefix(version, p.Version+".")
+}
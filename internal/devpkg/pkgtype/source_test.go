@@ -0,0 +1,57 @@
+package pkgtype
+
+import "testing"
+
+func TestParseSourcePackage(t *testing.T) {
+	sources := map[string]string{"mycorp": "github:mycorp/nixpkgs-fork"}
+	tests := []struct {
+		in   string
+		want SourcePackage
+		ok   bool
+	}{
+		{
+			in:   "mycorp#internal-tool@1.2",
+			want: SourcePackage{Source: "mycorp", Ref: sources["mycorp"], AttrPath: "internal-tool", Version: "1.2"},
+			ok:   true,
+		},
+		{
+			in:   "mycorp#tools.internal-tool",
+			want: SourcePackage{Source: "mycorp", Ref: sources["mycorp"], AttrPath: "tools.internal-tool"},
+			ok:   true,
+		},
+		{in: "othercorp#internal-tool@1.2"},
+		{in: "mycorp"},
+		{in: "mycorp#"},
+		{in: "github:mycorp/nixpkgs-fork#internal-tool"},
+		{in: "go@1.22"},
+	}
+	for _, test := range tests {
+		t.Run(test.in, func(t *testing.T) {
+			got, ok := ParseSourcePackage(test.in, sources)
+			if got != test.want || ok != test.ok {
+				t.Errorf("ParseSourcePackage(%q) = %+v, %v, want %+v, %v", test.in, got, ok, test.want, test.ok)
+			}
+		})
+	}
+}
+
+func TestSourcePackageMatchesVersion(t *testing.T) {
+	tests := []struct {
+		want, version string
+		matches       bool
+	}{
+		{want: "", version: "2.0", matches: true},
+		{want: "latest", version: "2.0", matches: true},
+		{want: "1.2", version: "1.2", matches: true},
+		{want: "1.2", version: "1.2.3", matches: true},
+		{want: "1.2", version: "1.20", matches: false},
+		{want: "1.2.3", version: "1.2", matches: false},
+	}
+	for _, test := range tests {
+		pkg := SourcePackage{Version: test.want}
+		if got := pkg.MatchesVersion(test.version); got != test.matches {
+			t.Errorf("SourcePackage{Version: %q}.MatchesVersion(%q) = %v, want %v",
+				test.want, test.version, got, test.matches)
+		}
+	}
+}
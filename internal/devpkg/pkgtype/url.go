@@ -0,0 +1,36 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package pkgtype
+
+import "strings"
+
+const (
+	URLScheme = "url"
+	URLPrefix = URLScheme + ":"
+
+	// urlChecksumFragment is the URL fragment that carries a url package's
+	// checksum, as in url:https://example.com/tool.tar.gz#sha256=<hex>.
+	urlChecksumFragment = "#sha256="
+)
+
+// IsURL returns true if s is a package that is downloaded from an arbitrary
+// URL instead of being built by nix.
+func IsURL(s string) bool {
+	return strings.HasPrefix(s, URLPrefix)
+}
+
+// SplitURLChecksum splits a url package, with or without the url: prefix, into
+// its download URL and the hex-encoded SHA-256 in its #sha256= fragment, if any.
+func SplitURLChecksum(s string) (url, checksum string) {
+	url, checksum, _ = strings.Cut(strings.TrimPrefix(s, URLPrefix), urlChecksumFragment)
+	return url, checksum
+}
+
+// JoinURLChecksum is the inverse of SplitURLChecksum, without the url: prefix.
+func JoinURLChecksum(url, checksum string) string {
+	if checksum == "" {
+		return url
+	}
+	return url + urlChecksumFragment + checksum
+}
@@ -13,6 +13,15 @@ func (p *Package) ValidateExists(ctx context.Context) (bool, error) {
 		_, err := p.lockfile.Resolve(p.Raw)
 		return err == nil, err
 	}
+	if p.IsURL() {
+		if p.URLChecksum() == "" {
+			return false, usererr.New(
+				"Package %q needs a SHA-256 checksum. Add it to the URL as %s#sha256=<checksum> "+
+					"or set the package's sha256 field in devbox.json.", p.Raw, p.Raw)
+		}
+		_, err := p.lockfile.Resolve(p.Raw)
+		return err == nil, err
+	}
 	if p.isVersioned() && p.version() == "" {
 		return false, usererr.New("No version specified for %q.", p.Raw)
 	}
@@ -25,20 +34,20 @@ func (p *Package) ValidateExists(ctx context.Context) (bool, error) {
 		return true, nil
 	}
 
-	info, err := p.NormalizedPackageAttributePath()
+	info, err := p.NormalizedPackageAttributePath(ctx)
 	return info != "", err
 }
 
-func (p *Package) ValidateInstallsOnSystem() (bool, error) {
+func (p *Package) ValidateInstallsOnSystem(ctx context.Context) (bool, error) {
 	u, err := p.urlForInstall()
 	if err != nil {
 		return false, err
 	}
-	info, _ := nix.Search(u)
+	info, _ := nix.Search(ctx, u)
 	if len(info) == 0 {
 		return false, nil
 	}
-	if out, err := nix.Eval(u); err != nil &&
+	if out, err := nix.Eval(ctx, u); err != nil &&
 		strings.Contains(string(out), "is not available on the requested hostPlatform") {
 		return false, nil
 	}
@@ -5,6 +5,13 @@
 
 const (
 	DevboxCache = "DEVBOX_CACHE"
+	// DevboxCABundle is the path to a PEM file with additional CA
+	// certificates to trust, such as the certificate of a corporate proxy.
+	DevboxCABundle = "DEVBOX_CA_BUNDLE"
+	// DevboxAuditWebhook is a URL that devbox posts the audit records of
+	// every project to, in addition to the project's audit webhook, so that
+	// an org can collect them without configuring each project.
+	DevboxAuditWebhook = "DEVBOX_AUDIT_WEBHOOK"
 	// DevboxConfig sets the default value for the --config flag, i.e. the path
 	// to the directory (or devbox.json file) of the devbox project to use. This
 	// is convenient for setting the config path in environments where passing
@@ -13,12 +20,36 @@
 	DevboxGateway = "DEVBOX_GATEWAY"
 	// DevboxLatestVersion is the latest version available of the devbox CLI binary.
 	// NOTE: it should NOT start with v (like 0.4.8)
-	DevboxLatestVersion  = "DEVBOX_LATEST_VERSION"
-	DevboxRegion         = "DEVBOX_REGION"
-	DevboxSearchHost     = "DEVBOX_SEARCH_HOST"
-	DevboxShellEnabled   = "DEVBOX_SHELL_ENABLED"
+	DevboxLatestVersion = "DEVBOX_LATEST_VERSION"
+	// DevboxLocked makes commands fail instead of changing devbox.lock or
+	// devbox.json, such as in CI. The --locked flag sets it.
+	DevboxLocked = "DEVBOX_LOCKED"
+	// DevboxMetrics makes devbox record metrics of the environment's
+	// health, such as how long shells take to start, in a local file that
+	// devbox metrics reports on.
+	DevboxMetrics = "DEVBOX_METRICS"
+	// DevboxNoTrustPrompt makes devbox run a project's init hooks, scripts
+	// and services without asking the user to trust the project first,
+	// such as in CI. The --no-trust-prompt flag sets it.
+	DevboxNoTrustPrompt = "DEVBOX_NO_TRUST_PROMPT"
+	DevboxRegion        = "DEVBOX_REGION"
+	DevboxSearchHost    = "DEVBOX_SEARCH_HOST"
+	DevboxShellEnabled  = "DEVBOX_SHELL_ENABLED"
+	// DevboxShellDepth is the number of devbox shells that the current
+	// shell is nested in, including itself. It's 1 in a devbox shell that
+	// was started from outside of one.
+	DevboxShellDepth     = "DEVBOX_SHELL_DEPTH"
 	DevboxShellStartTime = "DEVBOX_SHELL_START_TIME"
-	DevboxVM             = "DEVBOX_VM"
+	// DevboxSuggest is the maximum number of similar package names that
+	// errors suggest when a package isn't found. 0 disables suggestions.
+	// The --suggest flag sets it.
+	DevboxSuggest = "DEVBOX_SUGGEST"
+	DevboxVM      = "DEVBOX_VM"
+	// DevboxSharedProject makes each user of a project have their own nix
+	// profile, for projects that several users share on one machine. The
+	// project's .devbox directory must be writable by all of them, such as
+	// with a shared group.
+	DevboxSharedProject = "DEVBOX_SHARED_PROJECT"
 
 	LauncherVersion = "LAUNCHER_VERSION"
 	LauncherPath    = "LAUNCHER_PATH"
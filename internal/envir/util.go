@@ -19,6 +19,55 @@ func IsDevboxShellEnabled() bool {
 	return inDevboxShell
 }
 
+// ShellDepth returns the number of nested devbox shells that the current
+// process runs in, or 0 if it isn't in a devbox shell.
+func ShellDepth() int {
+	depth, err := strconv.Atoi(os.Getenv(DevboxShellDepth))
+	if err == nil && depth > 0 {
+		return depth
+	}
+	// Shells started by older versions of devbox don't set the depth.
+	if IsDevboxShellEnabled() {
+		return 1
+	}
+	return 0
+}
+
+// SuggestLimit returns the maximum number of package names to suggest when a
+// package isn't found.
+func SuggestLimit() int {
+	limit, err := strconv.Atoi(os.Getenv(DevboxSuggest))
+	if err != nil || limit < 0 {
+		return 3
+	}
+	return limit
+}
+
+// IsLocked reports if devbox.lock and devbox.json must not change.
+func IsLocked() bool {
+	locked, _ := strconv.ParseBool(os.Getenv(DevboxLocked))
+	return locked
+}
+
+// IsMetricsEnabled reports if devbox records the metrics that devbox metrics
+// reports on.
+func IsMetricsEnabled() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv(DevboxMetrics))
+	return enabled
+}
+
+// IsTrustPromptDisabled reports if devbox runs the code of projects that the
+// user didn't trust.
+func IsTrustPromptDisabled() bool {
+	disabled, _ := strconv.ParseBool(os.Getenv(DevboxNoTrustPrompt))
+	return disabled
+}
+
+func IsSharedProject() bool {
+	shared, _ := strconv.ParseBool(os.Getenv(DevboxSharedProject))
+	return shared
+}
+
 func DoNotTrack() bool {
 	// https://consoledonottrack.com/
 	doNotTrack, _ := strconv.ParseBool(os.Getenv("DO_NOT_TRACK"))
@@ -51,6 +51,29 @@ func Exists(path string) bool {
 	return err == nil
 }
 
+// WriteFileAtomic writes data to a temporary file and renames it to path, so
+// that other processes reading path never see a partially written file.
+func WriteFileAtomic(path string, data []byte, perm fs.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return errors.WithStack(err)
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return errors.WithStack(err)
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(os.Rename(tmp.Name(), path))
+}
+
 func IsDirEmpty(path string) (bool, error) {
 	entries, err := os.ReadDir(path)
 	if err != nil {
@@ -0,0 +1,107 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+// Package httpclient provides the HTTP client used for all of Devbox's network
+// requests, so that they consistently honor proxy settings and custom CA
+// certificates.
+//
+// Proxies are configured with the standard HTTPS_PROXY, HTTP_PROXY and
+// NO_PROXY environment variables. Additional CA certificates, such as the
+// certificate of a TLS-intercepting corporate proxy, can be added with
+// DEVBOX_CA_BUNDLE.
+//
+// Requests made with Client, or through http.DefaultTransport once
+// InstallDefault is called, are retried when they fail with a transient error
+// and are limited by the timeout set with SetTimeout.
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"go.jetify.com/devbox/internal/envir"
+	"go.jetify.com/devbox/internal/ux"
+)
+
+const (
+	dialTimeout         = 30 * time.Second
+	tlsHandshakeTimeout = 10 * time.Second
+)
+
+var (
+	transport = sync.OnceValue(func() *http.Transport {
+		t, err := newTransport(os.Getenv(envir.DevboxCABundle))
+		if err != nil {
+			ux.Fwarningf(os.Stderr, "%v. Using the system's CA certificates.\n", err)
+		}
+		return t
+	})
+	retrying = sync.OnceValue(func() http.RoundTripper {
+		return newRetryTransport(Transport())
+	})
+	client = sync.OnceValue(func() *http.Client {
+		return &http.Client{Transport: retrying()}
+	})
+)
+
+// Transport returns the shared HTTP transport. Unlike Client, it doesn't retry
+// failed requests.
+func Transport() *http.Transport {
+	return transport()
+}
+
+// Client returns the shared HTTP client. It retries transient failures and
+// only has an overall timeout if one was set with SetTimeout, so requests that
+// need a shorter one should use a context deadline.
+func Client() *http.Client {
+	return client()
+}
+
+// InstallDefault makes the shared retrying transport the default for the
+// process so that third-party libraries that use http.DefaultTransport or
+// http.DefaultClient also honor Devbox's settings.
+func InstallDefault() {
+	http.DefaultTransport = retrying()
+}
+
+// newTransport returns a transport that uses the proxy from the environment
+// and trusts the certificates in caBundle in addition to the system's. If
+// caBundle can't be used, the returned transport is still usable and only
+// trusts the system's certificates.
+func newTransport(caBundle string) (*http.Transport, error) {
+	t := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   dialTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   tlsHandshakeTimeout,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+	if caBundle == "" {
+		return t, nil
+	}
+
+	pem, err := os.ReadFile(caBundle)
+	if err != nil {
+		return t, fmt.Errorf("unable to read %s: %w", envir.DevboxCABundle, err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return t, fmt.Errorf("no PEM certificates found in %s file %s", envir.DevboxCABundle, caBundle)
+	}
+	t.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	return t, nil
+}
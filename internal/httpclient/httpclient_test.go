@@ -0,0 +1,65 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package httpclient
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewTransportCABundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	t.Cleanup(server.Close)
+
+	get := func(tr *http.Transport) error {
+		res, err := (&http.Client{Transport: tr}).Get(server.URL)
+		if err == nil {
+			res.Body.Close()
+		}
+		return err
+	}
+
+	tr, err := newTransport("")
+	if err != nil {
+		t.Fatalf("got error without a CA bundle: %v", err)
+	}
+	if err := get(tr); err == nil {
+		t.Error("got nil error for a server with an untrusted certificate")
+	}
+
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(bundle, certPEM, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tr, err = newTransport(bundle)
+	if err != nil {
+		t.Fatalf("got error with a valid CA bundle: %v", err)
+	}
+	if err := get(tr); err != nil {
+		t.Errorf("got error for a server trusted by the CA bundle: %v", err)
+	}
+}
+
+func TestNewTransportInvalidCABundle(t *testing.T) {
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(bundle, []byte("not a certificate"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tr, err := newTransport(bundle)
+	if err == nil {
+		t.Error("got nil error for a CA bundle without certificates")
+	}
+	if tr == nil || tr.Proxy == nil {
+		t.Error("got unusable transport for an invalid CA bundle")
+	}
+
+	if _, err := newTransport(filepath.Join(t.TempDir(), "missing.pem")); err == nil {
+		t.Error("got nil error for a missing CA bundle")
+	}
+}
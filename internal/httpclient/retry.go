@@ -0,0 +1,188 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package httpclient
+
+import (
+	"context"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// maxAttempts is the number of times a request is sent before giving up.
+	maxAttempts = 4
+	// baseRetryDelay is the delay before the first retry. It doubles with
+	// every attempt.
+	baseRetryDelay = 500 * time.Millisecond
+	// maxRetryDelay is the longest Devbox waits before retrying. Servers that
+	// ask to wait longer (with Retry-After or a rate limit reset) get their
+	// response returned instead.
+	maxRetryDelay = 30 * time.Second
+)
+
+// timeout is the maximum duration of a request, including retries. Zero means
+// no limit.
+var timeout atomic.Int64
+
+// SetTimeout sets the maximum time a request may take, including any retries.
+// A zero or negative duration disables the timeout.
+func SetTimeout(d time.Duration) {
+	timeout.Store(int64(max(d, 0)))
+}
+
+// retryTransport retries idempotent requests that fail with a network error or
+// with a status code that indicates a transient failure. Retries back off
+// exponentially and honor the server's Retry-After and rate limit headers.
+type retryTransport struct {
+	base http.RoundTripper
+
+	// sleep waits for d or until ctx is done. Tests replace it to avoid
+	// waiting.
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+func newRetryTransport(base http.RoundTripper) *retryTransport {
+	return &retryTransport{base: base, sleep: sleepContext}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var cancel context.CancelFunc
+	if d := time.Duration(timeout.Load()); d > 0 {
+		var ctx context.Context
+		ctx, cancel = context.WithTimeout(req.Context(), d)
+		req = req.WithContext(ctx)
+	}
+	res, err := t.roundTrip(req)
+	if cancel != nil {
+		if err != nil {
+			cancel()
+		} else {
+			// The deadline must outlive RoundTrip so that the body can still
+			// be read.
+			res.Body = &cancelBody{ReadCloser: res.Body, cancel: cancel}
+		}
+	}
+	return res, err
+}
+
+func (t *retryTransport) roundTrip(req *http.Request) (*http.Response, error) {
+	if !isRetryable(req) {
+		return t.base.RoundTrip(req)
+	}
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+		res, err := t.base.RoundTrip(req)
+		if attempt+1 >= maxAttempts || req.Context().Err() != nil {
+			return res, err
+		}
+
+		delay := backoff(attempt)
+		if err == nil {
+			retryAfter, ok := retryDelay(res)
+			if !ok || retryAfter > maxRetryDelay {
+				return res, nil
+			}
+			delay = max(delay, retryAfter)
+			// Drain the body so the connection can be reused.
+			_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 4096))
+			res.Body.Close()
+		}
+		if err := t.sleep(req.Context(), delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// isRetryable returns true if req can safely be sent more than once.
+func isRetryable(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions:
+	default:
+		return false
+	}
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// retryDelay reports whether res is a transient failure and how long the
+// server asked to wait before retrying. The delay is zero if the server didn't
+// say.
+func retryDelay(res *http.Response) (time.Duration, bool) {
+	switch res.StatusCode {
+	case http.StatusTooManyRequests,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return parseRetryAfter(res.Header), true
+	case http.StatusForbidden:
+		// GitHub responds with 403 when the rate limit is exceeded.
+		if d := parseRetryAfter(res.Header); d > 0 {
+			return d, true
+		}
+		if res.Header.Get("X-RateLimit-Remaining") == "0" {
+			reset, err := strconv.ParseInt(res.Header.Get("X-RateLimit-Reset"), 10, 64)
+			if err != nil {
+				return 0, false
+			}
+			return max(time.Until(time.Unix(reset, 0)), 0), true
+		}
+	}
+	return 0, false
+}
+
+// parseRetryAfter parses a Retry-After header, which is either a number of
+// seconds or an HTTP date.
+func parseRetryAfter(h http.Header) time.Duration {
+	value := h.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0)
+	}
+	return 0
+}
+
+// backoff returns the delay before retrying after the given attempt, with up
+// to 50% jitter so that concurrent clients don't retry in lockstep.
+func backoff(attempt int) time.Duration {
+	d := baseRetryDelay << attempt
+	return d/2 + rand.N(d/2+1)
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// cancelBody cancels a request's context once its response body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
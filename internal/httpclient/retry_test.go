@@ -0,0 +1,185 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestRetryTransport returns a retry transport that records its delays
+// instead of sleeping.
+func newTestRetryTransport(delays *[]time.Duration) *retryTransport {
+	t := newRetryTransport(http.DefaultTransport)
+	t.sleep = func(ctx context.Context, d time.Duration) error {
+		*delays = append(*delays, d)
+		return ctx.Err()
+	}
+	return t
+}
+
+func TestRetryTransientStatus(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch requests.Add(1) {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			w.Header().Set("Retry-After", "3")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.Write([]byte("ok"))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	var delays []time.Duration
+	client := &http.Client{Transport: newTestRetryTransport(&delays)}
+	res, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("got status %d, want %d", res.StatusCode, http.StatusOK)
+	}
+	if got := requests.Load(); got != 3 {
+		t.Errorf("got %d requests, want 3", got)
+	}
+	if len(delays) != 2 {
+		t.Fatalf("got %d retries, want 2", len(delays))
+	}
+	if delays[0] < baseRetryDelay/2 || delays[0] > baseRetryDelay {
+		t.Errorf("got first delay %s, want between %s and %s", delays[0], baseRetryDelay/2, baseRetryDelay)
+	}
+	if delays[1] != 3*time.Second {
+		t.Errorf("got second delay %s, want the server's Retry-After of 3s", delays[1])
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	t.Cleanup(server.Close)
+
+	var delays []time.Duration
+	client := &http.Client{Transport: newTestRetryTransport(&delays)}
+	res, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusBadGateway {
+		t.Errorf("got status %d, want %d", res.StatusCode, http.StatusBadGateway)
+	}
+	if got := requests.Load(); got != maxAttempts {
+		t.Errorf("got %d requests, want %d", got, maxAttempts)
+	}
+}
+
+func TestRetrySkipsNonIdempotentRequests(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(server.Close)
+
+	var delays []time.Duration
+	client := &http.Client{Transport: newTestRetryTransport(&delays)}
+	res, err := client.Post(server.URL, "text/plain", strings.NewReader("body"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if got := requests.Load(); got != 1 {
+		t.Errorf("got %d requests, want 1", got)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	reset := strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+	tests := []struct {
+		name      string
+		status    int
+		header    http.Header
+		wantRetry bool
+		wantDelay time.Duration
+	}{
+		{name: "ok", status: http.StatusOK},
+		{name: "not found", status: http.StatusNotFound},
+		{name: "unavailable", status: http.StatusServiceUnavailable, wantRetry: true},
+		{
+			name:      "retry after seconds",
+			status:    http.StatusTooManyRequests,
+			header:    http.Header{"Retry-After": {"10"}},
+			wantRetry: true,
+			wantDelay: 10 * time.Second,
+		},
+		{
+			name:      "retry after past date",
+			status:    http.StatusServiceUnavailable,
+			header:    http.Header{"Retry-After": {"Wed, 21 Oct 2015 07:28:00 GMT"}},
+			wantRetry: true,
+		},
+		{name: "forbidden", status: http.StatusForbidden},
+		{
+			name:      "github secondary rate limit",
+			status:    http.StatusForbidden,
+			header:    http.Header{"Retry-After": {"60"}},
+			wantRetry: true,
+			wantDelay: time.Minute,
+		},
+		{
+			name:   "github rate limit",
+			status: http.StatusForbidden,
+			header: http.Header{
+				"X-Ratelimit-Remaining": {"0"},
+				"X-Ratelimit-Reset":     {reset},
+			},
+			wantRetry: true,
+			wantDelay: time.Hour,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			delay, retry := retryDelay(&http.Response{StatusCode: test.status, Header: test.header})
+			if retry != test.wantRetry {
+				t.Errorf("got retry %t, want %t", retry, test.wantRetry)
+			}
+			if diff := (delay - test.wantDelay).Abs(); diff > 2*time.Second {
+				t.Errorf("got delay %s, want %s", delay, test.wantDelay)
+			}
+		})
+	}
+}
+
+func TestSetTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	t.Cleanup(server.Close)
+
+	SetTimeout(50 * time.Millisecond)
+	t.Cleanup(func() { SetTimeout(0) })
+
+	client := &http.Client{Transport: newRetryTransport(http.DefaultTransport)}
+	start := time.Now()
+	_, err := client.Get(server.URL)
+	if err == nil {
+		t.Fatal("got nil error for a request that exceeded the timeout")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("request took %s, want it to stop after the timeout", elapsed)
+	}
+}
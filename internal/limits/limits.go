@@ -0,0 +1,110 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+// Package limits runs processes with CPU and memory limits. On Linux, the
+// process runs in a transient systemd scope, which is a cgroup v2 that the
+// kernel enforces the limits on for the process and all of its children.
+package limits
+
+import (
+	"fmt"
+	"math"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"al.essio.dev/pkg/shellescape"
+	"github.com/pkg/errors"
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+)
+
+// ErrUnsupported is returned on systems where Devbox can't enforce limits.
+var ErrUnsupported = errors.New("resource limits are only supported on Linux")
+
+// memoryRegexp matches the memory sizes that systemd accepts, such as 512M,
+// 1.5G or 50%.
+var memoryRegexp = regexp.MustCompile(`^([0-9]+(\.[0-9]+)?[KMGT]?|[0-9]+%)$`)
+
+// Limits are the resources that a process and its children can use.
+type Limits struct {
+	// CPUs is the number of CPUs that the processes can use, such as 1.5.
+	CPUs float64
+
+	// Memory is the maximum memory, such as 512M or 4G. The processes
+	// are killed if they use more.
+	Memory string
+}
+
+// IsZero returns true if there are no limits.
+func (l Limits) IsZero() bool {
+	return l.CPUs == 0 && l.Memory == ""
+}
+
+// Validate returns an error if a limit is invalid.
+func (l Limits) Validate() error {
+	if l.CPUs < 0 || math.IsNaN(l.CPUs) || math.IsInf(l.CPUs, 0) {
+		return usererr.New("Invalid CPU limit %v. It must be a positive number of CPUs, such as 1.5.", l.CPUs)
+	}
+	if l.Memory != "" && !memoryRegexp.MatchString(l.Memory) {
+		return usererr.New("Invalid memory limit %q. It must be a size such as 512M or 4G.", l.Memory)
+	}
+	return nil
+}
+
+// String returns a description of the limits, such as "2 CPUs, 4G memory".
+func (l Limits) String() string {
+	parts := []string{}
+	if l.CPUs != 0 {
+		parts = append(parts, strconv.FormatFloat(l.CPUs, 'f', -1, 64)+" CPUs")
+	}
+	if l.Memory != "" {
+		parts = append(parts, l.Memory+" memory")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Command returns the command line that runs args with the limits. It returns
+// ErrUnsupported if the system can't enforce them.
+func Command(l Limits, args ...string) ([]string, error) {
+	if l.IsZero() {
+		return args, nil
+	}
+	if err := l.Validate(); err != nil {
+		return nil, err
+	}
+	if runtime.GOOS != "linux" {
+		return nil, ErrUnsupported
+	}
+	systemdRun, err := exec.LookPath("systemd-run")
+	if err != nil {
+		return nil, usererr.New("Resource limits need systemd-run, which isn't installed.")
+	}
+	return append(systemdRunArgs(systemdRun, l), args...), nil
+}
+
+// systemdRunArgs returns the systemd-run command line that runs a command in
+// a user scope with the limits. Swap is disabled when memory is limited so
+// that the limit can't be sidestepped by swapping.
+func systemdRunArgs(systemdRun string, l Limits) []string {
+	args := []string{systemdRun, "--user", "--scope", "--quiet", "--collect"}
+	if l.CPUs != 0 {
+		quota := max(1, int(math.Round(l.CPUs*100)))
+		args = append(args, "-p", fmt.Sprintf("CPUQuota=%d%%", quota))
+	}
+	if l.Memory != "" {
+		args = append(args, "-p", "MemoryMax="+l.Memory, "-p", "MemorySwapMax=0")
+	}
+	return append(args, "--")
+}
+
+// ShellCommand returns a shell command that runs command with the limits in a
+// new shell. It's for process managers that run their commands with a shell.
+func ShellCommand(l Limits, shell, command string) (string, error) {
+	args, err := Command(l, shell, "-c", command)
+	if err != nil {
+		return "", err
+	}
+	return "exec " + shellescape.QuoteCommand(args), nil
+}
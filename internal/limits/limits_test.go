@@ -0,0 +1,63 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package limits
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	valid := []Limits{{}, {CPUs: 1.5}, {Memory: "512M"}, {Memory: "1.5G"}, {Memory: "50%"}, {CPUs: 2, Memory: "4G"}}
+	for _, l := range valid {
+		if err := l.Validate(); err != nil {
+			t.Errorf("got %+v.Validate() = %v, want nil", l, err)
+		}
+	}
+	invalid := []Limits{{CPUs: -1}, {Memory: "4GB"}, {Memory: "lots"}, {Memory: "-1G"}}
+	for _, l := range invalid {
+		if err := l.Validate(); err == nil {
+			t.Errorf("got %+v.Validate() = nil, want error", l)
+		}
+	}
+}
+
+func TestSystemdRunArgs(t *testing.T) {
+	got := systemdRunArgs("systemd-run", Limits{CPUs: 1.5, Memory: "4G"})
+	want := []string{
+		"systemd-run", "--user", "--scope", "--quiet", "--collect",
+		"-p", "CPUQuota=150%",
+		"-p", "MemoryMax=4G", "-p", "MemorySwapMax=0",
+		"--",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got systemdRunArgs() = %q, want %q", got, want)
+	}
+
+	got = systemdRunArgs("systemd-run", Limits{CPUs: 0.001})
+	if !slices.Contains(got, "CPUQuota=1%") {
+		t.Errorf("got systemdRunArgs() = %q, want a CPUQuota of at least 1%%", got)
+	}
+}
+
+func TestCommandWithoutLimits(t *testing.T) {
+	args := []string{"sh", "-c", "make test"}
+	got, err := Command(Limits{}, args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got, args) {
+		t.Errorf("got Command() = %q, want %q", got, args)
+	}
+}
+
+func TestShellCommandWithoutLimits(t *testing.T) {
+	got, err := ShellCommand(Limits{}, "bash", "echo 'hi' && npm run dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `exec bash -c 'echo '"'"'hi'"'"' && npm run dev'`; got != want {
+		t.Errorf("got ShellCommand() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package lock
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/semver"
+)
+
+// versionConstraint is a semver constraint. A version satisfies the constraint
+// if it satisfies every comparator of at least one of its alternatives.
+//
+// The syntax follows npm's:
+//
+//	^1.2.3     >=1.2.3 <2.0.0
+//	^0.2.3     >=0.2.3 <0.3.0
+//	~1.2.3     >=1.2.3 <1.3.0
+//	1.2.x      >=1.2.0 <1.3.0
+//	>=1.2 <2   comparators are separated by spaces or commas
+//	^1 || ^2   alternatives are separated by ||
+type versionConstraint [][]comparator
+
+type comparator struct {
+	op string
+	// version is a canonical semver version with a "v" prefix.
+	version string
+}
+
+// isVersionConstraint returns true if version is a constraint rather than a
+// release tag. Plain versions such as 1.2.3 or v1.2 are release tags.
+func isVersionConstraint(version string) bool {
+	return strings.ContainsAny(version, "^~<>=*, |") ||
+		strings.HasSuffix(version, ".x") ||
+		strings.HasSuffix(version, ".X")
+}
+
+func parseVersionConstraint(s string) (versionConstraint, error) {
+	constraint := versionConstraint{}
+	for _, alternative := range strings.Split(s, "||") {
+		fields := strings.FieldsFunc(alternative, func(r rune) bool {
+			return r == ' ' || r == ','
+		})
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("empty constraint in %q", s)
+		}
+		comparators := []comparator{}
+		for _, field := range fields {
+			c, err := parseComparators(field)
+			if err != nil {
+				return nil, err
+			}
+			comparators = append(comparators, c...)
+		}
+		constraint = append(constraint, comparators)
+	}
+	return constraint, nil
+}
+
+// parseComparators parses a single term of a constraint, such as ^1.2 or
+// >=1.0.0, into the comparators it's equivalent to.
+func parseComparators(term string) ([]comparator, error) {
+	op := ""
+	for _, prefix := range []string{">=", "<=", ">", "<", "=", "^", "~"} {
+		if strings.HasPrefix(term, prefix) {
+			op = prefix
+			break
+		}
+	}
+	parts, err := parseVersionParts(strings.TrimPrefix(term, op))
+	if err != nil {
+		return nil, fmt.Errorf("invalid version %q: %w", term, err)
+	}
+	// n is the number of version parts that were specified.
+	n := len(parts)
+	for len(parts) < 3 {
+		parts = append(parts, 0)
+	}
+	lower := canonicalVersion(parts[0], parts[1], parts[2])
+
+	// upper returns the exclusive upper bound of a range where the version
+	// part at index i may not change.
+	upper := func(i int) comparator {
+		switch i {
+		case 0:
+			return comparator{"<", canonicalVersion(parts[0]+1, 0, 0)}
+		case 1:
+			return comparator{"<", canonicalVersion(parts[0], parts[1]+1, 0)}
+		default:
+			return comparator{"<", canonicalVersion(parts[0], parts[1], parts[2]+1)}
+		}
+	}
+
+	if n == 0 {
+		// A wildcard matches any version.
+		return nil, nil
+	}
+	switch op {
+	case "^":
+		// The leftmost non-zero part may not change.
+		i := 0
+		for i < n-1 && parts[i] == 0 {
+			i++
+		}
+		return []comparator{{">=", lower}, upper(i)}, nil
+	case "~":
+		return []comparator{{">=", lower}, upper(min(n-1, 1))}, nil
+	case "", "=":
+		return []comparator{{">=", lower}, upper(n - 1)}, nil
+	case ">":
+		// >1.2 excludes all of 1.2.x.
+		return []comparator{{">=", upper(n - 1).version}}, nil
+	case "<=":
+		// <=1.2 includes all of 1.2.x.
+		return []comparator{upper(n - 1)}, nil
+	default:
+		return []comparator{{op, lower}}, nil
+	}
+}
+
+// parseVersionParts parses the numeric parts of a version such as v1.2.3,
+// stopping at the first wildcard (x, X or *).
+func parseVersionParts(version string) ([]int, error) {
+	version = strings.TrimPrefix(version, "v")
+	if version == "" {
+		return nil, fmt.Errorf("missing version")
+	}
+	parts := []int{}
+	for i, part := range strings.Split(version, ".") {
+		if i >= 3 {
+			return nil, fmt.Errorf("too many version parts")
+		}
+		if part == "x" || part == "X" || part == "*" {
+			break
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("%q is not a number", part)
+		}
+		parts = append(parts, n)
+	}
+	return parts, nil
+}
+
+// tagVersion returns the semver version of a release tag, which may or may not
+// have a "v" prefix.
+func tagVersion(tag string) string {
+	return "v" + strings.TrimPrefix(tag, "v")
+}
+
+func canonicalVersion(major, minor, patch int) string {
+	return fmt.Sprintf("v%d.%d.%d", major, minor, patch)
+}
+
+// matches returns true if the release tag satisfies the constraint. Tags that
+// aren't semver versions, and prereleases, never match.
+func (c versionConstraint) matches(tag string) bool {
+	version := tagVersion(tag)
+	if !semver.IsValid(version) || semver.Prerelease(version) != "" {
+		return false
+	}
+	for _, comparators := range c {
+		if matchesAll(version, comparators) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesAll(version string, comparators []comparator) bool {
+	for _, c := range comparators {
+		cmp := semver.Compare(version, c.version)
+		ok := false
+		switch c.op {
+		case ">=":
+			ok = cmp >= 0
+		case ">":
+			ok = cmp > 0
+		case "<=":
+			ok = cmp <= 0
+		case "<":
+			ok = cmp < 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// latest returns the newest of tags that satisfies the constraint.
+func (c versionConstraint) latest(tags []string) (string, bool) {
+	best := ""
+	for _, tag := range tags {
+		if !c.matches(tag) {
+			continue
+		}
+		if best == "" || semver.Compare(tagVersion(tag), tagVersion(best)) > 0 {
+			best = tag
+		}
+	}
+	return best, best != ""
+}
@@ -0,0 +1,80 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package lock
+
+import (
+	"testing"
+)
+
+func TestIsVersionConstraint(t *testing.T) {
+	tests := map[string]bool{
+		"latest":   false,
+		"v1.2.3":   false,
+		"1.2":      false,
+		"^1.2":     true,
+		"~1.2.3":   true,
+		">=1, <2":  true,
+		"1.2.x":    true,
+		"1.*":      true,
+		"^1 || ^2": true,
+	}
+	for version, want := range tests {
+		if got := isVersionConstraint(version); got != want {
+			t.Errorf("isVersionConstraint(%q) = %t, want %t", version, got, want)
+		}
+	}
+}
+
+func TestVersionConstraintLatest(t *testing.T) {
+	tags := []string{
+		"v0.1.0", "v0.2.3", "v0.2.9", "v1.0.0", "v1.2.0", "v1.2.7",
+		"1.3.1", "v1.4.0-rc.1", "v2.0.0", "v2.1.0", "nightly",
+	}
+	tests := []struct {
+		constraint string
+		want       string
+	}{
+		{"^1.2", "1.3.1"},
+		{"^1.2.3", "1.3.1"},
+		{"^0.2.3", "v0.2.9"},
+		{"^0.1", "v0.1.0"},
+		{"~1.2", "v1.2.7"},
+		{"~1.2.3", "v1.2.7"},
+		{"~1", "1.3.1"},
+		{"1.2.x", "v1.2.7"},
+		{"1.x", "1.3.1"},
+		{"*", "v2.1.0"},
+		{"=v1.2.0", "v1.2.0"},
+		{">=1.0.0 <2", "1.3.1"},
+		{">=1.0.0, <1.3", "v1.2.7"},
+		{">1.2", "v2.1.0"},
+		{"<=1.2", "v1.2.7"},
+		{"<1", "v0.2.9"},
+		{"^0.1 || ~1.2", "v1.2.7"},
+		{"^3", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.constraint, func(t *testing.T) {
+			constraint, err := parseVersionConstraint(test.constraint)
+			if err != nil {
+				t.Fatalf("got error parsing constraint: %v", err)
+			}
+			got, ok := constraint.latest(tags)
+			if got != test.want {
+				t.Errorf("got latest %q, want %q", got, test.want)
+			}
+			if ok != (test.want != "") {
+				t.Errorf("got ok %t for latest %q", ok, got)
+			}
+		})
+	}
+}
+
+func TestParseVersionConstraintErrors(t *testing.T) {
+	for _, constraint := range []string{"^", "^a.b", ">=1.2.3.4", "^1 ||", "~-1"} {
+		if _, err := parseVersionConstraint(constraint); err == nil {
+			t.Errorf("got nil error for invalid constraint %q", constraint)
+		}
+	}
+}
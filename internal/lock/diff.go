@@ -0,0 +1,146 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package lock
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+
+	"golang.org/x/mod/semver"
+
+	"go.jetify.com/devbox/internal/cuecfg"
+	"go.jetify.com/devbox/internal/searcher"
+)
+
+// ChangeKind is how a package changed between two lockfiles.
+type ChangeKind string
+
+const (
+	Added      ChangeKind = "added"
+	Removed    ChangeKind = "removed"
+	Upgraded   ChangeKind = "upgraded"
+	Downgraded ChangeKind = "downgraded"
+
+	// Changed is a package whose version changed in a way that can't be
+	// ordered, or that resolves to a different build of the same version,
+	// such as after a nixpkgs update.
+	Changed ChangeKind = "changed"
+)
+
+// Change is a package that's different in two lockfiles.
+type Change struct {
+	Name       string     `json:"name"`
+	Kind       ChangeKind `json:"kind"`
+	OldVersion string     `json:"old_version,omitempty"`
+	NewVersion string     `json:"new_version,omitempty"`
+}
+
+// ParseBytes parses the contents of a devbox.lock, such as from an old git
+// revision. The returned file can only be read.
+func ParseBytes(data []byte) (*File, error) {
+	f := &File{Packages: map[string]*Package{}}
+	if err := cuecfg.Unmarshal(data, ".lock", f); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Diff returns the packages that changed from the old lockfile to the new one,
+// sorted by name. A nil lockfile has no packages.
+func Diff(oldFile, newFile *File) []Change {
+	oldPkgs, newPkgs := packagesOf(oldFile), packagesOf(newFile)
+	changes := []Change{}
+
+	// Packages with the same key, such as go@latest, can resolve to
+	// different versions.
+	for _, key := range slices.Sorted(maps.Keys(oldPkgs)) {
+		newPkg, ok := newPkgs[key]
+		if !ok {
+			continue
+		}
+		if change, changed := compare(packageName(key), oldPkgs[key], newPkg); changed {
+			changes = append(changes, change)
+		}
+		delete(oldPkgs, key)
+		delete(newPkgs, key)
+	}
+
+	// The remaining packages are a change of key, such as go@1.21 to
+	// go@1.22, if there's one of each with the same name.
+	oldByName, newByName := keysByName(oldPkgs), keysByName(newPkgs)
+	for name, oldKeys := range oldByName {
+		newKeys := newByName[name]
+		if len(oldKeys) == 1 && len(newKeys) == 1 {
+			change, _ := compare(name, oldPkgs[oldKeys[0]], newPkgs[newKeys[0]])
+			changes = append(changes, change)
+			delete(oldPkgs, oldKeys[0])
+			delete(newPkgs, newKeys[0])
+		}
+	}
+	for key, pkg := range oldPkgs {
+		changes = append(changes, Change{Name: packageName(key), Kind: Removed, OldVersion: pkgVersion(pkg)})
+	}
+	for key, pkg := range newPkgs {
+		changes = append(changes, Change{Name: packageName(key), Kind: Added, NewVersion: pkgVersion(pkg)})
+	}
+
+	slices.SortFunc(changes, func(a, b Change) int {
+		return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.OldVersion, b.OldVersion))
+	})
+	return changes
+}
+
+// compare returns how a package changed, and false if it didn't.
+func compare(name string, oldPkg, newPkg *Package) (Change, bool) {
+	change := Change{Name: name, OldVersion: pkgVersion(oldPkg), NewVersion: pkgVersion(newPkg)}
+	if change.OldVersion == change.NewVersion {
+		change.Kind = Changed
+		return change, oldPkg.Resolved != newPkg.Resolved
+	}
+
+	oldSemver, newSemver := "v"+change.OldVersion, "v"+change.NewVersion
+	switch {
+	case !semver.IsValid(oldSemver) || !semver.IsValid(newSemver):
+		change.Kind = Changed
+	case semver.Compare(oldSemver, newSemver) < 0:
+		change.Kind = Upgraded
+	default:
+		change.Kind = Downgraded
+	}
+	return change, true
+}
+
+func packagesOf(f *File) map[string]*Package {
+	if f == nil {
+		return map[string]*Package{}
+	}
+	return maps.Clone(f.Packages)
+}
+
+func keysByName(pkgs map[string]*Package) map[string][]string {
+	byName := map[string][]string{}
+	for key := range pkgs {
+		byName[packageName(key)] = append(byName[packageName(key)], key)
+	}
+	return byName
+}
+
+// packageName returns the name of a package in a lockfile key, such as go for
+// go@1.22.
+func packageName(key string) string {
+	if name, _, ok := searcher.ParseVersionedPackage(key); ok {
+		return name
+	}
+	return key
+}
+
+// pkgVersion returns a package's resolved version, or what it resolves to if
+// it doesn't have a version, such as a flake.
+func pkgVersion(pkg *Package) string {
+	if pkg.Version != "" {
+		return pkg.Version
+	}
+	return pkg.Resolved
+}
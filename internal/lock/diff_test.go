@@ -0,0 +1,64 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package lock
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	oldFile := &File{Packages: map[string]*Package{
+		"go@latest":      {Version: "1.22.3", Resolved: "github:NixOS/nixpkgs/a#go"},
+		"python@3.11":    {Version: "3.11.8", Resolved: "github:NixOS/nixpkgs/a#python311"},
+		"nodejs@20":      {Version: "20.11.1", Resolved: "github:NixOS/nixpkgs/a#nodejs_20"},
+		"ripgrep@latest": {Version: "14.1.0", Resolved: "github:NixOS/nixpkgs/a#ripgrep"},
+		"jq@latest":      {Version: "1.7.1", Resolved: "github:NixOS/nixpkgs/a#jq"},
+		"openssl@3":      {Version: "3.0.13", Resolved: "github:NixOS/nixpkgs/a#openssl"},
+	}}
+	newFile := &File{Packages: map[string]*Package{
+		"go@latest":      {Version: "1.23.0", Resolved: "github:NixOS/nixpkgs/b#go"},
+		"nodejs@18":      {Version: "18.19.1", Resolved: "github:NixOS/nixpkgs/b#nodejs_18"},
+		"ripgrep@latest": {Version: "14.1.0", Resolved: "github:NixOS/nixpkgs/a#ripgrep"},
+		"jq@latest":      {Version: "1.7.1", Resolved: "github:NixOS/nixpkgs/b#jq"},
+		"openssl@3":      {Version: "unstable-2024-05-01", Resolved: "github:NixOS/nixpkgs/b#openssl"},
+		"hello@latest":   {Version: "2.12.1", Resolved: "github:NixOS/nixpkgs/b#hello"},
+	}}
+
+	want := []Change{
+		{Name: "go", Kind: Upgraded, OldVersion: "1.22.3", NewVersion: "1.23.0"},
+		{Name: "hello", Kind: Added, NewVersion: "2.12.1"},
+		{Name: "jq", Kind: Changed, OldVersion: "1.7.1", NewVersion: "1.7.1"},
+		{Name: "nodejs", Kind: Downgraded, OldVersion: "20.11.1", NewVersion: "18.19.1"},
+		{Name: "openssl", Kind: Changed, OldVersion: "3.0.13", NewVersion: "unstable-2024-05-01"},
+		{Name: "python", Kind: Removed, OldVersion: "3.11.8"},
+	}
+	if got := Diff(oldFile, newFile); !slices.Equal(got, want) {
+		t.Errorf("got changes\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestDiffNil(t *testing.T) {
+	newFile := &File{Packages: map[string]*Package{
+		"github:nix-community/fenix#stable.toolchain": {Resolved: "github:nix-community/fenix/abc#stable.toolchain"},
+	}}
+	want := []Change{{
+		Name:       "github:nix-community/fenix#stable.toolchain",
+		Kind:       Added,
+		NewVersion: "github:nix-community/fenix/abc#stable.toolchain",
+	}}
+	if got := Diff(nil, newFile); !slices.Equal(got, want) {
+		t.Errorf("got changes %+v, want %+v", got, want)
+	}
+}
+
+func TestParseBytes(t *testing.T) {
+	f, err := ParseBytes([]byte(`{"lockfile_version": "1", "packages": {"go@latest": {"version": "1.22.3"}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Packages["go@latest"].Version != "1.22.3" {
+		t.Errorf("got packages %+v, want go 1.22.3", f.Packages)
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package lock
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+	"go.jetify.com/devbox/internal/envir"
+	"go.jetify.com/devbox/internal/fileutil"
+)
+
+const (
+	// maxHistory is the number of previous lockfiles that are kept.
+	maxHistory = 10
+
+	// historyTimeFormat names the previous lockfiles after when they were
+	// replaced, so that they sort from oldest to newest.
+	historyTimeFormat = "20060102T150405.000000000"
+)
+
+// Backup is a previous version of a project's devbox.lock.
+type Backup struct {
+	Path string
+
+	// Time is when the lockfile was replaced by a newer one.
+	Time time.Time
+}
+
+// historyDir is where the previous versions of devbox.lock are kept.
+func historyDir(projectDir string) string {
+	return filepath.Join(projectDir, ".devbox", "lock-history")
+}
+
+// backup adds the project's devbox.lock to the history before it's replaced,
+// and removes the oldest versions beyond maxHistory.
+func backup(projectDir string) error {
+	data, err := os.ReadFile(lockFilePath(projectDir))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return errors.WithStack(err)
+	}
+
+	dir := historyDir(projectDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return errors.WithStack(err)
+	}
+	// Clocks with a coarse resolution can give two backups the same time.
+	now := time.Now().UTC()
+	path := filepath.Join(dir, now.Format(historyTimeFormat)+".lock")
+	for fileutil.Exists(path) {
+		now = now.Add(time.Nanosecond)
+		path = filepath.Join(dir, now.Format(historyTimeFormat)+".lock")
+	}
+	if err := fileutil.WriteFileAtomic(path, data, 0o644); err != nil {
+		return err
+	}
+
+	backups, err := History(projectDir)
+	if err != nil {
+		return err
+	}
+	for _, b := range backups[min(len(backups), maxHistory):] {
+		if err := os.Remove(b.Path); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// History returns the previous versions of the project's devbox.lock, newest
+// first.
+func History(projectDir string) ([]Backup, error) {
+	entries, err := os.ReadDir(historyDir(projectDir))
+	if errors.Is(err, os.ErrNotExist) {
+		return []Backup{}, nil
+	} else if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	backups := []Backup{}
+	for _, entry := range entries {
+		t, err := time.Parse(historyTimeFormat, strings.TrimSuffix(entry.Name(), ".lock"))
+		if err != nil || !strings.HasSuffix(entry.Name(), ".lock") {
+			continue
+		}
+		backups = append(backups, Backup{Path: filepath.Join(historyDir(projectDir), entry.Name()), Time: t})
+	}
+	slices.SortFunc(backups, func(a, b Backup) int { return b.Time.Compare(a.Time) })
+	return backups, nil
+}
+
+// Rollback restores the newest previous version of the project's devbox.lock
+// and removes it from the history, so that rolling back again restores the
+// one before it.
+func Rollback(projectDir string) (*Backup, error) {
+	backups, err := History(projectDir)
+	if err != nil {
+		return nil, err
+	}
+	if len(backups) == 0 {
+		return nil, usererr.New("There's no previous devbox.lock to roll back to.")
+	}
+	if envir.IsLocked() {
+		return nil, usererr.New(
+			"Can't roll back devbox.lock because --locked or %s is set.", envir.DevboxLocked)
+	}
+	latest := backups[0]
+	data, err := os.ReadFile(latest.Path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err := fileutil.WriteFileAtomic(lockFilePath(projectDir), data, 0o644); err != nil {
+		return nil, err
+	}
+	return &latest, errors.WithStack(os.Remove(latest.Path))
+}
@@ -0,0 +1,70 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package lock
+
+import (
+	"os"
+	"strconv"
+	"testing"
+)
+
+func TestBackupAndRollback(t *testing.T) {
+	projectDir := t.TempDir()
+	path := lockFilePath(projectDir)
+
+	// There's nothing to back up before the first lockfile.
+	if err := backup(projectDir); err != nil {
+		t.Fatal(err)
+	}
+	for i := range 3 {
+		if err := backup(projectDir); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(strconv.Itoa(i)), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	backups, err := History(projectDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("got %d backups, want 2", len(backups))
+	}
+
+	for _, want := range []string{"1", "0"} {
+		if _, err := Rollback(projectDir); err != nil {
+			t.Fatal(err)
+		}
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("got lockfile %q after rolling back, want %q", got, want)
+		}
+	}
+	if _, err := Rollback(projectDir); err == nil {
+		t.Error("got no error rolling back without history")
+	}
+}
+
+func TestBackupPrunesHistory(t *testing.T) {
+	projectDir := t.TempDir()
+	if err := os.WriteFile(lockFilePath(projectDir), []byte("{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for range maxHistory + 3 {
+		if err := backup(projectDir); err != nil {
+			t.Fatal(err)
+		}
+	}
+	backups, err := History(projectDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != maxHistory {
+		t.Errorf("got %d backups, want %d", len(backups), maxHistory)
+	}
+}
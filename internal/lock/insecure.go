@@ -0,0 +1,85 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package lock
+
+import (
+	"slices"
+	"time"
+)
+
+// InsecureAcceptance records that a user accepted installing a package that
+// nixpkgs marks insecure with allow_insecure in devbox.json.
+type InsecureAcceptance struct {
+	// Name is the allow_insecure entry that was accepted, such as
+	// "openssl-1.1.1w".
+	Name string `json:"name"`
+
+	// Advisories are the package's known vulnerabilities when the
+	// acceptance was recorded, so that new advisories can be detected.
+	Advisories []string `json:"advisories,omitempty"`
+
+	// AcceptedAt is the date the acceptance was recorded, in the
+	// YYYY-MM-DD format.
+	AcceptedAt string `json:"accepted_at"`
+
+	// Expires is the optional date, in the YYYY-MM-DD format, after
+	// which the acceptance is stale and should be reviewed.
+	Expires string `json:"expires,omitempty"`
+}
+
+// InsecureDateFormat is the format of the dates of an InsecureAcceptance.
+const InsecureDateFormat = time.DateOnly
+
+// Expired reports whether the acceptance expired before now.
+func (a InsecureAcceptance) Expired(now time.Time) bool {
+	if a.Expires == "" {
+		return false
+	}
+	expires, err := time.Parse(InsecureDateFormat, a.Expires)
+	if err != nil {
+		return false
+	}
+	// The acceptance is valid for the whole day it expires.
+	return now.After(expires.AddDate(0, 0, 1))
+}
+
+// NewAdvisories returns the advisories in current that weren't accepted.
+func (a InsecureAcceptance) NewAdvisories(current []string) []string {
+	added := []string{}
+	for _, advisory := range current {
+		if !slices.Contains(a.Advisories, advisory) {
+			added = append(added, advisory)
+		}
+	}
+	return added
+}
+
+// AcceptInsecure records an acceptance for pkg, replacing any earlier one with
+// the same name. It doesn't save the lockfile.
+func (f *File) AcceptInsecure(pkg string, acceptance InsecureAcceptance) {
+	locked := f.Packages[pkg]
+	if locked == nil {
+		return
+	}
+	locked.InsecureAcceptances = slices.DeleteFunc(locked.InsecureAcceptances, func(a InsecureAcceptance) bool {
+		return a.Name == acceptance.Name
+	})
+	locked.InsecureAcceptances = append(locked.InsecureAcceptances, acceptance)
+}
+
+// InsecureAcceptance returns the acceptance for the allow_insecure entry name
+// of pkg, if one was recorded.
+func (f *File) InsecureAcceptance(pkg, name string) (InsecureAcceptance, bool) {
+	locked := f.Packages[pkg]
+	if locked == nil {
+		return InsecureAcceptance{}, false
+	}
+	i := slices.IndexFunc(locked.InsecureAcceptances, func(a InsecureAcceptance) bool {
+		return a.Name == name
+	})
+	if i == -1 {
+		return InsecureAcceptance{}, false
+	}
+	return locked.InsecureAcceptances[i], true
+}
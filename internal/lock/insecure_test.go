@@ -0,0 +1,60 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package lock
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestInsecureAcceptanceExpired(t *testing.T) {
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		expires string
+		want    bool
+	}{
+		{"", false},
+		{"2024-06-14", true},
+		{"2024-06-15", false},
+		{"2024-06-16", false},
+		{"not a date", false},
+	}
+	for _, tt := range tests {
+		a := InsecureAcceptance{Name: "openssl-1.1.1w", Expires: tt.expires}
+		if got := a.Expired(now); got != tt.want {
+			t.Errorf("Expired() with expires %q = %v, want %v", tt.expires, got, tt.want)
+		}
+	}
+}
+
+func TestInsecureAcceptanceNewAdvisories(t *testing.T) {
+	a := InsecureAcceptance{Advisories: []string{"CVE-2023-0001"}}
+	got := a.NewAdvisories([]string{"CVE-2023-0001", "CVE-2024-0002"})
+	if want := []string{"CVE-2024-0002"}; !slices.Equal(got, want) {
+		t.Errorf("NewAdvisories() = %v, want %v", got, want)
+	}
+	if got := a.NewAdvisories(nil); len(got) != 0 {
+		t.Errorf("NewAdvisories(nil) = %v, want none", got)
+	}
+}
+
+func TestAcceptInsecure(t *testing.T) {
+	f := &File{Packages: map[string]*Package{"openssl@1.1": {}}}
+
+	f.AcceptInsecure("openssl@1.1", InsecureAcceptance{Name: "openssl-1.1.1w", AcceptedAt: "2024-01-01"})
+	f.AcceptInsecure("openssl@1.1", InsecureAcceptance{Name: "openssl-1.1.1w", AcceptedAt: "2024-06-01"})
+	f.AcceptInsecure("missing@1", InsecureAcceptance{Name: "missing-1"})
+
+	got, ok := f.InsecureAcceptance("openssl@1.1", "openssl-1.1.1w")
+	if !ok || got.AcceptedAt != "2024-06-01" {
+		t.Errorf("InsecureAcceptance() = %v, %v, want the latest acceptance", got, ok)
+	}
+	if n := len(f.Packages["openssl@1.1"].InsecureAcceptances); n != 1 {
+		t.Errorf("got %d acceptances, want 1", n)
+	}
+	if _, ok := f.InsecureAcceptance("missing@1", "missing-1"); ok {
+		t.Error("InsecureAcceptance() found an acceptance for a package that isn't locked")
+	}
+}
@@ -8,6 +8,7 @@
 type devboxProject interface {
 	ConfigHash() (string, error)
 	Stdenv() flake.Ref
+	PackageSources() map[string]string
 	AllPackageNamesIncludingRemovedTriggerPackages() []string
 	ProjectDir() string
 }
@@ -15,6 +16,7 @@ type devboxProject interface {
 type Locker interface {
 	Get(string) *Package
 	Stdenv() flake.Ref
+	PackageSources() map[string]string
 	ProjectDir() string
 	Resolve(string) (*Package, error)
 }
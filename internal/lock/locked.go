@@ -0,0 +1,95 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package lock
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+	"go.jetify.com/devbox/internal/envir"
+)
+
+// errLocked returns the error for saving the lockfile while devbox.lock must
+// not change. It lists what saving would change.
+func (f *File) errLocked() error {
+	onDisk, err := GetFile(f.devboxProject)
+	if err != nil {
+		return err
+	}
+	changes := lockfileChanges(onDisk, f)
+	return usererr.New(
+		"devbox.lock is out of date, but it can't be updated because --locked or %s is set. "+
+			"Updating it would:\n%s\n"+
+			"Run the command without --locked to update devbox.lock and commit it.",
+		envir.DevboxLocked,
+		strings.Join(changes, "\n"),
+	)
+}
+
+// lockfileChanges describes the changes from the old lockfile to the new one,
+// one per line. Besides the package versions that [Diff] compares, it reports
+// the other fields that changed, such as the store paths of a system.
+func lockfileChanges(old, new *File) []string {
+	changes := []string{}
+	if old.LockFileVersion != new.LockFileVersion {
+		changes = append(changes, fmt.Sprintf("  ~ lockfile_version %s -> %s",
+			old.LockFileVersion, new.LockFileVersion))
+	}
+	signs := map[ChangeKind]string{Added: "+", Removed: "-", Upgraded: "↑", Downgraded: "↓", Changed: "~"}
+	for _, c := range Diff(old, new) {
+		switch {
+		case c.Kind == Added:
+			changes = append(changes, fmt.Sprintf("  + %s %s", c.Name, c.NewVersion))
+		case c.Kind == Removed:
+			changes = append(changes, fmt.Sprintf("  - %s %s", c.Name, c.OldVersion))
+		case c.OldVersion == c.NewVersion:
+			changes = append(changes, fmt.Sprintf("  ~ %s %s (new build)", c.Name, c.NewVersion))
+		default:
+			changes = append(changes, fmt.Sprintf("  %s %s %s -> %s", signs[c.Kind], c.Name, c.OldVersion, c.NewVersion))
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(old.Packages)) {
+		oldPkg, newPkg := old.Packages[key], new.Packages[key]
+		if newPkg == nil || oldPkg.Resolved != newPkg.Resolved || pkgVersion(oldPkg) != pkgVersion(newPkg) {
+			// Diff reported it.
+			continue
+		}
+		if fields := changedPackageFields(oldPkg, newPkg); len(fields) > 0 {
+			changes = append(changes, fmt.Sprintf("  ~ %s (%s)", key, strings.Join(fields, ", ")))
+		}
+	}
+	return changes
+}
+
+// changedPackageFields returns the JSON names of the fields that differ
+// between two versions of a package.
+func changedPackageFields(old, new *Package) []string {
+	oldFields, newFields := packageFields(old), packageFields(new)
+	fields := []string{}
+	for name, value := range oldFields {
+		if !reflect.DeepEqual(value, newFields[name]) {
+			fields = append(fields, name)
+		}
+	}
+	for name := range newFields {
+		if _, ok := oldFields[name]; !ok {
+			fields = append(fields, name)
+		}
+	}
+	slices.Sort(fields)
+	return fields
+}
+
+func packageFields(pkg *Package) map[string]any {
+	fields := map[string]any{}
+	if data, err := json.Marshal(pkg); err == nil {
+		_ = json.Unmarshal(data, &fields)
+	}
+	return fields
+}
@@ -0,0 +1,81 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package lock
+
+import (
+	"slices"
+	"testing"
+
+	"go.jetify.com/devbox/nix/flake"
+)
+
+func TestLockfileChanges(t *testing.T) {
+	old := &File{
+		LockFileVersion: "1",
+		Packages: map[string]*Package{
+			"go@latest":     {Version: "1.21.0", Resolved: "github:NixOS/nixpkgs/a#go"},
+			"hello@2.12":    {Version: "2.12", Resolved: "github:NixOS/nixpkgs/a#hello"},
+			"python@3.12":   {Version: "3.12.1", Resolved: "github:NixOS/nixpkgs/a#python312"},
+			"ripgrep@14.1":  {Version: "14.1.0", Resolved: "github:NixOS/nixpkgs/a#ripgrep"},
+			"unchanged@1.0": {Version: "1.0", Resolved: "github:NixOS/nixpkgs/a#unchanged"},
+		},
+	}
+	new := &File{
+		LockFileVersion: "1",
+		Packages: map[string]*Package{
+			"go@latest": {Version: "1.22.0", Resolved: "github:NixOS/nixpkgs/b#go"},
+			"jq@1.7":    {Version: "1.7", Resolved: "github:NixOS/nixpkgs/b#jq"},
+			"python@3.12": {
+				Version:  "3.12.1",
+				Resolved: "github:NixOS/nixpkgs/a#python312",
+				Systems:  map[string]*SystemInfo{"x86_64-linux": {}},
+			},
+			"ripgrep@14.1":  {Version: "14.1.0", Resolved: "github:NixOS/nixpkgs/b#ripgrep"},
+			"unchanged@1.0": {Version: "1.0", Resolved: "github:NixOS/nixpkgs/a#unchanged"},
+		},
+	}
+
+	got := lockfileChanges(old, new)
+	want := []string{
+		"  ↑ go 1.21.0 -> 1.22.0",
+		"  - hello 2.12",
+		"  + jq 1.7",
+		"  ~ ripgrep 14.1.0 (new build)",
+		"  ~ python@3.12 (systems)",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got changes:\n%q\nwant:\n%q", got, want)
+	}
+}
+
+type fakeProject struct {
+	devboxProject
+	packages []string
+}
+
+func (p fakeProject) AllPackageNamesIncludingRemovedTriggerPackages() []string {
+	return p.packages
+}
+
+func (fakeProject) Stdenv() flake.Ref {
+	return flake.Ref{Type: flake.TypeGitHub, Owner: "NixOS", Repo: "nixpkgs", Ref: "nixpkgs-unstable"}
+}
+
+func TestExtraPackages(t *testing.T) {
+	project := fakeProject{packages: []string{"go@1.22", "jq@latest"}}
+	f := &File{
+		devboxProject: project,
+		Packages: map[string]*Package{
+			"go@1.22":                 {},
+			"hello@2.12":              {},
+			"python@3.12":             {},
+			project.Stdenv().String(): {},
+		},
+	}
+	got := f.ExtraPackages()
+	want := []string{"hello@2.12", "python@3.12"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got ExtraPackages() = %v, want %v", got, want)
+	}
+}
@@ -14,10 +14,10 @@
 	"github.com/pkg/errors"
 	"go.jetify.com/devbox/internal/cachehash"
 	"go.jetify.com/devbox/internal/devpkg/pkgtype"
+	"go.jetify.com/devbox/internal/envir"
 	"go.jetify.com/devbox/internal/nix"
 	"go.jetify.com/devbox/internal/searcher"
 	"go.jetify.com/devbox/nix/flake"
-	"go.jetify.com/pkg/runx/impl/types"
 
 	"go.jetify.com/devbox/internal/cuecfg"
 )
@@ -34,13 +34,19 @@ type File struct {
 	Packages map[string]*Package `json:"packages"`
 }
 
-func GetFile(project devboxProject) (*File, error) {
-	lockFile := &File{
+// NewFile returns an empty lockfile for project without reading the project's
+// devbox.lock. Packages resolved with it stay in memory unless it's saved.
+func NewFile(project devboxProject) *File {
+	return &File{
 		devboxProject: project,
 
 		LockFileVersion: lockFileVersion,
 		Packages:        map[string]*Package{},
 	}
+}
+
+func GetFile(project devboxProject) (*File, error) {
+	lockFile := NewFile(project)
 	err := cuecfg.ParseFile(lockFilePath(project.ProjectDir()), lockFile)
 	if errors.Is(err, fs.ErrNotExist) {
 		return lockFile, nil
@@ -55,6 +61,18 @@ func GetFile(project devboxProject) (*File, error) {
 	return lockFile, nil
 }
 
+// Reload reads the lockfile from disk again, discarding the changes in memory,
+// because another process might have changed it.
+func (f *File) Reload() error {
+	fresh, err := GetFile(f.devboxProject)
+	if err != nil {
+		return err
+	}
+	f.LockFileVersion = fresh.LockFileVersion
+	f.Packages = fresh.Packages
+	return nil
+}
+
 func (f *File) Add(pkgs ...string) error {
 	for _, p := range pkgs {
 		if _, err := f.Resolve(p); err != nil {
@@ -73,6 +91,9 @@ func (f *File) Remove(pkgs ...string) error {
 
 // Resolve updates the in memory copy for performance but does not write to disk
 // This avoids writing values that may need to be removed in case of error.
+//
+// Resolve is called lazily by package getters, including the ones that the
+// flake templates call, which don't have a context to cancel it with.
 func (f *File) Resolve(pkg string) (*Package, error) {
 	entry, hasEntry := f.Packages[pkg]
 	if hasEntry && entry.Resolved != "" {
@@ -81,8 +102,8 @@ func (f *File) Resolve(pkg string) (*Package, error) {
 
 	locked := &Package{}
 	_, _, versioned := searcher.ParseVersionedPackage(pkg)
-	if pkgtype.IsRunX(pkg) || versioned || pkgtype.IsFlake(pkg) {
-		resolved, err := f.FetchResolvedPackage(pkg, false)
+	if pkgtype.IsRunX(pkg) || pkgtype.IsURL(pkg) || versioned || pkgtype.IsFlake(pkg) {
+		resolved, err := f.FetchResolvedPackage(context.Background(), pkg, false)
 		if err != nil {
 			return nil, err
 		}
@@ -123,6 +144,9 @@ func (f *File) Save() error {
 	if !isDirty {
 		return nil
 	}
+	if envir.IsLocked() {
+		return f.errLocked()
+	}
 
 	// In SystemInfo, preserve legacy StorePath field and clear out modern Outputs before writing
 	// Reason: We want to update `devbox.lock` file only upon a user action
@@ -138,6 +162,11 @@ func (f *File) Save() error {
 	// users of the `lock.File` struct will have the correct data.
 	defer ensurePackagesHaveOutputs(f.Packages)
 
+	// Keep the previous lockfile so that devbox lock rollback can restore
+	// it after a bad update.
+	if err := backup(f.devboxProject.ProjectDir()); err != nil {
+		return err
+	}
 	return cuecfg.WriteFile(lockFilePath(f.devboxProject.ProjectDir()), f)
 }
 
@@ -204,11 +233,23 @@ func IsLegacyPackage(pkg string) bool {
 // Tidy ensures that the lockfile has the set of packages corresponding to the devbox.json config.
 // It gets rid of older packages that are no longer needed.
 func (f *File) Tidy() {
+	for _, key := range f.ExtraPackages() {
+		delete(f.Packages, key)
+	}
+}
+
+// ExtraPackages returns the sorted keys of the packages in the lockfile that
+// aren't in the devbox.json config, which Tidy removes.
+func (f *File) ExtraPackages() []string {
 	keep := f.devboxProject.AllPackageNamesIncludingRemovedTriggerPackages()
 	keep = append(keep, f.devboxProject.Stdenv().String())
-	maps.DeleteFunc(f.Packages, func(key string, pkg *Package) bool {
-		return !slices.Contains(keep, key)
-	})
+	extra := []string{}
+	for _, key := range slices.Sorted(maps.Keys(f.Packages)) {
+		if !slices.Contains(keep, key) {
+			extra = append(extra, key)
+		}
+	}
+	return extra
 }
 
 // IsUpToDateAndInstalled returns true if the lockfile is up to date and the
@@ -246,6 +287,49 @@ func (f *File) SetOutputsForPackage(pkg string, outputs []Output) error {
 	return f.Save()
 }
 
+// SetLocalFlakeHash records the content hash of a local flake package for the
+// current system. Local flakes aren't resolved like other packages, so the entry
+// is created if it doesn't exist. It doesn't save the lockfile.
+func (f *File) SetLocalFlakeHash(pkg, hash string) {
+	p := f.Packages[pkg]
+	if p == nil {
+		p = &Package{Resolved: pkg}
+		f.Packages[pkg] = p
+	}
+	if p.Systems == nil {
+		p.Systems = map[string]*SystemInfo{}
+	}
+	if p.Systems[nix.System()] == nil {
+		p.Systems[nix.System()] = &SystemInfo{}
+	}
+	p.Systems[nix.System()].FlakeHash = hash
+}
+
+// SetPatchesHash records the content hash of the patch files applied to a
+// package. An empty hash removes it. It doesn't save the lockfile.
+func (f *File) SetPatchesHash(pkg, hash string) {
+	p := f.Packages[pkg]
+	if p == nil {
+		if hash == "" {
+			return
+		}
+		p = &Package{Resolved: pkg}
+		f.Packages[pkg] = p
+	}
+	p.PatchesHash = hash
+}
+
+// SetToolchain records the toolchain file that a package was added from. It
+// doesn't save the lockfile.
+func (f *File) SetToolchain(pkg string, toolchain *Toolchain) {
+	p := f.Packages[pkg]
+	if p == nil {
+		p = &Package{Resolved: pkg}
+		f.Packages[pkg] = p
+	}
+	p.Toolchain = toolchain
+}
+
 func (f *File) isDirty() (bool, error) {
 	currentHash, err := cachehash.JSON(f)
 	if err != nil {
@@ -265,16 +349,3 @@ func (f *File) isDirty() (bool, error) {
 func lockFilePath(projectDir string) string {
 	return filepath.Join(projectDir, "devbox.lock")
 }
-
-func ResolveRunXPackage(ctx context.Context, pkg string) (types.PkgRef, error) {
-	ref, err := types.NewPkgRef(strings.TrimPrefix(pkg, pkgtype.RunXPrefix))
-	if err != nil {
-		return types.PkgRef{}, err
-	}
-
-	registry, err := pkgtype.RunXRegistry(ctx)
-	if err != nil {
-		return types.PkgRef{}, err
-	}
-	return registry.ResolveVersion(ref)
-}
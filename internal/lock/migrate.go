@@ -0,0 +1,37 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package lock
+
+// LegacyStorePathCount returns the number of systems in the lockfile that
+// have the store_path field that outputs replaced.
+func (f *File) LegacyStorePathCount() int {
+	count := 0
+	for _, pkg := range f.Packages {
+		for _, sysInfo := range pkg.Systems {
+			if sysInfo.StorePath != "" {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// MigrateLegacyStorePaths replaces the store_path fields in the lockfile with
+// outputs, and returns the number of systems it changed. Save preserves the
+// legacy fields, so the changes are only written by an explicit migration.
+func (f *File) MigrateLegacyStorePaths() int {
+	count := 0
+	for _, pkg := range f.Packages {
+		for _, sysInfo := range pkg.Systems {
+			if sysInfo.StorePath == "" {
+				continue
+			}
+			sysInfo.addOutputFromLegacyStorePath()
+			sysInfo.StorePath = ""
+			sysInfo.outputIsFromStorePath = false
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,40 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package lock
+
+import "testing"
+
+func TestMigrateLegacyStorePaths(t *testing.T) {
+	f := &File{Packages: map[string]*Package{
+		"go@1.22": {Systems: map[string]*SystemInfo{
+			"x86_64-linux": {StorePath: "/nix/store/aaa-go-1.22"},
+			"aarch64-darwin": {
+				Outputs:   []Output{{Name: "out", Path: "/nix/store/bbb-go-1.22", Default: true}},
+				StorePath: "/nix/store/bbb-go-1.22",
+			},
+		}},
+		"jq@1.7": {Systems: map[string]*SystemInfo{
+			"x86_64-linux": {Outputs: []Output{{Name: "bin", Path: "/nix/store/ccc-jq-1.7-bin"}}},
+		}},
+	}}
+	ensurePackagesHaveOutputs(f.Packages)
+
+	if got := f.LegacyStorePathCount(); got != 2 {
+		t.Errorf("got LegacyStorePathCount() = %d, want 2", got)
+	}
+	if got := f.MigrateLegacyStorePaths(); got != 2 {
+		t.Errorf("got MigrateLegacyStorePaths() = %d, want 2", got)
+	}
+	if got := f.LegacyStorePathCount(); got != 0 {
+		t.Errorf("got LegacyStorePathCount() = %d after migrating, want 0", got)
+	}
+
+	linux := f.Packages["go@1.22"].Systems["x86_64-linux"]
+	if len(linux.Outputs) != 1 || linux.Outputs[0].Path != "/nix/store/aaa-go-1.22" {
+		t.Errorf("got outputs %v, want the legacy store path as the default output", linux.Outputs)
+	}
+	if linux.outputIsFromStorePath {
+		t.Error("got outputIsFromStorePath = true after migrating, want false so that Save keeps the outputs")
+	}
+}
@@ -22,13 +22,38 @@ type Package struct {
 	Version       string `json:"version,omitempty"`
 	// Systems is keyed by the system name
 	Systems map[string]*SystemInfo `json:"systems,omitempty"`
+	// Checksums is keyed by the system name and has the checksums of the
+	// artifacts that runx packages download.
+	Checksums map[string]string `json:"checksums,omitempty"`
+	// PatchesHash is the content hash of the local patch files applied to
+	// the package.
+	PatchesHash string `json:"patches_hash,omitempty"`
+	// Toolchain is the toolchain file that the package was added from.
+	Toolchain *Toolchain `json:"toolchain,omitempty"`
+	// InsecureAcceptances record the allow_insecure entries of the package
+	// in devbox.json that were accepted, with the advisories at the time.
+	InsecureAcceptances []InsecureAcceptance `json:"insecure_acceptances,omitempty"`
 
 	// NOTE: if you add more fields, please update SyncLockfiles
 }
 
+// Toolchain records the toolchain file, such as rust-toolchain.toml, that a
+// package was added from.
+type Toolchain struct {
+	// File is the path to the toolchain file, relative to the project.
+	File    string `json:"file"`
+	Channel string `json:"channel"`
+	// Hash is the content hash of the toolchain file.
+	Hash string `json:"hash"`
+}
+
 type SystemInfo struct {
 	Outputs []Output `json:"outputs,omitempty"`
 
+	// FlakeHash is the content hash of a local flake's directory when the
+	// environment was last built on this system.
+	FlakeHash string `json:"flake_hash,omitempty"`
+
 	// Legacy Format
 	StorePath             string `json:"store_path,omitempty"`
 	outputIsFromStorePath bool
@@ -0,0 +1,107 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package lock
+
+import (
+	"strings"
+
+	"go.jetify.com/devbox/internal/devpkg/pkgtype"
+	"go.jetify.com/devbox/internal/searcher"
+	"go.jetify.com/devbox/nix/flake"
+)
+
+// Datasources and skip reasons that Renovate understands.
+const (
+	renovateDevbox         = "devbox"
+	renovateGithubTags     = "github-tags"
+	renovateGithubReleases = "github-releases"
+
+	renovateUnspecifiedVersion    = "unspecified-version"
+	renovateLocalDependency       = "local-dependency"
+	renovateUnsupportedDatasource = "unsupported-datasource"
+)
+
+// RenovateManifest lists a project's packages with the fields of Renovate's
+// package dependencies, so that a Renovate custom manager can update
+// devbox.json.
+type RenovateManifest struct {
+	Deps []RenovateDependency `json:"deps"`
+}
+
+// RenovateDependency is a package in devbox.json. CurrentValue is the version
+// in devbox.json, and LockedVersion is the version that it resolves to in
+// devbox.lock. Datasource tells Renovate where to look up new versions, and
+// SkipReason is set instead for packages that Renovate can't update.
+type RenovateDependency struct {
+	DepName       string `json:"depName"`
+	PackageName   string `json:"packageName,omitempty"`
+	CurrentValue  string `json:"currentValue,omitempty"`
+	LockedVersion string `json:"lockedVersion,omitempty"`
+	Datasource    string `json:"datasource,omitempty"`
+	SkipReason    string `json:"skipReason,omitempty"`
+}
+
+// RenovateManifest returns the Renovate manifest of the packages in
+// devbox.json, which are given by their versioned names.
+func (f *File) RenovateManifest(pkgs []string) *RenovateManifest {
+	manifest := &RenovateManifest{Deps: []RenovateDependency{}}
+	for _, pkg := range pkgs {
+		dep := renovateDependency(pkg)
+		if locked := f.Get(pkg); locked != nil {
+			dep.LockedVersion = locked.Version
+		}
+		manifest.Deps = append(manifest.Deps, dep)
+	}
+	return manifest
+}
+
+func renovateDependency(pkg string) RenovateDependency {
+	switch {
+	case pkgtype.IsRunX(pkg):
+		repo, version, _ := strings.Cut(strings.TrimPrefix(pkg, pkgtype.RunXPrefix), "@")
+		dep := RenovateDependency{DepName: repo, PackageName: repo, CurrentValue: version}
+		if version == "" || version == "latest" {
+			dep.SkipReason = renovateUnspecifiedVersion
+		} else {
+			dep.Datasource = renovateGithubReleases
+		}
+		return dep
+	case pkgtype.IsURL(pkg):
+		return RenovateDependency{DepName: pkg, SkipReason: renovateUnsupportedDatasource}
+	case pkgtype.IsFlake(pkg):
+		return renovateFlakeDependency(pkg)
+	}
+
+	name, version, ok := searcher.ParseVersionedPackage(pkg)
+	if !ok {
+		return RenovateDependency{DepName: pkg, SkipReason: renovateUnspecifiedVersion}
+	}
+	return RenovateDependency{DepName: name, CurrentValue: version, Datasource: renovateDevbox}
+}
+
+// renovateFlakeDependency returns the dependency of a flake, which Renovate can
+// update if it's a GitHub repository at a tag or branch.
+func renovateFlakeDependency(pkg string) RenovateDependency {
+	dep := RenovateDependency{DepName: pkg}
+	installable, err := flake.ParseInstallable(pkg)
+	if err != nil {
+		dep.SkipReason = renovateUnsupportedDatasource
+		return dep
+	}
+	ref := installable.Ref
+	switch {
+	case ref.Type == flake.TypePath:
+		dep.SkipReason = renovateLocalDependency
+	case ref.Type != flake.TypeGitHub:
+		dep.SkipReason = renovateUnsupportedDatasource
+	case ref.Ref == "":
+		dep.PackageName = ref.Owner + "/" + ref.Repo
+		dep.SkipReason = renovateUnspecifiedVersion
+	default:
+		dep.PackageName = ref.Owner + "/" + ref.Repo
+		dep.CurrentValue = ref.Ref
+		dep.Datasource = renovateGithubTags
+	}
+	return dep
+}
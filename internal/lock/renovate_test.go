@@ -0,0 +1,57 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package lock
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRenovateManifest(t *testing.T) {
+	f := &File{Packages: map[string]*Package{
+		"nodejs@20":                           {Version: "20.11.1", Resolved: "github:NixOS/nixpkgs/a#nodejs_20"},
+		"go@latest":                           {Version: "1.23.0", Resolved: "github:NixOS/nixpkgs/a#go"},
+		"runx:golangci/golangci-lint@v1.59.1": {Version: "v1.59.1", Resolved: "runx:golangci/golangci-lint@v1.59.1"},
+	}}
+	pkgs := []string{
+		"nodejs@20",
+		"go@latest",
+		"hello",
+		"runx:golangci/golangci-lint@v1.59.1",
+		"runx:cli/cli",
+		"github:F1bonacc1/process-compose/v0.43.1",
+		"github:numtide/flake-utils",
+		"path:my-flake#hello",
+		"git+https://example.com/flake.git",
+	}
+	want := []RenovateDependency{
+		{DepName: "nodejs", CurrentValue: "20", LockedVersion: "20.11.1", Datasource: "devbox"},
+		{DepName: "go", CurrentValue: "latest", LockedVersion: "1.23.0", Datasource: "devbox"},
+		{DepName: "hello", SkipReason: "unspecified-version"},
+		{
+			DepName:       "golangci/golangci-lint",
+			PackageName:   "golangci/golangci-lint",
+			CurrentValue:  "v1.59.1",
+			LockedVersion: "v1.59.1",
+			Datasource:    "github-releases",
+		},
+		{DepName: "cli/cli", PackageName: "cli/cli", SkipReason: "unspecified-version"},
+		{
+			DepName:      "github:F1bonacc1/process-compose/v0.43.1",
+			PackageName:  "F1bonacc1/process-compose",
+			CurrentValue: "v0.43.1",
+			Datasource:   "github-tags",
+		},
+		{
+			DepName:     "github:numtide/flake-utils",
+			PackageName: "numtide/flake-utils",
+			SkipReason:  "unspecified-version",
+		},
+		{DepName: "path:my-flake#hello", SkipReason: "local-dependency"},
+		{DepName: "git+https://example.com/flake.git", SkipReason: "unsupported-datasource"},
+	}
+	if got := f.RenovateManifest(pkgs).Deps; !slices.Equal(got, want) {
+		t.Errorf("got dependencies\n%+v\nwant\n%+v", got, want)
+	}
+}
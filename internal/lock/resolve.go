@@ -7,6 +7,7 @@
 	"context"
 	"fmt"
 	"log/slog"
+	"strings"
 	"sync"
 	"time"
 
@@ -32,13 +33,16 @@
 // When refresh is true, flake ref resolution bypasses nix's own cache via
 // `--refresh`. This should only be set on the `devbox update` path; other
 // callers (Add, install, outdated checks) prefer the cache.
-func (f *File) FetchResolvedPackage(pkg string, refresh bool) (*Package, error) {
+func (f *File) FetchResolvedPackage(ctx context.Context, pkg string, refresh bool) (*Package, error) {
+	if source, ok := pkgtype.ParseSourcePackage(pkg, f.PackageSources()); ok {
+		return resolveSourcePackage(ctx, source, refresh)
+	}
 	if pkgtype.IsFlake(pkg) {
 		installable, err := flake.ParseInstallable(pkg)
 		if err != nil {
 			return nil, fmt.Errorf("package %q: %v", pkg, err)
 		}
-		installable.Ref, err = lockFlake(context.TODO(), installable.Ref, refresh)
+		installable.Ref, err = lockFlake(ctx, installable.Ref, refresh)
 		if err != nil {
 			return nil, err
 		}
@@ -48,28 +52,40 @@ func (f *File) FetchResolvedPackage(pkg string, refresh bool) (*Package, error)
 		}, nil
 	}
 
+	if pkgtype.IsURL(pkg) {
+		// The checksum is verified, and added if it's only in devbox.json,
+		// when the package is installed.
+		return &Package{Resolved: strings.TrimPrefix(pkg, pkgtype.URLPrefix)}, nil
+	}
+
 	name, version, _ := searcher.ParseVersionedPackage(pkg)
 	if version == "" {
 		return nil, usererr.New("No version specified for %q.", name)
 	}
 
 	if pkgtype.IsRunX(pkg) {
-		ref, err := ResolveRunXPackage(context.TODO(), pkg)
+		ref, err := ResolveRunXPackage(ctx, pkg)
+		if err != nil {
+			return nil, err
+		}
+		checksums, err := runxChecksums(ctx, ref)
 		if err != nil {
 			return nil, err
 		}
 		return &Package{
-			Resolved: ref.String(),
-			Version:  ref.Version,
+			Resolved:  ref.String(),
+			Version:   ref.Version,
+			Checksums: checksums,
 		}, nil
 	}
 	if featureflag.ResolveV2.Enabled() {
-		return resolveV2(context.TODO(), name, version)
+		return resolveV2(ctx, name, version)
 	}
 
-	packageVersion, err := searcher.Client().Resolve(name, version)
+	packageVersion, err := searcher.Client().Resolve(ctx, name, version)
 	if err != nil {
-		return nil, errors.Wrapf(nix.ErrPackageNotFound, "%s@%s", name, version)
+		err = errors.Wrapf(nix.ErrPackageNotFound, "%s@%s", name, version)
+		return nil, withSuggestions(ctx, err, name, version)
 	}
 
 	sysInfos, err := buildLockSystemInfos(packageVersion)
@@ -99,10 +115,41 @@ func (f *File) FetchResolvedPackage(pkg string, refresh bool) (*Package, error)
 	}, nil
 }
 
+// resolveSourcePackage locks the flake of a package from package_sources and
+// checks that the package has the version that it asks for.
+func resolveSourcePackage(ctx context.Context, source pkgtype.SourcePackage, refresh bool) (*Package, error) {
+	installable, err := flake.ParseInstallable(source.Installable())
+	if err != nil {
+		return nil, usererr.New("Package source %q: %v", source.Source, err)
+	}
+	installable.Ref, err = lockFlake(ctx, installable.Ref, refresh)
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := nix.EvalPackageVersion(ctx, installable.String())
+	if err != nil && source.Version != "" && source.Version != "latest" {
+		return nil, usererr.WithUserMessage(err,
+			"Unable to get the version of %s from package source %q.", source.AttrPath, source.Source)
+	}
+	if !source.MatchesVersion(version) {
+		return nil, usererr.New(
+			"Package source %q has %s@%s, not version %s. Change the version in devbox.json, "+
+				"or point the source to a revision that has it.",
+			source.Source, source.AttrPath, version, source.Version)
+	}
+	return &Package{
+		Resolved:     installable.String(),
+		LastModified: time.Unix(installable.Ref.LastModified, 0).UTC().Format(time.RFC3339),
+		Version:      version,
+	}, nil
+}
+
 func resolveV2(ctx context.Context, name, version string) (*Package, error) {
 	resolved, err := searcher.Client().ResolveV2(ctx, name, version)
 	if errors.Is(err, searcher.ErrNotFound) {
-		return nil, redact.Errorf("%s@%s: %w", name, version, nix.ErrPackageNotFound)
+		err = redact.Errorf("%s@%s: %w", name, version, nix.ErrPackageNotFound)
+		return nil, withSuggestions(ctx, err, name, version)
 	}
 	if err != nil {
 		return nil, err
@@ -250,3 +297,21 @@ func lockFlake(ctx context.Context, ref flake.Ref, refresh bool) (flake.Ref, err
 	}
 	return meta.Locked, nil
 }
+
+// withSuggestions adds the names of similar packages to the error of a package
+// that wasn't found, so that typos are easy to fix.
+func withSuggestions(ctx context.Context, err error, name, version string) error {
+	suggestions := searcher.Suggest(ctx, name)
+	if len(suggestions) == 0 {
+		return err
+	}
+	for i, s := range suggestions {
+		suggestions[i] = s + "@" + version
+	}
+	last := len(suggestions) - 1
+	didYouMean := suggestions[last]
+	if last > 0 {
+		didYouMean = strings.Join(suggestions[:last], ", ") + " or " + didYouMean
+	}
+	return usererr.WithUserMessage(err, "Package %s@%s was not found. Did you mean %s?", name, version, didYouMean)
+}
@@ -0,0 +1,249 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package lock
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+	"go.jetify.com/devbox/internal/devpkg/pkgtype"
+	"go.jetify.com/devbox/internal/envir"
+	"go.jetify.com/devbox/internal/httpclient"
+	"go.jetify.com/devbox/internal/nix"
+	"go.jetify.com/pkg/runx/impl/types"
+)
+
+const runxChecksumPrefix = "sha256:"
+
+// runxGithubAPIURL is the GitHub API that runx fetches releases from.
+var runxGithubAPIURL = "https://api.github.com/"
+
+// ResolveRunXPackage resolves a runx package to an exact release. The version
+// can be a release tag, "latest", or a semver constraint such as ^1.2, ~1.2.3
+// or ">=1.2 <2". Constraints resolve to the newest stable release that
+// satisfies them.
+func ResolveRunXPackage(ctx context.Context, pkg string) (types.PkgRef, error) {
+	ref, err := types.NewPkgRef(strings.TrimPrefix(pkg, pkgtype.RunXPrefix))
+	if err != nil {
+		return types.PkgRef{}, err
+	}
+
+	registry, err := pkgtype.RunXRegistry(ctx)
+	if err != nil {
+		return types.PkgRef{}, err
+	}
+	if !isVersionConstraint(ref.Version) {
+		resolved, err := registry.ResolveVersion(ref)
+		if err != nil {
+			return types.PkgRef{}, runxAccessError(ctx, ref, err)
+		}
+		return resolved, nil
+	}
+
+	constraint, err := parseVersionConstraint(ref.Version)
+	if err != nil {
+		return types.PkgRef{}, usererr.New("Invalid version %q for %s: %v", ref.Version, pkg, err)
+	}
+	releases, err := registry.ListReleases(ctx, ref.Owner, ref.Repo)
+	if err != nil {
+		return types.PkgRef{}, runxAccessError(ctx, ref, err)
+	}
+	tags := []string{}
+	for _, release := range releases {
+		if !release.Draft && !release.Prerelease {
+			tags = append(tags, release.TagName)
+		}
+	}
+	tag, ok := constraint.latest(tags)
+	if !ok {
+		return types.PkgRef{}, usererr.New(
+			"No release of %s/%s matches version %q.", ref.Owner, ref.Repo, ref.Version)
+	}
+	ref.Version = tag
+	return ref, nil
+}
+
+// runxPlatforms are the platforms of the systems that the checksums of runx
+// artifacts are recorded for when a package is resolved.
+var runxPlatforms = map[string]types.Platform{
+	"aarch64-darwin": types.NewPlatform("darwin", "arm64"),
+	"aarch64-linux":  types.NewPlatform("linux", "arm64"),
+	"x86_64-darwin":  types.NewPlatform("darwin", "amd64"),
+	"x86_64-linux":   types.NewPlatform("linux", "amd64"),
+}
+
+// runxChecksums downloads the artifacts of a runx release for the current
+// system and every system in runxPlatforms, and returns their checksums keyed
+// by system. Systems that the release doesn't have an artifact for are left
+// out.
+func runxChecksums(ctx context.Context, ref types.PkgRef) (map[string]string, error) {
+	registry, err := pkgtype.RunXRegistry(ctx)
+	if err != nil {
+		return nil, err
+	}
+	platforms := maps.Clone(runxPlatforms)
+	platforms[nix.System()] = types.CurrentPlatform()
+
+	checksums := map[string]string{}
+	for _, system := range slices.Sorted(maps.Keys(platforms)) {
+		artifact, err := registry.GetArtifact(ctx, ref, platforms[system])
+		if errors.Is(err, types.ErrPlatformNotSupported) {
+			continue
+		}
+		if err != nil {
+			return nil, runxAccessError(ctx, ref, err)
+		}
+		checksum, err := fileChecksum(artifact)
+		if err != nil {
+			return nil, err
+		}
+		checksums[system] = checksum
+	}
+	return checksums, nil
+}
+
+// InstallRunXPackage installs the locked release of a runx package and returns
+// the directories it was installed to. The downloaded artifact is verified
+// against the checksum that was recorded in the lockfile when the package was
+// resolved. Lockfiles from before checksums were recorded don't have them, so
+// they're recorded like a resolve would, and saved with the rest of the
+// lockfile. With --locked, that's an error instead.
+func (f *File) InstallRunXPackage(ctx context.Context, pkg string) ([]string, error) {
+	locked, err := f.Resolve(pkg)
+	if err != nil {
+		return nil, err
+	}
+	ref, err := types.NewPkgRef(locked.Resolved)
+	if err != nil {
+		return nil, err
+	}
+	system := nix.System()
+	if locked.Checksums[system] == "" {
+		if envir.IsLocked() {
+			return nil, usererr.New(
+				"devbox.lock is out of date: it doesn't have the checksum of %s for %s, but it can't "+
+					"be updated because --locked or %s is set. Run `devbox update %s` without "+
+					"--locked to record the checksums, and commit devbox.lock.",
+				pkg, system, envir.DevboxLocked, pkg)
+		}
+		checksums, err := runxChecksums(ctx, ref)
+		if err != nil {
+			return nil, err
+		}
+		if locked.Checksums == nil {
+			locked.Checksums = map[string]string{}
+		}
+		for sys, checksum := range checksums {
+			if locked.Checksums[sys] == "" {
+				locked.Checksums[sys] = checksum
+			}
+		}
+	}
+
+	registry, err := pkgtype.RunXRegistry(ctx)
+	if err != nil {
+		return nil, err
+	}
+	platform := types.CurrentPlatform()
+	artifact, err := registry.GetArtifact(ctx, ref, platform)
+	if err != nil {
+		return nil, runxAccessError(ctx, ref, err)
+	}
+	checksum, err := fileChecksum(artifact)
+	if err != nil {
+		return nil, err
+	}
+	if want := locked.Checksums[system]; checksum != want {
+		// Remove the artifact so that it's downloaded again next time instead
+		// of failing the same way.
+		_ = os.Remove(artifact)
+		return nil, usererr.New(
+			"Checksum mismatch for %s on %s. devbox.lock has %s but the downloaded "+
+				"artifact has %s. The release may have been modified after it was locked.",
+			ref, system, want, checksum,
+		)
+	}
+
+	path, err := registry.GetPackage(ctx, ref, platform)
+	if err != nil {
+		return nil, err
+	}
+	return []string{path}, nil
+}
+
+func fileChecksum(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", errors.WithStack(err)
+	}
+	return runxChecksumPrefix + hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// runxAccessError explains why err happened when it's because the package's
+// GitHub repository can't be accessed. GitHub responds to requests for private
+// repositories with 404 Not Found when the token is missing or can't access the
+// repository, and with 401 Unauthorized when the token is invalid. If the
+// repository is accessible, err is returned unchanged.
+func runxAccessError(ctx context.Context, ref types.PkgRef, err error) error {
+	url := fmt.Sprintf("%srepos/%s/%s", runxGithubAPIURL, ref.Owner, ref.Repo)
+	req, reqErr := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if reqErr != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	token := pkgtype.GithubToken()
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	res, reqErr := httpclient.Client().Do(req)
+	if reqErr != nil {
+		return err
+	}
+	res.Body.Close()
+
+	repo := ref.Owner + "/" + ref.Repo
+	switch {
+	case res.StatusCode == http.StatusUnauthorized:
+		return usererr.WithUserMessage(err,
+			"GitHub rejected the token in GITHUB_TOKEN while fetching runx package %s. "+
+				"Check that the token is valid and hasn't expired.", repo)
+	case res.StatusCode == http.StatusForbidden && res.Header.Get("X-RateLimit-Remaining") == "0":
+		if token == "" {
+			return usererr.WithUserMessage(err,
+				"GitHub's rate limit was exceeded while fetching runx package %s. "+
+					"Set GITHUB_TOKEN to get a higher limit.", repo)
+		}
+		return usererr.WithUserMessage(err,
+			"GitHub's rate limit was exceeded while fetching runx package %s.", repo)
+	case res.StatusCode == http.StatusForbidden:
+		return usererr.WithUserMessage(err,
+			"The token in GITHUB_TOKEN doesn't have access to %s.", repo)
+	case res.StatusCode == http.StatusNotFound && token == "":
+		return usererr.WithUserMessage(err,
+			"GitHub repository %s for runx package %s was not found. If it's a private "+
+				"repository, set GITHUB_TOKEN to a token that can read it.", repo, ref)
+	case res.StatusCode == http.StatusNotFound:
+		return usererr.WithUserMessage(err,
+			"GitHub repository %s for runx package %s was not found, or the token in "+
+				"GITHUB_TOKEN can't read it. Private repositories need a token with read "+
+				"access to the repository's contents.", repo, ref)
+	}
+	return err
+}
@@ -0,0 +1,113 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package lock
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.jetify.com/devbox/internal/envir"
+	"go.jetify.com/pkg/runx/impl/types"
+)
+
+func TestRunXAccessError(t *testing.T) {
+	tests := []struct {
+		name    string
+		token   string
+		status  int
+		header  http.Header
+		wantMsg string
+	}{
+		{
+			name:    "private repo without token",
+			status:  http.StatusNotFound,
+			wantMsg: "If it's a private repository, set GITHUB_TOKEN",
+		},
+		{
+			name:    "private repo token without access",
+			token:   "token",
+			status:  http.StatusNotFound,
+			wantMsg: "or the token in GITHUB_TOKEN can't read it",
+		},
+		{
+			name:    "invalid token",
+			token:   "expired",
+			status:  http.StatusUnauthorized,
+			wantMsg: "GitHub rejected the token in GITHUB_TOKEN",
+		},
+		{
+			name:    "rate limited",
+			status:  http.StatusForbidden,
+			header:  http.Header{"X-Ratelimit-Remaining": {"0"}},
+			wantMsg: "Set GITHUB_TOKEN to get a higher limit",
+		},
+		{
+			name:   "accessible repo",
+			token:  "token",
+			status: http.StatusOK,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("GITHUB_TOKEN", test.token)
+			t.Setenv("DEVBOX_GITHUB_API_TOKEN", "")
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/repos/acme/tool" {
+					t.Errorf("got request for %s, want /repos/acme/tool", r.URL.Path)
+				}
+				wantAuth := ""
+				if test.token != "" {
+					wantAuth = "Bearer " + test.token
+				}
+				if got := r.Header.Get("Authorization"); got != wantAuth {
+					t.Errorf("got Authorization header %q, want %q", got, wantAuth)
+				}
+				for k, v := range test.header {
+					w.Header()[k] = v
+				}
+				w.WriteHeader(test.status)
+			}))
+			t.Cleanup(server.Close)
+			runxGithubAPIURL = server.URL + "/"
+			t.Cleanup(func() { runxGithubAPIURL = "https://api.github.com/" })
+
+			source := errors.New("GET releases: 404 Not Found")
+			ref := types.PkgRef{Owner: "acme", Repo: "tool", Version: "v1.0.0"}
+			err := runxAccessError(context.Background(), ref, source)
+			if !errors.Is(err, source) {
+				t.Errorf("got error %v that doesn't wrap the original error", err)
+			}
+			if test.wantMsg == "" {
+				if err != source {
+					t.Errorf("got error %q, want the original error", err)
+				}
+				return
+			}
+			if !strings.Contains(err.Error(), test.wantMsg) {
+				t.Errorf("got error %q, want it to contain %q", err, test.wantMsg)
+			}
+		})
+	}
+}
+
+func TestInstallRunXPackageLocked(t *testing.T) {
+	t.Setenv(envir.XDGCacheHome, t.TempDir())
+	t.Setenv(envir.DevboxLocked, "1")
+
+	const pkg = "runx:golangci/golangci-lint@v1.59.1"
+	f := &File{Packages: map[string]*Package{
+		pkg: {Resolved: "golangci/golangci-lint@v1.59.1", Version: "v1.59.1"},
+	}}
+	_, err := f.InstallRunXPackage(context.Background(), pkg)
+	if err == nil || !strings.Contains(err.Error(), "devbox.lock is out of date") {
+		t.Errorf("got error %v, want devbox.lock to be out of date", err)
+	}
+	if len(f.Packages[pkg].Checksums) != 0 {
+		t.Errorf("got checksums %v, want none recorded with --locked", f.Packages[pkg].Checksums)
+	}
+}
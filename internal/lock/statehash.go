@@ -6,11 +6,13 @@
 import (
 	"errors"
 	"io/fs"
+	"os"
 	"path/filepath"
 
 	"go.jetify.com/devbox/internal/build"
 	"go.jetify.com/devbox/internal/cachehash"
 	"go.jetify.com/devbox/internal/cuecfg"
+	"go.jetify.com/devbox/internal/nix"
 )
 
 var ignoreShellMismatch = false
@@ -71,6 +73,39 @@ func isStateUpToDate(args UpdateStateHashFileArgs) (bool, error) {
 	return *filesystemStateHash == *newStateHash, nil
 }
 
+// IsStale is a fast check of whether a project's environment is out of date.
+// It reports whether devbox.lock changed since devbox last brought the
+// environment up to date, or whether the config file at configPath was
+// modified after that. Unlike File.IsUpToDateAndInstalled, it doesn't load the
+// project's config or plugins, so it's fast enough to run on every shell
+// prompt, but it can miss changes to plugins.
+func IsStale(projectDir, configPath string) (bool, error) {
+	state, err := os.Stat(stateHashFilePath(projectDir))
+	if errors.Is(err, fs.ErrNotExist) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	config, err := os.Stat(configPath)
+	if err != nil {
+		return false, err
+	}
+	if config.ModTime().After(state.ModTime()) {
+		return true, nil
+	}
+
+	hashFile, err := readStateHashFile(projectDir)
+	if err != nil {
+		return false, err
+	}
+	lockfileHash, err := getLockfileHash(projectDir)
+	if err != nil {
+		return false, err
+	}
+	return hashFile.LockFileHash != lockfileHash, nil
+}
+
 func readStateHashFile(projectDir string) (*stateHashFile, error) {
 	hashFile := &stateHashFile{}
 	err := cuecfg.ParseFile(stateHashFilePath(projectDir), hashFile)
@@ -112,11 +147,11 @@ func getCurrentStateHash(args UpdateStateHashFileArgs) (*stateHashFile, error) {
 }
 
 func stateHashFilePath(projectDir string) string {
-	return filepath.Join(projectDir, ".devbox", "state.json")
+	return filepath.Join(nix.ProjectUserDir(projectDir), "state.json")
 }
 
 func manifestHash(profileDir string) (string, error) {
-	return cachehash.JSONFile(filepath.Join(profileDir, ".devbox/nix/profile/default/manifest.json"))
+	return cachehash.JSONFile(filepath.Join(nix.ProjectProfilePath(profileDir), "manifest.json"))
 }
 
 func printDevEnvCacheHash(profileDir string) (string, error) {
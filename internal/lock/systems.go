@@ -0,0 +1,71 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package lock
+
+import (
+	"context"
+	"maps"
+	"slices"
+)
+
+// FillSystems records the store paths of a package for the systems that the
+// search index knows about, so that devbox.lock works on other platforms
+// without resolving the package again. If systems isn't empty, it only records
+// those systems. It doesn't change the version that the package is locked to:
+// if the index now resolves the package to a different version, it doesn't
+// record anything. It returns true if it recorded any systems.
+func (f *File) FillSystems(ctx context.Context, pkg string, systems []string) (bool, error) {
+	locked := f.Get(pkg)
+	if locked == nil || locked.Source != devboxSearchSource {
+		return false, nil
+	}
+	resolved, err := f.FetchResolvedPackage(ctx, pkg, false /*refresh*/)
+	if err != nil {
+		return false, err
+	}
+	return mergeSystems(locked, resolved, systems), nil
+}
+
+// mergeSystems adds the systems of resolved that locked doesn't have outputs
+// for, and that are in systems if it isn't empty. It returns false if resolved
+// is a different build than locked.
+func mergeSystems(locked, resolved *Package, systems []string) bool {
+	if locked.Resolved != resolved.Resolved {
+		return false
+	}
+	changed := false
+	for sys, info := range resolved.Systems {
+		if existing, ok := locked.Systems[sys]; ok && len(existing.Outputs) > 0 {
+			continue
+		}
+		if len(info.Outputs) == 0 || (len(systems) > 0 && !slices.Contains(systems, sys)) {
+			continue
+		}
+		if locked.Systems == nil {
+			locked.Systems = map[string]*SystemInfo{}
+		}
+		locked.Systems[sys] = info
+		changed = true
+	}
+	return changed
+}
+
+// PruneSystems removes the store paths and runx checksums of systems that
+// aren't in keep from every package. It returns the number of entries that it
+// removed. It doesn't save the lockfile.
+func (f *File) PruneSystems(keep []string) int {
+	removed := 0
+	for _, pkg := range f.Packages {
+		removed += pruneSystems(pkg.Systems, keep) + pruneSystems(pkg.Checksums, keep)
+	}
+	return removed
+}
+
+func pruneSystems[V any](systems map[string]V, keep []string) int {
+	before := len(systems)
+	maps.DeleteFunc(systems, func(system string, _ V) bool {
+		return !slices.Contains(keep, system)
+	})
+	return before - len(systems)
+}
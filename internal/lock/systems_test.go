@@ -0,0 +1,70 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package lock
+
+import "testing"
+
+func TestMergeSystems(t *testing.T) {
+	const ref = "github:NixOS/nixpkgs/75a5ebf473cd60148ba9aec0d219f72e5cf52519#go"
+	linux := &SystemInfo{Outputs: []Output{{Name: "out", Path: "/nix/store/aaa-go-1.22.3", Default: true}}}
+	darwin := &SystemInfo{Outputs: []Output{{Name: "out", Path: "/nix/store/bbb-go-1.22.3", Default: true}}}
+	resolved := &Package{
+		Resolved: ref,
+		Systems:  map[string]*SystemInfo{"x86_64-linux": linux, "aarch64-darwin": darwin},
+	}
+
+	locked := &Package{Resolved: ref, Systems: map[string]*SystemInfo{"x86_64-linux": linux}}
+	if !mergeSystems(locked, resolved, nil) {
+		t.Error("mergeSystems() = false, want true when a system is missing")
+	}
+	if locked.Systems["aarch64-darwin"] != darwin {
+		t.Error("mergeSystems() didn't add aarch64-darwin")
+	}
+	if mergeSystems(locked, resolved, nil) {
+		t.Error("mergeSystems() = true, want false when every system is locked")
+	}
+
+	other := &Package{Resolved: "github:NixOS/nixpkgs/0000000000000000000000000000000000000000#go"}
+	if mergeSystems(other, resolved, nil) {
+		t.Error("mergeSystems() = true, want false for a different build")
+	}
+	if len(other.Systems) != 0 {
+		t.Errorf("mergeSystems() added systems to a different build: %v", other.Systems)
+	}
+
+	linuxOnly := &Package{Resolved: ref}
+	if !mergeSystems(linuxOnly, resolved, []string{"x86_64-linux"}) {
+		t.Error("mergeSystems() = false, want true when a listed system is missing")
+	}
+	if _, ok := linuxOnly.Systems["aarch64-darwin"]; ok || len(linuxOnly.Systems) != 1 {
+		t.Errorf("got systems %v, want only x86_64-linux", linuxOnly.Systems)
+	}
+}
+
+func TestPruneSystems(t *testing.T) {
+	f := &File{Packages: map[string]*Package{
+		"go@latest": {Systems: map[string]*SystemInfo{
+			"x86_64-linux":   {},
+			"aarch64-darwin": {},
+			"i686-linux":     {},
+		}},
+		"runx:golangci/golangci-lint@latest": {Checksums: map[string]string{
+			"x86_64-linux":  "aaa",
+			"x86_64-darwin": "bbb",
+		}},
+		"github:NixOS/nixpkgs/nixpkgs-unstable": {},
+	}}
+
+	removed := f.PruneSystems([]string{"x86_64-linux", "aarch64-darwin"})
+	if removed != 2 {
+		t.Errorf("PruneSystems() removed %d entries, want 2", removed)
+	}
+	if got := len(f.Packages["go@latest"].Systems); got != 2 {
+		t.Errorf("go@latest has %d systems, want 2", got)
+	}
+	checksums := f.Packages["runx:golangci/golangci-lint@latest"].Checksums
+	if _, ok := checksums["x86_64-darwin"]; ok || len(checksums) != 1 {
+		t.Errorf("got checksums %v, want only x86_64-linux", checksums)
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package lock
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+	"go.jetify.com/devbox/internal/devpkg/pkgtype"
+	"go.jetify.com/devbox/internal/httpclient"
+	"go.jetify.com/devbox/internal/state"
+	"go.jetify.com/pkg/runx/impl/registry"
+)
+
+var sha256Regexp = regexp.MustCompile("^[0-9a-f]{64}$")
+
+// archiveExts are the file extensions of the archives that url packages
+// unpack. Other files are treated as a single executable.
+var archiveExts = []string{
+	".tar", ".tar.gz", ".tgz", ".tar.bz2", ".tbz", ".tbz2",
+	".tar.xz", ".txz", ".tar.zst", ".tzst", ".zip",
+}
+
+// urlPackagesDir is where url packages are unpacked. Each package is in a
+// directory named after its checksum so that it can be shared by projects.
+func urlPackagesDir() string {
+	return state.Cache("url")
+}
+
+// InstallURLPackage downloads and unpacks the file of a url package and returns
+// the directory with the package's executables. The file must match checksum,
+// which defaults to the checksum in the lockfile. The lockfile records the
+// package's URL and checksum.
+func (f *File) InstallURLPackage(ctx context.Context, pkg, checksum string) (string, error) {
+	locked, err := f.Resolve(pkg)
+	if err != nil {
+		return "", err
+	}
+	downloadURL, lockedChecksum := pkgtype.SplitURLChecksum(locked.Resolved)
+	checksum = strings.ToLower(cmp.Or(checksum, lockedChecksum))
+	if checksum != "" && !sha256Regexp.MatchString(checksum) {
+		return "", usererr.New(
+			"Invalid sha256 %q for package %s. It must be 64 hexadecimal characters.", checksum, pkg)
+	}
+
+	dir := filepath.Join(urlPackagesDir(), cmp.Or(checksum, "unknown"))
+	if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
+		if err := downloadURLPackage(ctx, pkg, downloadURL, checksum, dir); err != nil {
+			return "", err
+		}
+	} else if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	if resolved := pkgtype.JoinURLChecksum(downloadURL, checksum); locked.Resolved != resolved {
+		locked.Resolved = resolved
+		if err := f.Save(); err != nil {
+			return "", err
+		}
+	}
+
+	if info, err := os.Stat(filepath.Join(dir, "bin")); err == nil && info.IsDir() {
+		return filepath.Join(dir, "bin"), nil
+	}
+	return dir, nil
+}
+
+// downloadURLPackage downloads the file at downloadURL, verifies its checksum
+// and unpacks it into dir.
+func downloadURLPackage(ctx context.Context, pkg, downloadURL, checksum, dir string) error {
+	parsed, err := url.Parse(downloadURL)
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") {
+		return usererr.New("Package %s must have an http or https URL.", pkg)
+	}
+	if err := os.MkdirAll(filepath.Dir(dir), 0o755); err != nil {
+		return errors.WithStack(err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, downloadURL, nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	res, err := httpclient.Client().Do(req)
+	if err != nil {
+		return usererr.WithUserMessage(err, "Unable to download package %s.", pkg)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return usererr.New("Unable to download package %s: %s", pkg, res.Status)
+	}
+
+	name := path.Base(parsed.Path)
+	tmp, err := os.MkdirTemp(filepath.Dir(dir), ".download-")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	file, err := os.Create(filepath.Join(tmp, name))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	hash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(file, hash), res.Body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return usererr.WithUserMessage(err, "Unable to download package %s.", pkg)
+	}
+
+	got := hex.EncodeToString(hash.Sum(nil))
+	if checksum == "" {
+		return usererr.New(
+			"Package %s needs a SHA-256 checksum. The downloaded file's checksum is %s. "+
+				"Add it to the URL as url:%s or set the package's sha256 field in devbox.json.",
+			pkg, got, pkgtype.JoinURLChecksum(downloadURL, got))
+	}
+	if got != checksum {
+		return usererr.New(
+			"Checksum mismatch for package %s. Expected sha256 %s but the downloaded file has %s.",
+			pkg, checksum, got)
+	}
+
+	unpacked := filepath.Join(tmp, "out")
+	if isArchive(name) {
+		err = registry.Extract(ctx, file.Name(), unpacked)
+	} else {
+		err = installExecutable(file.Name(), unpacked)
+	}
+	if err != nil {
+		return fmt.Errorf("unpack package %s: %w", pkg, err)
+	}
+	// Rename the unpacked files into place last so that an interrupted
+	// install doesn't leave a partial package behind.
+	if err := os.Rename(unpacked, dir); err != nil && !errors.Is(err, os.ErrExist) {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// installExecutable moves the file at src into the directory dst and makes it
+// executable.
+func installExecutable(src, dst string) error {
+	if err := os.MkdirAll(dst, 0o755); err != nil {
+		return err
+	}
+	if err := os.Chmod(src, 0o755); err != nil {
+		return err
+	}
+	return os.Rename(src, filepath.Join(dst, filepath.Base(src)))
+}
+
+func isArchive(name string) bool {
+	name = strings.ToLower(name)
+	for _, ext := range archiveExts {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
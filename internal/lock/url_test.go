@@ -0,0 +1,89 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package lock
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDownloadURLPackage(t *testing.T) {
+	content := []byte("#!/bin/sh\necho hello\n")
+	sum := sha256.Sum256(content)
+	checksum := hex.EncodeToString(sum[:])
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/releases/hello" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(content)
+	}))
+	t.Cleanup(server.Close)
+	url := server.URL + "/releases/hello"
+	pkg := "url:" + url
+
+	t.Run("Executable", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), checksum)
+		if err := downloadURLPackage(context.Background(), pkg, url, checksum, dir); err != nil {
+			t.Fatal(err)
+		}
+		info, err := os.Stat(filepath.Join(dir, "hello"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode()&0o111 == 0 {
+			t.Errorf("got mode %s, want an executable file", info.Mode())
+		}
+	})
+	t.Run("ChecksumMismatch", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "pkg")
+		wrong := strings.Repeat("0", 64)
+		err := downloadURLPackage(context.Background(), pkg, url, wrong, dir)
+		if err == nil || !strings.Contains(err.Error(), "Checksum mismatch") {
+			t.Errorf("got error %v, want a checksum mismatch", err)
+		}
+		if _, err := os.Stat(dir); !os.IsNotExist(err) {
+			t.Errorf("got package directory after a checksum mismatch")
+		}
+	})
+	t.Run("MissingChecksum", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "pkg")
+		err := downloadURLPackage(context.Background(), pkg, url, "", dir)
+		if err == nil || !strings.Contains(err.Error(), checksum) {
+			t.Errorf("got error %v, want it to suggest the checksum %s", err, checksum)
+		}
+	})
+	t.Run("NotFound", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "pkg")
+		err := downloadURLPackage(context.Background(), pkg, server.URL+"/missing", checksum, dir)
+		if err == nil || !strings.Contains(err.Error(), "404") {
+			t.Errorf("got error %v, want a 404 error", err)
+		}
+	})
+}
+
+func TestIsArchive(t *testing.T) {
+	tests := map[string]bool{
+		"tool-linux-amd64.tar.gz": true,
+		"tool.TGZ":                true,
+		"tool.zip":                true,
+		"tool.tar.zst":            true,
+		"tool":                    false,
+		"tool-1.2.3-linux-amd64":  false,
+		"tool.sh":                 false,
+	}
+	for name, want := range tests {
+		if got := isArchive(name); got != want {
+			t.Errorf("isArchive(%q) = %t, want %t", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package nix
+
+import (
+	"go.jetify.com/devbox/nix"
+)
+
+type Flavor = nix.Flavor
+
+const (
+	FlavorNix         = nix.FlavorNix
+	FlavorLix         = nix.FlavorLix
+	FlavorDeterminate = nix.FlavorDeterminate
+)
+
+// Feature is a capability of the nix CLI that devbox uses when it's
+// available and works around when it isn't. Whether a feature is available
+// depends on the nix flavor and its version.
+type Feature string
+
+const (
+	// FeatureStoreJSON is the --json flag of nix store info and ping.
+	FeatureStoreJSON Feature = "store-json"
+
+	// FeatureLockedSystems is support for the per-system store paths that
+	// devbox records in devbox.lock.
+	FeatureLockedSystems Feature = "locked-systems"
+
+	// FeatureStoreInfo is nix store info, which replaces nix store ping.
+	FeatureStoreInfo Feature = "store-info"
+
+	// FeatureFlakeUpdateFlag is the --flake flag of nix flake update. Without
+	// it, nix flake update takes the flake as a positional argument.
+	FeatureFlakeUpdateFlag Feature = "flake-update-flag"
+
+	// FeatureNarHashOnly is support for flake references that have a
+	// narHash without a lastModifiedDate.
+	FeatureNarHashOnly Feature = "narhash-only"
+)
+
+// Features lists every feature in the order that devbox info nix prints them.
+var Features = []Feature{
+	FeatureStoreJSON,
+	FeatureLockedSystems,
+	FeatureStoreInfo,
+	FeatureFlakeUpdateFlag,
+	FeatureNarHashOnly,
+}
+
+// featureVersions are the minimum versions of each flavor that have a
+// feature. A flavor that's missing from a feature's map doesn't have it.
+// Determinate Nix reports the upstream Nix version, so it has the same
+// features as upstream Nix. Lix forked Nix 2.18, so it has the features
+// of Nix 2.18 regardless of its own version.
+var featureVersions = map[Feature]map[Flavor]string{
+	FeatureStoreJSON: {
+		FlavorNix:         Version2_14,
+		FlavorDeterminate: Version2_14,
+		FlavorLix:         Version2_18,
+	},
+	FeatureLockedSystems: {
+		FlavorNix:         Version2_17,
+		FlavorDeterminate: Version2_17,
+		FlavorLix:         Version2_18,
+	},
+	FeatureStoreInfo: {
+		FlavorNix:         Version2_19,
+		FlavorDeterminate: Version2_19,
+	},
+	FeatureFlakeUpdateFlag: {
+		FlavorNix:         Version2_19,
+		FlavorDeterminate: Version2_19,
+	},
+	FeatureNarHashOnly: {
+		FlavorNix:         Version2_25,
+		FlavorDeterminate: Version2_25,
+	},
+}
+
+// Backend is a nix implementation that devbox runs commands with.
+type Backend interface {
+	// Path returns the path to the nix executable.
+	Path() string
+
+	// Info returns the flavor, version and configuration of the backend.
+	Info() Info
+
+	// Supports reports if the backend has a feature.
+	Supports(feature Feature) bool
+}
+
+// CurrentBackend returns the backend of the default nix installation. Its
+// info is empty if nix isn't installed, and it doesn't support any feature.
+func CurrentBackend() Backend {
+	info, _ := Default.Info()
+	return NewBackend(Default.Command().Path, info)
+}
+
+// NewBackend returns the backend of the nix executable at path, as described
+// by info.
+func NewBackend(path string, info Info) Backend {
+	if info.Flavor == "" {
+		info.Flavor = FlavorNix
+	}
+	return backend{path: path, info: info}
+}
+
+// Supports reports if the default nix installation has a feature.
+func Supports(feature Feature) bool {
+	return CurrentBackend().Supports(feature)
+}
+
+type backend struct {
+	path string
+	info Info
+}
+
+func (b backend) Path() string {
+	return b.path
+}
+
+func (b backend) Info() Info {
+	return b.info
+}
+
+func (b backend) Supports(feature Feature) bool {
+	version, ok := featureVersions[feature][b.info.Flavor]
+	return ok && b.info.AtLeast(version)
+}
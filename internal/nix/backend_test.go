@@ -0,0 +1,34 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package nix
+
+import "testing"
+
+func TestBackendSupports(t *testing.T) {
+	tests := []struct {
+		flavor  Flavor
+		version string
+		feature Feature
+		want    bool
+	}{
+		{FlavorNix, "2.18.1", FeatureStoreJSON, true},
+		{FlavorNix, "2.18.1", FeatureStoreInfo, false},
+		{FlavorNix, "2.24.9", FeatureStoreInfo, true},
+		{FlavorNix, "2.24.9", FeatureNarHashOnly, false},
+		{FlavorDeterminate, "2.29.0", FeatureNarHashOnly, true},
+		{FlavorLix, "2.91.1", FeatureLockedSystems, true},
+		{FlavorLix, "2.90.0-beta.1", FeatureStoreJSON, true},
+		{FlavorLix, "2.91.1", FeatureStoreInfo, false},
+		{FlavorLix, "2.91.1", FeatureFlakeUpdateFlag, false},
+		{"", "2.25.0", FeatureNarHashOnly, true},
+		{"", "", FeatureStoreJSON, false},
+	}
+	for _, test := range tests {
+		backend := NewBackend("/nix/bin/nix", Info{Flavor: test.flavor, Version: test.version})
+		if got := backend.Supports(test.feature); got != test.want {
+			t.Errorf("%s %s: got Supports(%s) = %t, want %t",
+				test.flavor, test.version, test.feature, got, test.want)
+		}
+	}
+}
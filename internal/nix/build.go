@@ -16,6 +16,10 @@ type BuildArgs struct {
 	ExtraSubstituters []string
 	Flags             []string
 	Writer            io.Writer
+
+	// LogDir is the directory to save the log of each build in. Builds
+	// aren't logged if it's empty.
+	LogDir string
 }
 
 func Build(ctx context.Context, args *BuildArgs, installables ...string) error {
@@ -41,11 +45,17 @@ func Build(ctx context.Context, args *BuildArgs, installables ...string) error {
 		cmd.Env = allowInsecureEnv(cmd.Env)
 	}
 
-	// If nix build runs as tty, the output is much nicer. If we ever
-	// need to change this to our own writers, consider that you may need
-	// to implement your own nicer output. --print-build-logs flag may be useful.
+	// Nix logs in its internal-json format, which the progress writer
+	// renders as a status line or JSON depending on the context.
+	progress := newProgressWriterFromContext(ctx, args.Writer)
+	if args.LogDir != "" {
+		progress.logs = newBuildLogger(args.LogDir)
+	}
+	cmd.Args = append(cmd.Args, progress.Args()...)
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = args.Writer
-	cmd.Stderr = args.Writer
-	return cmd.Run(ctx)
+	cmd.Stderr = progress
+	err := cmd.Run(ctx)
+	progress.Flush()
+	return err
 }
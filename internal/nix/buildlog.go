@@ -0,0 +1,172 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package nix
+
+import (
+	"cmp"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// buildLogsPerDerivation is how many logs are kept for each derivation. Older
+// logs are deleted when a new build starts.
+const buildLogsPerDerivation = 5
+
+// buildLogTimeFormat names log files so that they sort by time.
+const buildLogTimeFormat = "20060102T150405.000000000Z"
+
+const failedBuildLogSuffix = "-failed.log"
+
+// builderFailedRegexp matches the error nix reports when a build fails.
+var builderFailedRegexp = regexp.MustCompile(`builder for '([^']+\.drv)' failed`)
+
+// BuildLog is the log of a nix build.
+type BuildLog struct {
+	// Name is the name of the derivation, such as hello-2.12.1.
+	Name   string    `json:"name"`
+	Path   string    `json:"path"`
+	Time   time.Time `json:"time"`
+	Failed bool      `json:"failed"`
+}
+
+// buildLogger writes the log lines of each build to a file in dir, at
+// <dir>/<derivation name>/<time>.log. The file of a failed build is renamed
+// to <time>-failed.log.
+type buildLogger struct {
+	dir string
+	now func() time.Time
+
+	// files are the open logs of builds in progress, by activity ID.
+	files map[int64]*os.File
+
+	// paths are the logs of the builds that started, by derivation path,
+	// so that they can be marked as failed after the build stops.
+	paths map[string]string
+}
+
+func newBuildLogger(dir string) *buildLogger {
+	return &buildLogger{
+		dir:   dir,
+		now:   time.Now,
+		files: map[int64]*os.File{},
+		paths: map[string]string{},
+	}
+}
+
+// start creates the log of a build. Logging is best effort, so errors only
+// mean that the build isn't logged.
+func (l *buildLogger) start(id int64, drvPath string) {
+	dir := filepath.Join(l.dir, StorePathName(drvPath))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return
+	}
+	pruneBuildLogs(dir, buildLogsPerDerivation-1)
+	path := filepath.Join(dir, l.now().UTC().Format(buildLogTimeFormat)+".log")
+	f, err := os.Create(path)
+	if err != nil {
+		return
+	}
+	l.files[id] = f
+	l.paths[drvPath] = path
+}
+
+func (l *buildLogger) line(id int64, line string) {
+	if f := l.files[id]; f != nil {
+		_, _ = f.WriteString(ansiRegexp.ReplaceAllString(line, "") + "\n")
+	}
+}
+
+func (l *buildLogger) stop(id int64) {
+	if f := l.files[id]; f != nil {
+		_ = f.Close()
+		delete(l.files, id)
+	}
+}
+
+// error marks the log of a build as failed if msg is nix's error for it.
+func (l *buildLogger) error(msg string) {
+	match := builderFailedRegexp.FindStringSubmatch(ansiRegexp.ReplaceAllString(msg, ""))
+	if match == nil {
+		return
+	}
+	if path, ok := l.paths[match[1]]; ok {
+		_ = os.Rename(path, strings.TrimSuffix(path, ".log")+failedBuildLogSuffix)
+		delete(l.paths, match[1])
+	}
+}
+
+// close closes the logs of builds that didn't stop, such as when nix is
+// interrupted.
+func (l *buildLogger) close() {
+	for id := range l.files {
+		l.stop(id)
+	}
+}
+
+// pruneBuildLogs deletes all but the newest keep logs in dir.
+func pruneBuildLogs(dir string, keep int) {
+	logs := readBuildLogs(dir)
+	for _, log := range logs[min(keep, len(logs)):] {
+		_ = os.Remove(log.Path)
+	}
+}
+
+// readBuildLogs returns the logs of a derivation, newest first.
+func readBuildLogs(dir string) []BuildLog {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	logs := []BuildLog{}
+	for _, entry := range entries {
+		name := entry.Name()
+		stamp, failed := strings.CutSuffix(name, failedBuildLogSuffix)
+		if !failed {
+			var ok bool
+			if stamp, ok = strings.CutSuffix(name, ".log"); !ok {
+				continue
+			}
+		}
+		t, err := time.Parse(buildLogTimeFormat, stamp)
+		if err != nil {
+			continue
+		}
+		logs = append(logs, BuildLog{
+			Name:   filepath.Base(dir),
+			Path:   filepath.Join(dir, name),
+			Time:   t,
+			Failed: failed,
+		})
+	}
+	slices.SortFunc(logs, func(a, b BuildLog) int { return b.Time.Compare(a.Time) })
+	return logs
+}
+
+// BuildLogs returns the build logs in dir, newest first.
+func BuildLogs(dir string) ([]BuildLog, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	logs := []BuildLog{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			logs = append(logs, readBuildLogs(filepath.Join(dir, entry.Name()))...)
+		}
+	}
+	slices.SortStableFunc(logs, func(a, b BuildLog) int {
+		return cmp.Or(b.Time.Compare(a.Time), strings.Compare(a.Name, b.Name))
+	})
+	return logs, nil
+}
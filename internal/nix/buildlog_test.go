@@ -0,0 +1,76 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package nix
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testBuildLog = `@nix {"action":"start","id":5,"level":3,"type":105,"text":"building","fields":["/nix/store/pkbgcyzfbn0mz5j2rvsizkgv4vdpw5b7-my-tool-1.0.drv","",1,1]}
+@nix {"action":"result","id":5,"type":101,"fields":["compiling main.c"]}
+@nix {"action":"result","id":5,"type":101,"fields":["\u001b[31mmain.c:3: error: expected ';'\u001b[0m"]}
+@nix {"action":"stop","id":5}
+`
+
+const testBuildFailed = `@nix {"action":"msg","level":0,"msg":"\u001b[31;1merror:\u001b[0m builder for '\u001b[35;1m/nix/store/pkbgcyzfbn0mz5j2rvsizkgv4vdpw5b7-my-tool-1.0.drv\u001b[0m' failed with exit code 1"}
+`
+
+func TestBuildLogger(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for i := range buildLogsPerDerivation + 1 {
+		p := newProgressWriter(ProgressQuiet, &bytes.Buffer{})
+		p.logs = newBuildLogger(dir)
+		p.logs.now = func() time.Time { return start.Add(time.Duration(i) * time.Minute) }
+		log := testBuildLog
+		if i == buildLogsPerDerivation {
+			// Only the last build fails.
+			log += testBuildFailed
+		}
+		if _, err := p.Write([]byte(log)); err != nil {
+			t.Fatal(err)
+		}
+		p.Flush()
+	}
+
+	logs, err := BuildLogs(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != buildLogsPerDerivation {
+		t.Fatalf("got %d logs, want %d", len(logs), buildLogsPerDerivation)
+	}
+	last := logs[0]
+	if last.Name != "my-tool-1.0" || !last.Failed || !last.Time.Equal(start.Add(buildLogsPerDerivation*time.Minute)) {
+		t.Errorf("got last log %+v, want the failed build of my-tool-1.0", last)
+	}
+	if logs[1].Failed {
+		t.Errorf("got log %+v failed, want it successful", logs[1])
+	}
+	if oldest := logs[len(logs)-1]; !oldest.Time.Equal(start.Add(time.Minute)) {
+		t.Errorf("got oldest log at %s, want the first log pruned", oldest.Time)
+	}
+
+	got, err := os.ReadFile(last.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "compiling main.c\nmain.c:3: error: expected ';'\n"; string(got) != want {
+		t.Errorf("got log %q, want %q", got, want)
+	}
+	if filepath.Dir(last.Path) != filepath.Join(dir, "my-tool-1.0") {
+		t.Errorf("got log path %s, want it in %s", last.Path, filepath.Join(dir, "my-tool-1.0"))
+	}
+}
+
+func TestBuildLogsMissingDir(t *testing.T) {
+	logs, err := BuildLogs(filepath.Join(t.TempDir(), "missing"))
+	if err != nil || len(logs) != 0 {
+		t.Errorf("got %v, %v, want no logs", logs, err)
+	}
+}
@@ -0,0 +1,90 @@
+package nix
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+
+	"github.com/pkg/errors"
+	"go.jetify.com/devbox/nix/flake"
+)
+
+// LocalBinaryCache returns the URL of a binary cache in a local directory.
+// Its NARs aren't compressed, so that they compress well as part of a larger
+// archive.
+func LocalBinaryCache(dir string) string {
+	return "file://" + dir + "?compression=none"
+}
+
+// CopyClosures copies store paths and the store paths that they depend on
+// from one store to another. An empty from or to is the local store.
+// noCheckSigs copies paths that aren't signed by a trusted key, which
+// requires the user to be trusted by the nix daemon.
+func CopyClosures(
+	ctx context.Context,
+	out io.Writer,
+	from, to string,
+	noCheckSigs bool,
+	storePaths []string,
+) error {
+	cmd := Command("copy")
+	if from != "" {
+		cmd.Args = append(cmd.Args, "--from", from)
+	}
+	if to != "" {
+		cmd.Args = append(cmd.Args, "--to", to)
+	}
+	if noCheckSigs {
+		cmd.Args = append(cmd.Args, "--no-check-sigs")
+	}
+	cmd.Args = appendArgs(cmd.Args, storePaths)
+	cmd.Stdout = out
+	cmd.Stderr = out
+	return cmd.Run(ctx)
+}
+
+// ArchiveFlake copies the flake in dir and all of its inputs, such as
+// nixpkgs, to a store. It returns the store paths of the flake and its inputs.
+func ArchiveFlake(ctx context.Context, dir, to string) ([]string, error) {
+	resolved, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	ref := flake.Ref{Type: flake.TypePath, Path: resolved}
+	cmd := Command("flake", "archive", "--json", "--to", to, ref)
+	out, err := cmd.Output(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return parseFlakeArchiveOutput(out)
+}
+
+type flakeArchive struct {
+	Path   string                  `json:"path"`
+	Inputs map[string]flakeArchive `json:"inputs"`
+}
+
+// parseFlakeArchiveOutput returns the store paths in the output of
+// `nix flake archive --json`, which is a tree of paths and inputs.
+func parseFlakeArchiveOutput(output []byte) ([]string, error) {
+	archive := flakeArchive{}
+	if err := json.Unmarshal(output, &archive); err != nil {
+		return nil, fmt.Errorf("failed to parse flake archive output: %w", err)
+	}
+	paths := []string{}
+	var walk func(a flakeArchive)
+	walk = func(a flakeArchive) {
+		if a.Path != "" && !slices.Contains(paths, a.Path) {
+			paths = append(paths, a.Path)
+		}
+		for _, input := range a.Inputs {
+			walk(input)
+		}
+	}
+	walk(archive)
+	slices.Sort(paths)
+	return paths, nil
+}
@@ -0,0 +1,43 @@
+package nix
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseFlakeArchiveOutput(t *testing.T) {
+	output := []byte(`{
+		"path": "/nix/store/aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa-source",
+		"inputs": {
+			"nixpkgs": {
+				"path": "/nix/store/bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb-source",
+				"inputs": {}
+			},
+			"nixpkgs-unstable": {
+				"path": "/nix/store/cccccccccccccccccccccccccccccccc-source",
+				"inputs": {
+					"nixpkgs": {
+						"path": "/nix/store/bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb-source",
+						"inputs": {}
+					}
+				}
+			}
+		}
+	}`)
+	got, err := parseFlakeArchiveOutput(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"/nix/store/aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa-source",
+		"/nix/store/bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb-source",
+		"/nix/store/cccccccccccccccccccccccccccccccc-source",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("parseFlakeArchiveOutput() = %v, want %v", got, want)
+	}
+
+	if _, err := parseFlakeArchiveOutput([]byte("not json")); err == nil {
+		t.Error("parseFlakeArchiveOutput() got nil error for invalid output, want error")
+	}
+}
@@ -1,6 +1,9 @@
 package nix
 
-import "os"
+import (
+	"os"
+	"time"
+)
 
 func init() {
 	Default.ExtraArgs = Args{
@@ -30,3 +33,25 @@ func allowUnfreeEnv(curEnv []string) []string {
 func allowInsecureEnv(curEnv []string) []string {
 	return append(curEnv, "NIXPKGS_ALLOW_INSECURE=1")
 }
+
+// SetEvalCache makes every nix command use nix's evaluation cache, or not,
+// regardless of the eval-cache setting in nix.conf.
+func SetEvalCache(enabled bool) {
+	value := "false"
+	if enabled {
+		value = "true"
+	}
+	for i := 0; i+2 < len(Default.ExtraArgs); i++ {
+		if Default.ExtraArgs[i] == "--option" && Default.ExtraArgs[i+1] == "eval-cache" {
+			Default.ExtraArgs[i+2] = value
+			return
+		}
+	}
+	Default.ExtraArgs = append(Default.ExtraArgs, "--option", "eval-cache", value)
+}
+
+// SetTimeout sets the maximum time that each nix command may run before it's
+// interrupted. Zero means no limit.
+func SetTimeout(d time.Duration) {
+	Default.Timeout = d
+}
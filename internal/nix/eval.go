@@ -1,15 +1,29 @@
 package nix
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"os"
+	"regexp"
 	"strconv"
+	"strings"
 )
 
-func EvalPackageName(path string) (string, error) {
+func EvalPackageName(ctx context.Context, path string) (string, error) {
 	cmd := Command("eval", "--raw", path+".name")
-	out, err := cmd.Output(context.TODO())
+	out, err := cmd.Output(ctx)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// EvalPackageVersion returns the version attribute of the package at path.
+func EvalPackageVersion(ctx context.Context, path string) (string, error) {
+	cmd := Command("eval", "--raw", path+".version")
+	out, err := cmd.Output(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -17,9 +31,9 @@ func EvalPackageName(path string) (string, error) {
 }
 
 // PackageIsInsecure is a fun little nix eval that maybe works.
-func PackageIsInsecure(path string) bool {
+func PackageIsInsecure(ctx context.Context, path string) bool {
 	cmd := Command("eval", path+".meta.insecure")
-	out, err := cmd.Output(context.TODO())
+	out, err := cmd.Output(ctx)
 	if err != nil {
 		// We can't know for sure, but probably not.
 		return false
@@ -32,9 +46,9 @@ func PackageIsInsecure(path string) bool {
 	return insecure
 }
 
-func PackageKnownVulnerabilities(path string) []string {
+func PackageKnownVulnerabilities(ctx context.Context, path string) []string {
 	cmd := Command("eval", path+".meta.knownVulnerabilities")
-	out, err := cmd.Output(context.TODO())
+	out, err := cmd.Output(ctx)
 	if err != nil {
 		// We can't know for sure, but probably not.
 		return nil
@@ -47,15 +61,203 @@ func PackageKnownVulnerabilities(path string) []string {
 	return vulnerabilities
 }
 
+// PackageLicense is the license of a package, according to its meta
+// attributes.
+type PackageLicense struct {
+	Unfree bool `json:"unfree"`
+
+	// Licenses are the SPDX identifiers of the package's licenses, or their
+	// short names if they don't have one.
+	Licenses []string `json:"licenses"`
+}
+
+// licenseLetExpr binds ls to the licenses of the package p, which nixpkgs
+// allows to be a single license or a list.
+const licenseLetExpr = `l = p.meta.license or []; ls = if builtins.isList l then l else [ l ]; `
+
+// licenseFieldsExpr are the fields of a PackageLicense.
+const licenseFieldsExpr = `unfree = p.meta.unfree or (builtins.any (x: !(x.free or true)) ls); ` +
+	`licenses = map (x: if builtins.isString x then x else x.spdxId or x.shortName or x.fullName or "unknown") ls; `
+
+const packageLicenseExpr = `p: let ` + licenseLetExpr + `in { ` + licenseFieldsExpr + `}`
+
+// EvalPackageLicense evaluates whether the package at installable has an
+// unfree license, without building or downloading it.
+func EvalPackageLicense(ctx context.Context, installable string) (PackageLicense, error) {
+	// --impure for NIXPKGS_ALLOW_UNFREE
+	cmd := Command("eval", "--json", "--impure", FixInstallableArg(installable), "--apply", packageLicenseExpr)
+	cmd.Env = allowUnfreeEnv(allowInsecureEnv(os.Environ()))
+	out, err := cmd.Output(ctx)
+	if err != nil {
+		return PackageLicense{}, err
+	}
+	license := PackageLicense{}
+	if err := json.Unmarshal(out, &license); err != nil {
+		return PackageLicense{}, fmt.Errorf("failed to parse the license of %s: %w", installable, err)
+	}
+	return license, nil
+}
+
+// PackageMeta is the metadata of a package that devbox info displays.
+type PackageMeta struct {
+	PackageLicense
+
+	Description string `json:"description"`
+	Homepage    string `json:"homepage"`
+	Changelog   string `json:"changelog"`
+}
+
+const packageMetaExpr = `p: let ` + licenseLetExpr +
+	`h = p.meta.homepage or ""; ` +
+	`in { ` + licenseFieldsExpr +
+	`description = p.meta.description or ""; ` +
+	`homepage = if builtins.isList h then (if h == [] then "" else builtins.head h) else h; ` +
+	`changelog = p.meta.changelog or ""; }`
+
+// EvalPackageMeta evaluates the description, homepage, changelog and license
+// of the package at installable, without building or downloading it.
+func EvalPackageMeta(ctx context.Context, installable string) (PackageMeta, error) {
+	// --impure for NIXPKGS_ALLOW_UNFREE
+	cmd := Command("eval", "--json", "--impure", FixInstallableArg(installable), "--apply", packageMetaExpr)
+	cmd.Env = allowUnfreeEnv(allowInsecureEnv(os.Environ()))
+	out, err := cmd.Output(ctx)
+	if err != nil {
+		return PackageMeta{}, err
+	}
+	meta := PackageMeta{}
+	if err := json.Unmarshal(out, &meta); err != nil {
+		return PackageMeta{}, fmt.Errorf("failed to parse the meta attributes of %s: %w", installable, err)
+	}
+	return meta, nil
+}
+
 // Eval is raw nix eval. Needs to be parsed. Useful for stuff like
 // nix eval --raw nixpkgs/9ef09e06806e79e32e30d17aee6879d69c011037#fuse3
 // to determine if a package if a package can be installed in system.
-func Eval(path string) ([]byte, error) {
+func Eval(ctx context.Context, path string) ([]byte, error) {
 	cmd := Command("eval", "--raw", path)
-	return cmd.CombinedOutput(context.TODO())
+	return cmd.CombinedOutput(ctx)
 }
 
 func IsInsecureAllowed() bool {
 	allowed, _ := strconv.ParseBool(os.Getenv("NIXPKGS_ALLOW_INSECURE"))
 	return allowed
 }
+
+// PackageOutput is an output of a package and its store path.
+type PackageOutput struct {
+	Name    string `json:"name"`
+	Path    string `json:"path"`
+	Default bool   `json:"default"`
+}
+
+// packageOutputsExpr maps a derivation to its outputs, marking the ones that
+// nix installs by default.
+const packageOutputsExpr = `p: let defaults = p.meta.outputsToInstall or [ (builtins.head p.outputs) ]; ` +
+	`in map (o: { name = o; path = p.${o}.outPath; default = builtins.elem o defaults; }) p.outputs`
+
+// EvalPackageOutputs evaluates the store paths of a package's outputs without
+// building or downloading them, so it works for packages of other systems,
+// such as github:NixOS/nixpkgs/<rev>#legacyPackages.aarch64-darwin.go.
+func EvalPackageOutputs(ctx context.Context, installable string, allowInsecure bool) ([]PackageOutput, error) {
+	// --impure for NIXPKGS_ALLOW_UNFREE
+	cmd := Command("eval", "--json", "--impure", FixInstallableArg(installable), "--apply", packageOutputsExpr)
+	cmd.Env = allowUnfreeEnv(os.Environ())
+	if allowInsecure {
+		cmd.Env = allowInsecureEnv(cmd.Env)
+	}
+	out, err := cmd.Output(ctx)
+	if err != nil {
+		return nil, err
+	}
+	outputs := []PackageOutput{}
+	if err := json.Unmarshal(out, &outputs); err != nil {
+		return nil, fmt.Errorf("failed to parse the outputs of %s: %w", installable, err)
+	}
+	return outputs, nil
+}
+
+// PackageStatus is whether a package can still be installed from a nixpkgs
+// revision.
+type PackageStatus struct {
+	Broken               bool     `json:"broken"`
+	Insecure             bool     `json:"insecure"`
+	KnownVulnerabilities []string `json:"known_vulnerabilities"`
+
+	// Removed is true if the attribute no longer exists or nixpkgs throws
+	// an error that it was removed.
+	Removed bool `json:"-"`
+
+	// RenamedTo is the attribute that replaced the package, according to
+	// the nixpkgs aliases. The old name may still work as an alias.
+	RenamedTo string `json:"-"`
+
+	// Message is nixpkgs' explanation of why the package was removed or
+	// renamed.
+	Message string `json:"-"`
+}
+
+// OK reports whether there's nothing to tell the user about the package.
+func (s PackageStatus) OK() bool {
+	return !s.Broken && !s.Insecure && !s.Removed && s.RenamedTo == ""
+}
+
+const packageStatusExpr = `p: { broken = p.meta.broken or false; insecure = p.meta.insecure or false; ` +
+	`known_vulnerabilities = p.meta.knownVulnerabilities or []; }`
+
+var (
+	// renamedRegexp matches the messages of aliases in nixpkgs'
+	// pkgs/top-level/aliases.nix, such as "'foo' has been renamed to 'bar'".
+	renamedRegexp = regexp.MustCompile(`has been (?:renamed to|replaced by|superseded by) ['‘"]?([A-Za-z0-9_.+-]+[A-Za-z0-9_+-])`)
+	removedRegexp = regexp.MustCompile(`has been removed|does not provide attribute|attribute '[^']+' missing`)
+)
+
+// EvalPackageStatus evaluates whether the package at installable is broken,
+// insecure, removed or renamed, without building or downloading it.
+func EvalPackageStatus(ctx context.Context, installable string) (PackageStatus, error) {
+	// --impure for NIXPKGS_ALLOW_UNFREE
+	cmd := Command("eval", "--json", "--impure", FixInstallableArg(installable), "--apply", packageStatusExpr)
+	cmd.Env = allowUnfreeEnv(os.Environ())
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	out, err := cmd.Output(ctx)
+
+	status := parsePackageStatusMessage(stderr.String())
+	if err != nil {
+		if status.Removed || status.RenamedTo != "" {
+			// Aliases that throw can't be used anymore, even if they
+			// name a replacement.
+			status.Removed = true
+			return status, nil
+		}
+		return status, fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	if err := json.Unmarshal(out, &status); err != nil {
+		return status, fmt.Errorf("failed to parse the status of %s: %w", installable, err)
+	}
+	return status, nil
+}
+
+// parsePackageStatusMessage looks for the messages that nixpkgs prints for
+// removed and renamed packages in the stderr of nix eval.
+func parsePackageStatusMessage(stderr string) PackageStatus {
+	status := PackageStatus{}
+	for _, line := range strings.Split(stderr, "\n") {
+		line = strings.TrimSpace(line)
+		renamed := renamedRegexp.FindStringSubmatch(line)
+		removed := removedRegexp.MatchString(line)
+		if renamed == nil && !removed {
+			continue
+		}
+		if renamed != nil {
+			status.RenamedTo = renamed[1]
+		}
+		status.Removed = status.Removed || removed
+		for _, prefix := range []string{"error:", "evaluation warning:", "trace: warning:", "warning:"} {
+			line = strings.TrimSpace(strings.TrimPrefix(line, prefix))
+		}
+		status.Message = line
+		break
+	}
+	return status
+}
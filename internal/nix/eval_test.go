@@ -0,0 +1,53 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package nix
+
+import "testing"
+
+func TestParsePackageStatusMessage(t *testing.T) {
+	cases := []struct {
+		name   string
+		stderr string
+		want   PackageStatus
+	}{
+		{
+			name:   "renamed alias warning",
+			stderr: "evaluation warning: 'nodejs-16_x' has been renamed to 'nodejs_16'\n",
+			want: PackageStatus{
+				RenamedTo: "nodejs_16",
+				Message:   "'nodejs-16_x' has been renamed to 'nodejs_16'",
+			},
+		},
+		{
+			name: "removed throw",
+			stderr: "error:\n       … while evaluating the attribute 'python2'\n\n" +
+				"       error: python2 has been removed because it reached its end of life\n",
+			want: PackageStatus{
+				Removed: true,
+				Message: "python2 has been removed because it reached its end of life",
+			},
+		},
+		{
+			name:   "missing attribute",
+			stderr: "error: flake 'github:NixOS/nixpkgs/abc' does not provide attribute 'legacyPackages.x86_64-linux.foo'\n",
+			want: PackageStatus{
+				Removed: true,
+				Message: "flake 'github:NixOS/nixpkgs/abc' does not provide attribute 'legacyPackages.x86_64-linux.foo'",
+			},
+		},
+		{
+			name:   "unrelated output",
+			stderr: "warning: unknown setting 'foo'\n",
+			want:   PackageStatus{},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := parsePackageStatusMessage(tc.stderr)
+			if got.Removed != tc.want.Removed || got.RenamedTo != tc.want.RenamedTo || got.Message != tc.want.Message {
+				t.Errorf("got %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
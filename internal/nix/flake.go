@@ -5,13 +5,14 @@
 	"encoding/json"
 	"time"
 
+	"go.jetify.com/devbox/internal/state"
 	"go.jetify.com/devbox/nix/flake"
 	"go.jetify.com/pkg/filecache"
 )
 
 const flakeCacheTTL = time.Hour * 24 * 30
 
-var flakeFileCache = filecache.New[FlakeMetadata]("devbox/flakes")
+var flakeFileCache = filecache.New("devbox/flakes", filecache.WithCacheDir[FlakeMetadata](state.FileCacheDir()))
 
 type FlakeMetadata struct {
 	Description  string    `json:"description"`
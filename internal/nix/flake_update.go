@@ -8,15 +8,14 @@
 	"os"
 
 	"go.jetify.com/devbox/internal/ux"
-	"go.jetify.com/devbox/nix"
 )
 
-func FlakeUpdate(ProfileDir string) error {
+func FlakeUpdate(ctx context.Context, ProfileDir string) error {
 	ux.Finfof(os.Stderr, "Running \"nix flake update\"\n")
 	cmd := Command("flake", "update")
-	if nix.AtLeast(Version2_19) {
+	if Supports(FeatureFlakeUpdateFlag) {
 		cmd.Args = append(cmd.Args, "--flake")
 	}
 	cmd.Args = append(cmd.Args, ProfileDir)
-	return cmd.Run(context.TODO())
+	return cmd.Run(ctx)
 }
@@ -9,6 +9,7 @@
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/briandowns/spinner"
@@ -18,6 +19,7 @@
 	"go.jetify.com/devbox/internal/boxcli/usererr"
 	"go.jetify.com/devbox/internal/cmdutil"
 	"go.jetify.com/devbox/internal/fileutil"
+	"go.jetify.com/devbox/internal/state"
 	"go.jetify.com/devbox/nix"
 )
 
@@ -75,36 +77,63 @@ func EnsureNixInstalled(ctx context.Context, writer io.Writer, withDaemonFunc fu
 	}
 
 	color.Yellow("\nNix is not installed. Devbox will attempt to install it.\n\n")
-
-	installer := nix.Installer{}
 	if isatty.IsTerminal(os.Stdout.Fd()) {
 		color.Yellow("Press enter to continue or ctrl-c to exit.\n")
 		fmt.Scanln() //nolint:errcheck
+	}
+	return Install(ctx, writer)
+}
 
+// Install downloads and runs the Nix installer without asking for
+// confirmation, and writes its progress to writer.
+func Install(ctx context.Context, writer io.Writer) error {
+	installer := nix.Installer{}
+	if isatty.IsTerminal(os.Stdout.Fd()) {
 		spinny := spinner.New(spinner.CharSets[11], 100*time.Millisecond, spinner.WithWriter(writer))
 		spinny.Suffix = " Downloading the Nix installer..."
 		spinny.Start()
 		defer spinny.Stop() // reset the terminal in case of a panic
 
-		err = installer.Download(ctx)
+		err := installer.Download(ctx)
 		if err != nil {
 			return err
 		}
 		spinny.Stop()
 	} else {
 		fmt.Fprint(writer, "Downloading the Nix installer...")
-		err = installer.Download(ctx)
+		err := installer.Download(ctx)
 		if err != nil {
 			fmt.Fprintln(writer)
 			return err
 		}
 		fmt.Fprintln(writer, " done.")
 	}
-	err = installer.Run(ctx)
-	if err != nil {
+	if err := installer.Run(ctx); err != nil {
 		return err
 	}
+	markInstalledByDevbox()
 
 	fmt.Fprintln(writer, "Nix installed successfully. Devbox is ready to use!")
 	return nil
 }
+
+// installedByDevboxPath is a file that records that devbox installed nix, so
+// that devbox uninstall knows that it may uninstall it.
+func installedByDevboxPath() string {
+	return state.Data("nix-installed")
+}
+
+func markInstalledByDevbox() {
+	path := installedByDevboxPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return
+	}
+	_ = os.WriteFile(path, []byte(time.Now().UTC().Format(time.RFC3339)+"\n"), 0o644)
+}
+
+// InstalledByDevbox returns true if devbox installed the current nix
+// installation.
+func InstalledByDevbox() bool {
+	_, err := os.Stat(installedByDevboxPath())
+	return err == nil && BinaryInstalled()
+}
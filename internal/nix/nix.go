@@ -30,6 +30,7 @@
 // ProfilePath contains the contents of the profile generated via `nix-env --profile ProfilePath <command>`
 // or `nix profile install --profile ProfilePath <package...>`
 // Instead of using directory, prefer using the devbox.ProfileDir() function that ensures the directory exists.
+// Shared projects have a profile for each user instead (see ProjectProfilePath).
 const ProfilePath = ".devbox/nix/profile/default"
 
 type PrintDevEnvOut struct {
@@ -82,7 +83,7 @@ func (*NixInstance) PrintDevEnv(ctx context.Context, args *PrintDevEnvArgs) (*Pr
 		cmd.Args = append(cmd.Args, ref)
 		slog.Debug("running print-dev-env cmd", "cmd", cmd)
 		data, err = cmd.Output(ctx)
-		if insecure, insecureErr := IsExitErrorInsecurePackage(err, "" /*pkgName*/, "" /*installable*/); insecure {
+		if insecure, insecureErr := IsExitErrorInsecurePackage(ctx, err, "" /*pkgName*/, "" /*installable*/); insecure {
 			return nil, insecureErr
 		} else if err != nil {
 			return nil, err
@@ -100,6 +101,30 @@ func (*NixInstance) PrintDevEnv(ctx context.Context, args *PrintDevEnvArgs) (*Pr
 	return &out, nil
 }
 
+// WarmEvalCache evaluates the devShell of the flake in flakeDir with nix's
+// evaluation cache enabled, so that later evaluations of the same flake,
+// such as print-dev-env, can reuse it. It returns the devShell's derivation
+// path.
+func WarmEvalCache(ctx context.Context, flakeDir string) (string, error) {
+	defer debug.FunctionTimer().End()
+	defer trace.StartRegion(ctx, "nixWarmEvalCache").End()
+
+	flakeDirResolved, err := filepath.EvalSymlinks(flakeDir)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	ref := flake.Ref{Type: flake.TypePath, Path: flakeDirResolved}
+	cmd := Command("eval", "--raw", "--option", "eval-cache", "true",
+		fmt.Sprintf("%s#devShells.%s.default.drvPath", ref, System()))
+	out, err := cmd.Output(ctx)
+	if insecure, insecureErr := IsExitErrorInsecurePackage(ctx, err, "" /*pkgName*/, "" /*installable*/); insecure {
+		return "", insecureErr
+	} else if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
 func savePrintDevEnvCache(path string, out PrintDevEnvOut) error {
 	data, err := json.Marshal(out)
 	if err != nil {
@@ -165,10 +190,10 @@ func EnsureValidPlatform(platforms ...string) error {
 // Warning: be careful using the bins in default/bin, they won't always match bins
 // produced by the flakes.nix. Use devbox.NixBins() instead.
 func ProfileBinPath(projectDir string) string {
-	return filepath.Join(projectDir, ProfilePath, "bin")
+	return filepath.Join(ProjectProfilePath(projectDir), "bin")
 }
 
-func IsExitErrorInsecurePackage(err error, pkgNameOrEmpty, installableOrEmpty string) (bool, error) {
+func IsExitErrorInsecurePackage(ctx context.Context, err error, pkgNameOrEmpty, installableOrEmpty string) (bool, error) {
 	var exitErr *exec.ExitError
 	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
 		if strings.Contains(string(exitErr.Stderr), "is marked as insecure") {
@@ -177,7 +202,7 @@ func IsExitErrorInsecurePackage(err error, pkgNameOrEmpty, installableOrEmpty st
 
 			knownVulnerabilities := []string{}
 			if installableOrEmpty != "" {
-				knownVulnerabilities = PackageKnownVulnerabilities(installableOrEmpty)
+				knownVulnerabilities = PackageKnownVulnerabilities(ctx, installableOrEmpty)
 			}
 
 			insecurePackages := parseInsecurePackagesFromExitError(string(exitErr.Stderr))
@@ -261,13 +286,13 @@ func restartDaemon(ctx context.Context) error {
 }
 
 // FixInstallableArgs removes the narHash and lastModifiedDate query parameters
-// from any args that are valid installables and the Nix version is <2.25.
-// Otherwise it returns them unchanged.
+// from any args that are valid installables and nix doesn't support a narHash
+// without a lastModifiedDate. Otherwise it returns them unchanged.
 //
 // This fixes an issues with some older versions of Nix where specifying a
 // narHash without a lastModifiedDate results in an error.
 func FixInstallableArgs(args []string) {
-	if AtLeast(Version2_25) {
+	if Supports(FeatureNarHashOnly) {
 		return
 	}
 
@@ -1,6 +1,7 @@
 package nix
 
 import (
+	"slices"
 	"testing"
 )
 
@@ -68,3 +69,19 @@ func TestParseInsecurePackagesFromExitError(t *testing.T) {
 		t.Errorf("Expected package 'python-2.7.18.7', got %s", packages[0])
 	}
 }
+
+func TestSetEvalCache(t *testing.T) {
+	saved := Default.ExtraArgs
+	t.Cleanup(func() { Default.ExtraArgs = saved })
+	Default.ExtraArgs = Args{"--option", "experimental-features", "nix-command flakes"}
+
+	SetEvalCache(true)
+	SetEvalCache(false)
+	want := Args{
+		"--option", "experimental-features", "nix-command flakes",
+		"--option", "eval-cache", "false",
+	}
+	if !slices.Equal(Default.ExtraArgs, want) {
+		t.Errorf("got ExtraArgs %v, want %v", Default.ExtraArgs, want)
+	}
+}
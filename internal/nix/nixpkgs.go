@@ -17,11 +17,11 @@
 	"github.com/fatih/color"
 	"github.com/pkg/errors"
 	"go.jetify.com/devbox/internal/fileutil"
-	"go.jetify.com/devbox/internal/xdg"
+	"go.jetify.com/devbox/internal/state"
 )
 
 // EnsureNixpkgsPrefetched runs the prefetch step to download the flake of the registry
-func EnsureNixpkgsPrefetched(w io.Writer, commit string) error {
+func EnsureNixpkgsPrefetched(ctx context.Context, w io.Writer, commit string) error {
 	// Look up the cached map of commitHash:nixStoreLocation
 	commitToLocation, err := nixpkgsCommitFileContents()
 	if err != nil {
@@ -44,7 +44,7 @@ func EnsureNixpkgsPrefetched(w io.Writer, commit string) error {
 	)
 	cmd.Stdout = w
 	cmd.Stderr = cmd.Stdout
-	if err := cmd.Run(context.TODO()); err != nil {
+	if err := cmd.Run(ctx); err != nil {
 		fmt.Fprintf(w, "Ensuring nixpkgs registry is downloaded: ")
 		color.New(color.FgRed).Fprintf(w, "Fail\n")
 		return err
@@ -52,7 +52,7 @@ func EnsureNixpkgsPrefetched(w io.Writer, commit string) error {
 	fmt.Fprintf(w, "Ensuring nixpkgs registry is downloaded: ")
 	color.New(color.FgGreen).Fprintf(w, "Success\n")
 
-	return saveToNixpkgsCommitFile(commit, commitToLocation)
+	return saveToNixpkgsCommitFile(ctx, commit, commitToLocation)
 }
 
 func nixpkgsCommitFileContents() (map[string]string, error) {
@@ -70,12 +70,12 @@ func nixpkgsCommitFileContents() (map[string]string, error) {
 	return commitToLocation, errors.WithStack(json.Unmarshal(contents, &commitToLocation))
 }
 
-func saveToNixpkgsCommitFile(commit string, commitToLocation map[string]string) error {
+func saveToNixpkgsCommitFile(ctx context.Context, commit string, commitToLocation map[string]string) error {
 	// Make a query to get the /nix/store path for this commit hash.
 	cmd := Command("flake", "prefetch", "--json",
 		FlakeNixpkgs(commit),
 	)
-	out, err := cmd.Output(context.TODO())
+	out, err := cmd.Output(ctx)
 	if err != nil {
 		return errors.WithStack(err)
 	}
@@ -106,7 +106,7 @@ func saveToNixpkgsCommitFile(commit string, commitToLocation map[string]string)
 }
 
 func nixpkgsCommitFilePath() string {
-	cacheDir := xdg.CacheSubpath("devbox")
+	cacheDir := state.Cache()
 	return filepath.Join(cacheDir, "nixpkgs.json")
 }
 
@@ -1,6 +1,7 @@
 package nixprofile
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -58,7 +59,7 @@ func (i *NixProfileListItem) AttributePath() (string, error) {
 
 // Matches compares a devpkg.Package with this profile item and returns true if the profile item
 // was the result of adding the Package to the nix profile.
-func (i *NixProfileListItem) Matches(pkg *devpkg.Package, locker lock.Locker) bool {
+func (i *NixProfileListItem) Matches(ctx context.Context, pkg *devpkg.Package, locker lock.Locker) bool {
 	if i.addedByStorePath() {
 		// If an Item was added via store path, the best we can do when comparing to a Package is to check
 		// if its store path matches that of the Package. Note that the item should only have 1 store path.
@@ -75,7 +76,7 @@ func (i *NixProfileListItem) Matches(pkg *devpkg.Package, locker lock.Locker) bo
 		return false
 	}
 
-	return pkg.Equals(devpkg.PackageFromStringWithDefaults(i.unlockedReference, locker))
+	return pkg.Equals(ctx, devpkg.PackageFromStringWithDefaults(i.unlockedReference, locker))
 }
 
 func (i *NixProfileListItem) MatchesUnlockedReference(installable string) bool {
@@ -5,6 +5,7 @@
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"io"
 	"strconv"
@@ -21,15 +22,16 @@
 
 // ProfileListItems returns a list of the installed packages.
 func ProfileListItems(
+	ctx context.Context,
 	writer io.Writer,
 	profileDir string,
 ) ([]*NixProfileListItem, error) {
 	defer debug.FunctionTimer().End()
-	output, err := nix.ProfileList(writer, profileDir, true /*useJSON*/)
+	output, err := nix.ProfileList(ctx, writer, profileDir, true /*useJSON*/)
 	if err != nil {
 		// fallback to legacy profile list
 		// NOTE: maybe we should check the nix version first, instead of falling back on _any_ error.
-		return profileListLegacy(writer, profileDir)
+		return profileListLegacy(ctx, writer, profileDir)
 	}
 
 	type ProfileListElement struct {
@@ -86,10 +88,11 @@ type ProfileListOutputJSONLegacy struct {
 
 // profileListLegacy lists the items in a nix profile before nix 2.17.0 introduced --json.
 func profileListLegacy(
+	ctx context.Context,
 	writer io.Writer,
 	profileDir string,
 ) ([]*NixProfileListItem, error) {
-	output, err := nix.ProfileList(writer, profileDir, false /*useJSON*/)
+	output, err := nix.ProfileList(ctx, writer, profileDir, false /*useJSON*/)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
@@ -131,11 +134,11 @@ type ProfileListNameOrIndexArgs struct {
 
 // ProfileListNameOrIndex returns the name or index of args.Package in the nix profile specified by args.ProfileDir,
 // or nix.ErrPackageNotFound if it's not found. Callers can pass in args.Items to avoid having to call `nix-profile list` again.
-func ProfileListNameOrIndex(args *ProfileListNameOrIndexArgs) (string, error) {
+func ProfileListNameOrIndex(ctx context.Context, args *ProfileListNameOrIndexArgs) (string, error) {
 	var err error
 	items := args.Items
 	if items == nil {
-		items, err = ProfileListItems(args.Writer, args.ProfileDir)
+		items, err = ProfileListItems(ctx, args.Writer, args.ProfileDir)
 		if err != nil {
 			return "", err
 		}
@@ -164,7 +167,7 @@ func ProfileListNameOrIndex(args *ProfileListNameOrIndexArgs) (string, error) {
 	}
 
 	for _, item := range items {
-		if item.Matches(args.Package, args.Lockfile) {
+		if item.Matches(ctx, args.Package, args.Lockfile) {
 			return item.NameOrIndex(), nil
 		}
 	}
@@ -12,18 +12,20 @@
 	"io/fs"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/pkg/errors"
 	"go.jetify.com/devbox/internal/debug"
 	"go.jetify.com/devbox/internal/redact"
 )
 
-func ProfileList(writer io.Writer, profilePath string, useJSON bool) (string, error) {
+func ProfileList(ctx context.Context, writer io.Writer, profilePath string, useJSON bool) (string, error) {
 	cmd := Command("profile", "list", "--profile", profilePath)
 	if useJSON {
 		cmd.Args = append(cmd.Args, "--json")
 	}
-	out, err := cmd.Output(context.TODO())
+	out, err := cmd.Output(ctx)
 	if err != nil {
 		return "", redact.Errorf("error running \"nix profile list\": %w", err)
 	}
@@ -69,7 +71,7 @@ func ProfileInstall(ctx context.Context, args *ProfileInstallArgs) error {
 
 // ProfileRemove removes packages from a profile.
 // WARNING, don't use indexes, they are not supported by nix 2.20+
-func ProfileRemove(profilePath string, packageNames ...string) error {
+func ProfileRemove(ctx context.Context, profilePath string, packageNames ...string) error {
 	defer debug.FunctionTimer().End()
 	cmd := Command(
 		"profile", "remove",
@@ -80,7 +82,54 @@ func ProfileRemove(profilePath string, packageNames ...string) error {
 	FixInstallableArgs(packageNames)
 	cmd.Args = appendArgs(cmd.Args, packageNames)
 	cmd.Env = allowUnfreeEnv(allowInsecureEnv(os.Environ()))
-	return cmd.Run(context.TODO())
+	return cmd.Run(ctx)
+}
+
+// ProfileGeneration returns the generation that a profile is at, or 0 if the
+// profile doesn't exist. A profile is a symlink to the link of its current
+// generation, such as default -> default-3-link.
+func ProfileGeneration(profilePath string) (int, error) {
+	target, err := os.Readlink(profilePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	return parseGenerationLink(filepath.Base(profilePath), filepath.Base(target))
+}
+
+func parseGenerationLink(profile, link string) (int, error) {
+	n, found := strings.CutPrefix(link, profile+"-")
+	if found {
+		n, found = strings.CutSuffix(n, "-link")
+	}
+	generation, err := strconv.Atoi(n)
+	if !found || err != nil || generation <= 0 {
+		return 0, errors.Errorf("%s isn't a generation of the nix profile %s", link, profile)
+	}
+	return generation, nil
+}
+
+// ProfileGenerationExists reports whether a generation of a profile still
+// exists. nix-collect-garbage -d deletes the generations that a profile isn't
+// at.
+func ProfileGenerationExists(profilePath string, generation int) bool {
+	link := fmt.Sprintf("%s-%d-link", profilePath, generation)
+	_, err := os.Lstat(link)
+	return err == nil
+}
+
+// ProfileRollback switches a profile to one of its generations, without
+// building or downloading anything.
+func ProfileRollback(ctx context.Context, profilePath string, generation int) error {
+	defer debug.FunctionTimer().End()
+	cmd := Command(
+		"profile", "rollback",
+		"--profile", profilePath,
+		"--to", strconv.Itoa(generation),
+	)
+	return cmd.Run(ctx)
 }
 
 type manifest struct {
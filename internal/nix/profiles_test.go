@@ -0,0 +1,42 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package nix
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestProfileGeneration(t *testing.T) {
+	dir := t.TempDir()
+	profile := filepath.Join(dir, "default")
+	if got, err := ProfileGeneration(profile); err != nil || got != 0 {
+		t.Errorf("ProfileGeneration() of a missing profile = %d, %v, want 0", got, err)
+	}
+
+	if err := os.Symlink("default-12-link", profile); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := ProfileGeneration(profile); err != nil || got != 12 {
+		t.Errorf("ProfileGeneration() = %d, %v, want 12", got, err)
+	}
+	if ProfileGenerationExists(profile, 12) {
+		t.Error("ProfileGenerationExists() = true for a generation without a link")
+	}
+	if err := os.Symlink("/nix/store/abc-profile", filepath.Join(dir, "default-12-link")); err != nil {
+		t.Fatal(err)
+	}
+	if !ProfileGenerationExists(profile, 12) {
+		t.Error("ProfileGenerationExists() = false for a generation with a link")
+	}
+}
+
+func TestParseGenerationLink(t *testing.T) {
+	for _, link := range []string{"default", "default-link", "default-x-link", "other-3-link", "default-0-link"} {
+		if _, err := parseGenerationLink("default", link); err == nil {
+			t.Errorf("parseGenerationLink(%q) returned no error", link)
+		}
+	}
+}
@@ -0,0 +1,425 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package nix
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-isatty"
+)
+
+// ProgressMode controls how nix commands that build and download packages
+// report their progress.
+type ProgressMode string
+
+const (
+	// ProgressAuto redraws a status line with the number of builds and
+	// downloads on terminals, and prints a line for each build and
+	// download otherwise.
+	ProgressAuto ProgressMode = "auto"
+
+	// ProgressQuiet only prints nix's warnings and errors.
+	ProgressQuiet ProgressMode = "quiet"
+
+	// ProgressJSON prints a JSON object for each build, download, progress
+	// update and message.
+	ProgressJSON ProgressMode = "json"
+)
+
+type progressCtxKey struct{}
+
+type progressOpts struct {
+	mode   ProgressMode
+	writer io.Writer
+}
+
+// WithProgress returns a context that makes the nix commands run with it
+// report their progress in mode. If w isn't nil, the progress is written to w
+// instead of the command's output.
+func WithProgress(ctx context.Context, mode ProgressMode, w io.Writer) context.Context {
+	return context.WithValue(ctx, progressCtxKey{}, progressOpts{mode: mode, writer: w})
+}
+
+// newProgressWriterFromContext returns the progress writer for a command run
+// with ctx that would otherwise write its output to w.
+func newProgressWriterFromContext(ctx context.Context, w io.Writer) *progressWriter {
+	opts, _ := ctx.Value(progressCtxKey{}).(progressOpts)
+	if opts.mode == "" {
+		opts.mode = ProgressAuto
+	}
+	if opts.writer != nil {
+		w = opts.writer
+	}
+	return newProgressWriter(opts.mode, w)
+}
+
+// Activity and result types of nix's internal-json log format. See
+// src/libutil/logging.hh in the nix repository.
+const (
+	actCopyPath     = 100
+	actFileTransfer = 101
+	actCopyPaths    = 103
+	actBuilds       = 104
+	actBuild        = 105
+	actSubstitute   = 108
+
+	resBuildLogLine     = 101
+	resProgress         = 105
+	resPostBuildLogLine = 107
+
+	lvlError = 0
+	lvlWarn  = 1
+)
+
+// progressRedrawInterval limits how often the status line is redrawn.
+const progressRedrawInterval = 100 * time.Millisecond
+
+var ansiRegexp = regexp.MustCompile("\x1b\\[[0-9;]*[A-Za-z]")
+
+// nixLogEvent is a line of nix's internal-json log format after its "@nix "
+// prefix.
+type nixLogEvent struct {
+	Action string `json:"action"`
+	ID     int64  `json:"id"`
+	Level  int    `json:"level"`
+	Type   int    `json:"type"`
+	Msg    string `json:"msg"`
+	Fields []any  `json:"fields"`
+}
+
+func (e nixLogEvent) intField(i int) int64 {
+	if i >= len(e.Fields) {
+		return 0
+	}
+	f, _ := e.Fields[i].(float64)
+	return int64(f)
+}
+
+func (e nixLogEvent) stringField(i int) string {
+	if i >= len(e.Fields) {
+		return ""
+	}
+	s, _ := e.Fields[i].(string)
+	return s
+}
+
+// ProgressEvent is what ProgressJSON prints for each build, download,
+// progress update and message.
+type ProgressEvent struct {
+	// Event is one of "build", "download", "done", "progress", "warning",
+	// "error" or "message".
+	Event string `json:"event"`
+
+	// ID identifies the build or download of a "done" event.
+	ID int64 `json:"id,omitempty"`
+
+	// Name is the name of the package being built or downloaded, and Path
+	// is its derivation or store path.
+	Name string `json:"name,omitempty"`
+	Path string `json:"path,omitempty"`
+
+	// Message is the text of a warning, error or message.
+	Message string `json:"message,omitempty"`
+
+	*ProgressCounts `json:",omitempty"`
+}
+
+// ProgressCounts are the totals of a progress event.
+type ProgressCounts struct {
+	BuildsDone        int64 `json:"builds_done"`
+	BuildsExpected    int64 `json:"builds_expected"`
+	DownloadsDone     int64 `json:"downloads_done"`
+	DownloadsExpected int64 `json:"downloads_expected"`
+	BytesDone         int64 `json:"bytes_done"`
+	BytesExpected     int64 `json:"bytes_expected"`
+}
+
+type transfer struct {
+	done, expected int64
+}
+
+// progressWriter renders the internal-json log output of a nix command.
+// Lines that aren't in that format are printed as they are.
+type progressWriter struct {
+	mode ProgressMode
+	w    io.Writer
+	tty  bool
+	now  func() time.Time
+
+	partial []byte
+
+	// logs saves the log of each build if it isn't nil.
+	logs *buildLogger
+
+	// types are the types of the activities in progress, by activity ID.
+	types map[int64]int
+
+	// running are the names of the builds and downloads in progress, by
+	// activity ID.
+	running   map[int64]string
+	order     []int64
+	transfers map[int64]transfer
+	counts    ProgressCounts
+
+	lastCounts ProgressCounts
+	lastDraw   time.Time
+	drawn      bool
+}
+
+func newProgressWriter(mode ProgressMode, w io.Writer) *progressWriter {
+	tty := false
+	if f, ok := w.(*os.File); ok {
+		tty = isatty.IsTerminal(f.Fd())
+	}
+	return &progressWriter{
+		mode:      mode,
+		w:         w,
+		tty:       tty,
+		now:       time.Now,
+		types:     map[int64]int{},
+		running:   map[int64]string{},
+		transfers: map[int64]transfer{},
+	}
+}
+
+// Args returns the nix arguments that make it log in the format that the
+// writer parses.
+func (*progressWriter) Args() []any {
+	return []any{"--log-format", "internal-json"}
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	p.partial = append(p.partial, b...)
+	for {
+		line, rest, found := bytes.Cut(p.partial, []byte("\n"))
+		if !found {
+			break
+		}
+		p.handleLine(string(line))
+		p.partial = rest
+	}
+	return len(b), nil
+}
+
+// Flush prints any unterminated line and clears the status line. Call it
+// after the command exits.
+func (p *progressWriter) Flush() {
+	if len(p.partial) > 0 {
+		p.handleLine(string(p.partial))
+		p.partial = nil
+	}
+	if p.logs != nil {
+		p.logs.close()
+	}
+	p.clearStatus()
+	if p.mode == ProgressJSON && p.counts != p.lastCounts {
+		p.emit(ProgressEvent{Event: "progress", ProgressCounts: &p.counts})
+	}
+}
+
+func (p *progressWriter) handleLine(line string) {
+	data, ok := strings.CutPrefix(line, "@nix ")
+	event := nixLogEvent{}
+	if !ok || json.Unmarshal([]byte(data), &event) != nil {
+		p.message("message", line)
+		return
+	}
+
+	switch event.Action {
+	case "msg":
+		switch {
+		case event.Level <= lvlError:
+			if p.logs != nil {
+				p.logs.error(event.Msg)
+			}
+			p.message("error", event.Msg)
+		case event.Level <= lvlWarn:
+			p.message("warning", event.Msg)
+		}
+	case "start":
+		p.start(event)
+	case "stop":
+		p.stop(event.ID)
+	case "result":
+		switch event.Type {
+		case resProgress:
+			p.result(event)
+		case resBuildLogLine, resPostBuildLogLine:
+			if p.logs != nil {
+				p.logs.line(event.ID, event.stringField(0))
+			}
+		}
+	}
+}
+
+func (p *progressWriter) start(event nixLogEvent) {
+	p.types[event.ID] = event.Type
+	var kind, path string
+	switch event.Type {
+	case actBuild:
+		kind, path = "build", event.stringField(0)
+	case actSubstitute:
+		kind, path = "download", event.stringField(0)
+	default:
+		return
+	}
+	if kind == "build" && p.logs != nil {
+		p.logs.start(event.ID, path)
+	}
+	name := StorePathName(path)
+	p.running[event.ID] = name
+	p.order = append(p.order, event.ID)
+
+	switch p.mode {
+	case ProgressJSON:
+		p.emit(ProgressEvent{Event: kind, ID: event.ID, Name: name, Path: path})
+	case ProgressAuto:
+		if p.tty {
+			p.drawStatus(false)
+		} else {
+			verb := map[string]string{"build": "Building", "download": "Downloading"}[kind]
+			fmt.Fprintf(p.w, "%s %s\n", verb, name)
+		}
+	}
+}
+
+func (p *progressWriter) stop(id int64) {
+	delete(p.types, id)
+	if p.logs != nil {
+		p.logs.stop(id)
+	}
+	if _, ok := p.running[id]; !ok {
+		return
+	}
+	delete(p.running, id)
+	p.order = slices.DeleteFunc(p.order, func(i int64) bool { return i == id })
+	if p.mode == ProgressJSON {
+		p.emit(ProgressEvent{Event: "done", ID: id})
+	}
+}
+
+func (p *progressWriter) result(event nixLogEvent) {
+	// Results don't repeat the type of their activity, so it's looked up
+	// from the start event.
+	done, expected := event.intField(0), event.intField(1)
+	switch p.types[event.ID] {
+	case actBuilds:
+		p.counts.BuildsDone, p.counts.BuildsExpected = done, expected
+	case actCopyPaths:
+		p.counts.DownloadsDone, p.counts.DownloadsExpected = done, expected
+	case actFileTransfer, actCopyPath:
+		p.transfers[event.ID] = transfer{done: done, expected: expected}
+		p.counts.BytesDone, p.counts.BytesExpected = 0, 0
+		for _, t := range p.transfers {
+			p.counts.BytesDone += t.done
+			p.counts.BytesExpected += t.expected
+		}
+	default:
+		return
+	}
+
+	switch p.mode {
+	case ProgressJSON:
+		if p.now().Sub(p.lastDraw) >= progressRedrawInterval {
+			p.lastDraw = p.now()
+			p.lastCounts = p.counts
+			p.emit(ProgressEvent{Event: "progress", ProgressCounts: &p.counts})
+		}
+	case ProgressAuto:
+		if p.tty {
+			p.drawStatus(false)
+		}
+	}
+}
+
+func (p *progressWriter) message(kind, msg string) {
+	msg = strings.TrimRight(msg, "\n")
+	if msg == "" {
+		return
+	}
+	switch p.mode {
+	case ProgressJSON:
+		p.emit(ProgressEvent{Event: kind, Message: ansiRegexp.ReplaceAllString(msg, "")})
+	case ProgressQuiet:
+		if kind == "message" {
+			return
+		}
+		fallthrough
+	default:
+		if !p.tty {
+			msg = ansiRegexp.ReplaceAllString(msg, "")
+		}
+		p.clearStatus()
+		fmt.Fprintln(p.w, msg)
+		p.drawStatus(true)
+	}
+}
+
+func (p *progressWriter) emit(event ProgressEvent) {
+	data, err := json.Marshal(event)
+	if err == nil {
+		fmt.Fprintf(p.w, "%s\n", data)
+	}
+}
+
+// drawStatus redraws the status line on terminals. Unless force is set, it
+// limits how often the line is redrawn.
+func (p *progressWriter) drawStatus(force bool) {
+	if p.mode != ProgressAuto || !p.tty {
+		return
+	}
+	if !force && p.drawn && p.now().Sub(p.lastDraw) < progressRedrawInterval {
+		return
+	}
+	status := p.status()
+	if status == "" {
+		p.clearStatus()
+		return
+	}
+	fmt.Fprintf(p.w, "\r\x1b[K%s", status)
+	p.drawn = true
+	p.lastDraw = p.now()
+}
+
+func (p *progressWriter) clearStatus() {
+	if p.drawn {
+		fmt.Fprint(p.w, "\r\x1b[K")
+		p.drawn = false
+	}
+}
+
+// status returns the status line, such as "[built 1/3, downloaded 10/40,
+// 12.3/80.0 MiB] building hello-2.12.1".
+func (p *progressWriter) status() string {
+	c := p.counts
+	parts := []string{}
+	if c.BuildsExpected > 0 {
+		parts = append(parts, fmt.Sprintf("built %d/%d", c.BuildsDone, c.BuildsExpected))
+	}
+	if c.DownloadsExpected > 0 {
+		parts = append(parts, fmt.Sprintf("downloaded %d/%d", c.DownloadsDone, c.DownloadsExpected))
+	}
+	if c.BytesExpected > 0 {
+		parts = append(parts, fmt.Sprintf("%.1f/%.1f MiB",
+			float64(c.BytesDone)/(1<<20), float64(c.BytesExpected)/(1<<20)))
+	}
+	status := ""
+	if len(parts) > 0 {
+		status = "[" + strings.Join(parts, ", ") + "]"
+	}
+	if len(p.order) > 0 {
+		// Show the most recently started build or download.
+		status += " " + p.running[p.order[len(p.order)-1]]
+	}
+	return strings.TrimSpace(status)
+}
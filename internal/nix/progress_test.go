@@ -0,0 +1,98 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package nix
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testNixLog = `@nix {"action":"start","id":1,"level":3,"type":104,"text":"","fields":[]}
+@nix {"action":"start","id":2,"level":3,"type":103,"text":"","fields":[]}
+@nix {"action":"result","id":2,"type":105,"fields":[0,2,0,0]}
+@nix {"action":"start","id":3,"level":4,"type":108,"text":"copying path","fields":["/nix/store/0a6fb2n6b2aw7xqcgfmvsd3dp8lxw0qp-hello-2.12.1","https://cache.nixos.org"]}
+@nix {"action":"start","id":4,"level":4,"type":101,"text":"downloading","fields":["https://cache.nixos.org/nar/x.nar.xz"]}
+@nix {"action":"result","id":4,"type":105,"fields":[1048576,2097152,0,0]}
+@nix {"action":"stop","id":4}
+@nix {"action":"stop","id":3}
+@nix {"action":"result","id":2,"type":105,"fields":[1,2,0,0]}
+@nix {"action":"start","id":5,"level":3,"type":105,"text":"building","fields":["/nix/store/pkbgcyzfbn0mz5j2rvsizkgv4vdpw5b7-my-tool-1.0.drv","",1,1]}
+@nix {"action":"result","id":5,"type":101,"fields":["compiling..."]}
+@nix {"action":"msg","level":1,"msg":"warning: Git tree is dirty"}
+@nix {"action":"msg","level":3,"msg":"evaluating derivation"}
+@nix {"action":"stop","id":5}
+@nix {"action":"result","id":1,"type":105,"fields":[1,1,0,0]}
+@nix {"action":"msg","level":0,"msg":"\u001b[31;1merror:\u001b[0m something failed"}
+`
+
+func writeTestNixLog(mode ProgressMode) string {
+	out := &bytes.Buffer{}
+	p := newProgressWriter(mode, out)
+	p.now = func() time.Time { return time.Unix(1, 0) }
+	// Write in chunks that split lines to check that they're reassembled.
+	for chunk := range slices.Chunk([]byte(testNixLog+"trailing output"), 37) {
+		if _, err := p.Write(chunk); err != nil {
+			panic(err)
+		}
+	}
+	p.Flush()
+	return out.String()
+}
+
+func TestProgressWriterAuto(t *testing.T) {
+	got := writeTestNixLog(ProgressAuto)
+	want := `Downloading hello-2.12.1
+Building my-tool-1.0
+warning: Git tree is dirty
+error: something failed
+trailing output
+`
+	if got != want {
+		t.Errorf("got output:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestProgressWriterQuiet(t *testing.T) {
+	got := writeTestNixLog(ProgressQuiet)
+	want := "warning: Git tree is dirty\nerror: something failed\n"
+	if got != want {
+		t.Errorf("got output:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestProgressWriterJSON(t *testing.T) {
+	got := strings.Split(strings.TrimSpace(writeTestNixLog(ProgressJSON)), "\n")
+	want := []string{
+		`{"event":"progress","builds_done":0,"builds_expected":0,"downloads_done":0,"downloads_expected":2,"bytes_done":0,"bytes_expected":0}`,
+		`{"event":"download","id":3,"name":"hello-2.12.1","path":"/nix/store/0a6fb2n6b2aw7xqcgfmvsd3dp8lxw0qp-hello-2.12.1"}`,
+		`{"event":"done","id":3}`,
+		`{"event":"build","id":5,"name":"my-tool-1.0","path":"/nix/store/pkbgcyzfbn0mz5j2rvsizkgv4vdpw5b7-my-tool-1.0.drv"}`,
+		`{"event":"warning","message":"warning: Git tree is dirty"}`,
+		`{"event":"done","id":5}`,
+		`{"event":"error","message":"error: something failed"}`,
+		`{"event":"message","message":"trailing output"}`,
+		`{"event":"progress","builds_done":1,"builds_expected":1,"downloads_done":1,"downloads_expected":2,"bytes_done":1048576,"bytes_expected":2097152}`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got output:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestProgressWriterStatus(t *testing.T) {
+	p := newProgressWriter(ProgressAuto, &bytes.Buffer{})
+	p.counts = ProgressCounts{
+		BuildsDone: 1, BuildsExpected: 3,
+		DownloadsDone: 10, DownloadsExpected: 40,
+		BytesDone: 12 << 20, BytesExpected: 80 << 20,
+	}
+	p.running[7] = "hello-2.12.1"
+	p.order = []int64{7}
+	want := "[built 1/3, downloaded 10/40, 12.0/80.0 MiB] hello-2.12.1"
+	if got := p.status(); got != want {
+		t.Errorf("got status %q, want %q", got, want)
+	}
+}
@@ -14,7 +14,14 @@
 	"go.jetify.com/devbox/internal/cmdutil"
 )
 
-func RunScript(projectDir, cmdWithArgs string, env map[string]string) error {
+// CommandWrapper wraps a command line in another command that runs it, such as
+// a sandbox.
+type CommandWrapper func(args []string) ([]string, error)
+
+// RunScript runs a command line with sh in the project directory. Each wrapper
+// wraps the command line that the previous one returned. Nil wrappers are
+// skipped.
+func RunScript(projectDir, cmdWithArgs string, env map[string]string, wrappers ...CommandWrapper) error {
 	if cmdWithArgs == "" {
 		return errors.New("attempted to run an empty command or script")
 	}
@@ -26,7 +33,17 @@ func RunScript(projectDir, cmdWithArgs string, env map[string]string) error {
 
 	// Try to find sh in the PATH, if not, default to a well known absolute path.
 	shPath := cmdutil.GetPathOrDefault("sh", "/bin/sh")
-	cmd := exec.Command(shPath, "-c", cmdWithArgs)
+	args := []string{shPath, "-c", cmdWithArgs}
+	for _, wrap := range wrappers {
+		if wrap == nil {
+			continue
+		}
+		var err error
+		if args, err = wrap(args); err != nil {
+			return err
+		}
+	}
+	cmd := exec.Command(args[0], args[1:]...)
 	cmd.Env = envPairs
 	cmd.Dir = projectDir
 	cmd.Stdin = os.Stdin
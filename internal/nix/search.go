@@ -10,7 +10,7 @@
 	"time"
 
 	"github.com/pkg/errors"
-	"go.jetify.com/devbox/internal/xdg"
+	"go.jetify.com/devbox/internal/state"
 	"go.jetify.com/pkg/filecache"
 )
 
@@ -32,13 +32,13 @@ func (i *PkgInfo) String() string {
 	return fmt.Sprintf("%s-%s", i.PName, i.Version)
 }
 
-func Search(url string) (map[string]*PkgInfo, error) {
+func Search(ctx context.Context, url string) (map[string]*PkgInfo, error) {
 	if strings.HasPrefix(url, "runx:") {
 		// TODO implement runx search. Also, move this check outside this function: nix package
 		// should not be handling runx logic.
 		return map[string]*PkgInfo{}, nil
 	}
-	return searchSystem(url, "" /* system */)
+	return searchSystem(ctx, url, "" /* system */)
 }
 
 func parseSearchResults(data []byte) map[string]*PkgInfo {
@@ -60,7 +60,7 @@ func parseSearchResults(data []byte) map[string]*PkgInfo {
 
 // PkgExistsForAnySystem is a bit slow (~600ms). Only use it if there's already
 // been an error and we want to provide a better error message.
-func PkgExistsForAnySystem(pkg string) bool {
+func PkgExistsForAnySystem(ctx context.Context, pkg string) bool {
 	systems := []string{
 		// Check most common systems first.
 		"x86_64-linux",
@@ -77,7 +77,7 @@ func PkgExistsForAnySystem(pkg string) bool {
 		"riscv64-linux",
 	}
 	for _, system := range systems {
-		results, _ := searchSystem(pkg, system)
+		results, _ := searchSystem(ctx, pkg, system)
 		if len(results) > 0 {
 			return true
 		}
@@ -85,7 +85,7 @@ func PkgExistsForAnySystem(pkg string) bool {
 	return false
 }
 
-func searchSystem(url, system string) (map[string]*PkgInfo, error) {
+func searchSystem(ctx context.Context, url, system string) (map[string]*PkgInfo, error) {
 	// Eventually we may pass a writer here, but for now it is safe to use stderr
 	writer := os.Stderr
 	// Search will download nixpkgs if it's not already downloaded. Adding this
@@ -94,7 +94,7 @@ func searchSystem(url, system string) (map[string]*PkgInfo, error) {
 		hash := HashFromNixPkgsURL(url)
 		// purposely ignore error here. The function already prints an error.
 		// We don't want to panic or stop execution if we can't prefetch.
-		_ = EnsureNixpkgsPrefetched(writer, hash)
+		_ = EnsureNixpkgsPrefetched(ctx, writer, hash)
 	}
 
 	// The `^` is added to indicate we want to show all packages
@@ -102,7 +102,7 @@ func searchSystem(url, system string) (map[string]*PkgInfo, error) {
 	if system != "" {
 		cmd.Args = append(cmd.Args, "--system", system)
 	}
-	out, err := cmd.Output(context.TODO())
+	out, err := cmd.Output(ctx)
 	if err != nil {
 		// for now, assume all errors are invalid packages.
 		// TODO: check the error string for "did not find attribute" and
@@ -125,7 +125,7 @@ func searchSystem(url, system string) (map[string]*PkgInfo, error) {
 // queries of the form `nixpkgs/<commit-hash>#attribute`, we can know for sure that
 // once `nix search` returns a valid result, it will always be the very same result.
 // Hence we can cache it locally and answer future queries fast, by not calling `nix search`.
-func SearchNixpkgsAttribute(query string) (map[string]*PkgInfo, error) {
+func SearchNixpkgsAttribute(ctx context.Context, query string) (map[string]*PkgInfo, error) {
 	if !allowableQuery.MatchString(query) {
 		return nil, errors.Errorf("invalid query: %s, must match regex: %s", query, allowableQuery)
 	}
@@ -135,7 +135,7 @@ func SearchNixpkgsAttribute(query string) (map[string]*PkgInfo, error) {
 	// Check if the query was already cached, and return the result if so
 	cache := filecache.New(
 		"devbox/nix",
-		filecache.WithCacheDir[map[string]*PkgInfo](xdg.CacheSubpath("")),
+		filecache.WithCacheDir[map[string]*PkgInfo](state.FileCacheDir()),
 	)
 
 	if results, err := cache.Get(key); err == nil {
@@ -145,7 +145,7 @@ func SearchNixpkgsAttribute(query string) (map[string]*PkgInfo, error) {
 	}
 
 	// If not cached, or an update is needed, then call searchSystem
-	infos, err := searchSystem(query, "" /*system*/)
+	infos, err := searchSystem(ctx, query, "" /*system*/)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,85 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package nix
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/pkg/errors"
+
+	"go.jetify.com/devbox/internal/envir"
+)
+
+// usersDir is the directory in .devbox with the state of each user of a shared
+// project.
+const usersDir = "users"
+
+// IsSharedProject returns true if several users on this machine use the
+// project, such as a checkout on a build server. Each user of a shared project
+// has their own nix profile so that they don't race on its generation links or
+// remove each other's packages. A project is shared if DEVBOX_SHARED_PROJECT
+// is set, or if its .devbox directory belongs to another user.
+func IsSharedProject(projectDir string) bool {
+	if envir.IsSharedProject() {
+		return true
+	}
+	info, err := os.Stat(filepath.Join(projectDir, ".devbox"))
+	if err != nil {
+		return false
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	return ok && int(stat.Uid) != os.Getuid()
+}
+
+// ProjectUserDir returns the directory with the current user's own state in a
+// project: .devbox/users/<user> in shared projects, and .devbox otherwise.
+func ProjectUserDir(projectDir string) string {
+	if !IsSharedProject(projectDir) {
+		return filepath.Join(projectDir, ".devbox")
+	}
+	return filepath.Join(projectDir, ".devbox", usersDir, userDirName())
+}
+
+// ProjectProfilePath returns the absolute path of the current user's nix
+// profile in a project. It's ProfilePath unless the project is shared.
+func ProjectProfilePath(projectDir string) string {
+	if !IsSharedProject(projectDir) {
+		return filepath.Join(projectDir, ProfilePath)
+	}
+	return filepath.Join(ProjectUserDir(projectDir), "nix", "profile", "default")
+}
+
+// EnsureProjectUserDir creates the current user's directory in a project. In
+// shared projects, the directory that has every user's directory is writable
+// by all users, like /tmp, so that whoever comes first doesn't lock the others
+// out.
+func EnsureProjectUserDir(projectDir string) error {
+	dir := ProjectUserDir(projectDir)
+	if IsSharedProject(projectDir) {
+		parent := filepath.Dir(dir)
+		if err := os.Mkdir(parent, 0o755); err == nil {
+			if err := os.Chmod(parent, 0o777|os.ModeSticky); err != nil {
+				return errors.WithStack(err)
+			}
+		} else if !errors.Is(err, os.ErrExist) {
+			return errors.WithStack(err)
+		}
+	}
+	return errors.WithStack(os.MkdirAll(dir, 0o755))
+}
+
+// userDirName returns the name of the current user's directory in shared
+// projects.
+func userDirName() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		// Some systems have usernames like DOMAIN\user.
+		return strings.NewReplacer("/", "_", `\`, "_").Replace(u.Username)
+	}
+	return strconv.Itoa(os.Getuid())
+}
@@ -0,0 +1,58 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package nix
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.jetify.com/devbox/internal/envir"
+)
+
+func TestProjectProfilePath(t *testing.T) {
+	t.Setenv(envir.DevboxSharedProject, "")
+	projectDir := t.TempDir()
+
+	if IsSharedProject(projectDir) {
+		t.Fatal("got a shared project for a directory that the current user owns")
+	}
+	if got, want := ProjectProfilePath(projectDir), filepath.Join(projectDir, ProfilePath); got != want {
+		t.Errorf("got profile path %q, want %q", got, want)
+	}
+	if got, want := ProjectUserDir(projectDir), filepath.Join(projectDir, ".devbox"); got != want {
+		t.Errorf("got user dir %q, want %q", got, want)
+	}
+}
+
+func TestProjectProfilePathShared(t *testing.T) {
+	t.Setenv(envir.DevboxSharedProject, "1")
+	projectDir := t.TempDir()
+
+	userDir := ProjectUserDir(projectDir)
+	if !strings.HasPrefix(userDir, filepath.Join(projectDir, ".devbox", "users")+"/") {
+		t.Errorf("got user dir %q, want one in .devbox/users", userDir)
+	}
+	if got, want := ProjectProfilePath(projectDir), filepath.Join(userDir, "nix", "profile", "default"); got != want {
+		t.Errorf("got profile path %q, want %q", got, want)
+	}
+
+	if err := os.Mkdir(filepath.Join(projectDir, ".devbox"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := EnsureProjectUserDir(projectDir); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(filepath.Dir(userDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 0o777 | os.ModeSticky | os.ModeDir; info.Mode() != want {
+		t.Errorf("got users dir mode %v, want %v", info.Mode(), want)
+	}
+	if _, err := os.Stat(userDir); err != nil {
+		t.Errorf("got error for user dir: %v", err)
+	}
+}
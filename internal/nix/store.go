@@ -12,7 +12,6 @@
 
 	"go.jetify.com/devbox/internal/debug"
 	"go.jetify.com/devbox/internal/redact"
-	"go.jetify.com/devbox/nix"
 	"golang.org/x/exp/maps"
 )
 
@@ -66,6 +65,84 @@ func StorePathsAreInStore(ctx context.Context, storePaths []string) (map[string]
 	return parseStorePathFromInstallableOutput(output)
 }
 
+// StorePathClosure returns the store paths and all of the store paths that
+// they depend on.
+func StorePathClosure(ctx context.Context, storePaths []string) ([]string, error) {
+	defer debug.FunctionTimer().End()
+	if len(storePaths) == 0 {
+		return []string{}, nil
+	}
+	cmd := Command("path-info", "--recursive")
+	cmd.Args = appendArgs(cmd.Args, storePaths)
+	output, err := cmd.Output(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(string(output)), nil
+}
+
+// ClosureSize returns the total NAR size of the store paths and all of the
+// store paths that they depend on. It queries the local store if the paths are
+// in it, and cache.nixos.org otherwise, so the paths don't need to be
+// downloaded.
+func ClosureSize(ctx context.Context, storePaths []string) (int64, error) {
+	defer debug.FunctionTimer().End()
+	if len(storePaths) == 0 {
+		return 0, nil
+	}
+	size, err := closureSize(ctx, storePaths, "")
+	if err == nil {
+		return size, nil
+	}
+	slog.Debug("failed to get the closure size from the local store", "err", err)
+	return closureSize(ctx, storePaths, "https://cache.nixos.org")
+}
+
+func closureSize(ctx context.Context, storePaths []string, store string) (int64, error) {
+	cmd := Command("path-info", "--recursive", "--json")
+	if store == "" {
+		cmd.Args = append(cmd.Args, "--offline")
+	} else {
+		cmd.Args = append(cmd.Args, "--store", store)
+	}
+	cmd.Args = appendArgs(cmd.Args, storePaths)
+	out, err := cmd.Output(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return parseClosureSize(out)
+}
+
+// parseClosureSize sums the narSize of each path in the output of
+// `nix path-info --json`, in either the modern or legacy format.
+func parseClosureSize(output []byte) (int64, error) {
+	type narInfo struct {
+		Path    string `json:"path"`
+		NarSize int64  `json:"narSize"`
+	}
+
+	var size int64
+	var modern map[string]*narInfo
+	if err := json.Unmarshal(output, &modern); err == nil {
+		for path, info := range modern {
+			if info == nil {
+				return 0, fmt.Errorf("path %s is not valid", path)
+			}
+			size += info.NarSize
+		}
+		return size, nil
+	}
+
+	var legacy []narInfo
+	if err := json.Unmarshal(output, &legacy); err == nil {
+		for _, info := range legacy {
+			size += info.NarSize
+		}
+		return size, nil
+	}
+	return 0, fmt.Errorf("failed to parse path-info output: %s", output)
+}
+
 // Older nix versions (like 2.17) are an array of objects that contain path and valid fields
 type LegacyPathInfo struct {
 	Path  string `json:"path"`
@@ -131,12 +208,12 @@ func (e *DaemonError) Redact() string {
 // DaemonVersion returns the version of the currently running Nix daemon.
 func DaemonVersion(ctx context.Context) (string, error) {
 	storeCmd := "ping"
-	if nix.AtLeast(nix.Version2_19) {
+	if Supports(FeatureStoreInfo) {
 		// "nix store ping" is deprecated as of 2.19 in favor of
 		// "nix store info".
 		storeCmd = "info"
 	}
-	canJSON := nix.AtLeast(nix.Version2_14)
+	canJSON := Supports(FeatureStoreJSON)
 
 	cmd := Command("store", storeCmd, "--store", "daemon")
 	if canJSON {
@@ -56,3 +56,46 @@ func TestParseStorePathFromInstallableOutput(t *testing.T) {
 		})
 	}
 }
+
+func TestParseClosureSize(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected int64
+		wantErr  bool
+	}{
+		{
+			name:     "nix-2-20",
+			input:    `{"/nix/store/a-go-1.22.0":{"narSize":100},"/nix/store/b-tzdata":{"narSize":20}}`,
+			expected: 120,
+		},
+		{
+			name:     "nix-2-17",
+			input:    `[{"path":"/nix/store/a-go-1.22.0","narSize":100},{"path":"/nix/store/b-tzdata","narSize":20}]`,
+			expected: 120,
+		},
+		{
+			name:    "invalid-path",
+			input:   `{"/nix/store/a-go-1.22.0":null}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			size, err := parseClosureSize([]byte(tc.input))
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("parseClosureSize() succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if size != tc.expected {
+				t.Errorf("parseClosureSize() = %d, want %d", size, tc.expected)
+			}
+		})
+	}
+}
@@ -38,3 +38,13 @@ func NewStorePathParts(path string) StorePathParts {
 	}
 	return StorePathParts{Hash: hash, Name: name}
 }
+
+// StorePathName returns the name of a store path or derivation without its
+// hash, such as hello-2.12.1 for /nix/store/<hash>-hello-2.12.1.drv.
+func StorePathName(path string) string {
+	name := path[strings.LastIndex(path, "/")+1:]
+	if _, after, ok := strings.Cut(name, "-"); ok {
+		name = after
+	}
+	return strings.TrimSuffix(name, ".drv")
+}
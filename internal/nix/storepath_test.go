@@ -56,3 +56,16 @@ func TestStorePathParts(t *testing.T) {
 		})
 	}
 }
+
+func TestStorePathName(t *testing.T) {
+	tests := map[string]string{
+		"/nix/store/pkbgcyzfbn0mz5j2rvsizkgv4vdpw5b7-my-tool-1.0.drv":    "my-tool-1.0",
+		"/nix/store/0a6fb2n6b2aw7xqcgfmvsd3dp8lxw0qp-python3-3.12.1-dev": "python3-3.12.1-dev",
+		"0a6fb2n6b2aw7xqcgfmvsd3dp8lxw0qp-hello-2.12.1":                  "hello-2.12.1",
+	}
+	for path, want := range tests {
+		if got := StorePathName(path); got != want {
+			t.Errorf("StorePathName(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+// Package notify posts notifications about a devbox project, such as a change
+// to its lockfile, to Slack and other webhooks.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"go.jetify.com/devbox/internal/httpclient"
+)
+
+// Event is something that happened to a project that targets can be notified
+// about.
+type Event string
+
+const (
+	LockfileChanged Event = "lockfile_changed"
+	InstallFailed   Event = "install_failed"
+	ServiceCrashed  Event = "service_crashed"
+)
+
+// Events are all the events that targets can be notified about.
+var Events = []Event{LockfileChanged, InstallFailed, ServiceCrashed}
+
+// Target types.
+const (
+	Slack = "slack"
+	HTTP  = "http"
+)
+
+// timeout is how long a webhook has to respond.
+const timeout = 10 * time.Second
+
+// Target is a webhook that's notified of events.
+type Target struct {
+	// Type is Slack or HTTP.
+	Type string
+	URL  string
+	// Events are the events the target is notified of. It's notified of
+	// all events if it's empty.
+	Events []Event
+	// Template renders the payload from a Message. It's optional.
+	Template string
+}
+
+// Message is a notification of an event. Templates render its fields, such as
+// {{.Summary}}, and targets without a template get it as JSON.
+type Message struct {
+	Event   Event     `json:"event"`
+	Time    time.Time `json:"time"`
+	Project string    `json:"project"`
+	User    string    `json:"user"`
+	Host    string    `json:"host"`
+	// Summary is a line that describes the event, such as
+	// "devbox update changed devbox.lock in /src/app".
+	Summary string `json:"summary"`
+	// Command is the devbox command that caused the event, if any.
+	Command string `json:"command,omitempty"`
+	// Changes describes the packages whose locked versions changed, such
+	// as "go 1.22.1 -> 1.22.3".
+	Changes []string `json:"changes,omitempty"`
+	// Service is the service that crashed.
+	Service string `json:"service,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Validate returns an error if the target can't be notified.
+func (t *Target) Validate() error {
+	if t.Type != Slack && t.Type != HTTP {
+		return fmt.Errorf("notification type %q isn't one of %s or %s", t.Type, Slack, HTTP)
+	}
+	u, err := url.Parse(t.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%s notification URL %q isn't an http or https URL", t.Type, redactURL(t.URL))
+	}
+	for _, event := range t.Events {
+		if !slices.Contains(Events, event) {
+			return fmt.Errorf("%s notification event %q isn't one of %s", t.Type, event, joinEvents(Events))
+		}
+	}
+	if _, err := parseTemplate(t.Template); err != nil {
+		return fmt.Errorf("%s notification template: %w", t.Type, err)
+	}
+	return nil
+}
+
+// Wants reports whether the target is notified of event.
+func (t *Target) Wants(event Event) bool {
+	return len(t.Events) == 0 || slices.Contains(t.Events, event)
+}
+
+// Send notifies the targets that want msg's event. It notifies every target,
+// even if some fail, and returns their errors joined. The errors don't
+// contain the targets' URLs, which are often secrets, only their hosts.
+func Send(ctx context.Context, targets []*Target, msg Message) error {
+	var errs []error
+	for _, t := range targets {
+		if !t.Wants(msg.Event) {
+			continue
+		}
+		if err := send(ctx, t, msg); err != nil {
+			errs = append(errs, fmt.Errorf("%s notification to %s: %w", t.Type, redactURL(t.URL), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func send(ctx context.Context, t *Target, msg Message) error {
+	body, contentType, err := payload(t, msg)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.URL, bytes.NewReader(body))
+	if err != nil {
+		// The error would contain the URL.
+		return errors.New("invalid request")
+	}
+	req.Header.Set("Content-Type", contentType)
+	res, err := httpclient.Client().Do(req)
+	if err != nil {
+		// Unwrap the *url.Error, which contains the URL.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return err
+	}
+	res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("response %s", res.Status)
+	}
+	return nil
+}
+
+// payload renders the body that's posted to t for msg.
+func payload(t *Target, msg Message) (body []byte, contentType string, err error) {
+	text := ""
+	if t.Template != "" {
+		tmpl, err := parseTemplate(t.Template)
+		if err != nil {
+			return nil, "", err
+		}
+		buf := &bytes.Buffer{}
+		if err := tmpl.Execute(buf, msg); err != nil {
+			return nil, "", err
+		}
+		text = buf.String()
+	}
+
+	switch t.Type {
+	case Slack:
+		if t.Template == "" {
+			text = slackText(msg)
+		}
+		body, err = json.Marshal(map[string]string{"text": text})
+		return body, "application/json", err
+	case HTTP:
+		if t.Template != "" {
+			return []byte(text), "text/plain; charset=utf-8", nil
+		}
+		body, err = json.Marshal(msg)
+		return body, "application/json", err
+	}
+	return nil, "", fmt.Errorf("unknown notification type %q", t.Type)
+}
+
+// slackText is the text of a Slack message without a template: the summary,
+// followed by the changes or the error.
+func slackText(msg Message) string {
+	lines := []string{msg.Summary}
+	for _, change := range msg.Changes {
+		lines = append(lines, "• "+change)
+	}
+	if msg.Error != "" {
+		lines = append(lines, "```"+msg.Error+"```")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func parseTemplate(text string) (*template.Template, error) {
+	return template.New("notification").Funcs(template.FuncMap{
+		"join": strings.Join,
+		"json": func(v any) (string, error) {
+			data, err := json.Marshal(v)
+			return string(data), err
+		},
+	}).Parse(text)
+}
+
+// redactURL returns the scheme and host of a webhook URL, whose path and query
+// usually contain a secret token.
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return "an invalid URL"
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+func joinEvents(events []Event) string {
+	names := make([]string, len(events))
+	for i, e := range events {
+		names[i] = string(e)
+	}
+	return strings.Join(names, ", ")
+}
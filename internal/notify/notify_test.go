@@ -0,0 +1,97 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSend(t *testing.T) {
+	bodies := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies[r.URL.Path] = string(body)
+	}))
+	defer server.Close()
+
+	targets := []*Target{
+		{Type: Slack, URL: server.URL + "/slack"},
+		{Type: HTTP, URL: server.URL + "/http"},
+		{Type: HTTP, URL: server.URL + "/template", Template: `{{.Event}}: {{join .Changes "; "}}`},
+		{Type: Slack, URL: server.URL + "/crashes", Events: []Event{ServiceCrashed}},
+	}
+	msg := Message{
+		Event:   LockfileChanged,
+		Summary: "devbox update changed devbox.lock",
+		Changes: []string{"go 1.22.1 -> 1.22.3", "added jq 1.7.1"},
+	}
+	if err := Send(context.Background(), targets, msg); err != nil {
+		t.Fatal(err)
+	}
+
+	slack := map[string]string{}
+	if err := json.Unmarshal([]byte(bodies["/slack"]), &slack); err != nil {
+		t.Fatal(err)
+	}
+	want := "devbox update changed devbox.lock\n• go 1.22.1 -> 1.22.3\n• added jq 1.7.1"
+	if slack["text"] != want {
+		t.Errorf("got Slack text %q, want %q", slack["text"], want)
+	}
+
+	got := Message{}
+	if err := json.Unmarshal([]byte(bodies["/http"]), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Event != LockfileChanged || len(got.Changes) != 2 {
+		t.Errorf("got http message %+v, want %+v", got, msg)
+	}
+
+	if want := "lockfile_changed: go 1.22.1 -> 1.22.3; added jq 1.7.1"; bodies["/template"] != want {
+		t.Errorf("got templated body %q, want %q", bodies["/template"], want)
+	}
+	if _, ok := bodies["/crashes"]; ok {
+		t.Error("notified a target that only wants service_crashed of lockfile_changed")
+	}
+}
+
+func TestSendErrorHidesURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	targets := []*Target{{Type: Slack, URL: server.URL + "/services/T000/B000/secret-token"}}
+	err := Send(context.Background(), targets, Message{Event: InstallFailed})
+	if err == nil {
+		t.Fatal("got no error for a webhook that responded 403 Forbidden")
+	}
+	if strings.Contains(err.Error(), "secret-token") {
+		t.Errorf("error %q contains the webhook's secret path", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		target  Target
+		wantErr bool
+	}{
+		{Target{Type: Slack, URL: "https://hooks.slack.com/services/x"}, false},
+		{Target{Type: HTTP, URL: "http://localhost:8080/hook", Events: []Event{InstallFailed}}, false},
+		{Target{Type: "email", URL: "https://example.com"}, true},
+		{Target{Type: HTTP, URL: "example.com/hook"}, true},
+		{Target{Type: HTTP, URL: "https://example.com", Events: []Event{"lockfile_deleted"}}, true},
+		{Target{Type: HTTP, URL: "https://example.com", Template: "{{.Summary"}, true},
+	}
+	for _, test := range tests {
+		if err := test.target.Validate(); (err != nil) != test.wantErr {
+			t.Errorf("Validate(%+v) = %v, want error: %t", test.target, err, test.wantErr)
+		}
+	}
+}
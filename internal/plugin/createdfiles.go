@@ -0,0 +1,187 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package plugin
+
+import (
+	"cmp"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+	"go.jetify.com/devbox/internal/cachehash"
+	"go.jetify.com/devbox/internal/cuecfg"
+)
+
+// createdFilesState records the files that plugins have created so that they
+// can be cleaned up once the plugin is no longer part of the project.
+type createdFilesState struct {
+	// Plugins maps a plugin's canonical name to the files it created and the
+	// hash of their content when they were created.
+	Plugins map[string]map[string]string `json:"plugins"`
+}
+
+// CreatedFile is a file that was created by a plugin.
+type CreatedFile struct {
+	Plugin string
+	Path   string
+	// Modified is true if the file was changed since the plugin created it.
+	Modified bool
+}
+
+func createdFilesStatePath(projectDir string) string {
+	return filepath.Join(projectDir, devboxHiddenDirName, "plugin-files.json")
+}
+
+func readCreatedFilesState(projectDir string) (*createdFilesState, error) {
+	state := &createdFilesState{Plugins: map[string]map[string]string{}}
+	err := cuecfg.ParseFile(createdFilesStatePath(projectDir), state)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	if state.Plugins == nil {
+		state.Plugins = map[string]map[string]string{}
+	}
+	return state, nil
+}
+
+func (s *createdFilesState) save(projectDir string) error {
+	return cuecfg.WriteFile(createdFilesStatePath(projectDir), s)
+}
+
+// recordCreatedFiles adds files (path to content hash) to the files created by
+// the plugin.
+func (m *Manager) recordCreatedFiles(pluginName string, files map[string]string) error {
+	if len(files) == 0 {
+		return nil
+	}
+	state, err := readCreatedFilesState(m.ProjectDir())
+	if err != nil {
+		return err
+	}
+	if state.Plugins[pluginName] == nil {
+		state.Plugins[pluginName] = map[string]string{}
+	}
+	maps.Copy(state.Plugins[pluginName], files)
+	return state.save(m.ProjectDir())
+}
+
+// OrphanedFiles returns the files created by plugins that are not in active
+// and that are not created by any of the active plugins. Files that no longer
+// exist are forgotten.
+func (m *Manager) OrphanedFiles(active []*Config) ([]CreatedFile, error) {
+	state, err := readCreatedFilesState(m.ProjectDir())
+	if err != nil {
+		return nil, err
+	}
+	activeNames := map[string]bool{}
+	activeFiles := map[string]bool{}
+	for _, cfg := range active {
+		activeNames[cfg.Source.CanonicalName()] = true
+		for file := range cfg.CreateFiles {
+			activeFiles[file] = true
+		}
+	}
+
+	orphans := []CreatedFile{}
+	changed := false
+	for name, files := range state.Plugins {
+		if activeNames[name] {
+			continue
+		}
+		for path, hash := range files {
+			if activeFiles[path] {
+				continue
+			}
+			if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+				delete(files, path)
+				changed = true
+				continue
+			}
+			current, err := cachehash.File(path)
+			if err != nil {
+				return nil, err
+			}
+			orphans = append(orphans, CreatedFile{
+				Plugin:   name,
+				Path:     path,
+				Modified: current != hash,
+			})
+		}
+		if len(files) == 0 {
+			delete(state.Plugins, name)
+			changed = true
+		}
+	}
+	if changed {
+		if err := state.save(m.ProjectDir()); err != nil {
+			return nil, err
+		}
+	}
+	slices.SortFunc(orphans, func(a, b CreatedFile) int {
+		return cmp.Or(strings.Compare(a.Plugin, b.Plugin), strings.Compare(a.Path, b.Path))
+	})
+	return orphans, nil
+}
+
+// RemoveCreatedFiles deletes files that were created by plugins and stops
+// tracking them.
+func (m *Manager) RemoveCreatedFiles(files []CreatedFile) error {
+	if len(files) == 0 {
+		return nil
+	}
+	state, err := readCreatedFilesState(m.ProjectDir())
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err := os.Remove(file.Path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return errors.WithStack(err)
+		}
+		delete(state.Plugins[file.Plugin], file.Path)
+		if len(state.Plugins[file.Plugin]) == 0 {
+			delete(state.Plugins, file.Plugin)
+		}
+	}
+	return state.save(m.ProjectDir())
+}
+
+// RestoreCreatedFiles creates the files of an active plugin again, overwriting
+// the changes that were made to them since the plugin created them, and
+// recreating the ones that were deleted. It returns the paths of the files.
+func (m *Manager) RestoreCreatedFiles(cfg *Config) ([]string, error) {
+	virtenvPath := filepath.Join(m.ProjectDir(), VirtenvPath)
+	created := map[string]string{}
+	for filePath, contentPath := range cfg.CreateFiles {
+		// Empty content paths create directories, which aren't restored.
+		if contentPath == "" {
+			continue
+		}
+		if err := createDir(filepath.Dir(filePath)); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if err := m.createFile(cfg.Source, filePath, contentPath, virtenvPath); err != nil {
+			return nil, err
+		}
+		hash, err := cachehash.File(filePath)
+		if err != nil {
+			return nil, err
+		}
+		created[filePath] = hash
+	}
+	if err := m.recordCreatedFiles(cfg.Source.CanonicalName(), created); err != nil {
+		return nil, err
+	}
+	return slices.Sorted(maps.Keys(created)), nil
+}
+
+// IsHidden returns true if the file is in the project's .devbox directory.
+// Those files are always safe to delete.
+func (f *CreatedFile) IsHidden(projectDir string) bool {
+	rel, err := filepath.Rel(filepath.Join(projectDir, devboxHiddenDirName), f.Path)
+	return err == nil && !strings.HasPrefix(rel, "..")
+}
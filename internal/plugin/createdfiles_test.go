@@ -0,0 +1,98 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.jetify.com/devbox/nix/flake"
+)
+
+type testProject struct{ dir string }
+
+func (p testProject) AllPackageNamesIncludingRemovedTriggerPackages() []string { return nil }
+func (p testProject) ProjectDir() string                                       { return p.dir }
+
+func TestOrphanedFiles(t *testing.T) {
+	dir := t.TempDir()
+	m := NewManager(WithDevbox(testProject{dir}))
+
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		return path
+	}
+	conf := write("devbox.d/redis/redis.conf", "port 6379")
+	pc := write(".devbox/virtenv/redis/process-compose.yaml", "processes: {}")
+	kept := write("devbox.d/nginx/nginx.conf", "server {}")
+	require.NoError(t, m.recordCreatedFiles("redis", map[string]string{
+		conf: "stale-hash",
+		pc:   "",
+	}))
+	require.NoError(t, m.recordCreatedFiles("nginx", map[string]string{kept: ""}))
+
+	nginx := &Config{PluginOnlyData: PluginOnlyData{
+		Source: &LocalPlugin{name: "nginx"},
+	}}
+	orphans, err := m.OrphanedFiles([]*Config{nginx})
+	require.NoError(t, err)
+	require.Len(t, orphans, 2)
+	assert.Equal(t, pc, orphans[0].Path)
+	assert.True(t, orphans[0].IsHidden(dir))
+	assert.Equal(t, conf, orphans[1].Path)
+	assert.True(t, orphans[1].Modified)
+	assert.False(t, orphans[1].IsHidden(dir))
+
+	require.NoError(t, m.RemoveCreatedFiles(orphans[:1]))
+	assert.NoFileExists(t, pc)
+
+	// Files deleted by the user are forgotten.
+	require.NoError(t, os.Remove(conf))
+	orphans, err = m.OrphanedFiles([]*Config{nginx})
+	require.NoError(t, err)
+	assert.Empty(t, orphans)
+	assert.FileExists(t, kept)
+}
+
+func TestRestoreCreatedFiles(t *testing.T) {
+	dir := t.TempDir()
+	m := NewManager(WithDevbox(testProject{dir}))
+
+	pluginDir := filepath.Join(dir, "plugins", "nginx")
+	require.NoError(t, os.MkdirAll(pluginDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(pluginDir, "nginx.conf"), []byte("server {}"), 0o644))
+	conf := filepath.Join(dir, "devbox.d", "nginx", "nginx.conf")
+	nginx := &Config{
+		PluginOnlyData: PluginOnlyData{
+			Source: &LocalPlugin{ref: flake.Ref{Type: flake.TypePath, Path: pluginDir}, name: "nginx"},
+		},
+		CreateFiles: map[string]string{
+			conf:                                 "nginx.conf",
+			filepath.Join(dir, "devbox.d/nginx"): "",
+		},
+	}
+
+	restored, err := m.RestoreCreatedFiles(nginx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{conf}, restored)
+
+	require.NoError(t, os.WriteFile(conf, []byte("server { listen 8080; }"), 0o644))
+	_, err = m.RestoreCreatedFiles(nginx)
+	require.NoError(t, err)
+	content, err := os.ReadFile(conf)
+	require.NoError(t, err)
+	assert.Equal(t, "server {}", string(content))
+
+	// The restored file is tracked with its new content, so it isn't modified
+	// once the plugin is removed.
+	orphans, err := m.OrphanedFiles(nil)
+	require.NoError(t, err)
+	require.Len(t, orphans, 1)
+	assert.False(t, orphans[0].Modified)
+}
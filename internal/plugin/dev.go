@@ -0,0 +1,142 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package plugin
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+	"go.jetify.com/devbox/internal/cachehash"
+	"go.jetify.com/devbox/nix/flake"
+)
+
+// DevPath is the directory where local plugins under development are
+// symlinked. Plugins in this directory are included in the project without
+// being listed in devbox.json, and none of their state is cached.
+var DevPath = filepath.Join(devboxHiddenDirName, "plugin-dev")
+
+// LinkDevPlugin symlinks the local plugin at pluginPath into the project's
+// plugin development directory and returns the plugin's name.
+func LinkDevPlugin(projectDir, pluginPath string) (string, error) {
+	abs, err := filepath.Abs(strings.TrimSuffix(pluginPath, pluginConfigName))
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	plugin := &LocalPlugin{ref: flake.Ref{Type: flake.TypePath, Path: abs}}
+	if _, err := os.Stat(plugin.Path()); err != nil {
+		return "", usererr.New("no %s found in %s", pluginConfigName, abs)
+	}
+	if err := validateDevPlugin(plugin); err != nil {
+		return "", err
+	}
+	name, err := getPluginNameFromContent(plugin)
+	if err != nil {
+		return "", err
+	}
+
+	devDir := filepath.Join(projectDir, DevPath)
+	if err := os.MkdirAll(devDir, 0o755); err != nil {
+		return "", errors.WithStack(err)
+	}
+	link := filepath.Join(devDir, name)
+	if _, err := os.Lstat(link); err == nil {
+		if err := os.Remove(link); err != nil {
+			return "", errors.WithStack(err)
+		}
+	}
+	return name, errors.WithStack(os.Symlink(abs, link))
+}
+
+// UnlinkDevPlugin removes a plugin from the project's plugin development
+// directory. It accepts either the plugin name or the path that was linked.
+func UnlinkDevPlugin(projectDir, nameOrPath string) error {
+	devDir := filepath.Join(projectDir, DevPath)
+	entries, err := os.ReadDir(devDir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return errors.WithStack(err)
+	}
+	abs, _ := filepath.Abs(nameOrPath)
+	for _, entry := range entries {
+		link := filepath.Join(devDir, entry.Name())
+		target, _ := os.Readlink(link)
+		if entry.Name() == nameOrPath || target == abs {
+			return errors.WithStack(os.Remove(link))
+		}
+	}
+	return usererr.New("plugin %q is not in development mode", nameOrPath)
+}
+
+// DevIncludes returns include refs for every plugin linked into the project's
+// plugin development directory.
+func DevIncludes(projectDir string) []string {
+	entries, err := os.ReadDir(filepath.Join(projectDir, DevPath))
+	if err != nil {
+		return nil
+	}
+	includes := []string{}
+	for _, entry := range entries {
+		includes = append(
+			includes,
+			"path:"+filepath.Join(projectDir, DevPath, entry.Name()),
+		)
+	}
+	return includes
+}
+
+// DevPluginsHash hashes the contents of every file in every plugin under
+// development. Including it in the project's config hash ensures any edit to
+// a plugin causes the environment to be recomputed on the next command.
+func DevPluginsHash(projectDir string) (string, error) {
+	data := []byte{}
+	for _, include := range DevIncludes(projectDir) {
+		root, err := filepath.EvalSymlinks(strings.TrimPrefix(include, "path:"))
+		if err != nil {
+			// Dangling link. The include itself will report a better error.
+			continue
+		}
+		err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			h, err := cachehash.File(path)
+			if err != nil {
+				return err
+			}
+			data = append(data, path...)
+			data = append(data, h...)
+			return nil
+		})
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+	}
+	if len(data) == 0 {
+		return "", nil
+	}
+	return cachehash.Bytes(data), nil
+}
+
+// IsDev returns true if the plugin was linked with `devbox plugin dev`.
+func (l *LocalPlugin) IsDev() bool {
+	sep := string(filepath.Separator)
+	return strings.Contains(l.Path(), sep+DevPath+sep)
+}
+
+// validateDevPlugin checks that a plugin under development is well formed,
+// so that authors get an actionable error before anything is installed.
+func validateDevPlugin(plugin *LocalPlugin) error {
+	content, err := os.ReadFile(plugin.Path())
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if problems := validate(plugin, content); len(problems) > 0 {
+		return usererr.New(
+			"plugin %s is invalid:\n%s", plugin.Path(), strings.Join(problems, "\n"))
+	}
+	return nil
+}
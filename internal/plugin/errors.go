@@ -0,0 +1,81 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package plugin
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+)
+
+// contextLines is the number of lines shown before and after the line that
+// caused an error.
+const contextLines = 2
+
+var (
+	// text/template errors look like:
+	//   template: name:3: unexpected "}" in operand
+	//   template: name:3:14: executing "name" at <.Foo>: map has no entry for key "Foo"
+	templateErrorRegex = regexp.MustCompile(`^template: .*?:(\d+)(?::(\d+))?: (.*)$`)
+
+	// hujson errors look like:
+	//   hujson: line 3, column 5: invalid character '}'
+	jsonErrorRegex = regexp.MustCompile(`^hujson: line (\d+), column (\d+): (.*)$`)
+)
+
+// templateErrorWithContext converts a text/template error into a user error
+// that points at the offending line in file.
+func templateErrorWithContext(file string, content []byte, err error) error {
+	return errorWithContext(templateErrorRegex, file, content, err)
+}
+
+// jsonErrorWithContext converts a hujson syntax error into a user error that
+// points at the offending line in file.
+func jsonErrorWithContext(file string, content []byte, err error) error {
+	return errorWithContext(jsonErrorRegex, file, content, err)
+}
+
+func errorWithContext(re *regexp.Regexp, file string, content []byte, err error) error {
+	if err == nil {
+		return nil
+	}
+	matches := re.FindStringSubmatch(err.Error())
+	if matches == nil {
+		return usererr.WithUserMessage(err, "error in %s", file)
+	}
+	line, _ := strconv.Atoi(matches[1])
+	column, _ := strconv.Atoi(matches[2])
+	location := fmt.Sprintf("%s:%d", file, line)
+	if column > 0 {
+		location = fmt.Sprintf("%s:%d", location, column)
+	}
+	return usererr.New(
+		"%s: %s\n%s", location, matches[3], sourceContext(content, line, column),
+	)
+}
+
+// sourceContext returns the lines surrounding line (1-indexed) with the line
+// itself marked. If column is non-zero, a caret is printed underneath it.
+func sourceContext(content []byte, line, column int) string {
+	lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+	width := len(strconv.Itoa(min(line+contextLines, len(lines))))
+	buf := strings.Builder{}
+	for i := max(line-contextLines, 1); i <= min(line+contextLines, len(lines)); i++ {
+		marker := "  "
+		if i == line {
+			marker = "> "
+		}
+		fmt.Fprintf(&buf, "%s%*d | %s\n", marker, width, i, lines[i-1])
+		if i == line && column > 0 {
+			fmt.Fprintf(&buf, "  %s | %s^\n", strings.Repeat(" ", width), strings.Repeat(" ", column-1))
+		}
+	}
+	return buf.String()
+}
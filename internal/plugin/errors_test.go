@@ -0,0 +1,42 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package plugin
+
+import (
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplateErrorWithContext(t *testing.T) {
+	content := "{\n  \"name\": \"test\",\n  \"env\": {\n    \"FOO\": \"{{ .Foo }\"\n  }\n}\n"
+	_, err := template.New("test-template").Parse(content)
+	require.Error(t, err)
+
+	err = templateErrorWithContext("my-plugin/plugin.json", []byte(content), err)
+	msg := err.Error()
+	assert.True(t, strings.HasPrefix(msg, "my-plugin/plugin.json:4: "), msg)
+	assert.Contains(t, msg, `> 4 |     "FOO": "{{ .Foo }"`)
+	assert.Contains(t, msg, `  2 |   "name": "test",`)
+	assert.NotContains(t, msg, `1 | {`)
+}
+
+func TestJSONErrorWithContext(t *testing.T) {
+	content := []byte("{\n  \"name\": \"test\"\n  \"version\": \"0.0.1\"\n}\n")
+	_, err := jsonPurifyPluginContent(content)
+	require.Error(t, err)
+
+	msg := jsonErrorWithContext("plugin.json", content, err).Error()
+	assert.True(t, strings.HasPrefix(msg, "plugin.json:3:3: "), msg)
+	assert.Contains(t, msg, `> 3 |   "version": "0.0.1"`)
+	assert.Contains(t, msg, "    |   ^\n")
+}
+
+func TestSourceContextOutOfRange(t *testing.T) {
+	assert.Empty(t, sourceContext([]byte("a\nb\n"), 10, 0))
+	assert.Empty(t, sourceContext([]byte("a\nb\n"), 0, 0))
+}
@@ -31,6 +31,11 @@ func getConfigIfAny(inc Includable, projectDir string) (*Config, error) {
 		}
 		return buildConfig(includable, projectDir, string(content))
 	case *LocalPlugin:
+		if includable.IsDev() {
+			if err := validateDevPlugin(includable); err != nil {
+				return nil, err
+			}
+		}
 		content, err := os.ReadFile(includable.Path())
 		if err != nil && !os.IsNotExist(err) {
 			return nil, errors.WithStack(err)
@@ -54,7 +59,11 @@ func getBuiltinPluginConfigIfExists(
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
-	return buildConfig(pkg, projectDir, string(content))
+	cfg, err := buildConfig(pkg, projectDir, string(content))
+	if err != nil {
+		return nil, err
+	}
+	return cfg, applyOverrides(cfg, pkg.PluginOverrides, projectDir)
 }
 
 func GetBuiltinsForPackages(
@@ -11,11 +11,12 @@
 	"strings"
 	"time"
 
+	"go.jetify.com/devbox/internal/state"
 	"go.jetify.com/devbox/nix/flake"
 	"go.jetify.com/pkg/filecache"
 )
 
-var gitCache = filecache.New[[]byte]("devbox/plugin/git")
+var gitCache = filecache.New("devbox/plugin/git", filecache.WithCacheDir[[]byte](state.FileCacheDir()))
 
 type gitPlugin struct {
 	ref  *flake.Ref
@@ -16,11 +16,36 @@
 	"github.com/samber/lo"
 	"go.jetify.com/devbox/internal/boxcli/usererr"
 	"go.jetify.com/devbox/internal/cachehash"
+	"go.jetify.com/devbox/internal/httpclient"
+	"go.jetify.com/devbox/internal/state"
 	"go.jetify.com/devbox/nix/flake"
 	"go.jetify.com/pkg/filecache"
 )
 
-var githubCache = filecache.New[[]byte]("devbox/plugin/github")
+// githubRawURL is where plugin files are downloaded from. It is a variable so
+// that tests can point it at a local server.
+var githubRawURL = "https://raw.githubusercontent.com/"
+
+// githubAPIURL is the default GitHub API URL, used when raw.githubusercontent.com
+// can't be used.
+var githubAPIURL = "https://api.github.com/"
+
+var githubCache = filecache.New(
+	"devbox/plugin/github-content",
+	filecache.WithCacheDir[githubContent](state.FileCacheDir()),
+)
+
+// errGithubUnavailable is wrapped by errors that happen when GitHub can't be
+// reached. Stale cached content is used instead, if there is any.
+var errGithubUnavailable = errors.New("github is unavailable")
+
+// githubContent is a cached plugin file along with the validators needed to
+// make a conditional request for it once the cache entry expires.
+type githubContent struct {
+	Body         []byte
+	ETag         string
+	LastModified string
+}
 
 type githubPlugin struct {
 	ref  flake.Ref
@@ -31,8 +56,13 @@ type githubPlugin struct {
 // but we clean up just in case.
 var githubNameRegexp = regexp.MustCompile("[^a-zA-Z0-9-_.]+")
 
+var (
+	githubHostEnvRegexp = regexp.MustCompile("[^A-Z0-9]+")
+	githubRevRegexp     = regexp.MustCompile("^[0-9a-f]{40}$")
+)
+
 func newGithubPlugin(ref flake.Ref) (*githubPlugin, error) {
-	plugin := &githubPlugin{ref: ref}
+	plugin := &githubPlugin{ref: normalizeGithubRef(ref)}
 	// For backward compatibility, we don't strictly require name to be present
 	// in github plugins. If it's missing, we just use the directory as the name.
 	name, err := getPluginNameFromContent(plugin)
@@ -43,7 +73,7 @@ func newGithubPlugin(ref flake.Ref) (*githubPlugin, error) {
 		name = strings.ReplaceAll(ref.Dir, "/", "-")
 	}
 	plugin.name = githubNameRegexp.ReplaceAllString(
-		strings.Join(lo.Compact([]string{ref.Owner, ref.Repo, name}), "."),
+		strings.Join(lo.Compact([]string{plugin.ref.Owner, plugin.ref.Repo, name}), "."),
 		" ",
 	)
 	return plugin, nil
@@ -71,9 +101,9 @@ func (p *githubPlugin) FileContent(subpath string) ([]byte, error) {
 		return nil, err
 	}
 
-	// Cache for 24 hours. Once we store the plugin in the lockfile, we
-	// should cache this indefinitely and only invalidate if the plugin
-	// is updated.
+	// Cache for 24 hours. After that, the content is revalidated with a
+	// conditional request, which doesn't count against GitHub rate limits if
+	// the content hasn't changed.
 	ttl := 24 * time.Hour
 
 	// This is a stopgap until plugin is stored in lockfile.
@@ -90,52 +120,148 @@ func (p *githubPlugin) FileContent(subpath string) ([]byte, error) {
 		}
 	}
 
-	return githubCache.GetOrSet(
-		contentURL+ttl.String(),
-		func() ([]byte, time.Duration, error) {
-			req, err := p.request(contentURL)
-			if err != nil {
-				return nil, 0, err
-			}
-
-			client := &http.Client{}
-			res, err := client.Do(req)
-			if err != nil {
-				return nil, 0, err
-			}
-			defer res.Body.Close()
-			if res.StatusCode != http.StatusOK {
-				authInfo := "No auth header was sent with this request."
-				if req.Header.Get("Authorization") != "" {
-					authInfo = fmt.Sprintf(
-						"The auth header `%s` was sent with this request.",
-						getRedactedAuthHeader(req),
-					)
-				}
-				return nil, 0, usererr.New(
-					"failed to get plugin %s @ %s (Status code %d).\n%s\nPlease make "+
-						"sure a plugin.json file exists in plugin directory.",
-					p.LockfileKey(),
-					req.URL.String(),
-					res.StatusCode,
-					authInfo,
-				)
-			}
-			body, err := io.ReadAll(res.Body)
-			if err != nil {
-				return nil, 0, err
-			}
-
-			return body, ttl, nil
-		},
+	cached, err := githubCache.Get(contentURL)
+	if err == nil {
+		return cached.Body, nil
+	}
+	if !filecache.IsCacheMiss(err) {
+		slog.Debug("ignoring unreadable github plugin cache entry", "url", contentURL, "err", err)
+		cached = githubContent{}
+	}
+
+	content, err := p.fetch(contentURL, subpath, cached)
+	if errors.Is(err, errGithubUnavailable) && cached.Body != nil {
+		slog.Warn("using stale cached plugin content", "url", contentURL, "err", err)
+		return cached.Body, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := githubCache.Set(contentURL, content, ttl); err != nil {
+		return nil, err
+	}
+	return content.Body, nil
+}
+
+// fetch downloads a plugin file from raw.githubusercontent.com. If that host
+// can't be reached, or if it returns a 404 (which it does for private repos
+// when the token can't be used), the file is fetched with the GitHub contents
+// API instead.
+func (p *githubPlugin) fetch(
+	contentURL, subpath string,
+	cached githubContent,
+) (githubContent, error) {
+	req, err := p.request(contentURL)
+	if err != nil {
+		return githubContent{}, err
+	}
+	content, err := fetchGithubContent(req, cached)
+	var statusErr *githubStatusError
+	isNotFound := errors.As(err, &statusErr) && statusErr.statusCode == http.StatusNotFound
+	if err == nil || (!isNotFound && !errors.Is(err, errGithubUnavailable)) {
+		return content, p.userError(err)
+	}
+
+	apiReq, apiErr := p.apiRequest(subpath)
+	if apiErr != nil {
+		return githubContent{}, apiErr
+	}
+	slog.Debug("falling back to the github contents api", "url", apiReq.URL, "err", err)
+	content, apiErr = fetchGithubContent(apiReq, cached)
+	if apiErr == nil {
+		return content, nil
+	}
+	slog.Debug("github contents api request failed", "url", apiReq.URL, "err", apiErr)
+	// Report the original error. It's usually more meaningful because the
+	// API is only a fallback.
+	return githubContent{}, p.userError(err)
+}
+
+// userError converts a githubStatusError into an error that explains how to
+// fix it. Other errors are returned as is.
+func (p *githubPlugin) userError(err error) error {
+	var statusErr *githubStatusError
+	if !errors.As(err, &statusErr) {
+		return err
+	}
+	req := statusErr.req
+	authInfo := "No auth header was sent with this request."
+	if req.Header.Get("Authorization") != "" {
+		authInfo = fmt.Sprintf(
+			"The auth header `%s` was sent with this request.",
+			getRedactedAuthHeader(req),
+		)
+	}
+	return usererr.New(
+		"failed to get plugin %s @ %s (Status code %d).\n%s\nPlease make "+
+			"sure a plugin.json file exists in plugin directory.",
+		p.LockfileKey(),
+		req.URL.String(),
+		statusErr.statusCode,
+		authInfo,
 	)
 }
 
+// githubStatusError is returned when GitHub responds with an unexpected
+// status code.
+type githubStatusError struct {
+	req        *http.Request
+	statusCode int
+}
+
+func (e *githubStatusError) Error() string {
+	return fmt.Sprintf("%s returned status code %d", e.req.URL, e.statusCode)
+}
+
+// fetchGithubContent sends req. If cached has an ETag or Last-Modified time,
+// the request is conditional and cached is returned if the content is
+// unchanged. Network failures and server errors wrap errGithubUnavailable.
+func fetchGithubContent(req *http.Request, cached githubContent) (githubContent, error) {
+	if cached.Body != nil {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	res, err := httpclient.Client().Do(req)
+	if err != nil {
+		return githubContent{}, fmt.Errorf("%w: %w", errGithubUnavailable, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotModified && cached.Body != nil {
+		slog.Debug("github plugin content not modified", "url", req.URL)
+		return cached, nil
+	}
+	if res.StatusCode >= http.StatusInternalServerError {
+		return githubContent{}, fmt.Errorf(
+			"%w: %s returned %s", errGithubUnavailable, req.URL, res.Status)
+	}
+	if res.StatusCode != http.StatusOK {
+		return githubContent{}, &githubStatusError{req: req, statusCode: res.StatusCode}
+	}
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return githubContent{}, fmt.Errorf("%w: %w", errGithubUnavailable, err)
+	}
+	return githubContent{
+		Body:         body,
+		ETag:         res.Header.Get("ETag"),
+		LastModified: res.Header.Get("Last-Modified"),
+	}, nil
+}
+
 func (p *githubPlugin) url(subpath string) (string, error) {
 	// Github redirects "master" to "main" in new repos. They don't do the reverse
 	// so setting master here is better.
+	rawURL := githubRawURL
+	if p.isEnterprise() {
+		rawURL = "https://" + p.ref.Host + "/raw/"
+	}
 	return url.JoinPath(
-		"https://raw.githubusercontent.com/",
+		rawURL,
 		p.ref.Owner,
 		p.ref.Repo,
 		cmp.Or(p.ref.Rev, p.ref.Ref, "master"),
@@ -151,13 +277,13 @@ func (p *githubPlugin) request(contentURL string) (*http.Request, error) {
 	}
 
 	// Add github token to request if available
-	ghToken := os.Getenv("GITHUB_TOKEN")
+	tokenEnv, ghToken := p.token()
 
 	if ghToken != "" {
 		authValue := fmt.Sprintf("token %s", ghToken)
 		req.Header.Add("Authorization", authValue)
 		slog.Debug(
-			"GITHUB_TOKEN env var found, adding to request's auth header",
+			tokenEnv+" env var found, adding to request's auth header",
 			"headerValue",
 			getRedactedAuthHeader(req),
 		)
@@ -166,6 +292,85 @@ func (p *githubPlugin) request(contentURL string) (*http.Request, error) {
 	return req, nil
 }
 
+// token returns the token used to authenticate with the plugin's GitHub host
+// and the name of the env var it came from. GITHUB_TOKEN is only sent to
+// github.com. GitHub Enterprise hosts use a token specific to the host, such
+// as GITHUB_TOKEN_GHE_MYCORP_COM for ghe.mycorp.com, or GH_ENTERPRISE_TOKEN.
+func (p *githubPlugin) token() (envVar, token string) {
+	envVars := []string{"GITHUB_TOKEN"}
+	if p.isEnterprise() {
+		envVars = []string{
+			"GITHUB_TOKEN_" + githubHostEnvRegexp.ReplaceAllString(strings.ToUpper(p.ref.Host), "_"),
+			"GH_ENTERPRISE_TOKEN",
+			"GITHUB_ENTERPRISE_TOKEN",
+		}
+	}
+	for _, envVar := range envVars {
+		if token := os.Getenv(envVar); token != "" {
+			return envVar, token
+		}
+	}
+	return "", ""
+}
+
+// isEnterprise returns true if the plugin is hosted on a GitHub Enterprise
+// Server instance instead of github.com.
+func (p *githubPlugin) isEnterprise() bool {
+	return p.ref.Host != "" && p.ref.Host != "github.com"
+}
+
+// normalizeGithubRef converts refs that start with a hostname, such as
+// github:ghe.mycorp.com/org/repo, into refs with a host parameter. GitHub
+// doesn't allow periods in owner names, so an owner with a period must be a
+// host.
+func normalizeGithubRef(ref flake.Ref) flake.Ref {
+	if ref.Host != "" || !strings.Contains(ref.Owner, ".") {
+		return ref
+	}
+	repo, revOrRef, _ := strings.Cut(cmp.Or(ref.Ref, ref.Rev), "/")
+	ref.Host, ref.Owner, ref.Repo = ref.Owner, ref.Repo, repo
+	ref.Ref, ref.Rev = "", ""
+	if githubRevRegexp.MatchString(revOrRef) {
+		ref.Rev = revOrRef
+	} else {
+		ref.Ref = revOrRef
+	}
+	return ref
+}
+
+// apiRequest returns a request for a plugin file using the GitHub contents
+// API. For github.com, the API URL can be changed with GITHUB_API_URL.
+func (p *githubPlugin) apiRequest(subpath string) (*http.Request, error) {
+	baseURL := cmp.Or(os.Getenv("GITHUB_API_URL"), githubAPIURL)
+	if p.isEnterprise() {
+		baseURL = "https://" + p.ref.Host + "/api/v3/"
+	}
+	apiURL, err := url.JoinPath(
+		baseURL,
+		"repos",
+		p.ref.Owner,
+		p.ref.Repo,
+		"contents",
+		p.ref.Dir,
+		subpath,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if rev := cmp.Or(p.ref.Rev, p.ref.Ref); rev != "" {
+		apiURL += "?ref=" + url.QueryEscape(rev)
+	}
+	req, err := p.request(apiURL)
+	if err != nil {
+		return nil, err
+	}
+	// Ask for the raw file content instead of a JSON object with the content
+	// base64 encoded.
+	req.Header.Set("Accept", "application/vnd.github.raw")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	return req, nil
+}
+
 func (p *githubPlugin) LockfileKey() string {
 	return p.ref.String()
 }
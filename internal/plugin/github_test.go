@@ -2,8 +2,10 @@
 
 import (
 	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/samber/lo"
 	"github.com/stretchr/testify/assert"
@@ -74,6 +76,36 @@ func TestNewGithubPlugin(t *testing.T) {
 			},
 			expectedURL: "https://raw.githubusercontent.com/jetify-com/devbox-plugins/initials/my-branch/mongodb",
 		},
+		{
+			name:    "parse github enterprise plugin with host in path",
+			Include: "github:ghe.mycorp.com/org/plugins/my-branch?dir=mongodb",
+			expected: githubPlugin{
+				ref: flake.Ref{
+					Type:  "github",
+					Host:  "ghe.mycorp.com",
+					Owner: "org",
+					Repo:  "plugins",
+					Ref:   "my-branch",
+					Dir:   "mongodb",
+				},
+				name: "org.plugins.mongodb",
+			},
+			expectedURL: "https://ghe.mycorp.com/raw/org/plugins/my-branch/mongodb",
+		},
+		{
+			name:    "parse github enterprise plugin with host param",
+			Include: "github:org/plugins?host=ghe.mycorp.com",
+			expected: githubPlugin{
+				ref: flake.Ref{
+					Type:  "github",
+					Host:  "ghe.mycorp.com",
+					Owner: "org",
+					Repo:  "plugins",
+				},
+				name: "org.plugins",
+			},
+			expectedURL: "https://ghe.mycorp.com/raw/org/plugins/master",
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -95,10 +127,10 @@ func newGithubPluginForTest(include string) (*githubPlugin, error) {
 		return nil, err
 	}
 
-	plugin := &githubPlugin{ref: ref}
+	plugin := &githubPlugin{ref: normalizeGithubRef(ref)}
 	name := strings.ReplaceAll(ref.Dir, "/", "-")
 	plugin.name = githubNameRegexp.ReplaceAllString(
-		strings.Join(lo.Compact([]string{ref.Owner, ref.Repo, name}), "."),
+		strings.Join(lo.Compact([]string{plugin.ref.Owner, plugin.ref.Repo, name}), "."),
 		" ",
 	)
 	return plugin, nil
@@ -137,6 +169,34 @@ func TestGithubPluginAuth(t *testing.T) {
 	})
 }
 
+func TestGithubEnterprisePluginAuth(t *testing.T) {
+	plugin := githubPlugin{
+		ref: flake.Ref{
+			Type:  "github",
+			Host:  "ghe.mycorp.com",
+			Owner: "org",
+			Repo:  "plugins",
+		},
+	}
+	t.Setenv("GITHUB_TOKEN", "gh_public")
+	t.Setenv("GH_ENTERPRISE_TOKEN", "")
+
+	req, err := plugin.request("https://ghe.mycorp.com/raw/org/plugins/master/plugin.json")
+	assert.NoError(t, err)
+	assert.Equal(t, "", req.Header.Get("Authorization"), "GITHUB_TOKEN must not be sent to other hosts")
+
+	t.Setenv("GH_ENTERPRISE_TOKEN", "ghe_shared")
+	req, err = plugin.request("https://ghe.mycorp.com/raw/org/plugins/master/plugin.json")
+	assert.NoError(t, err)
+	assert.Equal(t, "token ghe_shared", req.Header.Get("Authorization"))
+
+	t.Setenv("GITHUB_TOKEN_GHE_MYCORP_COM", "ghe_host")
+	req, err = plugin.apiRequest("plugin.json")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://ghe.mycorp.com/api/v3/repos/org/plugins/contents/plugin.json", req.URL.String())
+	assert.Equal(t, "token ghe_host", req.Header.Get("Authorization"))
+}
+
 func TestGetRedactedAuthHeader(t *testing.T) {
 	testCases := []struct {
 		name       string
@@ -174,3 +234,96 @@ func TestGetRedactedAuthHeader(t *testing.T) {
 		})
 	}
 }
+
+func TestGithubPluginConditionalRequests(t *testing.T) {
+	if err := githubCache.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = githubCache.Clear() })
+	t.Setenv("DEVBOX_X_GITHUB_PLUGIN_CACHE_TTL", "1ns")
+
+	requests := 0
+	down := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if down {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte(`{"name": "test"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	oldURL := githubRawURL
+	githubRawURL = server.URL
+	t.Cleanup(func() { githubRawURL = oldURL })
+
+	plugin := githubPlugin{ref: flake.Ref{Type: "github", Owner: "org", Repo: "repo"}}
+
+	content, err := plugin.FileContent("plugin.json")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"name": "test"}`, string(content))
+
+	// The cache entry has expired, so this is a conditional request.
+	time.Sleep(time.Millisecond)
+	content, err = plugin.FileContent("plugin.json")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"name": "test"}`, string(content))
+	assert.Equal(t, 2, requests)
+
+	// Stale content is used when GitHub is unavailable.
+	down = true
+	time.Sleep(time.Millisecond)
+	content, err = plugin.FileContent("plugin.json")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"name": "test"}`, string(content))
+	assert.Equal(t, 3, requests)
+}
+
+func TestGithubPluginAPIFallback(t *testing.T) {
+	if err := githubCache.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = githubCache.Clear() })
+
+	raw := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	t.Cleanup(raw.Close)
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/org/repo/contents/my-plugin/plugin.json" ||
+			r.URL.Query().Get("ref") != "v1" ||
+			r.Header.Get("Accept") != "application/vnd.github.raw" ||
+			r.Header.Get("Authorization") != "token secret-token" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"name": "private"}`))
+	}))
+	t.Cleanup(api.Close)
+
+	oldURL := githubRawURL
+	githubRawURL = raw.URL
+	t.Cleanup(func() { githubRawURL = oldURL })
+	t.Setenv("GITHUB_API_URL", api.URL)
+	t.Setenv("GITHUB_TOKEN", "secret-token")
+
+	plugin := githubPlugin{ref: flake.Ref{
+		Type:  "github",
+		Owner: "org",
+		Repo:  "repo",
+		Ref:   "v1",
+		Dir:   "my-plugin",
+	}}
+	content, err := plugin.FileContent("plugin.json")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"name": "private"}`, string(content))
+
+	_, err = plugin.FileContent("missing.json")
+	assert.ErrorContains(t, err, "Status code 404")
+}
@@ -17,6 +17,8 @@
 
 	"github.com/pkg/errors"
 	"github.com/tailscale/hujson"
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+	"go.jetify.com/devbox/internal/cachehash"
 	"go.jetify.com/devbox/internal/devconfig/configfile"
 	"go.jetify.com/devbox/internal/devpkg"
 	"go.jetify.com/devbox/internal/lock"
@@ -83,8 +85,9 @@ func (m *Manager) CreateFilesForConfig(cfg *Config) error {
 	}
 
 	slog.Debug("creating files for package", "pkg", pkg)
+	created := map[string]string{}
 	for filePath, contentPath := range cfg.CreateFiles {
-		if !m.shouldCreateFile(locked, filePath) {
+		if !m.shouldCreateFile(cfg, locked, filePath) {
 			continue
 		}
 
@@ -103,9 +106,14 @@ func (m *Manager) CreateFilesForConfig(cfg *Config) error {
 		if err := m.createFile(pkg, filePath, contentPath, virtenvPath); err != nil {
 			return err
 		}
+		hash, err := cachehash.File(filePath)
+		if err != nil {
+			return err
+		}
+		created[filePath] = hash
 	}
 
-	return nil
+	return m.recordCreatedFiles(name, created)
 }
 
 func (m *Manager) UpdateLockfileVersion(cfg *Config) error {
@@ -132,7 +140,7 @@ func (m *Manager) createFile(
 	}
 	tmpl, err := template.New(filePath + "-template").Parse(string(content))
 	if err != nil {
-		return errors.WithStack(err)
+		return templateErrorWithContext(sourceFile(pkg, contentPath), content, err)
 	}
 
 	var urlForInput, attributePath string
@@ -149,14 +157,14 @@ func (m *Manager) createFile(
 	if err = tmpl.Execute(&buf, map[string]any{
 		"DevboxDir":            filepath.Join(m.ProjectDir(), devboxDirName, name),
 		"DevboxDirRoot":        filepath.Join(m.ProjectDir(), devboxDirName),
-		"DevboxProfileDefault": filepath.Join(m.ProjectDir(), nix.ProfilePath),
+		"DevboxProfileDefault": nix.ProjectProfilePath(m.ProjectDir()),
 		"PackageAttributePath": attributePath,
 		"Packages":             m.AllPackageNamesIncludingRemovedTriggerPackages(),
 		"System":               nix.System(),
 		"URLForInput":          urlForInput,
 		"Virtenv":              filepath.Join(virtenvPath, name),
 	}); err != nil {
-		return errors.WithStack(err)
+		return templateErrorWithContext(sourceFile(pkg, contentPath), content, err)
 	}
 	var fileMode fs.FileMode = 0o644
 	if strings.Contains(filePath, "bin/") {
@@ -180,17 +188,13 @@ func buildConfig(pkg Includable, projectDir, content string) (*Config, error) {
 	name := pkg.CanonicalName()
 	t, err := template.New(name + "-template").Parse(content)
 	if err != nil {
-		return nil, errors.WithStack(err)
+		return nil, templateErrorWithContext(
+			sourceFile(pkg, pluginConfigName), []byte(content), err)
 	}
 	var buf bytes.Buffer
-	if err = t.Execute(&buf, map[string]string{
-		"DevboxProjectDir":     projectDir,
-		"DevboxDir":            filepath.Join(projectDir, devboxDirName, name),
-		"DevboxDirRoot":        filepath.Join(projectDir, devboxDirName),
-		"DevboxProfileDefault": filepath.Join(projectDir, nix.ProfilePath),
-		"Virtenv":              filepath.Join(projectDir, VirtenvPath, name),
-	}); err != nil {
-		return nil, errors.WithStack(err)
+	if err = t.Execute(&buf, configTemplateData(projectDir, name)); err != nil {
+		return nil, templateErrorWithContext(
+			sourceFile(pkg, pluginConfigName), []byte(content), err)
 	}
 
 	jsonb, err := jsonPurifyPluginContent(buf.Bytes())
@@ -201,6 +205,56 @@ func buildConfig(pkg Includable, projectDir, content string) (*Config, error) {
 	return cfg, errors.WithStack(json.Unmarshal(jsonb, cfg))
 }
 
+// configTemplateData returns the values available to plugin.json templates.
+func configTemplateData(projectDir, name string) map[string]string {
+	return map[string]string{
+		"DevboxProjectDir":     projectDir,
+		"DevboxDir":            filepath.Join(projectDir, devboxDirName, name),
+		"DevboxDirRoot":        filepath.Join(projectDir, devboxDirName),
+		"DevboxProfileDefault": nix.ProjectProfilePath(projectDir),
+		"Virtenv":              filepath.Join(projectDir, VirtenvPath, name),
+	}
+}
+
+// applyOverrides replaces values in cfg with the ones set by the user in
+// devbox.json. Override values are templates, just like plugin.json.
+func applyOverrides(
+	cfg *Config,
+	overrides *configfile.PluginOverrides,
+	projectDir string,
+) error {
+	if overrides == nil {
+		return nil
+	}
+	if cfg.Env == nil && len(overrides.Env) > 0 {
+		cfg.Env = map[string]string{}
+	}
+	data := configTemplateData(projectDir, cfg.Source.CanonicalName())
+	for name, value := range overrides.Env {
+		t, err := template.New(name + "-override").Parse(value)
+		if err != nil {
+			return usererr.WithUserMessage(
+				err, "invalid plugin_overrides env %s for %s", name, cfg.Source.CanonicalName())
+		}
+		var buf bytes.Buffer
+		if err := t.Execute(&buf, data); err != nil {
+			return usererr.WithUserMessage(
+				err, "invalid plugin_overrides env %s for %s", name, cfg.Source.CanonicalName())
+		}
+		cfg.Env[name] = buf.String()
+	}
+	return nil
+}
+
+// sourceFile returns a human-readable name for a file in a plugin. It is used
+// to point users at the file that caused an error.
+func sourceFile(pkg Includable, subpath string) string {
+	if local, ok := pkg.(*LocalPlugin); ok {
+		return filepath.Join(filepath.Dir(local.Path()), subpath)
+	}
+	return filepath.Join(pkg.CanonicalName(), subpath)
+}
+
 func jsonPurifyPluginContent(content []byte) ([]byte, error) {
 	return hujson.Standardize(slices.Clone(content))
 }
@@ -231,11 +285,18 @@ func createSymlink(root, filePath string) error {
 }
 
 func (m *Manager) shouldCreateFile(
+	cfg *Config,
 	pkg *lock.Package,
 	filePath string,
 ) bool {
 	sep := string(filepath.Separator)
 
+	// Plugins under development are always recreated so that authors see
+	// their changes immediately.
+	if local, ok := cfg.Source.(*LocalPlugin); ok && local.IsDev() {
+		return true
+	}
+
 	// Only create files in devbox.d directory if they are not in the lockfile
 	pluginInstalled := pkg != nil && pkg.PluginVersion != ""
 	if strings.Contains(filePath, sep+devboxDirName+sep) && pluginInstalled {
@@ -0,0 +1,75 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package plugin
+
+import (
+	"embed"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+)
+
+// The scaffold templates use [[ ]] delimiters so that the {{ }} plugin
+// templates they contain are written out verbatim.
+//
+//go:embed scaffold/*
+var scaffoldFS embed.FS
+
+// Scaffold creates a new plugin called name in dir. The plugin has a
+// plugin.json, a README and an example service, and passes Validate.
+func Scaffold(dir, name string) error {
+	if !nameRegex.MatchString(name) {
+		return usererr.New("invalid plugin name %q. Name must match %s", name, nameRegex)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return errors.WithStack(err)
+	}
+	if len(entries) > 0 {
+		return usererr.New("directory %s is not empty", dir)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return errors.WithStack(err)
+	}
+
+	data := map[string]string{
+		"Name":      name,
+		"Dir":       dir,
+		"EnvPrefix": envPrefix(name),
+	}
+	files, err := fs.Glob(scaffoldFS, "scaffold/*")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for _, file := range files {
+		tmpl, err := template.New(filepath.Base(file)).
+			Delims("[[", "]]").
+			ParseFS(scaffoldFS, file)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		f, err := os.Create(filepath.Join(dir, filepath.Base(file)))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		err = tmpl.Execute(f, data)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// envPrefix turns a plugin name into a prefix for its environment variables.
+func envPrefix(name string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "_", " ", "_").Replace(name))
+}
@@ -0,0 +1,89 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package plugin
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+
+	"github.com/pkg/errors"
+	"go.jetify.com/devbox/internal/devpkg"
+	"go.jetify.com/devbox/internal/services"
+)
+
+// Plugin types reported in a Summary.
+const (
+	TypeBuiltin = "builtin"
+	TypeLocal   = "local"
+	TypeGitHub  = "github"
+	TypeGit     = "git"
+)
+
+// Summary describes an active plugin and what it contributes to the
+// environment.
+type Summary struct {
+	Name        string `json:"name"`
+	Version     string `json:"version,omitempty"`
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+
+	// Source is the ref the plugin was loaded from. For builtin plugins this
+	// is the package that triggered the plugin.
+	Source string `json:"source"`
+	// Rev is the git revision or ref that github and git plugins are pinned
+	// to, if any.
+	Rev string `json:"rev,omitempty"`
+	// Dev is true for plugins linked with `devbox plugin dev`.
+	Dev bool `json:"dev,omitempty"`
+
+	Env         map[string]string `json:"env,omitempty"`
+	CreateFiles []string          `json:"create_files,omitempty"`
+	Services    []string          `json:"services,omitempty"`
+}
+
+// Summarize returns a Summary of the plugin.
+func (c *Config) Summarize() (*Summary, error) {
+	summary := &Summary{
+		Name:        cmp.Or(c.Name, c.Source.CanonicalName()),
+		Version:     c.Version,
+		Description: c.Description(),
+		Source:      c.Source.LockfileKey(),
+		Env:         c.Env,
+	}
+	switch source := c.Source.(type) {
+	case *devpkg.Package:
+		summary.Type = TypeBuiltin
+	case *LocalPlugin:
+		summary.Type = TypeLocal
+		summary.Dev = source.IsDev()
+	case *githubPlugin:
+		summary.Type = TypeGitHub
+		summary.Rev = cmp.Or(source.ref.Rev, source.ref.Ref)
+	case *gitPlugin:
+		summary.Type = TypeGit
+		summary.Rev = cmp.Or(source.ref.Rev, source.ref.Ref)
+	}
+
+	for _, file := range slices.Sorted(maps.Keys(c.CreateFiles)) {
+		// Empty content paths create directories, which aren't interesting.
+		if c.CreateFiles[file] != "" {
+			summary.CreateFiles = append(summary.CreateFiles, file)
+		}
+	}
+
+	if _, contentPath := c.ProcessComposeYaml(); contentPath != "" {
+		content, err := c.Source.FileContent(contentPath)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		names, err := services.NamesFromProcessCompose(content)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		slices.Sort(names)
+		summary.Services = names
+	}
+	return summary, nil
+}
@@ -0,0 +1,216 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package plugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+
+	"go.jetify.com/devbox/internal/nix"
+	"go.jetify.com/devbox/internal/services"
+	"go.jetify.com/devbox/nix/flake"
+)
+
+// validationProjectDir is a stand-in project directory used to render
+// templates when a plugin is validated outside of a project.
+const validationProjectDir = "/devbox-project"
+
+// pluginFields are the top-level fields allowed in a plugin.json. They mirror
+// .schema/devbox-plugin.schema.json.
+var pluginFields = []string{
+	"$schema",
+	"__remove_trigger_package",
+	"create_files",
+	"description",
+	"env",
+	"include",
+	"name",
+	"packages",
+	"readme",
+	"shell",
+	"tests",
+	"version",
+}
+
+// Validate checks the plugin referenced by includableRef and returns a list of
+// problems with it. It checks the plugin.json syntax, schema and templates,
+// that the files it references exist, and that its services are well formed.
+// The returned error is only non-nil if the plugin could not be read at all.
+//
+// includableRef may also be a plain path to a plugin directory or plugin.json.
+func Validate(includableRef, workingDir string) ([]string, error) {
+	path := includableRef
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(workingDir, path)
+	}
+	if _, err := os.Stat(path); err == nil {
+		includableRef = "path:" + includableRef
+	}
+	ref, err := flake.ParseRef(includableRef)
+	if err != nil {
+		return nil, err
+	}
+	var inc Includable
+	switch ref.Type {
+	case flake.TypePath:
+		inc = &LocalPlugin{ref: ref, pluginDir: workingDir}
+	case flake.TypeGitHub:
+		inc = &githubPlugin{ref: normalizeGithubRef(ref)}
+	case flake.TypeGit:
+		if inc, err = newGitPlugin(ref); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported ref type %q", ref.Type)
+	}
+
+	content, err := inc.FileContent(pluginConfigName)
+	if err != nil {
+		return nil, err
+	}
+	return validate(inc, content), nil
+}
+
+func validate(inc Includable, content []byte) []string {
+	file := sourceFile(inc, pluginConfigName)
+	if _, err := jsonPurifyPluginContent(content); err != nil {
+		return []string{jsonErrorWithContext(file, content, err).Error()}
+	}
+
+	tmpl, err := template.New(pluginConfigName).Parse(string(content))
+	if err != nil {
+		return []string{templateErrorWithContext(file, content, err).Error()}
+	}
+	var rendered bytes.Buffer
+	if err := tmpl.Execute(&rendered, validationTemplateData()); err != nil {
+		return []string{templateErrorWithContext(file, content, err).Error()}
+	}
+	jsonb, err := jsonPurifyPluginContent(rendered.Bytes())
+	if err != nil {
+		return []string{fmt.Sprintf("%s: invalid JSON after rendering templates: %v", file, err)}
+	}
+
+	problems := validateSchema(file, jsonb)
+	cfg := &Config{}
+	if err := json.Unmarshal(jsonb, cfg); err != nil {
+		return append(problems, fmt.Sprintf("%s: %v", file, err))
+	}
+	for _, dst := range slices.Sorted(maps.Keys(cfg.CreateFiles)) {
+		problems = append(problems, validateCreateFile(inc, dst, cfg.CreateFiles[dst])...)
+	}
+	return problems
+}
+
+func validateSchema(file string, jsonb []byte) []string {
+	fields := map[string]any{}
+	if err := json.Unmarshal(jsonb, &fields); err != nil {
+		return []string{fmt.Sprintf("%s: plugin must be a JSON object", file)}
+	}
+
+	problems := []string{}
+	for _, key := range slices.Sorted(maps.Keys(fields)) {
+		if !slices.Contains(pluginFields, key) {
+			problems = append(problems, fmt.Sprintf(
+				"%s: unknown field %q. Allowed fields are: %s",
+				file, key, strings.Join(pluginFields, ", "),
+			))
+		}
+	}
+
+	name, _ := fields["name"].(string)
+	if name == "" {
+		problems = append(problems, fmt.Sprintf("%s: %q is required and must be a string", file, "name"))
+	} else if !nameRegex.MatchString(name) {
+		problems = append(problems, fmt.Sprintf(
+			"%s: invalid name %q. Name must match %s", file, name, nameRegex))
+	}
+	if _, ok := fields["version"].(string); !ok {
+		problems = append(problems, fmt.Sprintf("%s: %q is required and must be a string", file, "version"))
+	}
+	_, hasDescription := fields["description"].(string)
+	_, hasReadme := fields["readme"].(string)
+	if !hasDescription && !hasReadme {
+		problems = append(problems, fmt.Sprintf("%s: %q is required and must be a string", file, "description"))
+	}
+
+	for _, key := range []string{"env", "create_files"} {
+		value, ok := fields[key]
+		if !ok {
+			continue
+		}
+		obj, ok := value.(map[string]any)
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s: %q must be an object", file, key))
+			continue
+		}
+		for _, k := range slices.Sorted(maps.Keys(obj)) {
+			if _, ok := obj[k].(string); !ok {
+				problems = append(problems, fmt.Sprintf("%s: %s.%s must be a string", file, key, k))
+			}
+		}
+	}
+	if include, ok := fields["include"]; ok {
+		arr, ok := include.([]any)
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s: %q must be an array of strings", file, "include"))
+		}
+		for _, v := range arr {
+			if _, ok := v.(string); !ok {
+				problems = append(problems, fmt.Sprintf("%s: %q must be an array of strings", file, "include"))
+				break
+			}
+		}
+	}
+	return problems
+}
+
+func validateCreateFile(inc Includable, dst, contentPath string) []string {
+	if contentPath == "" {
+		// Empty content paths create directories.
+		return nil
+	}
+	file := sourceFile(inc, contentPath)
+	content, err := inc.FileContent(contentPath)
+	if err != nil {
+		return []string{fmt.Sprintf(
+			"create_files: %q references %s which could not be read: %v", dst, file, err)}
+	}
+	tmpl, err := template.New(contentPath).Parse(string(content))
+	if err != nil {
+		return []string{templateErrorWithContext(file, content, err).Error()}
+	}
+	var rendered bytes.Buffer
+	if err := tmpl.Execute(&rendered, validationTemplateData()); err != nil {
+		return []string{templateErrorWithContext(file, content, err).Error()}
+	}
+	if strings.HasSuffix(dst, "process-compose.yaml") || strings.HasSuffix(dst, "process-compose.yml") {
+		if err := services.ValidateProcessCompose(rendered.Bytes()); err != nil {
+			return []string{fmt.Sprintf("%s: invalid service definition: %v", file, err)}
+		}
+	}
+	return nil
+}
+
+// validationTemplateData returns the union of the values available to
+// plugin.json and create_files templates, rooted at validationProjectDir.
+func validationTemplateData() map[string]any {
+	return map[string]any{
+		"DevboxProjectDir":     validationProjectDir,
+		"DevboxDir":            filepath.Join(validationProjectDir, devboxDirName, "plugin"),
+		"DevboxDirRoot":        filepath.Join(validationProjectDir, devboxDirName),
+		"DevboxProfileDefault": filepath.Join(validationProjectDir, nix.ProfilePath),
+		"PackageAttributePath": "",
+		"Packages":             []string{},
+		"System":               "",
+		"URLForInput":          "",
+		"Virtenv":              filepath.Join(validationProjectDir, VirtenvPath, "plugin"),
+	}
+}
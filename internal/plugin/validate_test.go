@@ -0,0 +1,91 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScaffoldIsValid(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "my-plugin")
+	require.NoError(t, Scaffold(dir, "my-plugin"))
+
+	problems, err := Validate(dir, "")
+	require.NoError(t, err)
+	assert.Empty(t, problems)
+
+	require.Error(t, Scaffold(dir, "my-plugin"), "scaffolding into a non-empty dir should fail")
+}
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		files    map[string]string
+		expected []string
+	}{
+		{
+			name: "missing required fields and unknown field",
+			files: map[string]string{
+				"plugin.json": `{"name": "test", "pakages": []}`,
+			},
+			expected: []string{
+				`unknown field "pakages"`,
+				`"version" is required`,
+				`"description" is required`,
+			},
+		},
+		{
+			name: "missing create_files source",
+			files: map[string]string{
+				"plugin.json": `{
+					"name": "test",
+					"version": "0.0.1",
+					"description": "test",
+					"create_files": {"{{ .Virtenv }}/foo": "foo.conf"}
+				}`,
+			},
+			expected: []string{`create_files: "/devbox-project/.devbox/virtenv/plugin/foo" references`},
+		},
+		{
+			name: "service without command",
+			files: map[string]string{
+				"plugin.json": `{
+					"name": "test",
+					"version": "0.0.1",
+					"description": "test",
+					"create_files": {"{{ .Virtenv }}/process-compose.yaml": "pc.yaml"}
+				}`,
+				"pc.yaml": "processes:\n  test:\n    working_dir: /tmp\n",
+			},
+			expected: []string{`process "test" has no command`},
+		},
+		{
+			name: "template error",
+			files: map[string]string{
+				"plugin.json": `{"name": "{{ .Nope }", "version": "0.0.1", "description": "test"}`,
+			},
+			expected: []string{"plugin.json:1: "},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tc.files {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+			}
+			problems, err := Validate(dir, "")
+			require.NoError(t, err)
+			require.Len(t, problems, len(tc.expected), problems)
+			for i, expected := range tc.expected {
+				assert.Contains(t, problems[i], expected)
+			}
+		})
+	}
+}
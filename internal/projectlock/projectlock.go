@@ -0,0 +1,168 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+// Package projectlock implements an advisory lock on a project, so that devbox
+// commands that run at the same time, such as devbox add in one terminal and a
+// direnv-triggered devbox shellenv in another, take turns changing
+// devbox.lock and the project's nix profile.
+package projectlock
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+)
+
+// pollInterval is how often a command that's waiting with a timeout checks
+// whether the lock is free.
+const pollInterval = 100 * time.Millisecond
+
+// timeout is how long to wait for a lock. Zero means no limit.
+var timeout atomic.Int64
+
+// command is the command that holds the locks that this process takes.
+var command atomic.Pointer[string]
+
+// SetCommand sets the command that's recorded in the locks that this process
+// takes, such as "devbox add". It should be the command's path without its
+// arguments, which can hold secrets that other processes shouldn't see.
+func SetCommand(path string) {
+	command.Store(&path)
+}
+
+// SetTimeout sets how long commands wait for another command to release a
+// project's lock. A zero or negative duration waits as long as it takes.
+func SetTimeout(d time.Duration) {
+	timeout.Store(int64(max(d, 0)))
+}
+
+// Holder is the process that holds a lock. It's written to the lock file so
+// that waiting commands can say what they're waiting for.
+type Holder struct {
+	PID     int       `json:"pid"`
+	User    string    `json:"user,omitempty"`
+	Command string    `json:"command"`
+	Since   time.Time `json:"since"`
+}
+
+func (h Holder) String() string {
+	s := fmt.Sprintf("%q (pid %d", h.Command, h.PID)
+	if h.User != "" {
+		s += ", user " + h.User
+	}
+	if !h.Since.IsZero() {
+		s += ", since " + h.Since.Format(time.TimeOnly)
+	}
+	return s + ")"
+}
+
+// Lock is a held lock.
+type Lock struct {
+	file *os.File
+}
+
+// Acquire takes the lock in path, waiting until the process that holds it
+// releases it or the timeout passes. onWait is called with the holder if the
+// lock isn't free.
+func Acquire(path string, onWait func(Holder)) (*Lock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	// The lock file records who holds the lock, so only its owner may
+	// read it.
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if errors.Is(err, os.ErrPermission) {
+		// Another user created the lock file and made it readable,
+		// such as in a shared project. Locking only needs to read it.
+		file, err = os.Open(path)
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	err = tryLock(file)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		holder := readHolder(file)
+		onWait(holder)
+		err = wait(file, holder)
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	writeHolder(file)
+	return &Lock{file: file}, nil
+}
+
+// Release releases the lock.
+func (l *Lock) Release() error {
+	// Other users can't open the file for writing, so it isn't cleared.
+	// Closing the file releases the lock.
+	_ = l.file.Truncate(0)
+	return errors.WithStack(l.file.Close())
+}
+
+func tryLock(file *os.File) error {
+	return syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+}
+
+// wait waits for the lock until the timeout.
+func wait(file *os.File, holder Holder) error {
+	d := time.Duration(timeout.Load())
+	if d == 0 {
+		return errors.WithStack(syscall.Flock(int(file.Fd()), syscall.LOCK_EX))
+	}
+	deadline := time.Now().Add(d)
+	for time.Now().Before(deadline) {
+		time.Sleep(pollInterval)
+		err := tryLock(file)
+		if !errors.Is(err, syscall.EWOULDBLOCK) {
+			return errors.WithStack(err)
+		}
+	}
+	return usererr.New(
+		"Timed out after %s waiting for %s to finish with this project. "+
+			"If it's stuck, stop it, or wait longer with --lock-timeout.", d, holder)
+}
+
+func readHolder(file *os.File) Holder {
+	holder := Holder{Command: "another devbox command"}
+	data, err := os.ReadFile(file.Name())
+	if err == nil && len(data) > 0 {
+		_ = json.Unmarshal(data, &holder)
+	}
+	return holder
+}
+
+func writeHolder(file *os.File) {
+	holder := Holder{
+		PID:     os.Getpid(),
+		Command: "devbox",
+		Since:   time.Now(),
+	}
+	if path := command.Load(); path != nil {
+		holder.Command = *path
+	}
+	if u, err := user.Current(); err == nil {
+		holder.User = u.Username
+	}
+	data, err := json.Marshal(holder)
+	if err != nil {
+		return
+	}
+	// This fails if another user created the file, which only means that
+	// waiting commands can't say who holds the lock.
+	if err := file.Truncate(0); err == nil {
+		_, _ = file.WriteAt(data, 0)
+	}
+}
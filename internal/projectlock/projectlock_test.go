@@ -0,0 +1,101 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package projectlock
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAcquire(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".devbox", "project.lock")
+	onWait := func(Holder) { t.Error("got a wait for a free lock") }
+
+	lock, err := Acquire(path, onWait)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"pid"`) {
+		t.Errorf("got lock file %q, want the holder's pid in it", data)
+	}
+	if err := lock.Release(); err != nil {
+		t.Fatal(err)
+	}
+
+	lock, err = Acquire(path, onWait)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := lock.Release(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAcquireTimeout(t *testing.T) {
+	SetCommand("devbox add")
+	SetTimeout(200 * time.Millisecond)
+	t.Cleanup(func() { SetTimeout(0) })
+
+	path := filepath.Join(t.TempDir(), "project.lock")
+	lock, err := Acquire(path, func(Holder) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lock.Release()
+
+	var holder Holder
+	_, err = Acquire(path, func(h Holder) { holder = h })
+	if err == nil {
+		t.Fatal("got no error for a held lock")
+	}
+	if holder.PID != os.Getpid() {
+		t.Errorf("got holder pid %d, want %d", holder.PID, os.Getpid())
+	}
+	if holder.Command != "devbox add" {
+		t.Errorf("got holder command %q, want %q", holder.Command, "devbox add")
+	}
+	if !strings.Contains(err.Error(), "Timed out") {
+		t.Errorf("got error %q, want a timeout", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("got lock file mode %o, want 600", perm)
+	}
+}
+
+func TestAcquireWaits(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "project.lock")
+	lock, err := Acquire(path, func(Holder) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.AfterFunc(100*time.Millisecond, func() { lock.Release() })
+
+	waited := false
+	second, err := Acquire(path, func(Holder) { waited = true })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer second.Release()
+	if !waited {
+		t.Error("got no wait for a held lock")
+	}
+}
+
+func TestHolderString(t *testing.T) {
+	h := Holder{PID: 42, User: "alice", Command: "devbox add go"}
+	if got, want := h.String(), `"devbox add go" (pid 42, user alice)`; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
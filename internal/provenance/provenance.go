@@ -0,0 +1,153 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+// Package provenance creates and checks attestations that bind a project's
+// devbox.json, devbox.lock, plugins and the nix store paths that they install
+// to each other, so that teams can prove which environment built an artifact.
+//
+// An attestation is an in-toto statement in a DSSE envelope. It's signed with
+// a local ed25519 key, or with sigstore through cosign.
+package provenance
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// StatementType is the in-toto statement version.
+	StatementType = "https://in-toto.io/Statement/v1"
+
+	// PredicateType identifies Devbox's provenance predicate.
+	PredicateType = "https://jetify.com/devbox/provenance/v1"
+)
+
+// Statement is an in-toto statement about a project's environment.
+type Statement struct {
+	Type          string    `json:"_type"`
+	Subject       []Subject `json:"subject"`
+	PredicateType string    `json:"predicateType"`
+	Predicate     Predicate `json:"predicate"`
+}
+
+// Subject is a file that the statement is about, such as devbox.json.
+type Subject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// Predicate describes the environment that the subjects produce.
+type Predicate struct {
+	DevboxVersion string `json:"devbox_version"`
+
+	// System is the nix system of the environment, such as x86_64-linux.
+	System string `json:"system"`
+
+	Plugins []Plugin `json:"plugins"`
+
+	// StorePaths are the nix store paths of the project's packages,
+	// sorted.
+	StorePaths []string `json:"store_paths"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Plugin is a plugin that the project includes, at the revision it was used.
+type Plugin struct {
+	Ref     string `json:"ref"`
+	Version string `json:"version,omitempty"`
+	Hash    string `json:"hash"`
+}
+
+// NewStatement returns a statement about subjects.
+func NewStatement(subjects []Subject, predicate Predicate) *Statement {
+	slices.SortFunc(predicate.Plugins, func(a, b Plugin) int {
+		return strings.Compare(a.Ref, b.Ref)
+	})
+	slices.Sort(predicate.StorePaths)
+	predicate.StorePaths = slices.Compact(predicate.StorePaths)
+	return &Statement{
+		Type:          StatementType,
+		Subject:       subjects,
+		PredicateType: PredicateType,
+		Predicate:     predicate,
+	}
+}
+
+// FileSubject returns a subject for the file at path, named name.
+func FileSubject(name, path string) (Subject, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Subject{}, errors.WithStack(err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return Subject{}, errors.WithStack(err)
+	}
+	return Subject{Name: name, Digest: map[string]string{"sha256": hex.EncodeToString(h.Sum(nil))}}, nil
+}
+
+// Compare returns how the environment in got differs from the one that want
+// attests to. It ignores when the statements were made and with which Devbox
+// version.
+func Compare(want, got *Statement) []string {
+	diffs := []string{}
+	if want.PredicateType != PredicateType {
+		diffs = append(diffs, fmt.Sprintf("the attestation has the unknown predicate type %q", want.PredicateType))
+	}
+	if want.Predicate.System != got.Predicate.System {
+		diffs = append(diffs, fmt.Sprintf("system is %s, but the attestation is for %s",
+			got.Predicate.System, want.Predicate.System))
+	}
+
+	gotSubjects := map[string]string{}
+	for _, s := range got.Subject {
+		gotSubjects[s.Name] = s.Digest["sha256"]
+	}
+	for _, s := range want.Subject {
+		if digest, ok := gotSubjects[s.Name]; !ok {
+			diffs = append(diffs, s.Name+" is missing")
+		} else if digest != s.Digest["sha256"] {
+			diffs = append(diffs, s.Name+" has changed")
+		}
+	}
+
+	wantPlugins := map[string]Plugin{}
+	for _, p := range want.Predicate.Plugins {
+		wantPlugins[p.Ref] = p
+	}
+	for _, p := range got.Predicate.Plugins {
+		if w, ok := wantPlugins[p.Ref]; !ok {
+			diffs = append(diffs, "plugin "+p.Ref+" isn't in the attestation")
+		} else if w != p {
+			diffs = append(diffs, "plugin "+p.Ref+" has changed")
+		}
+		delete(wantPlugins, p.Ref)
+	}
+	for ref := range wantPlugins {
+		diffs = append(diffs, "plugin "+ref+" is missing")
+	}
+
+	for _, path := range got.Predicate.StorePaths {
+		if !slices.Contains(want.Predicate.StorePaths, path) {
+			diffs = append(diffs, "store path "+path+" isn't in the attestation")
+		}
+	}
+	for _, path := range want.Predicate.StorePaths {
+		if !slices.Contains(got.Predicate.StorePaths, path) {
+			diffs = append(diffs, "store path "+path+" is missing")
+		}
+	}
+	slices.Sort(diffs)
+	return diffs
+}
@@ -0,0 +1,109 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package provenance
+
+import (
+	"crypto/ed25519"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func testStatement() *Statement {
+	return NewStatement(
+		[]Subject{
+			{Name: "devbox.json", Digest: map[string]string{"sha256": "aaa"}},
+			{Name: "devbox.lock", Digest: map[string]string{"sha256": "bbb"}},
+		},
+		Predicate{
+			System:     "x86_64-linux",
+			Plugins:    []Plugin{{Ref: "github:jetify-com/devbox-plugins?dir=mongodb", Hash: "ccc"}},
+			StorePaths: []string{"/nix/store/b-go", "/nix/store/a-python", "/nix/store/b-go"},
+		},
+	)
+}
+
+func TestNewStatementSortsStorePaths(t *testing.T) {
+	got := testStatement().Predicate.StorePaths
+	want := []string{"/nix/store/a-python", "/nix/store/b-go"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got store paths %v, want %v", got, want)
+	}
+}
+
+func TestSignAndVerify(t *testing.T) {
+	keyPath := filepath.Join(t.TempDir(), "provenance.key")
+	key, err := LoadOrCreateKey(keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := LoadOrCreateKey(keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !key.Equal(again) {
+		t.Error("LoadOrCreateKey created a new key instead of loading the existing one")
+	}
+	if info, err := os.Stat(keyPath); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("private key has mode %v, want 0600 (err: %v)", info.Mode().Perm(), err)
+	}
+	publicKey, err := LoadPublicKey(keyPath + ".pub")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	envelope, err := NewEnvelope(testStatement())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := envelope.Sign(key); err != nil {
+		t.Fatal(err)
+	}
+	got, err := envelope.Verify(publicKey)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if diffs := Compare(testStatement(), got); len(diffs) != 0 {
+		t.Errorf("verified statement differs from the signed one: %v", diffs)
+	}
+
+	otherKey, _, _ := ed25519.GenerateKey(nil)
+	if _, err := envelope.Verify(otherKey); err == nil {
+		t.Error("Verify() succeeded with a different key")
+	}
+
+	tampered := *envelope
+	tampered.Payload = envelope.Payload[:len(envelope.Payload)-4] + "AAA="
+	if _, err := tampered.Verify(publicKey); err == nil {
+		t.Error("Verify() succeeded with a tampered payload")
+	}
+}
+
+func TestPAE(t *testing.T) {
+	got := string(pae("http://example.com/HelloWorld", []byte("hello world")))
+	want := "DSSEv1 29 http://example.com/HelloWorld 11 hello world"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	want := testStatement()
+	got := testStatement()
+	got.Subject[1].Digest = map[string]string{"sha256": "changed"}
+	got.Predicate.StorePaths = []string{"/nix/store/a-python", "/nix/store/c-node"}
+	got.Predicate.Plugins[0].Hash = "changed"
+
+	diffs := Compare(want, got)
+	expected := []string{
+		"devbox.lock has changed",
+		"plugin github:jetify-com/devbox-plugins?dir=mongodb has changed",
+		"store path /nix/store/b-go is missing",
+		"store path /nix/store/c-node isn't in the attestation",
+	}
+	if !slices.Equal(diffs, expected) {
+		t.Errorf("got diffs %q, want %q", diffs, expected)
+	}
+}
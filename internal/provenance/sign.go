@@ -0,0 +1,175 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package provenance
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+)
+
+// PayloadType is the DSSE payload type of in-toto statements.
+const PayloadType = "application/vnd.in-toto+json"
+
+// Envelope is a DSSE envelope with a statement as its payload.
+type Envelope struct {
+	PayloadType string      `json:"payloadType"`
+	Payload     string      `json:"payload"`
+	Signatures  []Signature `json:"signatures"`
+}
+
+// Signature is an envelope's signature by one key.
+type Signature struct {
+	KeyID string `json:"keyid"`
+	Sig   string `json:"sig"`
+}
+
+// NewEnvelope returns an unsigned envelope with the statement as its payload.
+func NewEnvelope(s *Statement) (*Envelope, error) {
+	payload, err := json.Marshal(s)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &Envelope{
+		PayloadType: PayloadType,
+		Payload:     base64.StdEncoding.EncodeToString(payload),
+		Signatures:  []Signature{},
+	}, nil
+}
+
+// Sign adds a signature by key to the envelope.
+func (e *Envelope) Sign(key ed25519.PrivateKey) error {
+	payload, err := base64.StdEncoding.DecodeString(e.Payload)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	e.Signatures = append(e.Signatures, Signature{
+		KeyID: KeyID(key.Public().(ed25519.PublicKey)),
+		Sig:   base64.StdEncoding.EncodeToString(ed25519.Sign(key, pae(e.PayloadType, payload))),
+	})
+	return nil
+}
+
+// Verify checks that the envelope is signed by key and returns its
+// statement.
+func (e *Envelope) Verify(key ed25519.PublicKey) (*Statement, error) {
+	payload, err := base64.StdEncoding.DecodeString(e.Payload)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	for _, sig := range e.Signatures {
+		if sig.KeyID != KeyID(key) {
+			continue
+		}
+		signature, err := base64.StdEncoding.DecodeString(sig.Sig)
+		if err == nil && ed25519.Verify(key, pae(e.PayloadType, payload), signature) {
+			return e.Statement()
+		}
+	}
+	return nil, usererr.New("The attestation isn't signed by the key %s.", KeyID(key))
+}
+
+// Statement returns the envelope's statement without checking its
+// signatures.
+func (e *Envelope) Statement() (*Statement, error) {
+	if e.PayloadType != PayloadType {
+		return nil, usererr.New("The attestation has the unknown payload type %q.", e.PayloadType)
+	}
+	payload, err := base64.StdEncoding.DecodeString(e.Payload)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	s := &Statement{}
+	return s, errors.WithStack(json.Unmarshal(payload, s))
+}
+
+// pae is the DSSE pre-authentication encoding, which is what's signed.
+func pae(payloadType string, payload []byte) []byte {
+	return fmt.Appendf(nil, "DSSEv1 %d %s %d %s", len(payloadType), payloadType, len(payload), payload)
+}
+
+// KeyID identifies a public key by its hash.
+func KeyID(key ed25519.PublicKey) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:])
+}
+
+// LoadOrCreateKey reads the private key in path, or creates one if it doesn't
+// exist. The public key is written next to it with a .pub extension, so that
+// it can be shared with the people who check attestations.
+func LoadOrCreateKey(path string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		block, _ := pem.Decode(data)
+		if block == nil {
+			return nil, usererr.New("%s isn't a PEM private key.", path)
+		}
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse %s", path)
+		}
+		edKey, ok := key.(ed25519.PrivateKey)
+		if !ok {
+			return nil, usererr.New("%s isn't an ed25519 key.", path)
+		}
+		return edKey, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, errors.WithStack(err)
+	}
+
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	pubPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER})
+	return key, errors.WithStack(os.WriteFile(path+".pub", pubPEM, 0o644))
+}
+
+// LoadPublicKey reads the PEM public key in path.
+func LoadPublicKey(path string) (ed25519.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, usererr.New("%s isn't a PEM public key.", path)
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse %s", path)
+	}
+	edKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, usererr.New("%s isn't an ed25519 key.", path)
+	}
+	return edKey, nil
+}
@@ -0,0 +1,53 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package provenance
+
+import (
+	"context"
+	"io"
+	"os/exec"
+
+	"github.com/pkg/errors"
+
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+)
+
+// SigstoreBundlePath returns the path of the sigstore bundle of the
+// attestation in path.
+func SigstoreBundlePath(path string) string {
+	return path + ".sigstore.json"
+}
+
+// SignSigstore signs the attestation in path with sigstore's keyless signing,
+// which asks the user to log in with their identity provider. The signature is
+// written to SigstoreBundlePath(path).
+func SignSigstore(ctx context.Context, w io.Writer, path string) error {
+	return cosign(ctx, w, "sign-blob", "--yes", "--bundle", SigstoreBundlePath(path), path)
+}
+
+// VerifySigstore checks that the attestation in path was signed with sigstore
+// by identity, such as an email address, who logged in with issuer, such as
+// https://accounts.google.com.
+func VerifySigstore(ctx context.Context, w io.Writer, path, identity, issuer string) error {
+	if identity == "" || issuer == "" {
+		return usererr.New("Checking a sigstore signature needs the signer's identity and OIDC issuer.")
+	}
+	return cosign(ctx, w, "verify-blob",
+		"--bundle", SigstoreBundlePath(path),
+		"--certificate-identity", identity,
+		"--certificate-oidc-issuer", issuer,
+		path,
+	)
+}
+
+func cosign(ctx context.Context, w io.Writer, args ...string) error {
+	path, err := exec.LookPath("cosign")
+	if err != nil {
+		return usererr.New("Signing with sigstore needs cosign. Add it to the project with `devbox add cosign`.")
+	}
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Stdout = w
+	cmd.Stderr = w
+	return errors.Wrapf(cmd.Run(), "cosign %s failed", args[0])
+}
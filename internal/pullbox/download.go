@@ -7,11 +7,13 @@
 	"fmt"
 	"io"
 	"net/http"
+
+	"go.jetify.com/devbox/internal/httpclient"
 )
 
 // Download downloads a file from the specified URL
 func download(url string) ([]byte, error) {
-	response, err := http.Get(url)
+	response, err := httpclient.Client().Get(url)
 	if err != nil {
 		return nil, err
 	}
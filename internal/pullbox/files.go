@@ -5,7 +5,6 @@
 
 import (
 	"io/fs"
-	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
@@ -15,6 +14,7 @@
 	"go.jetify.com/devbox/internal/devconfig"
 	"go.jetify.com/devbox/internal/devconfig/configfile"
 	"go.jetify.com/devbox/internal/fileutil"
+	"go.jetify.com/devbox/internal/httpclient"
 )
 
 func (p *pullbox) copyToProfile(src string) error {
@@ -80,7 +80,7 @@ func isModifiedConfig(path string) bool {
 
 // urlIsArchive checks if a file URL points to an archive file
 func urlIsArchive(url string) (bool, error) {
-	response, err := http.Head(url)
+	response, err := httpclient.Client().Head(url)
 	if err != nil {
 		return false, err
 	}
@@ -0,0 +1,57 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package python
+
+import (
+	"path/filepath"
+
+	"go.jetify.com/devbox/internal/fileutil"
+)
+
+// Tool is a tool that installs a Python project's dependencies into its
+// virtual environment.
+type Tool string
+
+const (
+	ToolPip    Tool = "pip"
+	ToolPoetry Tool = "poetry"
+	ToolUV     Tool = "uv"
+)
+
+// DetectTool returns the tool that manages the dependencies of the Python
+// project in dir, based on the files it has. It defaults to pip.
+func DetectTool(dir string) Tool {
+	if fileutil.Exists(filepath.Join(dir, "uv.lock")) {
+		return ToolUV
+	}
+	if fileutil.Exists(filepath.Join(dir, "poetry.lock")) {
+		return ToolPoetry
+	}
+	if ok, _ := fileutil.FileContains(filepath.Join(dir, "pyproject.toml"), "[tool.poetry]"); ok {
+		return ToolPoetry
+	}
+	if ok, _ := fileutil.FileContains(filepath.Join(dir, "pyproject.toml"), "[tool.uv]"); ok {
+		return ToolUV
+	}
+	return ToolPip
+}
+
+// InstallCommand returns the command that installs the dependencies of the
+// Python project in dir into its virtual environment, or an empty string if
+// the project doesn't declare any.
+func (t Tool) InstallCommand(dir string) string {
+	switch t {
+	case ToolUV:
+		return "uv sync"
+	case ToolPoetry:
+		return "poetry install"
+	}
+	if fileutil.Exists(filepath.Join(dir, "requirements.txt")) {
+		return "pip install -r requirements.txt"
+	}
+	if fileutil.Exists(filepath.Join(dir, "pyproject.toml")) {
+		return "pip install -e ."
+	}
+	return ""
+}
@@ -0,0 +1,71 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package python
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectTool(t *testing.T) {
+	tests := []struct {
+		name        string
+		files       map[string]string
+		wantTool    Tool
+		wantInstall string
+	}{
+		{
+			name:     "empty",
+			wantTool: ToolPip,
+		},
+		{
+			name:        "requirements",
+			files:       map[string]string{"requirements.txt": "requests\n"},
+			wantTool:    ToolPip,
+			wantInstall: "pip install -r requirements.txt",
+		},
+		{
+			name:        "uv",
+			files:       map[string]string{"pyproject.toml": "[project]\n", "uv.lock": ""},
+			wantTool:    ToolUV,
+			wantInstall: "uv sync",
+		},
+		{
+			name:        "poetry lock",
+			files:       map[string]string{"poetry.lock": ""},
+			wantTool:    ToolPoetry,
+			wantInstall: "poetry install",
+		},
+		{
+			name:        "poetry pyproject",
+			files:       map[string]string{"pyproject.toml": "[tool.poetry]\nname = \"app\"\n"},
+			wantTool:    ToolPoetry,
+			wantInstall: "poetry install",
+		},
+		{
+			name:        "pyproject",
+			files:       map[string]string{"pyproject.toml": "[project]\nname = \"app\"\n"},
+			wantTool:    ToolPip,
+			wantInstall: "pip install -e .",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range test.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			tool := DetectTool(dir)
+			if tool != test.wantTool {
+				t.Errorf("got tool %q, want %q", tool, test.wantTool)
+			}
+			if got := tool.InstallCommand(dir); got != test.wantInstall {
+				t.Errorf("got install command %q, want %q", got, test.wantInstall)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+// Package python manages the virtual environments of projects that use a
+// Python interpreter from Devbox.
+package python
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+	"go.jetify.com/devbox/internal/fileutil"
+	"go.jetify.com/devbox/internal/ux"
+)
+
+// interpreterRegexp matches the names of the nixpkgs packages that provide a
+// Python interpreter. It's the same pattern that triggers the python plugin.
+var interpreterRegexp = regexp.MustCompile(`^python[0-9]*(Full|Minimal|-full|-minimal)?$`)
+
+// IsInterpreter returns true if the package with the given canonical name
+// provides a Python interpreter.
+func IsInterpreter(canonicalName string) bool {
+	return interpreterRegexp.MatchString(canonicalName)
+}
+
+// stampFile is the file in a virtual environment that records the
+// interpreter that Devbox created it with.
+const stampFile = ".devbox-python"
+
+// Status describes a virtual environment relative to the interpreter it
+// should use.
+type Status int
+
+const (
+	// StatusMissing means the virtual environment doesn't exist.
+	StatusMissing Status = iota
+
+	// StatusUpToDate means Devbox created the virtual environment with the
+	// current interpreter.
+	StatusUpToDate
+
+	// StatusStale means Devbox created the virtual environment with an
+	// interpreter that's no longer in the project, such as an older
+	// version of the python package.
+	StatusStale
+
+	// StatusUnmanaged means the virtual environment wasn't created by
+	// Devbox. Devbox uses it, but doesn't recreate it.
+	StatusUnmanaged
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusMissing:
+		return "missing"
+	case StatusUpToDate:
+		return "up to date"
+	case StatusStale:
+		return "stale"
+	case StatusUnmanaged:
+		return "not managed by devbox"
+	default:
+		return "unknown"
+	}
+}
+
+// Venv is a Python virtual environment that's created from a Devbox Python
+// interpreter.
+type Venv struct {
+	// Dir is the virtual environment's directory.
+	Dir string
+
+	// Python is the path to the interpreter that the virtual environment
+	// is created from. It's usually in the project's nix profile, which
+	// links to the locked python package in the nix store.
+	Python string
+}
+
+// BinDir returns the directory with the virtual environment's executables.
+func (v *Venv) BinDir() string {
+	return filepath.Join(v.Dir, "bin")
+}
+
+// Interpreter returns the path to the interpreter in the nix store. The
+// virtual environment is keyed to this path, so it changes whenever the locked
+// python package changes.
+func (v *Venv) Interpreter() (string, error) {
+	path, err := filepath.EvalSymlinks(v.Python)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return path, nil
+}
+
+// Status returns the state of the virtual environment.
+func (v *Venv) Status() (Status, error) {
+	if _, err := os.Stat(v.Dir); errors.Is(err, os.ErrNotExist) {
+		return StatusMissing, nil
+	} else if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	if !isVenv(v.Dir) {
+		return StatusMissing, nil
+	}
+
+	stamp, err := os.ReadFile(filepath.Join(v.Dir, stampFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return StatusUnmanaged, nil
+	} else if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	interpreter, err := v.Interpreter()
+	if err != nil {
+		return 0, err
+	}
+	if strings.TrimSpace(string(stamp)) != interpreter {
+		return StatusStale, nil
+	}
+	return StatusUpToDate, nil
+}
+
+// Ensure creates the virtual environment if it doesn't exist, or recreates it
+// if Devbox created it with a different interpreter. Virtual environments that
+// Devbox didn't create are left alone. It returns the status from before any
+// changes were made.
+func (v *Venv) Ensure(ctx context.Context, w io.Writer) (Status, error) {
+	status, err := v.Status()
+	if err != nil {
+		return 0, err
+	}
+	switch status {
+	case StatusMissing:
+		ux.Finfof(w, "Creating a Python virtual environment in %s\n", v.Dir)
+	case StatusStale:
+		ux.Finfof(w, "Python changed. Recreating the virtual environment in %s\n", v.Dir)
+	default:
+		return status, nil
+	}
+	return status, v.Create(ctx, w)
+}
+
+// Create creates the virtual environment, replacing an existing one.
+func (v *Venv) Create(ctx context.Context, w io.Writer) error {
+	interpreter, err := v.Interpreter()
+	if errors.Is(err, os.ErrNotExist) {
+		return usererr.New("Python isn't installed at %s. Run `devbox install` and try again.", v.Python)
+	} else if err != nil {
+		return err
+	}
+
+	// venv --clear deletes everything in the directory, so make sure it
+	// isn't something else.
+	if fileutil.IsDir(v.Dir) && !isVenv(v.Dir) {
+		if empty, err := fileutil.IsDirEmpty(v.Dir); err != nil {
+			return errors.WithStack(err)
+		} else if !empty {
+			return usererr.New(
+				"Can't create a Python virtual environment in %s because the directory "+
+					"isn't empty. Set VENV_DIR in devbox.json to use a different directory.", v.Dir)
+		}
+	}
+
+	cmd := exec.CommandContext(ctx, interpreter, "-m", "venv", "--clear", v.Dir)
+	cmd.Stdout = w
+	cmd.Stderr = w
+	if err := cmd.Run(); err != nil {
+		return usererr.WithUserMessage(err,
+			"Unable to create a Python virtual environment in %s. "+
+				"Python must be version 3.3 or newer to create one.", v.Dir)
+	}
+
+	// Keep the virtual environment out of version control.
+	if err := os.WriteFile(filepath.Join(v.Dir, ".gitignore"), []byte("*\n"), 0o644); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := os.WriteFile(filepath.Join(v.Dir, stampFile), []byte(interpreter+"\n"), 0o644); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func isVenv(dir string) bool {
+	return fileutil.IsFile(filepath.Join(dir, "pyvenv.cfg"))
+}
@@ -0,0 +1,104 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package python
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// testVenv returns a Venv whose Python is a symlink to the python3 on the
+// PATH, like the python in a Devbox profile.
+func testVenv(t *testing.T) *Venv {
+	t.Helper()
+	python3, err := exec.LookPath("python3")
+	if err != nil {
+		t.Skip("python3 isn't installed")
+	}
+	if exec.Command(python3, "-c", "import venv, ensurepip").Run() != nil {
+		t.Skip("python3 can't create virtual environments")
+	}
+
+	dir := t.TempDir()
+	profileBin := filepath.Join(dir, "profile", "bin")
+	if err := os.MkdirAll(profileBin, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	python := filepath.Join(profileBin, "python3")
+	if err := os.Symlink(python3, python); err != nil {
+		t.Fatal(err)
+	}
+	return &Venv{Dir: filepath.Join(dir, ".venv"), Python: python}
+}
+
+func TestVenvEnsure(t *testing.T) {
+	venv := testVenv(t)
+	ctx := context.Background()
+
+	assertStatus := func(want Status) {
+		t.Helper()
+		got, err := venv.Status()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("got status %q, want %q", got, want)
+		}
+	}
+
+	assertStatus(StatusMissing)
+	if _, err := venv.Ensure(ctx, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	assertStatus(StatusUpToDate)
+	if _, err := os.Stat(filepath.Join(venv.BinDir(), "python")); err != nil {
+		t.Errorf("got error for venv python: %v", err)
+	}
+
+	// Simulate a change to the locked python package.
+	stamp := filepath.Join(venv.Dir, stampFile)
+	if err := os.WriteFile(stamp, []byte("/nix/store/old-python3/bin/python3\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	assertStatus(StatusStale)
+	if status, err := venv.Ensure(ctx, io.Discard); err != nil {
+		t.Fatal(err)
+	} else if status != StatusStale {
+		t.Errorf("got Ensure status %q, want %q", status, StatusStale)
+	}
+	assertStatus(StatusUpToDate)
+
+	if err := os.Remove(stamp); err != nil {
+		t.Fatal(err)
+	}
+	assertStatus(StatusUnmanaged)
+	if _, err := venv.Ensure(ctx, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(stamp); err == nil {
+		t.Error("got Ensure to take over a venv that Devbox didn't create")
+	}
+}
+
+func TestVenvCreateNonEmptyDir(t *testing.T) {
+	venv := testVenv(t)
+	if err := os.MkdirAll(venv.Dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(venv.Dir, "important.txt")
+	if err := os.WriteFile(file, []byte("data"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := venv.Create(context.Background(), io.Discard); err == nil {
+		t.Error("got nil error creating a venv in a non-empty directory")
+	}
+	if _, err := os.Stat(file); err != nil {
+		t.Errorf("got error for file in existing directory: %v", err)
+	}
+}
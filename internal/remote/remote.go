@@ -0,0 +1,264 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+// Package remote runs Devbox scripts on another machine over SSH. The project
+// is copied to the machine with rsync, and the machine's own Devbox installs
+// the packages in the lockfile and runs the script.
+package remote
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"al.essio.dev/pkg/shellescape"
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/mattn/go-isatty"
+	"github.com/pkg/errors"
+
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+	"go.jetify.com/devbox/internal/cachehash"
+	"go.jetify.com/devbox/internal/ux"
+)
+
+// installCommand is the command that installs Devbox on a remote machine.
+const installCommand = "curl -fsSL https://get.jetify.com/devbox | bash -s -- -f"
+
+// Target is the machine that a script runs on.
+type Target struct {
+	User string
+	Host string
+	Port string
+
+	// AcceptNewHostKey trusts the target's host key if it isn't known yet,
+	// instead of asking. It's for new machines.
+	AcceptNewHostKey bool
+
+	// InstallDevbox installs Devbox on the target if it isn't installed,
+	// instead of asking first. It's for machines that Devbox created, and
+	// for users who pass --install-devbox.
+	InstallDevbox bool
+
+	// Dir is the directory that the project is copied to. Relative
+	// directories are relative to the remote user's home directory.
+	Dir string
+}
+
+// ParseTarget parses a target URL such as ssh://user@host:2222/path. The path
+// is optional, and a path that starts with /~/ is relative to the remote
+// user's home directory.
+func ParseTarget(target string) (*Target, error) {
+	u, err := url.Parse(target)
+	if err != nil || u.Scheme != "ssh" || u.Hostname() == "" {
+		return nil, usererr.New("Invalid remote %q. It must be an ssh:// URL, such as ssh://user@host.", target)
+	}
+	dir := strings.TrimSuffix(u.Path, "/")
+	if dir == "/~" {
+		dir = ""
+	}
+	return &Target{
+		User: u.User.Username(),
+		Host: u.Hostname(),
+		Port: u.Port(),
+		Dir:  strings.TrimPrefix(dir, "/~/"),
+	}, nil
+}
+
+// String returns the target as a URL.
+func (t *Target) String() string {
+	u := url.URL{Scheme: "ssh", Host: t.Host}
+	if t.User != "" {
+		u.User = url.User(t.User)
+	}
+	if t.Port != "" {
+		u.Host += ":" + t.Port
+	}
+	if t.Dir != "" && !filepath.IsAbs(t.Dir) {
+		u.Path = "/~/" + t.Dir
+	} else {
+		u.Path = t.Dir
+	}
+	return u.String()
+}
+
+// ProjectDir returns the directory on the target that a project is copied to.
+// It's the target's directory if it has one, and otherwise a directory in the
+// remote user's cache that's named after the local project directory.
+func (t *Target) ProjectDir(localDir string) string {
+	if t.Dir != "" {
+		return t.Dir
+	}
+	return filepath.Join(".cache", "devbox", "remote",
+		filepath.Base(localDir)+"-"+cachehash.Bytes6([]byte(localDir)))
+}
+
+// Run copies the project in projectDir to the target, skipping the files that
+// git ignores, and runs devbox with args there. The output streams back to
+// stdout and stderr.
+func Run(ctx context.Context, stdout, stderr io.Writer, t *Target, projectDir string, args ...string) error {
+	remoteDir, err := Setup(ctx, stdout, stderr, t, projectDir)
+	if err != nil {
+		return err
+	}
+	return Exec(ctx, stdout, stderr, t, remoteDir, args...)
+}
+
+// Setup installs Devbox on the target if it isn't installed, copies the
+// project in projectDir to it, and installs the project's packages there. It
+// returns the project's directory on the target.
+func Setup(ctx context.Context, stdout, stderr io.Writer, t *Target, projectDir string) (string, error) {
+	if err := t.ensureDevbox(ctx, stdout, stderr); err != nil {
+		return "", err
+	}
+	remoteDir := t.ProjectDir(projectDir)
+	if err := t.ssh(ctx, false, stdout, stderr, "mkdir -p "+shellescape.Quote(remoteDir)); err != nil {
+		return "", errors.Wrapf(err, "failed to create %s on %s", remoteDir, t.Host)
+	}
+	if err := t.sync(ctx, stdout, stderr, projectDir, remoteDir); err != nil {
+		return "", err
+	}
+	if err := Exec(ctx, stdout, stderr, t, remoteDir, "install"); err != nil {
+		return "", errors.Wrapf(err, "failed to install the project's packages on %s", t.Host)
+	}
+	return remoteDir, nil
+}
+
+// ensureDevbox installs Devbox on the target with installCommand if it isn't
+// installed. Unless t.InstallDevbox is set, it asks first, and fails with
+// instructions to install Devbox by hand if it can't ask.
+func (t *Target) ensureDevbox(ctx context.Context, stdout, stderr io.Writer) error {
+	var out bytes.Buffer
+	if err := t.ssh(ctx, false, &out, stderr,
+		"if command -v devbox >/dev/null 2>&1; then echo installed; fi"); err != nil {
+		return errors.Wrapf(err, "failed to connect to %s", t.Host)
+	}
+	if strings.TrimSpace(out.String()) == "installed" {
+		return nil
+	}
+
+	if !t.InstallDevbox {
+		install, err := confirmInstall(t.Host)
+		if err != nil {
+			return err
+		}
+		if !install {
+			return usererr.New(
+				"Devbox isn't installed on %s. Install it there with:\n\n  %s\n\n"+
+					"or run the command again with --install-devbox to let Devbox run it.",
+				t.Host, installCommand)
+		}
+	}
+	ux.Finfof(stderr, "Installing Devbox on %s with: %s\n", t.Host, installCommand)
+	return errors.Wrapf(t.ssh(ctx, false, stdout, stderr, installCommand), "failed to install devbox on %s", t.Host)
+}
+
+// confirmInstall asks the user whether to install Devbox on host. It returns
+// false without asking if there's no terminal to ask in.
+func confirmInstall(host string) (bool, error) {
+	if !isatty.IsTerminal(os.Stdin.Fd()) || !isatty.IsTerminal(os.Stderr.Fd()) {
+		return false, nil
+	}
+	install := false
+	err := survey.AskOne(&survey.Confirm{
+		Message: fmt.Sprintf("Devbox isn't installed on %s. Install it by running `%s` there?", host, installCommand),
+	}, &install)
+	return install, errors.WithStack(err)
+}
+
+// Exec runs devbox with args in remoteDir on the target. It doesn't copy the
+// project first.
+func Exec(ctx context.Context, stdout, stderr io.Writer, t *Target, remoteDir string, args ...string) error {
+	return t.ssh(ctx, true, stdout, stderr,
+		"cd "+shellescape.Quote(remoteDir)+" && exec devbox "+shellescape.QuoteCommand(args))
+}
+
+// WaitForSSH waits until the target accepts SSH connections, such as after a
+// new machine boots.
+func (t *Target) WaitForSSH(ctx context.Context, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	args := append(t.sshArgs(), "-o", "BatchMode=yes", "-o", "ConnectTimeout=5", t.destination(), "true")
+	for {
+		if err := exec.CommandContext(ctx, "ssh", args...).Run(); err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return usererr.New("Timed out waiting for %s to accept SSH connections.", t.Host)
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+// ssh runs a shell command on the target. It allocates a terminal if tty is
+// true and the local process has one, so that the command's output isn't
+// buffered and interrupting the local process stops it.
+func (t *Target) ssh(ctx context.Context, tty bool, stdout, stderr io.Writer, command string) error {
+	args := t.sshArgs()
+	if tty && isatty.IsTerminal(os.Stdin.Fd()) && isatty.IsTerminal(os.Stdout.Fd()) {
+		args = append(args, "-t")
+	}
+	args = append(args, t.destination(), command)
+
+	cmd := exec.CommandContext(ctx, "ssh", args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	return errors.WithStack(cmd.Run())
+}
+
+// sync copies the project to the target with rsync. Files that git ignores
+// and the .devbox directory aren't copied, and the target keeps its own copies
+// of them, so that it doesn't reinstall its packages every time.
+func (t *Target) sync(ctx context.Context, stdout, stderr io.Writer, projectDir, remoteDir string) error {
+	if _, err := exec.LookPath("rsync"); err != nil {
+		return usererr.New("Running on a remote machine needs rsync, which isn't installed.")
+	}
+	cmd := exec.CommandContext(ctx, "rsync", rsyncArgs(t, projectDir, remoteDir)...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	return errors.Wrapf(cmd.Run(), "failed to copy the project to %s", t.Host)
+}
+
+func rsyncArgs(t *Target, projectDir, remoteDir string) []string {
+	return []string{
+		"--archive", "--compress", "--delete",
+		"--exclude=/.devbox/",
+		"--exclude=/.git/",
+		"--filter=:- .gitignore",
+		"--rsh=" + strings.Join(append([]string{"ssh"}, t.sshArgs()...), " "),
+		strings.TrimSuffix(projectDir, "/") + "/",
+		t.destination() + ":" + remoteDir + "/",
+	}
+}
+
+func (t *Target) sshArgs() []string {
+	args := []string{}
+	if t.Port != "" {
+		args = append(args, "-p", t.Port)
+	}
+	if t.AcceptNewHostKey {
+		args = append(args, "-o", "StrictHostKeyChecking=accept-new")
+	}
+	return args
+}
+
+// SSHCommand returns the ssh command line that connects to the target.
+func (t *Target) SSHCommand() []string {
+	return append(append([]string{"ssh"}, t.sshArgs()...), t.destination())
+}
+
+func (t *Target) destination() string {
+	if t.User == "" {
+		return t.Host
+	}
+	return t.User + "@" + t.Host
+}
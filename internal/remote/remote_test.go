@@ -0,0 +1,79 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package remote
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseTarget(t *testing.T) {
+	tests := []struct {
+		target string
+		want   Target
+	}{
+		{"ssh://host", Target{Host: "host"}},
+		{"ssh://user@host", Target{User: "user", Host: "host"}},
+		{"ssh://user@host:2222", Target{User: "user", Host: "host", Port: "2222"}},
+		{"ssh://host/srv/project/", Target{Host: "host", Dir: "/srv/project"}},
+		{"ssh://host/~/project", Target{Host: "host", Dir: "project"}},
+		{"ssh://host/~", Target{Host: "host"}},
+	}
+	for _, test := range tests {
+		t.Run(test.target, func(t *testing.T) {
+			got, err := ParseTarget(test.target)
+			if err != nil {
+				t.Fatalf("got error: %v", err)
+			}
+			if *got != test.want {
+				t.Errorf("got target %+v, want %+v", *got, test.want)
+			}
+			if got.String() != strings.TrimSuffix(test.target, "/") && test.target != "ssh://host/~" {
+				t.Errorf("got string %q, want %q", got.String(), test.target)
+			}
+		})
+	}
+}
+
+func TestParseTargetError(t *testing.T) {
+	for _, target := range []string{"host", "user@host", "https://host", "ssh:///path"} {
+		if _, err := ParseTarget(target); err == nil {
+			t.Errorf("got no error for target %q", target)
+		}
+	}
+}
+
+func TestProjectDir(t *testing.T) {
+	target := &Target{Host: "host"}
+	dir := target.ProjectDir("/home/user/myproject")
+	if !strings.HasPrefix(dir, ".cache/devbox/remote/myproject-") {
+		t.Errorf("got project dir %q, want one in .cache/devbox/remote", dir)
+	}
+	if other := target.ProjectDir("/home/user/other/myproject"); other == dir {
+		t.Errorf("got the same project dir %q for different projects", dir)
+	}
+
+	target.Dir = "/srv/project"
+	if got := target.ProjectDir("/home/user/myproject"); got != "/srv/project" {
+		t.Errorf("got project dir %q, want /srv/project", got)
+	}
+}
+
+func TestRsyncArgs(t *testing.T) {
+	target := &Target{User: "user", Host: "host", Port: "2222"}
+	got := rsyncArgs(target, "/home/user/project/", "/srv/project")
+	want := []string{
+		"--archive", "--compress", "--delete",
+		"--exclude=/.devbox/",
+		"--exclude=/.git/",
+		"--filter=:- .gitignore",
+		"--rsh=ssh -p 2222",
+		"/home/user/project/",
+		"user@host:/srv/project/",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got rsync args\n%q\nwant\n%q", got, want)
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+// Package rust resolves the Rust toolchains that projects declare in a
+// rust-toolchain.toml file to packages from the rust-overlay flake.
+package rust
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/pkg/errors"
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+)
+
+// OverlayFlake is the flake that provides the Rust toolchains.
+const OverlayFlake = "github:oxalica/rust-overlay"
+
+// toolchainFiles are the names of the toolchain files that rustup reads, in
+// order of precedence.
+var toolchainFiles = []string{"rust-toolchain.toml", "rust-toolchain"}
+
+var (
+	versionRegexp      = regexp.MustCompile(`^[0-9]+\.[0-9]+\.[0-9]+$`)
+	shortVersionRegexp = regexp.MustCompile(`^[0-9]+\.[0-9]+$`)
+	datedRegexp        = regexp.MustCompile(`^(nightly|beta)-([0-9]{4}-[0-9]{2}-[0-9]{2})$`)
+)
+
+// Toolchain is the [toolchain] table of a rust-toolchain.toml file.
+type Toolchain struct {
+	Channel    string   `toml:"channel"`
+	Components []string `toml:"components"`
+	Targets    []string `toml:"targets"`
+	Profile    string   `toml:"profile"`
+	Path       string   `toml:"path"`
+}
+
+// FindToolchainFile returns the path to the toolchain file in dir, or an
+// empty string if there isn't one.
+func FindToolchainFile(dir string) string {
+	for _, name := range toolchainFiles {
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			return path
+		}
+	}
+	return ""
+}
+
+// ParseToolchainFile parses a rust-toolchain.toml file, or a legacy
+// rust-toolchain file that only has the channel name.
+func ParseToolchainFile(path string) (*Toolchain, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var file struct {
+		Toolchain *Toolchain `toml:"toolchain"`
+	}
+	if err := toml.Unmarshal(data, &file); err == nil && file.Toolchain != nil {
+		return file.Toolchain, nil
+	}
+
+	channel := strings.TrimSpace(string(data))
+	if channel == "" || strings.ContainsAny(channel, "\n[=") {
+		return nil, usererr.New("%s isn't a valid Rust toolchain file.", path)
+	}
+	return &Toolchain{Channel: channel}, nil
+}
+
+// Installable returns the rust-overlay flake installable for the toolchain's
+// channel, such as github:oxalica/rust-overlay#rust_1_78_0 for 1.78.0.
+func (t *Toolchain) Installable() (string, error) {
+	if t.Path != "" {
+		return "", usererr.New(
+			"The Rust toolchain file uses a custom toolchain at %s, which Devbox can't install.", t.Path)
+	}
+
+	var attr string
+	switch channel := t.Channel; {
+	case channel == "" || channel == "stable":
+		attr = "rust"
+	case channel == "beta" || channel == "nightly":
+		attr = "rust-" + channel
+	case versionRegexp.MatchString(channel):
+		attr = "rust_" + strings.ReplaceAll(channel, ".", "_")
+	case datedRegexp.MatchString(channel):
+		m := datedRegexp.FindStringSubmatch(channel)
+		attr = "rust-" + m[1] + "_" + m[2]
+	case shortVersionRegexp.MatchString(channel):
+		return "", usererr.New(
+			"Devbox needs the full Rust version, such as %s.0, in the toolchain file's channel.", channel)
+	default:
+		return "", usererr.New("Devbox doesn't support the Rust toolchain channel %q.", channel)
+	}
+	return OverlayFlake + "#" + attr, nil
+}
+
+// Override returns the arguments to override the rust-overlay package with to
+// add the toolchain's components and targets, or nil if it doesn't have any.
+func (t *Toolchain) Override() map[string]any {
+	override := map[string]any{}
+	if len(t.Components) > 0 {
+		override["extensions"] = t.Components
+	}
+	if len(t.Targets) > 0 {
+		override["targets"] = t.Targets
+	}
+	if len(override) == 0 {
+		return nil
+	}
+	return override
+}
+
+// IsToolchainPackage returns true if the package with the given name is a Rust
+// toolchain from the rust-overlay flake.
+func IsToolchainPackage(name string) bool {
+	return strings.HasPrefix(name, OverlayFlake+"#")
+}
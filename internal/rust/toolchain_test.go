@@ -0,0 +1,118 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package rust
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseToolchainFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    *Toolchain
+	}{
+		{
+			name: "toml",
+			file: "rust-toolchain.toml",
+			content: `[toolchain]
+channel = "1.78.0"
+components = ["rustfmt", "clippy"]
+targets = ["wasm32-unknown-unknown"]
+profile = "minimal"
+`,
+			want: &Toolchain{
+				Channel:    "1.78.0",
+				Components: []string{"rustfmt", "clippy"},
+				Targets:    []string{"wasm32-unknown-unknown"},
+				Profile:    "minimal",
+			},
+		},
+		{
+			name:    "legacy toml",
+			file:    "rust-toolchain",
+			content: "[toolchain]\nchannel = \"nightly\"\n",
+			want:    &Toolchain{Channel: "nightly"},
+		},
+		{
+			name:    "legacy channel",
+			file:    "rust-toolchain",
+			content: "stable\n",
+			want:    &Toolchain{Channel: "stable"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, test.file), []byte(test.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			path := FindToolchainFile(dir)
+			if path != filepath.Join(dir, test.file) {
+				t.Fatalf("FindToolchainFile() = %q, want %q", path, filepath.Join(dir, test.file))
+			}
+			got, err := ParseToolchainFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("ParseToolchainFile() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestFindToolchainFileMissing(t *testing.T) {
+	if path := FindToolchainFile(t.TempDir()); path != "" {
+		t.Errorf("FindToolchainFile() = %q, want empty", path)
+	}
+}
+
+func TestInstallable(t *testing.T) {
+	tests := map[string]string{
+		"":                   OverlayFlake + "#rust",
+		"stable":             OverlayFlake + "#rust",
+		"beta":               OverlayFlake + "#rust-beta",
+		"nightly":            OverlayFlake + "#rust-nightly",
+		"1.78.0":             OverlayFlake + "#rust_1_78_0",
+		"nightly-2024-05-01": OverlayFlake + "#rust-nightly_2024-05-01",
+	}
+	for channel, want := range tests {
+		got, err := (&Toolchain{Channel: channel}).Installable()
+		if err != nil {
+			t.Errorf("Installable() for channel %q returned error: %v", channel, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("Installable() for channel %q = %q, want %q", channel, got, want)
+		}
+	}
+
+	for _, channel := range []string{"1.78", "stable-x86_64-unknown-linux-gnu", "1.78.0-beta"} {
+		if _, err := (&Toolchain{Channel: channel}).Installable(); err == nil {
+			t.Errorf("Installable() for channel %q returned no error", channel)
+		}
+	}
+	if _, err := (&Toolchain{Path: "/opt/rust"}).Installable(); err == nil {
+		t.Error("Installable() for a custom toolchain path returned no error")
+	}
+}
+
+func TestOverride(t *testing.T) {
+	if got := (&Toolchain{Channel: "stable"}).Override(); got != nil {
+		t.Errorf("Override() = %v, want nil", got)
+	}
+	got := (&Toolchain{Components: []string{"rust-src"}, Targets: []string{"wasm32-wasi"}}).Override()
+	want := map[string]any{
+		"extensions": []string{"rust-src"},
+		"targets":    []string{"wasm32-wasi"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Override() = %v, want %v", got, want)
+	}
+}
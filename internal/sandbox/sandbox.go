@@ -0,0 +1,115 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+// Package sandbox runs commands in a sandbox that denies network access and
+// only allows writes to a few directories. It uses bubblewrap (Linux
+// namespaces) on Linux and sandbox-exec on macOS.
+package sandbox
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+)
+
+// Policy is what a sandboxed command is allowed to do.
+type Policy struct {
+	// AllowNetwork lets the command use the network.
+	AllowNetwork bool
+
+	// Writable are the absolute paths of the directories that the command
+	// can write to. Everything else is read-only, except for temporary
+	// directories and devices.
+	Writable []string
+}
+
+// Command returns the command line that runs args in a sandbox with the given
+// policy. path is the PATH to look up the sandbox tool in, and tmpDir is the
+// command's TMPDIR, which stays writable.
+func Command(policy Policy, path, tmpDir string, args ...string) ([]string, error) {
+	switch runtime.GOOS {
+	case "linux":
+		bwrap, err := lookPath("bwrap", path)
+		if err != nil {
+			return nil, usererr.New(
+				"Sandboxing on Linux needs bubblewrap. Add it to the project with `devbox add bubblewrap`.")
+		}
+		return append(bwrapArgs(bwrap, policy, tmpDir), args...), nil
+	case "darwin":
+		profile := sandboxExecProfile(policy, tmpDir)
+		return append([]string{"/usr/bin/sandbox-exec", "-p", profile}, args...), nil
+	default:
+		return nil, usererr.New("Sandboxing isn't supported on %s.", runtime.GOOS)
+	}
+}
+
+// bwrapArgs returns the bubblewrap command line for a policy. The root file
+// system is mounted read-only with a private /tmp, and TMPDIR and the writable
+// paths are mounted over it.
+func bwrapArgs(bwrap string, policy Policy, tmpDir string) []string {
+	args := []string{
+		bwrap,
+		"--ro-bind", "/", "/",
+		"--dev", "/dev",
+		"--proc", "/proc",
+		"--tmpfs", "/tmp",
+		"--unshare-pid",
+		"--die-with-parent",
+	}
+	if !policy.AllowNetwork {
+		args = append(args, "--unshare-net")
+	}
+	writable := policy.Writable
+	if tmpDir != "" {
+		writable = append([]string{tmpDir}, writable...)
+	}
+	for _, path := range writable {
+		args = append(args, "--bind-try", path, path)
+	}
+	return append(args, "--")
+}
+
+// sandboxExecProfile returns the sandbox-exec profile for a policy. macOS
+// resolves symlinks before it checks paths, so the writable paths must be
+// real paths.
+func sandboxExecProfile(policy Policy, tmpDir string) string {
+	writable := []string{"/dev", "/private/tmp", "/private/var/tmp"}
+	if tmpDir != "" {
+		writable = append(writable, tmpDir)
+	}
+	writable = append(writable, policy.Writable...)
+
+	var b strings.Builder
+	b.WriteString("(version 1)\n(allow default)\n")
+	if !policy.AllowNetwork {
+		// Unix sockets are still allowed so that nix can talk to its
+		// daemon.
+		b.WriteString("(deny network*)\n(allow network* (remote unix-socket))\n")
+	}
+	b.WriteString("(deny file-write*)\n(allow file-write*")
+	for _, path := range writable {
+		if real, err := filepath.EvalSymlinks(path); err == nil {
+			path = real
+		}
+		fmt.Fprintf(&b, "\n  (subpath %q)", path)
+	}
+	b.WriteString(")\n")
+	return b.String()
+}
+
+// lookPath searches for an executable in a PATH list, and then in the current
+// process's PATH.
+func lookPath(name, path string) (string, error) {
+	for _, dir := range filepath.SplitList(path) {
+		file := filepath.Join(dir, name)
+		if info, err := os.Stat(file); err == nil && info.Mode().IsRegular() && info.Mode()&0o111 != 0 {
+			return file, nil
+		}
+	}
+	return exec.LookPath(name)
+}
@@ -0,0 +1,69 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package sandbox
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestBwrapArgs(t *testing.T) {
+	args := bwrapArgs("bwrap", Policy{Writable: []string{"/project"}}, "/run/user/1000")
+	want := []string{
+		"bwrap",
+		"--ro-bind", "/", "/",
+		"--dev", "/dev",
+		"--proc", "/proc",
+		"--tmpfs", "/tmp",
+		"--unshare-pid",
+		"--die-with-parent",
+		"--unshare-net",
+		"--bind-try", "/run/user/1000", "/run/user/1000",
+		"--bind-try", "/project", "/project",
+		"--",
+	}
+	if !slices.Equal(args, want) {
+		t.Errorf("got bwrapArgs() = %q, want %q", args, want)
+	}
+
+	args = bwrapArgs("bwrap", Policy{AllowNetwork: true}, "")
+	if slices.Contains(args, "--unshare-net") {
+		t.Errorf("got bwrapArgs() = %q with network allowed, want no --unshare-net", args)
+	}
+}
+
+func TestSandboxExecProfile(t *testing.T) {
+	profile := sandboxExecProfile(Policy{Writable: []string{"/project"}}, "")
+	for _, want := range []string{"(deny network*)", "(deny file-write*)", `(subpath "/project")`} {
+		if !strings.Contains(profile, want) {
+			t.Errorf("got profile without %s:\n%s", want, profile)
+		}
+	}
+
+	profile = sandboxExecProfile(Policy{AllowNetwork: true}, "")
+	if strings.Contains(profile, "(deny network*)") {
+		t.Errorf("got profile that denies network when it's allowed:\n%s", profile)
+	}
+}
+
+func TestLookPath(t *testing.T) {
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "bwrap-test")
+	if err := os.WriteFile(bin, nil, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	got, err := lookPath("bwrap-test", "/does-not-exist"+string(filepath.ListSeparator)+dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != bin {
+		t.Errorf("got lookPath() = %q, want %q", got, bin)
+	}
+	if _, err := lookPath("bwrap-test", ""); err == nil {
+		t.Error("got lookPath() with an empty PATH to succeed, want error")
+	}
+}
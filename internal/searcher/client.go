@@ -8,6 +8,7 @@
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"runtime"
@@ -15,6 +16,7 @@
 	"github.com/pkg/errors"
 	"go.jetify.com/devbox/internal/build"
 	"go.jetify.com/devbox/internal/envir"
+	"go.jetify.com/devbox/internal/httpclient"
 	"go.jetify.com/devbox/internal/redact"
 )
 
@@ -43,12 +45,20 @@ func (c *client) Search(ctx context.Context, query string) (*SearchResults, erro
 	}
 	searchURL := endpoint + "?q=" + url.QueryEscape(query)
 
-	return execGet[SearchResults](ctx, searchURL)
+	results, err := execGet[SearchResults](ctx, searchURL)
+	if err != nil {
+		return nil, err
+	}
+	// Remember the packages so that shell completion can suggest them later.
+	if err := LocalIndex().Add(results.Packages); err != nil {
+		slog.Debug("failed to update the local search index", "err", err)
+	}
+	return results, nil
 }
 
 // Resolve calls the /resolve endpoint of the search service. This returns
 // the latest version of the package that matches the version constraint.
-func (c *client) Resolve(name, version string) (*PackageVersion, error) {
+func (c *client) Resolve(ctx context.Context, name, version string) (*PackageVersion, error) {
 	if name == "" || version == "" {
 		return nil, fmt.Errorf("name and version should not be empty")
 	}
@@ -61,7 +71,7 @@ func (c *client) Resolve(name, version string) (*PackageVersion, error) {
 		"?name=" + url.QueryEscape(name) +
 		"&version=" + url.QueryEscape(version)
 
-	return execGet[PackageVersion](context.TODO(), searchURL)
+	return execGet[PackageVersion](ctx, searchURL)
 }
 
 // Resolve calls the /resolve endpoint of the search service. This returns
@@ -94,7 +104,7 @@ func execGet[T any](ctx context.Context, url string) (*T, error) {
 	}
 	req.Header.Set("User-Agent", userAgent)
 
-	response, err := http.DefaultClient.Do(req)
+	response, err := httpclient.Client().Do(req)
 	if err != nil {
 		return nil, redact.Errorf("GET %s: %w", redact.Safe(url), redact.Safe(err))
 	}
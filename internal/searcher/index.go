@@ -0,0 +1,94 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package searcher
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"go.jetify.com/devbox/internal/fileutil"
+	"go.jetify.com/devbox/internal/state"
+)
+
+// Index is a local index of the package names and summaries that the search
+// service has returned. It lets devbox complete package names without a
+// network request.
+type Index struct {
+	path string
+}
+
+// IndexEntry is a package in the local index.
+type IndexEntry struct {
+	Name    string
+	Summary string
+}
+
+// LocalIndex returns the index in the user's cache directory.
+func LocalIndex() *Index {
+	return &Index{path: state.Cache("search-index.json")}
+}
+
+// Add adds the packages in search results to the index.
+func (i *Index) Add(pkgs []Package) error {
+	if len(pkgs) == 0 {
+		return nil
+	}
+	entries, err := i.read()
+	if err != nil {
+		return err
+	}
+	for _, pkg := range pkgs {
+		summary := entries[pkg.Name]
+		if len(pkg.Versions) > 0 && pkg.Versions[0].Summary != "" {
+			summary = pkg.Versions[0].Summary
+		}
+		entries[pkg.Name] = summary
+	}
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(i.path), 0o755); err != nil {
+		return err
+	}
+	return fileutil.WriteFileAtomic(i.path, data, 0o644)
+}
+
+// Complete returns the packages in the index whose names start with prefix,
+// sorted by name.
+func (i *Index) Complete(prefix string) ([]IndexEntry, error) {
+	entries, err := i.read()
+	if err != nil {
+		return nil, err
+	}
+	matches := []IndexEntry{}
+	for name, summary := range entries {
+		if strings.HasPrefix(name, prefix) {
+			matches = append(matches, IndexEntry{Name: name, Summary: summary})
+		}
+	}
+	slices.SortFunc(matches, func(a, b IndexEntry) int { return strings.Compare(a.Name, b.Name) })
+	return matches, nil
+}
+
+func (i *Index) read() (map[string]string, error) {
+	entries := map[string]string{}
+	data, err := os.ReadFile(i.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		// The index is only a cache, so start over if it's corrupt.
+		return map[string]string{}, nil
+	}
+	return entries, nil
+}
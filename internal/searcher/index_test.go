@@ -0,0 +1,48 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package searcher
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestIndex(t *testing.T) {
+	index := &Index{path: filepath.Join(t.TempDir(), "devbox", "search-index.json")}
+
+	got, err := index.Complete("go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Errorf("Complete() on an empty index = %v, want none", got)
+	}
+
+	err = index.Add([]Package{
+		{Name: "go", Versions: []PackageVersion{{PackageInfo: PackageInfo{Summary: "The Go language"}}}},
+		{Name: "gopls"},
+		{Name: "nodejs"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Adding a package again without a summary keeps the old one.
+	if err := index.Add([]Package{{Name: "go"}, {Name: "golangci-lint"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err = index.Complete("go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []IndexEntry{
+		{Name: "go", Summary: "The Go language"},
+		{Name: "golangci-lint"},
+		{Name: "gopls"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Complete(%q) = %v, want %v", "go", got, want)
+	}
+}
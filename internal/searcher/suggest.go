@@ -0,0 +1,109 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package searcher
+
+import (
+	"cmp"
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"go.jetify.com/devbox/internal/envir"
+)
+
+// Suggest returns up to [envir.SuggestLimit] package names that are similar to
+// name, for "did you mean" hints when a package isn't found. Candidates come
+// from the search service and the local index. They're ranked by edit distance
+// and then by popularity, which is approximated by the number of versions.
+// Suggest returns nil if it can't find any, including when the search service
+// is unreachable.
+func Suggest(ctx context.Context, name string) []string {
+	limit := envir.SuggestLimit()
+	if limit == 0 || name == "" {
+		return nil
+	}
+
+	popularity := map[string]int{}
+	if results, err := Client().Search(ctx, name); err == nil {
+		for _, pkg := range results.Packages {
+			popularity[pkg.Name] = max(popularity[pkg.Name], pkg.NumVersions, len(pkg.Versions))
+		}
+	} else {
+		slog.Debug("failed to search for package suggestions", "name", name, "err", err)
+	}
+	if entries, err := LocalIndex().read(); err == nil {
+		for indexed := range entries {
+			if _, ok := popularity[indexed]; !ok {
+				popularity[indexed] = 0
+			}
+		}
+	}
+	return rankSuggestions(name, popularity, limit)
+}
+
+// rankSuggestions returns up to limit candidates that are within a few edits
+// of name, closest and most popular first.
+func rankSuggestions(name string, popularity map[string]int, limit int) []string {
+	type suggestion struct {
+		name       string
+		distance   int
+		popularity int
+	}
+
+	// Allow roughly one typo per three characters, so that short names
+	// don't match everything.
+	maxDistance := max(1, len(name)/3)
+	suggestions := []suggestion{}
+	for candidate, pop := range popularity {
+		if candidate == name {
+			continue
+		}
+		d := editDistance(strings.ToLower(name), strings.ToLower(candidate))
+		if d <= maxDistance {
+			suggestions = append(suggestions, suggestion{candidate, d, pop})
+		}
+	}
+	slices.SortFunc(suggestions, func(a, b suggestion) int {
+		return cmp.Or(
+			cmp.Compare(a.distance, b.distance),
+			cmp.Compare(b.popularity, a.popularity),
+			strings.Compare(a.name, b.name),
+		)
+	})
+
+	names := []string{}
+	for _, s := range suggestions[:min(limit, len(suggestions))] {
+		names = append(names, s.name)
+	}
+	return names
+}
+
+// editDistance returns the Damerau-Levenshtein distance between a and b, which
+// counts swapped adjacent characters as one edit.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	// d[i][j] is the distance between ra[:i] and rb[:j].
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(ra)][len(rb)]
+}
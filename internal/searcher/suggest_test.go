@@ -0,0 +1,54 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package searcher
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestEditDistance(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"nodejs", "nodejs", 0},
+		{"nodej", "nodejs", 1},
+		{"ndoejs", "nodejs", 1},
+		{"pyhton", "python", 1},
+		{"go", "rust", 4},
+		{"", "jq", 2},
+	}
+	for _, tc := range cases {
+		if got := editDistance(tc.a, tc.b); got != tc.want {
+			t.Errorf("got editDistance(%q, %q) = %d, want %d", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestRankSuggestions(t *testing.T) {
+	popularity := map[string]int{
+		"nodejs":    120,
+		"nodejs_20": 40,
+		"nodeenv":   10,
+		"node2nix":  5,
+		"ripgrep":   80,
+	}
+	got := rankSuggestions("ndoejs", popularity, 3)
+	want := []string{"nodejs"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got rankSuggestions(%q) = %v, want %v", "ndoejs", got, want)
+	}
+
+	popularity = map[string]int{"python3": 10, "python2": 50, "pythonx": 0, "python": 100}
+	got = rankSuggestions("python", popularity, 2)
+	want = []string{"python2", "python3"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got rankSuggestions(%q) = %v, want %v", "python", got, want)
+	}
+
+	if got := rankSuggestions("jq", popularity, 3); len(got) != 0 {
+		t.Errorf("got rankSuggestions(%q) = %v, want none", "jq", got)
+	}
+}
@@ -0,0 +1,228 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package serve
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/pkg/errors"
+
+	"go.jetify.com/devbox/internal/devbox"
+	"go.jetify.com/devbox/internal/devconfig/configfile"
+	"go.jetify.com/devbox/internal/plugin"
+)
+
+// Types of the events that GET /v1/events streams.
+const (
+	// ConfigChanged is sent when devbox.json changes.
+	ConfigChanged = "config_changed"
+
+	// LockfileChanged is sent when devbox.lock changes, such as after
+	// devbox update or a git pull.
+	LockfileChanged = "lockfile_changed"
+
+	// PluginChanged is sent when a file of a local plugin that the
+	// project includes changes.
+	PluginChanged = "plugin_changed"
+)
+
+// eventDelay is how long the watcher waits for more changes before it sends
+// an event, because editors and git write a file in several steps.
+const eventDelay = 200 * time.Millisecond
+
+// Event is a change to the project after which clients, such as an IDE,
+// should reload the project's environment.
+type Event struct {
+	Type string `json:"type"`
+	Path string `json:"path"`
+}
+
+// broker sends events to the clients that are streaming them.
+type broker struct {
+	mu   sync.Mutex
+	subs map[chan Event]struct{}
+}
+
+func (b *broker) subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, 16)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.subs == nil {
+		b.subs = map[chan Event]struct{}{}
+	}
+	b.subs[ch] = struct{}{}
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subs, ch)
+	}
+}
+
+// publish sends e to every client. Clients that don't keep up miss events
+// instead of blocking the others.
+func (b *broker) publish(e Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subs {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+// streamEvents streams the project's events to the client as server-sent
+// events until the client disconnects or the server shuts down.
+func (s *Server) streamEvents(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "missing or invalid token"})
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "streaming isn't supported"})
+		return
+	}
+	events, unsubscribe := s.events.subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e := <-events:
+			data, err := json.Marshal(e)
+			if err != nil {
+				slog.Error("devbox serve: encoding event", "err", err)
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data)
+			flusher.Flush()
+		}
+	}
+}
+
+// watch publishes an event when devbox.json, devbox.lock or a local plugin of
+// the project changes, until ctx is done.
+func (s *Server) watch(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer watcher.Close()
+
+	// Watch directories instead of files, because editors and git
+	// replace files instead of writing to them.
+	watched := map[string]bool{}
+	projectDir, pluginDirs := "", []string{}
+	rewatch := func() {
+		opts := s.opts
+		box, err := devbox.Open(&opts)
+		if err != nil {
+			slog.Debug("devbox serve: opening the project to watch it", "err", err)
+			return
+		}
+		projectDir, pluginDirs = box.ProjectDir(), localPluginDirs(box)
+		dirs := append([]string{projectDir}, pluginDirs...)
+		for dir := range maps.Clone(watched) {
+			if !slices.Contains(dirs, dir) {
+				_ = watcher.Remove(dir)
+				delete(watched, dir)
+			}
+		}
+		for _, dir := range dirs {
+			if watched[dir] {
+				continue
+			}
+			if err := watcher.Add(dir); err != nil {
+				slog.Debug("devbox serve: watching a directory", "dir", dir, "err", err)
+				continue
+			}
+			watched[dir] = true
+		}
+	}
+	rewatch()
+
+	pending := map[string]Event{}
+	timer := time.NewTimer(eventDelay)
+	timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Has(fsnotify.Chmod) && !event.Has(fsnotify.Write) {
+				continue
+			}
+			if eventType := changeType(event.Name, projectDir, pluginDirs); eventType != "" {
+				pending[event.Name] = Event{Type: eventType, Path: event.Name}
+				timer.Reset(eventDelay)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			slog.Debug("devbox serve: watching the project", "err", err)
+		case <-timer.C:
+			for _, path := range slices.Sorted(maps.Keys(pending)) {
+				e := pending[path]
+				if e.Type == ConfigChanged {
+					// The project can include other plugins now.
+					rewatch()
+				}
+				s.events.publish(e)
+			}
+			clear(pending)
+		}
+	}
+}
+
+// changeType returns the type of the event that a change to path sends, or ""
+// if the change doesn't affect the project's environment.
+func changeType(path, projectDir string, pluginDirs []string) string {
+	if filepath.Dir(path) == projectDir {
+		switch filepath.Base(path) {
+		case configfile.DefaultName:
+			return ConfigChanged
+		case "devbox.lock":
+			return LockfileChanged
+		}
+	}
+	for _, dir := range pluginDirs {
+		if path == dir || strings.HasPrefix(path, dir+string(filepath.Separator)) {
+			return PluginChanged
+		}
+	}
+	return ""
+}
+
+// localPluginDirs returns the directories of the local plugins that the
+// project includes. Changes to other plugins can't be watched.
+func localPluginDirs(box *devbox.Devbox) []string {
+	dirs := []string{}
+	for _, cfg := range box.Config().IncludedPluginConfigs() {
+		if local, ok := cfg.Source.(*plugin.LocalPlugin); ok {
+			dirs = append(dirs, filepath.Dir(local.Path()))
+		}
+	}
+	return dirs
+}
@@ -0,0 +1,41 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package serve
+
+import "testing"
+
+func TestChangeType(t *testing.T) {
+	pluginDirs := []string{"/project/plugins/db"}
+	cases := map[string]string{
+		"/project/devbox.json":                ConfigChanged,
+		"/project/devbox.lock":                LockfileChanged,
+		"/project/plugins/db/plugin.json":     PluginChanged,
+		"/project/plugins/db/process-compose": PluginChanged,
+		"/project/main.go":                    "",
+		"/project/sub/devbox.json":            "",
+		"/project/plugins/dbx/plugin.json":    "",
+	}
+	for path, want := range cases {
+		if got := changeType(path, "/project", pluginDirs); got != want {
+			t.Errorf("changeType(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestBroker(t *testing.T) {
+	b := broker{}
+	events, unsubscribe := b.subscribe()
+	b.publish(Event{Type: LockfileChanged, Path: "/project/devbox.lock"})
+	if got := <-events; got.Type != LockfileChanged {
+		t.Errorf("got event %v, want a %s event", got, LockfileChanged)
+	}
+
+	unsubscribe()
+	b.publish(Event{Type: ConfigChanged})
+	select {
+	case got := <-events:
+		t.Errorf("got event %v after unsubscribing", got)
+	default:
+	}
+}
@@ -0,0 +1,340 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+// Package serve implements devbox serve: a local API of a devbox project that
+// listens on a Unix socket, so that integrations such as web dashboards and
+// IDE plugins can list and change the project's packages, compute its
+// environment, run its scripts and control its services through one
+// long-lived process.
+//
+// The API is JSON over HTTP. Every request must have the header
+// "Authorization: Bearer <token>", where the token is the one that the server
+// prints when it starts.
+//
+//	GET  /v1/packages              list the packages
+//	POST /v1/packages              add {"packages": [...]}
+//	POST /v1/update                update {"packages": [...]}, or every package
+//	GET  /v1/env                   compute the environment
+//	POST /v1/run                   run {"script": "...", "args": [...]}
+//	GET  /v1/services              list the services and their states
+//	POST /v1/services/{action}     start, stop or restart {"services": [...]}
+//	GET  /v1/events                stream changes to the project as server-sent events
+//
+// The events tell clients, such as the VSCode extension, when to reload the
+// project's environment: config_changed when devbox.json changes,
+// lockfile_changed when devbox.lock changes and plugin_changed when a file of
+// a local plugin changes.
+package serve
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+	"go.jetify.com/devbox/internal/devbox"
+	"go.jetify.com/devbox/internal/devbox/devopt"
+	"go.jetify.com/devbox/internal/envir"
+)
+
+// Server serves the API of a devbox project.
+type Server struct {
+	opts  devopt.Opts
+	token string
+
+	// mu serializes the requests, because devbox commands of the same
+	// project can't safely run at the same time.
+	mu sync.Mutex
+
+	events broker
+}
+
+// New returns a server of the project that opts opens, which accepts requests
+// with token.
+func New(opts devopt.Opts, token string) *Server {
+	return &Server{opts: opts, token: token}
+}
+
+// NewToken returns a random token to authenticate requests with.
+func NewToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.WithStack(err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Listen listens on a Unix socket at path, which only the user can connect
+// to. The socket is removed when the listener is closed.
+func Listen(path string) (net.Listener, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	// Remove the socket of a server that didn't shut down cleanly.
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, errors.WithStack(err)
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		listener.Close()
+		return nil, errors.WithStack(err)
+	}
+	return listener, nil
+}
+
+// Serve serves the API on listener until ctx is done.
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	server := &http.Server{
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		// End the streams of events when the server shuts down.
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+	go func() {
+		if err := s.watch(ctx); err != nil {
+			slog.Error("devbox serve: watching the project", "err", err)
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+	if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// Handler returns the handler of the API's requests.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/packages", s.handle(s.listPackages))
+	mux.HandleFunc("POST /v1/packages", s.handle(s.addPackages))
+	mux.HandleFunc("POST /v1/update", s.handle(s.update))
+	mux.HandleFunc("GET /v1/env", s.handle(s.computeEnv))
+	mux.HandleFunc("POST /v1/run", s.handle(s.runScript))
+	mux.HandleFunc("GET /v1/services", s.handle(s.listServices))
+	mux.HandleFunc("POST /v1/services/{action}", s.handle(s.controlServices))
+	mux.HandleFunc("GET /v1/events", s.streamEvents)
+	return mux
+}
+
+// handlerFunc handles a request with the project opened for it, and returns
+// the response to encode as JSON.
+type handlerFunc func(r *http.Request, box *devbox.Devbox) (any, error)
+
+func (s *Server) handle(fn handlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.authorized(r) {
+			writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "missing or invalid token"})
+			return
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		// Open the project for each request, so that it sees the changes
+		// to devbox.json that were made outside of the server.
+		opts := s.opts
+		box, err := devbox.Open(&opts)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		resp, err := fn(r, box)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1
+}
+
+// Package is a package of the project in the response of GET /v1/packages.
+type Package struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+func (s *Server) listPackages(r *http.Request, box *devbox.Devbox) (any, error) {
+	packages := []Package{}
+	for _, pkg := range box.AllPackages() {
+		version := ""
+		if locked := box.Lockfile().Get(pkg.LockfileKey()); locked != nil {
+			version = locked.Version
+		}
+		packages = append(packages, Package{Name: pkg.Versioned(), Version: version})
+	}
+	return packages, nil
+}
+
+type packagesRequest struct {
+	Packages []string `json:"packages"`
+}
+
+func (s *Server) addPackages(r *http.Request, box *devbox.Devbox) (any, error) {
+	req := packagesRequest{}
+	if err := decodeJSON(r, &req); err != nil {
+		return nil, err
+	}
+	if len(req.Packages) == 0 {
+		return nil, usererr.New("The request doesn't have any packages to add.")
+	}
+	if err := box.Add(r.Context(), req.Packages, devopt.AddOpts{}); err != nil {
+		return nil, err
+	}
+	return s.listPackages(r, box)
+}
+
+func (s *Server) update(r *http.Request, box *devbox.Devbox) (any, error) {
+	req := packagesRequest{}
+	if err := decodeJSON(r, &req); err != nil {
+		return nil, err
+	}
+	if err := box.Update(r.Context(), devopt.UpdateOpts{Pkgs: req.Packages}); err != nil {
+		return nil, err
+	}
+	return s.listPackages(r, box)
+}
+
+func (s *Server) computeEnv(r *http.Request, box *devbox.Devbox) (any, error) {
+	pairs, err := box.EnvVars(r.Context())
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"env": envir.PairsToMap(pairs)}, nil
+}
+
+type runRequest struct {
+	Script string   `json:"script"`
+	Args   []string `json:"args"`
+}
+
+// RunResult is the response of POST /v1/run.
+type RunResult struct {
+	ExitCode int `json:"exit_code"`
+
+	// Output is the combined stdout and stderr of the script.
+	Output string `json:"output"`
+}
+
+// runScript runs the script with devbox run in a child process, so that its
+// output can be returned and it doesn't change the server's environment.
+func (s *Server) runScript(r *http.Request, box *devbox.Devbox) (any, error) {
+	req := runRequest{}
+	if err := decodeJSON(r, &req); err != nil {
+		return nil, err
+	}
+	if req.Script == "" {
+		return nil, usererr.New("The request doesn't have a script to run.")
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	args := []string{"run", "--config", box.ProjectDir(), "--environment", s.opts.Environment, "--", req.Script}
+	cmd := exec.CommandContext(r.Context(), exe, append(args, req.Args...)...)
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	err = cmd.Run()
+	if exitErr := (*exec.ExitError)(nil); errors.As(err, &exitErr) {
+		return RunResult{ExitCode: exitErr.ExitCode(), Output: output.String()}, nil
+	} else if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return RunResult{Output: output.String()}, nil
+}
+
+func (s *Server) listServices(r *http.Request, box *devbox.Devbox) (any, error) {
+	states, running, err := box.ServiceStates(r.Context())
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"running": running, "services": states}, nil
+}
+
+type servicesRequest struct {
+	Services []string `json:"services"`
+}
+
+func (s *Server) controlServices(r *http.Request, box *devbox.Devbox) (any, error) {
+	req := servicesRequest{}
+	if err := decodeJSON(r, &req); err != nil {
+		return nil, err
+	}
+	var err error
+	switch action := r.PathValue("action"); action {
+	case "start":
+		err = box.StartServices(r.Context(), false /*runInCurrentShell*/, req.Services...)
+	case "stop":
+		err = box.StopServices(r.Context(), false /*runInCurrentShell*/, false /*allProjects*/, req.Services...)
+	case "restart":
+		err = box.RestartServices(r.Context(), false /*runInCurrentShell*/, req.Services...)
+	default:
+		return nil, usererr.New("Unknown service action %q. Use start, stop or restart.", action)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.listServices(r, box)
+}
+
+// decodeJSON decodes the request's body into v. An empty body leaves v
+// unchanged.
+func decodeJSON(r *http.Request, v any) error {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return usererr.WithUserMessage(err, "The request's body isn't valid JSON.")
+	}
+	return nil
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// writeError responds with the error's user message if it has one. Other
+// errors are bugs or failures of the environment, and are logged too.
+func writeError(w http.ResponseWriter, err error) {
+	if userErr, ok := usererr.Extract(err); ok {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: userErr.Error()})
+		return
+	}
+	slog.Error("devbox serve: request failed", "err", err)
+	writeJSON(w, http.StatusInternalServerError, errorResponse{Error: err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		slog.Debug("devbox serve: writing response", "err", err)
+	}
+}
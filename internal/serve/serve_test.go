@@ -0,0 +1,57 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package serve
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.jetify.com/devbox/internal/devbox/devopt"
+	"go.jetify.com/devbox/internal/envir"
+)
+
+func TestHandler(t *testing.T) {
+	t.Setenv(envir.XDGConfigHome, t.TempDir())
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "devbox.json"), []byte(`{"packages": []}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	handler := New(devopt.Opts{Dir: dir, Stderr: os.Stderr}, "secret").Handler()
+
+	cases := []struct {
+		name       string
+		method     string
+		path       string
+		token      string
+		wantStatus int
+		wantBody   string
+	}{
+		{"no token", "GET", "/v1/packages", "", http.StatusUnauthorized, "missing or invalid token"},
+		{"wrong token", "GET", "/v1/packages", "guess", http.StatusUnauthorized, "missing or invalid token"},
+		{"list packages", "GET", "/v1/packages", "secret", http.StatusOK, "[]"},
+		{"unknown action", "POST", "/v1/services/pause", "secret", http.StatusBadRequest, "Unknown service action"},
+		{"run without script", "POST", "/v1/run", "secret", http.StatusBadRequest, "doesn't have a script"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tc.wantStatus {
+				t.Errorf("got status %d, want %d", rec.Code, tc.wantStatus)
+			}
+			if !strings.Contains(rec.Body.String(), tc.wantBody) {
+				t.Errorf("got body %q, want it to contain %q", rec.Body.String(), tc.wantBody)
+			}
+		})
+	}
+}
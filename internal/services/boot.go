@@ -0,0 +1,196 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package services
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+	"go.jetify.com/devbox/internal/xdg"
+)
+
+// BootUnit is a systemd user unit or launchd agent that runs a project's
+// services when the user logs in.
+type BootUnit struct {
+	// Name identifies the unit, such as devbox-services-1a2b3c.
+	Name string
+
+	// Description is a human readable description of the unit.
+	Description string
+
+	// Args is the command that runs the services in the foreground.
+	Args []string
+
+	// Dir is the working directory of the command.
+	Dir string
+
+	// Path is the PATH that the command runs with, because units don't
+	// inherit the PATH of the user's shell.
+	Path string
+
+	// LogFile is where launchd writes the output of the command. systemd
+	// writes it to the journal.
+	LogFile string
+}
+
+// File returns the path of the unit's file for the current system.
+func (u *BootUnit) File() (string, error) {
+	switch runtime.GOOS {
+	case "linux":
+		return xdg.ConfigSubpath(filepath.Join("systemd", "user", u.Name+".service")), nil
+	case "darwin":
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+		return filepath.Join(home, "Library", "LaunchAgents", u.launchdLabel()+".plist"), nil
+	}
+	return "", usererr.New("Starting services at login isn't supported on %s", runtime.GOOS)
+}
+
+// Enable writes the unit's file and starts the unit, so that it also starts
+// whenever the user logs in.
+func (u *BootUnit) Enable() error {
+	path, err := u.File()
+	if err != nil {
+		return err
+	}
+	content := u.systemdUnit()
+	if runtime.GOOS == "darwin" {
+		content = u.launchdPlist()
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if runtime.GOOS == "darwin" {
+		// Unload the agent first so that a changed plist takes effect.
+		_ = exec.Command("launchctl", "unload", path).Run()
+		return runBootCommand("launchctl", "load", "-w", path)
+	}
+	if err := runBootCommand("systemctl", "--user", "daemon-reload"); err != nil {
+		return err
+	}
+	return runBootCommand("systemctl", "--user", "enable", "--now", u.Name+".service")
+}
+
+// Disable stops the unit and removes its file. It returns false if the unit
+// isn't enabled.
+func (u *BootUnit) Disable() (bool, error) {
+	path, err := u.File()
+	if err != nil {
+		return false, err
+	}
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+
+	if runtime.GOOS == "darwin" {
+		if err := runBootCommand("launchctl", "unload", "-w", path); err != nil {
+			return false, err
+		}
+		return true, errors.WithStack(os.Remove(path))
+	}
+	if err := runBootCommand("systemctl", "--user", "disable", "--now", u.Name+".service"); err != nil {
+		return false, err
+	}
+	if err := os.Remove(path); err != nil {
+		return false, errors.WithStack(err)
+	}
+	return true, runBootCommand("systemctl", "--user", "daemon-reload")
+}
+
+func (u *BootUnit) launchdLabel() string {
+	return "com.jetify." + strings.ReplaceAll(u.Name, "-", ".")
+}
+
+// systemdUnit returns the unit as a systemd user service. systemd restarts
+// the services if they fail as a whole.
+func (u *BootUnit) systemdUnit() string {
+	args := make([]string, len(u.Args))
+	for i, arg := range u.Args {
+		args[i] = systemdQuote(arg)
+	}
+
+	sb := &strings.Builder{}
+	sb.WriteString("# Generated by devbox services enable-boot.\n")
+	sb.WriteString("[Unit]\n")
+	fmt.Fprintf(sb, "Description=%s\n", u.Description)
+	sb.WriteString("\n[Service]\n")
+	fmt.Fprintf(sb, "WorkingDirectory=%s\n", systemdQuote(u.Dir))
+	fmt.Fprintf(sb, "Environment=%s\n", systemdQuote("PATH="+u.Path))
+	fmt.Fprintf(sb, "ExecStart=%s\n", strings.Join(args, " "))
+	sb.WriteString("Restart=on-failure\n")
+	sb.WriteString("RestartSec=10\n")
+	sb.WriteString("\n[Install]\n")
+	sb.WriteString("WantedBy=default.target\n")
+	return sb.String()
+}
+
+// launchdPlist returns the unit as a launchd agent. launchd restarts the
+// services if they fail as a whole.
+func (u *BootUnit) launchdPlist() string {
+	sb := &strings.Builder{}
+	sb.WriteString(xml.Header)
+	sb.WriteString(`<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">` + "\n")
+	sb.WriteString("<!-- Generated by devbox services enable-boot. -->\n")
+	sb.WriteString(`<plist version="1.0">` + "\n<dict>\n")
+	writePlistKey(sb, "Label", u.launchdLabel())
+	sb.WriteString("  <key>ProgramArguments</key>\n  <array>\n")
+	for _, arg := range u.Args {
+		fmt.Fprintf(sb, "    <string>%s</string>\n", xmlEscape(arg))
+	}
+	sb.WriteString("  </array>\n")
+	writePlistKey(sb, "WorkingDirectory", u.Dir)
+	sb.WriteString("  <key>EnvironmentVariables</key>\n  <dict>\n")
+	fmt.Fprintf(sb, "    <key>PATH</key>\n    <string>%s</string>\n", xmlEscape(u.Path))
+	sb.WriteString("  </dict>\n")
+	sb.WriteString("  <key>RunAtLoad</key>\n  <true/>\n")
+	sb.WriteString("  <key>KeepAlive</key>\n  <dict>\n    <key>SuccessfulExit</key>\n    <false/>\n  </dict>\n")
+	sb.WriteString("  <key>ThrottleInterval</key>\n  <integer>10</integer>\n")
+	writePlistKey(sb, "StandardOutPath", u.LogFile)
+	writePlistKey(sb, "StandardErrorPath", u.LogFile)
+	sb.WriteString("</dict>\n</plist>\n")
+	return sb.String()
+}
+
+func writePlistKey(sb *strings.Builder, key, value string) {
+	fmt.Fprintf(sb, "  <key>%s</key>\n  <string>%s</string>\n", key, xmlEscape(value))
+}
+
+func xmlEscape(s string) string {
+	sb := &strings.Builder{}
+	_ = xml.EscapeText(sb, []byte(s))
+	return sb.String()
+}
+
+// systemdQuote quotes s for systemd's command line and assignment syntax.
+func systemdQuote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\n\"'\\$%;") {
+		return s
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "$", "$$", "%", "%%")
+	return `"` + r.Replace(s) + `"`
+}
+
+func runBootCommand(name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return usererr.WithUserMessage(
+			errors.WithStack(err), "`%s` failed: %s", cmd, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
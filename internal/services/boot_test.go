@@ -0,0 +1,66 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+var testBootUnit = &BootUnit{
+	Name:        "devbox-services-abc123",
+	Description: "devbox services of /home/me/my project",
+	Args:        []string{"/usr/bin/devbox", "services", "up", "--config", "/home/me/my project", "--supervise"},
+	Dir:         "/home/me/my project",
+	Path:        "/usr/bin:/bin",
+	LogFile:     "/home/me/my project/.devbox/services-boot.log",
+}
+
+func TestSystemdUnit(t *testing.T) {
+	got := testBootUnit.systemdUnit()
+	want := []string{
+		"Description=devbox services of /home/me/my project\n",
+		"WorkingDirectory=\"/home/me/my project\"\n",
+		"Environment=PATH=/usr/bin:/bin\n",
+		"ExecStart=/usr/bin/devbox services up --config \"/home/me/my project\" --supervise\n",
+		"Restart=on-failure\n",
+		"WantedBy=default.target\n",
+	}
+	for _, w := range want {
+		if !strings.Contains(got, w) {
+			t.Errorf("unit doesn't contain %q, got:\n%s", w, got)
+		}
+	}
+}
+
+func TestLaunchdPlist(t *testing.T) {
+	got := testBootUnit.launchdPlist()
+	want := []string{
+		"  <key>Label</key>\n  <string>com.jetify.devbox.services.abc123</string>\n",
+		"    <string>/home/me/my project</string>\n",
+		"    <key>PATH</key>\n    <string>/usr/bin:/bin</string>\n",
+		"  <key>RunAtLoad</key>\n  <true/>\n",
+		"  <key>StandardOutPath</key>\n  <string>/home/me/my project/.devbox/services-boot.log</string>\n",
+	}
+	for _, w := range want {
+		if !strings.Contains(got, w) {
+			t.Errorf("plist doesn't contain %q, got:\n%s", w, got)
+		}
+	}
+}
+
+func TestSystemdQuote(t *testing.T) {
+	tests := map[string]string{
+		"plain":      "plain",
+		"two words":  `"two words"`,
+		`say "hi"`:   `"say \"hi\""`,
+		"$HOME 100%": `"$$HOME 100%%"`,
+		"":           `""`,
+	}
+	for in, want := range tests {
+		if got := systemdQuote(in); got != want {
+			t.Errorf("systemdQuote(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
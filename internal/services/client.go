@@ -13,6 +13,7 @@
 	"time"
 
 	"github.com/f1bonacc1/process-compose/src/types"
+	"go.jetify.com/devbox/internal/httpclient"
 )
 
 type processStates = types.ProcessesState
@@ -125,8 +126,7 @@ func clientRequest(path, method, projectDir string) (string, int, error) {
 		return "", 0, err
 	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := httpclient.Client().Do(req)
 	if err != nil {
 		return "", 0, err
 	}
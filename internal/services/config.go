@@ -5,8 +5,10 @@
 
 import (
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 
 	"github.com/f1bonacc1/process-compose/src/types"
 	"github.com/pkg/errors"
@@ -16,7 +18,7 @@
 )
 
 func FromUserProcessCompose(projectDir, userProcessCompose string) Services {
-	processComposeYaml := lookupProcessCompose(projectDir, userProcessCompose)
+	processComposeYaml := LookupProcessCompose(projectDir, userProcessCompose)
 	if processComposeYaml == "" {
 		return nil
 	}
@@ -60,7 +62,30 @@ func NamesFromProcessCompose(content []byte) ([]string, error) {
 	return names, nil
 }
 
-func lookupProcessCompose(projectDir, path string) string {
+// ValidateProcessCompose checks that content is a process-compose file that
+// defines at least one process, and that every process has a command.
+func ValidateProcessCompose(content []byte) error {
+	var processCompose types.Project
+	if err := yaml.Unmarshal(content, &processCompose); err != nil {
+		return err
+	}
+	if len(processCompose.Processes) == 0 {
+		return errors.New("no processes defined")
+	}
+	names := slices.Sorted(maps.Keys(processCompose.Processes))
+	for _, name := range names {
+		process := processCompose.Processes[name]
+		if process.Command == "" && len(process.Entrypoint) == 0 {
+			return errors.Errorf("process %q has no command", name)
+		}
+	}
+	return nil
+}
+
+// LookupProcessCompose returns the path of the project's process-compose file,
+// either the one in path or the one in projectDir if path is empty. It returns
+// "" if there isn't one.
+func LookupProcessCompose(projectDir, path string) string {
 	if path == "" {
 		path = projectDir
 	}
@@ -0,0 +1,88 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package services
+
+import (
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/f1bonacc1/process-compose/src/command"
+	"github.com/f1bonacc1/process-compose/src/types"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+
+	"go.jetify.com/devbox/internal/cuecfg"
+	"go.jetify.com/devbox/internal/limits"
+	"go.jetify.com/devbox/internal/ux"
+)
+
+// WriteLimitsFile writes a process-compose file to path that overrides the
+// commands of services so that they run with their limits. process-compose
+// merges it into the services' own files when it's passed after them. It
+// returns false and removes the file if no service has limits that can be
+// enforced.
+func WriteLimitsFile(
+	w io.Writer,
+	path string,
+	svcs Services,
+	svcLimits map[string]limits.Limits,
+) (bool, error) {
+	processes := map[string]map[string]string{}
+	for _, name := range slices.Sorted(maps.Keys(svcLimits)) {
+		svc, ok := svcs[name]
+		l := svcLimits[name]
+		if !ok || l.IsZero() {
+			continue
+		}
+
+		project := &types.Project{}
+		if err := cuecfg.ParseFile(svc.ProcessComposePath, project); err != nil {
+			return false, errors.WithStack(err)
+		}
+		process := project.Processes[name]
+		if process.IsElevated {
+			ux.Fwarningf(w, "Service %s runs without its resource limits because it runs elevated.\n", name)
+			continue
+		}
+		if process.Command == "" {
+			ux.Fwarningf(w, "Service %s runs without its resource limits because it doesn't have a command.\n", name)
+			continue
+		}
+		shell := command.DefaultShellConfig().ShellCommand
+		if project.ShellConfig != nil && project.ShellConfig.ShellCommand != "" {
+			shell = project.ShellConfig.ShellCommand
+		}
+
+		wrapped, err := limits.ShellCommand(l, shell, process.Command)
+		if errors.Is(err, limits.ErrUnsupported) {
+			ux.Fwarningf(w, "Service %s runs without its resource limits because %v.\n", name, err)
+			continue
+		} else if err != nil {
+			return false, err
+		}
+		processes[name] = map[string]string{"command": wrapped}
+	}
+
+	if len(processes) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return false, errors.WithStack(err)
+		}
+		return false, nil
+	}
+
+	data, err := yaml.Marshal(map[string]any{
+		"version":   "0.5",
+		"processes": processes,
+	})
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return false, errors.WithStack(err)
+	}
+	return true, errors.WithStack(os.WriteFile(path, data, 0o644))
+}
@@ -19,7 +19,7 @@
 
 	"go.jetify.com/devbox/internal/boxcli/usererr"
 	"go.jetify.com/devbox/internal/cuecfg"
-	"go.jetify.com/devbox/internal/xdg"
+	"go.jetify.com/devbox/internal/state"
 )
 
 const (
@@ -45,6 +45,10 @@ type ProcessComposeOpts struct {
 	ExtraFlags         []string
 	Background         bool
 	ProcessComposePort int
+
+	// Detach starts process-compose in its own session when Background is
+	// true, so that it isn't tied to the terminal.
+	Detach bool
 }
 
 func newGlobalProcessComposeConfig() *globalProcessComposeConfig {
@@ -52,7 +56,7 @@ func newGlobalProcessComposeConfig() *globalProcessComposeConfig {
 }
 
 func globalProcessComposeJSONPath() (string, error) {
-	path := xdg.DataSubpath(filepath.Join("devbox", "global"))
+	path := state.Data("global")
 	return filepath.Join(path, "process-compose.json"), errors.WithStack(os.MkdirAll(path, 0o755))
 }
 
@@ -164,6 +168,11 @@ func StartProcessManager(
 	if processComposeConfig.Background {
 		flags = append(flags, "-t=false")
 		cmd := exec.Command(processComposeConfig.BinPath, flags...)
+		if processComposeConfig.Detach {
+			// A new session has no controlling terminal, so process-compose
+			// doesn't get a SIGHUP when the terminal closes.
+			cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+		}
 		return runProcessManagerInBackground(cmd, config, port, projectDir, w)
 	}
 
@@ -225,9 +234,11 @@ func runProcessManagerInBackground(cmd *exec.Cmd, config *globalProcessComposeCo
 
 	// These attributes set the process group ID to the process ID of process-compose
 	// Starting in it's own process group means it won't be terminated if the shell crashes
-	cmd.SysProcAttr = &syscall.SysProcAttr{
-		Setpgid: true,
-		Pgid:    0,
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{
+			Setpgid: true,
+			Pgid:    0,
+		}
 	}
 
 	if err := cmd.Start(); err != nil {
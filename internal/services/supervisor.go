@@ -0,0 +1,64 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package services
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/f1bonacc1/process-compose/src/types"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+
+	"go.jetify.com/devbox/internal/cuecfg"
+)
+
+// supervisorBackoffSeconds is how long process-compose waits before it
+// restarts a supervised service that crashed.
+const supervisorBackoffSeconds = 5
+
+// WriteSupervisorFile writes a process-compose file to path that restarts
+// the services when they exit with an error, after a backoff. Services whose
+// own files set a restart policy keep it. process-compose merges the file
+// into the services' own files when it's passed after them. It returns false
+// and removes the file if every service has its own restart policy.
+func WriteSupervisorFile(path string, svcs Services) (bool, error) {
+	processes := map[string]map[string]any{}
+	for _, name := range slices.Sorted(maps.Keys(svcs)) {
+		project := &types.Project{}
+		if err := cuecfg.ParseFile(svcs[name].ProcessComposePath, project); err != nil {
+			return false, errors.WithStack(err)
+		}
+		if project.Processes[name].RestartPolicy.Restart != types.RestartPolicyNo {
+			continue
+		}
+		processes[name] = map[string]any{
+			"availability": map[string]any{
+				"restart":         "on_failure",
+				"backoff_seconds": supervisorBackoffSeconds,
+			},
+		}
+	}
+
+	if len(processes) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return false, errors.WithStack(err)
+		}
+		return false, nil
+	}
+
+	data, err := yaml.Marshal(map[string]any{
+		"version":   "0.5",
+		"processes": processes,
+	})
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return false, errors.WithStack(err)
+	}
+	return true, errors.WithStack(os.WriteFile(path, data, 0o644))
+}
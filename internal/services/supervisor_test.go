@@ -0,0 +1,62 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/f1bonacc1/process-compose/src/types"
+
+	"go.jetify.com/devbox/internal/cuecfg"
+)
+
+func TestWriteSupervisorFile(t *testing.T) {
+	dir := t.TempDir()
+	pcPath := filepath.Join(dir, "process-compose.yaml")
+	err := os.WriteFile(pcPath, []byte(`version: "0.5"
+processes:
+  web:
+    command: python -m http.server
+  worker:
+    command: ./worker
+    availability:
+      restart: always
+`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	svcs := Services{
+		"web":    {Name: "web", ProcessComposePath: pcPath},
+		"worker": {Name: "worker", ProcessComposePath: pcPath},
+	}
+
+	path := filepath.Join(dir, "gen", "supervisor.yaml")
+	ok, err := WriteSupervisorFile(path, svcs)
+	if err != nil || !ok {
+		t.Fatalf("WriteSupervisorFile() = %v, %v, want true, nil", ok, err)
+	}
+	project := &types.Project{}
+	if err := cuecfg.ParseFile(path, project); err != nil {
+		t.Fatal(err)
+	}
+	web := project.Processes["web"].RestartPolicy
+	if web.Restart != types.RestartPolicyOnFailure || web.BackoffSeconds != supervisorBackoffSeconds {
+		t.Errorf("web availability = %+v, want on_failure with a %ds backoff", web, supervisorBackoffSeconds)
+	}
+	if _, ok := project.Processes["worker"]; ok {
+		t.Errorf("got availability for worker, which has its own restart policy")
+	}
+
+	// The file is removed when every service has its own restart policy.
+	delete(svcs, "web")
+	ok, err = WriteSupervisorFile(path, svcs)
+	if err != nil || ok {
+		t.Fatalf("WriteSupervisorFile() = %v, %v, want false, nil", ok, err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("got %s, want it removed", path)
+	}
+}
@@ -21,7 +21,7 @@
 	"go.jetify.com/devbox/internal/debug"
 	"go.jetify.com/devbox/internal/envir"
 	"go.jetify.com/devbox/internal/redact"
-	"go.jetify.com/devbox/internal/xdg"
+	statedir "go.jetify.com/devbox/internal/state"
 )
 
 // ErrUserRefused indicates that the user responded no to an interactive
@@ -358,7 +358,7 @@ func saveState(key string, s state) {
 }
 
 func statePath(key string) string {
-	dir := xdg.StateSubpath("devbox")
+	dir := statedir.State()
 	name := strings.ReplaceAll(key, "/", "-")
 	return filepath.Join(dir, name)
 }
@@ -3,12 +3,15 @@
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
+	"maps"
 	"runtime/trace"
 	"slices"
 	"strings"
 
 	"github.com/samber/lo"
+	"go.jetify.com/devbox/internal/devconfig/configfile"
 	"go.jetify.com/devbox/internal/devpkg"
 	"go.jetify.com/devbox/internal/nix"
 	"go.jetify.com/devbox/nix/flake"
@@ -20,6 +23,14 @@ type flakeInput struct {
 	Name     string
 	Packages []*devpkg.Package
 	Ref      flake.Ref
+
+	// ctx is the context of the flake generation. The flake template calls
+	// the methods that need it, so it can't pass it as an argument.
+	ctx context.Context
+
+	// Inputs overrides the inputs of the flake. All of the input's
+	// packages have the same overrides.
+	Inputs map[string]string
 }
 
 func (f *flakeInput) HashFromNixPkgsURL() string {
@@ -33,6 +44,25 @@ func (f *flakeInput) URLWithCaching() string {
 	return getNixpkgsInfo(f.Ref.Rev).URL
 }
 
+// InputOverrides returns the attributes that override the inputs of the flake
+// in the generated flake's inputs. Inputs that follow the standard environment
+// follow the generated flake's nixpkgs input, so that nix evaluates and
+// downloads only one copy of nixpkgs.
+func (f *flakeInput) InputOverrides() []string {
+	overrides := []string{}
+	for _, name := range slices.Sorted(maps.Keys(f.Inputs)) {
+		ref := f.Inputs[name]
+		if ref == configfile.InputFollowsStdenv {
+			overrides = append(overrides, fmt.Sprintf("%s.inputs.%s.follows = %s;",
+				f.Name, nixString(name), nixString("nixpkgs")))
+			continue
+		}
+		overrides = append(overrides, fmt.Sprintf("%s.inputs.%s.url = %s;",
+			f.Name, nixString(name), nixString(nix.FixInstallableArg(ref))))
+	}
+	return overrides
+}
+
 func (f *flakeInput) PkgImportName() string {
 	return f.Name + "-pkgs"
 }
@@ -66,7 +96,7 @@ func (f *flakeInput) BuildInputsForSymlinkJoin() ([]*SymlinkJoin, error) {
 			continue
 		}
 
-		attributePath, err := pkg.FullPackageAttributePath()
+		attributePath, err := pkg.FullPackageAttributePath(f.ctx)
 		if err != nil {
 			return nil, err
 		}
@@ -80,14 +110,14 @@ func (f *flakeInput) BuildInputsForSymlinkJoin() ([]*SymlinkJoin, error) {
 			return nil, err
 		}
 
+		expr, err := f.packageExpr(pkg, attributePath)
+		if err != nil {
+			return nil, err
+		}
 		joins = append(joins, &SymlinkJoin{
 			Name: pkg.String() + "-combined",
 			Paths: lo.Map(outputNames, func(outputName string, _ int) string {
-				if !f.Ref.IsNixpkgs() {
-					return f.Name + "." + attributePath + "." + outputName
-				}
-				parts := strings.Split(attributePath, ".")
-				return f.PkgImportName() + "." + strings.Join(parts[2:], ".") + "." + outputName
+				return expr + "." + outputName
 			}),
 		})
 	}
@@ -95,8 +125,6 @@ func (f *flakeInput) BuildInputsForSymlinkJoin() ([]*SymlinkJoin, error) {
 }
 
 func (f *flakeInput) BuildInputs() ([]string, error) {
-	var err error
-
 	// Skip packages that will be handled in BuildInputsForSymlinkJoin
 	packages := []*devpkg.Package{}
 	for _, pkg := range f.Packages {
@@ -107,33 +135,36 @@ func (f *flakeInput) BuildInputs() ([]string, error) {
 		}
 	}
 
-	attributePaths := lo.Map(packages, func(pkg *devpkg.Package, _ int) string {
-		attributePath, attributePathErr := pkg.FullPackageAttributePath()
-		if attributePathErr != nil {
-			err = attributePathErr
+	inputs := make([]string, len(packages))
+	for i, pkg := range packages {
+		attributePath, err := pkg.FullPackageAttributePath(f.ctx)
+		if err != nil {
+			return nil, err
 		}
 		if pkg.Patch {
 			// When the package comes from the glibc flake, the
 			// "legacyPackages" portion of the attribute path
 			// becomes just "packages" (matching the standard flake
 			// output schema).
-			return strings.Replace(attributePath, "legacyPackages", "packages", 1)
+			attributePath = strings.Replace(attributePath, "legacyPackages", "packages", 1)
+		}
+		inputs[i], err = f.packageExpr(pkg, attributePath)
+		if err != nil {
+			return nil, err
 		}
-		return attributePath
-	})
-	if err != nil {
-		return nil, err
 	}
+	return inputs, nil
+}
+
+// packageExpr returns the Nix expression for a package in the flake input
+// given the package's full attribute path in the input's flake.
+func (f *flakeInput) packageExpr(pkg *devpkg.Package, attributePath string) (string, error) {
 	if !f.Ref.IsNixpkgs() {
-		return lo.Map(attributePaths, func(pkg string, _ int) string {
-			return f.Name + "." + pkg
-		}), nil
+		return f.customBuildExpr(pkg, f.Name, attributePath)
 	}
-	return lo.Map(attributePaths, func(pkg string, _ int) string {
-		parts := strings.Split(pkg, ".")
-		// Ugh, not sure if this is reliable?
-		return f.PkgImportName() + "." + strings.Join(parts[2:], ".")
-	}), nil
+	// Ugh, not sure if this is reliable?
+	parts := strings.Split(attributePath, ".")
+	return f.customBuildExpr(pkg, f.PkgImportName(), strings.Join(parts[2:], "."))
 }
 
 // flakeInputs returns a list of flake inputs for the top level flake.nix
@@ -182,9 +213,11 @@ func flakeInputs(ctx context.Context, packages []*devpkg.Package) []flakeInput {
 			slog.Debug("error resolving package to flake installable", "err", err)
 			continue
 		}
-		flake := flakeInputs.getOrAppend(installable.Ref.String())
+		flake := flakeInputs.getOrAppend(installable.Ref.String() + "#" + pkg.InputsHash())
 		flake.Name = pkg.FlakeInputName()
+		flake.ctx = ctx
 		flake.Ref = installable.Ref
+		flake.Inputs = pkg.Inputs
 
 		// TODO(gcurtis): is the uniqueness check necessary? We're
 		// comparing pointers.
@@ -20,6 +20,10 @@ func (l *lockMock) Stdenv() flake.Ref {
 	return flake.Ref{}
 }
 
+func (l *lockMock) PackageSources() map[string]string {
+	return nil
+}
+
 func (l *lockMock) ProjectDir() string {
 	return ""
 }
@@ -57,6 +57,9 @@ func GenerateForPrintEnv(ctx context.Context, devbox devboxer) error {
 			return redact.Errorf("write glibc patch flake to directory: %v", err)
 		}
 	}
+	if err := writeCustomBuildFiles(FlakePath(devbox), plan.Packages); err != nil {
+		return err
+	}
 	if err := makeFlakeFile(devbox, plan); err != nil {
 		return err
 	}
@@ -161,9 +164,10 @@ func toJSON(a any) string {
 }
 
 var templateFuncs = template.FuncMap{
-	"json":     toJSON,
-	"contains": strings.Contains,
-	"debug":    debug.IsEnabled,
+	"json":      toJSON,
+	"nixString": nixString,
+	"contains":  strings.Contains,
+	"debug":     debug.IsEnabled,
 }
 
 func makeFlakeFile(d devboxer, plan *flakePlan) error {
@@ -130,6 +130,7 @@ func (*lockmock) Resolve(pkg string) (*lock.Package, error) {
 	}, nil
 }
 
-func (*lockmock) Get(pkg string) *lock.Package { return nil }
-func (*lockmock) Stdenv() flake.Ref            { return flake.Ref{} }
-func (*lockmock) ProjectDir() string           { return "" }
+func (*lockmock) Get(pkg string) *lock.Package      { return nil }
+func (*lockmock) Stdenv() flake.Ref                 { return flake.Ref{} }
+func (*lockmock) PackageSources() map[string]string { return nil }
+func (*lockmock) ProjectDir() string                { return "" }
@@ -0,0 +1,186 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package shellgen
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"go.jetify.com/devbox/internal/cachehash"
+	"go.jetify.com/devbox/internal/devbox/shellcmd"
+	"go.jetify.com/devbox/internal/nix"
+)
+
+// FishHooksFilename is the name of the fish version of the hooks file. Plain
+// hooks are the same in both files, but named hooks are wrapped in fish
+// syntax.
+const FishHooksFilename = ".hooks.fish"
+
+// slowHookSeconds is how long a named hook can run before devbox reports its
+// timing, even when DEVBOX_DEBUG isn't set.
+const slowHookSeconds = 2
+
+// FishHooksPath returns the path of the fish hooks file in a project.
+func FishHooksPath(projectDir string) string {
+	return filepath.Join(projectDir, scriptsDir, FishHooksFilename)
+}
+
+// hookRenderer writes init hooks as a POSIX or fish script. Plain hooks are
+// written as-is. Named hooks are wrapped so that failures are reported with
+// the hook's name, stop the remaining hooks unless continue_on_error is set,
+// and so that slow hooks report how long they took.
+type hookRenderer struct {
+	fish bool
+
+	// markerDir holds one file per only_once hook containing the lockHash
+	// the hook last succeeded with.
+	markerDir string
+	lockHash  string
+}
+
+func newHookRenderer(d devboxer, hooks *shellcmd.Hooks) (*hookRenderer, error) {
+	r := &hookRenderer{markerDir: filepath.Join(nix.ProjectUserDir(d.ProjectDir()), "hooks")}
+	for _, hook := range hooks.Hooks {
+		if hook.OnlyOnce {
+			hash, err := cachehash.JSONFile(filepath.Join(d.ProjectDir(), "devbox.lock"))
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			r.lockHash = hash
+			break
+		}
+	}
+	return r, nil
+}
+
+func (r *hookRenderer) render(hooks *shellcmd.Hooks) string {
+	if !hooks.Structured() {
+		return hooks.String()
+	}
+
+	sb := &strings.Builder{}
+	if r.fish {
+		sb.WriteString("set -e __DEVBOX_HOOK_FAILED\n")
+	} else {
+		sb.WriteString("unset __DEVBOX_HOOK_FAILED\n")
+	}
+	for i, hook := range hooks.Hooks {
+		sb.WriteString("\n")
+		if hook.Plain() {
+			sb.WriteString(hook.Run.String())
+			sb.WriteString("\n")
+			continue
+		}
+		if r.fish {
+			r.writeFish(sb, &hook, hookLabel(&hook, i))
+		} else {
+			r.writePOSIX(sb, &hook, hookLabel(&hook, i))
+		}
+	}
+	return sb.String()
+}
+
+func (r *hookRenderer) writePOSIX(sb *strings.Builder, hook *shellcmd.Hook, label string) {
+	marker := QuotePOSIX(r.markerPath(hook))
+	fmt.Fprintf(sb, "# init hook: %s\n", label)
+	if hook.OnlyOnce {
+		fmt.Fprintf(sb, "if [ \"$(cat %s 2>/dev/null)\" != %s ]; then\n", marker, QuotePOSIX(r.lockHash))
+	}
+	sb.WriteString("__devbox_hook_start=$(date +%s)\n")
+	sb.WriteString(hook.Run.String())
+	sb.WriteString("\n__devbox_hook_status=$?\n")
+	sb.WriteString("if [ \"$__devbox_hook_status\" -ne 0 ]; then\n")
+	fmt.Fprintf(sb, "  echo \"devbox: init hook \\\"%s\\\" failed with exit status $__devbox_hook_status\" >&2\n", label)
+	if !hook.ContinueOnError {
+		sb.WriteString("  __DEVBOX_HOOK_FAILED=$__devbox_hook_status\n")
+		sb.WriteString("  return \"$__devbox_hook_status\"\n")
+	}
+	if hook.OnlyOnce {
+		sb.WriteString("else\n")
+		fmt.Fprintf(sb, "  mkdir -p %s && echo %s > %s\n",
+			QuotePOSIX(r.markerDir), QuotePOSIX(r.lockHash), marker)
+	}
+	sb.WriteString("fi\n")
+	sb.WriteString("__devbox_hook_elapsed=$(( $(date +%s) - __devbox_hook_start ))\n")
+	fmt.Fprintf(sb, "if [ \"${DEVBOX_DEBUG:-}\" = 1 ] || [ \"${DEVBOX_DEBUG:-}\" = true ] || [ \"$__devbox_hook_elapsed\" -ge %d ]; then\n", slowHookSeconds)
+	fmt.Fprintf(sb, "  echo \"devbox: init hook \\\"%s\\\" took ${__devbox_hook_elapsed}s\" >&2\n", label)
+	sb.WriteString("fi\n")
+	if hook.OnlyOnce {
+		sb.WriteString("fi\n")
+	}
+}
+
+func (r *hookRenderer) writeFish(sb *strings.Builder, hook *shellcmd.Hook, label string) {
+	marker := QuoteFish(r.markerPath(hook))
+	fmt.Fprintf(sb, "# init hook: %s\n", label)
+	if hook.OnlyOnce {
+		fmt.Fprintf(sb, "if not string match -q -- %s (cat %s 2>/dev/null)\n", QuoteFish(r.lockHash), marker)
+	}
+	sb.WriteString("set __devbox_hook_start (date +%s)\n")
+	sb.WriteString(hook.Run.String())
+	sb.WriteString("\nset __devbox_hook_status $status\n")
+	sb.WriteString("if test $__devbox_hook_status -ne 0\n")
+	fmt.Fprintf(sb, "    echo \"devbox: init hook \\\"%s\\\" failed with exit status $__devbox_hook_status\" >&2\n", label)
+	if !hook.ContinueOnError {
+		sb.WriteString("    set -g __DEVBOX_HOOK_FAILED $__devbox_hook_status\n")
+		sb.WriteString("    return $__devbox_hook_status\n")
+	}
+	if hook.OnlyOnce {
+		sb.WriteString("else\n")
+		fmt.Fprintf(sb, "    mkdir -p %s; and echo %s > %s\n",
+			QuoteFish(r.markerDir), QuoteFish(r.lockHash), marker)
+	}
+	sb.WriteString("end\n")
+	sb.WriteString("set __devbox_hook_elapsed (math (date +%s) - $__devbox_hook_start)\n")
+	fmt.Fprintf(sb, "if contains -- \"$DEVBOX_DEBUG\" 1 true; or test $__devbox_hook_elapsed -ge %d\n", slowHookSeconds)
+	fmt.Fprintf(sb, "    echo \"devbox: init hook \\\"%s\\\" took \"$__devbox_hook_elapsed\"s\" >&2\n", label)
+	sb.WriteString("end\n")
+	if hook.OnlyOnce {
+		sb.WriteString("end\n")
+	}
+}
+
+// markerPath returns the file that records the lock hash an only_once hook
+// last succeeded with. Changing the hook's commands runs it again.
+func (r *hookRenderer) markerPath(hook *shellcmd.Hook) string {
+	key := cachehash.Bytes6([]byte(hook.Source + "\x00" + hook.Name + "\x00" + hook.Run.String()))
+	return filepath.Join(r.markerDir, key)
+}
+
+// hookLabel returns the name that identifies a hook in messages. It only
+// keeps characters that are safe inside a double-quoted string in both POSIX
+// shells and fish.
+func hookLabel(hook *shellcmd.Hook, index int) string {
+	name := hook.Name
+	if name == "" {
+		name = fmt.Sprintf("#%d", index+1)
+	}
+	if hook.Source != "" {
+		name = hook.Source + ": " + name
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		case strings.ContainsRune(" #.,:/@+_-", r):
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
+
+// QuotePOSIX quotes s for sh, bash and zsh.
+func QuotePOSIX(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// QuoteFish quotes s for fish, which allows escaping quotes and backslashes in
+// single quotes.
+func QuoteFish(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return "'" + strings.ReplaceAll(s, "'", `\'`) + "'"
+}
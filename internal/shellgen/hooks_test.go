@@ -0,0 +1,84 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package shellgen
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"go.jetify.com/devbox/internal/devbox/shellcmd"
+)
+
+func unmarshalHooks(t *testing.T, in string) *shellcmd.Hooks {
+	t.Helper()
+	hooks := &shellcmd.Hooks{}
+	if err := json.Unmarshal([]byte(in), hooks); err != nil {
+		t.Fatal(err)
+	}
+	return hooks
+}
+
+func TestRenderPlainHooks(t *testing.T) {
+	hooks := unmarshalHooks(t, `["echo a", "echo b"]`)
+	for _, fish := range []bool{false, true} {
+		r := &hookRenderer{fish: fish}
+		if got, want := r.render(hooks), "echo a\necho b"; got != want {
+			t.Errorf("render(fish=%v) = %q, want %q", fish, got, want)
+		}
+	}
+}
+
+func TestRenderStructuredHooks(t *testing.T) {
+	hooks := unmarshalHooks(t, `[
+		"echo plain",
+		{"name": "setup", "run": "./setup.sh", "only_once": true},
+		{"run": "./lint.sh", "continue_on_error": true}
+	]`)
+	hooks.Hooks[1].Source = "my-plugin"
+	r := &hookRenderer{markerDir: "/project/.devbox/hooks", lockHash: "abc"}
+
+	got := r.render(hooks)
+	for _, want := range []string{
+		"unset __DEVBOX_HOOK_FAILED\n",
+		"\necho plain\n",
+		"# init hook: my-plugin: setup\n",
+		"!= 'abc' ]; then\n",
+		"echo 'abc' > '/project/.devbox/hooks/",
+		`init hook \"my-plugin: setup\" failed`,
+		"  return \"$__devbox_hook_status\"\n",
+		"# init hook: #3\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("render() is missing %q, got:\n%s", want, got)
+		}
+	}
+	// The continue_on_error hook is last, so only the first named hook can
+	// stop the others.
+	if n := strings.Count(got, "return "); n != 1 {
+		t.Errorf("render() has %d return statements, want 1:\n%s", n, got)
+	}
+
+	r.fish = true
+	got = r.render(hooks)
+	for _, want := range []string{
+		"set -e __DEVBOX_HOOK_FAILED\n",
+		"if not string match -q -- 'abc' (cat '/project/.devbox/hooks/",
+		"    set -g __DEVBOX_HOOK_FAILED $__devbox_hook_status\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("render(fish) is missing %q, got:\n%s", want, got)
+		}
+	}
+}
+
+func TestHookLabel(t *testing.T) {
+	hook := &shellcmd.Hook{Name: `say "hi" $USER`, Source: "plugin"}
+	if got, want := hookLabel(hook, 0), "plugin: say _hi_ _USER"; got != want {
+		t.Errorf("hookLabel() = %q, want %q", got, want)
+	}
+	if got, want := hookLabel(&shellcmd.Hook{}, 1), "#2"; got != want {
+		t.Errorf("hookLabel() = %q, want %q", got, want)
+	}
+}
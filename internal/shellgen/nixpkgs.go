@@ -10,6 +10,7 @@
 	"time"
 
 	"go.jetify.com/devbox/internal/envir"
+	"go.jetify.com/devbox/internal/httpclient"
 )
 
 // Contains default nixpkgs used for mkShell
@@ -38,7 +39,7 @@ func nixpkgsMirrorURL(commitHash string) string {
 
 	// Check that the mirror is responsive and has the tar file. We can't
 	// leave this up to Nix because fetchTarball will retry indefinitely.
-	client := &http.Client{Timeout: 3 * time.Second}
+	client := &http.Client{Transport: httpclient.Transport(), Timeout: 3 * time.Second}
 	mirrorURL := fmt.Sprintf("%s/nixos/nixpkgs/archive/%s.tar.gz", baseURL, commitHash)
 	resp, err := client.Head(mirrorURL)
 	if err != nil || resp.StatusCode != http.StatusOK {
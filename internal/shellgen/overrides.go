@@ -0,0 +1,197 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package shellgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+	"go.jetify.com/devbox/internal/cachehash"
+	"go.jetify.com/devbox/internal/devpkg"
+	"go.jetify.com/devbox/internal/nix"
+)
+
+// overlaysDir and patchesDir are the directories in the generated flake that
+// have copies of the packages' overlay and patch files. Nix can't read files
+// outside of the flake when it evaluates it in pure mode.
+const (
+	overlaysDir = "overlays"
+	patchesDir  = "patches"
+)
+
+// overlayPath returns the path of the copy of an overlay file, relative to the
+// generated flake.
+func overlayPath(overlay string) string {
+	return filepath.Join(overlaysDir, cachehash.Bytes6([]byte(overlay))+".nix")
+}
+
+// patchPath returns the path of the copy of a patch file, relative to the
+// generated flake. It keeps the file's name so that it's recognizable in build
+// logs.
+func patchPath(patch string) string {
+	name := unsafePathChars.ReplaceAllString(filepath.Base(patch), "_")
+	return filepath.Join(patchesDir, cachehash.Bytes6([]byte(patch))+"-"+name)
+}
+
+// unsafePathChars matches characters that can't be in a Nix path literal.
+var unsafePathChars = regexp.MustCompile(`[^a-zA-Z0-9._+-]`)
+
+// writeCustomBuildFiles copies the overlay and patch files of packages into
+// the generated flake.
+func writeCustomBuildFiles(flakeDir string, packages []*devpkg.Package) error {
+	for _, pkg := range packages {
+		if pkg.Overlay != "" {
+			err := copyIntoFlake(flakeDir, pkg.Overlay, overlayPath(pkg.Overlay), "Overlay", pkg)
+			if err != nil {
+				return err
+			}
+		}
+		for _, patch := range pkg.Patches {
+			if err := copyIntoFlake(flakeDir, patch, patchPath(patch), "Patch", pkg); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func copyIntoFlake(flakeDir, src, dst, kind string, pkg *devpkg.Package) error {
+	data, err := os.ReadFile(src)
+	if errors.Is(err, os.ErrNotExist) {
+		return usererr.New("%s %s for package %s doesn't exist.", kind, src, pkg.Raw)
+	}
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if _, err := overwriteFileIfChanged(filepath.Join(flakeDir, dst), data, 0o644); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// customBuildExpr wraps the Nix expression for a package so that it's built
+// with the package's overlay, overrides and patches. pkgs is the expression for the
+// package set that the package comes from and attr is the package's attribute
+// within it.
+func (f *flakeInput) customBuildExpr(pkg *devpkg.Package, pkgs, attr string) (string, error) {
+	if !pkg.IsCustomBuild() {
+		return pkgs + "." + attr, nil
+	}
+	if pkg.Patch {
+		return "", usererr.New(
+			"Devbox can't apply its automatic fixes to package %s because it has an "+
+				`override, overlay, patches or inputs. Set "patch": "never" for the package in devbox.json.`, pkg.Raw)
+	}
+	if len(pkg.Inputs) > 0 && pkg.IsDevboxPackage {
+		return "", usererr.New(
+			"Package %s has inputs, but inputs can only be overridden for flake packages, such as github:org/tool.", pkg.Raw)
+	}
+
+	if pkg.Overlay != "" {
+		if !f.Ref.IsNixpkgs() {
+			return "", usererr.New(
+				"Package %s has an overlay, but overlays are only supported for nixpkgs packages.", pkg.Raw)
+		}
+		pkgs = fmt.Sprintf(
+			`(import %s { system = %s; config = %s.config; overlays = [ (import ./%s) ]; })`,
+			f.Name, nixString(nix.System()), f.PkgImportName(), filepath.ToSlash(overlayPath(pkg.Overlay)),
+		)
+	}
+	expr := pkgs + "." + attr
+	if len(pkg.Override) > 0 {
+		args, err := nixValue(pkg.Override)
+		if err != nil {
+			return "", usererr.WithUserMessage(err, "Invalid override for package %s.", pkg.Raw)
+		}
+		expr = fmt.Sprintf("(%s.override %s)", expr, args)
+	}
+	if len(pkg.Patches) > 0 {
+		patches := make([]string, len(pkg.Patches))
+		for i, patch := range pkg.Patches {
+			patches[i] = "./" + filepath.ToSlash(patchPath(patch))
+		}
+		expr = fmt.Sprintf(
+			"(%s.overrideAttrs (old: { patches = (old.patches or [ ]) ++ [ %s ]; }))",
+			expr, strings.Join(patches, " "),
+		)
+	}
+	return expr, nil
+}
+
+var (
+	nixIdentifierRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_'-]*$`)
+	nixKeywords         = []string{"assert", "else", "if", "in", "inherit", "let", "or", "rec", "then", "with"}
+)
+
+// nixValue converts a value decoded from JSON to a Nix expression.
+func nixValue(v any) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "null", nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case json.Number:
+		return v.String(), nil
+	case string:
+		return nixString(v), nil
+	case []any:
+		elems := make([]string, len(v))
+		for i, elem := range v {
+			s, err := nixValue(elem)
+			if err != nil {
+				return "", err
+			}
+			if strings.HasPrefix(s, "-") {
+				// Nix parses [ 1 -2 ] as a subtraction.
+				s = "(" + s + ")"
+			}
+			elems[i] = s
+		}
+		if len(elems) == 0 {
+			return "[ ]", nil
+		}
+		return "[ " + strings.Join(elems, " ") + " ]", nil
+	case map[string]any:
+		b := strings.Builder{}
+		b.WriteString("{ ")
+		for _, key := range slices.Sorted(maps.Keys(v)) {
+			s, err := nixValue(v[key])
+			if err != nil {
+				return "", err
+			}
+			if !nixIdentifierRegexp.MatchString(key) || slices.Contains(nixKeywords, key) {
+				key = nixString(key)
+			}
+			fmt.Fprintf(&b, "%s = %s; ", key, s)
+		}
+		b.WriteString("}")
+		return b.String(), nil
+	}
+	return "", errors.Errorf("unsupported value %v of type %T", v, v)
+}
+
+// nixString quotes s as a Nix string literal.
+func nixString(s string) string {
+	return `"` + strings.NewReplacer(
+		`\`, `\\`,
+		`"`, `\"`,
+		"${", `\${`,
+		"\n", `\n`,
+		"\r", `\r`,
+		"\t", `\t`,
+	).Replace(s) + `"`
+}
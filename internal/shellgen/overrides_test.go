@@ -0,0 +1,110 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package shellgen
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"go.jetify.com/devbox/internal/devpkg"
+	"go.jetify.com/devbox/nix/flake"
+)
+
+func TestNixValue(t *testing.T) {
+	tests := []struct {
+		in   any
+		want string
+	}{
+		{nil, "null"},
+		{true, "true"},
+		{float64(3), "3"},
+		{1.5, "1.5"},
+		{"hello", `"hello"`},
+		{`say "${hi}"`, `"say \"\${hi}\""`},
+		{[]any{}, "[ ]"},
+		{[]any{float64(1), float64(-2), "x"}, `[ 1 (-2) "x" ]`},
+		{map[string]any{}, "{ }"},
+		{
+			map[string]any{"withWebp": true, "enable-x": false, "2fa": "on", "with": 1.0, "nested": map[string]any{"a": nil}},
+			`{ "2fa" = "on"; enable-x = false; nested = { a = null; }; "with" = 1; withWebp = true; }`,
+		},
+	}
+	for _, test := range tests {
+		got, err := nixValue(test.in)
+		if err != nil {
+			t.Errorf("nixValue(%#v) error: %v", test.in, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("nixValue(%#v) = %s, want %s", test.in, got, test.want)
+		}
+	}
+
+	if _, err := nixValue(struct{}{}); err == nil {
+		t.Error("got nil error for an unsupported type")
+	}
+}
+
+func TestCustomBuildExpr(t *testing.T) {
+	input := &flakeInput{
+		Name: "nixpkgs-abc",
+		Ref:  flake.Ref{Type: flake.TypeGitHub, Owner: "NixOS", Repo: "nixpkgs", Rev: "abc"},
+	}
+
+	pkg := &devpkg.Package{Raw: "hello"}
+	got, err := input.customBuildExpr(pkg, input.PkgImportName(), "hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "nixpkgs-abc-pkgs.hello"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	pkg = &devpkg.Package{
+		Raw:      "ffmpeg",
+		Override: map[string]any{"withWebp": true},
+		Patches:  []string{"/project/patches/fix build.diff"},
+	}
+	got, err = input.customBuildExpr(pkg, input.PkgImportName(), "ffmpeg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "((nixpkgs-abc-pkgs.ffmpeg.override { withWebp = true; }).overrideAttrs " +
+		"(old: { patches = (old.patches or [ ]) ++ [ ./" + patchPath(pkg.Patches[0]) + " ]; }))"
+	if got != want {
+		t.Errorf("got %s\nwant %s", got, want)
+	}
+	if !strings.HasSuffix(patchPath(pkg.Patches[0]), "-fix_build.diff") {
+		t.Errorf("got patch path %s, want it to end with -fix_build.diff", patchPath(pkg.Patches[0]))
+	}
+
+	pkg.Patch = true
+	if _, err := input.customBuildExpr(pkg, input.PkgImportName(), "ffmpeg"); err == nil {
+		t.Error("got nil error for a custom build that needs Devbox's patches")
+	}
+}
+
+func TestInputOverrides(t *testing.T) {
+	input := &flakeInput{
+		Name: "gh-org-tool-abc123",
+		Inputs: map[string]string{
+			"nixpkgs":     "follows-stdenv",
+			"flake-utils": "github:numtide/flake-utils",
+		},
+	}
+	got := input.InputOverrides()
+	want := []string{
+		`gh-org-tool-abc123.inputs."flake-utils".url = "github:numtide/flake-utils";`,
+		`gh-org-tool-abc123.inputs."nixpkgs".follows = "nixpkgs";`,
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q\nwant %q", got, want)
+	}
+
+	pkg := &devpkg.Package{Raw: "go@1.22", IsDevboxPackage: true, Inputs: input.Inputs}
+	if _, err := input.customBuildExpr(pkg, "pkgs", "go"); err == nil {
+		t.Error("got nil error for input overrides of a devbox package")
+	}
+}
@@ -25,6 +25,29 @@
 
 const scriptsDir = ".devbox/gen/scripts"
 
+// scriptSourcesDir has the sources of the scripts that run with an
+// interpreter other than sh.
+const scriptSourcesDir = scriptsDir + "/src"
+
+// interpreterExtensions are the file extensions of the sources of scripts
+// that run with an interpreter, for interpreters that pick the language of
+// a file by its extension, such as deno.
+var interpreterExtensions = map[string]string{
+	"bash":    ".sh",
+	"bun":     ".ts",
+	"deno":    ".ts",
+	"fish":    ".fish",
+	"lua":     ".lua",
+	"node":    ".js",
+	"perl":    ".pl",
+	"php":     ".php",
+	"python":  ".py",
+	"python3": ".py",
+	"ruby":    ".rb",
+	"sh":      ".sh",
+	"zsh":     ".zsh",
+}
+
 const HooksFilename = ".hooks"
 
 type devboxer interface {
@@ -54,15 +77,31 @@ func WriteScriptsToFiles(devbox devboxer) error {
 	// Write all hooks to a file.
 	written := map[string]struct{}{} // set semantics; value is irrelevant
 	// always write it, even if there are no hooks, because scripts will source it.
-	err = writeRawInitHookFile(devbox, devbox.Config().InitHook().String())
+	err = writeInitHookFiles(devbox)
 	if err != nil {
 		return errors.WithStack(err)
 	}
 	written[HooksFilename] = struct{}{}
+	written[FishHooksFilename] = struct{}{}
+
+	// Rewrite the sources of interpreted scripts, so that the sources of
+	// removed scripts don't stay behind.
+	if err := os.RemoveAll(filepath.Join(devbox.ProjectDir(), scriptSourcesDir)); err != nil {
+		return errors.WithStack(err)
+	}
 
 	// Write scripts to files.
-	for name, body := range devbox.Config().Scripts() {
-		scriptBody, err := ScriptBody(devbox, body.String())
+	scripts := devbox.Config().Scripts()
+	if err := scripts.CheckDeps(); err != nil {
+		return err
+	}
+	for name, script := range scripts {
+		body, err := interpretedScriptBody(devbox.ProjectDir(), name, script.Interpreter, script.String())
+		if err != nil {
+			return err
+		}
+		body = depsBody(devbox, script.Deps) + body
+		scriptBody, err := ScriptBody(devbox, body)
 		if err != nil {
 			return errors.WithStack(err)
 		}
@@ -87,8 +126,24 @@ func WriteScriptsToFiles(devbox devboxer) error {
 	return nil
 }
 
-func writeRawInitHookFile(devbox devboxer, body string) (err error) {
-	script, err := createScriptFile(devbox, HooksFilename)
+// writeInitHookFiles writes the init hooks to the POSIX hooks file that shells
+// and scripts source, and to a fish version of it for fish shells.
+func writeInitHookFiles(devbox devboxer) error {
+	hooks := devbox.Config().InitHook()
+	renderer, err := newHookRenderer(devbox, hooks)
+	if err != nil {
+		return err
+	}
+	err = writeRawInitHookFile(ScriptPath(devbox.ProjectDir(), HooksFilename), renderer.render(hooks))
+	if err != nil {
+		return err
+	}
+	renderer.fish = true
+	return writeRawInitHookFile(FishHooksPath(devbox.ProjectDir()), renderer.render(hooks))
+}
+
+func writeRawInitHookFile(path, body string) (err error) {
+	script, err := createScriptFile(path)
 	if err != nil {
 		return errors.WithStack(err)
 	}
@@ -99,7 +154,7 @@ func writeRawInitHookFile(devbox devboxer, body string) (err error) {
 }
 
 func WriteScriptFile(devbox devboxer, name, body string) (err error) {
-	script, err := createScriptFile(devbox, name)
+	script, err := createScriptFile(ScriptPath(devbox.ProjectDir(), name))
 	if err != nil {
 		return errors.WithStack(err)
 	}
@@ -113,8 +168,8 @@ func WriteScriptFile(devbox devboxer, name, body string) (err error) {
 	return errors.WithStack(err)
 }
 
-func createScriptFile(devbox devboxer, name string) (script *os.File, err error) {
-	script, err = os.Create(ScriptPath(devbox.ProjectDir(), name))
+func createScriptFile(path string) (script *os.File, err error) {
+	script, err = os.Create(path)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
@@ -132,6 +187,45 @@ func createScriptFile(devbox devboxer, name string) (script *os.File, err error)
 	return script, nil
 }
 
+// interpretedScriptBody returns the body of the sh wrapper of a script. Scripts
+// that have an interpreter, or whose body starts with a shebang, don't run in
+// sh: their body is written to a source file, which the wrapper runs with the
+// interpreter, or directly so that the shebang picks it, after the init hooks.
+// The source gets the script's arguments.
+func interpretedScriptBody(projectDir, name, interpreter, body string) (string, error) {
+	fields := strings.Fields(interpreter)
+	if len(fields) == 0 && !strings.HasPrefix(body, "#!") {
+		return body, nil
+	}
+
+	path := filepath.Join(projectDir, scriptSourcesDir, name)
+	if len(fields) > 0 {
+		path += interpreterExtensions[filepath.Base(fields[0])]
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", errors.WithStack(err)
+	}
+	if err := os.WriteFile(path, []byte(body+"\n"), 0o755); err != nil {
+		return "", errors.WithStack(err)
+	}
+	if len(fields) == 0 {
+		return fmt.Sprintf("exec %s \"$@\"", QuotePOSIX(path)), nil
+	}
+	return fmt.Sprintf("exec %s %s \"$@\"", interpreter, QuotePOSIX(path)), nil
+}
+
+// depsBody returns the commands that run a script's deps before the script.
+// The deps run in the script's environment, so they skip the init hooks,
+// which already ran.
+func depsBody(devbox devboxer, deps []string) string {
+	body := ""
+	for _, dep := range deps {
+		body += fmt.Sprintf("%s=true %s || exit $?\n",
+			devbox.SkipInitHookEnvName(), QuotePOSIX(ScriptPath(devbox.ProjectDir(), dep)))
+	}
+	return body
+}
+
 func ScriptPath(projectDir, scriptName string) string {
 	return filepath.Join(projectDir, scriptsDir, scriptName+".sh")
 }
@@ -0,0 +1,69 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package shellgen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInterpretedScriptBody(t *testing.T) {
+	dir := t.TempDir()
+	srcDir := filepath.Join(dir, scriptSourcesDir)
+	tests := []struct {
+		name        string
+		interpreter string
+		body        string
+		want        string
+		wantSource  string
+	}{
+		{
+			name: "sh",
+			body: "echo hello",
+			want: "echo hello",
+		},
+		{
+			name:        "python",
+			interpreter: "python",
+			body:        "print('hello')",
+			want:        "exec python '" + filepath.Join(srcDir, "python.py") + `' "$@"`,
+			wantSource:  filepath.Join(srcDir, "python.py"),
+		},
+		{
+			name:        "strict",
+			interpreter: "bash -euo pipefail",
+			body:        "echo hello",
+			want:        "exec bash -euo pipefail '" + filepath.Join(srcDir, "strict.sh") + `' "$@"`,
+			wantSource:  filepath.Join(srcDir, "strict.sh"),
+		},
+		{
+			name:       "shebang",
+			body:       "#!/usr/bin/env python3\nprint('hello')",
+			want:       "exec '" + filepath.Join(srcDir, "shebang") + `' "$@"`,
+			wantSource: filepath.Join(srcDir, "shebang"),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := interpretedScriptBody(dir, test.name, test.interpreter, test.body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("got body %q, want %q", got, test.want)
+			}
+			if test.wantSource == "" {
+				return
+			}
+			source, err := os.ReadFile(test.wantSource)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(source) != test.body+"\n" {
+				t.Errorf("got source %q, want %q", source, test.body+"\n")
+			}
+		})
+	}
+}
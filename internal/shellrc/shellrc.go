@@ -0,0 +1,92 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+// Package shellrc finds and edits the rcfiles that shells run when they start,
+// which users load devbox from.
+package shellrc
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"go.jetify.com/devbox/internal/xdg"
+)
+
+// Files returns the rcfiles that users load devbox from.
+func Files() []string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+	zdotdir := zdotdir(home)
+	return []string{
+		filepath.Join(home, ".bashrc"),
+		filepath.Join(home, ".bash_profile"),
+		filepath.Join(home, ".profile"),
+		filepath.Join(zdotdir, ".zshrc"),
+		filepath.Join(zdotdir, ".zprofile"),
+		xdg.ConfigSubpath("fish/config.fish"),
+	}
+}
+
+// ForShell returns the rcfile that interactive shells of shell, the name or
+// path of a shell such as /bin/zsh, run. It returns "" for shells other than
+// bash, zsh and fish.
+func ForShell(shell string) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	switch filepath.Base(shell) {
+	case "bash":
+		return filepath.Join(home, ".bashrc")
+	case "zsh":
+		return filepath.Join(zdotdir(home), ".zshrc")
+	case "fish":
+		return xdg.ConfigSubpath("fish/config.fish")
+	}
+	return ""
+}
+
+// Contains reports whether a line of the rcfile in path matches re.
+func Contains(path string, re *regexp.Regexp) bool {
+	data, err := os.ReadFile(path)
+	return err == nil && re.Match(data)
+}
+
+// Append adds lines to the end of the rcfile in path, creating it if it
+// doesn't exist.
+func Append(path string, lines ...string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return errors.WithStack(err)
+	}
+	out := &bytes.Buffer{}
+	if len(data) > 0 && !bytes.HasSuffix(data, []byte("\n")) {
+		out.WriteString("\n")
+	}
+	out.WriteString(strings.Join(lines, "\n") + "\n")
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return errors.WithStack(err)
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer file.Close()
+	_, err = file.Write(out.Bytes())
+	return errors.WithStack(err)
+}
+
+func zdotdir(home string) string {
+	if dir := os.Getenv("ZDOTDIR"); dir != "" {
+		return os.ExpandEnv(dir)
+	}
+	return home
+}
@@ -0,0 +1,66 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package shellrc
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestAppend(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{name: "new file", want: "eval \"$(devbox global shellenv)\"\n"},
+		{name: "trailing newline", in: "export EDITOR=vim\n", want: "export EDITOR=vim\neval \"$(devbox global shellenv)\"\n"},
+		{name: "no trailing newline", in: "export EDITOR=vim", want: "export EDITOR=vim\neval \"$(devbox global shellenv)\"\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "zsh", ".zshrc")
+			if test.in != "" {
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(test.in), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := Append(path, `eval "$(devbox global shellenv)"`); err != nil {
+				t.Fatal(err)
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+			if !Contains(path, regexp.MustCompile(`devbox global shellenv`)) {
+				t.Error("Contains didn't find the appended line")
+			}
+		})
+	}
+}
+
+func TestForShell(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("ZDOTDIR", "")
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+
+	tests := map[string]string{
+		"/bin/bash":           filepath.Join(home, ".bashrc"),
+		"zsh":                 filepath.Join(home, ".zshrc"),
+		"/usr/local/bin/fish": filepath.Join(home, ".config", "fish", "config.fish"),
+		"/bin/tcsh":           "",
+	}
+	for shell, want := range tests {
+		if got := ForShell(shell); got != want {
+			t.Errorf("ForShell(%q) = %q, want %q", shell, got, want)
+		}
+	}
+}
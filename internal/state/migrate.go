@@ -0,0 +1,92 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package state
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// Migration moves the files of an old layout to the current one.
+type Migration struct {
+	Description string `json:"description"`
+	From        string `json:"from"`
+	To          string `json:"to"`
+}
+
+// PendingMigrations returns the migrations of the old layouts that exist on
+// this machine.
+func PendingMigrations() []Migration {
+	userCacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return nil
+	}
+	return pendingMigrations(userCacheDir)
+}
+
+func pendingMigrations(userCacheDir string) []Migration {
+	migrations := []Migration{}
+
+	// Older versions of devbox kept file caches, such as flake metadata and
+	// plugin downloads, in the system's cache directory instead of devbox's
+	// cache directory. They're different on macOS, which uses
+	// ~/Library/Caches.
+	from := filepath.Join(userCacheDir, "devbox")
+	if filepath.Clean(from) != filepath.Clean(Cache()) && exists(from) {
+		migrations = append(migrations, Migration{
+			Description: "file caches in the system's cache directory",
+			From:        from,
+			To:          Cache(),
+		})
+	}
+	return migrations
+}
+
+// Apply moves the files in m.From to m.To and removes m.From. Files that
+// already exist in m.To are kept, and the old ones are removed.
+func (m Migration) Apply() error {
+	if err := os.MkdirAll(filepath.Dir(m.To), 0o755); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := merge(m.From, m.To); err != nil {
+		return err
+	}
+	return errors.WithStack(os.RemoveAll(m.From))
+}
+
+// merge moves from to to. If both are directories, it moves the entries of
+// from that aren't in to.
+func merge(from, to string) error {
+	toInfo, err := os.Lstat(to)
+	if errors.Is(err, os.ErrNotExist) {
+		return errors.WithStack(os.Rename(from, to))
+	} else if err != nil {
+		return errors.WithStack(err)
+	}
+	fromInfo, err := os.Lstat(from)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if !fromInfo.IsDir() || !toInfo.IsDir() {
+		return nil
+	}
+
+	entries, err := os.ReadDir(from)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for _, entry := range entries {
+		if err := merge(filepath.Join(from, entry.Name()), filepath.Join(to, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func exists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
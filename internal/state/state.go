@@ -0,0 +1,192 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+// Package state locates the files that devbox keeps for the user outside of
+// projects: caches, devbox global, the tools that devbox installs for itself,
+// setup and telemetry state, and configuration. Code that reads or writes
+// these files should get their paths from this package so that
+// `devbox state` can list, clean and migrate them.
+package state
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"go.jetify.com/devbox/internal/xdg"
+)
+
+// Kind is the kind of files in a location, which determines whether it's safe
+// to delete them.
+type Kind string
+
+const (
+	// KindCache files are recreated or downloaded again when they're
+	// missing.
+	KindCache Kind = "cache"
+
+	// KindData files, such as devbox global packages, are lost when they're
+	// deleted.
+	KindData Kind = "data"
+
+	// KindState files, such as logs and telemetry buffers, aren't needed
+	// but are useful to keep.
+	KindState Kind = "state"
+
+	// KindConfig files are settings and keys that the user created.
+	KindConfig Kind = "config"
+)
+
+// Location is a file or directory that devbox keeps state in.
+type Location struct {
+	Name        string `json:"name"`
+	Kind        Kind   `json:"kind"`
+	Path        string `json:"path"`
+	Description string `json:"description"`
+}
+
+// Cache returns the path of elem in devbox's cache directory.
+func Cache(elem ...string) string {
+	return filepath.Join(append([]string{xdg.CacheSubpath("devbox")}, elem...)...)
+}
+
+// Data returns the path of elem in devbox's data directory.
+func Data(elem ...string) string {
+	return filepath.Join(append([]string{xdg.DataSubpath("devbox")}, elem...)...)
+}
+
+// State returns the path of elem in devbox's state directory.
+func State(elem ...string) string {
+	return filepath.Join(append([]string{xdg.StateSubpath("devbox")}, elem...)...)
+}
+
+// Config returns the path of elem in devbox's config directory.
+func Config(elem ...string) string {
+	return filepath.Join(append([]string{xdg.ConfigSubpath("devbox")}, elem...)...)
+}
+
+// FileCacheDir is the directory to pass to filecache.WithCacheDir. File caches
+// have domains that start with "devbox/", so they're in the Cache directory.
+func FileCacheDir() string {
+	return xdg.CacheSubpath("")
+}
+
+// UserLocations returns the locations that devbox keeps the user's state in.
+// Locations can be nested: a location in another one with a different kind
+// is kept when the outer one is cleaned.
+func UserLocations() []Location {
+	return []Location{
+		{
+			Name:        "cache",
+			Kind:        KindCache,
+			Path:        Cache(),
+			Description: "search index, flake metadata, plugin downloads and other caches",
+		},
+		{
+			Name:        "url packages",
+			Kind:        KindData,
+			Path:        Cache("url"),
+			Description: "unpacked files of url packages",
+		},
+		{
+			Name:        "global",
+			Kind:        KindData,
+			Path:        Data("global"),
+			Description: "devbox global's devbox.json and packages",
+		},
+		{
+			Name:        "utilities",
+			Kind:        KindData,
+			Path:        Data("util"),
+			Description: "tools that devbox installs for itself, such as process-compose",
+		},
+		{
+			Name:        "state",
+			Kind:        KindState,
+			Path:        State(),
+			Description: "setup task state and telemetry buffers",
+		},
+		{
+			Name:        "config",
+			Kind:        KindConfig,
+			Path:        Config(),
+			Description: "user settings, trusted projects and the provenance signing key",
+		},
+	}
+}
+
+// Size returns the total size of the regular files in the location. It doesn't
+// follow symlinks, and it skips files it can't read.
+func (l Location) Size() int64 {
+	var size int64
+	_ = filepath.WalkDir(l.Path, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if entry.Type().IsRegular() {
+			if info, err := entry.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
+
+// Exists returns true if the location's path exists.
+func (l Location) Exists() bool {
+	_, err := os.Lstat(l.Path)
+	return err == nil
+}
+
+// Clean deletes the locations of the given kinds. Locations of other kinds
+// that are nested in them are kept. It returns the locations it deleted
+// files from.
+func Clean(locations []Location, kinds ...Kind) ([]Location, error) {
+	keep := []string{}
+	for _, l := range locations {
+		if !slices.Contains(kinds, l.Kind) {
+			keep = append(keep, filepath.Clean(l.Path))
+		}
+	}
+
+	cleaned := []Location{}
+	for _, l := range locations {
+		if !slices.Contains(kinds, l.Kind) || !l.Exists() {
+			continue
+		}
+		if err := removeExcept(filepath.Clean(l.Path), keep); err != nil {
+			return cleaned, err
+		}
+		cleaned = append(cleaned, l)
+	}
+	return cleaned, nil
+}
+
+// removeExcept removes path, except for the paths in keep that are in it.
+func removeExcept(path string, keep []string) error {
+	if slices.Contains(keep, path) {
+		return nil
+	}
+	nested := slices.ContainsFunc(keep, func(k string) bool {
+		return strings.HasPrefix(k, path+string(filepath.Separator))
+	})
+	if !nested {
+		return errors.WithStack(os.RemoveAll(path))
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for _, entry := range entries {
+		if err := removeExcept(filepath.Join(path, entry.Name()), keep); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCleanKeepsNestedLocations(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "cache", "search-index.json"), "index")
+	writeFile(t, filepath.Join(dir, "cache", "url", "pkg", "bin", "tool"), "tool")
+	writeFile(t, filepath.Join(dir, "config", "provenance.key"), "key")
+
+	locations := []Location{
+		{Name: "cache", Kind: KindCache, Path: filepath.Join(dir, "cache")},
+		{Name: "url packages", Kind: KindData, Path: filepath.Join(dir, "cache", "url")},
+		{Name: "config", Kind: KindConfig, Path: filepath.Join(dir, "config")},
+		{Name: "missing", Kind: KindCache, Path: filepath.Join(dir, "missing")},
+	}
+	cleaned, err := Clean(locations, KindCache)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cleaned) != 1 || cleaned[0].Name != "cache" {
+		t.Errorf("Clean() cleaned %v, want only the cache", cleaned)
+	}
+	assertNotExist(t, filepath.Join(dir, "cache", "search-index.json"))
+	assertContent(t, filepath.Join(dir, "cache", "url", "pkg", "bin", "tool"), "tool")
+	assertContent(t, filepath.Join(dir, "config", "provenance.key"), "key")
+
+	if _, err := Clean(locations, KindCache, KindData); err != nil {
+		t.Fatal(err)
+	}
+	assertNotExist(t, filepath.Join(dir, "cache"))
+	assertContent(t, filepath.Join(dir, "config", "provenance.key"), "key")
+}
+
+func TestMigrationApply(t *testing.T) {
+	dir := t.TempDir()
+	from := filepath.Join(dir, "Library", "Caches", "devbox")
+	to := filepath.Join(dir, ".cache", "devbox")
+	writeFile(t, filepath.Join(from, "flake-metadata", "a"), "old a")
+	writeFile(t, filepath.Join(from, "flake-metadata", "b"), "old b")
+	writeFile(t, filepath.Join(from, "plugin", "git"), "git")
+	writeFile(t, filepath.Join(to, "flake-metadata", "a"), "new a")
+
+	m := Migration{From: from, To: to}
+	if err := m.Apply(); err != nil {
+		t.Fatal(err)
+	}
+	assertNotExist(t, from)
+	assertContent(t, filepath.Join(to, "flake-metadata", "a"), "new a")
+	assertContent(t, filepath.Join(to, "flake-metadata", "b"), "old b")
+	assertContent(t, filepath.Join(to, "plugin", "git"), "git")
+}
+
+func TestPendingMigrations(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(t.TempDir(), "xdg"))
+	userCacheDir := t.TempDir()
+	if got := pendingMigrations(userCacheDir); len(got) != 0 {
+		t.Errorf("pendingMigrations() = %v, want none without an old cache", got)
+	}
+
+	writeFile(t, filepath.Join(userCacheDir, "devbox", "flake-metadata"), "{}")
+	got := pendingMigrations(userCacheDir)
+	if len(got) != 1 || got[0].To != Cache() {
+		t.Errorf("pendingMigrations() = %v, want a migration to %s", got, Cache())
+	}
+
+	// Linux uses the same cache directory for both.
+	if got := pendingMigrations(filepath.Dir(Cache())); len(got) != 0 {
+		t.Errorf("pendingMigrations() = %v, want none when the directories match", got)
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func assertContent(t *testing.T, path, want string) {
+	t.Helper()
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("%s contains %q, want %q", path, got, want)
+	}
+}
+
+func assertNotExist(t *testing.T, path string) {
+	t.Helper()
+	if _, err := os.Lstat(path); !os.IsNotExist(err) {
+		t.Errorf("got %s, want it deleted", path)
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package telemetry
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	"go.jetify.com/devbox/internal/state"
+)
+
+// consent is the user's answer to whether devbox may send telemetry.
+type consent struct {
+	Enabled bool `json:"enabled"`
+}
+
+func consentPath() string {
+	return state.Config("telemetry.json")
+}
+
+// Consent returns whether the user agreed to send telemetry. decided is false
+// if they haven't answered, in which case telemetry is sent unless
+// DO_NOT_TRACK is set.
+func Consent() (enabled, decided bool) {
+	data, err := os.ReadFile(consentPath())
+	if err != nil {
+		return false, false
+	}
+	c := consent{}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return false, false
+	}
+	return c.Enabled, true
+}
+
+// SetConsent records whether the user agrees to send telemetry.
+func SetConsent(enabled bool) error {
+	data, err := json.Marshal(consent{Enabled: enabled})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	path := consentPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(os.WriteFile(path, data, 0o644))
+}
@@ -17,6 +17,7 @@
 
 	"go.jetify.com/devbox/internal/build"
 	"go.jetify.com/devbox/internal/envir"
+	"go.jetify.com/devbox/internal/httpclient"
 )
 
 var segmentClient segment.Client
@@ -28,8 +29,9 @@ func initSegmentClient() bool {
 
 	var err error
 	segmentClient, err = segment.NewWithConfig(build.TelemetryKey, segment.Config{
-		Logger:  segment.StdLogger(log.New(io.Discard, "", 0)),
-		Verbose: false,
+		Logger:    segment.StdLogger(log.New(io.Discard, "", 0)),
+		Transport: httpclient.Transport(),
+		Verbose:   false,
 	})
 	return err == nil
 }
@@ -18,6 +18,7 @@
 	"github.com/pkg/errors"
 
 	"go.jetify.com/devbox/internal/build"
+	"go.jetify.com/devbox/internal/httpclient"
 )
 
 var ExecutionID = newEventID()
@@ -41,6 +42,7 @@ func initSentryClient(appName string) bool {
 		Environment:      environment,
 		Release:          appName + "@" + build.Version,
 		Transport:        transport,
+		HTTPTransport:    httpclient.Transport(),
 		TracesSampleRate: 1,
 		BeforeSend: func(event *sentry.Event, _ *sentry.EventHint) *sentry.Event {
 			// redact the hostname, which the SDK automatically adds
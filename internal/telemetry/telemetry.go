@@ -32,7 +32,7 @@
 	"go.jetify.com/devbox/internal/build"
 	"go.jetify.com/devbox/internal/envir"
 	"go.jetify.com/devbox/internal/redact"
-	"go.jetify.com/devbox/internal/xdg"
+	"go.jetify.com/devbox/internal/state"
 )
 
 const appName = "devbox"
@@ -61,6 +61,9 @@ func Start() {
 	if started || envir.DoNotTrack() || build.SentryDSN == "" || build.TelemetryKey == "" {
 		return
 	}
+	if enabled, decided := Consent(); decided && !enabled {
+		return
+	}
 
 	const deviceSalt = "64ee464f-9450-4b14-8d9c-014c0012ac1a"
 	deviceID, _ = machineid.ProtectedID(deviceSalt)
@@ -272,8 +275,8 @@ func (m *Metadata) pkgContext() map[string]any {
 }
 
 var (
-	sentryBufferDir  = xdg.StateSubpath(filepath.FromSlash("devbox/sentry"))
-	segmentBufferDir = xdg.StateSubpath(filepath.FromSlash("devbox/segment"))
+	sentryBufferDir  = state.State("sentry")
+	segmentBufferDir = state.State("segment")
 )
 
 func Upload() {
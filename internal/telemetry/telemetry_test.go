@@ -17,3 +17,19 @@ func TestErrorBasic(t *testing.T) {
 
 	Error(fakeErr, meta)
 }
+
+func TestConsent(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	if _, decided := Consent(); decided {
+		t.Fatal("got a decision before the user answered")
+	}
+	for _, want := range []bool{false, true} {
+		if err := SetConsent(want); err != nil {
+			t.Fatal(err)
+		}
+		if enabled, decided := Consent(); !decided || enabled != want {
+			t.Errorf("got consent %t (decided %t), want %t", enabled, decided, want)
+		}
+	}
+}
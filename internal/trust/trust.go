@@ -0,0 +1,161 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+// Package trust records which devbox projects the user trusts to run their
+// init hooks, scripts and services, and whether the devbox shell hook may
+// activate a project's environment when they cd into it.
+package trust
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	"go.jetify.com/devbox/internal/state"
+)
+
+// Decision is whether the user allowed or denied a project.
+type Decision string
+
+const (
+	Allow Decision = "allow"
+	Deny  Decision = "deny"
+)
+
+// store is the file that the decisions are kept in, keyed by the absolute
+// path of each project's directory.
+type store struct {
+	// Projects are the decisions about the devbox shell hook.
+	Projects map[string]Decision `json:"projects"`
+	// Trusted are the hashes of the configs that the user trusted.
+	Trusted map[string]string `json:"trusted,omitempty"`
+}
+
+func storePath() string {
+	return state.Config("trusted-projects.json")
+}
+
+// Lookup returns the user's decision for the project in projectDir, or "" if
+// they haven't decided.
+func Lookup(projectDir string) (Decision, error) {
+	s, err := load()
+	if err != nil {
+		return "", err
+	}
+	return s.Projects[filepath.Clean(projectDir)], nil
+}
+
+// Set records the user's decision for the project in projectDir.
+func Set(projectDir string, decision Decision) error {
+	s, err := load()
+	if err != nil {
+		return err
+	}
+	s.Projects[filepath.Clean(projectDir)] = decision
+	return s.save()
+}
+
+// Forget removes the user's decision for the project in projectDir, so that
+// they're asked again.
+func Forget(projectDir string) error {
+	s, err := load()
+	if err != nil {
+		return err
+	}
+	delete(s.Projects, filepath.Clean(projectDir))
+	return s.save()
+}
+
+// Projects returns the user's decisions, keyed by the directory of each
+// project.
+func Projects() (map[string]Decision, error) {
+	s, err := load()
+	if err != nil {
+		return nil, err
+	}
+	return s.Projects, nil
+}
+
+// IsTrusted reports whether the user trusts the project in projectDir with the
+// config whose hash is configHash. A project that the user trusted is no longer
+// trusted once its config changes.
+func IsTrusted(projectDir, configHash string) (bool, error) {
+	s, err := load()
+	if err != nil {
+		return false, err
+	}
+	hash, ok := s.Trusted[filepath.Clean(projectDir)]
+	return ok && hash == configHash, nil
+}
+
+// HasTrusted reports whether the user trusted the project in projectDir with
+// any config.
+func HasTrusted(projectDir string) bool {
+	s, err := load()
+	if err != nil {
+		return false
+	}
+	_, ok := s.Trusted[filepath.Clean(projectDir)]
+	return ok
+}
+
+// SetTrusted records that the user trusts the project in projectDir with the
+// config whose hash is configHash.
+func SetTrusted(projectDir, configHash string) error {
+	s, err := load()
+	if err != nil {
+		return err
+	}
+	s.Trusted[filepath.Clean(projectDir)] = configHash
+	return s.save()
+}
+
+// Untrust removes the trust of the project in projectDir.
+func Untrust(projectDir string) error {
+	s, err := load()
+	if err != nil {
+		return err
+	}
+	delete(s.Trusted, filepath.Clean(projectDir))
+	return s.save()
+}
+
+func load() (*store, error) {
+	s := &store{}
+	data, err := os.ReadFile(storePath())
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, errors.WithStack(err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, s); err != nil {
+			return nil, errors.Wrapf(err, "reading %s", storePath())
+		}
+	}
+	if s.Projects == nil {
+		s.Projects = map[string]Decision{}
+	}
+	if s.Trusted == nil {
+		s.Trusted = map[string]string{}
+	}
+	return s, nil
+}
+
+func (s *store) save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	path := storePath()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return errors.WithStack(err)
+	}
+	// Write to a temporary file first, so that shells that read the file
+	// while it's written don't see it half-written.
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(os.Rename(tmp, path))
+}
@@ -0,0 +1,64 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package trust
+
+import "testing"
+
+func TestDecisions(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	if decision, err := Lookup("/src/app"); err != nil || decision != "" {
+		t.Fatalf("got decision %q, err %v before the user decided", decision, err)
+	}
+	if err := Set("/src/app/", Allow); err != nil {
+		t.Fatal(err)
+	}
+	if err := Set("/src/other", Deny); err != nil {
+		t.Fatal(err)
+	}
+	if decision, _ := Lookup("/src/app"); decision != Allow {
+		t.Errorf("got decision %q for /src/app, want %q", decision, Allow)
+	}
+	if decision, _ := Lookup("/src/other"); decision != Deny {
+		t.Errorf("got decision %q for /src/other, want %q", decision, Deny)
+	}
+
+	if err := Forget("/src/app"); err != nil {
+		t.Fatal(err)
+	}
+	decisions, err := Projects()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decisions) != 1 || decisions["/src/other"] != Deny {
+		t.Errorf("got decisions %v, want only /src/other denied", decisions)
+	}
+}
+
+func TestTrusted(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	if trusted, err := IsTrusted("/src/app", "hash1"); err != nil || trusted {
+		t.Fatalf("got trusted %t, err %v before the user trusted the project", trusted, err)
+	}
+	if err := SetTrusted("/src/app", "hash1"); err != nil {
+		t.Fatal(err)
+	}
+	if trusted, _ := IsTrusted("/src/app/", "hash1"); !trusted {
+		t.Error("got an untrusted project after the user trusted it")
+	}
+	if trusted, _ := IsTrusted("/src/app", "hash2"); trusted {
+		t.Error("got a trusted project after its config changed")
+	}
+	if !HasTrusted("/src/app") {
+		t.Error("got no trust of a project that the user trusted with another config")
+	}
+
+	if err := Untrust("/src/app"); err != nil {
+		t.Fatal(err)
+	}
+	if trusted, _ := IsTrusted("/src/app", "hash1"); trusted {
+		t.Error("got a trusted project after the user untrusted it")
+	}
+}
@@ -0,0 +1,201 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+// Package uninstall removes what devbox created outside of projects, so that
+// devbox can be removed from a machine without leaving files behind.
+package uninstall
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+	"go.jetify.com/devbox/internal/nix"
+	"go.jetify.com/devbox/internal/shellrc"
+	"go.jetify.com/devbox/internal/state"
+	nixinstall "go.jetify.com/devbox/nix"
+)
+
+// gcrootsDir is where nix keeps the garbage collector roots of profiles that
+// aren't in /nix/var/nix/profiles, such as devbox's.
+const gcrootsDir = "/nix/var/nix/gcroots/auto"
+
+// shellHookRegexp matches the lines of shell rcfiles that load devbox.
+var shellHookRegexp = regexp.MustCompile(`\bdevbox\s+(global\s+shellenv|completion)\b`)
+
+// Opts are the options of an uninstall.
+type Opts struct {
+	// Nix also uninstalls nix if devbox installed it.
+	Nix bool
+}
+
+// Step is one part of an uninstall.
+type Step struct {
+	Description string
+	Paths       []string
+
+	run func(ctx context.Context) error
+}
+
+// Run runs the step.
+func (s Step) Run(ctx context.Context) error {
+	return s.run(ctx)
+}
+
+// Plan returns the steps that remove devbox from the machine. It doesn't
+// change anything, so that the caller can ask the user to confirm them first.
+func Plan(opts Opts) ([]Step, error) {
+	steps := []Step{}
+	for _, rcfile := range shellrc.Files() {
+		if shellrc.Contains(rcfile, shellHookRegexp) {
+			steps = append(steps, Step{
+				Description: "remove the lines that load devbox from " + rcfile,
+				Paths:       []string{rcfile},
+				run: func(context.Context) error {
+					return removeShellHooks(rcfile)
+				},
+			})
+		}
+	}
+
+	roots := devboxGCRoots(gcrootsDir)
+	if len(roots) > 0 {
+		steps = append(steps, Step{
+			Description: "remove the nix garbage collector roots of devbox profiles",
+			Paths:       roots,
+			run: func(ctx context.Context) error {
+				return removePaths(ctx, roots)
+			},
+		})
+	}
+
+	// The global profile is in the data directory, so it's removed with
+	// the rest of devbox's files.
+	dirs := []string{}
+	for _, dir := range []string{state.Cache(), state.Data(), state.State()} {
+		if _, err := os.Lstat(dir); err == nil {
+			dirs = append(dirs, dir)
+		}
+	}
+	if len(dirs) > 0 {
+		steps = append(steps, Step{
+			Description: "delete devbox global, caches, installed tools and state",
+			Paths:       dirs,
+			run: func(context.Context) error {
+				for _, dir := range dirs {
+					if err := os.RemoveAll(dir); err != nil {
+						return errors.WithStack(err)
+					}
+				}
+				return nil
+			},
+		})
+	}
+
+	if opts.Nix {
+		if !nix.InstalledByDevbox() {
+			return nil, usererr.New(
+				"Nix wasn't installed by devbox, so devbox won't uninstall it. " +
+					"Uninstall it the way it was installed, or run devbox uninstall without --nix.")
+		}
+		steps = append(steps, Step{
+			Description: "uninstall nix and delete the nix store",
+			Paths:       []string{"/nix"},
+			run:         nixinstall.Uninstall,
+		})
+	}
+	return steps, nil
+}
+
+// removeShellHooks removes the lines that load devbox from an rcfile and
+// keeps the rest of it as is.
+func removeShellHooks(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	out := &bytes.Buffer{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if !shellHookRegexp.MatchString(scanner.Text()) {
+			out.WriteString(scanner.Text() + "\n")
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return errors.WithStack(err)
+	}
+	if !bytes.HasSuffix(data, []byte("\n")) {
+		out.Truncate(max(out.Len()-1, 0))
+	}
+	return errors.WithStack(os.WriteFile(path, out.Bytes(), info.Mode().Perm()))
+}
+
+// devboxGCRoots returns the garbage collector roots in dir that point to the
+// nix profiles of devbox projects or of devbox global.
+func devboxGCRoots(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	roots := []string{}
+	for _, entry := range entries {
+		root := filepath.Join(dir, entry.Name())
+		target, err := os.Readlink(root)
+		if err != nil {
+			continue
+		}
+		if isDevboxProfile(target) {
+			roots = append(roots, root)
+		}
+	}
+	return roots
+}
+
+func isDevboxProfile(path string) bool {
+	sep := string(filepath.Separator)
+	if strings.Contains(path, sep+".devbox"+sep+"nix"+sep+"profile"+sep) {
+		return true
+	}
+	return strings.HasPrefix(path, state.Data()+sep)
+}
+
+// removePaths removes paths, and runs rm with sudo for the ones that the user
+// can't remove, which is the case for garbage collector roots of multi-user
+// nix installs.
+func removePaths(ctx context.Context, paths []string) error {
+	denied := []string{}
+	for _, path := range paths {
+		err := os.Remove(path)
+		if errors.Is(err, os.ErrPermission) {
+			denied = append(denied, path)
+		} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return errors.WithStack(err)
+		}
+	}
+	if len(denied) == 0 {
+		return nil
+	}
+
+	cmd := exec.CommandContext(ctx, "sudo", append([]string{"rm", "-f", "--"}, denied...)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return usererr.WithUserMessage(
+			errors.WithStack(err), "Failed to remove %s", strings.Join(denied, ", "))
+	}
+	return nil
+}
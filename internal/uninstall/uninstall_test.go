@@ -0,0 +1,74 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package uninstall
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestRemoveShellHooks(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{
+			name: "shellenv",
+			in:   "export EDITOR=vim\neval \"$(devbox global shellenv)\"\nalias ll='ls -l'\n",
+			want: "export EDITOR=vim\nalias ll='ls -l'\n",
+		},
+		{
+			name: "fish",
+			in:   "devbox global shellenv --init-hook | source\ndevbox completion fish | source\nset -x EDITOR vim",
+			want: "set -x EDITOR vim",
+		},
+		{
+			name: "other devbox commands",
+			in:   "alias dbs='devbox shell'\n",
+			want: "alias dbs='devbox shell'\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), ".rc")
+			if err := os.WriteFile(path, []byte(test.in), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if err := removeShellHooks(path); err != nil {
+				t.Fatal(err)
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+			if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+				t.Errorf("got mode %v, %v, want it kept", info.Mode().Perm(), err)
+			}
+		})
+	}
+}
+
+func TestDevboxGCRoots(t *testing.T) {
+	dir := t.TempDir()
+	links := map[string]string{
+		"project": "/home/user/src/app/.devbox/nix/profile/default-3-link",
+		"home":    "/home/user/.config/home-manager/result",
+		"direnv":  "/home/user/src/app/.direnv/flake-profile-1-link",
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(dir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := devboxGCRoots(dir)
+	want := []string{filepath.Join(dir, "project")}
+	if !slices.Equal(got, want) {
+		t.Errorf("devboxGCRoots() = %v, want %v", got, want)
+	}
+}
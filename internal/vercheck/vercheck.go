@@ -10,7 +10,6 @@
 	"io/fs"
 	"os"
 	"os/exec"
-	"path/filepath"
 	"strings"
 
 	"github.com/fatih/color"
@@ -22,8 +21,8 @@
 	"go.jetify.com/devbox/internal/build"
 	"go.jetify.com/devbox/internal/cmdutil"
 	"go.jetify.com/devbox/internal/envir"
+	"go.jetify.com/devbox/internal/state"
 	"go.jetify.com/devbox/internal/ux"
-	"go.jetify.com/devbox/internal/xdg"
 )
 
 // Keep this in-sync with latest version in launch.sh.
@@ -273,7 +272,7 @@ func removeCurrentVersionFile() error {
 	// If the version is newer, then the launcher updates.
 	//
 	// Note: keep this in sync with launch.sh code
-	currentVersionFilePath := filepath.Join(xdg.CacheSubpath("devbox"), "current-version")
+	currentVersionFilePath := state.Cache("current-version")
 
 	if err := os.Remove(currentVersionFilePath); err != nil && !errors.Is(err, fs.ErrNotExist) {
 		return usererr.WithLoggedUserMessage(
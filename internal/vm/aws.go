@@ -0,0 +1,220 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package vm
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+	"go.jetify.com/devbox/internal/httpclient"
+	"go.jetify.com/devbox/internal/ux"
+)
+
+const (
+	awsDefaultSize = "t3.large"
+
+	// awsImage is the latest Ubuntu 24.04 LTS image in the region.
+	awsImage = "resolve:ssm:/aws/service/canonical/ubuntu/server/24.04/stable/current/amd64/hvm/ebs-gp3/ami-id"
+
+	// awsSecurityGroup is the security group that lets SSH in to the
+	// machines. It's created the first time and shared between them.
+	awsSecurityGroup = "devbox-vm"
+
+	// anywhereCIDR allows SSH from any IPv4 address. It's only used with
+	// CreateOpts.SSHFromAnywhere.
+	anywhereCIDR = "0.0.0.0/0"
+)
+
+// awsCheckIPURL responds with the public IP address that requests come from.
+var awsCheckIPURL = "https://checkip.amazonaws.com"
+
+// aws creates EC2 instances with the aws CLI.
+type aws struct{}
+
+func (*aws) Create(ctx context.Context, opts CreateOpts) (*Machine, error) {
+	region := regionArgs("--region", opts.Region)
+	cidr := anywhereCIDR
+	if !opts.SSHFromAnywhere {
+		ip, err := publicIP(ctx)
+		if err != nil {
+			return nil, usererr.WithUserMessage(err,
+				"Unable to find this machine's public IP address to allow SSH from it. "+
+					"Use --ssh-from-anywhere to allow SSH to the VM from any address instead.")
+		}
+		cidr = ip + "/32"
+	}
+	securityGroup, err := awsEnsureSecurityGroup(ctx, opts.Stderr, region, cidr)
+	if err != nil {
+		return nil, err
+	}
+
+	size := opts.Size
+	if size == "" {
+		size = awsDefaultSize
+	}
+	id, err := runCLI(ctx, opts.Stderr, "aws", append([]string{
+		"ec2", "run-instances",
+		"--image-id", awsImage,
+		"--instance-type", size,
+		"--security-group-ids", securityGroup,
+		"--user-data", cloudConfig(opts.PublicKey),
+		"--block-device-mappings", "DeviceName=/dev/sda1,Ebs={VolumeSize=50}",
+		"--tag-specifications", "ResourceType=instance,Tags=[{Key=Name,Value=" + opts.Name + "},{Key=devbox,Value=true}]",
+		"--query", "Instances[0].InstanceId",
+		"--output", "text",
+	}, region...)...)
+	if err != nil {
+		return nil, err
+	}
+	m := &Machine{Name: opts.Name, Provider: "aws", ID: string(id), Region: opts.Region, User: "ubuntu"}
+
+	_, err = runCLI(ctx, opts.Stderr, "aws",
+		append([]string{"ec2", "wait", "instance-running", "--instance-ids", m.ID}, region...)...)
+	if err != nil {
+		return m, err
+	}
+	host, err := runCLI(ctx, opts.Stderr, "aws", append([]string{
+		"ec2", "describe-instances",
+		"--instance-ids", m.ID,
+		"--query", "Reservations[0].Instances[0].PublicIpAddress",
+		"--output", "text",
+	}, region...)...)
+	if err != nil {
+		return m, err
+	}
+	m.Host, err = awsPublicIP(m.ID, host)
+	return m, err
+}
+
+// awsPublicIP returns the public IP address that aws ec2 describe-instances
+// printed. The CLI prints None if the instance doesn't have one, which
+// happens when its subnet doesn't assign public addresses.
+func awsPublicIP(id string, out []byte) (string, error) {
+	host := strings.TrimSpace(string(out))
+	if host == "" || host == "None" {
+		return "", usererr.New(
+			"The instance %s doesn't have a public IP address, so Devbox can't SSH into it. "+
+				"Turn on auto-assign public IPv4 addresses for the default VPC's subnets, "+
+				"or use a region where it's on.", id)
+	}
+	return host, nil
+}
+
+func (*aws) Destroy(ctx context.Context, m *Machine, stderr io.Writer) error {
+	_, err := runCLI(ctx, stderr, "aws", append([]string{
+		"ec2", "terminate-instances", "--instance-ids", m.ID,
+	}, regionArgs("--region", m.Region)...)...)
+	return err
+}
+
+// awsEnsureSecurityGroup returns the ID of the security group that allows SSH
+// from cidr, and creates it in the default VPC if it doesn't exist. The group
+// is shared by the VMs, so each new VM adds the address it's created from.
+func awsEnsureSecurityGroup(ctx context.Context, stderr io.Writer, region []string, cidr string) (string, error) {
+	id, err := runCLI(ctx, io.Discard, "aws", append([]string{
+		"ec2", "describe-security-groups",
+		"--group-names", awsSecurityGroup,
+		"--query", "SecurityGroups[0].GroupId",
+		"--output", "text",
+	}, region...)...)
+	if err == nil {
+		return string(id), awsAllowSSH(ctx, stderr, region, string(id), cidr)
+	}
+
+	id, err = runCLI(ctx, stderr, "aws", append([]string{
+		"ec2", "create-security-group",
+		"--group-name", awsSecurityGroup,
+		"--description", "SSH access to Devbox VMs",
+		"--query", "GroupId",
+		"--output", "text",
+	}, region...)...)
+	if err != nil {
+		return "", err
+	}
+	return string(id), awsAllowSSH(ctx, stderr, region, string(id), cidr)
+}
+
+// awsAllowSSH adds a rule that allows SSH from cidr to the security group,
+// unless it already has one.
+func awsAllowSSH(ctx context.Context, stderr io.Writer, region []string, groupID, cidr string) error {
+	out, err := runCLI(ctx, io.Discard, "aws", append([]string{
+		"ec2", "describe-security-groups",
+		"--group-ids", groupID,
+		"--query", "SecurityGroups[0].IpPermissions[?FromPort==`22`].IpRanges[].CidrIp",
+		"--output", "text",
+	}, region...)...)
+	if err != nil {
+		return err
+	}
+	allowed := strings.Fields(string(out))
+	if cidr != anywhereCIDR && slices.Contains(allowed, anywhereCIDR) {
+		ux.Fwarningf(stderr, "The %s security group allows SSH from any address. Remove its %s rule "+
+			"to only allow SSH from the addresses that VMs were created from.\n", awsSecurityGroup, anywhereCIDR)
+	}
+	if slices.Contains(allowed, cidr) {
+		return nil
+	}
+	_, err = runCLI(ctx, stderr, "aws", append([]string{
+		"ec2", "authorize-security-group-ingress",
+		"--group-id", groupID,
+		"--protocol", "tcp",
+		"--port", "22",
+		"--cidr", cidr,
+		"--output", "text",
+	}, region...)...)
+	return err
+}
+
+// publicIP returns the public IPv4 address that this machine's requests come
+// from.
+func publicIP(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, awsCheckIPURL, nil)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	res, err := httpclient.Client().Do(req)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return "", errors.Errorf("GET %s: %s", awsCheckIPURL, res.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(res.Body, 64))
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return parsePublicIP(body)
+}
+
+// parsePublicIP parses the response of awsCheckIPURL.
+func parsePublicIP(body []byte) (string, error) {
+	ip := net.ParseIP(strings.TrimSpace(string(body)))
+	if ip == nil || ip.To4() == nil {
+		return "", errors.Errorf("%s responded with %q, which isn't an IPv4 address", awsCheckIPURL, body)
+	}
+	return ip.String(), nil
+}
+
+// cloudConfig returns the cloud-init configuration that lets the public key
+// log in as the image's default user.
+func cloudConfig(publicKey string) string {
+	return "#cloud-config\nssh_authorized_keys:\n  - " + publicKey + "\n"
+}
+
+// regionArgs returns the CLI flag that selects a region, or nothing if region
+// is empty so that the CLI uses its default.
+func regionArgs(flag, region string) []string {
+	if region == "" {
+		return []string{}
+	}
+	return []string{flag, region}
+}
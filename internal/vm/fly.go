@@ -0,0 +1,54 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package vm
+
+import (
+	"context"
+	"io"
+)
+
+const flyDefaultSize = "shared-cpu-2x"
+
+// flyBootScript installs an SSH server in the machine when it boots, and lets
+// the public key in the PUBLIC_KEY environment variable log in as root.
+const flyBootScript = `set -e
+apt-get update
+apt-get install -y --no-install-recommends openssh-server ca-certificates curl xz-utils sudo git rsync
+mkdir -p /run/sshd /root/.ssh
+echo "$PUBLIC_KEY" > /root/.ssh/authorized_keys
+exec /usr/sbin/sshd -D -e`
+
+// fly creates Fly Machines with the fly CLI. Each machine gets its own app,
+// which is deleted with it.
+type fly struct{}
+
+func (*fly) Create(ctx context.Context, opts CreateOpts) (*Machine, error) {
+	if _, err := runCLI(ctx, opts.Stderr, "fly", "apps", "create", opts.Name); err != nil {
+		return nil, err
+	}
+	m := &Machine{Name: opts.Name, Provider: "fly", Region: opts.Region, Host: opts.Name + ".fly.dev", User: "root"}
+
+	size := opts.Size
+	if size == "" {
+		size = flyDefaultSize
+	}
+	_, err := runCLI(ctx, opts.Stderr, "fly", append([]string{
+		"machine", "run", "ubuntu:24.04",
+		"--app", opts.Name,
+		"--vm-size", size,
+		"--port", "22/tcp",
+		"--env", "PUBLIC_KEY=" + opts.PublicKey,
+		"--entrypoint", "/bin/sh",
+	}, append(regionArgs("--region", opts.Region), "--", "-c", flyBootScript)...)...)
+	if err != nil {
+		return m, err
+	}
+	_, err = runCLI(ctx, opts.Stderr, "fly", "ips", "allocate-v6", "--app", opts.Name)
+	return m, err
+}
+
+func (*fly) Destroy(ctx context.Context, m *Machine, stderr io.Writer) error {
+	_, err := runCLI(ctx, stderr, "fly", "apps", "destroy", m.Name, "--yes")
+	return err
+}
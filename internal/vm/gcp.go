@@ -0,0 +1,75 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package vm
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"path"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	gcpDefaultSize = "e2-standard-2"
+
+	// gcpUser is the user that the SSH key is added for.
+	gcpUser = "devbox"
+)
+
+// gcp creates Compute Engine instances with the gcloud CLI.
+type gcp struct{}
+
+func (*gcp) Create(ctx context.Context, opts CreateOpts) (*Machine, error) {
+	size := opts.Size
+	if size == "" {
+		size = gcpDefaultSize
+	}
+	out, err := runCLI(ctx, opts.Stderr, "gcloud", append([]string{
+		"compute", "instances", "create", opts.Name,
+		"--machine-type", size,
+		"--image-family", "ubuntu-2404-lts-amd64",
+		"--image-project", "ubuntu-os-cloud",
+		"--boot-disk-size", "50GB",
+		"--metadata", "ssh-keys=" + gcpUser + ":" + opts.PublicKey,
+		"--labels", "devbox=true",
+		"--format", "json",
+	}, regionArgs("--zone", opts.Region)...)...)
+	if err != nil {
+		return nil, err
+	}
+	m := &Machine{Name: opts.Name, Provider: "gcp", Region: opts.Region, User: gcpUser}
+
+	m.Host, m.Region, err = parseGCPInstance(out)
+	return m, err
+}
+
+func (*gcp) Destroy(ctx context.Context, m *Machine, stderr io.Writer) error {
+	_, err := runCLI(ctx, stderr, "gcloud", append([]string{
+		"compute", "instances", "delete", m.Name, "--quiet",
+	}, regionArgs("--zone", m.Region)...)...)
+	return err
+}
+
+// parseGCPInstance returns the external IP address and the zone of the
+// instance that gcloud compute instances create printed.
+func parseGCPInstance(out []byte) (host, zone string, err error) {
+	instances := []struct {
+		Zone              string `json:"zone"`
+		NetworkInterfaces []struct {
+			AccessConfigs []struct {
+				NatIP string `json:"natIP"`
+			} `json:"accessConfigs"`
+		} `json:"networkInterfaces"`
+	}{}
+	if err := json.Unmarshal(out, &instances); err != nil {
+		return "", "", errors.Wrap(err, "failed to parse the output of gcloud")
+	}
+	if len(instances) == 0 || len(instances[0].NetworkInterfaces) == 0 ||
+		len(instances[0].NetworkInterfaces[0].AccessConfigs) == 0 {
+		return "", "", errors.New("the instance doesn't have an external IP address")
+	}
+	return instances[0].NetworkInterfaces[0].AccessConfigs[0].NatIP, path.Base(instances[0].Zone), nil
+}
@@ -0,0 +1,54 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package vm
+
+import (
+	"context"
+	"io"
+	"os/exec"
+)
+
+// StartSync starts a mutagen session that keeps the project in projectDir in
+// sync with the machine, so that changes on either side show up on the other
+// one. It returns an empty session name if mutagen isn't installed.
+func StartSync(ctx context.Context, stderr io.Writer, m *Machine, projectDir string) (string, error) {
+	if _, err := exec.LookPath("mutagen"); err != nil {
+		return "", nil
+	}
+	session := m.Name
+	_, err := runCLI(ctx, stderr, "mutagen", "sync", "create",
+		"--name", session,
+		"--sync-mode", "two-way-resolved",
+		"--ignore-vcs",
+		"--ignore", "/.devbox",
+		projectDir,
+		mutagenURL(m),
+	)
+	if err != nil {
+		return "", err
+	}
+	return session, nil
+}
+
+// StopSync stops the machine's mutagen session, if it has one.
+func StopSync(ctx context.Context, stderr io.Writer, m *Machine) error {
+	if m.SyncSession == "" {
+		return nil
+	}
+	_, err := runCLI(ctx, stderr, "mutagen", "sync", "terminate", m.SyncSession)
+	return err
+}
+
+// mutagenURL returns the mutagen URL of the project's directory on the machine,
+// such as user@host:22:path.
+func mutagenURL(m *Machine) string {
+	url := m.Host + ":"
+	if m.User != "" {
+		url = m.User + "@" + url
+	}
+	if m.Port != "" {
+		url += m.Port + ":"
+	}
+	return url + m.Dir
+}
@@ -0,0 +1,205 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+// Package vm creates virtual machines in the user's own cloud account to run
+// a project on. It uses the cloud provider's CLI, so it works with the
+// credentials that the user is already logged in with.
+package vm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"go.jetify.com/devbox/internal/boxcli/usererr"
+	"go.jetify.com/devbox/internal/remote"
+)
+
+// Machine is a virtual machine that Devbox created.
+type Machine struct {
+	// Name is the machine's name in the cloud provider.
+	Name string `json:"name"`
+
+	// Provider is the name of the cloud provider, such as aws.
+	Provider string `json:"provider"`
+
+	// ID identifies the machine in the cloud provider, if it's different
+	// from the name.
+	ID string `json:"id,omitempty"`
+
+	// Region is the region or zone that the machine runs in. It's empty if
+	// the machine runs in the provider's default region.
+	Region string `json:"region,omitempty"`
+
+	// Host, User and Port are how to SSH into the machine.
+	Host string `json:"host"`
+	User string `json:"user"`
+	Port string `json:"port,omitempty"`
+
+	// Dir is the project's directory on the machine.
+	Dir string `json:"dir,omitempty"`
+
+	// SyncSession is the name of the mutagen session that syncs the project
+	// to the machine, if there is one.
+	SyncSession string `json:"sync_session,omitempty"`
+}
+
+// Target returns the SSH target of the machine.
+func (m *Machine) Target() *remote.Target {
+	return &remote.Target{
+		User:             m.User,
+		Host:             m.Host,
+		Port:             m.Port,
+		Dir:              m.Dir,
+		AcceptNewHostKey: true,
+		InstallDevbox:    true,
+	}
+}
+
+// CreateOpts configures a new machine.
+type CreateOpts struct {
+	Name string
+
+	// Region is the region or zone to create the machine in. The provider's
+	// default is used if it's empty.
+	Region string
+
+	// Size is the provider's machine type, such as t3.large. Each provider
+	// has a default.
+	Size string
+
+	// PublicKey is the SSH public key that can log in to the machine.
+	PublicKey string
+
+	// SSHFromAnywhere allows SSH to the machine from any address. By
+	// default, providers that manage the firewall only allow SSH from the
+	// public IP address of the machine that creates it.
+	SSHFromAnywhere bool
+
+	// Stderr is where the provider's CLI writes its progress.
+	Stderr io.Writer
+}
+
+// Provider creates and deletes machines in a cloud.
+type Provider interface {
+	// Create creates a machine that PublicKey can SSH into and waits
+	// until it has an address.
+	Create(ctx context.Context, opts CreateOpts) (*Machine, error)
+
+	// Destroy deletes a machine and the resources that were created for it.
+	Destroy(ctx context.Context, m *Machine, stderr io.Writer) error
+}
+
+var providers = map[string]Provider{
+	"aws": &aws{},
+	"gcp": &gcp{},
+	"fly": &fly{},
+}
+
+// Providers returns the names of the supported cloud providers.
+func Providers() []string {
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// NewProvider returns the provider with the given name.
+func NewProvider(name string) (Provider, error) {
+	provider, ok := providers[name]
+	if !ok {
+		return nil, usererr.New(
+			"Unknown provider %q. The supported providers are %s.", name, strings.Join(Providers(), ", "))
+	}
+	return provider, nil
+}
+
+// nameRegexp matches the characters that machine names can't have.
+var nameRegexp = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// MachineName returns a name for a project's machine that's valid in all
+// providers: lowercase letters, digits and dashes, starting with a letter.
+func MachineName(projectDir, suffix string) string {
+	name := nameRegexp.ReplaceAllString(strings.ToLower(filepath.Base(projectDir)), "-")
+	name = strings.Trim(name, "-")
+	if len(name) > 30 {
+		name = strings.TrimRight(name[:30], "-")
+	}
+	if name == "" {
+		return "devbox-" + suffix
+	}
+	return "devbox-" + name + "-" + suffix
+}
+
+// PublicKey returns the user's default SSH public key.
+func PublicKey() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	for _, name := range []string{"id_ed25519.pub", "id_ecdsa.pub", "id_rsa.pub"} {
+		key, err := os.ReadFile(filepath.Join(home, ".ssh", name))
+		if err == nil {
+			return strings.TrimSpace(string(key)), nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return "", errors.WithStack(err)
+		}
+	}
+	return "", usererr.New("You don't have an SSH key to log in to the VM with. Create one with `ssh-keygen -t ed25519`.")
+}
+
+// Load reads the machine saved in path. It returns nil if there's no machine.
+func Load(path string) (*Machine, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	m := &Machine{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, errors.Wrapf(err, "failed to read %s", path)
+	}
+	return m, nil
+}
+
+// Save writes the machine to path.
+func (m *Machine) Save(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(os.WriteFile(path, append(data, '\n'), 0o644))
+}
+
+// runCLI runs a cloud provider's CLI and returns its output. The CLI's errors
+// and progress go to stderr.
+func runCLI(ctx context.Context, stderr io.Writer, name string, args ...string) ([]byte, error) {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return nil, usererr.New("Creating VMs with this provider needs the %s CLI. Install it and log in first.", name)
+	}
+	var stdout bytes.Buffer
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return nil, errors.Wrapf(err, "%s %s failed", name, strings.Join(args[:min(len(args), 2)], " "))
+	}
+	return bytes.TrimSpace(stdout.Bytes()), nil
+}
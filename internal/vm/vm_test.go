@@ -0,0 +1,111 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package vm
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestMachineName(t *testing.T) {
+	tests := []struct {
+		projectDir string
+		want       string
+	}{
+		{"/home/user/my-app", "devbox-my-app-abc123"},
+		{"/home/user/My_App.v2", "devbox-my-app-v2-abc123"},
+		{"/home/user/a-very-long-project-name-that-goes-on-and-on", "devbox-a-very-long-project-name-that-abc123"},
+		{"/home/user/__", "devbox-abc123"},
+	}
+	for _, test := range tests {
+		if got := MachineName(test.projectDir, "abc123"); got != test.want {
+			t.Errorf("got name %q for %s, want %q", got, test.projectDir, test.want)
+		}
+	}
+}
+
+func TestNewProvider(t *testing.T) {
+	for _, name := range Providers() {
+		if _, err := NewProvider(name); err != nil {
+			t.Errorf("got error for provider %s: %v", name, err)
+		}
+	}
+	if _, err := NewProvider("azure"); err == nil {
+		t.Error("got no error for an unknown provider")
+	}
+}
+
+func TestParseGCPInstance(t *testing.T) {
+	out := []byte(`[{
+		"name": "devbox-app-abc123",
+		"zone": "https://www.googleapis.com/compute/v1/projects/p/zones/us-central1-a",
+		"networkInterfaces": [{"accessConfigs": [{"natIP": "203.0.113.7"}]}]
+	}]`)
+	host, zone, err := parseGCPInstance(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if host != "203.0.113.7" || zone != "us-central1-a" {
+		t.Errorf("got host %q and zone %q, want 203.0.113.7 and us-central1-a", host, zone)
+	}
+
+	if _, _, err := parseGCPInstance([]byte(`[{"networkInterfaces": []}]`)); err == nil {
+		t.Error("got no error for an instance without an IP address")
+	}
+}
+
+func TestMutagenURL(t *testing.T) {
+	m := &Machine{Host: "203.0.113.7", User: "ubuntu", Dir: ".cache/devbox/remote/app"}
+	if got, want := mutagenURL(m), "ubuntu@203.0.113.7:.cache/devbox/remote/app"; got != want {
+		t.Errorf("got URL %q, want %q", got, want)
+	}
+	m.Port = "2222"
+	if got, want := mutagenURL(m), "ubuntu@203.0.113.7:2222:.cache/devbox/remote/app"; got != want {
+		t.Errorf("got URL %q, want %q", got, want)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".devbox", "vm.json")
+	m, err := Load(path)
+	if err != nil || m != nil {
+		t.Fatalf("got machine %v and error %v before saving, want neither", m, err)
+	}
+
+	want := Machine{Name: "devbox-app-abc123", Provider: "aws", ID: "i-0123", Host: "203.0.113.7", User: "ubuntu"}
+	if err := want.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	m, err = Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *m != want {
+		t.Errorf("got machine %+v, want %+v", *m, want)
+	}
+}
+
+func TestAWSPublicIP(t *testing.T) {
+	host, err := awsPublicIP("i-0123", []byte("203.0.113.7\n"))
+	if err != nil || host != "203.0.113.7" {
+		t.Errorf("got host %q and error %v, want 203.0.113.7", host, err)
+	}
+	for _, out := range []string{"None", ""} {
+		if _, err := awsPublicIP("i-0123", []byte(out)); err == nil {
+			t.Errorf("got no error for describe-instances output %q", out)
+		}
+	}
+}
+
+func TestParsePublicIP(t *testing.T) {
+	ip, err := parsePublicIP([]byte("198.51.100.4\n"))
+	if err != nil || ip != "198.51.100.4" {
+		t.Errorf("got IP %q and error %v, want 198.51.100.4", ip, err)
+	}
+	for _, body := range []string{"", "<html>", "2001:db8::1"} {
+		if _, err := parsePublicIP([]byte(body)); err == nil {
+			t.Errorf("got no error for response %q", body)
+		}
+	}
+}
@@ -9,12 +9,15 @@
 	"log/slog"
 	"os"
 	"os/exec"
+	"os/signal"
 	"runtime"
 	"slices"
 	"strconv"
 	"strings"
 	"syscall"
 	"time"
+
+	"github.com/mattn/go-isatty"
 )
 
 // Cmd is an external command that invokes a [*Nix] executable. It provides
@@ -43,6 +46,10 @@ type Cmd struct {
 	// defaults to [slog.Default].
 	Logger *slog.Logger
 
+	// Timeout is the maximum time that the command may run before it's
+	// interrupted. Zero means no limit.
+	Timeout time.Duration
+
 	execCmd *exec.Cmd
 	err     error
 	dur     time.Duration
@@ -52,8 +59,9 @@ type Cmd struct {
 // Logger and other defaults from n.
 func (n *Nix) Command(args ...any) *Cmd {
 	cmd := &Cmd{
-		Args:   make(Args, 1, 1+len(n.ExtraArgs)+len(args)),
-		Logger: n.logger(),
+		Args:    make(Args, 1, 1+len(n.ExtraArgs)+len(args)),
+		Logger:  n.logger(),
+		Timeout: n.Timeout,
 	}
 	cmd.Path, cmd.err = n.resolvePath()
 
@@ -68,6 +76,8 @@ func (n *Nix) Command(args ...any) *Cmd {
 }
 
 func (c *Cmd) CombinedOutput(ctx context.Context) ([]byte, error) {
+	ctx, cancel := c.runContext(ctx)
+	defer cancel()
 	defer c.logRunFunc(ctx)()
 
 	start := time.Now()
@@ -79,6 +89,8 @@ func (c *Cmd) CombinedOutput(ctx context.Context) ([]byte, error) {
 }
 
 func (c *Cmd) Output(ctx context.Context) ([]byte, error) {
+	ctx, cancel := c.runContext(ctx)
+	defer cancel()
 	defer c.logRunFunc(ctx)()
 
 	start := time.Now()
@@ -90,6 +102,8 @@ func (c *Cmd) Output(ctx context.Context) ([]byte, error) {
 }
 
 func (c *Cmd) Run(ctx context.Context) error {
+	ctx, cancel := c.runContext(ctx)
+	defer cancel()
 	defer c.logRunFunc(ctx)()
 
 	start := time.Now()
@@ -100,6 +114,57 @@ func (c *Cmd) Run(ctx context.Context) error {
 	return c.err
 }
 
+// runContext returns the context that the command runs with. It's canceled
+// when the command times out or when Devbox receives SIGINT or SIGTERM, so
+// that Devbox interrupts Nix and waits for it to exit instead of exiting
+// first and leaving Nix running. The returned function also kills any
+// processes that Nix started and left behind.
+func (c *Cmd) runContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, stopSignals := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	cancelTimeout := context.CancelFunc(func() {})
+	if c.Timeout > 0 {
+		ctx, cancelTimeout = context.WithTimeout(ctx, c.Timeout)
+	}
+	return ctx, func() {
+		if ctx.Err() != nil {
+			c.killProcessGroup()
+		}
+		cancelTimeout()
+		stopSignals()
+	}
+}
+
+// ownProcessGroup reports if Nix runs in its own process group. It does
+// unless it reads from a terminal, because a process that reads from the
+// terminal must be in the terminal's foreground process group.
+func (c *Cmd) ownProcessGroup() bool {
+	f, ok := c.Stdin.(*os.File)
+	return !ok || !isatty.IsTerminal(f.Fd())
+}
+
+// signal sends sig to Nix and, if it runs in its own process group, to the
+// processes that it started.
+func (c *Cmd) signal(sig syscall.Signal) error {
+	proc := c.execCmd.Process
+	if !c.ownProcessGroup() {
+		return proc.Signal(sig)
+	}
+	err := syscall.Kill(-proc.Pid, sig)
+	if errors.Is(err, syscall.ESRCH) {
+		return os.ErrProcessDone
+	}
+	return err
+}
+
+// killProcessGroup kills the processes left in the process group of a Nix
+// command that was interrupted.
+func (c *Cmd) killProcessGroup() {
+	if c.execCmd == nil || c.execCmd.Process == nil || !c.ownProcessGroup() {
+		return
+	}
+	_ = syscall.Kill(-c.execCmd.Process.Pid, syscall.SIGKILL)
+}
+
 func (c *Cmd) LogValue() slog.Value {
 	attrs := []slog.Attr{
 		slog.Any("args", c.Args),
@@ -152,16 +217,24 @@ func (c *Cmd) initExecCommand(ctx context.Context) *exec.Cmd {
 	c.execCmd.Stdin = c.Stdin
 	c.execCmd.Stdout = c.Stdout
 	c.execCmd.Stderr = c.Stderr
+	if c.ownProcessGroup() {
+		// Signals from the terminal, such as Ctrl-C, don't reach Nix
+		// directly. Devbox interrupts the process group when ctx is
+		// canceled instead.
+		c.execCmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	}
 
 	c.execCmd.Cancel = func() error {
 		// Try to let Nix exit gracefully by sending an interrupt
-		// instead of the default behavior of killing it.
+		// instead of the default behavior of killing it. It's sent to
+		// the whole process group so that processes started by Nix,
+		// such as builders, are interrupted too.
 		c.logger().DebugContext(ctx, "sending interrupt to nix process", slog.Group("cmd",
 			"args", c.Args,
 			"path", c.execCmd.Path,
 			"pid", c.execCmd.Process.Pid,
 		))
-		err := c.execCmd.Process.Signal(os.Interrupt)
+		err := c.signal(syscall.SIGINT)
 		if errors.Is(err, os.ErrProcessDone) {
 			// Nix already exited; execCmd.Wait will use the exit
 			// code.
@@ -182,7 +255,7 @@ func (c *Cmd) initExecCommand(ctx context.Context) *exec.Cmd {
 					"path", c.execCmd.Path,
 					"pid", c.execCmd.Process.Pid,
 				))
-			return c.execCmd.Process.Kill()
+			return c.signal(syscall.SIGKILL)
 		}
 
 		// We sent the SIGINT successfully. It's still possible for Nix
@@ -209,6 +282,8 @@ func (c *Cmd) error(ctx context.Context, err error) error {
 	switch {
 	case errors.Is(ctx.Err(), context.Canceled):
 		cmdErr.msg = "nix: command canceled"
+	case errors.Is(ctx.Err(), context.DeadlineExceeded) && c.Timeout > 0:
+		cmdErr.msg = fmt.Sprintf("nix: command timed out after %s", c.Timeout)
 	case errors.Is(ctx.Err(), context.DeadlineExceeded):
 		cmdErr.msg = "nix: command timed out"
 	default:
@@ -0,0 +1,52 @@
+package nix
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCmdTimeout(t *testing.T) {
+	// A fake nix that starts a child process which outlives it unless it's
+	// killed with its process group.
+	dir := t.TempDir()
+	pidFile := filepath.Join(dir, "child.pid")
+	script := "#!/bin/sh\nsleep 60 &\necho $! > " + pidFile + "\nwait\n"
+	path := filepath.Join(dir, "nix")
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	n := &Nix{Path: path, Timeout: 200 * time.Millisecond}
+	cmd := n.Command("build")
+	start := time.Now()
+	err := cmd.Run(context.Background())
+	if err == nil {
+		t.Fatal("got nil error for a command that timed out")
+	}
+	if got, want := err.Error(), "nix: command timed out after 200ms"; !strings.Contains(got, want) {
+		t.Errorf("got error %q, want it to contain %q", got, want)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("command took %s to time out", elapsed)
+	}
+
+	data, err := os.ReadFile(pidFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid := strings.TrimSpace(string(data))
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Stat("/proc/" + pid); os.IsNotExist(err) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("child process %s is still running after nix timed out", pid)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -265,6 +265,9 @@ func parseGitHubRef(refURL *url.URL, parsed *Ref) error {
 	if err != nil {
 		return err
 	}
+	if len(split) < 2 {
+		return redact.Errorf("github flake reference must have an owner and a repo")
+	}
 	parsed.Owner = split[0]
 	parsed.Repo = split[1]
 	if len(split) > 2 {
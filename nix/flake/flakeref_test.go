@@ -182,6 +182,14 @@ func TestParseFlakeRefError(t *testing.T) {
 			}
 		}
 	})
+	t.Run("GitHubMissingRepo", func(t *testing.T) {
+		for _, ref := range []string{"github:", "github:NixOS"} {
+			_, err := ParseRef(ref)
+			if err == nil {
+				t.Error("got nil error for bad flakeref:", ref)
+			}
+		}
+	})
 	t.Run("GitHubInvalidRefRevCombo", func(t *testing.T) {
 		in := []string{
 			"github:NixOS/nix?ref=v1.2.3&rev=5233fd2ba76a3accb5aaa999c00509a11fd0793c",
@@ -9,6 +9,8 @@
 	"os"
 	"os/exec"
 	"runtime"
+
+	"go.jetify.com/devbox/internal/httpclient"
 )
 
 // Installer downloads and installs Nix.
@@ -49,7 +51,7 @@ func (i *Installer) Download(ctx context.Context) error {
 		return fmt.Errorf("create request: %v", err)
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := httpclient.Client().Do(req)
 	if err != nil {
 		return fmt.Errorf("do request: %v", err)
 	}
@@ -108,6 +110,26 @@ func (i *Installer) Run(ctx context.Context) error {
 	return nil
 }
 
+// uninstallerPath is where the installer keeps a copy of itself that
+// uninstalls Nix.
+const uninstallerPath = "/nix/nix-installer"
+
+// Uninstall uninstalls a Nix installation that the installer installed,
+// including the Nix store.
+func Uninstall(ctx context.Context) error {
+	if _, err := os.Stat(uninstallerPath); err != nil {
+		return fmt.Errorf("find nix uninstaller: %v", err)
+	}
+	cmd := exec.CommandContext(ctx, uninstallerPath, "uninstall", "--no-confirm")
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("run uninstaller: %v", err)
+	}
+	return nil
+}
+
 func writeTempFile(r io.Reader) (path string, err error) {
 	tempFile, err := os.CreateTemp("", "devbox-nix-installer-")
 	if err != nil {
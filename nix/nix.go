@@ -58,6 +58,10 @@ type Nix struct {
 	// ExtraArgs are command line arguments to pass to every Nix command.
 	ExtraArgs Args
 
+	// Timeout is the maximum time that each Nix command may run before
+	// it's interrupted. Zero means no limit.
+	Timeout time.Duration
+
 	info     Info
 	infoErr  error
 	infoOnce sync.Once
@@ -180,7 +184,7 @@ func (n *Nix) Info() (Info, error) {
 // The semantic component is sourced from <https://semver.org/#is-there-a-suggested-regular-expression-regex-to-check-a-semver-string>.
 // It's been modified to tolerate Nix prerelease versions, which don't have a
 // hyphen before the prerelease component and contain underscores.
-var versionRegexp = regexp.MustCompile(`^(.+) \(.+\) ((?P<major>0|[1-9]\d*)\.(?P<minor>0|[1-9]\d*)\.(?P<patch>0|[1-9]\d*)(?:(?:-|pre)(?P<prerelease>(?:0|[1-9]\d*|\d*[_a-zA-Z-][_0-9a-zA-Z-]*)(?:\.(?:0|[1-9]\d*|\d*[_a-zA-Z-][_0-9a-zA-Z-]*))*))?(?:\+(?P<buildmetadata>[0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?)$`)
+var versionRegexp = regexp.MustCompile(`^(.+) \((.+)\) ((?P<major>0|[1-9]\d*)\.(?P<minor>0|[1-9]\d*)\.(?P<patch>0|[1-9]\d*)(?:(?:-|pre)(?P<prerelease>(?:0|[1-9]\d*|\d*[_a-zA-Z-][_0-9a-zA-Z-]*)(?:\.(?:0|[1-9]\d*|\d*[_a-zA-Z-][_0-9a-zA-Z-]*))*))?(?:\+(?P<buildmetadata>[0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?)$`)
 
 // preReleaseRegexp matches Nix prerelease version strings, which are not valid
 // semvers.
@@ -192,9 +196,18 @@ type Info struct {
 	// also be a fork like "lix".
 	Name string
 
-	// Version is the semantic Nix version string.
+	// Version is the semantic Nix version string. Determinate Nix reports
+	// the version of the upstream Nix it's based on.
 	Version string
 
+	// Flavor is the Nix implementation, such as upstream Nix or Lix.
+	Flavor Flavor
+
+	// FlavorVersion is the version of the Nix distribution when it differs
+	// from Version, such as the Determinate Nix version. It's empty
+	// otherwise.
+	FlavorVersion string
+
 	// System is the Nix system tuple. It follows the pattern <arch>-<os>
 	// and does not use the same values as GOOS or GOARCH. Note that the Nix
 	// system is configurable and may not represent the actual operating
@@ -227,6 +240,35 @@ type Info struct {
 	DataDir string
 }
 
+// Flavor identifies a Nix implementation.
+type Flavor string
+
+const (
+	// FlavorNix is upstream Nix from NixOS/nix.
+	FlavorNix Flavor = "nix"
+
+	// FlavorLix is Lix, a fork of Nix 2.18 with its own version numbers
+	// starting at 2.90.
+	FlavorLix Flavor = "lix"
+
+	// FlavorDeterminate is Determinate Nix, a distribution of upstream Nix
+	// with flakes enabled by default.
+	FlavorDeterminate Flavor = "determinate"
+)
+
+// parseFlavor parses the implementation in the parentheses of nix --version,
+// such as "Nix", "Lix, like Nix" or "Determinate Nix 3.6.2".
+func parseFlavor(s string) (flavor Flavor, version string) {
+	switch {
+	case strings.HasPrefix(s, "Lix"):
+		return FlavorLix, ""
+	case strings.HasPrefix(s, "Determinate Nix"):
+		return FlavorDeterminate, strings.TrimSpace(strings.TrimPrefix(s, "Determinate Nix"))
+	default:
+		return FlavorNix, ""
+	}
+}
+
 func parseInfo(data []byte) (Info, error) {
 	// Example nix --version --debug output from Nix versions 2.12 to 2.21.
 	// Version 2.12 omits the data directory, but they're otherwise
@@ -251,11 +293,12 @@ func parseInfo(data []byte) (Info, error) {
 
 	lines := strings.Split(string(data), "\n")
 	matches := versionRegexp.FindStringSubmatch(lines[0])
-	if len(matches) < 3 {
+	if len(matches) < 4 {
 		return info, redact.Errorf("parse nix version: %s", redact.Safe(lines[0]))
 	}
 	info.Name = matches[1]
-	info.Version = matches[2]
+	info.Version = matches[3]
+	info.Flavor, info.FlavorVersion = parseFlavor(matches[2])
 	for _, line := range lines {
 		name, value, found := strings.Cut(line, ": ")
 		if !found {
@@ -104,14 +104,17 @@ func TestParseLixVersionInfo(t *testing.T) {
 
 func TestParseVersionInfoShort(t *testing.T) {
 	cases := []struct {
-		in      string
-		name    string
-		version string
+		in            string
+		name          string
+		version       string
+		flavor        Flavor
+		flavorVersion string
 	}{
-		{"nix (Nix) 2.21.2", "nix", "2.21.2"},
-		{"nix (Nix) 2.23.0pre20240526_7de033d6", "nix", "2.23.0pre20240526_7de033d6"},
-		{"command (Nix) name (Nix) 2.21.2", "command (Nix) name", "2.21.2"},
-		{"nix (Lix, like Nix) 2.90.0-beta.1", "nix", "2.90.0-beta.1"},
+		{"nix (Nix) 2.21.2", "nix", "2.21.2", FlavorNix, ""},
+		{"nix (Nix) 2.23.0pre20240526_7de033d6", "nix", "2.23.0pre20240526_7de033d6", FlavorNix, ""},
+		{"command (Nix) name (Nix) 2.21.2", "command (Nix) name", "2.21.2", FlavorNix, ""},
+		{"nix (Lix, like Nix) 2.90.0-beta.1", "nix", "2.90.0-beta.1", FlavorLix, ""},
+		{"nix (Determinate Nix 3.6.2) 2.29.0", "nix", "2.29.0", FlavorDeterminate, "3.6.2"},
 	}
 
 	for _, tt := range cases {
@@ -126,6 +129,12 @@ func TestParseVersionInfoShort(t *testing.T) {
 			if got.Version != tt.version {
 				t.Errorf("got Version = %q, want %q", got.Version, tt.version)
 			}
+			if got.Flavor != tt.flavor {
+				t.Errorf("got Flavor = %q, want %q", got.Flavor, tt.flavor)
+			}
+			if got.FlavorVersion != tt.flavorVersion {
+				t.Errorf("got FlavorVersion = %q, want %q", got.FlavorVersion, tt.flavorVersion)
+			}
 		})
 	}
 }
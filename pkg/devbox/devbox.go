@@ -0,0 +1,190 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+// Package devbox is the Go API of Devbox. It lets Go programs, such as IDE
+// backends and platform CLIs, open Devbox projects, resolve their packages,
+// compute their environments, run their scripts and control their services,
+// instead of running the devbox CLI and parsing its output.
+//
+// The package follows semantic versioning with the devbox module: the
+// functions, types and fields that it exports are only removed or changed
+// incompatibly in a new major version. New fields can be added to its
+// structs, so construct them with field names.
+//
+// Like the devbox CLI, the API only runs a project's init hooks, scripts and
+// services if the user trusts the project. Run `devbox trust` in the project,
+// or set DEVBOX_NO_TRUST_PROMPT=1 to run them anyway, such as in CI.
+package devbox
+
+import (
+	"context"
+	"io"
+	"maps"
+	"slices"
+
+	"go.jetify.com/devbox/internal/devbox"
+	"go.jetify.com/devbox/internal/devbox/devopt"
+	"go.jetify.com/devbox/internal/envir"
+)
+
+// Options configure how a project is opened. The zero value opens the
+// project's default environment and discards Devbox's messages.
+type Options struct {
+	// Environment is the environment of devbox.json's env_from secrets,
+	// such as "dev" or "prod". It defaults to "dev".
+	Environment string
+
+	// Env are variables that override the project's environment.
+	Env map[string]string
+
+	// ProcessComposeFile is the process-compose file of the project's
+	// services, if it isn't process-compose.yaml in the project
+	// directory.
+	ProcessComposeFile string
+
+	// Stderr receives Devbox's progress messages and warnings.
+	Stderr io.Writer
+}
+
+// Project is a Devbox project: a devbox.json and the directory it's in.
+type Project struct {
+	box *devbox.Devbox
+}
+
+// Open opens the project whose devbox.json is in dir, or in the closest
+// parent directory of dir that has one.
+func Open(dir string, opts Options) (*Project, error) {
+	if opts.Stderr == nil {
+		opts.Stderr = io.Discard
+	}
+	box, err := devbox.Open(&devopt.Opts{
+		Dir:                      dir,
+		Env:                      opts.Env,
+		Environment:              opts.Environment,
+		CustomProcessComposeFile: opts.ProcessComposeFile,
+		Stderr:                   opts.Stderr,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Project{box: box}, nil
+}
+
+// Dir returns the project's directory.
+func (p *Project) Dir() string {
+	return p.box.ProjectDir()
+}
+
+// Package is a package of the project, resolved to a version.
+type Package struct {
+	// Name is the package as devbox.json or a plugin lists it, such as
+	// "go@1.22" or "github:NixOS/nixpkgs#hello".
+	Name string
+
+	// Version is the version that the package is locked to in
+	// devbox.lock. It's empty for packages that don't have versions, such
+	// as flakes.
+	Version string
+
+	// Resolved is the flake installable that the package resolves to.
+	Resolved string
+}
+
+// Resolve resolves the project's packages, including the ones that plugins
+// add, to the versions that devbox.lock locks them to. Packages that aren't
+// locked yet are resolved with the Devbox search index and saved to
+// devbox.lock, but nothing is installed.
+func (p *Project) Resolve(ctx context.Context) ([]Package, error) {
+	lockfile := p.box.Lockfile()
+	packages := []Package{}
+	for _, pkg := range p.box.AllPackages() {
+		locked, err := lockfile.Resolve(pkg.LockfileKey())
+		if err != nil {
+			return nil, err
+		}
+		packages = append(packages, Package{
+			Name:     pkg.Raw,
+			Version:  locked.Version,
+			Resolved: locked.Resolved,
+		})
+	}
+	if err := lockfile.Save(); err != nil {
+		return nil, err
+	}
+	return packages, nil
+}
+
+// Install installs the project's packages that aren't installed yet.
+func (p *Project) Install(ctx context.Context) error {
+	return p.box.Install(ctx)
+}
+
+// ComputeEnv installs the project's packages if they aren't installed yet and
+// returns the variables of its environment, like devbox shellenv does. It
+// doesn't run the project's init hooks.
+func (p *Project) ComputeEnv(ctx context.Context) (map[string]string, error) {
+	pairs, err := p.box.EnvVars(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return envir.PairsToMap(pairs), nil
+}
+
+// Scripts returns the names of the project's scripts, including the ones
+// that plugins add, sorted.
+func (p *Project) Scripts() []string {
+	return slices.Sorted(maps.Keys(p.box.Config().Scripts()))
+}
+
+// Run runs a script of the project, or a command if the project doesn't have
+// a script named name, with args in the project's environment, after its
+// init hooks. The script uses the stdin, stdout and stderr of the current
+// process.
+func (p *Project) Run(ctx context.Context, name string, args ...string) error {
+	return p.box.RunScript(ctx, devopt.EnvOptions{}, name, args)
+}
+
+// Service is a service of the project.
+type Service struct {
+	Name string
+
+	// Status is the process-compose status of the service, such as
+	// "Running" or "Completed". It's empty if the project's services
+	// aren't running.
+	Status   string
+	Health   string
+	Restarts int
+}
+
+// Services returns the services of the project and the plugins it includes,
+// sorted by name, with their state if the project's services are running.
+func (p *Project) Services(ctx context.Context) ([]Service, error) {
+	states, _, err := p.box.ServiceStates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	services := make([]Service, len(states))
+	for i, state := range states {
+		services[i] = Service{
+			Name:     state.Name,
+			Status:   state.Status,
+			Health:   state.Health,
+			Restarts: state.Restarts,
+		}
+	}
+	return services, nil
+}
+
+// StartServices starts the named services of the project. Like `devbox
+// services start`, if process-compose isn't running for the project, it
+// starts it in the background with the named services, or with all of them if
+// names is empty.
+func (p *Project) StartServices(ctx context.Context, names ...string) error {
+	return p.box.StartServices(ctx, false /*runInCurrentShell*/, names...)
+}
+
+// StopServices stops the named services of the project, or all of them and
+// process-compose if names is empty.
+func (p *Project) StopServices(ctx context.Context, names ...string) error {
+	return p.box.StopServices(ctx, false /*runInCurrentShell*/, false /*allProjects*/, names...)
+}
@@ -0,0 +1,44 @@
+// Copyright 2024 Jetify Inc. and contributors. All rights reserved.
+// Use of this source code is governed by the license in the LICENSE file.
+
+package devbox
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"go.jetify.com/devbox/internal/envir"
+)
+
+func TestOpen(t *testing.T) {
+	t.Setenv(envir.XDGConfigHome, t.TempDir())
+
+	dir := t.TempDir()
+	config := `{"packages": [], "shell": {"scripts": {"test": "go test ./...", "build": "go build"}}}`
+	if err := os.WriteFile(filepath.Join(dir, "devbox.json"), []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	processCompose := "processes:\n  web:\n    command: ./serve.sh\n"
+	if err := os.WriteFile(filepath.Join(dir, "process-compose.yaml"), []byte(processCompose), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	project, err := Open(dir, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := project.Scripts(); !slices.Equal(got, []string{"build", "test"}) {
+		t.Errorf("got Scripts() = %v, want [build test]", got)
+	}
+	services, err := project.Services(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Service{{Name: "web"}}
+	if !slices.Equal(services, want) {
+		t.Errorf("got Services() = %v, want %v", services, want)
+	}
+}
@@ -57,25 +57,37 @@ func TestInitHookPathsAreQuoted(t *testing.T) {
 }
 
 // initHookLines normalizes the init_hook field, which may be either a single
-// string or an array of strings, into a slice of strings.
+// string or an array of strings and hook objects, into a slice of strings.
 func initHookLines(t *testing.T, raw json.RawMessage) []string {
 	t.Helper()
 	if len(raw) == 0 {
 		return nil
 	}
 
-	var list []string
-	if err := json.Unmarshal(raw, &list); err == nil {
-		return list
-	}
-
 	var single string
 	if err := json.Unmarshal(raw, &single); err == nil {
 		return []string{single}
 	}
 
-	t.Fatalf("init_hook is neither a string nor an array of strings: %s", raw)
-	return nil
+	var items []json.RawMessage
+	if err := json.Unmarshal(raw, &items); err != nil {
+		t.Fatalf("init_hook is neither a string nor an array: %s", raw)
+	}
+	var lines []string
+	for _, item := range items {
+		if err := json.Unmarshal(item, &single); err == nil {
+			lines = append(lines, single)
+			continue
+		}
+		var hook struct {
+			Run json.RawMessage `json:"run"`
+		}
+		if err := json.Unmarshal(item, &hook); err != nil {
+			t.Fatalf("init_hook item is neither a string nor an object: %s", item)
+		}
+		lines = append(lines, initHookLines(t, hook.Run)...)
+	}
+	return lines
 }
 
 // templateIndices returns the starting index of every "{{" template opener in
@@ -18,6 +18,9 @@ func setupTestEnv(env *testscript.Env) error {
 	setupPATH(env)
 	setupHome(env)
 	setupCacheHome(env)
+	// Tests run the code of the projects that they create without
+	// trusting them, like CI does.
+	env.Setenv(envir.DevboxNoTrustPrompt, "1")
 	propagateEnvVars(env,
 		debug.DevboxDebug, // to enable extra logging
 		"SSL_CERT_FILE",   // so HTTPS works with Nix-installed certs